@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"kasir/models"
+	"net/http"
+	"strings"
+)
+
+// Akses API ditentukan oleh permission role (lihat models.AllPermissions).
+// User tanpa permission warehouse.all hanya melihat data gudangnya sendiri.
+
+// forbid mengirim respon 403 dengan alasan yang jelas
+func forbid(w http.ResponseWriter, reason string) {
+	http.Error(w, "Forbidden: "+reason, http.StatusForbidden)
+}
+
+// methodPermissions memetakan method HTTP ke permission yang dibutuhkan.
+// Cukup salah satu permission dalam daftar. Method tanpa entri cukup login.
+type methodPermissions map[string][]string
+
+// requirePermissions membatasi endpoint sesuai permission per method
+func requirePermissions(perms methodPermissions, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		required, ok := perms[r.Method]
+		if !ok || len(required) == 0 {
+			next(w, r)
+			return
+		}
+
+		user := userFromContext(r)
+		for _, perm := range required {
+			if user.Can(perm) {
+				next(w, r)
+				return
+			}
+		}
+		forbid(w, fmt.Sprintf("membutuhkan permission %s", strings.Join(required, " atau ")))
+	}
+}
+
+// allMethods membuat methodPermissions yang sama untuk semua method umum
+func allMethods(perm string) methodPermissions {
+	return methodPermissions{
+		http.MethodGet:    {perm},
+		http.MethodPost:   {perm},
+		http.MethodPut:    {perm},
+		http.MethodDelete: {perm},
+	}
+}
+
+// warehouseScope mengembalikan gudang yang boleh diakses user (nil = semua gudang)
+func warehouseScope(user *models.User) *int {
+	if user == nil || user.HasAllWarehouses() {
+		return nil
+	}
+	if user.WarehouseID == nil {
+		// User tanpa gudang tidak boleh melihat data gudang mana pun
+		none := 0
+		return &none
+	}
+	return user.WarehouseID
+}
+
+// checkWarehouseAccess memastikan user boleh mengakses data milik gudang tertentu
+func checkWarehouseAccess(user *models.User, warehouseID int) error {
+	scope := warehouseScope(user)
+	if scope != nil && *scope != warehouseID {
+		return fmt.Errorf("anda tidak memiliki akses ke gudang %d", warehouseID)
+	}
+	return nil
+}
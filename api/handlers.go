@@ -1,16 +1,26 @@
 package api
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"kasir/models"
+	"math"
+	"net"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// authMiddleware memverifikasi Basic Auth
+type contextKey string
+
+const userContextKey contextKey = "user"
+const sessionContextKey contextKey = "session"
+
+// authMiddleware memverifikasi Bearer access token dan menaruh user di context
 func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -20,38 +30,36 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Basic" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		payload, _ := base64.StdEncoding.DecodeString(parts[1])
-		pair := strings.SplitN(string(payload), ":", 2)
-		if len(pair) != 2 {
+		if len(parts) != 2 || parts[0] != "Bearer" {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		_, err := models.Authenticate(pair[0], pair[1])
+		claims, err := parseAccessToken(parts[1])
 		if err != nil {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		next(w, r)
+		ctx := context.WithValue(r.Context(), userContextKey, claims.user())
+		ctx = context.WithValue(ctx, sessionContextKey, claims.SessionID)
+		next(w, r.WithContext(ctx))
 	}
 }
 
-// Helper to get user from request (re-checking basic auth)
-func getUserFromRequest(r *http.Request) (*models.User, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return nil, http.ErrNoCookie
+// userFromContext mengambil user yang sudah diverifikasi oleh authMiddleware
+func userFromContext(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	return user
+}
+
+// clientIP mengambil alamat IP client dari koneksi (tanpa port)
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	parts := strings.SplitN(authHeader, " ", 2)
-	payload, _ := base64.StdEncoding.DecodeString(parts[1])
-	pair := strings.SplitN(string(payload), ":", 2)
-	return models.Authenticate(pair[0], pair[1])
+	return host
 }
 
 func handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -70,21 +78,122 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := models.Authenticate(creds.Username, creds.Password)
+	user, err := models.Authenticate(creds.Username, creds.Password, clientIP(r))
 	if err != nil {
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			http.Error(w, "Too many login attempts: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
+	accessToken, refreshToken, err := issueTokens(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Login successful",
+		"user":          user.Username,
+		"role":          user.Role,
+		"token_type":    "Bearer",
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
+	})
+}
+
+func handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, user, err := refreshTokens(req.RefreshToken)
+	if err != nil {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login successful",
-		"user":    user.Username,
-		"role":    user.Role,
+		"user":          user.Username,
+		"role":          user.Role,
+		"token_type":    "Bearer",
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(accessTokenTTL.Seconds()),
 	})
 }
 
+func handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID, _ := r.Context().Value(sessionContextKey).(string)
+	if err := revokeSession(sessionID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logout successful"})
+}
+
+// productErrorStatus memilih status HTTP untuk error simpan produk
+func productErrorStatus(err error) int {
+	if errors.Is(err, models.ErrDuplicateCode) || errors.Is(err, models.ErrDuplicateVariant) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, models.ErrInvalidProductRef) || errors.Is(err, models.ErrHasVariants) || errors.Is(err, models.ErrNotInWarehouse) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// queryID membaca parameter query berupa ID positif (nil jika kosong/tidak valid)
+func queryID(r *http.Request, name string) *int {
+	if id, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil && id > 0 {
+		return &id
+	}
+	return nil
+}
+
+// optionalRef mengubah ID referensi dari request: nil = tidak diubah, 0 = dikosongkan
+func optionalRef(current *int, requested *int) *int {
+	if requested == nil {
+		return current
+	}
+	if *requested == 0 {
+		return nil
+	}
+	return requested
+}
+
+// productQuantity adalah jumlah satu produk di item request (retur, hitung opname, transfer)
+type productQuantity struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
 func handleProducts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
 
 	switch r.Method {
 	case http.MethodGet:
@@ -106,7 +215,18 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		products, total, err := models.GetProducts(page, limit, search, nil)
+		// User biasa selalu dibatasi ke gudangnya, admin boleh memfilter via ?warehouse_id=
+		warehouseID := warehouseScope(user)
+		if warehouseID == nil {
+			warehouseID = queryID(r, "warehouse_id")
+		}
+
+		products, total, err := models.GetProducts(page, limit, models.ProductFilter{
+			Search:      search,
+			WarehouseID: warehouseID,
+			CategoryID:  queryID(r, "category_id"), // termasuk subkategori
+			BrandID:     queryID(r, "brand_id"),
+		})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -127,18 +247,44 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		var req struct {
 			Name          string  `json:"name"`
+			SKU           string  `json:"sku"`
+			Barcode       string  `json:"barcode"`
 			PurchasePrice float64 `json:"purchase_price"`
 			SellingPrice  float64 `json:"selling_price"`
 			Stock         int     `json:"stock"`
 			WarehouseID   int     `json:"warehouse_id"`
+			CategoryID    *int    `json:"category_id"`
+			BrandID       *int    `json:"brand_id"`
+			ParentID      *int    `json:"parent_id"` // diisi = buat varian dari produk induk ini
+			Variant       string  `json:"variant"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
-		p, err := models.CreateProduct(req.Name, req.PurchasePrice, req.SellingPrice, req.Stock, req.WarehouseID)
+		// warehouse_id kosong = produk hanya masuk katalog master (untuk varian: gudang induknya)
+		if scope := warehouseScope(user); scope != nil && req.WarehouseID == 0 {
+			req.WarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.WarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		p, err := models.CreateProduct(user, models.Product{
+			Name:          req.Name,
+			SKU:           req.SKU,
+			Barcode:       req.Barcode,
+			PurchasePrice: req.PurchasePrice,
+			SellingPrice:  req.SellingPrice,
+			Stock:         req.Stock,
+			WarehouseID:   req.WarehouseID,
+			CategoryID:    optionalRef(nil, req.CategoryID),
+			BrandID:       optionalRef(nil, req.BrandID),
+			ParentID:      req.ParentID,
+			Variant:       req.Variant,
+		})
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), productErrorStatus(err))
 			return
 		}
 		json.NewEncoder(w).Encode(p)
@@ -147,17 +293,73 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			ID            int     `json:"id"`
 			Name          string  `json:"name"`
+			SKU           *string `json:"sku"`         // nil = tidak diubah
+			Barcode       *string `json:"barcode"`     // nil = tidak diubah
+			CategoryID    *int    `json:"category_id"` // nil = tidak diubah, 0 = dikosongkan
+			BrandID       *int    `json:"brand_id"`    // nil = tidak diubah, 0 = dikosongkan
+			Variant       *string `json:"variant"`     // nil = tidak diubah (hanya untuk varian)
 			PurchasePrice float64 `json:"purchase_price"`
 			SellingPrice  float64 `json:"selling_price"`
-			Stock         int     `json:"stock"`
+			Stock         *int    `json:"stock"`        // nil = tidak diubah
+			WarehouseID   int     `json:"warehouse_id"` // gudang stok yang diubah (default gudang user)
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
-		err := models.UpdateProduct(req.ID, req.Name, req.PurchasePrice, req.SellingPrice, req.Stock)
+
+		product, err := models.GetProductByID(req.ID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		// User gudang hanya boleh mengubah produk yang ada di gudangnya
+		var stocked *models.Product
+		if warehouseScope(user) != nil || (req.Stock != nil && user.Can(models.PermStockAdjust)) {
+			var status int
+			if stocked, status, err = productInRequestWarehouse(user, req.ID, req.WarehouseID); err != nil {
+				writeError(w, status, err)
+				return
+			}
+		}
+
+		// Data master berlaku di semua gudang, jadi hanya boleh diubah user dengan permission kelola
+		// produk dan akses semua gudang. User lain hanya mengubah stok; data master yang dikirim
+		// harus sama dengan yang tersimpan.
+		var master *models.Product
+		if user.Can(models.PermProductManage) {
+			updated := *product
+			updated.Name, updated.PurchasePrice, updated.SellingPrice = req.Name, req.PurchasePrice, req.SellingPrice
+			if req.SKU != nil {
+				updated.SKU = *req.SKU
+			}
+			if req.Barcode != nil {
+				updated.Barcode = *req.Barcode
+			}
+			updated.CategoryID = optionalRef(product.CategoryID, req.CategoryID)
+			updated.BrandID = optionalRef(product.BrandID, req.BrandID)
+			if req.Variant != nil {
+				updated.Variant = *req.Variant
+			}
+			if user.HasAllWarehouses() {
+				master = &updated
+			} else if !reflect.DeepEqual(updated, *product) {
+				forbid(w, "data master produk hanya bisa diubah user dengan akses semua gudang")
+				return
+			}
+		}
+		var stock *models.ProductStock
+		if req.Stock != nil && user.Can(models.PermStockAdjust) {
+			st, err := currentStock(req.ID, stocked.WarehouseID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			st.Stock = *req.Stock
+			stock = &st
+		}
+		if err := models.UpdateProductWithStock(user, master, stock); err != nil {
+			http.Error(w, err.Error(), productErrorStatus(err))
 			return
 		}
 		json.NewEncoder(w).Encode(map[string]string{"message": "Product updated"})
@@ -165,15 +367,35 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		// Delete using JSON body to be consistent with other endpoints
 		var req struct {
-			ID int `json:"id"`
+			ID          int `json:"id"`
+			WarehouseID int `json:"warehouse_id"` // diisi = hanya keluarkan dari gudang ini
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
 
-		err := models.DeleteProduct(req.ID)
-		if err != nil {
+		if _, err := models.GetProductByID(req.ID); err != nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+
+		// User gudang hanya mengeluarkan produk dari gudangnya; katalog master tetap ada
+		if warehouseScope(user) != nil || req.WarehouseID != 0 {
+			stocked, status, err := productInRequestWarehouse(user, req.ID, req.WarehouseID)
+			if err != nil {
+				writeError(w, status, err)
+				return
+			}
+			if err := models.RemoveProductFromWarehouse(user, req.ID, stocked.WarehouseID); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"message": "Product removed from warehouse"})
+			return
+		}
+
+		if err := models.DeleteProduct(user, req.ID); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -184,8 +406,180 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// productInRequestWarehouse mengambil produk di gudang yang dimaksud request: gudang user jika
+// dibatasi, warehouse_id dari request, atau satu-satunya gudang tempat produk tersedia.
+// Jika gagal, status berisi kode HTTP yang sesuai.
+func productInRequestWarehouse(user *models.User, productID, requested int) (*models.Product, int, error) {
+	if requested != 0 {
+		if err := checkWarehouseAccess(user, requested); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	}
+	warehouseID := requested
+	scope := warehouseScope(user)
+	if scope != nil {
+		warehouseID = *scope
+	}
+
+	products, err := models.GetProductWarehouses(productID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.New("Product not found")
+	}
+	if warehouseID == 0 {
+		if len(products) == 1 {
+			return &products[0], 0, nil
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("product %d is stocked in %d warehouses, specify warehouse_id", productID, len(products))
+	}
+	for i := range products {
+		if products[i].WarehouseID == warehouseID {
+			return &products[i], 0, nil
+		}
+	}
+	if scope != nil {
+		return nil, http.StatusForbidden, fmt.Errorf("anda tidak memiliki akses ke produk %d", productID)
+	}
+	return nil, http.StatusBadRequest, fmt.Errorf("product %d is not stocked in warehouse %d", productID, warehouseID)
+}
+
+// writeError menulis error dengan status HTTP; 403 memakai format forbid
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status == http.StatusForbidden {
+		forbid(w, err.Error())
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// currentStock mengambil stok dan harga gudang produk saat ini (harga nil jika belum ada di gudang)
+func currentStock(productID, warehouseID int) (models.ProductStock, error) {
+	stocks, err := models.GetProductStocks(productID)
+	if err != nil {
+		return models.ProductStock{}, err
+	}
+	for _, st := range stocks {
+		if st.WarehouseID == warehouseID {
+			return st, nil
+		}
+	}
+	return models.ProductStock{ProductID: productID, WarehouseID: warehouseID}, nil
+}
+
+// handleProductStock menampilkan dan mengatur stok serta harga satu produk per gudang
+func handleProductStock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		id := queryID(r, "product_id")
+		if id == nil {
+			http.Error(w, "product_id is required", http.StatusBadRequest)
+			return
+		}
+		product, err := models.GetProductByID(*id)
+		if err != nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		warehouses, err := models.GetProductWarehouses(*id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// User gudang hanya melihat stok gudangnya sendiri
+		if scope := warehouseScope(user); scope != nil {
+			var own []models.Product
+			for _, p := range warehouses {
+				if p.WarehouseID == *scope {
+					own = append(own, p)
+				}
+			}
+			warehouses = own
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"product":    product,
+			"warehouses": warehouses,
+		})
+
+	case http.MethodPut:
+		var req struct {
+			ProductID     int      `json:"product_id"`
+			WarehouseID   int      `json:"warehouse_id"`
+			Stock         *int     `json:"stock"`          // nil = tidak diubah
+			PurchasePrice *float64 `json:"purchase_price"` // harga khusus gudang, nil = tidak diubah
+			SellingPrice  *float64 `json:"selling_price"`
+			ResetPrices   bool     `json:"reset_prices"`  // true = kembali ke harga master
+			ReorderPoint  *int     `json:"reorder_point"` // stok minimum, nil = tidak diubah
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if scope := warehouseScope(user); scope != nil && req.WarehouseID == 0 {
+			req.WarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.WarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		if req.ReorderPoint != nil && *req.ReorderPoint < 0 {
+			http.Error(w, "reorder_point must not be negative", http.StatusBadRequest)
+			return
+		}
+		if (req.Stock != nil || req.ReorderPoint != nil) && !user.Can(models.PermStockAdjust) {
+			forbid(w, "membutuhkan permission "+models.PermStockAdjust)
+			return
+		}
+		if (req.PurchasePrice != nil || req.SellingPrice != nil || req.ResetPrices) && !user.Can(models.PermProductManage) {
+			forbid(w, "membutuhkan permission "+models.PermProductManage)
+			return
+		}
+
+		st, err := currentStock(req.ProductID, req.WarehouseID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if req.Stock != nil {
+			st.Stock = *req.Stock
+		}
+		if req.ResetPrices {
+			st.PurchasePrice, st.SellingPrice = nil, nil
+		}
+		if req.PurchasePrice != nil {
+			st.PurchasePrice = req.PurchasePrice
+		}
+		if req.SellingPrice != nil {
+			st.SellingPrice = req.SellingPrice
+		}
+		if req.Stock != nil || req.PurchasePrice != nil || req.SellingPrice != nil || req.ResetPrices {
+			if err := models.SetProductStock(user, st); err != nil {
+				http.Error(w, err.Error(), productErrorStatus(err))
+				return
+			}
+		}
+		if req.ReorderPoint != nil {
+			if err := models.SetReorderPoint(user, req.ProductID, req.WarehouseID, *req.ReorderPoint); err != nil {
+				http.Error(w, err.Error(), productErrorStatus(err))
+				return
+			}
+		}
+		product, err := models.GetProductInWarehouse(req.ProductID, req.WarehouseID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(product)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func handleUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
 	switch r.Method {
 	case http.MethodGet:
 		users, err := models.GetAllUsers()
@@ -205,7 +599,19 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
-		u, err := models.Register(req.Username, req.Password, req.Role, req.WarehouseID)
+		if _, err := models.GetRoleByName(req.Role); err != nil {
+			http.Error(w, "Unknown role: "+req.Role, http.StatusBadRequest)
+			return
+		}
+		u, err := models.Register(user, req.Username, req.Password, req.Role, req.WarehouseID)
+		if errors.Is(err, models.ErrRoleNotAllowed) {
+			forbid(w, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrWarehouseRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -219,7 +625,19 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Bad request", http.StatusBadRequest)
 			return
 		}
-		err := models.DeleteUser(req.ID)
+		if req.ID == user.ID {
+			forbid(w, "tidak bisa menghapus akun sendiri")
+			return
+		}
+		err := models.DeleteUser(user, req.ID)
+		if errors.Is(err, models.ErrRoleNotAllowed) {
+			forbid(w, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrLastAdmin) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -232,6 +650,7 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 
 func handleWarehouses(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
 	switch r.Method {
 	case http.MethodGet:
 		data, err := models.GetAllWarehouses()
@@ -249,7 +668,7 @@ func handleWarehouses(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		wObj, err := models.CreateWarehouse(req.Name, req.Address)
+		wObj, err := models.CreateWarehouse(user, req.Name, req.Address)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -263,7 +682,7 @@ func handleWarehouses(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		err := models.DeleteWarehouse(req.ID)
+		err := models.DeleteWarehouse(user, req.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -279,7 +698,7 @@ func handleWarehouses(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		err := models.UpdateWarehouse(req.ID, req.Name, req.Address)
+		err := models.UpdateWarehouse(user, req.ID, req.Name, req.Address)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -290,95 +709,392 @@ func handleWarehouses(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func handleReports(w http.ResponseWriter, r *http.Request) {
-	user, err := getUserFromRequest(r)
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	dateStr := r.URL.Query().Get("date")
-	if dateStr == "" {
-		dateStr = time.Now().Format("02-01-2006")
-	}
-	date, err := time.Parse("02-01-2006", dateStr)
-	if err != nil {
-		http.Error(w, "Invalid date format DD-MM-YYYY", http.StatusBadRequest)
-		return
-	}
-
-	transactions, err := models.GetTransactionsByDate(user, date)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	total, profit, count, _ := models.GetDailyTotal(user, date)
-
-	resp := map[string]interface{}{
-		"date": dateStr,
-		"summary": map[string]interface{}{
-			"total_sales":       total,
-			"total_profit":      profit,
-			"transaction_count": count,
-		},
-		"transactions": transactions,
+// catalogErrorStatus memilih status HTTP untuk error simpan kategori/merek
+func catalogErrorStatus(err error) int {
+	if errors.Is(err, models.ErrDuplicateCategory) || errors.Is(err, models.ErrDuplicateBrand) {
+		return http.StatusConflict
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	return http.StatusInternalServerError
 }
 
-func handleTransactions(w http.ResponseWriter, r *http.Request) {
-	user, err := getUserFromRequest(r)
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	if r.Method == http.MethodGet {
-		// List transactions
-		transactions, err := models.GetTransactionsByDate(user, time.Now())
-		// Note: Real API would accept date param, defaulting to today for simplicity
+func handleCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+	switch r.Method {
+	case http.MethodGet:
+		data, err := models.GetAllCategories()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(transactions)
-		return
-	}
-
-	if r.Method == http.MethodPost {
-		// Create transaction
+		json.NewEncoder(w).Encode(data)
+	case http.MethodPost:
 		var req struct {
-			Items []struct {
-				ProductID int `json:"product_id"`
-				Quantity  int `json:"quantity"`
-			} `json:"items"`
-			Payment float64 `json:"payment"`
+			Name     string `json:"name"`
+			ParentID *int   `json:"parent_id"` // nil = kategori utama
 		}
-
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
-
-		// Build items
-		var cart []models.CartItem
-		for _, itemReq := range req.Items {
-			product, err := models.GetProductByID(itemReq.ProductID)
-			if err != nil {
-				http.Error(w, "Product not found: "+string(rune(itemReq.ProductID)), http.StatusBadRequest)
-				return
-			}
-			cart = append(cart, models.CartItem{
-				Product:  product,
-				Quantity: itemReq.Quantity,
-			})
+		c, err := models.CreateCategory(user, req.Name, req.ParentID)
+		if err != nil {
+			http.Error(w, err.Error(), catalogErrorStatus(err))
+			return
+		}
+		json.NewEncoder(w).Encode(c)
+	case http.MethodPut:
+		var req struct {
+			ID       int    `json:"id"`
+			Name     string `json:"name"`
+			ParentID *int   `json:"parent_id"` // nil = jadikan kategori utama
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.UpdateCategory(user, req.ID, req.Name, req.ParentID); err != nil {
+			http.Error(w, err.Error(), catalogErrorStatus(err))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Category updated"})
+	case http.MethodDelete:
+		var req struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.DeleteCategory(user, req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Category deleted"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleBrands(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+	switch r.Method {
+	case http.MethodGet:
+		data, err := models.GetAllBrands()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(data)
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		b, err := models.CreateBrand(user, req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), catalogErrorStatus(err))
+			return
+		}
+		json.NewEncoder(w).Encode(b)
+	case http.MethodPut:
+		var req struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.UpdateBrand(user, req.ID, req.Name); err != nil {
+			http.Error(w, err.Error(), catalogErrorStatus(err))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Brand updated"})
+	case http.MethodDelete:
+		var req struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.DeleteBrand(user, req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Brand deleted"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleReports(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+
+	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("02-01-2006")
+	}
+	date, err := time.Parse("02-01-2006", dateStr)
+	if err != nil {
+		http.Error(w, "Invalid date format DD-MM-YYYY", http.StatusBadRequest)
+		return
+	}
+
+	transactions, err := models.GetTransactionsByDate(user, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := models.GetDailySummary(user, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	returns, err := models.GetReturnsByDate(user, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	voided, err := models.GetVoidedTransactionsByDate(user, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"date":         dateStr,
+		"summary":      summary,
+		"transactions": transactions,
+		"returns":      returns,
+		"voided":       voided,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleCategoryReport mengembalikan penjualan harian dan stok yang dikelompokkan per kategori
+func handleCategoryReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("02-01-2006")
+	}
+	date, err := time.Parse("02-01-2006", dateStr)
+	if err != nil {
+		http.Error(w, "Invalid date format DD-MM-YYYY", http.StatusBadRequest)
+		return
+	}
+
+	sales, err := models.GetCategorySalesByDate(user, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stock, err := models.GetCategoryStock(warehouseScope(user))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"date":  dateStr,
+		"sales": sales,
+		"stock": stock,
+	})
+}
+
+// handleProductReport mengembalikan penjualan per produk; ?rollup=true menggabungkan varian ke produk induknya
+func handleProductReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("02-01-2006")
+	}
+	date, err := time.Parse("02-01-2006", dateStr)
+	if err != nil {
+		http.Error(w, "Invalid date format DD-MM-YYYY", http.StatusBadRequest)
+		return
+	}
+	rollUp := r.URL.Query().Get("rollup") == "true"
+
+	sales, err := models.GetProductSalesByDate(user, date, rollUp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"date":   dateStr,
+		"rollup": rollUp,
+		"sales":  sales,
+	})
+}
+
+func handleStockCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	productID := queryID(r, "product_id")
+	if productID == nil {
+		http.Error(w, "product_id is required", http.StatusBadRequest)
+		return
+	}
+	warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+	if err != nil {
+		forbid(w, err.Error())
+		return
+	}
+
+	from, to, ok := queryDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	card, err := models.GetStockCard(*productID, warehouseID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if card.Movements == nil {
+		card.Movements = []models.StockMovement{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
+
+// queryDateRange membaca ?from= dan ?to= (DD-MM-YYYY), default awal bulan ini sampai hari ini.
+// Jika format salah, respon 400 sudah ditulis.
+func queryDateRange(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	q := r.URL.Query()
+	now := time.Now()
+	from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to = now
+	var err error
+	if s := q.Get("from"); s != "" {
+		if from, err = time.ParseInLocation("02-01-2006", s, time.Local); err != nil {
+			http.Error(w, "Invalid from date format DD-MM-YYYY", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	if s := q.Get("to"); s != "" {
+		if to, err = time.ParseInLocation("02-01-2006", s, time.Local); err != nil {
+			http.Error(w, "Invalid to date format DD-MM-YYYY", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	return from, to, true
+}
+
+// resolveVariant memilih varian dari produk induk (di gudang yang sama) berdasarkan ID atau nama varian.
+// Produk tanpa varian (atau varian itu sendiri) dikembalikan apa adanya.
+func resolveVariant(product *models.Product, variantID int, variant string) (*models.Product, error) {
+	variants, err := models.GetWarehouseVariants(product.ID, product.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		if variantID != 0 || variant != "" {
+			return nil, fmt.Errorf("product %d has no variants", product.ID)
+		}
+		return product, nil
+	}
+	for i, v := range variants {
+		if (variantID != 0 && v.ID == variantID) || (variantID == 0 && strings.EqualFold(v.Variant, strings.TrimSpace(variant))) {
+			return &variants[i], nil
+		}
+	}
+	if variantID == 0 && variant == "" {
+		return nil, fmt.Errorf("product %d has variants, specify variant_id or variant", product.ID)
+	}
+	return nil, fmt.Errorf("variant not found for product %d", product.ID)
+}
+
+func handleTransactions(w http.ResponseWriter, r *http.Request) {
+	user := userFromContext(r)
+
+	if r.Method == http.MethodGet {
+		// List transactions
+		transactions, err := models.GetTransactionsByDate(user, time.Now())
+		// Note: Real API would accept date param, defaulting to today for simplicity
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transactions)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		// Create transaction
+		var req struct {
+			Items []struct {
+				ProductID int    `json:"product_id"`
+				VariantID int    `json:"variant_id"` // varian dari product_id (opsional)
+				Variant   string `json:"variant"`    // atau nama variannya, contoh "L"
+				Quantity  int    `json:"quantity"`
+			} `json:"items"`
+			Payment     float64 `json:"payment"`
+			WarehouseID int     `json:"warehouse_id"` // gudang penjualan (user gudang selalu gudangnya sendiri)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		// Build items
+		var cart []models.CartItem
+		for _, itemReq := range req.Items {
+			if _, err := models.GetProductByID(itemReq.ProductID); err != nil {
+				http.Error(w, "Product not found: "+strconv.Itoa(itemReq.ProductID), http.StatusBadRequest)
+				return
+			}
+			product, status, err := productInRequestWarehouse(user, itemReq.ProductID, req.WarehouseID)
+			if err != nil {
+				writeError(w, status, err)
+				return
+			}
+			product, err = resolveVariant(product, itemReq.VariantID, itemReq.Variant)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			cart = append(cart, models.CartItem{
+				Product:  product,
+				Quantity: itemReq.Quantity,
+			})
 		}
 
 		trx, err := models.CreateTransaction(user, cart, req.Payment)
+		if errors.Is(err, models.ErrInvalidTransaction) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, "Transaction failed: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -388,4 +1104,1035 @@ func handleTransactions(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(trx)
 		return
 	}
+
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// handleTransactionVoid membatalkan transaksi yang sudah tersimpan ({"id", "reason"}). Void harus
+// disetujui supervisor: username dan password-nya dikirim di approver_username/approver_password.
+func handleTransactionVoid(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID               int    `json:"id"`
+		Reason           string `json:"reason"`
+		ApproverUsername string `json:"approver_username"`
+		ApproverPassword string `json:"approver_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if _, err := models.GetTransaction(user, req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	approver, err := models.AuthorizeVoid(req.ApproverUsername, req.ApproverPassword, clientIP(r))
+	if err != nil {
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			http.Error(w, "Too many login attempts: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		forbid(w, "approval rejected: "+err.Error())
+		return
+	}
+
+	t, err := models.VoidTransaction(user, approver, req.ID, req.Reason)
+	if err != nil {
+		http.Error(w, "Void failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleReturns menampilkan dan mencatat retur penjualan. GET ?transaction_id= mengembalikan item
+// transaksi beserta jumlah yang masih bisa diretur dan daftar returnya; tanpa transaction_id daftar
+// retur pada ?date= (DD-MM-YYYY, default hari ini). POST mencatat retur baru.
+func handleReturns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := queryID(r, "transaction_id"); id != nil {
+			items, err := models.GetReturnableItems(user, *id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			returns, err := models.GetTransactionReturns(user, *id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if returns == nil {
+				returns = []models.SalesReturn{}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"transaction_id": *id,
+				"items":          items,
+				"returns":        returns,
+			})
+			return
+		}
+
+		date := time.Now()
+		if s := r.URL.Query().Get("date"); s != "" {
+			parsed, err := time.ParseInLocation("02-01-2006", s, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid date format DD-MM-YYYY", http.StatusBadRequest)
+				return
+			}
+			date = parsed
+		}
+		returns, err := models.GetReturnsByDate(user, date)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if returns == nil {
+			returns = []models.SalesReturn{}
+		}
+		json.NewEncoder(w).Encode(returns)
+
+	case http.MethodPost:
+		var req struct {
+			TransactionID int               `json:"transaction_id"`
+			Reason        string            `json:"reason"`
+			Items         []productQuantity `json:"items"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if _, err := models.GetTransaction(user, req.TransactionID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		var items []models.ReturnItem
+		for _, item := range req.Items {
+			items = append(items, models.ReturnItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+		ret, err := models.CreateReturn(user, req.TransactionID, items, req.Reason)
+		if err != nil {
+			http.Error(w, "Return failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ret)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTransfers menampilkan, membuat, dan membatalkan transfer stok antar gudang.
+// GET ?id= mengembalikan detail transfer, tanpa id daftar transfer (?status=, ?warehouse_id=).
+func handleTransfers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := queryID(r, "id"); id != nil {
+			t, err := models.GetTransfer(user, *id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(t)
+			return
+		}
+
+		warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+		if err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		transfers, err := models.GetWarehouseTransfers(warehouseID, r.URL.Query().Get("status"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if transfers == nil {
+			transfers = []models.Transfer{}
+		}
+		json.NewEncoder(w).Encode(transfers)
+
+	case http.MethodPost:
+		var req struct {
+			FromWarehouseID int               `json:"from_warehouse_id"` // default: gudang user
+			ToWarehouseID   int               `json:"to_warehouse_id"`
+			Note            string            `json:"note"`
+			Items           []productQuantity `json:"items"`
+			Send            bool              `json:"send"` // true = langsung kirim
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if scope := warehouseScope(user); scope != nil && req.FromWarehouseID == 0 {
+			req.FromWarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.FromWarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+
+		var items []models.TransferItem
+		for _, item := range req.Items {
+			items = append(items, models.TransferItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+		t, err := models.CreateTransfer(user, req.FromWarehouseID, req.ToWarehouseID, req.Note, items)
+		if err != nil {
+			http.Error(w, "Transfer failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Send {
+			if t, err = models.SendTransfer(user, t.ID); err != nil {
+				http.Error(w, "Transfer created as draft but not sent: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+
+	case http.MethodDelete:
+		t, ok := transferForAction(w, user, queryID(r, "id"), true)
+		if !ok {
+			return
+		}
+		if err := models.CancelTransfer(user, t.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Transfer cancelled"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// transferForAction mengambil transfer yang akan dikirim/dibatalkan (source = true, oleh gudang asal)
+// atau diterima (oleh gudang tujuan). Jika gagal, respon error sudah ditulis.
+func transferForAction(w http.ResponseWriter, user *models.User, id *int, source bool) (*models.Transfer, bool) {
+	if id == nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return nil, false
+	}
+	t, err := models.GetTransfer(user, *id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	warehouseID := t.ToWarehouseID
+	if source {
+		warehouseID = t.FromWarehouseID
+	}
+	if err := checkWarehouseAccess(user, warehouseID); err != nil {
+		forbid(w, err.Error())
+		return nil, false
+	}
+	return t, true
+}
+
+// handleTransferSend mengirim draft transfer: {"id": 1}
+func handleTransferSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	t, ok := transferForAction(w, user, &req.ID, true)
+	if !ok {
+		return
+	}
+	t, err := models.SendTransfer(user, t.ID)
+	if err != nil {
+		http.Error(w, "Send failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleTransferReceive mencatat penerimaan transfer di gudang tujuan:
+// {"id": 1, "items": [{"product_id": 5, "quantity": 3}]}. Produk yang tidak disebut diterima penuh.
+func handleTransferReceive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID    int               `json:"id"`
+		Items []productQuantity `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	t, ok := transferForAction(w, user, &req.ID, false)
+	if !ok {
+		return
+	}
+
+	received := make(map[int]int, len(req.Items))
+	for _, item := range req.Items {
+		received[item.ProductID] = item.Quantity
+	}
+	t, err := models.ReceiveTransfer(user, t.ID, received)
+	if err != nil {
+		http.Error(w, "Receive failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(t)
+}
+
+// handleOpnames menampilkan, membuka, dan membatalkan stock opname.
+// GET ?id= mengembalikan detail beserta item, tanpa id daftar stock opname (?status=, ?warehouse_id=).
+func handleOpnames(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := queryID(r, "id"); id != nil {
+			o, err := models.GetOpname(user, *id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(o)
+			return
+		}
+
+		warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+		if err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		opnames, err := models.GetWarehouseOpnames(warehouseID, r.URL.Query().Get("status"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if opnames == nil {
+			opnames = []models.StockOpname{}
+		}
+		json.NewEncoder(w).Encode(opnames)
+
+	case http.MethodPost:
+		var req struct {
+			WarehouseID int    `json:"warehouse_id"` // default: gudang user
+			Note        string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if scope := warehouseScope(user); scope != nil && req.WarehouseID == 0 {
+			req.WarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.WarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+
+		o, err := models.CreateOpname(user, req.WarehouseID, req.Note)
+		if err != nil {
+			http.Error(w, "Stock opname failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(o)
+
+	case http.MethodDelete:
+		id := queryID(r, "id")
+		if id == nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := models.GetOpname(user, *id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := models.CancelOpname(user, *id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Stock opname cancelled"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleOpnameCount menyimpan hasil hitung fisik:
+// {"id": 1, "items": [{"product_id": 5, "quantity": 48}]}. Produk yang tidak disebut tidak berubah.
+func handleOpnameCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID    int               `json:"id"`
+		Items []productQuantity `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if _, err := models.GetOpname(user, req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	counts := make(map[int]int, len(req.Items))
+	for _, item := range req.Items {
+		counts[item.ProductID] = item.Quantity
+	}
+	o, err := models.RecordOpnameCounts(user, req.ID, counts)
+	if err != nil {
+		http.Error(w, "Count failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(o)
+}
+
+// handleOpnameApprove menyetujui stock opname dan membukukan selisihnya ke stok: {"id": 1}
+func handleOpnameApprove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if _, err := models.GetOpname(user, req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	o, err := models.ApproveOpname(user, req.ID)
+	if err != nil {
+		http.Error(w, "Approve failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(o)
+}
+
+// handleSuppliers mengelola data supplier (GET daftar, POST tambah, PUT ubah, DELETE hapus)
+func handleSuppliers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+	switch r.Method {
+	case http.MethodGet:
+		data, err := models.GetAllSuppliers()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if data == nil {
+			data = []models.Supplier{}
+		}
+		json.NewEncoder(w).Encode(data)
+	case http.MethodPost:
+		var req struct {
+			Name    string `json:"name"`
+			Phone   string `json:"phone"`
+			Address string `json:"address"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		sup, err := models.CreateSupplier(user, req.Name, req.Phone, req.Address)
+		if err != nil {
+			http.Error(w, err.Error(), supplierErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sup)
+	case http.MethodPut:
+		var req struct {
+			ID      int    `json:"id"`
+			Name    string `json:"name"`
+			Phone   string `json:"phone"`
+			Address string `json:"address"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.UpdateSupplier(user, req.ID, req.Name, req.Phone, req.Address); err != nil {
+			http.Error(w, err.Error(), supplierErrorStatus(err))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Supplier updated"})
+	case http.MethodDelete:
+		var req struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if err := models.DeleteSupplier(user, req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Supplier deleted"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// supplierErrorStatus memilih status HTTP untuk error simpan supplier
+func supplierErrorStatus(err error) int {
+	if errors.Is(err, models.ErrDuplicateSupplier) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// purchaseLine adalah satu baris pesanan atau penerimaan; price 0 saat penerimaan = harga PO
+type purchaseLine struct {
+	ProductID  int     `json:"product_id"`
+	Quantity   int     `json:"quantity"`
+	Price      float64 `json:"price"`
+	BatchNo    string  `json:"batch_no"`    // hanya saat penerimaan
+	ExpiryDate string  `json:"expiry_date"` // DD-MM-YYYY, diisi = barang dicatat sebagai batch
+}
+
+// handlePurchaseOrders menampilkan, membuat, dan membatalkan purchase order.
+// GET ?id= mengembalikan detail PO, tanpa id daftar PO (?status=, ?supplier_id=, ?warehouse_id=, ?outstanding=true).
+func handlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := queryID(r, "id"); id != nil {
+			o, err := models.GetPurchaseOrder(user, *id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(o)
+			return
+		}
+
+		warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+		if err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		filter := models.PurchaseFilter{
+			WarehouseID: warehouseID,
+			Status:      r.URL.Query().Get("status"),
+			Outstanding: r.URL.Query().Get("outstanding") == "true",
+		}
+		if id := queryID(r, "supplier_id"); id != nil {
+			filter.SupplierID = *id
+		}
+		orders, err := models.GetPurchaseOrders(user, filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if orders == nil {
+			orders = []models.PurchaseOrder{}
+		}
+		json.NewEncoder(w).Encode(orders)
+
+	case http.MethodPost:
+		var req struct {
+			SupplierID  int            `json:"supplier_id"`
+			WarehouseID int            `json:"warehouse_id"` // default: gudang user
+			Note        string         `json:"note"`
+			Items       []purchaseLine `json:"items"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if scope := warehouseScope(user); scope != nil && req.WarehouseID == 0 {
+			req.WarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.WarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+
+		var items []models.PurchaseOrderItem
+		for _, item := range req.Items {
+			items = append(items, models.PurchaseOrderItem{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price})
+		}
+		o, err := models.CreatePurchaseOrder(user, req.SupplierID, req.WarehouseID, req.Note, items)
+		if err != nil {
+			http.Error(w, "Purchase order failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(o)
+
+	case http.MethodDelete:
+		id := queryID(r, "id")
+		if id == nil {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := models.GetPurchaseOrder(user, *id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := models.CancelPurchaseOrder(user, *id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Purchase order cancelled"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePurchaseReceive mencatat barang yang datang dari supplier:
+// {"id": 1, "note": "faktur 001", "items": [{"product_id": 5, "quantity": 10, "price": 2600}]}.
+// Tanpa items semua sisa pesanan dianggap datang dengan harga PO.
+func handlePurchaseReceive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID    int            `json:"id"`
+		Note  string         `json:"note"`
+		Items []purchaseLine `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if _, err := models.GetPurchaseOrder(user, req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var items []models.GoodsReceiptItem
+	for _, item := range req.Items {
+		in := models.GoodsReceiptItem{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price, BatchNo: item.BatchNo}
+		if item.ExpiryDate != "" {
+			expiry, err := time.ParseInLocation("02-01-2006", item.ExpiryDate, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid expiry_date format DD-MM-YYYY", http.StatusBadRequest)
+				return
+			}
+			in.ExpiryDate = &expiry
+		}
+		items = append(items, in)
+	}
+	gr, err := models.ReceivePurchaseOrder(user, req.ID, items, req.Note)
+	if err != nil {
+		http.Error(w, "Receive failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(gr)
+}
+
+// handleOutstandingPurchases menampilkan barang yang sudah dipesan tapi belum diterima (?warehouse_id=)
+func handleOutstandingPurchases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+	if err != nil {
+		forbid(w, err.Error())
+		return
+	}
+	report, err := models.GetOutstandingPurchases(warehouseID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		report = []models.OutstandingPurchase{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleLowStock menampilkan produk yang stoknya sudah mencapai stok minimum (?warehouse_id=)
+func handleLowStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+	if err != nil {
+		forbid(w, err.Error())
+		return
+	}
+	items, err := models.GetLowStock(warehouseID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []models.LowStockItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// handleBatches menampilkan batch produk yang masih bersisa di satu gudang (?product_id=,
+// ?warehouse_id=) dan menambah stok sebagai batch baru
+func handleBatches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		productID := queryID(r, "product_id")
+		if productID == nil {
+			http.Error(w, "product_id is required", http.StatusBadRequest)
+			return
+		}
+		warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+		if err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		if warehouseID == nil {
+			http.Error(w, "warehouse_id is required", http.StatusBadRequest)
+			return
+		}
+		batches, err := models.GetBatches(*productID, *warehouseID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if batches == nil {
+			batches = []models.StockBatch{}
+		}
+		json.NewEncoder(w).Encode(batches)
+
+	case http.MethodPost:
+		var req struct {
+			ProductID   int    `json:"product_id"`
+			WarehouseID int    `json:"warehouse_id"`
+			BatchNo     string `json:"batch_no"`
+			ExpiryDate  string `json:"expiry_date"` // DD-MM-YYYY
+			Quantity    int    `json:"quantity"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if scope := warehouseScope(user); scope != nil && req.WarehouseID == 0 {
+			req.WarehouseID = *scope
+		}
+		if err := checkWarehouseAccess(user, req.WarehouseID); err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		expiry, err := time.ParseInLocation("02-01-2006", req.ExpiryDate, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid expiry_date format DD-MM-YYYY", http.StatusBadRequest)
+			return
+		}
+		batch, err := models.AddStockBatch(user, req.ProductID, req.WarehouseID, req.BatchNo, expiry, req.Quantity)
+		if err != nil {
+			http.Error(w, "Add batch failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(batch)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleBatchWriteOff memusnahkan sisa satu batch ({"id"}) atau semua batch yang sudah kedaluwarsa
+// ({"expired": true, "warehouse_id"}). Respons berisi batch yang dimusnahkan; Remaining adalah
+// jumlah yang dimusnahkan.
+func handleBatchWriteOff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+
+	var req struct {
+		ID          int  `json:"id"`
+		Expired     bool `json:"expired"`
+		WarehouseID *int `json:"warehouse_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if (req.ID > 0) == req.Expired {
+		http.Error(w, "either id or expired is required", http.StatusBadRequest)
+		return
+	}
+
+	var batches []models.StockBatch
+	if req.Expired {
+		warehouseID, err := user.ScopeWarehouse(req.WarehouseID)
+		if err != nil {
+			forbid(w, err.Error())
+			return
+		}
+		if batches, err = models.WriteOffExpiredBatches(user, warehouseID); err != nil {
+			http.Error(w, "Write-off failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		b, err := models.WriteOffBatch(user, req.ID)
+		if err != nil {
+			http.Error(w, "Write-off failed: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, *b)
+	}
+	if batches == nil {
+		batches = []models.StockBatch{}
+	}
+	json.NewEncoder(w).Encode(batches)
+}
+
+// handleExpiringBatches menampilkan batch yang kedaluwarsa dalam ?days= hari ke depan (default 30),
+// termasuk yang sudah lewat (?warehouse_id=)
+func handleExpiringBatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	days := 30
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "days must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+	if err != nil {
+		forbid(w, err.Error())
+		return
+	}
+	report, err := models.GetExpiringBatches(warehouseID, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		report = []models.ExpiringBatch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleSupplierPurchases menampilkan riwayat penerimaan barang dari satu supplier
+// (?supplier_id=, ?warehouse_id=, ?from=, ?to=)
+func handleSupplierPurchases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := userFromContext(r)
+
+	supplierID := queryID(r, "supplier_id")
+	if supplierID == nil {
+		http.Error(w, "supplier_id is required", http.StatusBadRequest)
+		return
+	}
+	warehouseID, err := user.ScopeWarehouse(queryID(r, "warehouse_id"))
+	if err != nil {
+		forbid(w, err.Error())
+		return
+	}
+	from, to, ok := queryDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := models.GetSupplierPurchases(*supplierID, warehouseID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if history.Receipts == nil {
+		history.Receipts = []models.GoodsReceipt{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+func handleRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := userFromContext(r)
+	switch r.Method {
+	case http.MethodGet:
+		roles, err := models.GetAllRoles()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"roles":       roles,
+			"permissions": models.AllPermissions,
+		})
+	case http.MethodPost:
+		var req struct {
+			Name        string   `json:"name"`
+			Description string   `json:"description"`
+			Permissions []string `json:"permissions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		role, err := models.CreateRole(user, req.Name, req.Description, req.Permissions)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(role)
+	case http.MethodPut:
+		var req struct {
+			Name        string   `json:"name"`
+			Permissions []string `json:"permissions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := models.SetRolePermissions(user, req.Name, req.Permissions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Role updated"})
+	case http.MethodDelete:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := models.DeleteRole(user, req.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Role deleted"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	page, limit := 1, 50
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	filter := models.AuditFilter{
+		Entity:   q.Get("entity"),
+		Action:   q.Get("action"),
+		Username: q.Get("username"),
+	}
+	if id, err := strconv.Atoi(q.Get("entity_id")); err == nil {
+		filter.EntityID = id
+	}
+	if from := q.Get("from"); from != "" {
+		date, err := time.ParseInLocation("02-01-2006", from, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid from date format DD-MM-YYYY", http.StatusBadRequest)
+			return
+		}
+		filter.From = &date
+	}
+	if to := q.Get("to"); to != "" {
+		date, err := time.ParseInLocation("02-01-2006", to, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid to date format DD-MM-YYYY", http.StatusBadRequest)
+			return
+		}
+		end := date.Add(24 * time.Hour)
+		filter.To = &end
+	}
+
+	logs, total, err := models.GetAuditLogs(filter, page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": logs,
+		"meta": map[string]interface{}{
+			"current_page": page,
+			"limit":        limit,
+			"total_items":  total,
+			"total_pages":  (total + limit - 1) / limit,
+		},
+	})
 }
@@ -0,0 +1,1043 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"kasir/config"
+	"kasir/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testTokenSecret = "rahasia-token-untuk-test-api-0123456789"
+
+type testEnv struct {
+	t       *testing.T
+	handler http.Handler
+	pusat   *models.Warehouse
+	cabang  *models.Warehouse
+}
+
+// newTestEnv menyiapkan API di atas memory store dengan dua gudang,
+// satu admin (admin/admin123) dan satu kasir Gudang Pusat (kasir1/user123)
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	models.SetStore(models.NewMemoryStore())
+	config.App.Auth.TokenSecret = testTokenSecret
+	if err := initTokens(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := models.CreateRole(nil, models.AdminRole, "Administrator", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := models.CreateRole(nil, "user", "Kasir", []string{
+		models.PermTransactionCreate, models.PermProductView, models.PermReportView,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{t: t, handler: newRouter()}
+	env.pusat, _ = models.CreateWarehouse(nil, "Gudang Pusat", "")
+	env.cabang, _ = models.CreateWarehouse(nil, "Gudang Cabang A", "")
+
+	if _, err := models.Register(nil, "admin", "admin123", models.AdminRole, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Register(nil, "kasir1", "user123", "user", &env.pusat.ID); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func (e *testEnv) product(name string, stock, warehouseID int) *models.Product {
+	e.t.Helper()
+	p, err := models.CreateProduct(nil, models.Product{Name: name, PurchasePrice: 2500, SellingPrice: 3500, Stock: stock, WarehouseID: warehouseID})
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return p
+}
+
+// do mengirim request JSON ke router; token kosong berarti tanpa header Authorization
+func (e *testEnv) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (e *testEnv) login(username, password string) (string, string) {
+	e.t.Helper()
+	rec := e.do(http.MethodPost, "/api/login", "", map[string]string{"username": username, "password": password})
+	if rec.Code != http.StatusOK {
+		e.t.Fatalf("login %s: status %d: %s", username, rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	return resp.AccessToken, resp.RefreshToken
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+	env := newTestEnv(t)
+	rec := env.do(http.MethodPost, "/api/login", "", map[string]string{"username": "kasir1", "password": "salah"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestProductsRequireToken(t *testing.T) {
+	env := newTestEnv(t)
+	if rec := env.do(http.MethodGet, "/api/products", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without token: status = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/products", "bukan-token", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestListProductsScopedAndPaginated(t *testing.T) {
+	env := newTestEnv(t)
+	env.product("Indomie Goreng", 10, env.pusat.ID)
+	env.product("Indomie Soto", 10, env.pusat.ID)
+	env.product("Aqua 600ml", 10, env.pusat.ID)
+	env.product("Indomie Goreng", 10, env.cabang.ID)
+
+	type listResponse struct {
+		Data []models.Product `json:"data"`
+		Meta struct {
+			TotalItems int `json:"total_items"`
+			TotalPages int `json:"total_pages"`
+		} `json:"meta"`
+	}
+
+	tests := []struct {
+		name       string
+		user, pass string
+		query      string
+		wantItems  int
+		wantTotal  int
+		wantPages  int
+	}{
+		{"kasir sees own warehouse", "kasir1", "user123", "", 3, 3, 1},
+		{"kasir cannot widen scope", "kasir1", "user123", "?warehouse_id=2", 3, 3, 1},
+		{"kasir search", "kasir1", "user123", "?search=indomie", 2, 2, 1},
+		{"admin sees all, paginated", "admin", "admin123", "?limit=3&page=2", 1, 4, 2},
+		{"admin filters warehouse", "admin", "admin123", "?warehouse_id=2", 1, 1, 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, _ := env.login(tc.user, tc.pass)
+			rec := env.do(http.MethodGet, "/api/products"+tc.query, token, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			var resp listResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if len(resp.Data) != tc.wantItems || resp.Meta.TotalItems != tc.wantTotal || resp.Meta.TotalPages != tc.wantPages {
+				t.Errorf("got %d items, total %d, pages %d; want %d, %d, %d",
+					len(resp.Data), resp.Meta.TotalItems, resp.Meta.TotalPages, tc.wantItems, tc.wantTotal, tc.wantPages)
+			}
+		})
+	}
+}
+
+func TestCreateTransactionEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	other := env.product("Indomie Goreng", 10, env.cabang.ID)
+	token, _ := env.login("kasir1", "user123")
+
+	rec := env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+		"items":   []map[string]int{{"product_id": mie.ID, "quantity": 4}},
+		"payment": 20000,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var trx models.Transaction
+	json.NewDecoder(rec.Body).Decode(&trx)
+	if trx.Total != 14000 || trx.Change != 6000 || trx.CashierName != "kasir1" {
+		t.Errorf("transaction = %+v", trx)
+	}
+	if p, _ := models.GetProductByID(mie.ID); p.Stock != 6 {
+		t.Errorf("stock = %d, want 6", p.Stock)
+	}
+
+	// Produk gudang lain ditolak
+	rec = env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+		"items":   []map[string]int{{"product_id": other.ID, "quantity": 1}},
+		"payment": 5000,
+	})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("other warehouse: status = %d, want 403", rec.Code)
+	}
+
+	// Stok tidak cukup
+	rec = env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+		"items":   []map[string]int{{"product_id": mie.ID, "quantity": 7}},
+		"payment": 50000,
+	})
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("insufficient stock: status = %d, want 500", rec.Code)
+	}
+
+	// Jumlah tidak positif dan pembayaran kurang ditolak sebagai request tidak valid
+	invalid := []struct {
+		name     string
+		quantity int
+		payment  float64
+	}{
+		{"zero quantity", 0, 20000},
+		{"negative quantity", -2, 20000},
+		{"underpayment", 2, 5000},
+	}
+	for _, tc := range invalid {
+		rec = env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+			"items":   []map[string]int{{"product_id": mie.ID, "quantity": tc.quantity}},
+			"payment": tc.payment,
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400: %s", tc.name, rec.Code, rec.Body.String())
+		}
+	}
+	if p, _ := models.GetProductByID(mie.ID); p.Stock != 6 {
+		t.Errorf("stock after failed checkout = %d, want 6", p.Stock)
+	}
+}
+
+func TestTransactionWithVariants(t *testing.T) {
+	env := newTestEnv(t)
+	kaos := env.product("Kaos Polos", 0, env.pusat.ID)
+	l, _ := models.CreateVariant(nil, kaos.ID, models.Product{Variant: "L", Stock: 5})
+	xl, _ := models.CreateVariant(nil, kaos.ID, models.Product{Variant: "XL", SellingPrice: 4000, Stock: 5})
+	token, _ := env.login("kasir1", "user123")
+
+	tests := []struct {
+		name string
+		item map[string]interface{}
+		want int
+	}{
+		{"parent without variant", map[string]interface{}{"product_id": kaos.ID, "quantity": 1}, http.StatusBadRequest},
+		{"unknown variant name", map[string]interface{}{"product_id": kaos.ID, "variant": "XXL", "quantity": 1}, http.StatusBadRequest},
+		{"variant by name", map[string]interface{}{"product_id": kaos.ID, "variant": "xl", "quantity": 1}, http.StatusOK},
+		{"variant by id", map[string]interface{}{"product_id": kaos.ID, "variant_id": l.ID, "quantity": 2}, http.StatusOK},
+		{"variant as product", map[string]interface{}{"product_id": l.ID, "quantity": 1}, http.StatusOK},
+	}
+	for _, tc := range tests {
+		rec := env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+			"items":   []map[string]interface{}{tc.item},
+			"payment": 20000,
+		})
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+
+	if p, _ := models.GetProductByID(l.ID); p.Stock != 2 {
+		t.Errorf("stock L = %d, want 2", p.Stock)
+	}
+	if p, _ := models.GetProductByID(xl.ID); p.Stock != 4 {
+		t.Errorf("stock XL = %d, want 4", p.Stock)
+	}
+
+	rec := env.do(http.MethodGet, "/api/reports/products?rollup=true", token, nil)
+	var report struct {
+		Sales []models.ProductSales `json:"sales"`
+	}
+	json.NewDecoder(rec.Body).Decode(&report)
+	if rec.Code != http.StatusOK || len(report.Sales) != 1 || report.Sales[0].ProductID != kaos.ID || report.Sales[0].Quantity != 4 {
+		t.Errorf("rolled up report: status %d, %+v", rec.Code, report.Sales)
+	}
+}
+
+func TestProductStockAcrossWarehouses(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	admin, _ := env.login("admin", "admin123")
+	kasir, _ := env.login("kasir1", "user123")
+
+	rec := env.do(http.MethodPut, "/api/products/stock", admin, map[string]interface{}{
+		"product_id": mie.ID, "warehouse_id": env.cabang.ID, "stock": 6, "selling_price": 4000,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("stock cabang: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.do(http.MethodPut, "/api/products/stock", kasir, map[string]interface{}{
+		"product_id": mie.ID, "warehouse_id": env.cabang.ID, "stock": 1,
+	}); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir stock other warehouse: status %d, want 403", rec.Code)
+	}
+
+	rec = env.do(http.MethodGet, "/api/products/stock?product_id="+strconv.Itoa(mie.ID), kasir, nil)
+	var view struct {
+		Product    models.Product   `json:"product"`
+		Warehouses []models.Product `json:"warehouses"`
+	}
+	json.NewDecoder(rec.Body).Decode(&view)
+	if rec.Code != http.StatusOK || view.Product.Stock != 16 || len(view.Warehouses) != 1 || view.Warehouses[0].WarehouseID != env.pusat.ID {
+		t.Errorf("kasir stock view: status %d, %+v", rec.Code, view)
+	}
+
+	// Admin harus memilih gudang jika produk ada di beberapa gudang
+	sell := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		body["items"] = []map[string]int{{"product_id": mie.ID, "quantity": 1}}
+		body["payment"] = 5000
+		return env.do(http.MethodPost, "/api/transactions", admin, body)
+	}
+	if rec := sell(map[string]interface{}{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("ambiguous warehouse: status %d, want 400", rec.Code)
+	}
+	rec = sell(map[string]interface{}{"warehouse_id": env.cabang.ID})
+	var trx models.Transaction
+	json.NewDecoder(rec.Body).Decode(&trx)
+	if rec.Code != http.StatusOK || trx.WarehouseID != env.cabang.ID || trx.Total != 4000 {
+		t.Errorf("sale in cabang: status %d, %+v", rec.Code, trx)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.cabang.ID); p.Stock != 5 {
+		t.Errorf("cabang stock = %d, want 5", p.Stock)
+	}
+
+	// User gudang hanya mengeluarkan produk dari gudangnya; katalog dan gudang lain tetap
+	role, _ := models.CreateRole(nil, "kepala-gudang", "", []string{models.PermProductManage})
+	models.Register(nil, "kepala1", "kepala123", role.Name, &env.pusat.ID)
+	kepala, _ := env.login("kepala1", "kepala123")
+	if rec := env.do(http.MethodDelete, "/api/products", kepala, map[string]int{"id": mie.ID}); rec.Code != http.StatusOK {
+		t.Fatalf("remove from pusat: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rows, _ := models.GetProductWarehouses(mie.ID); len(rows) != 1 || rows[0].WarehouseID != env.cabang.ID {
+		t.Errorf("after remove = %+v", rows)
+	}
+}
+
+func TestUpdateProductMasterAndStock(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	admin, _ := env.login("admin", "admin123")
+	role, _ := models.CreateRole(nil, "kepala-gudang", "", []string{models.PermProductManage, models.PermStockAdjust})
+	models.Register(nil, "kepala1", "kepala123", role.Name, &env.pusat.ID)
+	kepala, _ := env.login("kepala1", "kepala123")
+
+	update := func(token, name string, stock int) int {
+		return env.do(http.MethodPut, "/api/products", token, map[string]interface{}{
+			"id": mie.ID, "name": name, "purchase_price": mie.PurchasePrice, "selling_price": mie.SellingPrice,
+			"stock": stock,
+		}).Code
+	}
+
+	// Data master dan stok disimpan bersama: stok yang ditolak membatalkan perubahan nama
+	if code := update(admin, "Indomie Goreng Jumbo", -1); code == http.StatusOK {
+		t.Errorf("negative stock: status %d, want error", code)
+	}
+	if p, _ := models.GetProductByID(mie.ID); p.Name != "Indomie Goreng" {
+		t.Errorf("name after failed update = %q, want unchanged", p.Name)
+	}
+
+	// User gudang tidak boleh mengubah data master, tapi tetap bisa mengubah stok gudangnya
+	if code := update(kepala, "Indomie Goreng Jumbo", 8); code != http.StatusForbidden {
+		t.Errorf("warehouse user renaming: status %d, want 403", code)
+	}
+	if code := update(kepala, "Indomie Goreng", 8); code != http.StatusOK {
+		t.Errorf("warehouse user adjusting stock: status %d, want 200", code)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Name != "Indomie Goreng" || p.Stock != 8 {
+		t.Errorf("after warehouse update = %q stock %d, want unchanged name and stock 8", p.Name, p.Stock)
+	}
+
+	if code := update(admin, "Indomie Goreng Jumbo", 8); code != http.StatusOK {
+		t.Errorf("admin renaming: status %d, want 200", code)
+	}
+	if p, _ := models.GetProductByID(mie.ID); p.Name != "Indomie Goreng Jumbo" {
+		t.Errorf("name = %q, want renamed", p.Name)
+	}
+}
+
+func TestTransferEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	role, _ := models.CreateRole(nil, "petugas-gudang", "", []string{models.PermStockTransfer})
+	models.Register(nil, "gudang1", "gudang123", role.Name, &env.pusat.ID)
+	models.Register(nil, "gudang2", "gudang123", role.Name, &env.cabang.ID)
+	pengirim, _ := env.login("gudang1", "gudang123")
+	penerima, _ := env.login("gudang2", "gudang123")
+	kasir, _ := env.login("kasir1", "user123")
+
+	if rec := env.do(http.MethodGet, "/api/transfers", kasir, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir without stock.transfer: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/transfers", penerima, map[string]interface{}{
+		"from_warehouse_id": env.pusat.ID, "to_warehouse_id": env.cabang.ID,
+		"items": []map[string]int{{"product_id": mie.ID, "quantity": 1}},
+	}); rec.Code != http.StatusForbidden {
+		t.Errorf("create from other warehouse: status %d, want 403", rec.Code)
+	}
+
+	rec := env.do(http.MethodPost, "/api/transfers", pengirim, map[string]interface{}{
+		"to_warehouse_id": env.cabang.ID, "note": "stok mingguan",
+		"items": []map[string]int{{"product_id": mie.ID, "quantity": 5}},
+	})
+	var trf models.Transfer
+	json.NewDecoder(rec.Body).Decode(&trf)
+	if rec.Code != http.StatusCreated || trf.Status != models.TransferDraft || trf.FromWarehouseID != env.pusat.ID {
+		t.Fatalf("create: status %d, %+v", rec.Code, trf)
+	}
+
+	if rec := env.do(http.MethodPost, "/api/transfers/send", penerima, map[string]int{"id": trf.ID}); rec.Code != http.StatusForbidden {
+		t.Errorf("destination sending: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/transfers/send", pengirim, map[string]int{"id": trf.ID}); rec.Code != http.StatusOK {
+		t.Fatalf("send: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(http.MethodGet, "/api/transfers?status=sent", penerima, nil)
+	var inTransit []models.Transfer
+	json.NewDecoder(rec.Body).Decode(&inTransit)
+	if rec.Code != http.StatusOK || len(inTransit) != 1 {
+		t.Errorf("in transit for destination: status %d, %+v", rec.Code, inTransit)
+	}
+
+	rec = env.do(http.MethodPost, "/api/transfers/receive", penerima, map[string]interface{}{
+		"id": trf.ID, "items": []map[string]int{{"product_id": mie.ID, "quantity": 4}},
+	})
+	json.NewDecoder(rec.Body).Decode(&trf)
+	if rec.Code != http.StatusOK || trf.Status != models.TransferReceived || trf.Items[0].Shortage() != 1 {
+		t.Fatalf("receive: status %d, %+v", rec.Code, trf)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.cabang.ID); p.Stock != 4 {
+		t.Errorf("cabang stock = %d, want 4", p.Stock)
+	}
+	if rec := env.do(http.MethodDelete, "/api/transfers?id="+strconv.Itoa(trf.ID), pengirim, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("cancel received transfer: status %d, want 400", rec.Code)
+	}
+}
+
+func TestStockCardEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	token, _ := env.login("kasir1", "user123")
+
+	rec := env.do(http.MethodPost, "/api/transactions", token, map[string]interface{}{
+		"items":   []map[string]int{{"product_id": mie.ID, "quantity": 4}},
+		"payment": 20000,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("checkout: status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(http.MethodGet, "/api/reports/stock-card?product_id="+strconv.Itoa(mie.ID), token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var card models.StockCard
+	json.NewDecoder(rec.Body).Decode(&card)
+	if card.WarehouseID == nil || *card.WarehouseID != env.pusat.ID || card.Closing != 6 || len(card.Movements) != 2 {
+		t.Fatalf("stock card = %+v", card)
+	}
+	if m := card.Movements[1]; m.Type != models.MovementSale || m.Quantity != -4 || m.Balance != 6 || m.Username != "kasir1" {
+		t.Errorf("sale movement = %+v", m)
+	}
+
+	path := "/api/reports/stock-card?product_id=" + strconv.Itoa(mie.ID) + "&warehouse_id=" + strconv.Itoa(env.cabang.ID)
+	if rec := env.do(http.MethodGet, path, token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("other warehouse: status = %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/reports/stock-card", token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing product_id: status = %d, want 400", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/reports/stock-card?product_id=1&from=2026-01-01", token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad date: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestLowStockEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	aqua := env.product("Aqua 600ml", 3, env.cabang.ID)
+	admin, _ := env.login("admin", "admin123")
+	kasir, _ := env.login("kasir1", "user123")
+
+	if rec := env.do(http.MethodPut, "/api/products/stock", kasir, map[string]interface{}{
+		"product_id": mie.ID, "reorder_point": 12,
+	}); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir setting reorder point: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPut, "/api/products/stock", admin, map[string]interface{}{
+		"product_id": mie.ID, "warehouse_id": env.cabang.ID, "reorder_point": 5,
+	}); rec.Code != http.StatusBadRequest {
+		t.Errorf("reorder point outside warehouse: status %d, want 400", rec.Code)
+	}
+	for _, st := range []map[string]interface{}{
+		{"product_id": mie.ID, "warehouse_id": env.pusat.ID, "reorder_point": 12},
+		{"product_id": aqua.ID, "warehouse_id": env.cabang.ID, "reorder_point": 5},
+	} {
+		if rec := env.do(http.MethodPut, "/api/products/stock", admin, st); rec.Code != http.StatusOK {
+			t.Fatalf("set reorder point: status %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Stock != 10 {
+		t.Errorf("reorder point alone changed stock to %d", p.Stock)
+	}
+
+	rec := env.do(http.MethodGet, "/api/alerts/low-stock", admin, nil)
+	var items []models.LowStockItem
+	json.NewDecoder(rec.Body).Decode(&items)
+	if rec.Code != http.StatusOK || len(items) != 2 {
+		t.Fatalf("admin low stock: status %d, %+v", rec.Code, items)
+	}
+
+	rec = env.do(http.MethodGet, "/api/alerts/low-stock", kasir, nil)
+	items = nil
+	json.NewDecoder(rec.Body).Decode(&items)
+	if rec.Code != http.StatusOK || len(items) != 1 || items[0].ProductID != mie.ID || items[0].ReorderPoint != 12 {
+		t.Errorf("kasir low stock: status %d, %+v", rec.Code, items)
+	}
+	if rec := env.do(http.MethodGet, "/api/alerts/low-stock?warehouse_id="+strconv.Itoa(env.cabang.ID), kasir, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir other warehouse: status %d, want 403", rec.Code)
+	}
+}
+
+func TestBatchEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	roti := env.product("Roti Tawar", 0, env.pusat.ID)
+	admin, _ := env.login("admin", "admin123")
+	kasir, _ := env.login("kasir1", "user123")
+	yesterday := time.Now().AddDate(0, 0, -1).Format("02-01-2006")
+	nextWeek := time.Now().AddDate(0, 0, 7).Format("02-01-2006")
+
+	add := map[string]interface{}{"product_id": roti.ID, "warehouse_id": env.pusat.ID, "batch_no": "R-01", "expiry_date": yesterday, "quantity": 3}
+	if rec := env.do(http.MethodPost, "/api/batches", kasir, add); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir adding batch: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/batches", admin, add); rec.Code != http.StatusCreated {
+		t.Fatalf("add batch: status %d: %s", rec.Code, rec.Body.String())
+	}
+	add["batch_no"], add["expiry_date"], add["quantity"] = "R-02", nextWeek, 5
+	if rec := env.do(http.MethodPost, "/api/batches", admin, add); rec.Code != http.StatusCreated {
+		t.Fatalf("add batch: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Batch R-01 sudah kedaluwarsa: penjualan mengambil R-02
+	rec := env.do(http.MethodPost, "/api/transactions", kasir, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": roti.ID, "quantity": 2}}, "payment": 100000,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("transaction: status %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = env.do(http.MethodGet, "/api/batches?product_id="+strconv.Itoa(roti.ID), kasir, nil)
+	var batches []models.StockBatch
+	json.NewDecoder(rec.Body).Decode(&batches)
+	if rec.Code != http.StatusOK || len(batches) != 2 || batches[0].Remaining != 3 || batches[1].Remaining != 3 {
+		t.Fatalf("batches: status %d, %+v", rec.Code, batches)
+	}
+
+	rec = env.do(http.MethodGet, "/api/reports/expiring-batches?days=3", kasir, nil)
+	var report []models.ExpiringBatch
+	json.NewDecoder(rec.Body).Decode(&report)
+	if rec.Code != http.StatusOK || len(report) != 1 || report[0].BatchNo != "R-01" || report[0].DaysLeft != -1 {
+		t.Errorf("expiring: status %d, %+v", rec.Code, report)
+	}
+
+	if rec := env.do(http.MethodPost, "/api/batches/write-off", admin, map[string]interface{}{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("write-off without target: status %d, want 400", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/batches/write-off", admin, map[string]interface{}{"id": batches[0].ID})
+	var written []models.StockBatch
+	json.NewDecoder(rec.Body).Decode(&written)
+	if rec.Code != http.StatusOK || len(written) != 1 || written[0].Remaining != 3 {
+		t.Fatalf("write-off: status %d, %+v", rec.Code, written)
+	}
+	if p, _ := models.GetProductInWarehouse(roti.ID, env.pusat.ID); p.Stock != 3 {
+		t.Errorf("stock after write-off = %d, want 3", p.Stock)
+	}
+}
+
+func TestReturnEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	admin, _ := env.login("admin", "admin123")
+	kasir, _ := env.login("kasir1", "user123")
+	models.CreateRole(nil, "supervisor", "Supervisor", []string{models.PermTransactionReturn})
+	models.Register(nil, "spv2", "spv123", "supervisor", &env.cabang.ID)
+	spvCabang, _ := env.login("spv2", "spv123")
+
+	rec := env.do(http.MethodPost, "/api/transactions", kasir, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": mie.ID, "quantity": 4}}, "payment": 20000,
+	})
+	var trx models.Transaction
+	json.NewDecoder(rec.Body).Decode(&trx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("transaction: status %d", rec.Code)
+	}
+
+	body := map[string]interface{}{
+		"transaction_id": trx.ID, "reason": "kemasan rusak",
+		"items": []map[string]interface{}{{"product_id": mie.ID, "quantity": 3}},
+	}
+	if rec := env.do(http.MethodPost, "/api/returns", kasir, body); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir without transaction.return: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/returns", spvCabang, body); rec.Code != http.StatusNotFound {
+		t.Errorf("return from another warehouse: status %d, want 404", rec.Code)
+	}
+	over := map[string]interface{}{
+		"transaction_id": trx.ID, "reason": "rusak",
+		"items": []map[string]interface{}{{"product_id": mie.ID, "quantity": 5}},
+	}
+	if rec := env.do(http.MethodPost, "/api/returns", admin, over); rec.Code != http.StatusBadRequest {
+		t.Errorf("over-return: status %d, want 400", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/returns", admin, body)
+	var ret models.SalesReturn
+	json.NewDecoder(rec.Body).Decode(&ret)
+	if rec.Code != http.StatusCreated || ret.Total != 10500 || len(ret.Items) != 1 {
+		t.Fatalf("return: status %d, %+v", rec.Code, ret)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Stock != 9 {
+		t.Errorf("stock after return = %d, want 9", p.Stock)
+	}
+
+	rec = env.do(http.MethodGet, "/api/returns?transaction_id="+strconv.Itoa(trx.ID), kasir, nil)
+	var detail struct {
+		Items []struct {
+			Quantity int
+			Returned int
+		} `json:"items"`
+		Returns []models.SalesReturn `json:"returns"`
+	}
+	json.NewDecoder(rec.Body).Decode(&detail)
+	if rec.Code != http.StatusOK || len(detail.Items) != 1 || detail.Items[0].Returned != 3 || len(detail.Returns) != 1 {
+		t.Errorf("return detail: status %d, %+v", rec.Code, detail)
+	}
+
+	rec = env.do(http.MethodGet, "/api/reports", kasir, nil)
+	var report struct {
+		Summary models.SalesSummary  `json:"summary"`
+		Returns []models.SalesReturn `json:"returns"`
+	}
+	json.NewDecoder(rec.Body).Decode(&report)
+	if rec.Code != http.StatusOK || report.Summary.GrossSales != 14000 || report.Summary.NetSales != 3500 || len(report.Returns) != 1 {
+		t.Errorf("daily report: status %d, %+v", rec.Code, report)
+	}
+}
+
+func TestTransactionVoidEndpoint(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	kasir, _ := env.login("kasir1", "user123")
+	models.CreateRole(nil, "supervisor", "Supervisor", []string{models.PermTransactionVoid})
+	models.Register(nil, "spv1", "spv123", "supervisor", &env.pusat.ID)
+
+	rec := env.do(http.MethodPost, "/api/transactions", kasir, map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": mie.ID, "quantity": 4}}, "payment": 20000,
+	})
+	var trx models.Transaction
+	json.NewDecoder(rec.Body).Decode(&trx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("transaction: status %d", rec.Code)
+	}
+
+	body := map[string]interface{}{"id": trx.ID, "reason": "pelanggan batal", "approver_username": "kasir1", "approver_password": "user123"}
+	if rec := env.do(http.MethodPost, "/api/transactions/void", kasir, body); rec.Code != http.StatusForbidden {
+		t.Errorf("cashier approving void: status %d, want 403", rec.Code)
+	}
+	body["id"] = 999
+	if rec := env.do(http.MethodPost, "/api/transactions/void", kasir, body); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown transaction: status %d, want 404", rec.Code)
+	}
+
+	body["id"], body["approver_username"], body["approver_password"] = trx.ID, "spv1", "spv123"
+	rec = env.do(http.MethodPost, "/api/transactions/void", kasir, body)
+	var voided models.Transaction
+	json.NewDecoder(rec.Body).Decode(&voided)
+	if rec.Code != http.StatusOK || !voided.Voided() || voided.VoidedBy != "spv1" {
+		t.Fatalf("void: status %d, %+v", rec.Code, voided)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Stock != 10 {
+		t.Errorf("stock after void = %d, want 10", p.Stock)
+	}
+	if rec := env.do(http.MethodPost, "/api/transactions/void", kasir, body); rec.Code != http.StatusBadRequest {
+		t.Errorf("second void: status %d, want 400", rec.Code)
+	}
+
+	rec = env.do(http.MethodGet, "/api/reports", kasir, nil)
+	var report struct {
+		Summary models.SalesSummary  `json:"summary"`
+		Voided  []models.Transaction `json:"voided"`
+	}
+	json.NewDecoder(rec.Body).Decode(&report)
+	if rec.Code != http.StatusOK || report.Summary.GrossSales != 0 || len(report.Voided) != 1 {
+		t.Errorf("daily report: status %d, %+v", rec.Code, report)
+	}
+}
+
+func TestOpnameEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	clerk, _ := models.CreateRole(nil, "petugas-gudang", "", []string{models.PermStockOpname})
+	supervisor, _ := models.CreateRole(nil, "supervisor", "", []string{models.PermStockOpname, models.PermOpnameApprove})
+	models.Register(nil, "gudang1", "gudang123", clerk.Name, &env.pusat.ID)
+	models.Register(nil, "gudang2", "gudang123", clerk.Name, &env.cabang.ID)
+	models.Register(nil, "spv1", "spv12345", supervisor.Name, &env.pusat.ID)
+	petugas, _ := env.login("gudang1", "gudang123")
+	petugasCabang, _ := env.login("gudang2", "gudang123")
+	spv, _ := env.login("spv1", "spv12345")
+	kasir, _ := env.login("kasir1", "user123")
+
+	if rec := env.do(http.MethodGet, "/api/opnames", kasir, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir without stock.opname: status %d, want 403", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/opnames", petugasCabang, map[string]interface{}{"warehouse_id": env.pusat.ID}); rec.Code != http.StatusForbidden {
+		t.Errorf("open other warehouse: status %d, want 403", rec.Code)
+	}
+
+	rec := env.do(http.MethodPost, "/api/opnames", petugas, map[string]string{"note": "akhir bulan"})
+	var opn models.StockOpname
+	json.NewDecoder(rec.Body).Decode(&opn)
+	if rec.Code != http.StatusCreated || opn.WarehouseID != env.pusat.ID || len(opn.Items) != 1 || opn.Items[0].SystemStock != 10 {
+		t.Fatalf("create: status %d, %+v", rec.Code, opn)
+	}
+
+	if rec := env.do(http.MethodPost, "/api/opnames/count", petugasCabang, map[string]interface{}{
+		"id": opn.ID, "items": []map[string]int{{"product_id": mie.ID, "quantity": 1}},
+	}); rec.Code != http.StatusNotFound {
+		t.Errorf("count other warehouse: status %d, want 404", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/opnames/count", petugas, map[string]interface{}{
+		"id": opn.ID, "items": []map[string]int{{"product_id": mie.ID, "quantity": 7}},
+	})
+	json.NewDecoder(rec.Body).Decode(&opn)
+	if rec.Code != http.StatusOK || opn.Items[0].Variance() != -3 || opn.VarianceValue() != -7500 {
+		t.Fatalf("count: status %d, %+v", rec.Code, opn)
+	}
+
+	if rec := env.do(http.MethodPost, "/api/opnames/approve", petugas, map[string]int{"id": opn.ID}); rec.Code != http.StatusForbidden {
+		t.Errorf("clerk approving: status %d, want 403", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/opnames/approve", spv, map[string]int{"id": opn.ID})
+	json.NewDecoder(rec.Body).Decode(&opn)
+	if rec.Code != http.StatusOK || opn.Status != models.OpnameApproved {
+		t.Fatalf("approve: status %d, %+v", rec.Code, opn)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Stock != 7 {
+		t.Errorf("stock after approval = %d, want 7", p.Stock)
+	}
+	if rec := env.do(http.MethodDelete, "/api/opnames?id="+strconv.Itoa(opn.ID), petugas, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("cancel approved opname: status %d, want 400", rec.Code)
+	}
+}
+
+func TestPurchaseEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	mie := env.product("Indomie Goreng", 10, env.pusat.ID)
+	clerk, _ := models.CreateRole(nil, "petugas-gudang", "", []string{models.PermPurchaseReceive})
+	models.Register(nil, "gudang1", "gudang123", clerk.Name, &env.pusat.ID)
+	models.Register(nil, "gudang2", "gudang123", clerk.Name, &env.cabang.ID)
+	petugas, _ := env.login("gudang1", "gudang123")
+	petugasCabang, _ := env.login("gudang2", "gudang123")
+	admin, _ := env.login("admin", "admin123")
+
+	rec := env.do(http.MethodPost, "/api/suppliers", admin, map[string]string{"name": "PT Sumber Pangan"})
+	var sup models.Supplier
+	json.NewDecoder(rec.Body).Decode(&sup)
+	if rec.Code != http.StatusCreated || sup.ID == 0 {
+		t.Fatalf("create supplier: status %d, %+v", rec.Code, sup)
+	}
+	if rec := env.do(http.MethodPost, "/api/suppliers", admin, map[string]string{"name": "PT Sumber Pangan"}); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate supplier: status %d, want 409", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/suppliers", petugas, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("clerk listing suppliers: status %d, want 403", rec.Code)
+	}
+
+	order := map[string]interface{}{
+		"supplier_id": sup.ID, "warehouse_id": env.pusat.ID,
+		"items": []map[string]interface{}{{"product_id": mie.ID, "quantity": 24, "price": 2400}},
+	}
+	if rec := env.do(http.MethodPost, "/api/purchase-orders", petugas, order); rec.Code != http.StatusForbidden {
+		t.Errorf("clerk creating PO: status %d, want 403", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/purchase-orders", admin, order)
+	var po models.PurchaseOrder
+	json.NewDecoder(rec.Body).Decode(&po)
+	if rec.Code != http.StatusCreated || po.Status != models.PurchaseOpen || po.Total() != 24*2400 {
+		t.Fatalf("create PO: status %d, %+v", rec.Code, po)
+	}
+
+	receive := map[string]interface{}{"id": po.ID, "items": []map[string]int{{"product_id": mie.ID, "quantity": 20}}}
+	if rec := env.do(http.MethodPost, "/api/purchase-orders/receive", petugasCabang, receive); rec.Code != http.StatusNotFound {
+		t.Errorf("receive other warehouse: status %d, want 404", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/purchase-orders/receive", petugas, receive)
+	var gr models.GoodsReceipt
+	json.NewDecoder(rec.Body).Decode(&gr)
+	if rec.Code != http.StatusCreated || gr.Total != 20*2400 {
+		t.Fatalf("receive: status %d, %+v", rec.Code, gr)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, env.pusat.ID); p.Stock != 30 {
+		t.Errorf("stock after receipt = %d, want 30", p.Stock)
+	}
+
+	rec = env.do(http.MethodGet, "/api/reports/outstanding-purchases", petugas, nil)
+	var outstanding []models.OutstandingPurchase
+	json.NewDecoder(rec.Body).Decode(&outstanding)
+	if rec.Code != http.StatusOK || len(outstanding) != 1 || outstanding[0].Outstanding != 4 {
+		t.Errorf("outstanding: status %d, %+v", rec.Code, outstanding)
+	}
+	rec = env.do(http.MethodGet, "/api/reports/supplier-purchases?supplier_id="+strconv.Itoa(sup.ID), admin, nil)
+	var history models.SupplierPurchases
+	json.NewDecoder(rec.Body).Decode(&history)
+	if rec.Code != http.StatusOK || len(history.Receipts) != 1 || history.Total != 20*2400 {
+		t.Errorf("supplier history: status %d, %+v", rec.Code, history)
+	}
+	if rec := env.do(http.MethodDelete, "/api/purchase-orders?id="+strconv.Itoa(po.ID), admin, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("cancel partially received PO: status %d, want 400", rec.Code)
+	}
+}
+
+func TestPermissionsEnforced(t *testing.T) {
+	env := newTestEnv(t)
+	kasir, _ := env.login("kasir1", "user123")
+	admin, _ := env.login("admin", "admin123")
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{"kasir cannot create product", kasir, http.MethodPost, "/api/products", map[string]interface{}{"name": "Kopi"}, http.StatusForbidden},
+		{"kasir cannot list users", kasir, http.MethodGet, "/api/users", nil, http.StatusForbidden},
+		{"kasir cannot create warehouse", kasir, http.MethodPost, "/api/warehouses", map[string]string{"name": "Baru"}, http.StatusForbidden},
+		{"kasir can list warehouses", kasir, http.MethodGet, "/api/warehouses", nil, http.StatusOK},
+		{"admin can list users", admin, http.MethodGet, "/api/users", nil, http.StatusOK},
+		{"admin can create warehouse", admin, http.MethodPost, "/api/warehouses", map[string]string{"name": "Baru"}, http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := env.do(tc.method, tc.path, tc.token, tc.body)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateUserChecksRole(t *testing.T) {
+	env := newTestEnv(t)
+	models.CreateRole(nil, "hrd", "Kelola user", []string{models.PermUserManage, models.PermTransactionCreate, models.PermProductView, models.PermReportView})
+	models.Register(nil, "hrd1", "hrd12345", "hrd", &env.pusat.ID)
+	hrd, _ := env.login("hrd1", "hrd12345")
+
+	tests := []struct {
+		name string
+		role string
+		want int
+	}{
+		{"unknown role", "kasir", http.StatusBadRequest},
+		{"escalate to admin", models.AdminRole, http.StatusForbidden},
+		{"role within own permissions", "user", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := env.do(http.MethodPost, "/api/users", hrd, map[string]interface{}{
+				"username": "baru-" + tc.role, "password": "rahasia1", "role": tc.role, "warehouse_id": env.pusat.ID,
+			})
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+
+	// Role tanpa warehouse.all wajib diberi gudang
+	rec := env.do(http.MethodPost, "/api/users", hrd, map[string]interface{}{
+		"username": "tanpa-gudang", "password": "rahasia1", "role": "user",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("user without warehouse: status = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDeleteUserChecksRole(t *testing.T) {
+	env := newTestEnv(t)
+	models.CreateRole(nil, "hrd", "Kelola user", []string{models.PermUserManage, models.PermTransactionCreate, models.PermProductView, models.PermReportView})
+	models.Register(nil, "hrd1", "hrd12345", "hrd", &env.pusat.ID)
+	admin2, _ := models.Register(nil, "admin2", "admin123", models.AdminRole, nil)
+	hrd, _ := env.login("hrd1", "hrd12345")
+	admin, _ := env.login("admin", "admin123")
+
+	ids := map[string]int{}
+	users, _ := models.GetAllUsers()
+	for _, u := range users {
+		ids[u.Username] = u.ID
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		id    int
+		want  int
+	}{
+		{"user manager deleting admin", hrd, ids["admin2"], http.StatusForbidden},
+		{"user manager deleting cashier", hrd, ids["kasir1"], http.StatusOK},
+		{"admin deleting itself", admin, ids["admin"], http.StatusForbidden},
+		{"admin deleting another admin", admin, admin2.ID, http.StatusOK},
+	}
+	for _, tc := range tests {
+		rec := env.do(http.MethodDelete, "/api/users", tc.token, map[string]int{"id": tc.id})
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	env := newTestEnv(t)
+	access, refresh := env.login("kasir1", "user123")
+
+	rec := env.do(http.MethodPost, "/api/refresh", "", map[string]string{"refresh_token": refresh})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var rotated struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&rotated)
+
+	// Refresh token lama tidak bisa dipakai ulang setelah rotasi, dan pemakaian ulangnya
+	// mencabut seluruh sesi: refresh token dan access token terbaru ikut ditolak
+	rec = env.do(http.MethodPost, "/api/refresh", "", map[string]string{"refresh_token": refresh})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status = %d, want 401", rec.Code)
+	}
+	rec = env.do(http.MethodPost, "/api/refresh", "", map[string]string{"refresh_token": rotated.RefreshToken})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("newer refresh token after reuse: status = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/products", rotated.AccessToken, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token after reuse: status = %d, want 401", rec.Code)
+	}
+
+	access, _ = env.login("kasir1", "user123")
+	if rec := env.do(http.MethodPost, "/api/logout", access, nil); rec.Code != http.StatusOK {
+		t.Fatalf("logout: status = %d", rec.Code)
+	}
+	if rec := env.do(http.MethodGet, "/api/products", access, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestUserChangesRevokeTokens(t *testing.T) {
+	env := newTestEnv(t)
+	admin, _ := env.login("admin", "admin123")
+	models.Register(nil, "kasir2", "user12345", "user", &env.pusat.ID)
+
+	ids := map[string]int{}
+	users, _ := models.GetAllUsers()
+	for _, u := range users {
+		ids[u.Username] = u.ID
+	}
+
+	// Ganti password mencabut semua sesi user, termasuk access token yang masih berlaku
+	kasir1, refresh1 := env.login("kasir1", "user123")
+	if err := models.UpdatePassword(nil, ids["kasir1"], "baru12345"); err != nil {
+		t.Fatal(err)
+	}
+	if rec := env.do(http.MethodGet, "/api/products", kasir1, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access after password change: status = %d, want 401", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/refresh", "", map[string]string{"refresh_token": refresh1}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after password change: status = %d, want 401", rec.Code)
+	}
+
+	// User yang dihapus tidak bisa memakai access token-nya lagi
+	kasir2, _ := env.login("kasir2", "user12345")
+	if rec := env.do(http.MethodDelete, "/api/users", admin, map[string]int{"id": ids["kasir2"]}); rec.Code != http.StatusOK {
+		t.Fatalf("delete user: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.do(http.MethodGet, "/api/products", kasir2, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access after delete: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestTokensSurviveRestart(t *testing.T) {
+	env := newTestEnv(t)
+	access, _ := env.login("kasir1", "user123")
+
+	// Server restart dengan secret yang sama: access token lama tetap berlaku
+	if err := initTokens(); err != nil {
+		t.Fatal(err)
+	}
+	if rec := env.do(http.MethodGet, "/api/products", access, nil); rec.Code != http.StatusOK {
+		t.Errorf("after restart: status = %d, want 200", rec.Code)
+	}
+
+	config.App.Auth.TokenSecret = ""
+	t.Cleanup(func() { config.App.Auth.TokenSecret = testTokenSecret })
+	if err := initTokens(); err == nil {
+		t.Error("expected API to refuse starting without auth.token_secret")
+	}
+}
+
+func TestCategoriesEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	admin, _ := env.login("admin", "admin123")
+	kasir, _ := env.login("kasir1", "user123")
+
+	rec := env.do(http.MethodPost, "/api/categories", admin, map[string]interface{}{"name": "Minuman"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create category: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var minuman models.Category
+	json.NewDecoder(rec.Body).Decode(&minuman)
+
+	rec = env.do(http.MethodPost, "/api/categories", admin, map[string]interface{}{"name": "Teh", "parent_id": minuman.ID})
+	var teh models.Category
+	json.NewDecoder(rec.Body).Decode(&teh)
+	if teh.Path != "Minuman > Teh" {
+		t.Errorf("path = %q", teh.Path)
+	}
+	if rec := env.do(http.MethodPost, "/api/categories", admin, map[string]interface{}{"name": "Minuman"}); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate category: status %d, want 409", rec.Code)
+	}
+	if rec := env.do(http.MethodPost, "/api/categories", kasir, map[string]interface{}{"name": "Snack"}); rec.Code != http.StatusForbidden {
+		t.Errorf("kasir create category: status %d, want 403", rec.Code)
+	}
+
+	p := env.product("Teh Botol", 10, env.pusat.ID)
+	env.product("Aqua 600ml", 10, env.pusat.ID)
+	rec = env.do(http.MethodPut, "/api/products", admin, map[string]interface{}{
+		"id": p.ID, "name": p.Name, "purchase_price": p.PurchasePrice, "selling_price": p.SellingPrice,
+		"stock": p.Stock, "category_id": teh.ID,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set category: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(http.MethodGet, "/api/products?category_id="+strconv.Itoa(minuman.ID), kasir, nil)
+	var resp struct {
+		Data []models.Product `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || len(resp.Data) != 1 || resp.Data[0].ID != p.ID {
+		t.Errorf("filter by parent category: status %d, %+v", rec.Code, resp)
+	}
+}
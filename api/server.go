@@ -2,23 +2,116 @@ package api
 
 import (
 	"fmt"
+	"kasir/config"
+	"kasir/models"
 	"net/http"
 )
 
-// StartServer menjalankan web server
-func StartServer(port string) {
+// StartServer menjalankan web server sesuai pengaturan server di config
+func StartServer(cfg config.ServerConfig) {
+	if err := initTokens(); err != nil {
+		fmt.Printf("❌ Gagal menyiapkan token: %v\n", err)
+		return
+	}
+
+	server := &http.Server{
+		Addr:         cfg.Listen,
+		Handler:      newRouter(),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	fmt.Printf("🚀 Server berjalan di %s\n", cfg.Listen)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Printf("❌ Failed to start server: %v\n", err)
+	}
+}
+
+// newRouter mendaftarkan semua endpoint API beserta middleware-nya
+func newRouter() http.Handler {
 	mux := http.NewServeMux()
 
 	// Register handlers
 	mux.HandleFunc("/api/login", handleLogin)
-	mux.HandleFunc("/api/products", authMiddleware(handleProducts))
-	mux.HandleFunc("/api/transactions", authMiddleware(handleTransactions))
-	mux.HandleFunc("/api/users", authMiddleware(handleUsers))
-	mux.HandleFunc("/api/warehouses", authMiddleware(handleWarehouses))
-	mux.HandleFunc("/api/reports", authMiddleware(handleReports))
-
-	fmt.Printf("🚀 Server berjalan di port %s\n", port)
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
-		fmt.Printf("❌ Failed to start server: %v\n", err)
+	mux.HandleFunc("/api/refresh", handleRefresh)
+	mux.HandleFunc("/api/logout", authMiddleware(handleLogout))
+	mux.HandleFunc("/api/products", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:    {models.PermProductView, models.PermProductManage},
+		http.MethodPost:   {models.PermProductManage},
+		http.MethodPut:    {models.PermProductManage, models.PermStockAdjust},
+		http.MethodDelete: {models.PermProductManage},
+	}, handleProducts)))
+	mux.HandleFunc("/api/products/stock", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet: {models.PermProductView, models.PermProductManage},
+		http.MethodPut: {models.PermProductManage, models.PermStockAdjust},
+	}, handleProductStock)))
+	mux.HandleFunc("/api/batches", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:  {models.PermProductView, models.PermProductManage, models.PermStockAdjust},
+		http.MethodPost: {models.PermStockAdjust},
+	}, handleBatches)))
+	mux.HandleFunc("/api/batches/write-off", authMiddleware(requirePermissions(allMethods(models.PermStockAdjust), handleBatchWriteOff)))
+	catalogPermissions := methodPermissions{
+		http.MethodGet:    {models.PermProductView, models.PermProductManage},
+		http.MethodPost:   {models.PermProductManage},
+		http.MethodPut:    {models.PermProductManage},
+		http.MethodDelete: {models.PermProductManage},
 	}
+	mux.HandleFunc("/api/categories", authMiddleware(requirePermissions(catalogPermissions, handleCategories)))
+	mux.HandleFunc("/api/brands", authMiddleware(requirePermissions(catalogPermissions, handleBrands)))
+	mux.HandleFunc("/api/transactions", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:  {models.PermReportView, models.PermTransactionCreate},
+		http.MethodPost: {models.PermTransactionCreate},
+	}, handleTransactions)))
+	mux.HandleFunc("/api/transactions/void", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodPost: {models.PermTransactionCreate, models.PermTransactionVoid},
+	}, handleTransactionVoid)))
+	mux.HandleFunc("/api/returns", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:  {models.PermTransactionReturn, models.PermReportView},
+		http.MethodPost: {models.PermTransactionReturn},
+	}, handleReturns)))
+	transferPermissions := allMethods(models.PermStockTransfer)
+	mux.HandleFunc("/api/transfers", authMiddleware(requirePermissions(transferPermissions, handleTransfers)))
+	mux.HandleFunc("/api/transfers/send", authMiddleware(requirePermissions(transferPermissions, handleTransferSend)))
+	mux.HandleFunc("/api/transfers/receive", authMiddleware(requirePermissions(transferPermissions, handleTransferReceive)))
+	mux.HandleFunc("/api/opnames", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:    {models.PermStockOpname, models.PermOpnameApprove},
+		http.MethodPost:   {models.PermStockOpname},
+		http.MethodDelete: {models.PermStockOpname},
+	}, handleOpnames)))
+	mux.HandleFunc("/api/opnames/count", authMiddleware(requirePermissions(allMethods(models.PermStockOpname), handleOpnameCount)))
+	mux.HandleFunc("/api/opnames/approve", authMiddleware(requirePermissions(allMethods(models.PermOpnameApprove), handleOpnameApprove)))
+	mux.HandleFunc("/api/suppliers", authMiddleware(requirePermissions(allMethods(models.PermPurchaseManage), handleSuppliers)))
+	mux.HandleFunc("/api/purchase-orders", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet:    {models.PermPurchaseManage, models.PermPurchaseReceive},
+		http.MethodPost:   {models.PermPurchaseManage},
+		http.MethodDelete: {models.PermPurchaseManage},
+	}, handlePurchaseOrders)))
+	mux.HandleFunc("/api/purchase-orders/receive", authMiddleware(requirePermissions(allMethods(models.PermPurchaseReceive), handlePurchaseReceive)))
+	mux.HandleFunc("/api/users", authMiddleware(requirePermissions(allMethods(models.PermUserManage), handleUsers)))
+	mux.HandleFunc("/api/warehouses", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodPost:   {models.PermWarehouseManage},
+		http.MethodPut:    {models.PermWarehouseManage},
+		http.MethodDelete: {models.PermWarehouseManage},
+	}, handleWarehouses)))
+	mux.HandleFunc("/api/reports", authMiddleware(requirePermissions(allMethods(models.PermReportView), handleReports)))
+	mux.HandleFunc("/api/reports/categories", authMiddleware(requirePermissions(allMethods(models.PermReportView), handleCategoryReport)))
+	mux.HandleFunc("/api/reports/products", authMiddleware(requirePermissions(allMethods(models.PermReportView), handleProductReport)))
+	mux.HandleFunc("/api/reports/stock-card", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet: {models.PermReportView, models.PermStockAdjust},
+	}, handleStockCard)))
+	mux.HandleFunc("/api/reports/outstanding-purchases", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet: {models.PermPurchaseManage, models.PermPurchaseReceive},
+	}, handleOutstandingPurchases)))
+	mux.HandleFunc("/api/reports/supplier-purchases", authMiddleware(requirePermissions(allMethods(models.PermPurchaseManage), handleSupplierPurchases)))
+	mux.HandleFunc("/api/alerts/low-stock", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet: {models.PermProductView, models.PermProductManage, models.PermStockAdjust, models.PermPurchaseManage},
+	}, handleLowStock)))
+	mux.HandleFunc("/api/reports/expiring-batches", authMiddleware(requirePermissions(methodPermissions{
+		http.MethodGet: {models.PermProductView, models.PermProductManage, models.PermStockAdjust, models.PermPurchaseManage},
+	}, handleExpiringBatches)))
+	mux.HandleFunc("/api/audit", authMiddleware(requirePermissions(allMethods(models.PermAuditView), handleAudit)))
+	mux.HandleFunc("/api/roles", authMiddleware(requirePermissions(allMethods(models.PermRoleManage), handleRoles)))
+
+	return mux
 }
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"kasir/config"
+	"kasir/models"
+	"strings"
+	"time"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// tokenSecret adalah kunci HMAC untuk menandatangani access token (auth.token_secret di config)
+var tokenSecret []byte
+
+// accessClaims adalah isi access token. Data user ikut disimpan sehingga middleware tidak perlu
+// memuat user dan role di setiap request; yang dicek ke database hanya status sesinya.
+type accessClaims struct {
+	SessionID   string   `json:"sid"`
+	UserID      int      `json:"uid"`
+	Username    string   `json:"usr"`
+	Role        string   `json:"role"`
+	WarehouseID *int     `json:"wid,omitempty"`
+	Permissions []string `json:"perms"`
+	ExpiresAt   int64    `json:"exp"`
+}
+
+// initTokens menyiapkan secret penandatangan token
+func initTokens() error {
+	// Secret acak per proses membuat semua client logout setiap kali server restart
+	secret := config.App.Auth.TokenSecret
+	if secret == "" {
+		return errors.New("auth.token_secret (API_TOKEN_SECRET) wajib diisi untuk mode API, buat dengan: openssl rand -hex 32")
+	}
+	tokenSecret = []byte(secret)
+	return nil
+}
+
+// issueTokens membuat sesi baru dan mengembalikan access token + refresh token
+func issueTokens(user *models.User) (string, string, error) {
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = models.CreateSession(sessionID, user.ID, hashToken(refreshToken), time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := signAccessToken(user, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// refreshTokens menukar refresh token dengan pasangan token baru (rotasi)
+func refreshTokens(refreshToken string) (string, string, *models.User, error) {
+	oldHash := hashToken(refreshToken)
+	session, err := models.GetActiveSessionByRefreshHash(oldHash)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	user, err := models.GetUserByID(session.UserID)
+	if err != nil {
+		return "", "", nil, errors.New("user tidak ditemukan")
+	}
+	if err := user.CheckWarehouseAssigned(); err != nil {
+		return "", "", nil, err
+	}
+
+	newRefresh, err := randomToken(32)
+	if err != nil {
+		return "", "", nil, err
+	}
+	err = models.RotateSessionRefresh(session.ID, oldHash, hashToken(newRefresh), time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	accessToken, err := signAccessToken(user, session.ID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return accessToken, newRefresh, user, nil
+}
+
+// revokeSession mencabut sesi di database (logout)
+func revokeSession(sessionID string) error {
+	return models.RevokeSession(sessionID)
+}
+
+func signAccessToken(user *models.User, sessionID string) (string, error) {
+	claims := accessClaims{
+		SessionID:   sessionID,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		WarehouseID: user.WarehouseID,
+		Permissions: user.Permissions,
+		ExpiresAt:   time.Now().Add(accessTokenTTL).Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + signature(body), nil
+}
+
+// parseAccessToken memverifikasi tanda tangan, masa berlaku, dan status sesi
+func parseAccessToken(token string) (*accessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("token tidak valid")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(signature(parts[0]))) {
+		return nil, errors.New("token tidak valid")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("token tidak valid")
+	}
+	var claims accessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("token tidak valid")
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token sudah kedaluwarsa")
+	}
+	// Sesi dicek ke database agar logout, penggantian password, dan penghapusan user (termasuk dari
+	// CLI di proses lain) langsung berlaku tanpa menunggu access token kedaluwarsa
+	active, err := models.IsSessionActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errors.New("sesi sudah berakhir")
+	}
+	return &claims, nil
+}
+
+func (c *accessClaims) user() *models.User {
+	return &models.User{
+		ID:          c.UserID,
+		Username:    c.Username,
+		Role:        c.Role,
+		WarehouseID: c.WarehouseID,
+		Permissions: c.Permissions,
+	}
+}
+
+func signature(body string) string {
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
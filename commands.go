@@ -0,0 +1,1983 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"kasir/handlers"
+	"kasir/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Exit code subcommand non-interaktif (untuk script dan cron)
+const (
+	exitOK        = 0 // berhasil
+	exitError     = 1 // gagal saat dijalankan (database, data tidak valid, sebagian baris import gagal)
+	exitUsage     = 2 // perintah atau flag salah
+	exitAuth      = 3 // login gagal atau akun terkunci
+	exitForbidden = 4 // user tidak punya permission untuk perintah ini
+)
+
+// command adalah satu subcommand, contoh "product list". setup mendaftarkan flag
+// khusus perintah dan mengembalikan fungsi yang dijalankan setelah flag di-parse dan login.
+type command struct {
+	usage   string
+	allowed func(u *models.User) bool
+	setup   func(fs *flag.FlagSet) func(c *cmdContext) int
+}
+
+var commands = map[string]command{
+	"product list":            {"product list [--warehouse ID] [--search TEKS|SKU|BARCODE] [--category ID] [--brand ID]", canViewProducts, setupProductList},
+	"product add":             {"product add --name NAMA --purchase HARGA --price HARGA [--sku SKU] [--barcode KODE] [--stock N] [--warehouse ID] [--category ID] [--brand ID]", canManageProducts, setupProductAdd},
+	"product variant":         {"product variant --parent ID --variant NAMA [--sku SKU] [--barcode KODE] [--purchase HARGA] [--price HARGA] [--stock N] [--warehouse ID]", canManageProducts, setupProductVariant},
+	"product stock":           {"product stock --id ID [--warehouse ID] [--stock N] [--min N] [--purchase HARGA] [--price HARGA] [--master-price]", canAdjustStock, setupProductStock},
+	"product import":          {"product import --file FILE.xlsx", canTransferExcel, setupProductImport},
+	"product export":          {"product export [--warehouse ID]", canTransferExcel, setupProductExport},
+	"transfer list":           {"transfer list [--warehouse ID] [--status draft|sent|received]", canTransferStock, setupTransferList},
+	"transfer show":           {"transfer show --id ID", canTransferStock, setupTransferShow},
+	"transfer create":         {"transfer create --from ID --to ID --items PRODUK:QTY[,PRODUK:QTY...] [--note TEKS] [--send]", canTransferStock, setupTransferCreate},
+	"transfer send":           {"transfer send --id ID", canTransferStock, setupTransferSend},
+	"transfer receive":        {"transfer receive --id ID [--received PRODUK:QTY[,PRODUK:QTY...]]", canTransferStock, setupTransferReceive},
+	"transfer cancel":         {"transfer cancel --id ID", canTransferStock, setupTransferCancel},
+	"opname list":             {"opname list [--warehouse ID] [--status open|approved]", canViewOpname, setupOpnameList},
+	"opname show":             {"opname show --id ID", canViewOpname, setupOpnameShow},
+	"opname create":           {"opname create [--warehouse ID] [--note TEKS]", canCountStock, setupOpnameCreate},
+	"opname count":            {"opname count --id ID --counts PRODUK:QTY[,PRODUK:QTY...]", canCountStock, setupOpnameCount},
+	"opname export":           {"opname export --id ID", canCountStock, setupOpnameExport},
+	"opname import":           {"opname import --id ID --file FILE.xlsx", canCountStock, setupOpnameImport},
+	"opname approve":          {"opname approve --id ID", canApproveOpname, setupOpnameApprove},
+	"opname cancel":           {"opname cancel --id ID", canCountStock, setupOpnameCancel},
+	"supplier list":           {"supplier list", canManagePurchases, setupSupplierList},
+	"supplier add":            {"supplier add --name NAMA [--phone TELEPON] [--address ALAMAT]", canManagePurchases, setupSupplierAdd},
+	"purchase list":           {"purchase list [--warehouse ID] [--supplier ID] [--status open|partial|received] [--outstanding]", canViewPurchases, setupPurchaseList},
+	"purchase show":           {"purchase show --id ID", canViewPurchases, setupPurchaseShow},
+	"purchase create":         {"purchase create --supplier ID [--warehouse ID] --items PRODUK:QTY[@HARGA][,...] [--note TEKS]", canManagePurchases, setupPurchaseCreate},
+	"purchase receive":        {"purchase receive --id ID [--received PRODUK:QTY[@HARGA][,...]] [--expiry PRODUK:DD-MM-YYYY[@BATCH][,...]] [--note TEKS]", canReceivePurchases, setupPurchaseReceive},
+	"purchase cancel":         {"purchase cancel --id ID", canManagePurchases, setupPurchaseCancel},
+	"batch list":              {"batch list --product ID [--warehouse ID]", canViewProducts, setupBatchList},
+	"batch add":               {"batch add --product ID [--warehouse ID] --batch NO --expiry DD-MM-YYYY --qty N", canAdjustStock, setupBatchAdd},
+	"batch write-off":         {"batch write-off --id ID | --expired [--warehouse ID]", canAdjustStock, setupBatchWriteOff},
+	"transaction void":        {"transaction void --trx TRX-ID --reason TEKS --approver USERNAME [--approver-password PASS | --approver-password-stdin]", canVoidTransactions, setupTransactionVoid},
+	"return list":             {"return list --trx TRX-ID", canViewReturns, setupReturnList},
+	"return create":           {"return create --trx TRX-ID --items PRODUK:QTY[,PRODUK:QTY...] --reason TEKS", canReturnSales, setupReturnCreate},
+	"report daily":            {"report daily [--date DD-MM-YYYY] [--warehouse ID]", canViewReport, setupReportDaily},
+	"report stock-card":       {"report stock-card --product ID [--warehouse ID] [--from DD-MM-YYYY] [--to DD-MM-YYYY]", canViewStockCard, setupReportStockCard},
+	"report low-stock":        {"report low-stock [--warehouse ID]", canViewProducts, setupReportLowStock},
+	"report expiring":         {"report expiring [--days N] [--warehouse ID]", canViewProducts, setupReportExpiring},
+	"report outstanding-po":   {"report outstanding-po [--warehouse ID]", canViewPurchases, setupReportOutstandingPO},
+	"report supplier-history": {"report supplier-history --supplier ID [--warehouse ID] [--from DD-MM-YYYY] [--to DD-MM-YYYY]", canManagePurchases, setupReportSupplierHistory},
+	"user add":                {"user add --username NAMA --role ROLE [--warehouse ID] [--new-password PASS | --password-stdin]", canManageUsers, setupUserAdd},
+	"warehouse list":          {"warehouse list", nil, setupWarehouseList},
+}
+
+// cmdContext adalah user yang sudah login beserta tujuan output satu subcommand
+type cmdContext struct {
+	user   *models.User
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// isCommand mengecek apakah argumen program adalah subcommand non-interaktif (selain migrate)
+func isCommand(args []string) bool {
+	return len(args) > 0 && args[0] != "migrate"
+}
+
+// runCommand menjalankan subcommand non-interaktif dan mengembalikan exit code.
+// Setiap perintah login dulu lewat --user/--password atau KASIR_USER/KASIR_PASSWORD.
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		printCommandUsage(stderr)
+		return exitUsage
+	}
+
+	name := args[0] + " " + args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "❌ Perintah tidak dikenal: %s\n\n", name)
+		printCommandUsage(stderr)
+		return exitUsage
+	}
+
+	c := &cmdContext{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("kasir "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Penggunaan: kasir %s [--json] [--user NAMA --password PASS]\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	username := fs.String("user", os.Getenv("KASIR_USER"), "username login (default $KASIR_USER)")
+	password := fs.String("password", "", "password login (default $KASIR_PASSWORD)")
+	fs.BoolVar(&c.json, "json", false, "output dalam format JSON")
+	run := cmd.setup(fs)
+
+	if err := fs.Parse(args[2:]); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "❌ Argumen tidak dikenal: %s\n", strings.Join(fs.Args(), " "))
+		return exitUsage
+	}
+
+	if *password == "" {
+		*password = os.Getenv("KASIR_PASSWORD")
+	}
+	if *username == "" || *password == "" {
+		fmt.Fprintln(stderr, "❌ Login diperlukan: gunakan --user dan --password, atau KASIR_USER dan KASIR_PASSWORD")
+		return exitAuth
+	}
+	user, err := models.Authenticate(*username, *password, "")
+	if err != nil {
+		fmt.Fprintf(stderr, "❌ %v\n", err)
+		return exitAuth
+	}
+	c.user = user
+
+	if cmd.allowed != nil && !cmd.allowed(user) {
+		fmt.Fprintf(stderr, "❌ Role %s tidak punya akses untuk 'kasir %s'\n", user.Role, name)
+		return exitForbidden
+	}
+
+	return run(c)
+}
+
+func printCommandUsage(w io.Writer) {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Penggunaan: kasir [-config FILE] <perintah> [flag]")
+	fmt.Fprintln(w, "\nPerintah:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  kasir %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "  kasir migrate up | down [n] | status | seed [nama]")
+	fmt.Fprintln(w, "\nFlag bersama: --json, --user/--password (atau KASIR_USER/KASIR_PASSWORD)")
+	fmt.Fprintln(w, "Exit code: 0 berhasil, 1 gagal, 2 perintah salah, 3 login gagal, 4 tidak punya akses")
+}
+
+// Permission per perintah, sama dengan menu interaktif
+func canViewProducts(u *models.User) bool {
+	return u.Can(models.PermProductView) || u.Can(models.PermProductManage)
+}
+
+func canManageProducts(u *models.User) bool {
+	return u.Can(models.PermProductManage)
+}
+
+func canAdjustStock(u *models.User) bool {
+	return u.Can(models.PermStockAdjust) || u.Can(models.PermProductManage)
+}
+
+func canTransferStock(u *models.User) bool {
+	return u.Can(models.PermStockTransfer)
+}
+
+func canCountStock(u *models.User) bool {
+	return u.Can(models.PermStockOpname)
+}
+
+func canApproveOpname(u *models.User) bool {
+	return u.Can(models.PermOpnameApprove)
+}
+
+func canViewOpname(u *models.User) bool {
+	return canCountStock(u) || canApproveOpname(u)
+}
+
+func canManagePurchases(u *models.User) bool {
+	return u.Can(models.PermPurchaseManage)
+}
+
+func canReceivePurchases(u *models.User) bool {
+	return u.Can(models.PermPurchaseReceive)
+}
+
+func canViewPurchases(u *models.User) bool {
+	return canManagePurchases(u) || canReceivePurchases(u)
+}
+
+func canTransferExcel(u *models.User) bool {
+	return u.Can(models.PermProductManage) && u.HasAllWarehouses()
+}
+
+func canVoidTransactions(u *models.User) bool {
+	return u.Can(models.PermTransactionCreate) || u.Can(models.PermTransactionVoid)
+}
+
+func canReturnSales(u *models.User) bool {
+	return u.Can(models.PermTransactionReturn)
+}
+
+func canViewReturns(u *models.User) bool {
+	return canReturnSales(u) || u.Can(models.PermReportView)
+}
+
+func canViewReport(u *models.User) bool {
+	return u.Can(models.PermReportView)
+}
+
+func canViewStockCard(u *models.User) bool {
+	return u.Can(models.PermReportView) || u.Can(models.PermStockAdjust)
+}
+
+func canManageUsers(u *models.User) bool {
+	return u.Can(models.PermUserManage)
+}
+
+// fail menulis pesan error ke stderr dan mengembalikan exit code
+func (c *cmdContext) fail(code int, format string, args ...interface{}) int {
+	fmt.Fprintf(c.stderr, "❌ "+format+"\n", args...)
+	return code
+}
+
+// writeJSON menulis v sebagai JSON ke stdout
+func (c *cmdContext) writeJSON(v interface{}) int {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	return exitOK
+}
+
+// table membuat writer kolom rata kiri untuk output tabel
+func (c *cmdContext) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+}
+
+// warehouseFlag membaca --warehouse (0 = tidak diisi) dan membatasinya ke gudang user
+func (c *cmdContext) warehouseFlag(id int) (*int, error) {
+	var requested *int
+	if id > 0 {
+		requested = &id
+	}
+	return c.user.ScopeWarehouse(requested)
+}
+
+func setupProductList(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+	search := fs.String("search", "", "cari nama produk, atau SKU/barcode persis")
+	category := fs.Int("category", 0, "ID kategori, termasuk subkategorinya")
+	brand := fs.Int("brand", 0, "ID merek")
+
+	return func(c *cmdContext) int {
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		filter := models.ProductFilter{
+			Search:      *search,
+			WarehouseID: warehouseID,
+			CategoryID:  optionalID(*category),
+			BrandID:     optionalID(*brand),
+		}
+
+		// Ambil semua halaman hasil pencarian
+		const pageSize = 100
+		var products []models.Product
+		for page := 1; ; page++ {
+			batch, total, err := models.GetProducts(page, pageSize, filter)
+			if err != nil {
+				return c.fail(exitError, "%v", err)
+			}
+			products = append(products, batch...)
+			if len(batch) == 0 || len(products) >= total {
+				break
+			}
+		}
+
+		if c.json {
+			if products == nil {
+				products = []models.Product{}
+			}
+			return c.writeJSON(products)
+		}
+
+		categoryPaths, err := models.CategoryPathMap()
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tSKU\tBARCODE\tNAMA\tKATEGORI\tHARGA BELI\tHARGA JUAL\tSTOK\tGUDANG")
+		for _, p := range products {
+			categoryPath := ""
+			if p.CategoryID != nil {
+				categoryPath = categoryPaths[*p.CategoryID]
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%.0f\t%.0f\t%d\t%d\n",
+				p.ID, orDash(p.SKU), orDash(p.Barcode), p.DisplayName(), orDash(categoryPath), p.PurchasePrice, p.SellingPrice, p.Stock, p.WarehouseID)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupProductAdd(fs *flag.FlagSet) func(c *cmdContext) int {
+	name := fs.String("name", "", "nama produk (wajib)")
+	sku := fs.String("sku", "", "SKU, unik di katalog")
+	barcode := fs.String("barcode", "", "barcode, unik di katalog")
+	purchase := fs.Float64("purchase", 0, "harga beli (wajib)")
+	price := fs.Float64("price", 0, "harga jual (wajib)")
+	stock := fs.Int("stock", 0, "stok awal")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (wajib untuk user dengan akses semua gudang)")
+	category := fs.Int("category", 0, "ID kategori")
+	brand := fs.Int("brand", 0, "ID merek")
+
+	return func(c *cmdContext) int {
+		if strings.TrimSpace(*name) == "" || *purchase <= 0 || *price <= 0 || *stock < 0 {
+			return c.fail(exitUsage, "--name, --purchase dan --price wajib diisi (harga > 0, stok >= 0)")
+		}
+
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if warehouseID == nil {
+			return c.fail(exitUsage, "--warehouse wajib diisi")
+		}
+		if w, _ := models.GetWarehouseByID(*warehouseID); w == nil {
+			return c.fail(exitError, "gudang dengan ID %d tidak ditemukan", *warehouseID)
+		}
+
+		p, err := models.CreateProduct(c.user, models.Product{
+			Name:          strings.TrimSpace(*name),
+			SKU:           *sku,
+			Barcode:       *barcode,
+			PurchasePrice: *purchase,
+			SellingPrice:  *price,
+			Stock:         *stock,
+			WarehouseID:   *warehouseID,
+			CategoryID:    optionalID(*category),
+			BrandID:       optionalID(*brand),
+		})
+		if errors.Is(err, models.ErrDuplicateCode) {
+			return c.fail(exitError, "gagal menambah produk: %v (untuk menambah stok ke gudang lain gunakan 'kasir product stock')", err)
+		}
+		if err != nil {
+			return c.fail(exitError, "gagal menambah produk: %v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(p)
+		}
+		fmt.Fprintf(c.stdout, "✅ Produk '%s' berhasil ditambahkan dengan ID: %d\n", p.Name, p.ID)
+		return exitOK
+	}
+}
+
+func setupProductVariant(fs *flag.FlagSet) func(c *cmdContext) int {
+	parent := fs.Int("parent", 0, "ID produk induk (wajib)")
+	variant := fs.String("variant", "", "nama varian, contoh \"L, Merah\" (wajib)")
+	sku := fs.String("sku", "", "SKU, unik di katalog")
+	barcode := fs.String("barcode", "", "barcode, unik di katalog")
+	purchase := fs.Float64("purchase", 0, "harga beli (default: harga beli induk)")
+	price := fs.Float64("price", 0, "harga jual (default: harga jual induk)")
+	stock := fs.Int("stock", 0, "stok awal")
+	warehouse := fs.Int("warehouse", 0, "ID gudang stok awal (default: gudang user, atau satu-satunya gudang induk)")
+
+	return func(c *cmdContext) int {
+		if *parent <= 0 || strings.TrimSpace(*variant) == "" || *purchase < 0 || *price < 0 || *stock < 0 {
+			return c.fail(exitUsage, "--parent dan --variant wajib diisi (harga dan stok >= 0)")
+		}
+
+		p, err := models.GetProductByID(*parent)
+		if err != nil {
+			return c.fail(exitError, "produk induk dengan ID %d tidak ditemukan", *parent)
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		v := models.Product{
+			Variant:       *variant,
+			SKU:           *sku,
+			Barcode:       *barcode,
+			PurchasePrice: *purchase,
+			SellingPrice:  *price,
+			Stock:         *stock,
+		}
+		if warehouseID != nil {
+			v.WarehouseID = *warehouseID
+		}
+		created, err := models.CreateVariant(c.user, p.ID, v)
+		if err != nil {
+			return c.fail(exitError, "gagal menambah varian: %v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(created)
+		}
+		fmt.Fprintf(c.stdout, "✅ Varian '%s' berhasil ditambahkan dengan ID: %d\n", created.DisplayName(), created.ID)
+		return exitOK
+	}
+}
+
+func setupProductStock(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID produk (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (wajib untuk mengubah stok/harga jika punya akses semua gudang)")
+	stock := fs.Int("stock", -1, "stok di gudang tersebut")
+	minStock := fs.Int("min", -1, "stok minimum di gudang tersebut (0 = tanpa peringatan)")
+	purchase := fs.Float64("purchase", -1, "harga beli khusus gudang")
+	price := fs.Float64("price", -1, "harga jual khusus gudang")
+	masterPrice := fs.Bool("master-price", false, "hapus harga khusus gudang, kembali ke harga master")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		product, err := models.GetProductByID(*id)
+		if err != nil {
+			return c.fail(exitError, "produk dengan ID %d tidak ditemukan", *id)
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		changePrice := *purchase >= 0 || *price >= 0 || *masterPrice
+		if *stock >= 0 || *minStock >= 0 || changePrice {
+			if warehouseID == nil {
+				return c.fail(exitUsage, "--warehouse wajib diisi untuk mengubah stok atau harga")
+			}
+			if (*stock >= 0 || *minStock >= 0) && !c.user.Can(models.PermStockAdjust) {
+				return c.fail(exitForbidden, "role %s tidak punya permission %s", c.user.Role, models.PermStockAdjust)
+			}
+			if changePrice && !c.user.Can(models.PermProductManage) {
+				return c.fail(exitForbidden, "role %s tidak punya permission %s", c.user.Role, models.PermProductManage)
+			}
+
+			st := models.ProductStock{ProductID: product.ID, WarehouseID: *warehouseID}
+			stocks, err := models.GetProductStocks(product.ID)
+			if err != nil {
+				return c.fail(exitError, "%v", err)
+			}
+			for _, current := range stocks {
+				if current.WarehouseID == *warehouseID {
+					st = current
+				}
+			}
+			if *stock >= 0 {
+				st.Stock = *stock
+			}
+			if *masterPrice {
+				st.PurchasePrice, st.SellingPrice = nil, nil
+			}
+			if *purchase >= 0 {
+				st.PurchasePrice = purchase
+			}
+			if *price >= 0 {
+				st.SellingPrice = price
+			}
+			if *stock >= 0 || changePrice {
+				if err := models.SetProductStock(c.user, st); err != nil {
+					return c.fail(exitError, "gagal menyimpan stok: %v", err)
+				}
+			}
+			if *minStock >= 0 {
+				if err := models.SetReorderPoint(c.user, product.ID, *warehouseID, *minStock); err != nil {
+					return c.fail(exitError, "gagal menyimpan stok minimum: %v", err)
+				}
+			}
+		}
+
+		// Tampilkan stok produk di semua gudang yang boleh diakses
+		products, err := models.GetProductWarehouses(product.ID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		if scope := c.user.GetWarehouseID(); !c.user.HasAllWarehouses() {
+			var own []models.Product
+			for _, p := range products {
+				if p.WarehouseID == scope {
+					own = append(own, p)
+				}
+			}
+			products = own
+		}
+
+		if c.json {
+			if products == nil {
+				products = []models.Product{}
+			}
+			return c.writeJSON(products)
+		}
+		minimums := make(map[int]int)
+		if stocks, err := models.GetProductStocks(product.ID); err == nil {
+			for _, st := range stocks {
+				minimums[st.WarehouseID] = st.ReorderPoint
+			}
+		}
+		tw := c.table()
+		fmt.Fprintf(tw, "GUDANG\tNAMA GUDANG\tHARGA BELI\tHARGA JUAL\tSTOK\tMINIMUM\n")
+		for _, p := range products {
+			fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.0f\t%d\t%d\n", p.WarehouseID, warehouseLabel(p.WarehouseID), p.PurchasePrice, p.SellingPrice, p.Stock, minimums[p.WarehouseID])
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+// warehouseLabel mengembalikan nama gudang, atau "-" jika tidak ditemukan
+func warehouseLabel(id int) string {
+	if w, _ := models.GetWarehouseByID(id); w != nil {
+		return w.Name
+	}
+	return "-"
+}
+
+func setupProductImport(fs *flag.FlagSet) func(c *cmdContext) int {
+	file := fs.String("file", "", "file Excel (.xlsx) berisi nama, harga beli, harga jual, stok, gudang ID")
+
+	return func(c *cmdContext) int {
+		if *file == "" {
+			return c.fail(exitUsage, "--file wajib diisi")
+		}
+
+		result, err := handlers.ImportProductsExcel(c.user, *file)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		code := exitOK
+		if len(result.Skipped) > 0 {
+			code = exitError
+		}
+
+		if c.json {
+			if result.Skipped == nil {
+				result.Skipped = []string{}
+			}
+			if jsonCode := c.writeJSON(result); jsonCode != exitOK {
+				return jsonCode
+			}
+			return code
+		}
+
+		for _, msg := range result.Skipped {
+			fmt.Fprintf(c.stderr, "⚠️  %s\n", msg)
+		}
+		fmt.Fprintf(c.stdout, "✅ Import selesai: %d berhasil, %d gagal\n", result.Success, len(result.Skipped))
+		return code
+	}
+}
+
+func setupProductExport(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang)")
+
+	return func(c *cmdContext) int {
+		if *warehouse > 0 {
+			if w, _ := models.GetWarehouseByID(*warehouse); w == nil {
+				return c.fail(exitError, "gudang dengan ID %d tidak ditemukan", *warehouse)
+			}
+		}
+
+		filePath, count, err := handlers.ExportProductsExcel(c.user, *warehouse)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(map[string]interface{}{"file": filePath, "count": count})
+		}
+		fmt.Fprintf(c.stdout, "✅ Berhasil export %d produk ke %s\n", count, filePath)
+		return exitOK
+	}
+}
+
+func setupReportDaily(fs *flag.FlagSet) func(c *cmdContext) int {
+	dateStr := fs.String("date", "", "tanggal laporan DD-MM-YYYY (default: hari ini)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+
+	return func(c *cmdContext) int {
+		date := time.Now()
+		if *dateStr != "" {
+			parsed, err := time.ParseInLocation("02-01-2006", *dateStr, time.Local)
+			if err != nil {
+				return c.fail(exitUsage, "format tanggal tidak valid, gunakan DD-MM-YYYY")
+			}
+			date = parsed
+		}
+
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		transactions, err := models.GetWarehouseTransactionsByDate(date, warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		summary, err := models.GetWarehouseDailySummary(date, warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		returns, err := models.GetWarehouseReturnsByDate(date, warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		voided, err := models.GetWarehouseVoidedTransactionsByDate(date, warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if transactions == nil {
+				transactions = []models.Transaction{}
+			}
+			if returns == nil {
+				returns = []models.SalesReturn{}
+			}
+			if voided == nil {
+				voided = []models.Transaction{}
+			}
+			return c.writeJSON(map[string]interface{}{
+				"date":         date.Format("02-01-2006"),
+				"warehouse_id": warehouseID,
+				"summary":      summary,
+				"transactions": transactions,
+				"returns":      returns,
+				"voided":       voided,
+			})
+		}
+
+		fmt.Fprintf(c.stdout, "Laporan penjualan %s\n", date.Format("02-01-2006"))
+		fmt.Fprintf(c.stdout, "Jumlah transaksi: %d\nPenjualan kotor : %.0f\nRetur           : %.0f (%d retur)\nPenjualan bersih: %.0f\nProfit bersih   : %.0f\n\n",
+			summary.Transactions, summary.GrossSales, summary.ReturnTotal, summary.Returns, summary.NetSales, summary.NetProfit)
+
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tWAKTU\tGUDANG\tKASIR\tTOTAL\tPROFIT")
+		for _, t := range transactions {
+			fmt.Fprintf(tw, "TRX-%06d\t%s\t%d\t%s\t%.0f\t%.0f\n", t.ID, t.CreatedAt.Format("15:04"), t.WarehouseID, t.CashierName, t.Total, t.Profit)
+		}
+		for _, r := range returns {
+			fmt.Fprintf(tw, "RET-%06d\t%s\t%d\t%s\t%.0f\t%.0f\n", r.ID, r.CreatedAt.Format("15:04"), r.WarehouseID, r.CashierName, -r.Total, -r.Profit)
+		}
+		tw.Flush()
+
+		if len(voided) > 0 {
+			fmt.Fprintln(c.stdout, "\nTransaksi di-void (tidak dihitung):")
+			tw = c.table()
+			fmt.Fprintln(tw, "ID\tWAKTU\tGUDANG\tKASIR\tTOTAL\tDISETUJUI\tALASAN")
+			for _, t := range voided {
+				fmt.Fprintf(tw, "TRX-%06d\t%s\t%d\t%s\t%.0f\t%s\t%s\n", t.ID, t.CreatedAt.Format("15:04"), t.WarehouseID,
+					t.CashierName, t.Total, t.VoidedBy, t.VoidReason)
+			}
+			tw.Flush()
+		}
+		return exitOK
+	}
+}
+
+func setupReportStockCard(fs *flag.FlagSet) func(c *cmdContext) int {
+	product := fs.Int("product", 0, "ID produk (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+	fromStr := fs.String("from", "", "tanggal awal DD-MM-YYYY (default: awal bulan ini)")
+	toStr := fs.String("to", "", "tanggal akhir DD-MM-YYYY (default: hari ini)")
+
+	return func(c *cmdContext) int {
+		if *product <= 0 {
+			return c.fail(exitUsage, "--product wajib diisi")
+		}
+		now := time.Now()
+		from, err := parseDateFlag(*fromStr, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+		if err != nil {
+			return c.fail(exitUsage, "--from: %v", err)
+		}
+		to, err := parseDateFlag(*toStr, now)
+		if err != nil {
+			return c.fail(exitUsage, "--to: %v", err)
+		}
+
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		card, err := models.GetStockCard(*product, warehouseID, from, to)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if card.Movements == nil {
+				card.Movements = []models.StockMovement{}
+			}
+			return c.writeJSON(card)
+		}
+
+		scope := "semua gudang"
+		if warehouseID != nil {
+			scope = warehouseLabel(*warehouseID)
+		}
+		fmt.Fprintf(c.stdout, "Kartu stok %s (ID %d), %s, %s s/d %s\n", card.Product.DisplayName(), card.Product.ID,
+			scope, card.From.Format("02-01-2006"), card.To.Format("02-01-2006"))
+		fmt.Fprintf(c.stdout, "Saldo awal: %d\n\n", card.Opening)
+
+		tw := c.table()
+		fmt.Fprintln(tw, "WAKTU\tGUDANG\tJENIS\tMASUK\tKELUAR\tSALDO\tUSER\tREFERENSI")
+		for _, m := range card.Movements {
+			in, out := "-", "-"
+			if m.Quantity > 0 {
+				in = strconv.Itoa(m.Quantity)
+			} else {
+				out = strconv.Itoa(-m.Quantity)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", m.CreatedAt.Format("02-01-2006 15:04"), warehouseLabel(m.WarehouseID),
+				m.Type, in, out, m.Balance, m.Username, orDash(m.Reference))
+		}
+		tw.Flush()
+		fmt.Fprintf(c.stdout, "\nMasuk: %d, keluar: %d, saldo akhir: %d\n", card.In, card.Out, card.Closing)
+		return exitOK
+	}
+}
+
+func setupTransferList(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang asal atau tujuan (default: semua gudang yang boleh diakses)")
+	status := fs.String("status", "", "filter status: draft, sent, received")
+
+	return func(c *cmdContext) int {
+		switch *status {
+		case "", models.TransferDraft, models.TransferSent, models.TransferReceived:
+		default:
+			return c.fail(exitUsage, "--status harus draft, sent, atau received")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		transfers, err := models.GetWarehouseTransfers(warehouseID, *status)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if transfers == nil {
+				transfers = []models.Transfer{}
+			}
+			return c.writeJSON(transfers)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tDARI\tKE\tSTATUS\tDIBUAT\tOLEH")
+		for _, t := range transfers {
+			fmt.Fprintf(tw, "TRF-%06d\t%s\t%s\t%s\t%s\t%s\n", t.ID, warehouseLabel(t.FromWarehouseID), warehouseLabel(t.ToWarehouseID),
+				t.Status, t.CreatedAt.Format("02-01-2006 15:04"), orDash(t.CreatedBy))
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupTransferShow(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID transfer (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		t, err := models.GetTransfer(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		return c.writeTransfer(t)
+	}
+}
+
+func setupTransferCreate(fs *flag.FlagSet) func(c *cmdContext) int {
+	from := fs.Int("from", 0, "ID gudang asal (default: gudang user)")
+	to := fs.Int("to", 0, "ID gudang tujuan (wajib)")
+	itemsFlag := fs.String("items", "", "daftar ID_PRODUK:JUMLAH dipisah koma, contoh 12:10,15:4 (wajib)")
+	note := fs.String("note", "", "catatan transfer")
+	send := fs.Bool("send", false, "langsung kirim (stok gudang asal dikurangi)")
+
+	return func(c *cmdContext) int {
+		quantities, err := parseQuantities(*itemsFlag)
+		if err != nil || len(quantities) == 0 || *to <= 0 {
+			return c.fail(exitUsage, "--to dan --items (ID_PRODUK:JUMLAH) wajib diisi")
+		}
+		source, err := c.warehouseFlag(*from)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if source == nil {
+			return c.fail(exitUsage, "--from wajib diisi")
+		}
+
+		var items []models.TransferItem
+		for _, q := range quantities {
+			items = append(items, models.TransferItem{ProductID: q.id, Quantity: q.qty})
+		}
+		t, err := models.CreateTransfer(c.user, *source, *to, *note, items)
+		if err != nil {
+			return c.fail(exitError, "gagal membuat transfer: %v", err)
+		}
+		if *send {
+			sent, err := models.SendTransfer(c.user, t.ID)
+			if err != nil {
+				return c.fail(exitError, "draft TRF-%06d dibuat tapi gagal dikirim: %v", t.ID, err)
+			}
+			t = sent
+		}
+		return c.writeTransfer(t)
+	}
+}
+
+func setupTransferSend(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID transfer draft (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		t, err := models.SendTransfer(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "gagal mengirim transfer: %v", err)
+		}
+		return c.writeTransfer(t)
+	}
+}
+
+func setupTransferReceive(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID transfer dalam perjalanan (wajib)")
+	receivedFlag := fs.String("received", "", "jumlah diterima per produk ID_PRODUK:JUMLAH dipisah koma (default: semua diterima penuh)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		quantities, err := parseQuantities(*receivedFlag)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		received := make(map[int]int, len(quantities))
+		for _, q := range quantities {
+			received[q.id] = q.qty
+		}
+
+		t, err := models.ReceiveTransfer(c.user, *id, received)
+		if err != nil {
+			return c.fail(exitError, "gagal menerima transfer: %v", err)
+		}
+		return c.writeTransfer(t)
+	}
+}
+
+func setupTransferCancel(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID transfer draft (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		if err := models.CancelTransfer(c.user, *id); err != nil {
+			return c.fail(exitError, "gagal membatalkan transfer: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(map[string]interface{}{"id": *id, "cancelled": true})
+		}
+		fmt.Fprintf(c.stdout, "✅ Draft transfer TRF-%06d dibatalkan\n", *id)
+		return exitOK
+	}
+}
+
+// writeTransfer menulis detail transfer (JSON atau tabel item dengan selisih penerimaan)
+func (c *cmdContext) writeTransfer(t *models.Transfer) int {
+	if c.json {
+		return c.writeJSON(t)
+	}
+	fmt.Fprintf(c.stdout, "TRF-%06d %s -> %s [%s]\n", t.ID, warehouseLabel(t.FromWarehouseID), warehouseLabel(t.ToWarehouseID), t.Status)
+	tw := c.table()
+	fmt.Fprintln(tw, "PRODUK ID\tNAMA\tDIKIRIM\tDITERIMA\tSELISIH")
+	for _, item := range t.Items {
+		received, shortage := "-", "-"
+		if t.Status == models.TransferReceived {
+			received, shortage = strconv.Itoa(item.Received), strconv.Itoa(item.Shortage())
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", item.ProductID, item.ProductName, item.Quantity, received, shortage)
+	}
+	tw.Flush()
+	return exitOK
+}
+
+func setupOpnameList(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+	status := fs.String("status", "", "filter status: open, approved")
+
+	return func(c *cmdContext) int {
+		switch *status {
+		case "", models.OpnameOpen, models.OpnameApproved:
+		default:
+			return c.fail(exitUsage, "--status harus open atau approved")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		opnames, err := models.GetWarehouseOpnames(warehouseID, *status)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if opnames == nil {
+				opnames = []models.StockOpname{}
+			}
+			return c.writeJSON(opnames)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tGUDANG\tSTATUS\tDIBUAT\tOLEH\tDISETUJUI")
+		for _, o := range opnames {
+			approved := "-"
+			if o.ApprovedAt != nil {
+				approved = o.ApprovedAt.Format("02-01-2006 15:04") + " " + o.ApprovedBy
+			}
+			fmt.Fprintf(tw, "OPN-%06d\t%s\t%s\t%s\t%s\t%s\n", o.ID, warehouseLabel(o.WarehouseID), o.Status,
+				o.CreatedAt.Format("02-01-2006 15:04"), orDash(o.CreatedBy), approved)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupOpnameShow(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		o, err := models.GetOpname(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		return c.writeOpname(o)
+	}
+}
+
+func setupOpnameCreate(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang yang dihitung (default: gudang user)")
+	note := fs.String("note", "", "catatan stock opname")
+
+	return func(c *cmdContext) int {
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if warehouseID == nil {
+			return c.fail(exitUsage, "--warehouse wajib diisi")
+		}
+
+		o, err := models.CreateOpname(c.user, *warehouseID, *note)
+		if err != nil {
+			return c.fail(exitError, "gagal membuat stock opname: %v", err)
+		}
+		return c.writeOpname(o)
+	}
+}
+
+func setupOpnameCount(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+	countsFlag := fs.String("counts", "", "hasil hitung fisik ID_PRODUK:JUMLAH dipisah koma, contoh 12:48,15:0 (wajib)")
+
+	return func(c *cmdContext) int {
+		quantities, err := parseQuantities(*countsFlag)
+		if err != nil || len(quantities) == 0 || *id <= 0 {
+			return c.fail(exitUsage, "--id dan --counts (ID_PRODUK:JUMLAH) wajib diisi")
+		}
+		counts := make(map[int]int, len(quantities))
+		for _, q := range quantities {
+			counts[q.id] = q.qty
+		}
+
+		o, err := models.RecordOpnameCounts(c.user, *id, counts)
+		if err != nil {
+			return c.fail(exitError, "gagal menyimpan hasil hitung: %v", err)
+		}
+		return c.writeOpname(o)
+	}
+}
+
+func setupOpnameExport(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		filePath, err := handlers.ExportOpnameExcel(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(map[string]interface{}{"id": *id, "file": filePath})
+		}
+		fmt.Fprintf(c.stdout, "✅ Lembar hitung OPN-%06d disimpan ke %s\n", *id, filePath)
+		return exitOK
+	}
+}
+
+func setupOpnameImport(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+	file := fs.String("file", "", "lembar hitung Excel (.xlsx) dengan kolom Jumlah Fisik terisi (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 || *file == "" {
+			return c.fail(exitUsage, "--id dan --file wajib diisi")
+		}
+
+		result, o, err := handlers.ImportOpnameExcel(c.user, *id, *file)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		code := exitOK
+		if len(result.Skipped) > 0 {
+			code = exitError
+		}
+
+		if c.json {
+			if result.Skipped == nil {
+				result.Skipped = []string{}
+			}
+			if jsonCode := c.writeJSON(result); jsonCode != exitOK {
+				return jsonCode
+			}
+			return code
+		}
+
+		for _, msg := range result.Skipped {
+			fmt.Fprintf(c.stderr, "⚠️  %s\n", msg)
+		}
+		fmt.Fprintf(c.stdout, "✅ Import selesai: %d produk dihitung, %d gagal, %d belum dihitung\n",
+			result.Success, len(result.Skipped), o.Uncounted())
+		return code
+	}
+}
+
+func setupOpnameApprove(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		o, err := models.ApproveOpname(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "gagal menyetujui stock opname: %v", err)
+		}
+		return c.writeOpname(o)
+	}
+}
+
+func setupOpnameCancel(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID stock opname (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		if err := models.CancelOpname(c.user, *id); err != nil {
+			return c.fail(exitError, "gagal membatalkan stock opname: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(map[string]interface{}{"id": *id, "cancelled": true})
+		}
+		fmt.Fprintf(c.stdout, "✅ Stock opname OPN-%06d dibatalkan\n", *id)
+		return exitOK
+	}
+}
+
+// writeOpname menulis detail stock opname (JSON atau tabel item dengan selisih dan nilainya)
+func (c *cmdContext) writeOpname(o *models.StockOpname) int {
+	if c.json {
+		return c.writeJSON(o)
+	}
+	fmt.Fprintf(c.stdout, "OPN-%06d %s [%s]\n", o.ID, warehouseLabel(o.WarehouseID), o.Status)
+	tw := c.table()
+	fmt.Fprintln(tw, "PRODUK ID\tNAMA\tSISTEM\tFISIK\tSELISIH\tNILAI")
+	for _, item := range o.Items {
+		counted, variance, value := "-", "-", "-"
+		if item.Counted != nil {
+			counted, variance = strconv.Itoa(*item.Counted), strconv.Itoa(item.Variance())
+			value = fmt.Sprintf("%.0f", item.VarianceValue())
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\n", item.ProductID, item.ProductName, item.SystemStock, counted, variance, value)
+	}
+	tw.Flush()
+	fmt.Fprintf(c.stdout, "\nNilai selisih: %.0f, belum dihitung: %d produk\n", o.VarianceValue(), o.Uncounted())
+	return exitOK
+}
+
+func setupSupplierList(fs *flag.FlagSet) func(c *cmdContext) int {
+	return func(c *cmdContext) int {
+		suppliers, err := models.GetAllSuppliers()
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if suppliers == nil {
+				suppliers = []models.Supplier{}
+			}
+			return c.writeJSON(suppliers)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tNAMA\tTELEPON\tALAMAT")
+		for _, s := range suppliers {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.ID, s.Name, orDash(s.Phone), orDash(s.Address))
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupSupplierAdd(fs *flag.FlagSet) func(c *cmdContext) int {
+	name := fs.String("name", "", "nama supplier (wajib)")
+	phone := fs.String("phone", "", "nomor telepon")
+	address := fs.String("address", "", "alamat")
+
+	return func(c *cmdContext) int {
+		if strings.TrimSpace(*name) == "" {
+			return c.fail(exitUsage, "--name wajib diisi")
+		}
+		sup, err := models.CreateSupplier(c.user, *name, *phone, *address)
+		if err != nil {
+			return c.fail(exitError, "gagal menambah supplier: %v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(sup)
+		}
+		fmt.Fprintf(c.stdout, "✅ Supplier '%s' ditambahkan dengan ID %d\n", sup.Name, sup.ID)
+		return exitOK
+	}
+}
+
+func setupPurchaseList(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang penerima (default: semua gudang yang boleh diakses)")
+	supplier := fs.Int("supplier", 0, "ID supplier")
+	status := fs.String("status", "", "filter status: open, partial, received")
+	outstanding := fs.Bool("outstanding", false, "hanya PO yang masih menunggu barang")
+
+	return func(c *cmdContext) int {
+		switch *status {
+		case "", models.PurchaseOpen, models.PurchasePartial, models.PurchaseReceived:
+		default:
+			return c.fail(exitUsage, "--status harus open, partial, atau received")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		orders, err := models.GetPurchaseOrders(c.user, models.PurchaseFilter{
+			WarehouseID: warehouseID, SupplierID: *supplier, Status: *status, Outstanding: *outstanding,
+		})
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if orders == nil {
+				orders = []models.PurchaseOrder{}
+			}
+			return c.writeJSON(orders)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tSUPPLIER\tGUDANG\tSTATUS\tNILAI\tBELUM DITERIMA\tDIBUAT\tOLEH")
+		for _, o := range orders {
+			fmt.Fprintf(tw, "PO-%06d\t%s\t%s\t%s\t%.0f\t%.0f\t%s\t%s\n", o.ID, o.SupplierName, warehouseLabel(o.WarehouseID),
+				o.Status, o.Total(), o.OutstandingValue(), o.CreatedAt.Format("02-01-2006 15:04"), orDash(o.CreatedBy))
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupPurchaseShow(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID purchase order (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		o, err := models.GetPurchaseOrder(c.user, *id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		return c.writePurchaseOrder(o)
+	}
+}
+
+func setupPurchaseCreate(fs *flag.FlagSet) func(c *cmdContext) int {
+	supplier := fs.Int("supplier", 0, "ID supplier (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang penerima (default: gudang user)")
+	itemsFlag := fs.String("items", "", "daftar ID_PRODUK:JUMLAH[@HARGA] dipisah koma, contoh 12:24@2400,15:12 (wajib; tanpa harga = harga beli gudang)")
+	note := fs.String("note", "", "catatan purchase order")
+
+	return func(c *cmdContext) int {
+		lines, err := parsePurchaseLines(*itemsFlag)
+		if err != nil || len(lines) == 0 || *supplier <= 0 {
+			return c.fail(exitUsage, "--supplier dan --items (ID_PRODUK:JUMLAH[@HARGA]) wajib diisi")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if warehouseID == nil {
+			return c.fail(exitUsage, "--warehouse wajib diisi")
+		}
+
+		var items []models.PurchaseOrderItem
+		for _, l := range lines {
+			price := l.price
+			if price == 0 {
+				if p, err := models.GetProductInWarehouse(l.id, *warehouseID); err == nil {
+					price = p.PurchasePrice
+				} else if p, err := models.GetProductByID(l.id); err == nil {
+					price = p.PurchasePrice
+				}
+			}
+			items = append(items, models.PurchaseOrderItem{ProductID: l.id, Quantity: l.qty, Price: price})
+		}
+		o, err := models.CreatePurchaseOrder(c.user, *supplier, *warehouseID, *note, items)
+		if err != nil {
+			return c.fail(exitError, "gagal membuat purchase order: %v", err)
+		}
+		return c.writePurchaseOrder(o)
+	}
+}
+
+func setupPurchaseReceive(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID purchase order (wajib)")
+	receivedFlag := fs.String("received", "", "barang datang ID_PRODUK:JUMLAH[@HARGA] dipisah koma (default: semua sisa pesanan dengan harga PO)")
+	expiryFlag := fs.String("expiry", "", "tanggal kedaluwarsa ID_PRODUK:DD-MM-YYYY[@NO_BATCH] dipisah koma; barangnya dicatat sebagai batch")
+	note := fs.String("note", "", "catatan penerimaan, contoh nomor faktur supplier")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		lines, err := parsePurchaseLines(*receivedFlag)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		expiries, err := parseExpiries(*expiryFlag)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		var items []models.GoodsReceiptItem
+		for _, l := range lines {
+			items = append(items, models.GoodsReceiptItem{ProductID: l.id, Quantity: l.qty, Price: l.price})
+		}
+		if len(items) == 0 && len(expiries) > 0 {
+			// Batch butuh daftar barang, jadi "semua sisa pesanan" diuraikan di sini
+			o, err := models.GetPurchaseOrder(c.user, *id)
+			if err != nil {
+				return c.fail(exitError, "gagal menerima barang: %v", err)
+			}
+			for _, line := range o.Items {
+				if line.Outstanding() > 0 {
+					items = append(items, models.GoodsReceiptItem{ProductID: line.ProductID, Quantity: line.Outstanding()})
+				}
+			}
+		}
+		for productID, e := range expiries {
+			found := false
+			for i := range items {
+				if items[i].ProductID == productID {
+					expiry := e.date
+					items[i].ExpiryDate, items[i].BatchNo = &expiry, e.batchNo
+					found = true
+				}
+			}
+			if !found {
+				return c.fail(exitUsage, "produk %d di --expiry tidak ikut diterima", productID)
+			}
+		}
+
+		gr, err := models.ReceivePurchaseOrder(c.user, *id, items, *note)
+		if err != nil {
+			return c.fail(exitError, "gagal menerima barang: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(gr)
+		}
+		fmt.Fprintf(c.stdout, "GR-%06d untuk PO-%06d dari %s ke %s\n", gr.ID, gr.OrderID, gr.SupplierName, warehouseLabel(gr.WarehouseID))
+		tw := c.table()
+		fmt.Fprintln(tw, "PRODUK ID\tNAMA\tDITERIMA\tHARGA BELI")
+		for _, item := range gr.Items {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%.0f\n", item.ProductID, item.ProductName, item.Quantity, item.Price)
+		}
+		tw.Flush()
+		fmt.Fprintf(c.stdout, "\nNilai penerimaan: %.0f\n", gr.Total)
+		return exitOK
+	}
+}
+
+func setupPurchaseCancel(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID purchase order yang belum menerima barang (wajib)")
+
+	return func(c *cmdContext) int {
+		if *id <= 0 {
+			return c.fail(exitUsage, "--id wajib diisi")
+		}
+		if err := models.CancelPurchaseOrder(c.user, *id); err != nil {
+			return c.fail(exitError, "gagal membatalkan purchase order: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(map[string]interface{}{"id": *id, "cancelled": true})
+		}
+		fmt.Fprintf(c.stdout, "✅ Purchase order PO-%06d dibatalkan\n", *id)
+		return exitOK
+	}
+}
+
+// writePurchaseOrder menulis detail purchase order (JSON atau tabel item dengan sisa pesanan)
+func (c *cmdContext) writePurchaseOrder(o *models.PurchaseOrder) int {
+	if c.json {
+		return c.writeJSON(o)
+	}
+	fmt.Fprintf(c.stdout, "PO-%06d %s -> %s [%s]\n", o.ID, o.SupplierName, warehouseLabel(o.WarehouseID), o.Status)
+	tw := c.table()
+	fmt.Fprintln(tw, "PRODUK ID\tNAMA\tPESAN\tHARGA BELI\tDITERIMA\tSISA")
+	for _, item := range o.Items {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%.0f\t%d\t%d\n", item.ProductID, item.ProductName, item.Quantity, item.Price, item.Received, item.Outstanding())
+	}
+	tw.Flush()
+	fmt.Fprintf(c.stdout, "\nNilai PO: %.0f, belum diterima: %.0f\n", o.Total(), o.OutstandingValue())
+	return exitOK
+}
+
+func setupBatchList(fs *flag.FlagSet) func(c *cmdContext) int {
+	product := fs.Int("product", 0, "ID produk (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (wajib jika punya akses semua gudang)")
+
+	return func(c *cmdContext) int {
+		if *product <= 0 {
+			return c.fail(exitUsage, "--product wajib diisi")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if warehouseID == nil {
+			return c.fail(exitUsage, "--warehouse wajib diisi")
+		}
+		batches, err := models.GetBatches(*product, *warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if batches == nil {
+				batches = []models.StockBatch{}
+			}
+			return c.writeJSON(batches)
+		}
+		now := time.Now()
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tBATCH\tKEDALUWARSA\tSISA HARI\tMASUK\tSISA\tREFERENSI")
+		for _, b := range batches {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%s\n", b.ID, b.BatchNo, b.ExpiryDate.Format("02-01-2006"),
+				b.DaysLeft(now), b.Quantity, b.Remaining, orDash(b.Reference))
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupBatchAdd(fs *flag.FlagSet) func(c *cmdContext) int {
+	product := fs.Int("product", 0, "ID produk (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (wajib jika punya akses semua gudang)")
+	batchNo := fs.String("batch", "", "nomor batch (wajib)")
+	expiryFlag := fs.String("expiry", "", "tanggal kedaluwarsa DD-MM-YYYY (wajib)")
+	qty := fs.Int("qty", 0, "jumlah barang, menambah stok gudang (wajib)")
+
+	return func(c *cmdContext) int {
+		if *product <= 0 || *batchNo == "" || *expiryFlag == "" || *qty <= 0 {
+			return c.fail(exitUsage, "--product, --batch, --expiry, dan --qty wajib diisi")
+		}
+		expiry, err := parseDateFlag(*expiryFlag, time.Time{})
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if warehouseID == nil {
+			return c.fail(exitUsage, "--warehouse wajib diisi")
+		}
+
+		b, err := models.AddStockBatch(c.user, *product, *warehouseID, *batchNo, expiry, *qty)
+		if err != nil {
+			return c.fail(exitError, "gagal menambah batch: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(b)
+		}
+		fmt.Fprintf(c.stdout, "✅ Batch %s (ID %d) %d unit, kedaluwarsa %s, ditambahkan ke %s\n", b.BatchNo, b.ID, b.Quantity,
+			b.ExpiryDate.Format("02-01-2006"), warehouseLabel(b.WarehouseID))
+		return exitOK
+	}
+}
+
+func setupBatchWriteOff(fs *flag.FlagSet) func(c *cmdContext) int {
+	id := fs.Int("id", 0, "ID batch yang sisanya dimusnahkan")
+	expired := fs.Bool("expired", false, "musnahkan semua batch yang sudah kedaluwarsa")
+	warehouse := fs.Int("warehouse", 0, "ID gudang untuk --expired (default: semua gudang yang boleh diakses)")
+
+	return func(c *cmdContext) int {
+		if (*id > 0) == *expired {
+			return c.fail(exitUsage, "isi salah satu: --id atau --expired")
+		}
+
+		var batches []models.StockBatch
+		if *expired {
+			warehouseID, err := c.warehouseFlag(*warehouse)
+			if err != nil {
+				return c.fail(exitForbidden, "%v", err)
+			}
+			if batches, err = models.WriteOffExpiredBatches(c.user, warehouseID); err != nil {
+				return c.fail(exitError, "gagal memusnahkan batch: %v", err)
+			}
+		} else {
+			b, err := models.WriteOffBatch(c.user, *id)
+			if err != nil {
+				return c.fail(exitError, "gagal memusnahkan batch: %v", err)
+			}
+			batches = append(batches, *b)
+		}
+
+		if c.json {
+			if batches == nil {
+				batches = []models.StockBatch{}
+			}
+			return c.writeJSON(batches)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tGUDANG\tPRODUK ID\tBATCH\tKEDALUWARSA\tDIMUSNAHKAN")
+		for _, b := range batches {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%d\n", b.ID, warehouseLabel(b.WarehouseID), b.ProductID, b.BatchNo,
+				b.ExpiryDate.Format("02-01-2006"), b.Remaining)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+// parseTransactionFlag menerima nomor struk "TRX-000123" atau ID angka saja
+func parseTransactionFlag(s string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "TRX-"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("nomor transaksi '%s' tidak valid, gunakan TRX-000123 atau ID", s)
+	}
+	return id, nil
+}
+
+func setupTransactionVoid(fs *flag.FlagSet) func(c *cmdContext) int {
+	trx := fs.String("trx", "", "nomor transaksi, contoh TRX-000123 (wajib)")
+	reason := fs.String("reason", "", "alasan void (wajib)")
+	approver := fs.String("approver", "", "username supervisor yang menyetujui (wajib)")
+	approverPassword := fs.String("approver-password", "", "password supervisor")
+	passwordStdin := fs.Bool("approver-password-stdin", false, "baca password supervisor dari baris pertama stdin")
+
+	return func(c *cmdContext) int {
+		password := *approverPassword
+		if *passwordStdin {
+			line, err := bufio.NewReader(c.stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return c.fail(exitUsage, "gagal membaca password supervisor dari stdin")
+			}
+			password = strings.TrimRight(line, "\r\n")
+		}
+		if *trx == "" || strings.TrimSpace(*reason) == "" || *approver == "" || password == "" {
+			return c.fail(exitUsage, "--trx, --reason, --approver dan --approver-password (atau --approver-password-stdin) wajib diisi")
+		}
+		id, err := parseTransactionFlag(*trx)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		if _, err := models.GetTransaction(c.user, id); err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		approvedBy, err := models.AuthorizeVoid(*approver, password, "")
+		if err != nil {
+			return c.fail(exitForbidden, "persetujuan ditolak: %v", err)
+		}
+		t, err := models.VoidTransaction(c.user, approvedBy, id, *reason)
+		if err != nil {
+			return c.fail(exitError, "gagal void transaksi: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(t)
+		}
+		fmt.Fprintf(c.stdout, "✅ TRX-%06d di-void (disetujui %s), stok %d produk dikembalikan\n", t.ID, t.VoidedBy, len(t.Items))
+		return exitOK
+	}
+}
+
+func setupReturnList(fs *flag.FlagSet) func(c *cmdContext) int {
+	trx := fs.String("trx", "", "nomor transaksi, contoh TRX-000123 (wajib)")
+
+	return func(c *cmdContext) int {
+		if *trx == "" {
+			return c.fail(exitUsage, "--trx wajib diisi")
+		}
+		id, err := parseTransactionFlag(*trx)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		items, err := models.GetReturnableItems(c.user, id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+		returns, err := models.GetTransactionReturns(c.user, id)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if returns == nil {
+				returns = []models.SalesReturn{}
+			}
+			return c.writeJSON(map[string]interface{}{
+				"transaction_id": id,
+				"items":          items,
+				"returns":        returns,
+			})
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "PRODUK ID\tPRODUK\tHARGA\tTERJUAL\tDIRETUR\tSISA")
+		for _, item := range items {
+			fmt.Fprintf(tw, "%d\t%s\t%.0f\t%d\t%d\t%d\n", item.ProductID, item.ProductName, item.SellingPrice,
+				item.Quantity, item.Returned, item.Returnable())
+		}
+		tw.Flush()
+		if len(returns) > 0 {
+			fmt.Fprintln(c.stdout)
+			tw = c.table()
+			fmt.Fprintln(tw, "RETUR\tTANGGAL\tKASIR\tDIKEMBALIKAN\tALASAN")
+			for _, r := range returns {
+				fmt.Fprintf(tw, "RET-%06d\t%s\t%s\t%.0f\t%s\n", r.ID, r.CreatedAt.Format("02-01-2006 15:04"),
+					orDash(r.CashierName), r.Total, r.Reason)
+			}
+			tw.Flush()
+		}
+		return exitOK
+	}
+}
+
+func setupReturnCreate(fs *flag.FlagSet) func(c *cmdContext) int {
+	trx := fs.String("trx", "", "nomor transaksi, contoh TRX-000123 (wajib)")
+	itemsFlag := fs.String("items", "", "barang yang diretur, ID_PRODUK:JUMLAH dipisah koma (wajib)")
+	reason := fs.String("reason", "", "alasan retur (wajib)")
+
+	return func(c *cmdContext) int {
+		if *trx == "" || *itemsFlag == "" || strings.TrimSpace(*reason) == "" {
+			return c.fail(exitUsage, "--trx, --items, dan --reason wajib diisi")
+		}
+		id, err := parseTransactionFlag(*trx)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		quantities, err := parseQuantities(*itemsFlag)
+		if err != nil {
+			return c.fail(exitUsage, "%v", err)
+		}
+		var items []models.ReturnItem
+		for _, q := range quantities {
+			items = append(items, models.ReturnItem{ProductID: q.id, Quantity: q.qty})
+		}
+
+		r, err := models.CreateReturn(c.user, id, items, *reason)
+		if err != nil {
+			return c.fail(exitError, "gagal memproses retur: %v", err)
+		}
+		if c.json {
+			return c.writeJSON(r)
+		}
+		fmt.Fprintf(c.stdout, "✅ Retur RET-%06d untuk TRX-%06d dicatat, uang dikembalikan %.0f\n", r.ID, r.TransactionID, r.Total)
+		tw := c.table()
+		fmt.Fprintln(tw, "PRODUK ID\tPRODUK\tJUMLAH\tSUBTOTAL")
+		for _, item := range r.Items {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%.0f\n", item.ProductID, item.ProductName, item.Quantity, item.Subtotal)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupReportLowStock(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+
+	return func(c *cmdContext) int {
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		items, err := models.GetLowStock(warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if items == nil {
+				items = []models.LowStockItem{}
+			}
+			return c.writeJSON(items)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "GUDANG\tID\tPRODUK\tSKU\tSTOK\tMINIMUM\tDIPESAN")
+		for _, item := range items {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%d\t%d\n", warehouseLabel(item.WarehouseID), item.ProductID, item.ProductName,
+				orDash(item.SKU), item.Stock, item.ReorderPoint, item.OnOrder)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupReportExpiring(fs *flag.FlagSet) func(c *cmdContext) int {
+	days := fs.Int("days", 30, "batch yang kedaluwarsa dalam N hari ke depan, termasuk yang sudah lewat")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (default: semua gudang yang boleh diakses)")
+
+	return func(c *cmdContext) int {
+		if *days < 0 {
+			return c.fail(exitUsage, "--days tidak boleh negatif")
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		report, err := models.GetExpiringBatches(warehouseID, *days)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if report == nil {
+				report = []models.ExpiringBatch{}
+			}
+			return c.writeJSON(report)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tGUDANG\tPRODUK\tBATCH\tKEDALUWARSA\tSISA HARI\tSISA\tNILAI")
+		for _, b := range report {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%.0f\n", b.ID, warehouseLabel(b.WarehouseID), b.ProductName,
+				b.BatchNo, b.ExpiryDate.Format("02-01-2006"), b.DaysLeft, b.Remaining, b.Value)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+func setupReportOutstandingPO(fs *flag.FlagSet) func(c *cmdContext) int {
+	warehouse := fs.Int("warehouse", 0, "ID gudang penerima (default: semua gudang yang boleh diakses)")
+
+	return func(c *cmdContext) int {
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		report, err := models.GetOutstandingPurchases(warehouseID)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if report == nil {
+				report = []models.OutstandingPurchase{}
+			}
+			return c.writeJSON(report)
+		}
+		tw := c.table()
+		fmt.Fprintln(tw, "PO\tTANGGAL\tSUPPLIER\tGUDANG\tPRODUK\tPESAN\tDITERIMA\tSISA\tNILAI")
+		var total float64
+		for _, r := range report {
+			fmt.Fprintf(tw, "PO-%06d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%.0f\n", r.OrderID, r.CreatedAt.Format("02-01-2006"), r.SupplierName,
+				warehouseLabel(r.WarehouseID), r.ProductName, r.Ordered, r.Received, r.Outstanding, r.Value)
+			total += r.Value
+		}
+		tw.Flush()
+		fmt.Fprintf(c.stdout, "\nNilai belum diterima: %.0f\n", total)
+		return exitOK
+	}
+}
+
+func setupReportSupplierHistory(fs *flag.FlagSet) func(c *cmdContext) int {
+	supplier := fs.Int("supplier", 0, "ID supplier (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang penerima (default: semua gudang yang boleh diakses)")
+	fromStr := fs.String("from", "", "tanggal awal DD-MM-YYYY (default: awal bulan ini)")
+	toStr := fs.String("to", "", "tanggal akhir DD-MM-YYYY (default: hari ini)")
+
+	return func(c *cmdContext) int {
+		if *supplier <= 0 {
+			return c.fail(exitUsage, "--supplier wajib diisi")
+		}
+		now := time.Now()
+		from, err := parseDateFlag(*fromStr, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+		if err != nil {
+			return c.fail(exitUsage, "--from: %v", err)
+		}
+		to, err := parseDateFlag(*toStr, now)
+		if err != nil {
+			return c.fail(exitUsage, "--to: %v", err)
+		}
+		warehouseID, err := c.warehouseFlag(*warehouse)
+		if err != nil {
+			return c.fail(exitForbidden, "%v", err)
+		}
+
+		history, err := models.GetSupplierPurchases(*supplier, warehouseID, from, to)
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			if history.Receipts == nil {
+				history.Receipts = []models.GoodsReceipt{}
+			}
+			return c.writeJSON(history)
+		}
+		fmt.Fprintf(c.stdout, "Riwayat pembelian %s, %s s/d %s\n\n", history.Supplier.Name,
+			history.From.Format("02-01-2006"), history.To.Format("02-01-2006"))
+		tw := c.table()
+		fmt.Fprintln(tw, "GR\tTANGGAL\tPO\tGUDANG\tNILAI\tOLEH\tCATATAN")
+		for _, r := range history.Receipts {
+			fmt.Fprintf(tw, "GR-%06d\t%s\tPO-%06d\t%s\t%.0f\t%s\t%s\n", r.ID, r.CreatedAt.Format("02-01-2006 15:04"), r.OrderID,
+				warehouseLabel(r.WarehouseID), r.Total, orDash(r.ReceivedBy), orDash(r.Note))
+		}
+		tw.Flush()
+		fmt.Fprintf(c.stdout, "\nTotal pembelian: %.0f (%d penerimaan)\n", history.Total, len(history.Receipts))
+		return exitOK
+	}
+}
+
+// purchaseLine adalah satu ID_PRODUK:JUMLAH[@HARGA] dari flag (price 0 = tidak diisi)
+type purchaseLine struct {
+	id, qty int
+	price   float64
+}
+
+// parsePurchaseLines membaca daftar "ID:JUMLAH[@HARGA]" dipisah koma (string kosong = daftar kosong)
+func parsePurchaseLines(s string) ([]purchaseLine, error) {
+	var result []purchaseLine
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		quantity, price, hasPrice := strings.Cut(part, "@")
+		q, err := parseQuantities(quantity)
+		if err != nil || len(q) != 1 {
+			return nil, fmt.Errorf("format '%s' tidak valid, gunakan ID_PRODUK:JUMLAH[@HARGA]", part)
+		}
+		line := purchaseLine{id: q[0].id, qty: q[0].qty}
+		if hasPrice {
+			if line.price, err = strconv.ParseFloat(strings.TrimSpace(price), 64); err != nil || line.price <= 0 {
+				return nil, fmt.Errorf("harga '%s' tidak valid", price)
+			}
+		}
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// batchExpiry adalah tanggal kedaluwarsa dan nomor batch satu produk dari flag --expiry
+type batchExpiry struct {
+	date    time.Time
+	batchNo string
+}
+
+// parseExpiries membaca daftar "ID:DD-MM-YYYY[@NO_BATCH]" dipisah koma
+func parseExpiries(s string) (map[int]batchExpiry, error) {
+	result := map[int]batchExpiry{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, rest, _ := strings.Cut(part, ":")
+		date, batchNo, _ := strings.Cut(rest, "@")
+		productID, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("format '%s' tidak valid, gunakan ID_PRODUK:DD-MM-YYYY[@NO_BATCH]", part)
+		}
+		expiry, err := parseDateFlag(strings.TrimSpace(date), time.Time{})
+		if err != nil || expiry.IsZero() {
+			return nil, fmt.Errorf("tanggal kedaluwarsa '%s' tidak valid, gunakan DD-MM-YYYY", date)
+		}
+		result[productID] = batchExpiry{date: expiry, batchNo: strings.TrimSpace(batchNo)}
+	}
+	return result, nil
+}
+
+// productQuantity adalah satu pasangan ID_PRODUK:JUMLAH dari flag
+type productQuantity struct{ id, qty int }
+
+// parseQuantities membaca daftar "ID:JUMLAH" dipisah koma (string kosong = daftar kosong)
+func parseQuantities(s string) ([]productQuantity, error) {
+	var result []productQuantity
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, qty, _ := strings.Cut(part, ":")
+		var q productQuantity
+		var err1, err2 error
+		q.id, err1 = strconv.Atoi(strings.TrimSpace(id))
+		q.qty, err2 = strconv.Atoi(strings.TrimSpace(qty))
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("format '%s' tidak valid, gunakan ID_PRODUK:JUMLAH", part)
+		}
+		result = append(result, q)
+	}
+	return result, nil
+}
+
+func setupUserAdd(fs *flag.FlagSet) func(c *cmdContext) int {
+	username := fs.String("username", "", "username user baru (wajib)")
+	role := fs.String("role", "", "role user baru (wajib)")
+	warehouse := fs.Int("warehouse", 0, "ID gudang (wajib untuk role tanpa akses semua gudang)")
+	newPassword := fs.String("new-password", "", "password user baru")
+	passwordStdin := fs.Bool("password-stdin", false, "baca password user baru dari baris pertama stdin")
+
+	return func(c *cmdContext) int {
+		password := *newPassword
+		if *passwordStdin {
+			line, err := bufio.NewReader(c.stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return c.fail(exitUsage, "gagal membaca password dari stdin")
+			}
+			password = strings.TrimRight(line, "\r\n")
+		}
+		if strings.TrimSpace(*username) == "" || *role == "" || password == "" {
+			return c.fail(exitUsage, "--username, --role dan --new-password (atau --password-stdin) wajib diisi")
+		}
+
+		r, err := models.GetRoleByName(*role)
+		if err != nil {
+			return c.fail(exitError, "role '%s' tidak ditemukan", *role)
+		}
+
+		// Role tanpa akses semua gudang wajib terikat ke satu gudang
+		var warehouseID *int
+		if r.Name != models.AdminRole && !containsPermission(r.Permissions, models.PermAllWarehouses) {
+			if *warehouse <= 0 {
+				return c.fail(exitUsage, "--warehouse wajib diisi untuk role %s", r.Name)
+			}
+			if w, _ := models.GetWarehouseByID(*warehouse); w == nil {
+				return c.fail(exitError, "gudang dengan ID %d tidak ditemukan", *warehouse)
+			}
+			warehouseID = warehouse
+		}
+
+		u, err := models.Register(c.user, strings.TrimSpace(*username), password, r.Name, warehouseID)
+		if errors.Is(err, models.ErrRoleNotAllowed) {
+			return c.fail(exitForbidden, "%v", err)
+		}
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		if c.json {
+			return c.writeJSON(u)
+		}
+		fmt.Fprintf(c.stdout, "✅ User '%s' berhasil ditambahkan dengan ID: %d\n", u.Username, u.ID)
+		return exitOK
+	}
+}
+
+func setupWarehouseList(fs *flag.FlagSet) func(c *cmdContext) int {
+	return func(c *cmdContext) int {
+		warehouses, err := models.GetAllWarehouses()
+		if err != nil {
+			return c.fail(exitError, "%v", err)
+		}
+
+		// User yang terikat gudang hanya melihat gudangnya sendiri
+		if !c.user.HasAllWarehouses() {
+			var own []models.Warehouse
+			for _, w := range warehouses {
+				if w.ID == *c.user.WarehouseID {
+					own = append(own, w)
+				}
+			}
+			warehouses = own
+		}
+
+		if c.json {
+			if warehouses == nil {
+				warehouses = []models.Warehouse{}
+			}
+			return c.writeJSON(warehouses)
+		}
+
+		tw := c.table()
+		fmt.Fprintln(tw, "ID\tNAMA\tALAMAT")
+		for _, w := range warehouses {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", w.ID, w.Name, w.Address)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+// parseDateFlag membaca tanggal DD-MM-YYYY dari flag (kosong = def)
+func parseDateFlag(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	date, err := time.ParseInLocation("02-01-2006", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("format tanggal tidak valid, gunakan DD-MM-YYYY")
+	}
+	return date, nil
+}
+
+// optionalID mengubah nilai flag ID menjadi pointer (0 = tidak diisi)
+func optionalID(id int) *int {
+	if id <= 0 {
+		return nil
+	}
+	return &id
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func containsPermission(perms []string, perm string) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,627 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"kasir/config"
+	"kasir/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// setupCommandStore menyiapkan memory store dengan dua gudang, admin (admin/admin123)
+// dan kasir Gudang Pusat (kasir1/user123)
+func setupCommandStore(t *testing.T) (pusat, cabang *models.Warehouse) {
+	t.Helper()
+	models.SetStore(models.NewMemoryStore())
+	t.Setenv("KASIR_USER", "")
+	t.Setenv("KASIR_PASSWORD", "")
+
+	if _, err := models.CreateRole(nil, models.AdminRole, "Administrator", nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := models.CreateRole(nil, "user", "Kasir", []string{
+		models.PermTransactionCreate, models.PermProductView, models.PermReportView,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pusat, _ = models.CreateWarehouse(nil, "Gudang Pusat", "")
+	cabang, _ = models.CreateWarehouse(nil, "Gudang Cabang A", "")
+	if _, err := models.Register(nil, "admin", "admin123", models.AdminRole, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Register(nil, "kasir1", "user123", "user", &pusat.ID); err != nil {
+		t.Fatal(err)
+	}
+	return pusat, cabang
+}
+
+// runCmd menjalankan subcommand dan mengembalikan exit code, stdout, dan stderr
+func runCmd(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := runCommand(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	setupCommandStore(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no login", []string{"warehouse", "list"}, exitAuth},
+		{"unknown command", []string{"product", "hapus"}, exitUsage},
+		{"unknown flag", []string{"product", "list", "--bogus"}, exitUsage},
+		{"missing group action", []string{"product"}, exitUsage},
+		{"forbidden", []string{"user", "add", "--user", "kasir1", "--password", "user123"}, exitForbidden},
+		{"bad date", []string{"report", "daily", "--date", "2024-01-01", "--user", "admin", "--password", "admin123"}, exitUsage},
+		{"ok", []string{"warehouse", "list", "--user", "admin", "--password", "admin123"}, exitOK},
+		// terakhir: login gagal memicu jeda percobaan berikutnya
+		{"wrong password", []string{"warehouse", "list", "--user", "admin", "--password", "salah"}, exitAuth},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := runCmd(t, "", tt.args...); code != tt.want {
+			t.Errorf("%s: exit %d, want %d (stderr: %s)", tt.name, code, tt.want, stderr)
+		}
+	}
+}
+
+func TestCommandLoginFromEnv(t *testing.T) {
+	setupCommandStore(t)
+	t.Setenv("KASIR_USER", "admin")
+	t.Setenv("KASIR_PASSWORD", "admin123")
+
+	code, stdout, _ := runCmd(t, "", "warehouse", "list")
+	if code != exitOK || !strings.Contains(stdout, "Gudang Cabang A") {
+		t.Fatalf("exit %d, stdout %q", code, stdout)
+	}
+}
+
+func TestProductCommandsRespectWarehouseScope(t *testing.T) {
+	pusat, cabang := setupCommandStore(t)
+	models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	models.CreateProduct(nil, models.Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 10, WarehouseID: cabang.ID})
+
+	code, stdout, _ := runCmd(t, "", "product", "list", "--json", "--user", "kasir1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("exit %d", code)
+	}
+	var products []models.Product
+	if err := json.Unmarshal([]byte(stdout), &products); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if len(products) != 1 || products[0].Name != "Indomie Goreng" {
+		t.Errorf("kasir should only see own warehouse: %+v", products)
+	}
+
+	code, _, _ = runCmd(t, "", "product", "list", "--warehouse", "2", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("other warehouse: exit %d, want %d", code, exitForbidden)
+	}
+
+	code, _, stderr := runCmd(t, "", "product", "add", "--name", "Kopi", "--purchase", "1500", "--price", "2000",
+		"--warehouse", "2", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("product add: exit %d: %s", code, stderr)
+	}
+	code, stdout, _ = runCmd(t, "", "product", "list", "--search", "kopi", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "Kopi") || strings.Contains(stdout, "Indomie") {
+		t.Errorf("search output: %q", stdout)
+	}
+}
+
+func TestProductVariantCommand(t *testing.T) {
+	_, cabang := setupCommandStore(t)
+	kaos, _ := models.CreateProduct(nil, models.Product{Name: "Kaos Polos", PurchasePrice: 30000, SellingPrice: 50000, WarehouseID: cabang.ID})
+
+	code, _, _ := runCmd(t, "", "product", "variant", "--parent", "1", "--variant", "L", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without product.manage: exit %d, want %d", code, exitForbidden)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "product", "variant", "--parent", "1", "--variant", "XL", "--price", "55000", "--stock", "4",
+		"--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var v models.Product
+	if err := json.Unmarshal([]byte(stdout), &v); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if v.ParentID == nil || *v.ParentID != kaos.ID || v.WarehouseID != cabang.ID || v.PurchasePrice != 30000 || v.SellingPrice != 55000 {
+		t.Errorf("variant = %+v", v)
+	}
+
+	code, stdout, _ = runCmd(t, "", "product", "list", "--search", "kaos", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "Kaos Polos (XL)") {
+		t.Errorf("list output: %q", stdout)
+	}
+}
+
+func TestReportDailyCommand(t *testing.T) {
+	pusat, cabang := setupCommandStore(t)
+	kasir, _ := models.Authenticate("kasir1", "user123", "")
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	models.CreateProduct(nil, models.Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 10, WarehouseID: cabang.ID})
+
+	if _, err := models.CreateTransaction(kasir, []models.CartItem{{Product: mie, Quantity: 2}}, 10000); err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().Format("02-01-2006")
+	code, stdout, stderr := runCmd(t, "", "report", "daily", "--date", today, "--warehouse", "1", "--json",
+		"--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var report struct {
+		Summary struct {
+			TotalSales       float64 `json:"total_sales"`
+			TransactionCount int     `json:"transaction_count"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary.TransactionCount != 1 || report.Summary.TotalSales != 7000 {
+		t.Errorf("summary = %+v", report.Summary)
+	}
+
+	code, stdout, _ = runCmd(t, "", "report", "daily", "--warehouse", "2", "--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, `"transaction_count": 0`) {
+		t.Errorf("other warehouse report: exit %d, %s", code, stdout)
+	}
+}
+
+func TestReportStockCardCommand(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	kasir, _ := models.Authenticate("kasir1", "user123", "")
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	if _, err := models.CreateTransaction(kasir, []models.CartItem{{Product: mie, Quantity: 3}}, 20000); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "report", "stock-card", "--product", strconv.Itoa(mie.ID), "--user", "kasir1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "TRX-000001") || !strings.Contains(stdout, "saldo akhir: 7") {
+		t.Errorf("stock card output:\n%s", stdout)
+	}
+
+	code, _, _ = runCmd(t, "", "report", "stock-card", "--product", strconv.Itoa(mie.ID), "--from", "2026-01-01",
+		"--user", "admin", "--password", "admin123")
+	if code != exitUsage {
+		t.Errorf("bad date: exit %d, want %d", code, exitUsage)
+	}
+	code, _, _ = runCmd(t, "", "report", "stock-card", "--product", strconv.Itoa(mie.ID), "--warehouse", "2",
+		"--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("other warehouse: exit %d, want %d", code, exitForbidden)
+	}
+}
+
+func TestUserAddCommand(t *testing.T) {
+	setupCommandStore(t)
+
+	code, _, stderr := runCmd(t, "rahasia123\n", "user", "add", "--username", "kasir2", "--role", "user",
+		"--warehouse", "1", "--password-stdin", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if _, err := models.Authenticate("kasir2", "rahasia123", ""); err != nil {
+		t.Errorf("new user cannot log in: %v", err)
+	}
+
+	code, _, _ = runCmd(t, "", "user", "add", "--username", "kasir3", "--role", "user", "--new-password", "x",
+		"--user", "admin", "--password", "admin123")
+	if code != exitUsage {
+		t.Errorf("missing warehouse: exit %d, want %d", code, exitUsage)
+	}
+}
+
+func TestProductStockCommand(t *testing.T) {
+	pusat, cabang := setupCommandStore(t)
+	indomie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+
+	code, _, _ := runCmd(t, "", "product", "stock", "--id", "1", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without stock.adjust: exit %d, want %d", code, exitForbidden)
+	}
+	code, _, _ = runCmd(t, "", "product", "stock", "--id", "1", "--stock", "5", "--user", "admin", "--password", "admin123")
+	if code != exitUsage {
+		t.Errorf("missing --warehouse: exit %d, want %d", code, exitUsage)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "product", "stock", "--id", "1", "--warehouse", "2", "--stock", "5", "--price", "3800",
+		"--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var rows []models.Product
+	if err := json.Unmarshal([]byte(stdout), &rows); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if len(rows) != 2 || rows[1].WarehouseID != cabang.ID || rows[1].Stock != 5 || rows[1].SellingPrice != 3800 || rows[0].SellingPrice != 3500 {
+		t.Errorf("stocks = %+v", rows)
+	}
+	if master, _ := models.GetProductByID(indomie.ID); master.Stock != 15 || master.SellingPrice != 3500 {
+		t.Errorf("master = %+v", master)
+	}
+
+	// SKU yang sama tidak bisa dipakai produk baru di gudang lain
+	models.UpdateProduct(nil, models.Product{ID: indomie.ID, Name: "Indomie Goreng", SKU: "IDM-01", PurchasePrice: 2500, SellingPrice: 3500})
+	code, _, stderr = runCmd(t, "", "product", "add", "--name", "Indomie", "--sku", "IDM-01", "--purchase", "2500", "--price", "3500",
+		"--warehouse", "2", "--user", "admin", "--password", "admin123")
+	if code != exitError || !strings.Contains(stderr, "product stock") {
+		t.Errorf("duplicate sku: exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestReportLowStockCommand(t *testing.T) {
+	pusat, cabang := setupCommandStore(t)
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	models.SetProductStock(nil, models.ProductStock{ProductID: mie.ID, WarehouseID: cabang.ID, Stock: 4})
+
+	code, _, _ := runCmd(t, "", "product", "stock", "--id", "1", "--warehouse", "1", "--min", "5", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without stock.adjust: exit %d, want %d", code, exitForbidden)
+	}
+	for _, warehouse := range []string{"1", "2"} {
+		code, _, stderr := runCmd(t, "", "product", "stock", "--id", "1", "--warehouse", warehouse, "--min", "5", "--user", "admin", "--password", "admin123")
+		if code != exitOK {
+			t.Fatalf("set minimum: exit %d: %s", code, stderr)
+		}
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, cabang.ID); p.Stock != 4 {
+		t.Errorf("--min alone changed stock to %d", p.Stock)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "report", "low-stock", "--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var items []models.LowStockItem
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if len(items) != 1 || items[0].WarehouseID != cabang.ID || items[0].Stock != 4 || items[0].ReorderPoint != 5 {
+		t.Errorf("low stock = %+v", items)
+	}
+
+	// Kasir pusat hanya melihat gudangnya sendiri, yang stoknya masih aman
+	code, stdout, _ = runCmd(t, "", "report", "low-stock", "--json", "--user", "kasir1", "--password", "user123")
+	if code != exitOK || strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("kasir: exit %d, %s", code, stdout)
+	}
+}
+
+func TestBatchCommands(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	roti, _ := models.CreateProduct(nil, models.Product{Name: "Roti Tawar", PurchasePrice: 10000, SellingPrice: 15000, WarehouseID: pusat.ID})
+	yesterday := time.Now().AddDate(0, 0, -1).Format("02-01-2006")
+	nextWeek := time.Now().AddDate(0, 0, 7).Format("02-01-2006")
+
+	code, _, _ := runCmd(t, "", "batch", "add", "--product", "1", "--batch", "R-01", "--expiry", yesterday, "--qty", "3", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without stock.adjust: exit %d, want %d", code, exitForbidden)
+	}
+	if code, _, _ := runCmd(t, "", "batch", "add", "--product", "1", "--batch", "R-01", "--expiry", yesterday, "--qty", "3", "--user", "admin", "--password", "admin123"); code != exitUsage {
+		t.Errorf("admin without --warehouse: exit %d, want %d", code, exitUsage)
+	}
+	for _, b := range [][]string{{"R-01", yesterday, "3"}, {"R-02", nextWeek, "5"}} {
+		code, _, stderr := runCmd(t, "", "batch", "add", "--product", "1", "--warehouse", "1", "--batch", b[0], "--expiry", b[1], "--qty", b[2], "--user", "admin", "--password", "admin123")
+		if code != exitOK {
+			t.Fatalf("batch add %s: exit %d: %s", b[0], code, stderr)
+		}
+	}
+	if p, _ := models.GetProductInWarehouse(roti.ID, pusat.ID); p.Stock != 8 {
+		t.Errorf("stock after batch add = %d, want 8", p.Stock)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "report", "expiring", "--days", "3", "--json", "--user", "kasir1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var report []models.ExpiringBatch
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if len(report) != 1 || report[0].BatchNo != "R-01" || report[0].DaysLeft != -1 {
+		t.Errorf("expiring = %+v", report)
+	}
+
+	if code, _, _ := runCmd(t, "", "batch", "write-off", "--user", "admin", "--password", "admin123"); code != exitUsage {
+		t.Errorf("write-off without --id or --expired: exit %d, want %d", code, exitUsage)
+	}
+	code, stdout, stderr = runCmd(t, "", "batch", "write-off", "--expired", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "R-01") {
+		t.Fatalf("write-off: exit %d: %s%s", code, stdout, stderr)
+	}
+	code, stdout, _ = runCmd(t, "", "batch", "list", "--product", "1", "--json", "--user", "kasir1", "--password", "user123")
+	var batches []models.StockBatch
+	json.Unmarshal([]byte(stdout), &batches)
+	if code != exitOK || len(batches) != 1 || batches[0].BatchNo != "R-02" || batches[0].Remaining != 5 {
+		t.Errorf("batch list: exit %d, %+v", code, batches)
+	}
+	if p, _ := models.GetProductInWarehouse(roti.ID, pusat.ID); p.Stock != 5 {
+		t.Errorf("stock after write-off = %d, want 5", p.Stock)
+	}
+}
+
+func TestReturnCommands(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	kasir, _ := models.Authenticate("kasir1", "user123", "")
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	trx, err := models.CreateTransaction(kasir, []models.CartItem{{Product: mie, Quantity: 4}}, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trxNo := fmt.Sprintf("TRX-%06d", trx.ID)
+
+	code, _, _ := runCmd(t, "", "return", "create", "--trx", trxNo, "--items", "1:1", "--reason", "rusak", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without transaction.return: exit %d, want %d", code, exitForbidden)
+	}
+	if code, _, _ := runCmd(t, "", "return", "create", "--trx", trxNo, "--items", "1:1", "--user", "admin", "--password", "admin123"); code != exitUsage {
+		t.Errorf("without --reason: exit %d, want %d", code, exitUsage)
+	}
+	code, _, stderr := runCmd(t, "", "return", "create", "--trx", trxNo, "--items", "1:5", "--reason", "rusak", "--user", "admin", "--password", "admin123")
+	if code != exitError || !strings.Contains(stderr, "melebihi") {
+		t.Errorf("over-return: exit %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "return", "create", "--trx", trxNo, "--items", "1:3", "--reason", "kemasan rusak",
+		"--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("create: exit %d: %s", code, stderr)
+	}
+	var ret models.SalesReturn
+	if err := json.Unmarshal([]byte(stdout), &ret); err != nil || ret.Total != 10500 || ret.TransactionID != trx.ID {
+		t.Errorf("return = %+v, %v", ret, err)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 9 {
+		t.Errorf("stock after return = %d, want 9", p.Stock)
+	}
+
+	code, stdout, _ = runCmd(t, "", "return", "list", "--trx", strconv.Itoa(trx.ID), "--user", "kasir1", "--password", "user123")
+	if code != exitOK || !strings.Contains(stdout, "RET-000001") || !strings.Contains(stdout, "kemasan rusak") {
+		t.Errorf("return list: exit %d, %s", code, stdout)
+	}
+
+	code, stdout, _ = runCmd(t, "", "report", "daily", "--json", "--user", "kasir1", "--password", "user123")
+	var report struct {
+		Summary models.SalesSummary `json:"summary"`
+	}
+	json.Unmarshal([]byte(stdout), &report)
+	if code != exitOK || report.Summary.GrossSales != 14000 || report.Summary.ReturnTotal != 10500 || report.Summary.NetSales != 3500 {
+		t.Errorf("daily report: exit %d, %+v", code, report.Summary)
+	}
+}
+
+func TestTransactionVoidCommand(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	if _, err := models.CreateRole(nil, "supervisor", "Supervisor", []string{models.PermTransactionCreate, models.PermTransactionVoid}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Register(nil, "spv1", "spv123", "supervisor", &pusat.ID); err != nil {
+		t.Fatal(err)
+	}
+	kasir, _ := models.Authenticate("kasir1", "user123", "")
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	trx, err := models.CreateTransaction(kasir, []models.CartItem{{Product: mie, Quantity: 4}}, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trxNo := fmt.Sprintf("TRX-%06d", trx.ID)
+
+	if code, _, _ := runCmd(t, "", "transaction", "void", "--trx", trxNo, "--reason", "batal", "--user", "kasir1", "--password", "user123"); code != exitUsage {
+		t.Errorf("without --approver: exit %d, want %d", code, exitUsage)
+	}
+	code, _, stderr := runCmd(t, "user123\n", "transaction", "void", "--trx", trxNo, "--reason", "batal", "--approver", "kasir1",
+		"--approver-password-stdin", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden || !strings.Contains(stderr, "tidak punya izin") {
+		t.Errorf("cashier approving own void: exit %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runCmd(t, "spv123\n", "transaction", "void", "--trx", trxNo, "--reason", "pelanggan batal", "--approver", "spv1",
+		"--approver-password-stdin", "--json", "--user", "kasir1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("void: exit %d: %s", code, stderr)
+	}
+	var voided models.Transaction
+	if err := json.Unmarshal([]byte(stdout), &voided); err != nil || !voided.Voided() || voided.VoidedBy != "spv1" {
+		t.Errorf("voided = %+v, %v", voided, err)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 10 {
+		t.Errorf("stock after void = %d, want 10", p.Stock)
+	}
+
+	code, stdout, _ = runCmd(t, "", "report", "daily", "--json", "--user", "kasir1", "--password", "user123")
+	var report struct {
+		Summary models.SalesSummary  `json:"summary"`
+		Voided  []models.Transaction `json:"voided"`
+	}
+	json.Unmarshal([]byte(stdout), &report)
+	if code != exitOK || report.Summary.GrossSales != 0 || len(report.Voided) != 1 || report.Voided[0].ID != trx.ID {
+		t.Errorf("daily report: exit %d, %+v, %+v", code, report.Summary, report.Voided)
+	}
+}
+
+func TestTransferCommands(t *testing.T) {
+	pusat, cabang := setupCommandStore(t)
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+
+	code, _, _ := runCmd(t, "", "transfer", "list", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without stock.transfer: exit %d, want %d", code, exitForbidden)
+	}
+	code, _, _ = runCmd(t, "", "transfer", "create", "--from", "1", "--to", "2", "--items", "1", "--user", "admin", "--password", "admin123")
+	if code != exitUsage {
+		t.Errorf("bad --items: exit %d, want %d", code, exitUsage)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "transfer", "create", "--from", "1", "--to", "2", "--items", "1:4", "--send",
+		"--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("create: exit %d: %s", code, stderr)
+	}
+	var trf models.Transfer
+	if err := json.Unmarshal([]byte(stdout), &trf); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if trf.Status != models.TransferSent || len(trf.Items) != 1 || trf.Items[0].Quantity != 4 {
+		t.Errorf("transfer = %+v", trf)
+	}
+
+	code, stdout, _ = runCmd(t, "", "transfer", "list", "--status", "sent", "--warehouse", "2", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "TRF-000001") {
+		t.Errorf("in transit list: exit %d, %q", code, stdout)
+	}
+
+	code, stdout, stderr = runCmd(t, "", "transfer", "receive", "--id", "1", "--received", "1:3", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "received") {
+		t.Fatalf("receive: exit %d: %s%s", code, stdout, stderr)
+	}
+	if p, err := models.GetProductInWarehouse(mie.ID, cabang.ID); err != nil || p.Stock != 3 {
+		t.Errorf("destination stock = %+v, %v", p, err)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 6 {
+		t.Errorf("source stock = %d, want 6", p.Stock)
+	}
+}
+
+func TestOpnameCommands(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	excelDir := config.App.Export.ExcelDir
+	config.App.Export.ExcelDir = t.TempDir()
+	t.Cleanup(func() { config.App.Export.ExcelDir = excelDir })
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", SKU: "MIE-GRG", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	aqua, _ := models.CreateProduct(nil, models.Product{Name: "Aqua 600ml", PurchasePrice: 2000, SellingPrice: 4000, Stock: 5, WarehouseID: pusat.ID})
+	if _, err := models.CreateRole(nil, "stock_clerk", "Petugas Gudang", []string{models.PermStockOpname}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Register(nil, "gudang1", "user123", "stock_clerk", &pusat.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, _ := runCmd(t, "", "opname", "list", "--user", "kasir1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("kasir without stock.opname: exit %d, want %d", code, exitForbidden)
+	}
+	code, stdout, stderr := runCmd(t, "", "opname", "create", "--note", "akhir bulan", "--user", "gudang1", "--password", "user123")
+	if code != exitOK || !strings.Contains(stdout, "OPN-000001") {
+		t.Fatalf("create: exit %d: %s%s", code, stdout, stderr)
+	}
+	code, _, _ = runCmd(t, "", "opname", "approve", "--id", "1", "--user", "gudang1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("clerk without stock.opname.approve: exit %d, want %d", code, exitForbidden)
+	}
+
+	// Lembar hitung diisi lewat Excel: Indomie dicocokkan dari SKU, Aqua dari ID
+	code, stdout, stderr = runCmd(t, "", "opname", "export", "--id", "1", "--json", "--user", "gudang1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("export: exit %d: %s", code, stderr)
+	}
+	var exported struct{ File string }
+	if err := json.Unmarshal([]byte(stdout), &exported); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	f, err := excelize.OpenFile(exported.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetCellValue("Stock Opname", "A2", "")
+	f.SetCellValue("Stock Opname", "E2", 8)
+	f.SetCellValue("Stock Opname", "E3", "lima")
+	f.Save()
+	f.Close()
+
+	code, _, stderr = runCmd(t, "", "opname", "import", "--id", "1", "--file", exported.File, "--user", "gudang1", "--password", "user123")
+	if code != exitError || !strings.Contains(stderr, "Baris 3") {
+		t.Errorf("import with a bad row: exit %d, %q", code, stderr)
+	}
+	code, _, stderr = runCmd(t, "", "opname", "count", "--id", "1", "--counts", strconv.Itoa(aqua.ID)+":6", "--user", "gudang1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("count: exit %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr = runCmd(t, "", "opname", "approve", "--id", "1", "--json", "--user", "admin", "--password", "admin123")
+	if code != exitOK {
+		t.Fatalf("approve: exit %d: %s", code, stderr)
+	}
+	var opn models.StockOpname
+	if err := json.Unmarshal([]byte(stdout), &opn); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if opn.Status != models.OpnameApproved || opn.VarianceValue() != -2*2500+1*2000 {
+		t.Errorf("approved opname = %+v", opn)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 8 {
+		t.Errorf("stock after opname = %d, want 8", p.Stock)
+	}
+}
+
+func TestPurchaseCommands(t *testing.T) {
+	pusat, _ := setupCommandStore(t)
+	mie, _ := models.CreateProduct(nil, models.Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	if _, err := models.CreateRole(nil, "stock_clerk", "Petugas Gudang", []string{models.PermPurchaseReceive}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := models.Register(nil, "gudang1", "user123", "stock_clerk", &pusat.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCmd(t, "", "supplier", "add", "--name", "PT Sumber Pangan", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "ID 1") {
+		t.Fatalf("supplier add: exit %d: %s%s", code, stdout, stderr)
+	}
+	items := strconv.Itoa(mie.ID) + ":24"
+	code, _, _ = runCmd(t, "", "purchase", "create", "--supplier", "1", "--items", items, "--user", "gudang1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("clerk without purchase.manage: exit %d, want %d", code, exitForbidden)
+	}
+	code, stdout, stderr = runCmd(t, "", "purchase", "create", "--supplier", "1", "--warehouse", strconv.Itoa(pusat.ID), "--items", items, "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "PO-000001") || !strings.Contains(stdout, "2500") {
+		t.Fatalf("create: exit %d: %s%s", code, stdout, stderr)
+	}
+
+	// Petugas gudang menerima sebagian dengan harga faktur yang berbeda
+	code, stdout, stderr = runCmd(t, "", "purchase", "receive", "--id", "1", "--received", strconv.Itoa(mie.ID)+":10@2600", "--json", "--user", "gudang1", "--password", "user123")
+	if code != exitOK {
+		t.Fatalf("receive: exit %d: %s", code, stderr)
+	}
+	var gr models.GoodsReceipt
+	if err := json.Unmarshal([]byte(stdout), &gr); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, stdout)
+	}
+	if gr.Total != 10*2600 {
+		t.Errorf("receipt = %+v", gr)
+	}
+	if p, _ := models.GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 20 || p.PurchasePrice != 2600 {
+		t.Errorf("stock after receipt = %+v", p)
+	}
+
+	code, stdout, _ = runCmd(t, "", "report", "outstanding-po", "--user", "gudang1", "--password", "user123")
+	if code != exitOK || !strings.Contains(stdout, "PO-000001") || !strings.Contains(stdout, "35000") {
+		t.Errorf("outstanding-po: exit %d: %s", code, stdout)
+	}
+	code, _, _ = runCmd(t, "", "report", "supplier-history", "--supplier", "1", "--user", "gudang1", "--password", "user123")
+	if code != exitForbidden {
+		t.Errorf("clerk supplier-history: exit %d, want %d", code, exitForbidden)
+	}
+	code, stdout, _ = runCmd(t, "", "report", "supplier-history", "--supplier", "1", "--user", "admin", "--password", "admin123")
+	if code != exitOK || !strings.Contains(stdout, "GR-000001") || !strings.Contains(stdout, "26000") {
+		t.Errorf("supplier-history: exit %d: %s", code, stdout)
+	}
+}
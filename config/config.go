@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile dibaca otomatis jika ada di folder kerja dan -config/KASIR_CONFIG tidak diset
+const DefaultConfigFile = "kasir.yaml"
+
+// Config adalah seluruh pengaturan aplikasi (dari file YAML lalu di-override environment variable)
+type Config struct {
+	Database  DatabaseConfig  `yaml:"database"`
+	Server    ServerConfig    `yaml:"server"`
+	Export    ExportConfig    `yaml:"export"`
+	Store     StoreConfig     `yaml:"store"`
+	Inventory InventoryConfig `yaml:"inventory"`
+	Auth      AuthConfig      `yaml:"auth"`
+}
+
+// DatabaseConfig adalah pengaturan koneksi dan pool database
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"`
+	// DSN lengkap PostgreSQL; jika diisi, host/port/user/password/name/sslmode diabaikan
+	DSN      string `yaml:"dsn"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// Path file database jika driver sqlite
+	Path        string `yaml:"path"`
+	AutoMigrate bool   `yaml:"auto_migrate"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// ServerConfig adalah pengaturan server API
+type ServerConfig struct {
+	Listen       string        `yaml:"listen"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+}
+
+// ExportConfig adalah folder tujuan file hasil export
+type ExportConfig struct {
+	ExcelDir   string `yaml:"excel_dir"`
+	ReceiptDir string `yaml:"receipt_dir"`
+}
+
+// StoreConfig adalah identitas toko yang tampil di struk
+type StoreConfig struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Phone   string `yaml:"phone"`
+	Footer  string `yaml:"footer"`
+}
+
+// Metode harga pokok persediaan
+const (
+	CostingAverage = "average" // rata-rata tertimbang bergerak
+	CostingFIFO    = "fifo"    // barang yang masuk lebih dulu keluar lebih dulu
+)
+
+// InventoryConfig adalah pengaturan persediaan; metode harga pokok menentukan harga beli
+// dan profit yang dicatat di setiap penjualan
+type InventoryConfig struct {
+	Costing string `yaml:"costing"`
+}
+
+// AuthConfig adalah pengaturan token API dan pembatasan login gagal
+type AuthConfig struct {
+	// Kunci HMAC access token API, wajib untuk mode API agar token tetap valid setelah restart
+	TokenSecret        string `yaml:"token_secret"`
+	LoginMaxAttempts   int    `yaml:"login_max_attempts"`    // gagal per username sebelum dikunci
+	LoginMaxAttemptsIP int    `yaml:"login_max_attempts_ip"` // gagal per IP sebelum dikunci
+	LockoutMinutes     int    `yaml:"lockout_minutes"`       // lama username/IP dikunci
+}
+
+// MinTokenSecretLength adalah panjang minimal auth.token_secret
+const MinTokenSecretLength = 32
+
+// App adalah konfigurasi yang sedang dipakai (diisi oleh Load di main)
+var App = Default()
+
+// Default mengembalikan konfigurasi bawaan. Tidak ada kredensial bawaan:
+// password database harus diisi lewat file config atau DB_PASSWORD.
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Driver:          DriverPostgres,
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Name:            "kasir",
+			SSLMode:         "disable",
+			Path:            "kasir.db",
+			AutoMigrate:     true,
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Server: ServerConfig{
+			Listen:       ":8080",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		},
+		Export: ExportConfig{
+			ExcelDir:   "exports/excel",
+			ReceiptDir: "exports/nota",
+		},
+		Store: StoreConfig{
+			Name:   "Kasir",
+			Footer: "Terima Kasih Atas Kunjungan Anda",
+		},
+		Inventory: InventoryConfig{
+			Costing: CostingAverage,
+		},
+		Auth: AuthConfig{
+			LoginMaxAttempts:   5,
+			LoginMaxAttemptsIP: 20,
+			LockoutMinutes:     15,
+		},
+	}
+}
+
+// Load membaca konfigurasi: default, lalu file (path, KASIR_CONFIG, atau kasir.yaml jika ada),
+// lalu environment variable, kemudian divalidasi.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("KASIR_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = DefaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		// KnownFields: salah ketik nama kunci langsung ketahuan, bukan diam-diam diabaikan
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file config %s tidak valid: %v", path, err)
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("gagal membaca file config %s: %v", path, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv menimpa nilai dari file dengan environment variable yang diset
+func (c *Config) applyEnv() error {
+	var errs []string
+	str := func(key string, dst *string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	num := func(key string, dst *int) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus angka, bukan %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	dur := func(key string, dst *time.Duration) {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus durasi seperti 30s atau 5m, bukan %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+	flag := func(key string, dst *bool) {
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s harus true atau false, bukan %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	db := &c.Database
+	str("DB_DRIVER", &db.Driver)
+	str("DB_DSN", &db.DSN)
+	str("DB_HOST", &db.Host)
+	num("DB_PORT", &db.Port)
+	str("DB_USER", &db.User)
+	str("DB_PASSWORD", &db.Password)
+	str("DB_NAME", &db.Name)
+	str("DB_SSLMODE", &db.SSLMode)
+	str("DB_PATH", &db.Path)
+	flag("DB_AUTO_MIGRATE", &db.AutoMigrate)
+	num("DB_MAX_OPEN_CONNS", &db.MaxOpenConns)
+	num("DB_MAX_IDLE_CONNS", &db.MaxIdleConns)
+	dur("DB_CONN_MAX_LIFETIME", &db.ConnMaxLifetime)
+	dur("DB_CONN_MAX_IDLE_TIME", &db.ConnMaxIdleTime)
+
+	str("API_LISTEN", &c.Server.Listen)
+	dur("API_READ_TIMEOUT", &c.Server.ReadTimeout)
+	dur("API_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	dur("API_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+
+	str("EXPORT_EXCEL_DIR", &c.Export.ExcelDir)
+	str("EXPORT_RECEIPT_DIR", &c.Export.ReceiptDir)
+
+	str("STORE_NAME", &c.Store.Name)
+	str("STORE_ADDRESS", &c.Store.Address)
+	str("STORE_PHONE", &c.Store.Phone)
+	str("STORE_FOOTER", &c.Store.Footer)
+
+	str("INVENTORY_COSTING", &c.Inventory.Costing)
+
+	str("API_TOKEN_SECRET", &c.Auth.TokenSecret)
+	num("LOGIN_MAX_ATTEMPTS", &c.Auth.LoginMaxAttempts)
+	num("LOGIN_MAX_ATTEMPTS_IP", &c.Auth.LoginMaxAttemptsIP)
+	num("LOGIN_LOCKOUT_MINUTES", &c.Auth.LockoutMinutes)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("environment variable tidak valid:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// sslModes adalah nilai sslmode yang didukung driver lib/pq
+var sslModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
+// Validate memeriksa konfigurasi dan mengembalikan semua kesalahan sekaligus
+func (c *Config) Validate() error {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	db := c.Database
+	switch db.Driver {
+	case DriverPostgres:
+		if db.DSN == "" {
+			if db.Host == "" {
+				add("database.host wajib diisi")
+			}
+			if db.Port <= 0 || db.Port > 65535 {
+				add("database.port harus 1-65535, bukan %d", db.Port)
+			}
+			if db.User == "" {
+				add("database.user wajib diisi")
+			}
+			if db.Name == "" {
+				add("database.name wajib diisi")
+			}
+			if !contains(sslModes, db.SSLMode) {
+				add("database.sslmode harus salah satu dari %s, bukan %q", strings.Join(sslModes, ", "), db.SSLMode)
+			}
+		}
+	case DriverSQLite:
+		if db.Path == "" {
+			add("database.path wajib diisi untuk driver sqlite")
+		}
+	default:
+		add("database.driver harus postgres atau sqlite, bukan %q", db.Driver)
+	}
+
+	if db.MaxOpenConns < 0 {
+		add("database.max_open_conns tidak boleh negatif")
+	}
+	if db.MaxIdleConns < 0 {
+		add("database.max_idle_conns tidak boleh negatif")
+	}
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		add("database.max_idle_conns (%d) tidak boleh melebihi max_open_conns (%d)", db.MaxIdleConns, db.MaxOpenConns)
+	}
+	if db.ConnMaxLifetime < 0 || db.ConnMaxIdleTime < 0 {
+		add("database.conn_max_lifetime dan conn_max_idle_time tidak boleh negatif")
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		add("server.listen harus berbentuk host:port atau :port, bukan %q", c.Server.Listen)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		add("port pada server.listen tidak valid: %q", port)
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		add("timeout server tidak boleh negatif")
+	}
+
+	if c.Export.ExcelDir == "" {
+		add("export.excel_dir wajib diisi")
+	}
+	if c.Export.ReceiptDir == "" {
+		add("export.receipt_dir wajib diisi")
+	}
+	if strings.TrimSpace(c.Store.Name) == "" {
+		add("store.name wajib diisi")
+	}
+	if c.Inventory.Costing != CostingAverage && c.Inventory.Costing != CostingFIFO {
+		add("inventory.costing harus %s atau %s, bukan %q", CostingAverage, CostingFIFO, c.Inventory.Costing)
+	}
+
+	auth := c.Auth
+	if auth.TokenSecret != "" && len(auth.TokenSecret) < MinTokenSecretLength {
+		add("auth.token_secret minimal %d karakter", MinTokenSecretLength)
+	}
+	if auth.LoginMaxAttempts < 1 {
+		add("auth.login_max_attempts minimal 1, bukan %d", auth.LoginMaxAttempts)
+	}
+	if auth.LoginMaxAttemptsIP < 1 {
+		add("auth.login_max_attempts_ip minimal 1, bukan %d", auth.LoginMaxAttemptsIP)
+	}
+	if auth.LockoutMinutes < 1 {
+		add("auth.lockout_minutes minimal 1, bukan %d", auth.LockoutMinutes)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("konfigurasi tidak valid:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// PostgresDSN menyusun connection string PostgreSQL dari konfigurasi
+func (d DatabaseConfig) PostgresDSN() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+
+	parts := []string{
+		"host=" + quoteDSN(d.Host),
+		"port=" + strconv.Itoa(d.Port),
+		"user=" + quoteDSN(d.User),
+		"dbname=" + quoteDSN(d.Name),
+		"sslmode=" + quoteDSN(d.SSLMode),
+	}
+	if d.Password != "" {
+		parts = append(parts, "password="+quoteDSN(d.Password))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSN membungkus nilai dengan kutip agar spasi dan tanda kutip di password aman
+func quoteDSN(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kasir.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileAndEnvOverride(t *testing.T) {
+	path := writeConfig(t, `
+database:
+  host: db.internal
+  password: "it's secret"
+  sslmode: require
+  max_open_conns: 20
+  conn_max_lifetime: 1h
+server:
+  listen: "127.0.0.1:9090"
+store:
+  name: Toko Maju
+inventory:
+  costing: fifo
+auth:
+  token_secret: "0123456789abcdef0123456789abcdef"
+  lockout_minutes: 30
+`)
+	t.Setenv("DB_HOST", "db.override")
+	t.Setenv("API_READ_TIMEOUT", "3s")
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "3")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Host != "db.override" {
+		t.Errorf("env should override file, host = %q", cfg.Database.Host)
+	}
+	if cfg.Database.MaxOpenConns != 20 || cfg.Database.ConnMaxLifetime != time.Hour {
+		t.Errorf("pool settings not loaded: %+v", cfg.Database)
+	}
+	if cfg.Server.Listen != "127.0.0.1:9090" || cfg.Server.ReadTimeout != 3*time.Second {
+		t.Errorf("server settings not loaded: %+v", cfg.Server)
+	}
+	if cfg.Database.Port != 5432 || cfg.Export.ExcelDir != "exports/excel" {
+		t.Error("unset values should keep defaults")
+	}
+	if cfg.Store.Name != "Toko Maju" {
+		t.Errorf("store name = %q", cfg.Store.Name)
+	}
+	if cfg.Inventory.Costing != CostingFIFO {
+		t.Errorf("costing = %q", cfg.Inventory.Costing)
+	}
+	if a := cfg.Auth; a.TokenSecret != "0123456789abcdef0123456789abcdef" || a.LockoutMinutes != 30 ||
+		a.LoginMaxAttempts != 3 || a.LoginMaxAttemptsIP != 20 {
+		t.Errorf("auth settings not loaded: %+v", a)
+	}
+
+	dsn := cfg.Database.PostgresDSN()
+	if !strings.Contains(dsn, `password='it\'s secret'`) || !strings.Contains(dsn, "sslmode='require'") {
+		t.Errorf("dsn not built correctly: %s", dsn)
+	}
+}
+
+func TestLoadWithoutFileHasNoPassword(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Password != "" || strings.Contains(cfg.Database.PostgresDSN(), "password") {
+		t.Error("default config must not contain a password")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("explicit missing config file should fail")
+	}
+
+	if _, err := Load(writeConfig(t, "databse:\n  host: x\n")); err == nil {
+		t.Error("unknown key should fail")
+	}
+
+	t.Setenv("DB_PORT", "abc")
+	if _, err := Load(writeConfig(t, "")); err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Errorf("invalid env should name the variable, got %v", err)
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Database.SSLMode = "prefer"
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 5
+	cfg.Server.Listen = "8080"
+	cfg.Store.Name = " "
+	cfg.Inventory.Costing = "lifo"
+	cfg.Auth.TokenSecret = "pendek"
+	cfg.Auth.LoginMaxAttempts = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"sslmode", "max_idle_conns", "server.listen", "store.name", "inventory.costing", "auth.token_secret", "auth.login_max_attempts"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %s: %v", want, err)
+		}
+	}
+
+	cfg = Default()
+	cfg.Database.Driver = DriverSQLite
+	cfg.Database.SSLMode = "bogus"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("sqlite should ignore postgres settings: %v", err)
+	}
+}
@@ -3,47 +3,79 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"os"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Driver database yang didukung
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
 var DB *sql.DB
 
-// GetEnv mendapatkan environment variable dengan default value
-func GetEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
+// Driver adalah driver database yang sedang dipakai (diisi oleh InitDB)
+var Driver string
 
-// InitDB menginisialisasi koneksi database
+// InitDB menginisialisasi koneksi database sesuai App.Database (postgres atau sqlite)
 func InitDB() error {
-	host := GetEnv("DB_HOST", "localhost")
-	port := GetEnv("DB_PORT", "5432")
-	user := GetEnv("DB_USER", "postgres")
-	password := GetEnv("DB_PASSWORD", "123123")
-	dbname := GetEnv("DB_NAME", "kasir")
-
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
+	cfg := App.Database
+	Driver = cfg.Driver
 
 	var err error
-	DB, err = sql.Open("postgres", connStr)
+	switch Driver {
+	case DriverPostgres:
+		DB, err = openPostgres(cfg.PostgresDSN())
+	case DriverSQLite:
+		DB, err = OpenSQLite(cfg.Path)
+	default:
+		return fmt.Errorf("DB_DRIVER tidak dikenal: %s (pilih postgres atau sqlite)", Driver)
+	}
 	if err != nil {
-		return fmt.Errorf("gagal membuka koneksi database: %v", err)
+		return err
 	}
 
-	err = DB.Ping()
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return nil
+}
+
+func openPostgres(connStr string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return fmt.Errorf("gagal koneksi ke database: %v", err)
+		return nil, fmt.Errorf("gagal membuka koneksi database: %v", err)
 	}
 
-	return nil
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("gagal koneksi ke database: %v", err)
+	}
+
+	return db, nil
+}
+
+// OpenSQLite membuka (atau membuat) file database SQLite. Schema dipasang oleh migrator.
+func OpenSQLite(path string) (*sql.DB, error) {
+	// foreign_keys: SQLite tidak mengecek foreign key tanpa pragma ini
+	// busy_timeout + _txlock=immediate: penulisan bersamaan menunggu, bukan langsung gagal "database is locked"
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka database SQLite: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("gagal membaca database SQLite %s: %v", path, err)
+	}
+
+	return db, nil
 }
 
 // CloseDB menutup koneksi database
@@ -45,23 +45,85 @@ export interface Report {
 
 // Helpers
 const getAuthHeaders = (): Record<string, string> => {
-    const token = localStorage.getItem("auth_token"); // Basic base64
-    return token ? { Authorization: `Basic ${token}` } : {};
+    const token = localStorage.getItem("access_token");
+    return token ? { Authorization: `Bearer ${token}` } : {};
+};
+
+const saveTokens = (data: { access_token: string; refresh_token: string }) => {
+    localStorage.setItem("access_token", data.access_token);
+    localStorage.setItem("refresh_token", data.refresh_token);
+};
+
+const clearTokens = () => {
+    localStorage.removeItem("access_token");
+    localStorage.removeItem("refresh_token");
+};
+
+// Refresh yang sedang berjalan. Refresh token dirotasi server sehingga hanya boleh dipakai sekali:
+// request lain yang mendapat 401 menunggu refresh yang sama, bukan mengirim refresh sendiri.
+let refreshPromise: Promise<boolean> | null = null;
+
+const refreshTokens = (): Promise<boolean> => {
+    if (refreshPromise) return refreshPromise;
+
+    refreshPromise = (async () => {
+        const refreshToken = localStorage.getItem("refresh_token");
+        if (!refreshToken) return false;
+        try {
+            const res = await fetch(`${API_URL}/refresh`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ refresh_token: refreshToken }),
+            });
+            if (!res.ok) {
+                // Jangan hapus token yang sudah diganti (misalnya login ulang) selama refresh berjalan
+                if (localStorage.getItem("refresh_token") === refreshToken) clearTokens();
+                return false;
+            }
+            saveTokens(await res.json());
+            return true;
+        } catch {
+            // Gangguan jaringan: token tetap disimpan agar bisa dicoba lagi
+            return false;
+        }
+    })().finally(() => {
+        refreshPromise = null;
+    });
+    return refreshPromise;
+};
+
+// Wrapper fetch: jika access token kedaluwarsa, tukar refresh token lalu ulangi request sekali
+const authFetch = async (url: string, init: RequestInit = {}): Promise<Response> => {
+    const withAuth = (): RequestInit => ({ ...init, headers: { ...(init.headers as Record<string, string>), ...getAuthHeaders() } });
+    const usedToken = localStorage.getItem("access_token");
+    const res = await fetch(url, withAuth());
+    if (res.status !== 401) return res;
+
+    // Token sudah diperbarui request lain sejak request ini dikirim: cukup ulangi
+    if (localStorage.getItem("access_token") === usedToken && !(await refreshTokens())) return res;
+    return fetch(url, withAuth());
 };
 
 // API Functions
 export const api = {
     login: async (username: string, password: string) => {
-        // We test credentials by encoding them and calling a guarded endpoint or login endpoint
-        const token = btoa(`${username}:${password}`);
         const res = await fetch(`${API_URL}/login`, {
             method: "POST",
             headers: { "Content-Type": "application/json" },
             body: JSON.stringify({ username, password }),
         });
         if (!res.ok) throw new Error("Login failed");
-        localStorage.setItem("auth_token", token);
-        return res.json();
+        const data = await res.json();
+        saveTokens(data);
+        return data;
+    },
+
+    logout: async () => {
+        try {
+            await fetch(`${API_URL}/logout`, { method: "POST", headers: getAuthHeaders() });
+        } finally {
+            clearTokens();
+        }
     },
 
     getProducts: async (params?: { page?: number; limit?: number; search?: string }): Promise<{ data: Product[]; meta: { current_page: number; limit: number; total_items: number; total_pages: number } }> => {
@@ -70,24 +132,20 @@ export const api = {
         if (params?.limit) query.append("limit", params.limit.toString());
         if (params?.search) query.append("search", params.search);
 
-        const res = await fetch(`${API_URL}/products?${query.toString()}`, {
-            headers: getAuthHeaders(),
-        });
+        const res = await authFetch(`${API_URL}/products?${query.toString()}`);
         if (!res.ok) throw new Error("Failed to fetch products");
         return res.json();
     },
 
     getTransactions: async (): Promise<Transaction[]> => {
-        const res = await fetch(`${API_URL}/transactions`, {
-            headers: getAuthHeaders(),
-        });
+        const res = await authFetch(`${API_URL}/transactions`);
         if (!res.ok) throw new Error("Failed to fetch transactions");
         return res.json();
     },
 
     createTransaction: async (items: { product_id: number; quantity: number }[], payment: number) => {
-        const headers = { "Content-Type": "application/json", ...getAuthHeaders() };
-        const res = await fetch(`${API_URL}/transactions`, {
+        const headers = { "Content-Type": "application/json" };
+        const res = await authFetch(`${API_URL}/transactions`, {
             method: "POST",
             headers: headers,
             body: JSON.stringify({ items, payment }),
@@ -101,53 +159,53 @@ export const api = {
 
     // Products
     createProduct: async (data: Omit<Product, "ID">) => {
-        const res = await fetch(`${API_URL}/products`, { method: "POST", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify(data) });
+        const res = await authFetch(`${API_URL}/products`, { method: "POST", headers: { "Content-Type": "application/json" }, body: JSON.stringify(data) });
         if (!res.ok) throw new Error("Failed to create product");
         return res.json();
     },
     deleteProduct: async (id: number) => {
-        const res = await fetch(`${API_URL}/products`, { method: "DELETE", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify({ id }) });
+        const res = await authFetch(`${API_URL}/products`, { method: "DELETE", headers: { "Content-Type": "application/json" }, body: JSON.stringify({ id }) });
         if (!res.ok) throw new Error("Failed to delete product");
         return res.json();
     },
 
     // Users
     getUsers: async (): Promise<User[]> => {
-        const res = await fetch(`${API_URL}/users`, { headers: getAuthHeaders() });
+        const res = await authFetch(`${API_URL}/users`);
         if (!res.ok) throw new Error("Failed to fetch users");
         return res.json();
     },
     createUser: async (data: Omit<User, "ID"> & { password?: string }) => { // Assuming password is sent for creation
-        const res = await fetch(`${API_URL}/users`, { method: "POST", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify(data) });
+        const res = await authFetch(`${API_URL}/users`, { method: "POST", headers: { "Content-Type": "application/json" }, body: JSON.stringify(data) });
         if (!res.ok) throw new Error("Failed to create user");
         return res.json();
     },
     deleteUser: async (id: number) => {
-        const res = await fetch(`${API_URL}/users`, { method: "DELETE", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify({ id }) });
+        const res = await authFetch(`${API_URL}/users`, { method: "DELETE", headers: { "Content-Type": "application/json" }, body: JSON.stringify({ id }) });
         if (!res.ok) throw new Error("Failed to delete user");
         return res.json();
     },
 
     // Warehouses
     getWarehouses: async (): Promise<Warehouse[]> => {
-        const res = await fetch(`${API_URL}/warehouses`, { headers: getAuthHeaders() });
+        const res = await authFetch(`${API_URL}/warehouses`);
         if (!res.ok) throw new Error("Failed to fetch warehouses");
         return res.json();
     },
     createWarehouse: async (data: Omit<Warehouse, "ID">) => {
-        const res = await fetch(`${API_URL}/warehouses`, { method: "POST", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify(data) });
+        const res = await authFetch(`${API_URL}/warehouses`, { method: "POST", headers: { "Content-Type": "application/json" }, body: JSON.stringify(data) });
         if (!res.ok) throw new Error("Failed to create warehouse");
         return res.json();
     },
     deleteWarehouse: async (id: number) => {
-        const res = await fetch(`${API_URL}/warehouses`, { method: "DELETE", headers: { "Content-Type": "application/json", ...getAuthHeaders() }, body: JSON.stringify({ id }) });
+        const res = await authFetch(`${API_URL}/warehouses`, { method: "DELETE", headers: { "Content-Type": "application/json" }, body: JSON.stringify({ id }) });
         if (!res.ok) throw new Error("Failed to delete warehouse");
         return res.json();
     },
 
     // Reports
     getReport: async (dateStr: string): Promise<Report> => {
-        const res = await fetch(`${API_URL}/reports?date=${dateStr}`, { headers: getAuthHeaders() });
+        const res = await authFetch(`${API_URL}/reports?date=${dateStr}`);
         if (!res.ok) throw new Error("Failed to fetch report");
         return res.json();
     },
@@ -17,8 +17,8 @@ export default function Dashboard() {
   const queryClient = useQueryClient();
   const [activeTab, setActiveTab] = useState("pos"); // pos, products, users, warehouses, reports
 
-  const handleLogout = () => {
-    localStorage.removeItem("auth_token");
+  const handleLogout = async () => {
+    await api.logout();
     navigate("/");
   };
 
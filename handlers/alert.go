@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strings"
+)
+
+// canSeeStock bernilai true jika user boleh melihat stok produk
+func canSeeStock(user *models.User) bool {
+	return user.Can(models.PermProductView) || user.Can(models.PermProductManage) ||
+		user.Can(models.PermStockAdjust) || user.Can(models.PermPurchaseManage)
+}
+
+// ShowLowStockAlert menampilkan produk yang stoknya menipis di gudang user (semua gudang untuk
+// user dengan akses semua gudang). Dipanggil setelah login; tidak menampilkan apa pun jika stok aman.
+func ShowLowStockAlert(user *models.User) {
+	if user == nil || !canSeeStock(user) {
+		return
+	}
+	items, err := models.GetLowStock(stockScope(user))
+	if err != nil || len(items) == 0 {
+		return
+	}
+	fmt.Printf("\n⚠️  %d produk sudah mencapai stok minimum:\n", len(items))
+	printLowStock(items)
+}
+
+// showLowStock menampilkan semua produk yang stoknya menipis
+func showLowStock(user *models.User) {
+	items, err := models.GetLowStock(stockScope(user))
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	fmt.Println("\n═══ STOK MENIPIS ═══")
+	printLowStock(items)
+	fmt.Println("💡 Stok minimum diatur di menu Stok & Harga per Gudang")
+}
+
+// stockScope mengembalikan gudang user, nil untuk user dengan akses semua gudang
+func stockScope(user *models.User) *int {
+	if user != nil && !user.HasAllWarehouses() {
+		return user.WarehouseID
+	}
+	return nil
+}
+
+func printLowStock(items []models.LowStockItem) {
+	line := func(left, mid, right string) string {
+		return left + strings.Repeat("─", 18) + mid + strings.Repeat("─", 28) + mid + strings.Repeat("─", 8) +
+			mid + strings.Repeat("─", 8) + mid + strings.Repeat("─", 9) + right
+	}
+	fmt.Println(line("┌", "┬", "┐"))
+	fmt.Printf("│ %-16s │ %-26s │ %6s │ %6s │ %7s │\n", "Gudang", "Produk", "Stok", "Min", "Dipesan")
+	fmt.Println(line("├", "┼", "┤"))
+	if len(items) == 0 {
+		fmt.Printf("│ %-73s │\n", "Semua stok masih di atas stok minimum")
+	}
+	for _, item := range items {
+		fmt.Printf("│ %-16s │ %-26s │ %6d │ %6d │ %7d │\n", truncate(warehouseName(item.WarehouseID), 16),
+			truncate(item.ProductName, 26), item.Stock, item.ReorderPoint, item.OnOrder)
+	}
+	fmt.Println(line("└", "┴", "┘"))
+}
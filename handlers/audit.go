@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditLogMenu menampilkan audit log dengan filter dan pagination
+func AuditLogMenu() {
+	page := 1
+	limit := 15
+	var filter models.AuditFilter
+
+	for {
+		logs, total, err := models.GetAuditLogs(filter, page, limit)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return
+		}
+
+		totalPages := (total + limit - 1) / limit
+		if totalPages == 0 {
+			totalPages = 1
+		}
+
+		fmt.Println("\n╔═══════════════════════════════════════════════════════════════════════════════╗")
+		fmt.Println("║                                 AUDIT LOG                                     ║")
+		fmt.Println("╚═══════════════════════════════════════════════════════════════════════════════╝")
+		fmt.Printf("🔍 Filter: %-40s  📄 Page: %d/%d  📋 Total: %d\n", describeAuditFilter(filter), page, totalPages, total)
+
+		fmt.Println("┌───────┬──────────────────┬──────────────┬──────────┬─────────────┬───────────┐")
+		fmt.Println("│ ID    │ Waktu            │ User         │ Aksi     │ Entitas     │ ID Entitas│")
+		fmt.Println("├───────┼──────────────────┼──────────────┼──────────┼─────────────┼───────────┤")
+
+		if len(logs) == 0 {
+			fmt.Println("│                      T I D A K   A D A   D A T A                             │")
+		}
+
+		for _, l := range logs {
+			entityID := "-"
+			if l.EntityID != nil {
+				entityID = strconv.Itoa(*l.EntityID)
+			}
+			fmt.Printf("│ %-5d │ %s │ %-12s │ %-8s │ %-11s │ %9s │\n",
+				l.ID, l.CreatedAt.Format("02-01-2006 15:04"), truncate(l.Username, 12), l.Action, l.Entity, entityID)
+		}
+		fmt.Println("└───────┴──────────────────┴──────────────┴──────────┴─────────────┴───────────┘")
+
+		fmt.Println("\n[n] Next  [p] Prev  [f] Filter  [r] Reset Filter  [d] Detail  [q] Back")
+		fmt.Print("Pilihan: ")
+		input := readInput()
+
+		switch strings.ToLower(input) {
+		case "n":
+			if page < totalPages {
+				page++
+			} else {
+				fmt.Println("⚠️  Sudah di halaman terakhir")
+			}
+		case "p":
+			if page > 1 {
+				page--
+			} else {
+				fmt.Println("⚠️  Sudah di halaman pertama")
+			}
+		case "f":
+			filter = promptAuditFilter()
+			page = 1
+		case "r":
+			filter = models.AuditFilter{}
+			page = 1
+		case "d":
+			showAuditDetail(logs)
+		case "q":
+			return
+		default:
+			if pNum, err := strconv.Atoi(input); err == nil && len(input) > 0 {
+				if pNum >= 1 && pNum <= totalPages {
+					page = pNum
+				}
+			}
+		}
+	}
+}
+
+func promptAuditFilter() models.AuditFilter {
+	var filter models.AuditFilter
+
+	fmt.Println("\n═══ FILTER AUDIT LOG ═══")
+	fmt.Println("(Tekan Enter untuk melewati)")
+
+	fmt.Print("Entitas (product/warehouse/user/role/transaction): ")
+	filter.Entity = strings.ToLower(readInput())
+
+	fmt.Print("Aksi (create/update/delete/import/export): ")
+	filter.Action = strings.ToLower(readInput())
+
+	fmt.Print("Username: ")
+	filter.Username = readInput()
+
+	fmt.Print("ID Entitas: ")
+	if id, err := strconv.Atoi(readInput()); err == nil {
+		filter.EntityID = id
+	}
+
+	fmt.Print("Dari tanggal (DD-MM-YYYY): ")
+	if date, err := time.ParseInLocation("02-01-2006", readInput(), time.Local); err == nil {
+		filter.From = &date
+	}
+
+	fmt.Print("Sampai tanggal (DD-MM-YYYY): ")
+	if date, err := time.ParseInLocation("02-01-2006", readInput(), time.Local); err == nil {
+		end := date.Add(24 * time.Hour)
+		filter.To = &end
+	}
+
+	return filter
+}
+
+func describeAuditFilter(filter models.AuditFilter) string {
+	var parts []string
+	if filter.Entity != "" {
+		parts = append(parts, "entitas="+filter.Entity)
+	}
+	if filter.Action != "" {
+		parts = append(parts, "aksi="+filter.Action)
+	}
+	if filter.Username != "" {
+		parts = append(parts, "user="+filter.Username)
+	}
+	if filter.EntityID > 0 {
+		parts = append(parts, fmt.Sprintf("id=%d", filter.EntityID))
+	}
+	if filter.From != nil {
+		parts = append(parts, "dari="+filter.From.Format("02-01-2006"))
+	}
+	if filter.To != nil {
+		parts = append(parts, "s/d="+filter.To.Add(-24*time.Hour).Format("02-01-2006"))
+	}
+	if len(parts) == 0 {
+		return "(none)"
+	}
+	return strings.Join(parts, " ")
+}
+
+func showAuditDetail(logs []models.AuditLog) {
+	fmt.Print("Masukkan ID log: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+
+	for _, l := range logs {
+		if l.ID != id {
+			continue
+		}
+
+		fmt.Printf("\n📋 Log #%d - %s %s oleh %s (%s)\n", l.ID, l.Action, l.Entity, l.Username, l.CreatedAt.Format("02-01-2006 15:04:05"))
+		fmt.Println("Sebelum :", jsonOrDash(l.Before))
+		fmt.Println("Sesudah :", jsonOrDash(l.After))
+		fmt.Print("\nTekan Enter untuk melanjutkan...")
+		readInput()
+		return
+	}
+	fmt.Println("❌ Log tidak ada di halaman ini!")
+}
+
+func jsonOrDash(data []byte) string {
+	if len(data) == 0 {
+		return "-"
+	}
+	return string(data)
+}
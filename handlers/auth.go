@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"kasir/models"
 	"os"
@@ -10,8 +11,8 @@ import (
 
 var reader = bufio.NewReader(os.Stdin)
 
-// LoginMenu menampilkan menu login
-func LoginMenu() bool {
+// LoginMenu menampilkan menu login, mengembalikan user yang berhasil login (nil jika gagal)
+func LoginMenu() *models.User {
 	fmt.Println("\n╔══════════════════════════════════════╗")
 	fmt.Println("║              LOGIN                   ║")
 	fmt.Println("╚══════════════════════════════════════╝")
@@ -24,10 +25,10 @@ func LoginMenu() bool {
 	password, _ := reader.ReadString('\n')
 	password = strings.TrimSpace(password)
 
-	user, err := models.Login(username, password)
+	user, err := models.Authenticate(username, password, "")
 	if err != nil {
 		fmt.Println("❌", err)
-		return false
+		return nil
 	}
 
 	warehouseInfo := "Semua Gudang"
@@ -40,11 +41,11 @@ func LoginMenu() bool {
 
 	fmt.Printf("\n✅ Login berhasil! Selamat datang, %s (%s)\n", user.Username, user.Role)
 	fmt.Printf("📦 Gudang: %s\n", warehouseInfo)
-	return true
+	return user
 }
 
 // UserMenu menampilkan menu manajemen user (admin only)
-func UserMenu() {
+func UserMenu(user *models.User) {
 	for {
 		fmt.Println("\n╔══════════════════════════════════════╗")
 		fmt.Println("║        MANAJEMEN USER                ║")
@@ -52,6 +53,7 @@ func UserMenu() {
 		fmt.Println("║  1. Lihat Daftar User                ║")
 		fmt.Println("║  2. Tambah User Baru                 ║")
 		fmt.Println("║  3. Hapus User                       ║")
+		fmt.Println("║  4. Buka Kunci User                  ║")
 		fmt.Println("║  0. Kembali ke Menu Utama            ║")
 		fmt.Println("╚══════════════════════════════════════╝")
 
@@ -63,9 +65,11 @@ func UserMenu() {
 		case "1":
 			listUsers()
 		case "2":
-			registerUser()
+			registerUser(user)
 		case "3":
-			deleteUser()
+			deleteUser(user)
+		case "4":
+			unlockUser(user)
 		case "0":
 			return
 		default:
@@ -81,9 +85,9 @@ func listUsers() {
 		return
 	}
 
-	fmt.Println("\n┌─────┬────────────────────┬──────────┬────────────────────────┐")
-	fmt.Println("│ ID  │ Username           │ Role     │ Gudang                 │")
-	fmt.Println("├─────┼────────────────────┼──────────┼────────────────────────┤")
+	fmt.Println("\n┌─────┬────────────────────┬──────────────┬────────────────────────┬──────────────────┐")
+	fmt.Println("│ ID  │ Username           │ Role         │ Gudang                 │ Status           │")
+	fmt.Println("├─────┼────────────────────┼──────────────┼────────────────────────┼──────────────────┤")
 
 	for _, u := range users {
 		warehouseName := "Semua Gudang"
@@ -93,12 +97,16 @@ func listUsers() {
 				warehouseName = warehouse.Name
 			}
 		}
-		fmt.Printf("│ %-3d │ %-18s │ %-8s │ %-22s │\n", u.ID, u.Username, u.Role, warehouseName)
+		status := "Aktif"
+		if u.LockedUntil != nil {
+			status = "🔒 s/d " + u.LockedUntil.Format("15:04")
+		}
+		fmt.Printf("│ %-3d │ %-18s │ %-12s │ %-22s │ %s │\n", u.ID, u.Username, truncate(u.Role, 12), warehouseName, padText(status, 16))
 	}
-	fmt.Println("└─────┴────────────────────┴──────────┴────────────────────────┘")
+	fmt.Println("└─────┴────────────────────┴──────────────┴────────────────────────┴──────────────────┘")
 }
 
-func registerUser() {
+func registerUser(user *models.User) {
 	fmt.Println("\n═══ TAMBAH USER BARU ═══")
 
 	fmt.Print("Username: ")
@@ -109,17 +117,29 @@ func registerUser() {
 	password, _ := reader.ReadString('\n')
 	password = strings.TrimSpace(password)
 
-	fmt.Print("Role (admin/user): ")
-	role, _ := reader.ReadString('\n')
-	role = strings.TrimSpace(role)
+	roles, err := models.GetAllRoles()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	var roleNames []string
+	for _, r := range roles {
+		roleNames = append(roleNames, r.Name)
+	}
+
+	fmt.Printf("Role (%s): ", strings.Join(roleNames, "/"))
+	roleName, _ := reader.ReadString('\n')
+	roleName = strings.TrimSpace(roleName)
 
-	if role != "admin" && role != "user" {
-		fmt.Println("❌ Role harus 'admin' atau 'user'!")
+	role, err := models.GetRoleByName(roleName)
+	if err != nil {
+		fmt.Printf("❌ Role harus salah satu dari: %s\n", strings.Join(roleNames, ", "))
 		return
 	}
 
+	// Role tanpa akses semua gudang wajib terikat ke satu gudang
 	var warehouseID *int
-	if role == "user" {
+	if role.Name != models.AdminRole && !containsString(role.Permissions, models.PermAllWarehouses) {
 		// Tampilkan daftar gudang
 		warehouses, err := models.GetAllWarehouses()
 		if err != nil {
@@ -139,16 +159,16 @@ func registerUser() {
 		warehouseID = &wID
 	}
 
-	user, err := models.Register(username, password, role, warehouseID)
+	newUser, err := models.Register(user, username, password, role.Name, warehouseID)
 	if err != nil {
 		fmt.Println("❌", err)
 		return
 	}
 
-	fmt.Printf("✅ User '%s' berhasil ditambahkan dengan ID: %d\n", user.Username, user.ID)
+	fmt.Printf("✅ User '%s' berhasil ditambahkan dengan ID: %d\n", newUser.Username, newUser.ID)
 }
 
-func deleteUser() {
+func deleteUser(user *models.User) {
 	listUsers()
 
 	fmt.Print("\nMasukkan ID user yang akan dihapus (0 untuk batal): ")
@@ -161,12 +181,12 @@ func deleteUser() {
 	}
 
 	// Jangan izinkan hapus diri sendiri
-	if models.CurrentUser != nil && models.CurrentUser.ID == id {
+	if user != nil && user.ID == id {
 		fmt.Println("❌ Tidak bisa menghapus akun sendiri!")
 		return
 	}
 
-	err := models.DeleteUser(id)
+	err := models.DeleteUser(user, id)
 	if err != nil {
 		fmt.Println("❌", err)
 		return
@@ -175,8 +195,28 @@ func deleteUser() {
 	fmt.Println("✅ User berhasil dihapus!")
 }
 
+func unlockUser(user *models.User) {
+	listUsers()
+
+	fmt.Print("\nMasukkan username yang akan dibuka kuncinya (kosongkan untuk batal): ")
+	username, _ := reader.ReadString('\n')
+	username = strings.TrimSpace(username)
+
+	if username == "" {
+		return
+	}
+
+	err := models.UnlockUser(user, username)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Printf("✅ Akun '%s' berhasil dibuka kuncinya!\n", username)
+}
+
 // ChangePassword menangani proses ubah password user
-func ChangePassword() {
+func ChangePassword(user *models.User) {
 	fmt.Println("\n═══ UBAH PASSWORD ═══")
 
 	// 1. Verifikasi Password Lama
@@ -184,17 +224,20 @@ func ChangePassword() {
 	oldPassword, _ := reader.ReadString('\n')
 	oldPassword = strings.TrimSpace(oldPassword)
 
-	// Cek password lama dengan query sederhana ke DB atau field user saat ini
-	// Karena kita tidak menyimpan password plain text di struct User saat login (biasanya),
-	// kita cek manual ke DB untuk validasi credentials
-	if models.CurrentUser == nil {
+	// Verifikasi password lama ke DB (hash bcrypt), bukan ke field struct User
+	if user == nil {
 		fmt.Println("❌ Anda belum login!")
 		return
 	}
 
-	_, err := models.Login(models.CurrentUser.Username, oldPassword)
+	_, err := models.Authenticate(user.Username, oldPassword, "")
 	if err != nil {
-		fmt.Println("❌ Password lama salah!")
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			fmt.Println("❌", err)
+		} else {
+			fmt.Println("❌ Password lama salah!")
+		}
 		return
 	}
 
@@ -208,8 +251,8 @@ func ChangePassword() {
 		return
 	}
 
-	// 3. Update Password
-	err = models.UpdatePassword(models.CurrentUser.ID, newPassword)
+	// 3. Update Password (di-hash di models.UpdatePassword)
+	err = models.UpdatePassword(user, user.ID, newPassword)
 	if err != nil {
 		fmt.Printf("❌ Gagal mengubah password: %v\n", err)
 		return
@@ -217,3 +260,12 @@ func ChangePassword() {
 
 	fmt.Println("✅ Password berhasil diubah!")
 }
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchMenu menampilkan menu batch dan tanggal kedaluwarsa. Penjualan selalu mengambil batch yang
+// paling cepat kedaluwarsa; batch yang sudah lewat tanggalnya tidak bisa dijual dan perlu dimusnahkan.
+func BatchMenu(user *models.User) {
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+	items := []MenuItem{
+		{"Lihat Batch Produk", as(listProductBatches)},
+		{"Batch Mendekati Kedaluwarsa", as(showExpiringBatches)},
+		{"Tambah Stok per Batch", as(addStockBatch)},
+		{"Musnahkan Batch", as(writeOffBatch)},
+		{"Musnahkan Semua Batch Kedaluwarsa", as(writeOffExpiredBatches)},
+	}
+
+	for {
+		var info []string
+		if !user.HasAllWarehouses() && user.WarehouseID != nil {
+			info = append(info, "Gudang: "+warehouseName(*user.WarehouseID))
+		}
+		PrintMenu("BATCH & KEDALUWARSA", info, items, "Kembali")
+		fmt.Print("Pilihan: ")
+
+		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+// chooseBatchTarget meminta produk dan gudang; user gudang selalu memakai gudangnya sendiri
+func chooseBatchTarget(user *models.User) (*models.Product, int, bool) {
+	fmt.Print("\nProduk (ID, SKU, atau scan barcode): ")
+	code := readInput()
+	var product *models.Product
+	if id, err := strconv.Atoi(code); err == nil {
+		product, _ = models.GetProductByID(id)
+	}
+	if product == nil {
+		product = catalogProduct(code)
+	}
+	if product == nil {
+		fmt.Println("❌ Produk tidak ditemukan!")
+		return nil, 0, false
+	}
+
+	if user != nil && !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return nil, 0, false
+		}
+		return product, *user.WarehouseID, true
+	}
+	warehouses, _ := models.GetAllWarehouses()
+	fmt.Println("Gudang:")
+	for _, w := range warehouses {
+		fmt.Printf("  %d. %s\n", w.ID, w.Name)
+	}
+	fmt.Print("ID Gudang: ")
+	warehouseID, err := strconv.Atoi(readInput())
+	if err != nil || warehouseID <= 0 {
+		fmt.Println("❌ ID gudang tidak valid!")
+		return nil, 0, false
+	}
+	return product, warehouseID, true
+}
+
+// listProductBatches menampilkan batch sebuah produk di satu gudang, urutan barang keluar (FEFO)
+func listProductBatches(user *models.User) {
+	product, warehouseID, ok := chooseBatchTarget(user)
+	if !ok {
+		return
+	}
+	batches, err := models.GetBatches(product.ID, warehouseID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	stock := 0
+	if st, err := models.GetProductInWarehouse(product.ID, warehouseID); err == nil {
+		stock = st.Stock
+	}
+
+	fmt.Printf("\n═══ BATCH %s DI %s ═══\n", strings.ToUpper(product.DisplayName()), strings.ToUpper(warehouseName(warehouseID)))
+	printBatches(batches, time.Now())
+	tracked := 0
+	for _, b := range batches {
+		tracked += b.Remaining
+	}
+	fmt.Printf("Stok gudang: %d, dalam batch: %d, tanpa batch: %d\n", stock, tracked, stock-tracked)
+}
+
+func printBatches(batches []models.StockBatch, now time.Time) {
+	fmt.Println("┌──────┬──────────────────┬────────────┬────────┬────────┬──────────────┐")
+	fmt.Println("│ ID   │ No. Batch        │ Kedaluwarsa│ Masuk  │ Sisa   │ Keterangan   │")
+	fmt.Println("├──────┼──────────────────┼────────────┼────────┼────────┼──────────────┤")
+	if len(batches) == 0 {
+		fmt.Printf("│ %-69s │\n", "Belum ada batch")
+	}
+	for _, b := range batches {
+		fmt.Printf("│ %-4d │ %-16s │ %-10s │ %6d │ %6d │ %-12s │\n", b.ID, truncate(b.BatchNo, 16),
+			b.ExpiryDate.Format("02-01-2006"), b.Quantity, b.Remaining, expiryLabel(b.DaysLeft(now)))
+	}
+	fmt.Println("└──────┴──────────────────┴────────────┴────────┴────────┴──────────────┘")
+}
+
+// expiryLabel menjelaskan sisa hari sebuah batch
+func expiryLabel(days int) string {
+	switch {
+	case days < 0:
+		return "KEDALUWARSA"
+	case days == 0:
+		return "hari terakhir"
+	default:
+		return fmt.Sprintf("%d hari lagi", days)
+	}
+}
+
+// addStockBatch menambah stok produk sebagai batch baru dengan tanggal kedaluwarsa
+func addStockBatch(user *models.User) {
+	product, warehouseID, ok := chooseBatchTarget(user)
+	if !ok {
+		return
+	}
+	fmt.Print("No. Batch: ")
+	batchNo := readInput()
+	if batchNo == "" {
+		fmt.Println("❌ Nomor batch tidak boleh kosong!")
+		return
+	}
+	expiry, ok := promptDate("Tanggal kedaluwarsa (DD-MM-YYYY): ", time.Time{})
+	if !ok {
+		return
+	}
+	if expiry.IsZero() {
+		fmt.Println("❌ Tanggal kedaluwarsa wajib diisi!")
+		return
+	}
+	fmt.Print("Jumlah: ")
+	qty, err := strconv.Atoi(readInput())
+	if err != nil || qty <= 0 {
+		fmt.Println("❌ Jumlah tidak valid!")
+		return
+	}
+
+	batch, err := models.AddStockBatch(user, product.ID, warehouseID, batchNo, expiry, qty)
+	if err != nil {
+		fmt.Printf("❌ Gagal menambah batch: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Batch %s (%d unit, kedaluwarsa %s) ditambahkan ke %s\n", batch.BatchNo, batch.Quantity,
+		batch.ExpiryDate.Format("02-01-2006"), warehouseName(batch.WarehouseID))
+}
+
+// showExpiringBatches menampilkan batch yang kedaluwarsa dalam beberapa hari ke depan
+func showExpiringBatches(user *models.User) {
+	days := 30
+	fmt.Printf("Kedaluwarsa dalam berapa hari [%d]: ", days)
+	if input := readInput(); input != "" {
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 0 {
+			fmt.Println("❌ Jumlah hari tidak valid!")
+			return
+		}
+		days = n
+	}
+
+	report, err := models.GetExpiringBatches(stockScope(user), days)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\n═══ BATCH KEDALUWARSA DALAM %d HARI ═══\n", days)
+	fmt.Println("┌──────┬────────────────┬──────────────────────┬──────────────┬────────────┬────────┬──────────────┬──────────────┐")
+	fmt.Println("│ ID   │ Gudang         │ Produk               │ No. Batch    │ Kedaluwarsa│ Sisa   │ Keterangan   │ Nilai        │")
+	fmt.Println("├──────┼────────────────┼──────────────────────┼──────────────┼────────────┼────────┼──────────────┼──────────────┤")
+	if len(report) == 0 {
+		fmt.Printf("│ %-111s │\n", "Tidak ada batch yang mendekati kedaluwarsa")
+	}
+	var total float64
+	for _, b := range report {
+		fmt.Printf("│ %-4d │ %-14s │ %-20s │ %-12s │ %-10s │ %6d │ %-12s │ %12s │\n", b.ID,
+			truncate(warehouseName(b.WarehouseID), 14), truncate(b.ProductName, 20), truncate(b.BatchNo, 12),
+			b.ExpiryDate.Format("02-01-2006"), b.Remaining, expiryLabel(b.DaysLeft), formatRupiah(b.Value))
+		total += b.Value
+	}
+	fmt.Println("└──────┴────────────────┴──────────────────────┴──────────────┴────────────┴────────┴──────────────┴──────────────┘")
+	fmt.Printf("Total nilai: %s\n", formatRupiah(total))
+}
+
+// writeOffBatch memusnahkan sisa satu batch
+func writeOffBatch(user *models.User) {
+	fmt.Print("\nID Batch yang dimusnahkan: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	fmt.Printf("Musnahkan sisa batch ID %d? Stok gudang akan berkurang (y/n): ", id)
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+	b, err := models.WriteOffBatch(user, id)
+	if err != nil {
+		fmt.Printf("❌ Gagal memusnahkan batch: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Batch %s (%d unit) dimusnahkan dari %s\n", b.BatchNo, b.Remaining, warehouseName(b.WarehouseID))
+}
+
+// writeOffExpiredBatches memusnahkan semua batch yang sudah kedaluwarsa di gudang user
+func writeOffExpiredBatches(user *models.User) {
+	fmt.Print("\nMusnahkan semua batch yang sudah kedaluwarsa? (y/n): ")
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+	batches, err := models.WriteOffExpiredBatches(user, stockScope(user))
+	if err != nil {
+		fmt.Printf("❌ Gagal memusnahkan batch: %v\n", err)
+		return
+	}
+	if len(batches) == 0 {
+		fmt.Println("💡 Tidak ada batch yang kedaluwarsa")
+		return
+	}
+	units := 0
+	for _, b := range batches {
+		units += b.Remaining
+	}
+	fmt.Printf("✅ %d batch (%d unit) dimusnahkan\n", len(batches), units)
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// CategoryMenu menampilkan menu kategori dan merek produk
+func CategoryMenu(user *models.User) {
+	items := []MenuItem{
+		{"Lihat Kategori", listCategories},
+		{"Tambah Kategori", func() { addCategory(user) }},
+		{"Edit Kategori", func() { editCategory(user) }},
+		{"Hapus Kategori", func() { deleteCategory(user) }},
+		{"Lihat Merek", listBrands},
+		{"Tambah Merek", func() { addBrand(user) }},
+		{"Edit Merek", func() { editBrand(user) }},
+		{"Hapus Merek", func() { deleteBrand(user) }},
+	}
+
+	for {
+		PrintMenu("KATEGORI & MEREK", nil, items, "Kembali")
+		fmt.Print("Pilihan: ")
+
+		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+func listCategories() {
+	categories, err := models.GetAllCategories()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	fmt.Println("\n┌─────┬──────────────────────────────────────────────────┐")
+	fmt.Println("│ ID  │ Kategori                                         │")
+	fmt.Println("├─────┼──────────────────────────────────────────────────┤")
+	if len(categories) == 0 {
+		fmt.Println("│          B E L U M   A D A   K A T E G O R I           │")
+	}
+	for _, c := range categories {
+		// Subkategori ditampilkan menjorok sesuai kedalamannya
+		depth := strings.Count(c.Path, models.CategoryPathSeparator)
+		label := strings.Repeat("  ", depth) + c.Name
+		fmt.Printf("│ %-3d │ %-48s │\n", c.ID, truncate(label, 48))
+	}
+	fmt.Println("└─────┴──────────────────────────────────────────────────┘")
+}
+
+func addCategory(user *models.User) {
+	fmt.Println("\n═══ TAMBAH KATEGORI ═══")
+
+	fmt.Print("Nama Kategori: ")
+	name := readInput()
+
+	listCategories()
+	fmt.Print("ID Kategori Induk (Enter = kategori utama): ")
+	parentID, ok := readRefID(nil, readInput())
+	if !ok {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+
+	category, err := models.CreateCategory(user, name, parentID)
+	if err != nil {
+		fmt.Printf("❌ Gagal menambah kategori: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Kategori '%s' berhasil ditambahkan dengan ID: %d\n", category.Name, category.ID)
+}
+
+func editCategory(user *models.User) {
+	listCategories()
+
+	fmt.Print("\nMasukkan ID kategori yang akan diedit: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	category, err := models.GetCategoryByID(id)
+	if err != nil {
+		fmt.Println("❌ Kategori tidak ditemukan!")
+		return
+	}
+
+	fmt.Println("(Tekan Enter untuk tidak mengubah)")
+	name := category.Name
+	fmt.Printf("Nama [%s]: ", category.Name)
+	if input := readInput(); input != "" {
+		name = input
+	}
+
+	parentLabel := "-"
+	if category.ParentID != nil {
+		if parent, err := models.GetCategoryByID(*category.ParentID); err == nil {
+			parentLabel = parent.Path
+		}
+	}
+	fmt.Printf("Kategori Induk [%s] (ID, Enter = tetap, '-' = jadikan kategori utama): ", parentLabel)
+	parentID, ok := readRefID(category.ParentID, readInput())
+	if !ok {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+
+	if err := models.UpdateCategory(user, id, name, parentID); err != nil {
+		fmt.Printf("❌ Gagal mengupdate kategori: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Kategori berhasil diupdate!")
+}
+
+func deleteCategory(user *models.User) {
+	listCategories()
+
+	fmt.Print("\nMasukkan ID kategori yang akan dihapus: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	category, err := models.GetCategoryByID(id)
+	if err != nil {
+		fmt.Println("❌ Kategori tidak ditemukan!")
+		return
+	}
+
+	fmt.Printf("⚠️  Yakin ingin menghapus kategori '%s'? (y/n): ", category.Path)
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("Batal menghapus.")
+		return
+	}
+
+	if err := models.DeleteCategory(user, id); err != nil {
+		fmt.Printf("❌ Gagal menghapus kategori: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Kategori berhasil dihapus!")
+}
+
+func listBrands() {
+	brands, err := models.GetAllBrands()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	fmt.Println("\n┌─────┬────────────────────────────────┐")
+	fmt.Println("│ ID  │ Merek                          │")
+	fmt.Println("├─────┼────────────────────────────────┤")
+	if len(brands) == 0 {
+		fmt.Println("│    B E L U M   A D A   M E R E K     │")
+	}
+	for _, b := range brands {
+		fmt.Printf("│ %-3d │ %-30s │\n", b.ID, truncate(b.Name, 30))
+	}
+	fmt.Println("└─────┴────────────────────────────────┘")
+}
+
+func addBrand(user *models.User) {
+	fmt.Println("\n═══ TAMBAH MEREK ═══")
+
+	fmt.Print("Nama Merek: ")
+	brand, err := models.CreateBrand(user, readInput())
+	if err != nil {
+		fmt.Printf("❌ Gagal menambah merek: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Merek '%s' berhasil ditambahkan dengan ID: %d\n", brand.Name, brand.ID)
+}
+
+func editBrand(user *models.User) {
+	listBrands()
+
+	fmt.Print("\nMasukkan ID merek yang akan diedit: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	brand, err := models.GetBrandByID(id)
+	if err != nil {
+		fmt.Println("❌ Merek tidak ditemukan!")
+		return
+	}
+
+	fmt.Printf("Nama [%s]: ", brand.Name)
+	name := readInput()
+	if name == "" {
+		return
+	}
+
+	if err := models.UpdateBrand(user, id, name); err != nil {
+		fmt.Printf("❌ Gagal mengupdate merek: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Merek berhasil diupdate!")
+}
+
+func deleteBrand(user *models.User) {
+	listBrands()
+
+	fmt.Print("\nMasukkan ID merek yang akan dihapus: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	brand, err := models.GetBrandByID(id)
+	if err != nil {
+		fmt.Println("❌ Merek tidak ditemukan!")
+		return
+	}
+
+	fmt.Printf("⚠️  Yakin ingin menghapus merek '%s'? (y/n): ", brand.Name)
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("Batal menghapus.")
+		return
+	}
+
+	if err := models.DeleteBrand(user, id); err != nil {
+		fmt.Printf("❌ Gagal menghapus merek: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Merek berhasil dihapus!")
+}
+
+// promptCategory menampilkan daftar kategori lalu membaca ID pilihan
+// (Enter = tetap, '-' = tanpa kategori). ok=false jika input tidak valid.
+func promptCategory(current *int) (*int, bool) {
+	categories, err := models.GetAllCategories()
+	if err != nil || len(categories) == 0 {
+		return current, true
+	}
+
+	label := "-"
+	fmt.Println("\nKategori:")
+	for _, c := range categories {
+		fmt.Printf("  %d. %s\n", c.ID, c.Path)
+		if current != nil && *current == c.ID {
+			label = c.Path
+		}
+	}
+	fmt.Printf("ID Kategori [%s] (Enter = tetap, '-' = tanpa kategori): ", label)
+	return readRefID(current, readInput())
+}
+
+// promptBrand menampilkan daftar merek lalu membaca ID pilihan
+// (Enter = tetap, '-' = tanpa merek). ok=false jika input tidak valid.
+func promptBrand(current *int) (*int, bool) {
+	brands, err := models.GetAllBrands()
+	if err != nil || len(brands) == 0 {
+		return current, true
+	}
+
+	label := "-"
+	fmt.Println("\nMerek:")
+	for _, b := range brands {
+		fmt.Printf("  %d. %s\n", b.ID, b.Name)
+		if current != nil && *current == b.ID {
+			label = b.Name
+		}
+	}
+	fmt.Printf("ID Merek [%s] (Enter = tetap, '-' = tanpa merek): ", label)
+	return readRefID(current, readInput())
+}
+
+// readRefID membaca ID referensi opsional: kosong = tetap, "-" = dikosongkan
+func readRefID(current *int, input string) (*int, bool) {
+	switch input {
+	case "":
+		return current, true
+	case "-":
+		return nil, true
+	}
+	id, err := strconv.Atoi(input)
+	if err != nil || id <= 0 {
+		return current, false
+	}
+	return &id, true
+}
+
+// promptCategoryFilter membaca kategori untuk memfilter daftar produk (Enter = semua kategori)
+func promptCategoryFilter() *int {
+	listCategories()
+	fmt.Print("ID Kategori (termasuk subkategori, Enter = semua kategori): ")
+	categoryID, ok := readRefID(nil, readInput())
+	if !ok {
+		fmt.Println("❌ ID tidak valid, filter kategori dihapus")
+		return nil
+	}
+	return categoryID
+}
+
+// printCategoryFilter menampilkan filter kategori yang sedang aktif di daftar produk
+func printCategoryFilter(filter models.ProductFilter) {
+	if filter.CategoryID == nil {
+		return
+	}
+	label := fmt.Sprintf("ID %d", *filter.CategoryID)
+	if c, err := models.GetCategoryByID(*filter.CategoryID); err == nil {
+		label = c.Path
+	}
+	fmt.Printf("🏷️  Kategori: %s\n", label)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// menuWidth adalah lebar bagian dalam kotak menu (di antara ║ ... ║)
+const menuWidth = 38
+
+// MenuItem adalah satu pilihan menu beserta aksinya
+type MenuItem struct {
+	Label  string
+	Action func()
+}
+
+// PrintMenu menampilkan kotak menu dengan nomor pilihan otomatis.
+// Baris info (misal nama gudang) ditampilkan di bawah judul jika ada.
+func PrintMenu(title string, info []string, items []MenuItem, exitLabel string) {
+	border := strings.Repeat("═", menuWidth)
+
+	fmt.Printf("\n╔%s╗\n", border)
+	fmt.Printf("║%s║\n", centerText(title, menuWidth))
+	fmt.Printf("╠%s╣\n", border)
+	if len(info) > 0 {
+		for _, line := range info {
+			fmt.Printf("║  %s║\n", padText(line, menuWidth-2))
+		}
+		fmt.Printf("╠%s╣\n", border)
+	}
+	for i, item := range items {
+		fmt.Printf("║ %2d. %s║\n", i+1, padText(item.Label, menuWidth-5))
+	}
+	fmt.Printf("║  0. %s║\n", padText(exitLabel, menuWidth-5))
+	fmt.Printf("╚%s╝\n", border)
+}
+
+// RunMenuChoice menjalankan aksi sesuai nomor pilihan, false jika pilihan tidak valid
+func RunMenuChoice(items []MenuItem, choice string) bool {
+	n, err := strconv.Atoi(choice)
+	if err != nil || n < 1 || n > len(items) {
+		return false
+	}
+	items[n-1].Action()
+	return true
+}
+
+// displayWidth menghitung lebar tampilan teks di terminal (emoji = 2 kolom)
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r >= 0x1F000 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func padText(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+func centerText(s string, width int) string {
+	w := displayWidth(s)
+	if w >= width {
+		return s
+	}
+	left := (width - w) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-w-left)
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/config"
+	"kasir/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// OpnameMenu menampilkan menu stock opname (hitung fisik). Petugas gudang membuka sesi dan mengisi
+// hasil hitung; selisih baru dibukukan ke stok setelah disetujui supervisor.
+func OpnameMenu(user *models.User) {
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+	var items []MenuItem
+	if user.Can(models.PermStockOpname) {
+		items = append(items,
+			MenuItem{"Daftar Stock Opname", as(listOpnames)},
+			MenuItem{"Mulai Stock Opname", as(createOpname)},
+			MenuItem{"Input Hitung Fisik", as(countOpname)},
+			MenuItem{"Export Lembar Hitung (Excel)", as(exportOpname)},
+			MenuItem{"Import Hasil Hitung (Excel)", as(importOpname)},
+			MenuItem{"Batalkan Stock Opname", as(cancelOpname)},
+		)
+	} else {
+		items = append(items, MenuItem{"Daftar Stock Opname", as(listOpnames)})
+	}
+	if user.Can(models.PermOpnameApprove) {
+		items = append(items, MenuItem{"Setujui Stock Opname", as(approveOpname)})
+	}
+
+	for {
+		var info []string
+		if !user.HasAllWarehouses() && user.WarehouseID != nil {
+			info = append(info, "Gudang: "+warehouseName(*user.WarehouseID))
+		}
+		PrintMenu("STOCK OPNAME", info, items, "Kembali ke Menu Utama")
+		fmt.Print("Pilihan: ")
+
+		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+// listOpnames menampilkan semua stock opname di gudang user, lalu detail sesi yang dipilih
+func listOpnames(user *models.User) {
+	opnames, err := models.GetOpnames(user, "")
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	printOpnames("DAFTAR STOCK OPNAME", opnames)
+	if len(opnames) == 0 {
+		return
+	}
+
+	fmt.Print("\nLihat detail ID stock opname (Enter = kembali): ")
+	input := readInput()
+	if input == "" {
+		return
+	}
+	id, err := strconv.Atoi(input)
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	o, err := models.GetOpname(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	printOpname(o)
+}
+
+func printOpnames(title string, opnames []models.StockOpname) {
+	fmt.Printf("\n═══ %s ═══\n", title)
+	fmt.Println("┌───────┬──────────────────┬──────────────┬──────────────────┬──────────────────┐")
+	fmt.Println("│ ID    │ Gudang           │ Status       │ Dibuat           │ Disetujui        │")
+	fmt.Println("├───────┼──────────────────┼──────────────┼──────────────────┼──────────────────┤")
+	if len(opnames) == 0 {
+		fmt.Println("│              B E L U M   A D A   S T O C K   O P N A M E                 │")
+	}
+	for _, o := range opnames {
+		approved := "-"
+		if o.ApprovedAt != nil {
+			approved = o.ApprovedAt.Format("02-01-2006 15:04")
+		}
+		fmt.Printf("│ %-5d │ %-16s │ %-12s │ %-16s │ %-16s │\n", o.ID, truncate(warehouseName(o.WarehouseID), 16),
+			models.OpnameStatusLabel(o.Status), o.CreatedAt.Format("02-01-2006 15:04"), approved)
+	}
+	fmt.Println("└───────┴──────────────────┴──────────────┴──────────────────┴──────────────────┘")
+}
+
+// printOpname menampilkan detail stock opname: stok sistem, hasil hitung, dan selisih beserta nilainya
+func printOpname(o *models.StockOpname) {
+	fmt.Printf("\n📋 OPN-%06d: %s (%s)\n", o.ID, warehouseName(o.WarehouseID), models.OpnameStatusLabel(o.Status))
+	fmt.Printf("Dibuat   : %s oleh %s\n", o.CreatedAt.Format("02-01-2006 15:04"), orDash(o.CreatedBy))
+	if o.ApprovedAt != nil {
+		fmt.Printf("Disetujui: %s oleh %s\n", o.ApprovedAt.Format("02-01-2006 15:04"), orDash(o.ApprovedBy))
+	}
+	if o.Note != "" {
+		fmt.Printf("Catatan  : %s\n", o.Note)
+	}
+
+	fmt.Println("┌──────────────────────────────┬──────────┬──────────┬──────────┬──────────────────┐")
+	fmt.Println("│ Produk                       │ Sistem   │ Fisik    │ Selisih  │ Nilai Selisih    │")
+	fmt.Println("├──────────────────────────────┼──────────┼──────────┼──────────┼──────────────────┤")
+	for _, item := range o.Items {
+		counted, variance, value := "-", "-", "-"
+		if item.Counted != nil {
+			counted, variance = strconv.Itoa(*item.Counted), fmt.Sprintf("%+d", item.Variance())
+			value = formatVariance(item.VarianceValue())
+		}
+		fmt.Printf("│ %-28s │ %8d │ %8s │ %8s │ %16s │\n", truncate(item.ProductName, 28), item.SystemStock,
+			counted, variance, value)
+	}
+	fmt.Println("├──────────────────────────────┴──────────┴──────────┴──────────┼──────────────────┤")
+	fmt.Printf("│ %-61s │ %16s │\n", "TOTAL NILAI SELISIH (harga beli)", formatVariance(o.VarianceValue()))
+	fmt.Println("└───────────────────────────────────────────────────────────────┴──────────────────┘")
+	if n := o.Uncounted(); n > 0 {
+		fmt.Printf("💡 %d produk belum dihitung\n", n)
+	}
+}
+
+// formatVariance memformat nilai selisih dengan tanda minus untuk kerugian
+func formatVariance(amount float64) string {
+	if amount < 0 {
+		return "-" + formatRupiah(-amount)
+	}
+	return formatRupiah(amount)
+}
+
+// chooseOpname menampilkan stock opname yang masih dihitung dan meminta user memilih salah satu
+func chooseOpname(user *models.User) *models.StockOpname {
+	opnames, err := models.GetOpnames(user, models.OpnameOpen)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return nil
+	}
+	printOpnames("STOCK OPNAME BERJALAN", opnames)
+	if len(opnames) == 0 {
+		return nil
+	}
+
+	fmt.Print("\nID Stock Opname: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return nil
+	}
+	o, err := models.GetOpname(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return nil
+	}
+	if o.Status != models.OpnameOpen {
+		fmt.Printf("❌ Stock opname %d sudah %s\n", o.ID, models.OpnameStatusLabel(o.Status))
+		return nil
+	}
+	return o
+}
+
+// createOpname membuka sesi stock opname di gudang user (atau gudang pilihan admin)
+func createOpname(user *models.User) {
+	fmt.Println("\n═══ MULAI STOCK OPNAME ═══")
+
+	var warehouseID int
+	if !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return
+		}
+		warehouseID = *user.WarehouseID
+		fmt.Printf("Gudang: %s\n", warehouseName(warehouseID))
+	} else {
+		var ok bool
+		if warehouseID, ok = chooseWarehouse("Gudang", 0); !ok {
+			return
+		}
+	}
+	fmt.Print("Catatan (opsional): ")
+	note := readInput()
+
+	o, err := models.CreateOpname(user, warehouseID, note)
+	if err != nil {
+		fmt.Printf("❌ Gagal membuat stock opname: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Stock opname OPN-%06d dibuka, stok sistem %d produk sudah dicatat\n", o.ID, len(o.Items))
+	fmt.Println("💡 Isi hasil hitung lewat menu Input Hitung Fisik atau Import Hasil Hitung (Excel)")
+}
+
+// countOpname mencatat hasil hitung fisik dengan mengetik atau scan kode produk lalu jumlahnya
+func countOpname(user *models.User) {
+	o := chooseOpname(user)
+	if o == nil {
+		return
+	}
+	lines := make(map[int]models.StockOpnameItem, len(o.Items))
+	for _, item := range o.Items {
+		lines[item.ProductID] = item
+	}
+
+	counts := make(map[int]int)
+	fmt.Println("\nMasukkan produk yang dihitung (ID, SKU, atau scan barcode). Enter kosong = selesai.")
+	for {
+		fmt.Print("Produk: ")
+		code := readInput()
+		if code == "" {
+			break
+		}
+		product, err := findWarehouseProduct(code, o.WarehouseID)
+		if err != nil {
+			fmt.Println("❌", err)
+			continue
+		}
+		item, ok := lines[product.ID]
+		if !ok {
+			fmt.Printf("❌ %s tidak ada di stock opname ini\n", product.DisplayName())
+			continue
+		}
+
+		prompt := fmt.Sprintf("Jumlah fisik %s: ", item.ProductName)
+		if qty, ok := counts[item.ProductID]; ok {
+			prompt = fmt.Sprintf("Jumlah fisik %s [%d]: ", item.ProductName, qty)
+		} else if item.Counted != nil {
+			prompt = fmt.Sprintf("Jumlah fisik %s [%d]: ", item.ProductName, *item.Counted)
+		}
+		fmt.Print(prompt)
+		qty, err := strconv.Atoi(readInput())
+		if err != nil || qty < 0 {
+			fmt.Println("❌ Jumlah tidak valid!")
+			continue
+		}
+		counts[item.ProductID] = qty
+	}
+	if len(counts) == 0 {
+		fmt.Println("❌ Belum ada hasil hitung yang diisi.")
+		return
+	}
+
+	updated, err := models.RecordOpnameCounts(user, o.ID, counts)
+	if err != nil {
+		fmt.Printf("❌ Gagal menyimpan hasil hitung: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Hasil hitung %d produk disimpan\n", len(counts))
+	printOpname(updated)
+}
+
+// exportOpname menulis lembar hitung stock opname ke file Excel
+func exportOpname(user *models.User) {
+	o := chooseOpname(user)
+	if o == nil {
+		return
+	}
+	filePath, err := ExportOpnameExcel(user, o.ID)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Lembar hitung %d produk disimpan ke file:\n", len(o.Items))
+	fmt.Printf("   📄 %s\n", filePath)
+	fmt.Println("💡 Isi kolom Jumlah Fisik, lalu import lewat menu Import Hasil Hitung (Excel)")
+}
+
+// ExportOpnameExcel menulis lembar hitung stock opname id ke file Excel di folder export.excel_dir
+// dan mengembalikan path file-nya. Stok sistem sengaja tidak ditulis agar hasil hitung tidak
+// terpengaruh angka sistem.
+func ExportOpnameExcel(user *models.User, id int) (string, error) {
+	o, err := models.GetOpname(user, id)
+	if err != nil {
+		return "", err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Stock Opname"
+	f.SetSheetName("Sheet1", sheetName)
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	headers := []string{"ID", "SKU", "Barcode", "Nama Produk", "Jumlah Fisik"}
+	for i, h := range headers {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, h)
+		f.SetCellStyle(sheetName, cell, cell, headerStyle)
+	}
+	f.SetColWidth(sheetName, "A", "A", 8)
+	f.SetColWidth(sheetName, "B", "C", 16)
+	f.SetColWidth(sheetName, "D", "D", 35)
+	f.SetColWidth(sheetName, "E", "E", 14)
+
+	for i, item := range o.Items {
+		row := i + 2
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), item.ProductID)
+		if p, err := models.GetProductByID(item.ProductID); err == nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), p.SKU)
+			// Barcode ditulis sebagai teks agar angka 0 di depan tidak hilang
+			f.SetCellStr(sheetName, fmt.Sprintf("C%d", row), p.Barcode)
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), item.ProductName)
+		if item.Counted != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), *item.Counted)
+		}
+	}
+
+	excelDir := config.App.Export.ExcelDir
+	if err := os.MkdirAll(excelDir, 0755); err != nil {
+		return "", fmt.Errorf("gagal membuat folder: %v", err)
+	}
+	filename := fmt.Sprintf("opname_%06d_%s.xlsx", o.ID, time.Now().Format("20060102_150405"))
+	filePath := filepath.Join(excelDir, filename)
+	if err := f.SaveAs(filePath); err != nil {
+		return "", fmt.Errorf("gagal menyimpan file: %v", err)
+	}
+	return filePath, nil
+}
+
+// importOpname membaca hasil hitung dari lembar hitung Excel
+func importOpname(user *models.User) {
+	o := chooseOpname(user)
+	if o == nil {
+		return
+	}
+	fmt.Println("\nFormat Excel sama dengan hasil Export Lembar Hitung:")
+	fmt.Println("  Kolom A: ID Produk (atau kosong jika SKU/barcode diisi)")
+	fmt.Println("  Kolom B: SKU, Kolom C: Barcode")
+	fmt.Println("  Kolom E: Jumlah Fisik (kosong = belum dihitung)")
+	fmt.Print("\nMasukkan path file Excel: ")
+	filePath := readInput()
+	if filePath == "" {
+		fmt.Println("❌ Path file tidak boleh kosong!")
+		return
+	}
+
+	result, updated, err := ImportOpnameExcel(user, o.ID, filePath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	for _, msg := range result.Skipped {
+		fmt.Printf("⚠️  %s\n", msg)
+	}
+	fmt.Printf("\n✅ Import selesai: %d produk dihitung", result.Success)
+	if len(result.Skipped) > 0 {
+		fmt.Printf(", %d baris gagal", len(result.Skipped))
+	}
+	fmt.Println()
+	printOpname(updated)
+}
+
+// ImportOpnameExcel mengisi hasil hitung stock opname id dari file Excel berformat lembar hitung
+// (kolom A ID produk, B SKU, C barcode, E jumlah fisik; baris pertama header). Produk dicari dari
+// ID, lalu SKU/barcode. Baris tanpa jumlah fisik dilewati tanpa pesan; baris yang tidak valid
+// dicatat dan baris lain tetap disimpan.
+func ImportOpnameExcel(user *models.User, id int, filePath string) (*ImportResult, *models.StockOpname, error) {
+	o, err := models.GetOpname(user, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := make(map[int]bool, len(o.Items))
+	for _, item := range o.Items {
+		lines[item.ProductID] = true
+	}
+
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal membuka file: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal membaca file: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, nil, fmt.Errorf("file tidak memiliki data (minimal 2 baris: header + data)")
+	}
+
+	result := &ImportResult{}
+	counts := make(map[int]int)
+	for i, row := range rows[1:] {
+		cell := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		if cell(4) == "" {
+			continue
+		}
+		qty, err := strconv.Atoi(cell(4))
+		if err != nil || qty < 0 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Jumlah fisik '%s' tidak valid, dilewati", i+2, cell(4)))
+			continue
+		}
+
+		productID, _ := strconv.Atoi(cell(0))
+		if !lines[productID] {
+			productID = 0
+			if p := catalogProduct(cell(1), cell(2)); p != nil && lines[p.ID] {
+				productID = p.ID
+			}
+		}
+		if productID == 0 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Produk tidak ada di stock opname ini, dilewati", i+2))
+			continue
+		}
+		counts[productID] = qty
+	}
+
+	if len(counts) > 0 {
+		if o, err = models.RecordOpnameCounts(user, id, counts); err != nil {
+			return nil, nil, err
+		}
+	}
+	result.Success = len(counts)
+	return result, o, nil
+}
+
+// approveOpname menampilkan selisih stock opname lalu membukukannya ke stok setelah dikonfirmasi
+func approveOpname(user *models.User) {
+	o := chooseOpname(user)
+	if o == nil {
+		return
+	}
+	printOpname(o)
+
+	if n := o.Uncounted(); n > 0 {
+		fmt.Printf("⚠️  %d produk belum dihitung, stoknya tidak akan diubah\n", n)
+	}
+	fmt.Print("\nSetujui dan bukukan selisih ke stok? (y/n): ")
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("❌ Dibatalkan")
+		return
+	}
+	if _, err := models.ApproveOpname(user, o.ID); err != nil {
+		fmt.Printf("❌ Gagal menyetujui stock opname: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Stock opname OPN-%06d disetujui, selisih %s dibukukan ke stok %s\n", o.ID,
+		formatVariance(o.VarianceValue()), warehouseName(o.WarehouseID))
+}
+
+// cancelOpname membatalkan stock opname yang belum disetujui; stok tidak berubah
+func cancelOpname(user *models.User) {
+	o := chooseOpname(user)
+	if o == nil {
+		return
+	}
+
+	fmt.Printf("Batalkan stock opname OPN-%06d? Hasil hitung akan hilang (y/n): ", o.ID)
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+	if err := models.CancelOpname(user, o.ID); err != nil {
+		fmt.Printf("❌ Gagal membatalkan stock opname: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Stock opname dibatalkan")
+}
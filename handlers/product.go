@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"kasir/config"
 	"kasir/models"
 	"os"
 	"path/filepath"
@@ -12,76 +13,64 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
-// ProductMenu menampilkan menu manajemen produk
-func ProductMenu() {
+// ProductMenu menampilkan menu manajemen produk sesuai permission user
+func ProductMenu(user *models.User) {
 	for {
-		// Menu berbeda untuk admin dan user
-		if models.CurrentUser != nil && models.CurrentUser.IsAdmin() {
-			fmt.Println("\n╔══════════════════════════════════════╗")
-			fmt.Println("║        MANAJEMEN PRODUK (ADMIN)      ║")
-			fmt.Println("╠══════════════════════════════════════╣")
-			fmt.Println("║  1. Lihat Produk (Semua Gudang)      ║")
-			fmt.Println("║  2. Lihat Stok per Gudang            ║")
-			fmt.Println("║  3. Tambah Produk                    ║")
-			fmt.Println("║  4. Edit Produk                      ║")
-			fmt.Println("║  5. Hapus Produk                     ║")
-			fmt.Println("║  6. Export ke Excel                  ║")
-			fmt.Println("║  7. Import dari Excel                ║")
-			fmt.Println("║  0. Kembali ke Menu Utama            ║")
-			fmt.Println("╚══════════════════════════════════════╝")
-		} else {
-			fmt.Println("\n╔══════════════════════════════════════╗")
-			fmt.Println("║        MANAJEMEN PRODUK              ║")
-			fmt.Println("╠══════════════════════════════════════╣")
-			fmt.Println("║  1. Lihat Daftar Produk              ║")
-			fmt.Println("║  2. Tambah Produk                    ║")
-			fmt.Println("║  3. Edit Produk                      ║")
-			fmt.Println("║  4. Hapus Produk                     ║")
-			fmt.Println("║  0. Kembali ke Menu Utama            ║")
-			fmt.Println("╚══════════════════════════════════════╝")
-		}
+		items := productMenuItems(user)
+		PrintMenu("MANAJEMEN PRODUK", nil, items, "Kembali ke Menu Utama")
 		fmt.Print("Pilihan: ")
 
 		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
 
-		if models.CurrentUser != nil && models.CurrentUser.IsAdmin() {
-			switch choice {
-			case "1":
-				ListAllProducts()
-			case "2":
-				listStockByWarehouse()
-			case "3":
-				addProduct()
-			case "4":
-				editProduct()
-			case "5":
-				deleteProduct()
-			case "6":
-				exportToExcel()
-			case "7":
-				importFromExcel()
-			case "0":
-				return
-			default:
-				fmt.Println("❌ Pilihan tidak valid!")
-			}
-		} else {
-			switch choice {
-			case "1":
-				ListProducts()
-			case "2":
-				addProduct()
-			case "3":
-				editProduct()
-			case "4":
-				deleteProduct()
-			case "0":
-				return
-			default:
-				fmt.Println("❌ Pilihan tidak valid!")
-			}
+func productMenuItems(user *models.User) []MenuItem {
+	var items []MenuItem
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+
+	if user.HasAllWarehouses() {
+		items = append(items,
+			MenuItem{"Lihat Produk (Semua Gudang)", ListAllProducts},
+			MenuItem{"Lihat Stok per Gudang", listStockByWarehouse},
+		)
+	} else {
+		items = append(items, MenuItem{"Lihat Daftar Produk", as(ListProducts)})
+	}
+
+	if user.Can(models.PermProductManage) {
+		items = append(items, MenuItem{"Tambah Produk", as(addProduct)})
+	}
+	if user.Can(models.PermProductManage) || user.Can(models.PermStockAdjust) {
+		items = append(items,
+			MenuItem{"Edit Produk", as(editProduct)},
+			MenuItem{"Stok & Harga per Gudang", as(manageWarehouseStock)},
+			MenuItem{"Kartu Stok", as(showStockCard)},
+			MenuItem{"Stok Menipis", as(showLowStock)},
+			MenuItem{"Batch & Kedaluwarsa", as(BatchMenu)},
+		)
+	}
+	if user.Can(models.PermProductManage) {
+		items = append(items,
+			MenuItem{"Hapus Produk", as(deleteProduct)},
+			MenuItem{"Kelola Varian", as(manageVariants)},
+			MenuItem{"Kategori & Merek", as(CategoryMenu)},
+		)
+		if user.HasAllWarehouses() {
+			items = append(items,
+				MenuItem{"Export ke Excel", as(exportToExcel)},
+				MenuItem{"Import dari Excel", as(importFromExcel)},
+			)
 		}
 	}
+	return items
 }
 
 func readInput() string {
@@ -93,10 +82,10 @@ func readInput() string {
 func ListAllProducts() {
 	page := 1
 	limit := 10
-	search := ""
+	var filter models.ProductFilter
 
 	for {
-		products, total, err := models.GetProducts(page, limit, search, nil)
+		products, total, err := models.GetProducts(page, limit, filter)
 		if err != nil {
 			fmt.Printf("❌ Error: %v\n", err)
 			return
@@ -112,13 +101,14 @@ func ListAllProducts() {
 		fmt.Println("╚═════════════════════════════════════════════════════════════════════════════════════════════╝")
 		fmt.Printf("🔍 Search: %-20s  📄 Page: %d/%d  📦 Total: %d\n",
 			func() string {
-				if search == "" {
+				if filter.Search == "" {
 					return "(none)"
 				} else {
-					return search
+					return filter.Search
 				}
 			}(),
 			page, totalPages, total)
+		printCategoryFilter(filter)
 
 		fmt.Println("┌─────┬──────────────────────┬─────────────┬─────────────┬──────┬─────────────────────────┐")
 		fmt.Println("│ ID  │ Nama Produk          │ Hrg Beli    │ Hrg Jual    │ Stok │ Gudang                  │")
@@ -129,18 +119,18 @@ func ListAllProducts() {
 		}
 
 		for _, p := range products {
-			warehouseName := "?"
-			w, _ := models.GetWarehouseByID(p.WarehouseID)
-			if w != nil {
-				warehouseName = w.Name
+			// Produk yang belum dimasukkan ke gudang mana pun hanya ada di katalog master
+			location := "- (belum di gudang)"
+			if p.WarehouseID != 0 {
+				location = warehouseName(p.WarehouseID)
 			}
 
 			fmt.Printf("│ %-3d │ %-20s │ %11s │ %11s │ %4d │ %-23s │\n",
-				p.ID, truncate(p.Name, 20), formatRupiah(p.PurchasePrice), formatRupiah(p.SellingPrice), p.Stock, truncate(warehouseName, 23))
+				p.ID, truncate(p.DisplayName(), 20), formatRupiah(p.PurchasePrice), formatRupiah(p.SellingPrice), p.Stock, truncate(location, 23))
 		}
 		fmt.Println("└─────┴──────────────────────┴─────────────┴─────────────┴──────┴─────────────────────────┘")
 
-		fmt.Println("\n[n] Next  [p] Prev  [s] Search  [q] Back")
+		fmt.Println("\n[n] Next  [p] Prev  [s] Search  [k] Kategori  [q] Back")
 		fmt.Print("Pilihan: ")
 		input := readInput()
 
@@ -159,8 +149,11 @@ func ListAllProducts() {
 			}
 		case "s":
 			fmt.Print("Masukkan kata kunci: ")
-			search = readInput()
+			filter.Search = readInput()
 			page = 1 // Reset ke halaman 1 saat search baru
+		case "k":
+			filter.CategoryID = promptCategoryFilter()
+			page = 1
 		case "q":
 			return
 		default:
@@ -174,7 +167,7 @@ func ListAllProducts() {
 	}
 }
 
-// listStockByWarehouse menampilkan ringkasan stok per gudang
+// listStockByWarehouse menampilkan ringkasan stok per gudang dan stok tiap produk di semua gudang
 func listStockByWarehouse() {
 	warehouses, _ := models.GetAllWarehouses()
 
@@ -186,8 +179,10 @@ func listStockByWarehouse() {
 	fmt.Println("│ Gudang                     │ Jml Produk    │ Total Stok    │ Nilai Stok        │")
 	fmt.Println("├────────────────────────────┼───────────────┼───────────────┼───────────────────┤")
 
-	var grandTotalProducts, grandTotalStock int
+	var grandTotalStock int
 	var grandTotalValue float64
+	// Produk yang sama di beberapa gudang dihitung sekali di baris TOTAL
+	allProducts := make(map[int]bool)
 
 	for _, w := range warehouses {
 		products, _ := models.GetProductsByWarehouse(w.ID)
@@ -197,9 +192,9 @@ func listStockByWarehouse() {
 		for _, p := range products {
 			totalStock += p.Stock
 			totalValue += p.SellingPrice * float64(p.Stock)
+			allProducts[p.ID] = true
 		}
 
-		grandTotalProducts += len(products)
 		grandTotalStock += totalStock
 		grandTotalValue += totalValue
 
@@ -209,23 +204,24 @@ func listStockByWarehouse() {
 
 	fmt.Println("├────────────────────────────┼───────────────┼───────────────┼───────────────────┤")
 	fmt.Printf("│ %-26s │ %13d │ %13d │ %17s │\n",
-		"TOTAL", grandTotalProducts, grandTotalStock, formatRupiah(grandTotalValue))
+		"TOTAL", len(allProducts), grandTotalStock, formatRupiah(grandTotalValue))
 	fmt.Println("└────────────────────────────┴───────────────┴───────────────┴───────────────────┘")
+
+	printStockMatrix(warehouses)
 }
 
-func ListProducts() {
+func ListProducts(user *models.User) {
 	page := 1
 	limit := 10
-	search := ""
+	var filter models.ProductFilter
 
 	// Ensure we have user warehouse ID if not admin
-	var warehouseID *int
-	if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() {
-		warehouseID = models.CurrentUser.WarehouseID
+	if user != nil && !user.HasAllWarehouses() {
+		filter.WarehouseID = user.WarehouseID
 	}
 
 	for {
-		products, total, err := models.GetProducts(page, limit, search, warehouseID)
+		products, total, err := models.GetProducts(page, limit, filter)
 		if err != nil {
 			fmt.Printf("❌ Error: %v\n", err)
 			return
@@ -241,28 +237,29 @@ func ListProducts() {
 		fmt.Println("╚══════════════════════════════════════════════════════════╝")
 		fmt.Printf("🔍 Search: %-15s  📄 Page: %d/%d  📦 Total: %d\n",
 			func() string {
-				if search == "" {
+				if filter.Search == "" {
 					return "(none)"
 				} else {
-					return search
+					return filter.Search
 				}
 			}(),
 			page, totalPages, total)
+		printCategoryFilter(filter)
 
-		fmt.Println("┌─────┬────────────────────────┬───────────────┬────────┐")
-		fmt.Println("│ ID  │ Nama Produk            │ Harga         │ Stok   │")
-		fmt.Println("├─────┼────────────────────────┼───────────────┼────────┤")
+		fmt.Println("┌─────┬───────────────┬────────────────────────┬───────────────┬────────┐")
+		fmt.Println("│ ID  │ SKU/Barcode   │ Nama Produk            │ Harga         │ Stok   │")
+		fmt.Println("├─────┼───────────────┼────────────────────────┼───────────────┼────────┤")
 
 		if len(products) == 0 {
-			fmt.Println("│               T I D A K   A D A   D A T A             │")
+			fmt.Println("│                       T I D A K   A D A   D A T A                     │")
 		}
 
 		for _, p := range products {
-			fmt.Printf("│ %-3d │ %-22s │ %13s │ %6d │\n", p.ID, truncate(p.Name, 22), formatRupiah(p.SellingPrice), p.Stock)
+			fmt.Printf("│ %-3d │ %-13s │ %-22s │ %13s │ %6d │\n", p.ID, truncate(productCode(p), 13), truncate(p.DisplayName(), 22), formatRupiah(p.SellingPrice), p.Stock)
 		}
-		fmt.Println("└─────┴────────────────────────┴───────────────┴────────┘")
+		fmt.Println("└─────┴───────────────┴────────────────────────┴───────────────┴────────┘")
 
-		fmt.Println("\n[n] Next  [p] Prev  [s] Search  [q] Back")
+		fmt.Println("\n[n] Next  [p] Prev  [s] Search  [k] Kategori  [q] Back")
 		fmt.Print("Pilihan: ")
 		input := readInput()
 
@@ -281,8 +278,11 @@ func ListProducts() {
 			}
 		case "s":
 			fmt.Print("Masukkan kata kunci: ")
-			search = readInput()
+			filter.Search = readInput()
 			page = 1 // Reset ke halaman 1 saat search baru
+		case "k":
+			filter.CategoryID = promptCategoryFilter()
+			page = 1
 		case "q":
 			return
 		default:
@@ -296,6 +296,17 @@ func ListProducts() {
 	}
 }
 
+// productCode mengembalikan SKU produk, atau barcode jika SKU kosong
+func productCode(p models.Product) string {
+	if p.SKU != "" {
+		return p.SKU
+	}
+	if p.Barcode != "" {
+		return p.Barcode
+	}
+	return "-"
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) > maxLen {
 		return s[:maxLen-3] + "..."
@@ -303,7 +314,7 @@ func truncate(s string, maxLen int) string {
 	return s
 }
 
-func addProduct() {
+func addProduct(user *models.User) {
 	fmt.Println("\n═══ TAMBAH PRODUK BARU ═══")
 
 	fmt.Print("Nama Produk: ")
@@ -313,6 +324,30 @@ func addProduct() {
 		return
 	}
 
+	fmt.Print("SKU (opsional): ")
+	sku := readInput()
+
+	fmt.Print("Barcode (opsional, bisa di-scan): ")
+	barcode := readInput()
+
+	// Produk yang sama sudah ada di katalog master, cukup ditambahkan ke gudang
+	if existing := catalogProduct(sku, barcode); existing != nil {
+		fmt.Printf("💡 Kode tersebut sudah dipakai '%s' (ID %d) di katalog.\n", existing.DisplayName(), existing.ID)
+		fmt.Println("   Tambahkan ke gudang lewat menu 'Stok & Harga per Gudang'.")
+		return
+	}
+
+	categoryID, ok := promptCategory(nil)
+	if !ok {
+		fmt.Println("❌ ID kategori tidak valid!")
+		return
+	}
+	brandID, ok := promptBrand(nil)
+	if !ok {
+		fmt.Println("❌ ID merek tidak valid!")
+		return
+	}
+
 	fmt.Print("Harga Beli: ")
 	purchasePriceStr := readInput()
 	purchasePrice, err := strconv.ParseFloat(purchasePriceStr, 64)
@@ -343,8 +378,8 @@ func addProduct() {
 
 	// Tentukan warehouse
 	var warehouseID int
-	if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() && models.CurrentUser.WarehouseID != nil {
-		warehouseID = *models.CurrentUser.WarehouseID
+	if user != nil && !user.HasAllWarehouses() {
+		warehouseID = *user.WarehouseID
 	} else {
 		// Admin pilih warehouse
 		warehouses, err := models.GetAllWarehouses()
@@ -362,7 +397,17 @@ func addProduct() {
 		reader.ReadString('\n')
 	}
 
-	product, err := models.CreateProduct(name, purchasePrice, sellingPrice, stock, warehouseID)
+	product, err := models.CreateProduct(user, models.Product{
+		Name:          name,
+		SKU:           sku,
+		Barcode:       barcode,
+		PurchasePrice: purchasePrice,
+		SellingPrice:  sellingPrice,
+		Stock:         stock,
+		WarehouseID:   warehouseID,
+		CategoryID:    categoryID,
+		BrandID:       brandID,
+	})
 	if err != nil {
 		fmt.Printf("❌ Gagal menambah produk: %v\n", err)
 		return
@@ -371,8 +416,8 @@ func addProduct() {
 	fmt.Printf("✅ Produk '%s' berhasil ditambahkan dengan ID: %d\n", product.Name, product.ID)
 }
 
-func editProduct() {
-	ListProducts()
+func editProduct(user *models.User) {
+	ListProducts(user)
 
 	fmt.Print("\nMasukkan ID produk yang akan diedit: ")
 	idStr := readInput()
@@ -389,66 +434,172 @@ func editProduct() {
 	}
 
 	// Cek akses untuk user biasa
-	if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() {
-		if models.CurrentUser.WarehouseID != nil && product.WarehouseID != *models.CurrentUser.WarehouseID {
-			fmt.Println("❌ Anda tidak memiliki akses ke produk ini!")
-			return
-		}
+	if !canAccessProduct(user, product.ID) {
+		fmt.Println("❌ Anda tidak memiliki akses ke produk ini!")
+		return
 	}
 
-	fmt.Printf("\n═══ EDIT PRODUK: %s ═══\n", product.Name)
-	fmt.Println("(Tekan Enter untuk tidak mengubah)")
+	fmt.Printf("\n═══ EDIT PRODUK: %s ═══\n", product.DisplayName())
+	fmt.Println("(Tekan Enter untuk tidak mengubah; nama, kode, dan harga master berlaku di semua gudang)")
 
-	fmt.Printf("Nama [%s]: ", product.Name)
-	name := readInput()
-	if name == "" {
-		name = product.Name
+	updated := *product
+	var ok bool
+
+	variants, err := models.GetVariants(product.ID)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
 	}
 
-	fmt.Printf("Harga Beli [%s]: ", formatRupiah(product.PurchasePrice))
-	purchasePriceStr := readInput()
-	purchasePrice := product.PurchasePrice
-	if purchasePriceStr != "" {
-		purchasePrice, err = strconv.ParseFloat(purchasePriceStr, 64)
-		if err != nil || purchasePrice < 0 {
-			fmt.Println("❌ Harga beli tidak valid!")
-			return
+	// Nama dan harga hanya bisa diubah dengan permission kelola produk
+	if user.Can(models.PermProductManage) {
+		// Varian mengikuti nama, kategori, dan merek induknya; yang bisa diubah nama variannya
+		if product.IsVariant() {
+			fmt.Printf("Varian [%s]: ", product.Variant)
+			if input := readInput(); input != "" {
+				updated.Variant = input
+			}
+		} else {
+			fmt.Printf("Nama [%s]: ", product.Name)
+			if input := readInput(); input != "" {
+				updated.Name = input
+			}
+		}
+
+		// "-" untuk mengosongkan SKU/barcode
+		fmt.Printf("SKU [%s] ('-' untuk kosongkan): ", product.SKU)
+		updated.SKU = editCode(updated.SKU, readInput())
+		fmt.Printf("Barcode [%s] ('-' untuk kosongkan): ", product.Barcode)
+		updated.Barcode = editCode(updated.Barcode, readInput())
+
+		if !product.IsVariant() {
+			if updated.CategoryID, ok = promptCategory(product.CategoryID); !ok {
+				fmt.Println("❌ ID kategori tidak valid!")
+				return
+			}
+			if updated.BrandID, ok = promptBrand(product.BrandID); !ok {
+				fmt.Println("❌ ID merek tidak valid!")
+				return
+			}
+		}
+
+		fmt.Printf("Harga Beli master [%s]: ", formatRupiah(product.PurchasePrice))
+		purchasePriceStr := readInput()
+		if purchasePriceStr != "" {
+			updated.PurchasePrice, err = strconv.ParseFloat(purchasePriceStr, 64)
+			if err != nil || updated.PurchasePrice < 0 {
+				fmt.Println("❌ Harga beli tidak valid!")
+				return
+			}
+		}
+
+		fmt.Printf("Harga Jual master [%s]: ", formatRupiah(product.SellingPrice))
+		sellingPriceStr := readInput()
+		if sellingPriceStr != "" {
+			updated.SellingPrice, err = strconv.ParseFloat(sellingPriceStr, 64)
+			if err != nil || updated.SellingPrice < 0 {
+				fmt.Println("❌ Harga jual tidak valid!")
+				return
+			}
 		}
 	}
 
-	fmt.Printf("Harga Jual [%s]: ", formatRupiah(product.SellingPrice))
-	sellingPriceStr := readInput()
-	sellingPrice := product.SellingPrice
-	if sellingPriceStr != "" {
-		sellingPrice, err = strconv.ParseFloat(sellingPriceStr, 64)
-		if err != nil || sellingPrice < 0 {
-			fmt.Println("❌ Harga jual tidak valid!")
+	// Stok diubah per gudang dan hanya dengan permission penyesuaian stok; produk induk tidak punya stok sendiri
+	var stock *models.ProductStock
+	if len(variants) > 0 {
+		fmt.Printf("💡 Produk ini punya %d varian, stok diatur di tiap varian\n", len(variants))
+	} else if user.Can(models.PermStockAdjust) {
+		if stock, ok = chooseStockWarehouse(user, product); !ok {
 			return
 		}
+		if stock != nil {
+			fmt.Printf("Stok di %s [%d]: ", warehouseName(stock.WarehouseID), stock.Stock)
+			if stockStr := readInput(); stockStr != "" {
+				stock.Stock, err = strconv.Atoi(stockStr)
+				if err != nil || stock.Stock < 0 {
+					fmt.Println("❌ Stok tidak valid!")
+					return
+				}
+			}
+		}
 	}
 
-	fmt.Printf("Stok [%d]: ", product.Stock)
-	stockStr := readInput()
-	stock := product.Stock
-	if stockStr != "" {
-		stock, err = strconv.Atoi(stockStr)
-		if err != nil || stock < 0 {
-			fmt.Println("❌ Stok tidak valid!")
+	if user.Can(models.PermProductManage) {
+		if err := models.UpdateProduct(user, updated); err != nil {
+			fmt.Printf("❌ Gagal mengupdate produk: %v\n", err)
+			return
+		}
+	}
+	if stock != nil {
+		if err := models.SetProductStock(user, *stock); err != nil {
+			fmt.Printf("❌ Gagal mengupdate stok: %v\n", err)
 			return
 		}
 	}
 
-	err = models.UpdateProduct(id, name, purchasePrice, sellingPrice, stock)
+	fmt.Println("✅ Produk berhasil diupdate!")
+}
+
+// chooseStockWarehouse menentukan stok gudang mana yang diubah: gudang user, satu-satunya gudang
+// produk, atau pilihan admin. Nil berarti stok tidak diubah.
+func chooseStockWarehouse(user *models.User, product *models.Product) (*models.ProductStock, bool) {
+	stocks, err := models.GetProductStocks(product.ID)
 	if err != nil {
-		fmt.Printf("❌ Gagal mengupdate produk: %v\n", err)
-		return
+		fmt.Println("❌ Error:", err)
+		return nil, false
 	}
 
-	fmt.Println("✅ Produk berhasil diupdate!")
+	if user != nil && !user.HasAllWarehouses() {
+		for i := range stocks {
+			if user.WarehouseID != nil && stocks[i].WarehouseID == *user.WarehouseID {
+				return &stocks[i], true
+			}
+		}
+		return nil, true
+	}
+
+	switch len(stocks) {
+	case 0:
+		fmt.Println("💡 Produk belum ada di gudang mana pun, tambahkan lewat menu 'Stok & Harga per Gudang'")
+		return nil, true
+	case 1:
+		return &stocks[0], true
+	}
+
+	fmt.Println("Produk ada di beberapa gudang:")
+	for _, st := range stocks {
+		fmt.Printf("  %d. %s (Stok: %d)\n", st.WarehouseID, warehouseName(st.WarehouseID), st.Stock)
+	}
+	fmt.Print("ID Gudang untuk ubah stok (Enter = lewati): ")
+	input := readInput()
+	if input == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(input)
+	if err == nil {
+		for i := range stocks {
+			if stocks[i].WarehouseID == id {
+				return &stocks[i], true
+			}
+		}
+	}
+	fmt.Println("❌ ID gudang tidak valid!")
+	return nil, false
+}
+
+// editCode mengembalikan kode baru dari input edit: kosong = tetap, "-" = dikosongkan
+func editCode(current, input string) string {
+	switch input {
+	case "":
+		return current
+	case "-":
+		return ""
+	}
+	return input
 }
 
-func deleteProduct() {
-	ListProducts()
+func deleteProduct(user *models.User) {
+	ListProducts(user)
 
 	fmt.Print("\nMasukkan ID produk yang akan dihapus: ")
 	idStr := readInput()
@@ -464,22 +615,33 @@ func deleteProduct() {
 		return
 	}
 
-	// Cek akses untuk user biasa
-	if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() {
-		if models.CurrentUser.WarehouseID != nil && product.WarehouseID != *models.CurrentUser.WarehouseID {
+	// User gudang hanya mengeluarkan produk dari gudangnya; katalog master dan gudang lain tetap
+	if user != nil && !user.HasAllWarehouses() {
+		if !canAccessProduct(user, product.ID) {
 			fmt.Println("❌ Anda tidak memiliki akses ke produk ini!")
 			return
 		}
+		fmt.Printf("⚠️  Yakin ingin mengeluarkan '%s' dari gudang Anda? (y/n): ", product.DisplayName())
+		if strings.ToLower(readInput()) != "y" {
+			fmt.Println("Batal menghapus.")
+			return
+		}
+		if err := models.RemoveProductFromWarehouse(user, id, *user.WarehouseID); err != nil {
+			fmt.Printf("❌ Gagal menghapus produk: %v\n", err)
+			return
+		}
+		fmt.Println("✅ Produk dikeluarkan dari gudang Anda!")
+		return
 	}
 
-	fmt.Printf("⚠️  Yakin ingin menghapus '%s'? (y/n): ", product.Name)
+	fmt.Printf("⚠️  Yakin ingin menghapus '%s' dari katalog dan semua gudang? (y/n): ", product.DisplayName())
 	confirm := readInput()
 	if strings.ToLower(confirm) != "y" {
 		fmt.Println("Batal menghapus.")
 		return
 	}
 
-	err = models.DeleteProduct(id)
+	err = models.DeleteProduct(user, id)
 	if err != nil {
 		fmt.Printf("❌ Gagal menghapus produk: %v\n", err)
 		return
@@ -489,7 +651,7 @@ func deleteProduct() {
 }
 
 // exportToExcel mengexport data produk ke file Excel
-func exportToExcel() {
+func exportToExcel(user *models.User) {
 	fmt.Println("\n═══ EXPORT DATA PRODUK KE EXCEL ═══")
 
 	// Pilih gudang atau semua
@@ -504,6 +666,25 @@ func exportToExcel() {
 	fmt.Scanln(&warehouseID)
 	reader.ReadString('\n')
 
+	filePath, count, err := ExportProductsExcel(user, warehouseID)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("✅ Berhasil export %d produk ke file:\n", count)
+	fmt.Printf("   📄 %s\n", filePath)
+}
+
+// ExportProductsExcel menulis produk satu gudang (0 = semua gudang) ke file Excel di
+// folder export.excel_dir dan mengembalikan path file beserta jumlah produk. Baris yang stoknya
+// sudah mencapai stok minimum diberi warna merah.
+func ExportProductsExcel(user *models.User, warehouseID int) (string, int, error) {
+	warehouses, err := models.GetAllWarehouses()
+	if err != nil {
+		return "", 0, err
+	}
+
 	// Buat file Excel
 	f := excelize.NewFile()
 	defer f.Close()
@@ -525,7 +706,7 @@ func exportToExcel() {
 	})
 
 	// Set headers
-	headers := []string{"ID", "Nama Produk", "Harga Beli", "Harga Jual", "Stok", "Gudang ID", "Nama Gudang"}
+	headers := []string{"ID", "Nama Produk", "Harga Beli", "Harga Jual", "Stok", "Gudang ID", "Nama Gudang", "SKU", "Barcode", "Kategori", "Merek", "Varian", "ID Induk", "Stok Minimum"}
 	for i, h := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, h)
@@ -539,62 +720,129 @@ func exportToExcel() {
 	f.SetColWidth(sheetName, "E", "E", 10)
 	f.SetColWidth(sheetName, "F", "F", 12)
 	f.SetColWidth(sheetName, "G", "G", 20)
+	f.SetColWidth(sheetName, "H", "I", 16)
+	f.SetColWidth(sheetName, "J", "J", 30)
+	f.SetColWidth(sheetName, "K", "L", 16)
+	f.SetColWidth(sheetName, "M", "M", 10)
+	f.SetColWidth(sheetName, "N", "N", 14)
+
+	// Baris yang stoknya sudah mencapai stok minimum diberi warna merah muda
+	lowStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "9C0006"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"FFC7CE"}, Pattern: 1},
+	})
 
 	// Get products
 	var products []models.Product
 	if warehouseID == 0 {
 		// Semua gudang
 		for _, w := range warehouses {
-			prods, _ := models.GetProductsByWarehouse(w.ID)
+			prods, err := models.GetProductsByWarehouse(w.ID)
+			if err != nil {
+				return "", 0, err
+			}
 			products = append(products, prods...)
 		}
 	} else {
-		products, _ = models.GetProductsByWarehouse(warehouseID)
+		products, err = models.GetProductsByWarehouse(warehouseID)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	warehouseNames := make(map[int]string)
+	for _, w := range warehouses {
+		warehouseNames[w.ID] = w.Name
+	}
+
+	var levelScope *int
+	if warehouseID != 0 {
+		levelScope = &warehouseID
+	}
+	levels, err := models.GetReorderLevels(levelScope)
+	if err != nil {
+		return "", 0, err
+	}
+	reorderPoints := make(map[[2]int]int)
+	for _, st := range levels {
+		reorderPoints[[2]int{st.ProductID, st.WarehouseID}] = st.ReorderPoint
+	}
+
+	// Kategori ditulis sebagai path lengkap ("Makanan > Mie Instan") agar bisa diimport kembali
+	categoryPaths, err := models.CategoryPathMap()
+	if err != nil {
+		return "", 0, err
+	}
+	brands, err := models.GetAllBrands()
+	if err != nil {
+		return "", 0, err
+	}
+	brandNames := make(map[int]string)
+	for _, b := range brands {
+		brandNames[b.ID] = b.Name
 	}
 
 	// Data rows
 	for i, p := range products {
 		row := i + 2
-		warehouseName := ""
-		warehouse, _ := models.GetWarehouseByID(p.WarehouseID)
-		if warehouse != nil {
-			warehouseName = warehouse.Name
-		}
-
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), p.ID)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), p.Name)
 		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), p.PurchasePrice)
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), p.SellingPrice)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), p.Stock)
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), p.WarehouseID)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), warehouseName)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), warehouseNames[p.WarehouseID])
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), p.SKU)
+		// Barcode ditulis sebagai teks agar angka 0 di depan tidak hilang
+		f.SetCellStr(sheetName, fmt.Sprintf("I%d", row), p.Barcode)
+		if p.CategoryID != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), categoryPaths[*p.CategoryID])
+		}
+		if p.BrandID != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), brandNames[*p.BrandID])
+		}
+		if p.IsVariant() {
+			f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), p.Variant)
+			f.SetCellValue(sheetName, fmt.Sprintf("M%d", row), *p.ParentID)
+		}
+		if level, ok := reorderPoints[[2]int{p.ID, p.WarehouseID}]; ok {
+			f.SetCellValue(sheetName, fmt.Sprintf("N%d", row), level)
+			if (models.ProductStock{Stock: p.Stock, ReorderPoint: level}).IsLow() {
+				f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("N%d", row), lowStyle)
+			}
+		}
 	}
 
 	// Generate filename
 	filename := fmt.Sprintf("produk_export_%s.xlsx", time.Now().Format("20060102_150405"))
 
-	// Get current directory and create exports/excel folder
-	cwd, _ := os.Getwd()
-	excelDir := filepath.Join(cwd, "exports", "excel")
+	// Folder export dari config (default exports/excel)
+	excelDir := config.App.Export.ExcelDir
 	if err := os.MkdirAll(excelDir, 0755); err != nil {
-		fmt.Printf("❌ Gagal membuat folder: %v\n", err)
-		return
+		return "", 0, fmt.Errorf("gagal membuat folder: %v", err)
 	}
 
 	filePath := filepath.Join(excelDir, filename)
 
 	// Save file
 	if err := f.SaveAs(filePath); err != nil {
-		fmt.Printf("❌ Gagal menyimpan file: %v\n", err)
-		return
+		return "", 0, fmt.Errorf("gagal menyimpan file: %v", err)
 	}
 
-	fmt.Printf("✅ Berhasil export %d produk ke file:\n", len(products))
-	fmt.Printf("   📄 %s\n", filePath)
+	err = models.RecordAudit(user, models.AuditExport, models.EntityProduct, 0, nil, map[string]interface{}{
+		"file":         filePath,
+		"warehouse_id": warehouseID,
+		"count":        len(products),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Gagal mencatat audit log export: %v\n", err)
+	}
+
+	return filePath, len(products), nil
 }
 
 // importFromExcel mengimport data produk dari file Excel
-func importFromExcel() {
+func importFromExcel(user *models.User) {
 	fmt.Println("\n═══ IMPORT DATA PRODUK DARI EXCEL ═══")
 	fmt.Println("Format Excel yang diperlukan:")
 	fmt.Println("  Kolom A: Nama Produk")
@@ -602,6 +850,12 @@ func importFromExcel() {
 	fmt.Println("  Kolom C: Harga Jual")
 	fmt.Println("  Kolom D: Stok")
 	fmt.Println("  Kolom E: Gudang ID")
+	fmt.Println("  Kolom F: SKU (opsional)")
+	fmt.Println("  Kolom G: Barcode (opsional)")
+	fmt.Println("  Kolom H: Kategori (opsional, contoh: Makanan > Mie Instan)")
+	fmt.Println("  Kolom I: Merek (opsional)")
+	fmt.Println("  (Kategori dan merek yang belum ada akan dibuat otomatis)")
+	fmt.Println("  (SKU/barcode yang sudah ada di katalog = tambah stok produk itu ke gudang)")
 	fmt.Println("  (Baris pertama = header, data mulai baris 2)")
 
 	fmt.Print("\nMasukkan path file Excel: ")
@@ -612,11 +866,38 @@ func importFromExcel() {
 		return
 	}
 
+	result, err := ImportProductsExcel(user, filePath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	for _, msg := range result.Skipped {
+		fmt.Printf("⚠️  %s\n", msg)
+	}
+
+	fmt.Printf("\n✅ Import selesai!\n")
+	fmt.Printf("   ✓ Berhasil: %d produk\n", result.Success)
+	if len(result.Skipped) > 0 {
+		fmt.Printf("   ✗ Gagal: %d produk\n", len(result.Skipped))
+	}
+}
+
+// ImportResult adalah hasil import produk dari Excel
+type ImportResult struct {
+	Success int
+	Skipped []string // alasan per baris yang tidak bisa diimport
+}
+
+// ImportProductsExcel membuat produk dari file Excel (kolom: nama, harga beli, harga jual,
+// stok, gudang ID, lalu SKU, barcode, path kategori dan merek yang opsional; baris pertama header).
+// Baris dengan SKU/barcode yang sudah ada di katalog mengisi stok produk itu di gudangnya; harga yang
+// berbeda dari harga master disimpan sebagai harga gudang. Kategori dan merek yang belum ada dibuat
+// otomatis. Baris yang tidak valid dilewati dan dicatat.
+func ImportProductsExcel(user *models.User, filePath string) (*ImportResult, error) {
 	// Open Excel file
 	f, err := excelize.OpenFile(filePath)
 	if err != nil {
-		fmt.Printf("❌ Gagal membuka file: %v\n", err)
-		return
+		return nil, fmt.Errorf("gagal membuka file: %v", err)
 	}
 	defer f.Close()
 
@@ -624,21 +905,18 @@ func importFromExcel() {
 	sheetName := f.GetSheetName(0)
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		fmt.Printf("❌ Gagal membaca file: %v\n", err)
-		return
+		return nil, fmt.Errorf("gagal membaca file: %v", err)
 	}
 
 	if len(rows) < 2 {
-		fmt.Println("❌ File tidak memiliki data (minimal 2 baris: header + data)")
-		return
+		return nil, fmt.Errorf("file tidak memiliki data (minimal 2 baris: header + data)")
 	}
 
 	// Process data (skip header)
-	var successCount, failCount int
+	result := &ImportResult{}
 	for i, row := range rows[1:] {
 		if len(row) < 5 {
-			fmt.Printf("⚠️  Baris %d: Data tidak lengkap, dilewati\n", i+2)
-			failCount++
+			result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Data tidak lengkap, dilewati", i+2))
 			continue
 		}
 
@@ -649,24 +927,73 @@ func importFromExcel() {
 		warehouseID, err4 := strconv.Atoi(row[4])
 
 		if name == "" || err1 != nil || err2 != nil || err3 != nil || err4 != nil {
-			fmt.Printf("⚠️  Baris %d: Data tidak valid, dilewati\n", i+2)
-			failCount++
+			result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Data tidak valid, dilewati", i+2))
 			continue
 		}
 
-		_, err := models.CreateProduct(name, purchasePrice, sellingPrice, stock, warehouseID)
+		p := models.Product{
+			Name:          name,
+			PurchasePrice: purchasePrice,
+			SellingPrice:  sellingPrice,
+			Stock:         stock,
+			WarehouseID:   warehouseID,
+		}
+		if len(row) > 5 {
+			p.SKU = row[5]
+		}
+		if len(row) > 6 {
+			p.Barcode = row[6]
+		}
+		if len(row) > 7 {
+			category, err := models.FindOrCreateCategoryPath(user, row[7])
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Kategori '%s' tidak valid: %v", i+2, row[7], err))
+				continue
+			}
+			if category != nil {
+				p.CategoryID = &category.ID
+			}
+		}
+		if len(row) > 8 {
+			brand, err := models.FindOrCreateBrand(user, row[8])
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Merek '%s' tidak valid: %v", i+2, row[8], err))
+				continue
+			}
+			if brand != nil {
+				p.BrandID = &brand.ID
+			}
+		}
+
+		// Produk yang kodenya sudah ada di katalog cukup ditambahkan ke gudang baris ini
+		var err error
+		if existing := catalogProduct(p.SKU, p.Barcode); existing != nil {
+			err = models.ImportProductStock(user, models.ProductStock{
+				ProductID:     existing.ID,
+				WarehouseID:   warehouseID,
+				Stock:         stock,
+				PurchasePrice: warehousePrice(purchasePrice, existing.PurchasePrice),
+				SellingPrice:  warehousePrice(sellingPrice, existing.SellingPrice),
+			}, filePath)
+		} else {
+			_, err = models.ImportProduct(user, p, filePath)
+		}
 		if err != nil {
-			fmt.Printf("⚠️  Baris %d: Gagal import '%s': %v\n", i+2, name, err)
-			failCount++
+			result.Skipped = append(result.Skipped, fmt.Sprintf("Baris %d: Gagal import '%s': %v", i+2, name, err))
 			continue
 		}
 
-		successCount++
+		result.Success++
 	}
 
-	fmt.Printf("\n✅ Import selesai!\n")
-	fmt.Printf("   ✓ Berhasil: %d produk\n", successCount)
-	if failCount > 0 {
-		fmt.Printf("   ✗ Gagal: %d produk\n", failCount)
+	err = models.RecordAudit(user, models.AuditImport, models.EntityProduct, 0, nil, map[string]interface{}{
+		"file":    filePath,
+		"success": result.Success,
+		"failed":  len(result.Skipped),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Gagal mencatat audit log import: %v\n", err)
 	}
+
+	return result, nil
 }
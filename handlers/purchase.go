@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PurchaseMenu menampilkan menu pembelian: supplier, purchase order, dan penerimaan barang.
+// Petugas gudang yang hanya boleh menerima barang tidak melihat menu supplier dan pembuatan PO.
+func PurchaseMenu(user *models.User) {
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+	items := []MenuItem{{"Daftar Purchase Order", as(listPurchaseOrders)}}
+	if user.Can(models.PermPurchaseManage) {
+		items = append(items,
+			MenuItem{"Buat Purchase Order", as(createPurchaseOrder)},
+			MenuItem{"Batalkan Purchase Order", as(cancelPurchaseOrder)},
+		)
+	}
+	if user.Can(models.PermPurchaseReceive) {
+		items = append(items, MenuItem{"Terima Barang", as(receivePurchaseOrder)})
+	}
+	items = append(items, MenuItem{"PO Belum Diterima", as(showOutstandingPurchases)})
+	if user.Can(models.PermPurchaseManage) {
+		items = append(items,
+			MenuItem{"Riwayat Pembelian per Supplier", as(showSupplierPurchases)},
+			MenuItem{"Lihat Supplier", listSuppliers},
+			MenuItem{"Tambah Supplier", as(addSupplier)},
+			MenuItem{"Edit Supplier", as(editSupplier)},
+			MenuItem{"Hapus Supplier", as(deleteSupplier)},
+		)
+	}
+
+	for {
+		var info []string
+		if !user.HasAllWarehouses() && user.WarehouseID != nil {
+			info = append(info, "Gudang: "+warehouseName(*user.WarehouseID))
+		}
+		PrintMenu("PEMBELIAN", info, items, "Kembali ke Menu Utama")
+		fmt.Print("Pilihan: ")
+
+		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+func listSuppliers() {
+	suppliers, err := models.GetAllSuppliers()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	fmt.Println("\n┌─────┬──────────────────────────────┬────────────────┬──────────────────────────────┐")
+	fmt.Println("│ ID  │ Supplier                     │ Telepon        │ Alamat                       │")
+	fmt.Println("├─────┼──────────────────────────────┼────────────────┼──────────────────────────────┤")
+	if len(suppliers) == 0 {
+		fmt.Println("│                      B E L U M   A D A   S U P P L I E R                       │")
+	}
+	for _, s := range suppliers {
+		fmt.Printf("│ %-3d │ %-28s │ %-14s │ %-28s │\n", s.ID, truncate(s.Name, 28), truncate(orDash(s.Phone), 14), truncate(orDash(s.Address), 28))
+	}
+	fmt.Println("└─────┴──────────────────────────────┴────────────────┴──────────────────────────────┘")
+}
+
+func addSupplier(user *models.User) {
+	fmt.Println("\n═══ TAMBAH SUPPLIER ═══")
+
+	fmt.Print("Nama Supplier: ")
+	name := readInput()
+	fmt.Print("Telepon: ")
+	phone := readInput()
+	fmt.Print("Alamat: ")
+	address := readInput()
+
+	sup, err := models.CreateSupplier(user, name, phone, address)
+	if err != nil {
+		fmt.Printf("❌ Gagal menambah supplier: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Supplier '%s' berhasil ditambahkan dengan ID: %d\n", sup.Name, sup.ID)
+}
+
+// chooseSupplier menampilkan daftar supplier dan meminta user memilih salah satu
+func chooseSupplier(prompt string) *models.Supplier {
+	listSuppliers()
+	fmt.Print(prompt)
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return nil
+	}
+	sup, err := models.GetSupplierByID(id)
+	if err != nil {
+		fmt.Println("❌ Supplier tidak ditemukan!")
+		return nil
+	}
+	return sup
+}
+
+func editSupplier(user *models.User) {
+	sup := chooseSupplier("\nMasukkan ID supplier yang akan diedit: ")
+	if sup == nil {
+		return
+	}
+
+	fmt.Println("(Tekan Enter untuk tidak mengubah)")
+	name, phone, address := sup.Name, sup.Phone, sup.Address
+	fmt.Printf("Nama [%s]: ", sup.Name)
+	if input := readInput(); input != "" {
+		name = input
+	}
+	fmt.Printf("Telepon [%s]: ", sup.Phone)
+	if input := readInput(); input != "" {
+		phone = input
+	}
+	fmt.Printf("Alamat [%s]: ", sup.Address)
+	if input := readInput(); input != "" {
+		address = input
+	}
+
+	if err := models.UpdateSupplier(user, sup.ID, name, phone, address); err != nil {
+		fmt.Printf("❌ Gagal mengupdate supplier: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Supplier berhasil diupdate!")
+}
+
+func deleteSupplier(user *models.User) {
+	sup := chooseSupplier("\nMasukkan ID supplier yang akan dihapus: ")
+	if sup == nil {
+		return
+	}
+
+	fmt.Printf("⚠️  Yakin ingin menghapus supplier '%s'? (y/n): ", sup.Name)
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("Batal menghapus.")
+		return
+	}
+	if err := models.DeleteSupplier(user, sup.ID); err != nil {
+		fmt.Printf("❌ Gagal menghapus supplier: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Supplier berhasil dihapus!")
+}
+
+// listPurchaseOrders menampilkan semua PO untuk gudang user, lalu detail PO yang dipilih
+func listPurchaseOrders(user *models.User) {
+	orders, err := models.GetPurchaseOrders(user, models.PurchaseFilter{})
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	printPurchaseOrders("DAFTAR PURCHASE ORDER", orders)
+	if len(orders) == 0 {
+		return
+	}
+
+	fmt.Print("\nLihat detail ID PO (Enter = kembali): ")
+	input := readInput()
+	if input == "" {
+		return
+	}
+	id, err := strconv.Atoi(input)
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	o, err := models.GetPurchaseOrder(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	printPurchaseOrder(o)
+}
+
+func printPurchaseOrders(title string, orders []models.PurchaseOrder) {
+	fmt.Printf("\n═══ %s ═══\n", title)
+	fmt.Println("┌───────┬──────────────────────┬──────────────────┬───────────────────┬──────────────────┐")
+	fmt.Println("│ ID    │ Supplier             │ Gudang           │ Status            │ Dibuat           │")
+	fmt.Println("├───────┼──────────────────────┼──────────────────┼───────────────────┼──────────────────┤")
+	if len(orders) == 0 {
+		fmt.Println("│               B E L U M   A D A   P U R C H A S E   O R D E R                        │")
+	}
+	for _, o := range orders {
+		fmt.Printf("│ %-5d │ %-20s │ %-16s │ %-17s │ %-16s │\n", o.ID, truncate(o.SupplierName, 20),
+			truncate(warehouseName(o.WarehouseID), 16), models.PurchaseStatusLabel(o.Status), o.CreatedAt.Format("02-01-2006 15:04"))
+	}
+	fmt.Println("└───────┴──────────────────────┴──────────────────┴───────────────────┴──────────────────┘")
+}
+
+// printPurchaseOrder menampilkan detail PO beserta jumlah yang sudah dan belum diterima
+func printPurchaseOrder(o *models.PurchaseOrder) {
+	fmt.Printf("\n🧾 PO-%06d: %s → %s (%s)\n", o.ID, o.SupplierName, warehouseName(o.WarehouseID), models.PurchaseStatusLabel(o.Status))
+	fmt.Printf("Dibuat : %s oleh %s\n", o.CreatedAt.Format("02-01-2006 15:04"), orDash(o.CreatedBy))
+	if o.Note != "" {
+		fmt.Printf("Catatan: %s\n", o.Note)
+	}
+
+	fmt.Println("┌──────────────────────────────┬────────┬──────────────┬──────────┬────────┐")
+	fmt.Println("│ Produk                       │ Pesan  │ Harga Beli   │ Diterima │ Sisa   │")
+	fmt.Println("├──────────────────────────────┼────────┼──────────────┼──────────┼────────┤")
+	for _, item := range o.Items {
+		fmt.Printf("│ %-28s │ %6d │ %12s │ %8d │ %6d │\n", truncate(item.ProductName, 28), item.Quantity,
+			formatRupiah(item.Price), item.Received, item.Outstanding())
+	}
+	fmt.Println("└──────────────────────────────┴────────┴──────────────┴──────────┴────────┘")
+	fmt.Printf("Nilai PO: %s, belum diterima: %s\n", formatRupiah(o.Total()), formatRupiah(o.OutstandingValue()))
+}
+
+// createPurchaseOrder membuat PO ke supplier untuk gudang user (atau gudang pilihan admin).
+// Harga beli default adalah harga beli produk di gudang tersebut.
+func createPurchaseOrder(user *models.User) {
+	fmt.Println("\n═══ BUAT PURCHASE ORDER ═══")
+
+	sup := chooseSupplier("ID Supplier: ")
+	if sup == nil {
+		return
+	}
+
+	var warehouseID int
+	if !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return
+		}
+		warehouseID = *user.WarehouseID
+		fmt.Printf("Gudang penerima: %s\n", warehouseName(warehouseID))
+	} else {
+		var ok bool
+		if warehouseID, ok = chooseWarehouse("Gudang Penerima", 0); !ok {
+			return
+		}
+	}
+
+	var items []models.PurchaseOrderItem
+	fmt.Println("\nMasukkan produk yang dipesan (ID, SKU, atau scan barcode). Enter kosong = selesai.")
+	for {
+		fmt.Print("Produk: ")
+		code := readInput()
+		if code == "" {
+			break
+		}
+		product := catalogProduct(code)
+		if id, err := strconv.Atoi(code); product == nil && err == nil {
+			product, _ = models.GetProductByID(id)
+		}
+		if product == nil {
+			fmt.Println("❌ Produk tidak ditemukan!")
+			continue
+		}
+		price := product.PurchasePrice
+		if p, err := models.GetProductInWarehouse(product.ID, warehouseID); err == nil {
+			price = p.PurchasePrice
+		}
+
+		fmt.Printf("Jumlah %s: ", product.DisplayName())
+		qty, err := strconv.Atoi(readInput())
+		if err != nil || qty <= 0 {
+			fmt.Println("❌ Jumlah tidak valid!")
+			continue
+		}
+		fmt.Printf("Harga beli [%s]: ", formatRupiah(price))
+		if input := readInput(); input != "" {
+			price, err = strconv.ParseFloat(input, 64)
+			if err != nil || price < 0 {
+				fmt.Println("❌ Harga tidak valid!")
+				continue
+			}
+		}
+		items = append(items, models.PurchaseOrderItem{ProductID: product.ID, Quantity: qty, Price: price})
+		fmt.Printf("✅ %s x %d @ %s ditambahkan\n", product.DisplayName(), qty, formatRupiah(price))
+	}
+	if len(items) == 0 {
+		fmt.Println("❌ Purchase order dibatalkan, belum ada produk.")
+		return
+	}
+
+	fmt.Print("Catatan (opsional): ")
+	note := readInput()
+
+	o, err := models.CreatePurchaseOrder(user, sup.ID, warehouseID, note, items)
+	if err != nil {
+		fmt.Printf("❌ Gagal membuat purchase order: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Purchase order PO-%06d dibuat\n", o.ID)
+	printPurchaseOrder(o)
+}
+
+// choosePurchaseOrder menampilkan PO yang masih menunggu barang dan meminta user memilih salah satu
+func choosePurchaseOrder(user *models.User, filter models.PurchaseFilter, title string) *models.PurchaseOrder {
+	orders, err := models.GetPurchaseOrders(user, filter)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return nil
+	}
+	printPurchaseOrders(title, orders)
+	if len(orders) == 0 {
+		return nil
+	}
+
+	fmt.Print("\nID PO: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return nil
+	}
+	o, err := models.GetPurchaseOrder(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return nil
+	}
+	return o
+}
+
+// receivePurchaseOrder mencatat barang yang datang dari supplier. Jumlah boleh lebih kecil dari
+// sisa pesanan (PO tetap terbuka), harga boleh berbeda dari PO sesuai faktur.
+func receivePurchaseOrder(user *models.User) {
+	o := choosePurchaseOrder(user, models.PurchaseFilter{Outstanding: true}, "PO MENUNGGU BARANG")
+	if o == nil {
+		return
+	}
+	if o.Status == models.PurchaseReceived {
+		fmt.Printf("❌ PO-%06d sudah diterima lengkap\n", o.ID)
+		return
+	}
+	printPurchaseOrder(o)
+
+	fmt.Println("\nHitung barang yang datang. Enter = sesuai sisa pesanan / harga PO, 0 = belum datang.")
+	var items []models.GoodsReceiptItem
+	for _, line := range o.Items {
+		if line.Outstanding() <= 0 {
+			continue
+		}
+		qty := line.Outstanding()
+		fmt.Printf("Diterima %s [%d]: ", line.ProductName, qty)
+		if input := readInput(); input != "" {
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 0 || n > line.Outstanding() {
+				fmt.Printf("❌ Jumlah harus antara 0 dan %d!\n", line.Outstanding())
+				return
+			}
+			qty = n
+		}
+		if qty == 0 {
+			continue
+		}
+		price := line.Price
+		fmt.Printf("Harga beli [%s]: ", formatRupiah(price))
+		if input := readInput(); input != "" {
+			p, err := strconv.ParseFloat(input, 64)
+			if err != nil || p <= 0 {
+				fmt.Println("❌ Harga tidak valid!")
+				return
+			}
+			price = p
+		}
+		item := models.GoodsReceiptItem{ProductID: line.ProductID, Quantity: qty, Price: price}
+		expiry, ok := promptDate("Tanggal kedaluwarsa (DD-MM-YYYY, Enter = tanpa batch): ", time.Time{})
+		if !ok {
+			return
+		}
+		if !expiry.IsZero() {
+			fmt.Print("No. Batch (Enter = nomor penerimaan): ")
+			item.BatchNo = readInput()
+			item.ExpiryDate = &expiry
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		fmt.Println("❌ Tidak ada barang yang diterima.")
+		return
+	}
+
+	fmt.Print("Catatan / No. faktur (opsional): ")
+	note := readInput()
+
+	gr, err := models.ReceivePurchaseOrder(user, o.ID, items, note)
+	if err != nil {
+		fmt.Printf("❌ Gagal menerima barang: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Penerimaan GR-%06d senilai %s dicatat, stok %s bertambah\n", gr.ID, formatRupiah(gr.Total), warehouseName(gr.WarehouseID))
+}
+
+// cancelPurchaseOrder membatalkan PO yang belum menerima barang sama sekali
+func cancelPurchaseOrder(user *models.User) {
+	o := choosePurchaseOrder(user, models.PurchaseFilter{Status: models.PurchaseOpen}, "PO BELUM ADA PENERIMAAN")
+	if o == nil {
+		return
+	}
+
+	fmt.Printf("Batalkan purchase order PO-%06d? (y/n): ", o.ID)
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+	if err := models.CancelPurchaseOrder(user, o.ID); err != nil {
+		fmt.Printf("❌ Gagal membatalkan purchase order: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Purchase order dibatalkan")
+}
+
+// showOutstandingPurchases menampilkan barang yang sudah dipesan tapi belum datang
+func showOutstandingPurchases(user *models.User) {
+	var warehouseID *int
+	if user != nil && !user.HasAllWarehouses() {
+		warehouseID = user.WarehouseID
+	}
+	report, err := models.GetOutstandingPurchases(warehouseID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n═══ PO BELUM DITERIMA ═══")
+	fmt.Println("┌───────┬──────────────────┬──────────────────┬──────────────────────────┬────────┬──────────────┐")
+	fmt.Println("│ PO    │ Supplier         │ Gudang           │ Produk                   │ Sisa   │ Nilai        │")
+	fmt.Println("├───────┼──────────────────┼──────────────────┼──────────────────────────┼────────┼──────────────┤")
+	if len(report) == 0 {
+		fmt.Println("│                 S E M U A   P E S A N A N   S U D A H   D I T E R I M A                  │")
+	}
+	var total float64
+	for _, r := range report {
+		fmt.Printf("│ %-5d │ %-16s │ %-16s │ %-24s │ %6d │ %12s │\n", r.OrderID, truncate(r.SupplierName, 16),
+			truncate(warehouseName(r.WarehouseID), 16), truncate(r.ProductName, 24), r.Outstanding, formatRupiah(r.Value))
+		total += r.Value
+	}
+	fmt.Println("├───────┴──────────────────┴──────────────────┴──────────────────────────┴────────┼──────────────┤")
+	fmt.Printf("│ %-78s │ %12s │\n", "TOTAL", formatRupiah(total))
+	fmt.Println("└────────────────────────────────────────────────────────────────────────────────┴──────────────┘")
+}
+
+// showSupplierPurchases menampilkan riwayat penerimaan barang dari satu supplier pada rentang tanggal
+func showSupplierPurchases(user *models.User) {
+	sup := chooseSupplier("\nID Supplier: ")
+	if sup == nil {
+		return
+	}
+	var warehouseID *int
+	if user != nil && !user.HasAllWarehouses() {
+		warehouseID = user.WarehouseID
+	}
+
+	now := time.Now()
+	from, ok := promptDate("Dari tanggal (DD-MM-YYYY, Enter = awal bulan ini): ",
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+	if !ok {
+		return
+	}
+	to, ok := promptDate("Sampai tanggal (DD-MM-YYYY, Enter = hari ini): ", now)
+	if !ok {
+		return
+	}
+
+	history, err := models.GetSupplierPurchases(sup.ID, warehouseID, from, to)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║%s║\n", centerText("RIWAYAT PEMBELIAN: "+strings.ToUpper(sup.Name), 76))
+	fmt.Printf("║%s║\n", centerText(history.From.Format("02-01-2006")+" s/d "+history.To.Format("02-01-2006"), 76))
+	fmt.Println("╚════════════════════════════════════════════════════════════════════════════╝")
+
+	fmt.Println("┌──────────────────┬───────────┬───────────┬──────────────────┬──────────────────┐")
+	fmt.Println("│ Tanggal          │ GR        │ PO        │ Gudang           │ Nilai            │")
+	fmt.Println("├──────────────────┼───────────┼───────────┼──────────────────┼──────────────────┤")
+	for _, r := range history.Receipts {
+		fmt.Printf("│ %-16s │ GR-%06d │ PO-%06d │ %-16s │ %16s │\n", r.CreatedAt.Format("02-01-2006 15:04"), r.ID, r.OrderID,
+			truncate(warehouseName(r.WarehouseID), 16), formatRupiah(r.Total))
+	}
+	fmt.Println("├──────────────────┴───────────┴───────────┴──────────────────┼──────────────────┤")
+	fmt.Printf("│ %-60s │ %16s │\n", fmt.Sprintf("TOTAL (%d penerimaan)", len(history.Receipts)), formatRupiah(history.Total))
+	fmt.Println("└──────────────────────────────────────────────────────────────┴──────────────────┘")
+}
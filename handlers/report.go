@@ -3,17 +3,22 @@ package handlers
 import (
 	"fmt"
 	"kasir/models"
+	"strings"
 	"time"
 )
 
 // ReportMenu menampilkan menu laporan
-func ReportMenu() {
+func ReportMenu(user *models.User) {
 	for {
 		fmt.Println("\n╔══════════════════════════════════════╗")
 		fmt.Println("║         LAPORAN PENJUALAN            ║")
 		fmt.Println("╠══════════════════════════════════════╣")
 		fmt.Println("║  1. Laporan Hari Ini                 ║")
 		fmt.Println("║  2. Laporan Tanggal Tertentu         ║")
+		fmt.Println("║  3. Penjualan per Kategori           ║")
+		fmt.Println("║  4. Stok per Kategori                ║")
+		fmt.Println("║  5. Penjualan per Produk             ║")
+		fmt.Println("║  6. Kartu Stok                       ║")
 		fmt.Println("║  0. Kembali ke Menu Utama            ║")
 		fmt.Println("╚══════════════════════════════════════╝")
 		fmt.Print("Pilihan: ")
@@ -21,9 +26,17 @@ func ReportMenu() {
 		choice := readInput()
 		switch choice {
 		case "1":
-			showDailyReport(time.Now())
+			showDailyReport(user, time.Now())
 		case "2":
-			selectDateReport()
+			selectDateReport(user)
+		case "3":
+			categorySalesReport(user)
+		case "4":
+			showCategoryStock(user)
+		case "5":
+			productSalesReport(user)
+		case "6":
+			showStockCard(user)
 		case "0":
 			return
 		default:
@@ -32,7 +45,7 @@ func ReportMenu() {
 	}
 }
 
-func selectDateReport() {
+func selectDateReport(user *models.User) {
 	fmt.Print("\nMasukkan tanggal (format: DD-MM-YYYY): ")
 	dateStr := readInput()
 
@@ -42,17 +55,27 @@ func selectDateReport() {
 		return
 	}
 
-	showDailyReport(date)
+	showDailyReport(user, date)
 }
 
-func showDailyReport(date time.Time) {
-	transactions, err := models.GetTransactionsByDate(models.CurrentUser, date)
+func showDailyReport(user *models.User, date time.Time) {
+	transactions, err := models.GetTransactionsByDate(user, date)
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
 		return
 	}
 
-	total, profit, count, err := models.GetDailyTotal(models.CurrentUser, date)
+	summary, err := models.GetDailySummary(user, date)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	returns, err := models.GetReturnsByDate(user, date)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	voided, err := models.GetVoidedTransactionsByDate(user, date)
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
 		return
@@ -60,8 +83,8 @@ func showDailyReport(date time.Time) {
 
 	// Header laporan
 	warehouseInfo := "Semua Gudang"
-	if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() && models.CurrentUser.WarehouseID != nil {
-		warehouse, _ := models.GetWarehouseByID(*models.CurrentUser.WarehouseID)
+	if user != nil && !user.HasAllWarehouses() {
+		warehouse, _ := models.GetWarehouseByID(*user.WarehouseID)
 		if warehouse != nil {
 			warehouseInfo = warehouse.Name
 		}
@@ -71,11 +94,21 @@ func showDailyReport(date time.Time) {
 	fmt.Printf("║          LAPORAN PENJUALAN: %s                      ║\n", date.Format("02-01-2006"))
 	fmt.Println("╠════════════════════════════════════════════════════════════════╣")
 	fmt.Printf("║  Gudang          : %-42s ║\n", warehouseInfo)
-	fmt.Printf("║  Jumlah Transaksi: %-3d                                         ║\n", count)
-	fmt.Printf("║  Total Penjualan : %-20s                     ║\n", formatRupiah(total))
-	fmt.Printf("║  Total Profit    : %-20s                     ║\n", formatRupiah(profit))
+	fmt.Printf("║  Jumlah Transaksi: %-3d                                         ║\n", summary.Transactions)
+	fmt.Printf("║  Penjualan Kotor : %-20s                     ║\n", formatRupiah(summary.GrossSales))
+	fmt.Printf("║  Retur           : %-42s ║\n", fmt.Sprintf("%s (%d retur)", formatRupiah(summary.ReturnTotal), summary.Returns))
+	fmt.Printf("║  Penjualan Bersih: %-20s                     ║\n", formatRupiah(summary.NetSales))
+	fmt.Printf("║  Profit Bersih   : %-20s                     ║\n", formatRupiah(summary.NetProfit))
+	fmt.Printf("║  Harga Pokok     : %-42s ║\n", models.CostingMethodLabel(models.CostingMethod()))
 	fmt.Println("╚════════════════════════════════════════════════════════════════╝")
 
+	if len(returns) > 0 {
+		printReturns(returns)
+	}
+	if len(voided) > 0 {
+		printVoidedTransactions(voided)
+	}
+
 	if len(transactions) == 0 {
 		fmt.Println("\n⚠️  Tidak ada transaksi pada tanggal ini.")
 		return
@@ -103,12 +136,11 @@ func showDailyReport(date time.Time) {
 	for _, t := range transactions {
 		fmt.Printf("\n📋 TRX-%06d (%s)\n", t.ID, t.CreatedAt.Format("15:04:05"))
 		for _, item := range t.Items {
-			profitItem := (item.SellingPrice - item.PurchasePrice) * float64(item.Quantity)
 			fmt.Printf("   • %-20s x%d = %s (profit: %s)\n",
 				truncate(item.ProductName, 20),
 				item.Quantity,
 				formatRupiah(item.Subtotal),
-				formatRupiah(profitItem))
+				formatRupiah(item.Profit))
 		}
 	}
 
@@ -116,3 +148,136 @@ func showDailyReport(date time.Time) {
 	fmt.Print("Tekan Enter untuk melanjutkan...")
 	readInput()
 }
+
+// promptReportDate membaca tanggal laporan (Enter = hari ini); ok=false jika format salah
+func promptReportDate() (time.Time, bool) {
+	fmt.Print("\nMasukkan tanggal (DD-MM-YYYY, Enter = hari ini): ")
+	dateStr := readInput()
+	if dateStr == "" {
+		return time.Now(), true
+	}
+
+	date, err := time.Parse("02-01-2006", dateStr)
+	if err != nil {
+		fmt.Println("❌ Format tanggal tidak valid! Gunakan DD-MM-YYYY")
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// categorySalesReport menampilkan penjualan per kategori pada tanggal tertentu (Enter = hari ini)
+func categorySalesReport(user *models.User) {
+	date, ok := promptReportDate()
+	if !ok {
+		return
+	}
+
+	report, err := models.GetCategorySalesByDate(user, date)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║%s║\n", centerText("PENJUALAN PER KATEGORI: "+date.Format("02-01-2006"), 76))
+	fmt.Println("╚════════════════════════════════════════════════════════════════════════════╝")
+
+	if len(report) == 0 {
+		fmt.Println("\n⚠️  Tidak ada transaksi pada tanggal ini.")
+		return
+	}
+
+	fmt.Println("┌──────────────────────────────┬───────┬──────────────────┬──────────────────┐")
+	fmt.Println("│ Kategori                     │ Qty   │ Penjualan        │ Profit           │")
+	fmt.Println("├──────────────────────────────┼───────┼──────────────────┼──────────────────┤")
+
+	var totalQty int
+	var totalSales, totalProfit float64
+	for _, r := range report {
+		fmt.Printf("│ %-28s │ %5d │ %16s │ %16s │\n",
+			truncate(r.Category, 28), r.Quantity, formatRupiah(r.Sales), formatRupiah(r.Profit))
+		totalQty += r.Quantity
+		totalSales += r.Sales
+		totalProfit += r.Profit
+	}
+	fmt.Println("├──────────────────────────────┼───────┼──────────────────┼──────────────────┤")
+	fmt.Printf("│ %-28s │ %5d │ %16s │ %16s │\n", "TOTAL", totalQty, formatRupiah(totalSales), formatRupiah(totalProfit))
+	fmt.Println("└──────────────────────────────┴───────┴──────────────────┴──────────────────┘")
+}
+
+// showCategoryStock menampilkan ringkasan stok per kategori di gudang yang boleh dilihat user
+func showCategoryStock(user *models.User) {
+	var warehouseID *int
+	if user != nil && !user.HasAllWarehouses() {
+		warehouseID = user.WarehouseID
+	}
+
+	report, err := models.GetCategoryStock(warehouseID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                             STOK PER KATEGORI                              ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════════════════════╝")
+
+	fmt.Println("┌──────────────────────────────┬────────────┬────────────┬───────────────────┐")
+	fmt.Println("│ Kategori                     │ Jml Produk │ Total Stok │ Nilai Stok        │")
+	fmt.Println("├──────────────────────────────┼────────────┼────────────┼───────────────────┤")
+
+	var totalProducts, totalStock int
+	var totalValue float64
+	for _, r := range report {
+		fmt.Printf("│ %-28s │ %10d │ %10d │ %17s │\n",
+			truncate(r.Category, 28), r.Products, r.Stock, formatRupiah(r.StockValue))
+		totalProducts += r.Products
+		totalStock += r.Stock
+		totalValue += r.StockValue
+	}
+	fmt.Println("├──────────────────────────────┼────────────┼────────────┼───────────────────┤")
+	fmt.Printf("│ %-28s │ %10d │ %10d │ %17s │\n", "TOTAL", totalProducts, totalStock, formatRupiah(totalValue))
+	fmt.Println("└──────────────────────────────┴────────────┴────────────┴───────────────────┘")
+}
+
+// productSalesReport menampilkan penjualan per produk, varian bisa digabung ke produk induknya
+func productSalesReport(user *models.User) {
+	date, ok := promptReportDate()
+	if !ok {
+		return
+	}
+	fmt.Print("Gabungkan varian ke produk induk? (y/n): ")
+	rollUp := strings.ToLower(readInput()) == "y"
+
+	report, err := models.GetProductSalesByDate(user, date, rollUp)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n╔════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║%s║\n", centerText("PENJUALAN PER PRODUK: "+date.Format("02-01-2006"), 76))
+	fmt.Println("╚════════════════════════════════════════════════════════════════════════════╝")
+
+	if len(report) == 0 {
+		fmt.Println("\n⚠️  Tidak ada transaksi pada tanggal ini.")
+		return
+	}
+
+	fmt.Println("┌──────────────────────────────┬───────┬──────────────────┬──────────────────┐")
+	fmt.Println("│ Produk                       │ Qty   │ Penjualan        │ Profit           │")
+	fmt.Println("├──────────────────────────────┼───────┼──────────────────┼──────────────────┤")
+
+	var totalQty int
+	var totalSales, totalProfit float64
+	for _, r := range report {
+		fmt.Printf("│ %-28s │ %5d │ %16s │ %16s │\n",
+			truncate(r.Product, 28), r.Quantity, formatRupiah(r.Sales), formatRupiah(r.Profit))
+		totalQty += r.Quantity
+		totalSales += r.Sales
+		totalProfit += r.Profit
+	}
+	fmt.Println("├──────────────────────────────┼───────┼──────────────────┼──────────────────┤")
+	fmt.Printf("│ %-28s │ %5d │ %16s │ %16s │\n", "TOTAL", totalQty, formatRupiah(totalSales), formatRupiah(totalProfit))
+	fmt.Println("└──────────────────────────────┴───────┴──────────────────┴──────────────────┘")
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// ReturnMenu memproses retur penjualan: cari transaksi lewat nomor TRX, pilih barang dan jumlah yang
+// dikembalikan, lalu stok kembali ke gudang dan uang dikembalikan ke pelanggan
+func ReturnMenu(user *models.User) {
+	fmt.Print("\nNomor transaksi (TRX-000123 atau 123): ")
+	id, ok := parseTransactionNumber(readInput())
+	if !ok {
+		fmt.Println("❌ Nomor transaksi tidak valid!")
+		return
+	}
+	t, err := models.GetTransaction(user, id)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if t.Voided() {
+		fmt.Printf("❌ Transaksi TRX-%06d sudah di-void, tidak bisa diretur\n", t.ID)
+		return
+	}
+	items, err := models.GetReturnableItems(user, t.ID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\n═══ RETUR TRX-%06d ═══\n", t.ID)
+	fmt.Printf("Tanggal: %s  Kasir: %s  Gudang: %s\n", t.CreatedAt.Format("02-01-2006 15:04"),
+		orDash(t.CashierName), warehouseName(t.WarehouseID))
+	fmt.Println("┌────┬──────────────────────┬──────────────┬────────┬────────┬────────┐")
+	fmt.Println("│ No │ Produk               │ Harga        │ Terjual│ Diretur│ Sisa   │")
+	fmt.Println("├────┼──────────────────────┼──────────────┼────────┼────────┼────────┤")
+	for i, item := range items {
+		fmt.Printf("│ %2d │ %-20s │ %12s │ %6d │ %6d │ %6d │\n", i+1, truncate(item.ProductName, 20),
+			formatRupiah(item.SellingPrice), item.Quantity, item.Returned, item.Returnable())
+	}
+	fmt.Println("└────┴──────────────────────┴──────────────┴────────┴────────┴────────┘")
+
+	var lines []models.ReturnItem
+	var refund float64
+	for _, item := range items {
+		if item.Returnable() == 0 {
+			continue
+		}
+		fmt.Printf("Jumlah retur %s (maks %d, Enter = 0): ", truncate(item.ProductName, 20), item.Returnable())
+		input := readInput()
+		if input == "" {
+			continue
+		}
+		qty, err := strconv.Atoi(input)
+		if err != nil || qty < 0 || qty > item.Returnable() {
+			fmt.Printf("❌ Jumlah harus 0 sampai %d!\n", item.Returnable())
+			return
+		}
+		if qty > 0 {
+			lines = append(lines, models.ReturnItem{ProductID: item.ProductID, Quantity: qty})
+			refund += float64(qty) * item.SellingPrice
+		}
+	}
+	if len(lines) == 0 {
+		fmt.Println("💡 Tidak ada barang yang diretur")
+		return
+	}
+
+	fmt.Print("Alasan retur: ")
+	reason := readInput()
+	if reason == "" {
+		fmt.Println("❌ Alasan retur wajib diisi!")
+		return
+	}
+	fmt.Printf("Kembalikan uang %s ke pelanggan dan masukkan barang ke stok? (y/n): ", formatRupiah(refund))
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+
+	ret, err := models.CreateReturn(user, t.ID, lines, reason)
+	if err != nil {
+		fmt.Printf("❌ Gagal memproses retur: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Retur RET-%06d dicatat, uang dikembalikan: %s\n", ret.ID, formatRupiah(ret.Total))
+}
+
+// parseTransactionNumber menerima nomor struk "TRX-000123" atau ID angka saja
+func parseTransactionNumber(s string) (int, bool) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "TRX-")
+	id, err := strconv.Atoi(s)
+	return id, err == nil && id > 0
+}
+
+// printReturns menampilkan daftar retur pada laporan harian
+func printReturns(returns []models.SalesReturn) {
+	fmt.Println("\n┌────────────┬────────────┬──────────┬───────────────┬──────────────────────┐")
+	fmt.Println("│ No. Retur  │ Transaksi  │ Waktu    │ Dikembalikan  │ Alasan               │")
+	fmt.Println("├────────────┼────────────┼──────────┼───────────────┼──────────────────────┤")
+	for _, r := range returns {
+		fmt.Printf("│ RET-%06d │ TRX-%06d │ %s    │ %13s │ %-20s │\n", r.ID, r.TransactionID,
+			r.CreatedAt.Format("15:04"), formatRupiah(r.Total), truncate(r.Reason, 20))
+	}
+	fmt.Println("└────────────┴────────────┴──────────┴───────────────┴──────────────────────┘")
+}
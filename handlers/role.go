@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// RoleMenu menampilkan menu manajemen role & permission
+func RoleMenu(user *models.User) {
+	for {
+		fmt.Println("\n╔══════════════════════════════════════╗")
+		fmt.Println("║        MANAJEMEN ROLE                ║")
+		fmt.Println("╠══════════════════════════════════════╣")
+		fmt.Println("║  1. Lihat Daftar Role                ║")
+		fmt.Println("║  2. Tambah Role Baru                 ║")
+		fmt.Println("║  3. Ubah Permission Role             ║")
+		fmt.Println("║  4. Hapus Role                       ║")
+		fmt.Println("║  0. Kembali ke Menu Utama            ║")
+		fmt.Println("╚══════════════════════════════════════╝")
+
+		fmt.Print("Pilihan: ")
+		choice := readInput()
+
+		switch choice {
+		case "1":
+			listRoles()
+		case "2":
+			createRole(user)
+		case "3":
+			editRolePermissions(user)
+		case "4":
+			deleteRole(user)
+		case "0":
+			return
+		default:
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+func listRoles() {
+	roles, err := models.GetAllRoles()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	fmt.Println("\n┌─────┬──────────────┬──────────────────────────────────────────────────────┐")
+	fmt.Println("│ ID  │ Role         │ Permission                                           │")
+	fmt.Println("├─────┼──────────────┼──────────────────────────────────────────────────────┤")
+
+	for _, r := range roles {
+		perms := strings.Join(r.Permissions, ", ")
+		if r.Name == models.AdminRole {
+			perms = "(semua permission)"
+		}
+		fmt.Printf("│ %-3d │ %-12s │ %-52s │\n", r.ID, truncate(r.Name, 12), truncate(perms, 52))
+	}
+	fmt.Println("└─────┴──────────────┴──────────────────────────────────────────────────────┘")
+}
+
+// selectPermissions menampilkan daftar permission dan membaca pilihan nomor (dipisah koma)
+func selectPermissions(current []string) ([]string, bool) {
+	selected := make(map[string]bool)
+	for _, p := range current {
+		selected[p] = true
+	}
+
+	fmt.Println("\nDaftar Permission:")
+	for i, p := range models.AllPermissions {
+		mark := " "
+		if selected[p.Code] {
+			mark = "x"
+		}
+		fmt.Printf("  [%s] %2d. %-20s %s\n", mark, i+1, p.Code, p.Description)
+	}
+
+	fmt.Print("Nomor permission (pisahkan dengan koma, Enter = tidak diubah): ")
+	input := readInput()
+	if input == "" {
+		return current, true
+	}
+
+	var perms []string
+	for _, part := range strings.Split(input, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > len(models.AllPermissions) {
+			fmt.Printf("❌ Nomor permission tidak valid: %s\n", strings.TrimSpace(part))
+			return nil, false
+		}
+		perms = append(perms, models.AllPermissions[n-1].Code)
+	}
+	return perms, true
+}
+
+func createRole(user *models.User) {
+	fmt.Println("\n═══ TAMBAH ROLE BARU ═══")
+
+	fmt.Print("Nama Role: ")
+	name := strings.ToLower(readInput())
+	if name == "" {
+		fmt.Println("❌ Nama role tidak boleh kosong!")
+		return
+	}
+
+	fmt.Print("Keterangan: ")
+	description := readInput()
+
+	perms, ok := selectPermissions(nil)
+	if !ok {
+		return
+	}
+
+	role, err := models.CreateRole(user, name, description, perms)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Printf("✅ Role '%s' berhasil ditambahkan dengan %d permission\n", role.Name, len(role.Permissions))
+}
+
+func editRolePermissions(user *models.User) {
+	listRoles()
+
+	fmt.Print("\nMasukkan nama role yang akan diubah (kosongkan untuk batal): ")
+	name := readInput()
+	if name == "" {
+		return
+	}
+
+	role, err := models.GetRoleByName(name)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if role.Name == models.AdminRole {
+		fmt.Println("❌ Permission role admin tidak dapat diubah!")
+		return
+	}
+
+	perms, ok := selectPermissions(role.Permissions)
+	if !ok {
+		return
+	}
+
+	if err := models.SetRolePermissions(user, role.Name, perms); err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Println("✅ Permission role berhasil diubah! (berlaku saat user login berikutnya)")
+}
+
+func deleteRole(user *models.User) {
+	listRoles()
+
+	fmt.Print("\nMasukkan nama role yang akan dihapus (kosongkan untuk batal): ")
+	name := readInput()
+	if name == "" {
+		return
+	}
+
+	fmt.Printf("⚠️  Yakin ingin menghapus role '%s'? (y/n): ", name)
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("Batal menghapus.")
+		return
+	}
+
+	if err := models.DeleteRole(user, name); err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+
+	fmt.Println("✅ Role berhasil dihapus!")
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// warehouseName mengembalikan nama gudang, atau "Gudang <id>" jika tidak ditemukan
+func warehouseName(id int) string {
+	if w, _ := models.GetWarehouseByID(id); w != nil {
+		return w.Name
+	}
+	return fmt.Sprintf("Gudang %d", id)
+}
+
+// canAccessProduct bernilai true jika user boleh mengelola produk: user dengan akses semua gudang
+// selalu boleh, user gudang hanya untuk produk yang ada di gudangnya
+func canAccessProduct(user *models.User, productID int) bool {
+	if user == nil || user.HasAllWarehouses() {
+		return true
+	}
+	if user.WarehouseID == nil {
+		return false
+	}
+	_, err := models.GetProductInWarehouse(productID, *user.WarehouseID)
+	return err == nil
+}
+
+// catalogProduct mencari produk master dengan SKU atau barcode tertentu (nil jika belum ada)
+func catalogProduct(codes ...string) *models.Product {
+	for _, code := range codes {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		if found, _ := models.FindProductsByCode(code, nil); len(found) > 0 {
+			if p, err := models.GetProductByID(found[0].ID); err == nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// warehousePrice mengembalikan harga gudang untuk disimpan: nil jika sama dengan harga master
+func warehousePrice(price, master float64) *float64 {
+	if price == master {
+		return nil
+	}
+	return &price
+}
+
+// manageWarehouseStock menampilkan stok sebuah produk di semua gudang, lalu mengatur stok dan
+// harga khusus di satu gudang. Produk juga bisa ditambahkan ke gudang yang belum menjualnya.
+func manageWarehouseStock(user *models.User) {
+	ListProducts(user)
+
+	fmt.Print("\nMasukkan ID produk: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	product, err := models.GetProductByID(id)
+	if err != nil {
+		fmt.Println("❌ Produk tidak ditemukan!")
+		return
+	}
+	if variants, _ := models.GetVariants(product.ID); len(variants) > 0 {
+		fmt.Printf("💡 '%s' punya %d varian, atur stok lewat ID variannya\n", product.Name, len(variants))
+		return
+	}
+
+	stocks, err := models.GetProductStocks(product.ID)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	// User gudang hanya mengatur gudangnya sendiri
+	var warehouseID int
+	if user != nil && !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return
+		}
+		warehouseID = *user.WarehouseID
+		var own []models.ProductStock
+		for _, st := range stocks {
+			if st.WarehouseID == warehouseID {
+				own = append(own, st)
+			}
+		}
+		stocks = own
+	}
+	printProductStocks(product, stocks)
+
+	if warehouseID == 0 {
+		warehouses, err := models.GetAllWarehouses()
+		if err != nil || len(warehouses) == 0 {
+			fmt.Println("❌ Tidak ada gudang tersedia!")
+			return
+		}
+		fmt.Println("\nPilih Gudang (boleh gudang yang belum punya produk ini):")
+		for _, w := range warehouses {
+			fmt.Printf("  %d. %s\n", w.ID, w.Name)
+		}
+		fmt.Print("ID Gudang: ")
+		if warehouseID, err = strconv.Atoi(readInput()); err != nil || warehouseID <= 0 {
+			fmt.Println("❌ ID gudang tidak valid!")
+			return
+		}
+	}
+
+	st := models.ProductStock{ProductID: product.ID, WarehouseID: warehouseID}
+	for _, current := range stocks {
+		if current.WarehouseID == warehouseID {
+			st = current
+		}
+	}
+
+	fmt.Printf("\n═══ %s DI %s ═══\n", strings.ToUpper(product.DisplayName()), strings.ToUpper(warehouseName(warehouseID)))
+	fmt.Println("(Tekan Enter untuk tidak mengubah)")
+
+	reorderPoint := st.ReorderPoint
+	if user.Can(models.PermStockAdjust) {
+		fmt.Printf("Stok [%d]: ", st.Stock)
+		if input := readInput(); input != "" {
+			if st.Stock, err = strconv.Atoi(input); err != nil || st.Stock < 0 {
+				fmt.Println("❌ Stok tidak valid!")
+				return
+			}
+		}
+		fmt.Printf("Stok minimum [%d] (0 = tanpa peringatan): ", st.ReorderPoint)
+		if input := readInput(); input != "" {
+			if reorderPoint, err = strconv.Atoi(input); err != nil || reorderPoint < 0 {
+				fmt.Println("❌ Stok minimum tidak valid!")
+				return
+			}
+		}
+	}
+
+	// Harga gudang hanya bisa diubah dengan permission kelola produk
+	if user.Can(models.PermProductManage) {
+		var ok bool
+		if st.PurchasePrice, ok = promptWarehousePrice("Harga Beli", st.PurchasePrice, product.PurchasePrice); !ok {
+			fmt.Println("❌ Harga beli tidak valid!")
+			return
+		}
+		if st.SellingPrice, ok = promptWarehousePrice("Harga Jual", st.SellingPrice, product.SellingPrice); !ok {
+			fmt.Println("❌ Harga jual tidak valid!")
+			return
+		}
+	}
+
+	if err := models.SetProductStock(user, st); err != nil {
+		fmt.Printf("❌ Gagal menyimpan stok: %v\n", err)
+		return
+	}
+	if reorderPoint != st.ReorderPoint {
+		if err := models.SetReorderPoint(user, product.ID, warehouseID, reorderPoint); err != nil {
+			fmt.Printf("❌ Gagal menyimpan stok minimum: %v\n", err)
+			return
+		}
+	}
+	fmt.Printf("✅ Stok '%s' di %s disimpan!\n", product.DisplayName(), warehouseName(warehouseID))
+}
+
+// promptWarehousePrice menanyakan harga khusus gudang: kosong = tetap, "-" = ikut harga master
+func promptWarehousePrice(label string, current *float64, master float64) (*float64, bool) {
+	shown := formatRupiah(master) + " (master)"
+	if current != nil {
+		shown = formatRupiah(*current)
+	}
+	fmt.Printf("%s gudang [%s] ('-' = ikut harga master): ", label, shown)
+
+	switch input := readInput(); input {
+	case "":
+		return current, true
+	case "-":
+		return nil, true
+	default:
+		price, err := strconv.ParseFloat(input, 64)
+		if err != nil || price < 0 {
+			return current, false
+		}
+		return warehousePrice(price, master), true
+	}
+}
+
+// printProductStocks menampilkan stok dan harga produk di tiap gudang; harga khusus gudang ditandai *
+func printProductStocks(product *models.Product, stocks []models.ProductStock) {
+	fmt.Printf("\n═══ STOK %s ═══\n", strings.ToUpper(product.DisplayName()))
+	fmt.Printf("Harga master: beli %s, jual %s\n", formatRupiah(product.PurchasePrice), formatRupiah(product.SellingPrice))
+	fmt.Println("┌──────────────────────────┬────────┬────────┬───────────────┬───────────────┐")
+	fmt.Println("│ Gudang                   │ Stok   │ Min    │ Hrg Beli      │ Hrg Jual      │")
+	fmt.Println("├──────────────────────────┼────────┼────────┼───────────────┼───────────────┤")
+	if len(stocks) == 0 {
+		fmt.Println("│           B E L U M   A D A   D I   G U D A N G   M A N A P U N            │")
+	}
+	for _, st := range stocks {
+		stock := fmt.Sprintf("%d", st.Stock)
+		if st.IsLow() {
+			stock = "!" + stock
+		}
+		fmt.Printf("│ %-24s │ %6s │ %6d │ %13s │ %13s │\n", truncate(warehouseName(st.WarehouseID), 24), stock, st.ReorderPoint,
+			stockPrice(st.PurchasePrice, product.PurchasePrice), stockPrice(st.SellingPrice, product.SellingPrice))
+	}
+	fmt.Println("└──────────────────────────┴────────┴────────┴───────────────┴───────────────┘")
+	fmt.Println("💡 * = harga khusus gudang, ! = stok sudah mencapai stok minimum")
+}
+
+// stockPrice memformat harga gudang, ditandai * jika berbeda dari harga master
+func stockPrice(price *float64, master float64) string {
+	if price == nil {
+		return formatRupiah(master)
+	}
+	return "*" + formatRupiah(*price)
+}
+
+// printStockMatrix menampilkan stok tiap produk di semua gudang dalam satu tabel
+func printStockMatrix(warehouses []models.Warehouse) {
+	products, err := models.GetAllProducts(nil)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	// Produk induk yang punya varian tidak ditampilkan, stoknya ada di tiap varian
+	parents := make(map[int]bool)
+	for _, p := range products {
+		if p.ParentID != nil {
+			parents[*p.ParentID] = true
+		}
+	}
+
+	type row struct {
+		name   string
+		stocks map[int]int
+	}
+	var rows []*row
+	byID := make(map[int]*row)
+	for _, p := range products {
+		if parents[p.ID] {
+			continue
+		}
+		r, ok := byID[p.ID]
+		if !ok {
+			r = &row{name: p.DisplayName(), stocks: make(map[int]int)}
+			byID[p.ID] = r
+			rows = append(rows, r)
+		}
+		if p.WarehouseID != 0 {
+			r.stocks[p.WarehouseID] = p.Stock
+		}
+	}
+
+	line := func(left, mid, right string) string {
+		s := left + strings.Repeat("─", 24)
+		for range warehouses {
+			s += mid + strings.Repeat("─", 10)
+		}
+		return s + mid + strings.Repeat("─", 8) + right
+	}
+
+	fmt.Println("\n═══ STOK PRODUK DI SEMUA GUDANG ═══")
+	fmt.Println(line("┌", "┬", "┐"))
+	header := fmt.Sprintf("│ %-22s ", "Produk")
+	for _, w := range warehouses {
+		header += fmt.Sprintf("│ %8s ", truncate(w.Name, 8))
+	}
+	fmt.Println(header + fmt.Sprintf("│ %6s │", "Total"))
+	fmt.Println(line("├", "┼", "┤"))
+
+	for _, r := range rows {
+		out := fmt.Sprintf("│ %-22s ", truncate(r.name, 22))
+		total := 0
+		for _, w := range warehouses {
+			stock, ok := r.stocks[w.ID]
+			if !ok {
+				out += fmt.Sprintf("│ %8s ", "-")
+				continue
+			}
+			total += stock
+			out += fmt.Sprintf("│ %8d ", stock)
+		}
+		fmt.Println(out + fmt.Sprintf("│ %6d │", total))
+	}
+	fmt.Println(line("└", "┴", "┘"))
+	fmt.Println("💡 - = produk belum ada di gudang tersebut")
+}
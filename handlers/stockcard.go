@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// showStockCard menampilkan kartu stok sebuah produk: saldo awal, setiap mutasi masuk/keluar
+// beserta saldonya, dan saldo akhir pada rentang tanggal
+func showStockCard(user *models.User) {
+	fmt.Print("\nProduk (ID, SKU, atau scan barcode): ")
+	code := readInput()
+	var product *models.Product
+	if id, err := strconv.Atoi(code); err == nil {
+		product, _ = models.GetProductByID(id)
+	}
+	if product == nil {
+		product = catalogProduct(code)
+	}
+	if product == nil {
+		fmt.Println("❌ Produk tidak ditemukan!")
+		return
+	}
+
+	// User gudang hanya melihat gudangnya sendiri
+	var warehouseID *int
+	if user != nil && !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return
+		}
+		warehouseID = user.WarehouseID
+	} else {
+		warehouses, _ := models.GetAllWarehouses()
+		fmt.Println("Gudang:")
+		for _, w := range warehouses {
+			fmt.Printf("  %d. %s\n", w.ID, w.Name)
+		}
+		fmt.Print("ID Gudang (Enter = semua gudang): ")
+		if input := readInput(); input != "" {
+			id, err := strconv.Atoi(input)
+			if err != nil || id <= 0 {
+				fmt.Println("❌ ID gudang tidak valid!")
+				return
+			}
+			warehouseID = &id
+		}
+	}
+
+	now := time.Now()
+	from, ok := promptDate("Dari tanggal (DD-MM-YYYY, Enter = awal bulan ini): ",
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local))
+	if !ok {
+		return
+	}
+	to, ok := promptDate("Sampai tanggal (DD-MM-YYYY, Enter = hari ini): ", now)
+	if !ok {
+		return
+	}
+
+	card, err := models.GetStockCard(product.ID, warehouseID, from, to)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	scope := "Semua Gudang"
+	if warehouseID != nil {
+		scope = warehouseName(*warehouseID)
+	}
+	fmt.Println("\n╔════════════════════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Printf("║%s║\n", centerText("KARTU STOK: "+strings.ToUpper(card.Product.DisplayName()), 92))
+	fmt.Printf("║%s║\n", centerText(fmt.Sprintf("%s, %s s/d %s", scope, card.From.Format("02-01-2006"), card.To.Format("02-01-2006")), 92))
+	fmt.Println("╚════════════════════════════════════════════════════════════════════════════════════════════╝")
+
+	fmt.Println("┌──────────────────┬────────────────┬─────────────┬────────┬────────┬────────┬────────────────┐")
+	fmt.Println("│ Waktu            │ Gudang         │ Jenis       │ Masuk  │ Keluar │ Saldo  │ Referensi      │")
+	fmt.Println("├──────────────────┼────────────────┼─────────────┼────────┼────────┼────────┼────────────────┤")
+	fmt.Printf("│ %-16s │ %-14s │ %-11s │ %6s │ %6s │ %6d │ %-14s │\n", "", "", "Saldo Awal", "", "", card.Opening, "")
+	for _, m := range card.Movements {
+		in, out := "", ""
+		if m.Quantity > 0 {
+			in = strconv.Itoa(m.Quantity)
+		} else {
+			out = strconv.Itoa(-m.Quantity)
+		}
+		fmt.Printf("│ %-16s │ %-14s │ %-11s │ %6s │ %6s │ %6d │ %-14s │\n",
+			m.CreatedAt.Format("02-01-2006 15:04"), truncate(warehouseName(m.WarehouseID), 14),
+			models.MovementTypeLabel(m.Type), in, out, m.Balance, truncate(m.Reference, 14))
+	}
+	fmt.Println("├──────────────────┴────────────────┴─────────────┼────────┼────────┼────────┼────────────────┤")
+	fmt.Printf("│ %-47s │ %6d │ %6d │ %6d │ %-14s │\n", "TOTAL / SALDO AKHIR", card.In, card.Out, card.Closing, "")
+	fmt.Println("└─────────────────────────────────────────────────┴────────┴────────┴────────┴────────────────┘")
+	if warehouseID == nil {
+		fmt.Println("💡 Saldo per baris adalah stok di gudang baris tersebut")
+	}
+}
+
+// promptDate membaca tanggal DD-MM-YYYY (Enter = def); ok=false jika format salah
+func promptDate(prompt string, def time.Time) (time.Time, bool) {
+	fmt.Print(prompt)
+	input := readInput()
+	if input == "" {
+		return def, true
+	}
+	date, err := time.ParseInLocation("02-01-2006", input, time.Local)
+	if err != nil {
+		fmt.Println("❌ Format tanggal tidak valid! Gunakan DD-MM-YYYY")
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -2,16 +2,18 @@ package handlers
 
 import (
 	"fmt"
+	"kasir/config"
 	"kasir/models"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // TransactionMenu menampilkan menu transaksi penjualan
-func TransactionMenu() {
+func TransactionMenu(user *models.User) {
 	cart := []models.CartItem{}
 
 	for {
@@ -19,7 +21,7 @@ func TransactionMenu() {
 		fmt.Println("║         TRANSAKSI BARU               ║")
 		fmt.Println("╠══════════════════════════════════════╣")
 		fmt.Println("║  1. Lihat Daftar Produk              ║")
-		fmt.Println("║  2. Tambah ke Keranjang              ║")
+		fmt.Println("║  2. Scan / Tambah ke Keranjang       ║")
 		fmt.Println("║  3. Lihat Keranjang                  ║")
 		fmt.Println("║  4. Hapus dari Keranjang             ║")
 		fmt.Println("║  5. Proses Pembayaran                ║")
@@ -30,15 +32,15 @@ func TransactionMenu() {
 		choice := readInput()
 		switch choice {
 		case "1":
-			ListProducts()
+			ListProducts(user)
 		case "2":
-			addToCart(&cart)
+			addToCart(user, &cart)
 		case "3":
 			viewCart(cart)
 		case "4":
 			removeFromCart(&cart)
 		case "5":
-			if processPayment(cart) {
+			if processPayment(user, cart) {
 				return // Transaksi selesai, kembali ke menu utama
 			}
 		case "0":
@@ -55,82 +57,187 @@ func TransactionMenu() {
 	}
 }
 
-func addToCart(cart *[]models.CartItem) {
-	ListProducts()
+// addToCart menambah produk ke keranjang lewat scan barcode (keyboard wedge), SKU, atau ID.
+// Hasil scan langsung menambah 1 unit; awalan "N*" menambah N unit sekaligus.
+func addToCart(user *models.User, cart *[]models.CartItem) {
+	fmt.Println("\n💡 Scan barcode atau ketik SKU/ID produk. Untuk beberapa unit: 3*8991002101234")
+	fmt.Println("   Ketik 'l' untuk lihat daftar produk, Enter kosong atau '0' untuk kembali")
 
 	for {
-		fmt.Print("\nMasukkan ID produk (atau '0' untuk kembali): ")
-		idStr := readInput()
-		if idStr == "0" {
-			break
+		fmt.Print("\nScan/Kode: ")
+		input := readInput()
+		if input == "" || input == "0" {
+			return
+		}
+		if strings.EqualFold(input, "l") {
+			ListProducts(user)
+			continue
 		}
 
-		id, err := strconv.Atoi(idStr)
+		qty, code, err := parseScanInput(input)
 		if err != nil {
-			fmt.Println("❌ ID tidak valid!")
+			fmt.Println("❌", err)
 			continue
 		}
 
-		product, err := models.GetProductByID(id)
+		product, byID, err := findCartProduct(user, *cart, code)
 		if err != nil {
-			fmt.Println("❌ Produk tidak ditemukan!")
+			fmt.Println("❌", err)
+			continue
+		}
+		// Produk induk tidak dijual langsung, kasir memilih variannya
+		if product, err = chooseVariant(product); err != nil {
+			fmt.Println("❌", err)
 			continue
 		}
 
-		// Cek akses warehouse untuk user biasa
-		if models.CurrentUser != nil && !models.CurrentUser.IsAdmin() {
-			if models.CurrentUser.WarehouseID != nil && product.WarehouseID != *models.CurrentUser.WarehouseID {
-				fmt.Println("❌ Produk tidak tersedia di gudang Anda!")
+		// Barcode/SKU tanpa pengali = 1 unit; ID produk tanpa pengali = tanya jumlah
+		if qty == 0 && byID {
+			fmt.Printf("Produk: %s (Stok: %d, Harga: %s)\n", product.DisplayName(), product.Stock, formatRupiah(product.SellingPrice))
+			fmt.Print("Jumlah: ")
+			qty, err = strconv.Atoi(readInput())
+			if err != nil || qty <= 0 {
+				fmt.Println("❌ Jumlah tidak valid!")
 				continue
 			}
+		} else if qty == 0 {
+			qty = 1
 		}
 
-		fmt.Printf("Produk: %s (Stok: %d, Harga: %s)\n", product.Name, product.Stock, formatRupiah(product.SellingPrice))
-		fmt.Print("Jumlah: ")
-		qtyStr := readInput()
-		qty, err := strconv.Atoi(qtyStr)
-		if err != nil || qty <= 0 {
-			fmt.Println("❌ Jumlah tidak valid!")
+		if err := addItemToCart(cart, product, qty); err != nil {
+			fmt.Println("❌", err)
 			continue
 		}
 
-		// Cek stok yang tersedia (dikurangi yang sudah di cart)
-		availableStock := product.Stock
-		for _, item := range *cart {
-			if item.Product.ID == product.ID {
-				availableStock -= item.Quantity
-			}
+		fmt.Printf("✅ %s x%d ditambahkan ke keranjang (total: %s)\n", product.DisplayName(), qty, formatRupiah(cartTotal(*cart)))
+	}
+}
+
+// parseScanInput memisahkan pengali jumlah dari kode, contoh "3*8991002101234" → 3, "8991002101234".
+// Jumlah 0 berarti tidak ada pengali.
+func parseScanInput(input string) (int, string, error) {
+	input = strings.TrimSpace(input)
+	qtyStr, code, found := strings.Cut(input, "*")
+	if !found {
+		return 0, input, nil
+	}
+
+	qty, err := strconv.Atoi(strings.TrimSpace(qtyStr))
+	if err != nil || qty <= 0 {
+		return 0, "", fmt.Errorf("jumlah '%s' tidak valid", qtyStr)
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return 0, "", fmt.Errorf("kode produk kosong setelah '*'")
+	}
+	return qty, code, nil
+}
+
+// findCartProduct mencari produk berdasarkan barcode/SKU, lalu ID sebagai cadangan.
+// byID bernilai true jika produk ditemukan lewat ID.
+func findCartProduct(user *models.User, cart []models.CartItem, code string) (*models.Product, bool, error) {
+	// Cari di gudang user, atau di gudang barang yang sudah ada di keranjang
+	var warehouseID *int
+	if user != nil && !user.HasAllWarehouses() {
+		warehouseID = user.WarehouseID
+	} else if len(cart) > 0 {
+		warehouseID = &cart[0].Product.WarehouseID
+	}
+
+	matches, err := models.FindProductsByCode(code, warehouseID)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case len(matches) == 1:
+		return &matches[0], false, nil
+	case len(matches) > 1:
+		product, err := chooseProduct(matches)
+		return product, false, err
+	}
+
+	id, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, false, fmt.Errorf("produk dengan kode '%s' tidak ditemukan", code)
+	}
+	products, err := models.GetProductWarehouses(id)
+	if err != nil {
+		return nil, false, fmt.Errorf("produk dengan kode atau ID '%s' tidak ditemukan", code)
+	}
+
+	var available []models.Product
+	for _, p := range products {
+		if warehouseID == nil || p.WarehouseID == *warehouseID {
+			available = append(available, p)
 		}
+	}
+	switch {
+	case len(available) == 1:
+		return &available[0], true, nil
+	case len(available) > 1:
+		product, err := chooseProduct(available)
+		return product, true, err
+	case user != nil && !user.HasAllWarehouses():
+		return nil, false, fmt.Errorf("produk tidak tersedia di gudang Anda")
+	case warehouseID != nil:
+		return nil, false, fmt.Errorf("produk tidak tersedia di gudang barang lain di keranjang")
+	}
+	return nil, false, fmt.Errorf("produk belum ada di gudang mana pun")
+}
 
-		if qty > availableStock {
-			fmt.Printf("❌ Stok tidak mencukupi! Tersedia: %d\n", availableStock)
-			continue
+// chooseProduct meminta kasir memilih jika produk yang dicari tersedia di beberapa gudang
+func chooseProduct(products []models.Product) (*models.Product, error) {
+	fmt.Println("Produk tersedia di beberapa gudang:")
+	for i, p := range products {
+		warehouseName := fmt.Sprintf("Gudang %d", p.WarehouseID)
+		if w, _ := models.GetWarehouseByID(p.WarehouseID); w != nil {
+			warehouseName = w.Name
 		}
+		fmt.Printf("  %d. %s - %s (Stok: %d, Harga: %s)\n", i+1, p.DisplayName(), warehouseName, p.Stock, formatRupiah(p.SellingPrice))
+	}
+	fmt.Print("Pilih: ")
 
-		// Cek apakah produk sudah ada di cart
-		found := false
-		for i, item := range *cart {
-			if item.Product.ID == product.ID {
-				(*cart)[i].Quantity += qty
-				found = true
-				break
-			}
+	choice, err := strconv.Atoi(readInput())
+	if err != nil || choice < 1 || choice > len(products) {
+		return nil, fmt.Errorf("pilihan tidak valid")
+	}
+	return &products[choice-1], nil
+}
+
+// addItemToCart menambah qty produk ke keranjang jika stok (dikurangi isi keranjang) mencukupi
+func addItemToCart(cart *[]models.CartItem, product *models.Product, qty int) error {
+	availableStock := product.Stock
+	for _, item := range *cart {
+		if item.Product.ID == product.ID {
+			availableStock -= item.Quantity
 		}
+	}
+	if qty > availableStock {
+		return fmt.Errorf("stok tidak mencukupi! Tersedia: %d", availableStock)
+	}
 
-		if !found {
-			*cart = append(*cart, models.CartItem{
-				Product:  product,
-				Quantity: qty,
-			})
+	// Cek apakah produk sudah ada di cart
+	for i, item := range *cart {
+		if item.Product.ID == product.ID {
+			(*cart)[i].Quantity += qty
+			return nil
 		}
+	}
 
-		fmt.Printf("✅ %s x%d ditambahkan ke keranjang\n", product.Name, qty)
+	*cart = append(*cart, models.CartItem{
+		Product:  product,
+		Quantity: qty,
+	})
+	return nil
+}
 
-		fmt.Print("\nTambah produk lagi? (y/n): ")
-		if strings.ToLower(readInput()) != "y" {
-			break
-		}
+// cartTotal menghitung total harga isi keranjang
+func cartTotal(cart []models.CartItem) float64 {
+	var total float64
+	for _, item := range cart {
+		total += item.Product.SellingPrice * float64(item.Quantity)
 	}
+	return total
 }
 
 func viewCart(cart []models.CartItem) {
@@ -147,7 +254,7 @@ func viewCart(cart []models.CartItem) {
 		subtotal := item.Product.SellingPrice * float64(item.Quantity)
 		total += subtotal
 		fmt.Printf("│ %-3d │ %-22s │ %13s │ %3d │ %13s │\n",
-			i+1, truncate(item.Product.Name, 22), formatRupiah(item.Product.SellingPrice), item.Quantity, formatRupiah(subtotal))
+			i+1, truncate(item.Product.DisplayName(), 22), formatRupiah(item.Product.SellingPrice), item.Quantity, formatRupiah(subtotal))
 	}
 	fmt.Println("├─────┴────────────────────────┴───────────────┴─────┼───────────────┤")
 	fmt.Printf("│                                       TOTAL        │ %13s │\n", formatRupiah(total))
@@ -172,10 +279,10 @@ func removeFromCart(cart *[]models.CartItem) {
 
 	removed := (*cart)[no-1]
 	*cart = append((*cart)[:no-1], (*cart)[no:]...)
-	fmt.Printf("✅ %s dihapus dari keranjang\n", removed.Product.Name)
+	fmt.Printf("✅ %s dihapus dari keranjang\n", removed.Product.DisplayName())
 }
 
-func processPayment(cart []models.CartItem) bool {
+func processPayment(user *models.User, cart []models.CartItem) bool {
 	if len(cart) == 0 {
 		fmt.Println("\n⚠️  Keranjang kosong! Tambahkan produk terlebih dahulu.")
 		return false
@@ -208,7 +315,7 @@ func processPayment(cart []models.CartItem) bool {
 	}
 
 	// Proses transaksi
-	transaction, err := models.CreateTransaction(models.CurrentUser, cart, payment)
+	transaction, err := models.CreateTransaction(user, cart, payment)
 	if err != nil {
 		fmt.Printf("❌ Gagal memproses transaksi: %v\n", err)
 		return false
@@ -235,18 +342,30 @@ func printReceipt(t *models.Transaction) {
 	readInput()
 }
 
+// receiptWidth adalah lebar struk dalam karakter
+const receiptWidth = 43
+
 func generateReceiptText(t *models.Transaction) string {
 	var sb strings.Builder
 
 	sb.WriteString("\n")
+	store := config.App.Store
 	sb.WriteString("═══════════════════════════════════════════\n")
+	sb.WriteString(centerText(store.Name, receiptWidth) + "\n")
+	if store.Address != "" {
+		sb.WriteString(centerText(store.Address, receiptWidth) + "\n")
+	}
+	if store.Phone != "" {
+		sb.WriteString(centerText("Telp. "+store.Phone, receiptWidth) + "\n")
+	}
+	sb.WriteString("───────────────────────────────────────────\n")
 	sb.WriteString("             STRUK PEMBAYARAN              \n")
 	sb.WriteString("═══════════════════════════════════════════\n")
 	sb.WriteString(fmt.Sprintf("No. Transaksi: TRX-%06d\n", t.ID))
 	sb.WriteString(fmt.Sprintf("Tanggal      : %s\n", t.CreatedAt.Format("02-01-2006 15:04:05")))
 
-	if models.CurrentUser != nil {
-		sb.WriteString(fmt.Sprintf("Kasir        : %s\n", models.CurrentUser.Username))
+	if t.CashierName != "" {
+		sb.WriteString(fmt.Sprintf("Kasir        : %s\n", t.CashierName))
 	}
 
 	// Tampilkan gudang
@@ -269,18 +388,17 @@ func generateReceiptText(t *models.Transaction) string {
 	sb.WriteString(fmt.Sprintf("BAYAR        : %13s\n", formatRupiah(t.Payment)))
 	sb.WriteString(fmt.Sprintf("KEMBALIAN    : %13s\n", formatRupiah(t.Change)))
 	sb.WriteString("═══════════════════════════════════════════\n")
-	sb.WriteString("    Terima Kasih Atas Kunjungan Anda       \n")
+	if store.Footer != "" {
+		sb.WriteString(centerText(store.Footer, receiptWidth) + "\n")
+	}
 	sb.WriteString("═══════════════════════════════════════════\n")
 
 	return sb.String()
 }
 
 func saveReceiptToFile(t *models.Transaction, receipt string) {
-	// Get current directory
-	cwd, _ := os.Getwd()
-
-	// Create exports/nota folder if not exists
-	notaDir := cwd + "/exports/nota"
+	// Folder nota dari config (default exports/nota)
+	notaDir := config.App.Export.ReceiptDir
 	if err := os.MkdirAll(notaDir, 0755); err != nil {
 		fmt.Printf("❌ Gagal membuat folder: %v\n", err)
 		return
@@ -288,21 +406,21 @@ func saveReceiptToFile(t *models.Transaction, receipt string) {
 
 	// Generate filename
 	filename := fmt.Sprintf("nota_TRX-%06d_%s.txt", t.ID, t.CreatedAt.Format("20060102_150405"))
-	filepath := notaDir + "/" + filename
+	filePath := filepath.Join(notaDir, filename)
 
 	// Write to file
-	err := os.WriteFile(filepath, []byte(receipt), 0644)
+	err := os.WriteFile(filePath, []byte(receipt), 0644)
 	if err != nil {
 		fmt.Printf("❌ Gagal menyimpan nota: %v\n", err)
 		return
 	}
 
-	fmt.Printf("✅ Nota berhasil disimpan ke: %s\n", filepath)
+	fmt.Printf("✅ Nota berhasil disimpan ke: %s\n", filePath)
 
 	// Try to print (Linux)
 	fmt.Print("Cetak langsung ke printer? (y/n): ")
 	if strings.ToLower(readInput()) == "y" {
-		printFile(filepath)
+		printFile(filePath)
 	}
 }
 
@@ -0,0 +1,30 @@
+package handlers
+
+import "testing"
+
+func TestParseScanInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantQty  int
+		wantCode string
+		wantErr  bool
+	}{
+		{"8991002101234", 0, "8991002101234", false},
+		{"3*8991002101234", 3, "8991002101234", false},
+		{" 12 * MIE-01 ", 12, "MIE-01", false},
+		{"0*8991002101234", 0, "", true},
+		{"x*8991002101234", 0, "", true},
+		{"3*", 0, "", true},
+	}
+
+	for _, tc := range tests {
+		qty, code, err := parseScanInput(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if qty != tc.wantQty || code != tc.wantCode {
+			t.Errorf("%q = (%d, %q), want (%d, %q)", tc.input, qty, code, tc.wantQty, tc.wantCode)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// TransferMenu menampilkan menu transfer stok antar gudang. Gudang asal membuat dan mengirim
+// transfer, gudang tujuan menerimanya.
+func TransferMenu(user *models.User) {
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+	items := []MenuItem{
+		{"Daftar Transfer", as(listTransfers)},
+		{"Barang Dalam Perjalanan", as(listInTransit)},
+		{"Buat Transfer", as(createTransfer)},
+		{"Kirim Transfer", as(sendTransfer)},
+		{"Terima Transfer", as(receiveTransfer)},
+		{"Batalkan Draft", as(cancelTransfer)},
+	}
+
+	for {
+		var info []string
+		if !user.HasAllWarehouses() && user.WarehouseID != nil {
+			info = append(info, "Gudang: "+warehouseName(*user.WarehouseID))
+		}
+		PrintMenu("TRANSFER STOK", info, items, "Kembali ke Menu Utama")
+		fmt.Print("Pilihan: ")
+
+		choice := readInput()
+		if choice == "0" {
+			return
+		}
+		if !RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
+		}
+	}
+}
+
+// listTransfers menampilkan semua transfer dari/ke gudang user, lalu detail transfer yang dipilih
+func listTransfers(user *models.User) {
+	transfers, err := models.GetTransfers(user, "")
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+	printTransfers("DAFTAR TRANSFER", transfers)
+	if len(transfers) == 0 {
+		return
+	}
+
+	fmt.Print("\nLihat detail ID transfer (Enter = kembali): ")
+	input := readInput()
+	if input == "" {
+		return
+	}
+	id, err := strconv.Atoi(input)
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	t, err := models.GetTransfer(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	printTransfer(t)
+}
+
+// listInTransit menampilkan barang yang sudah dikirim tapi belum diterima
+func listInTransit(user *models.User) {
+	transfers, err := models.GetTransfers(user, models.TransferSent)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return
+	}
+
+	fmt.Println("\n═══ BARANG DALAM PERJALANAN ═══")
+	fmt.Println("┌───────┬──────────────────┬──────────────────┬──────────────────────────┬────────┐")
+	fmt.Println("│ TRF   │ Dari             │ Ke               │ Produk                   │ Qty    │")
+	fmt.Println("├───────┼──────────────────┼──────────────────┼──────────────────────────┼────────┤")
+	if len(transfers) == 0 {
+		fmt.Println("│              T I D A K   A D A   B A R A N G   D I   J A L A N              │")
+	}
+	for _, summary := range transfers {
+		t, err := models.GetTransfer(user, summary.ID)
+		if err != nil {
+			fmt.Println("❌ Error:", err)
+			return
+		}
+		for _, item := range t.Items {
+			fmt.Printf("│ %-5d │ %-16s │ %-16s │ %-24s │ %6d │\n", t.ID,
+				truncate(warehouseName(t.FromWarehouseID), 16), truncate(warehouseName(t.ToWarehouseID), 16),
+				truncate(item.ProductName, 24), item.Quantity)
+		}
+	}
+	fmt.Println("└───────┴──────────────────┴──────────────────┴──────────────────────────┴────────┘")
+}
+
+func printTransfers(title string, transfers []models.Transfer) {
+	fmt.Printf("\n═══ %s ═══\n", title)
+	fmt.Println("┌───────┬──────────────────┬──────────────────┬──────────────────┬──────────────────┐")
+	fmt.Println("│ ID    │ Dari             │ Ke               │ Status           │ Dibuat           │")
+	fmt.Println("├───────┼──────────────────┼──────────────────┼──────────────────┼──────────────────┤")
+	if len(transfers) == 0 {
+		fmt.Println("│                   B E L U M   A D A   T R A N S F E R                        │")
+	}
+	for _, t := range transfers {
+		fmt.Printf("│ %-5d │ %-16s │ %-16s │ %-16s │ %-16s │\n", t.ID,
+			truncate(warehouseName(t.FromWarehouseID), 16), truncate(warehouseName(t.ToWarehouseID), 16),
+			models.TransferStatusLabel(t.Status), t.CreatedAt.Format("02-01-2006 15:04"))
+	}
+	fmt.Println("└───────┴──────────────────┴──────────────────┴──────────────────┴──────────────────┘")
+}
+
+// printTransfer menampilkan detail transfer beserta selisih penerimaan
+func printTransfer(t *models.Transfer) {
+	fmt.Printf("\n📦 TRF-%06d: %s → %s (%s)\n", t.ID, warehouseName(t.FromWarehouseID), warehouseName(t.ToWarehouseID),
+		models.TransferStatusLabel(t.Status))
+	fmt.Printf("Dibuat : %s oleh %s\n", t.CreatedAt.Format("02-01-2006 15:04"), orDash(t.CreatedBy))
+	if t.SentAt != nil {
+		fmt.Printf("Dikirim: %s\n", t.SentAt.Format("02-01-2006 15:04"))
+	}
+	if t.ReceivedAt != nil {
+		fmt.Printf("Diterima: %s\n", t.ReceivedAt.Format("02-01-2006 15:04"))
+	}
+	if t.Note != "" {
+		fmt.Printf("Catatan: %s\n", t.Note)
+	}
+
+	fmt.Println("┌──────────────────────────────┬──────────┬──────────┬──────────┐")
+	fmt.Println("│ Produk                       │ Dikirim  │ Diterima │ Selisih  │")
+	fmt.Println("├──────────────────────────────┼──────────┼──────────┼──────────┤")
+	for _, item := range t.Items {
+		received, shortage := "-", "-"
+		if t.Status == models.TransferReceived {
+			received, shortage = strconv.Itoa(item.Received), strconv.Itoa(item.Shortage())
+		}
+		fmt.Printf("│ %-28s │ %8d │ %8s │ %8s │\n", truncate(item.ProductName, 28), item.Quantity, received, shortage)
+	}
+	fmt.Println("└──────────────────────────────┴──────────┴──────────┴──────────┘")
+}
+
+// orDash mengganti string kosong dengan "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// chooseWarehouse menampilkan daftar gudang (kecuali exceptID) dan meminta user memilih salah satu
+func chooseWarehouse(label string, exceptID int) (int, bool) {
+	warehouses, err := models.GetAllWarehouses()
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return 0, false
+	}
+	fmt.Printf("\n%s:\n", label)
+	for _, w := range warehouses {
+		if w.ID != exceptID {
+			fmt.Printf("  %d. %s\n", w.ID, w.Name)
+		}
+	}
+	fmt.Print("ID Gudang: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil || id <= 0 || id == exceptID {
+		fmt.Println("❌ ID gudang tidak valid!")
+		return 0, false
+	}
+	if w, _ := models.GetWarehouseByID(id); w == nil {
+		fmt.Println("❌ Gudang tidak ditemukan!")
+		return 0, false
+	}
+	return id, true
+}
+
+// createTransfer membuat draft transfer dari gudang user (atau gudang pilihan admin),
+// lalu menawarkan untuk langsung mengirimnya
+func createTransfer(user *models.User) {
+	fmt.Println("\n═══ BUAT TRANSFER STOK ═══")
+
+	var from int
+	if !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			fmt.Println("❌ Anda tidak memiliki gudang!")
+			return
+		}
+		from = *user.WarehouseID
+		fmt.Printf("Gudang asal: %s\n", warehouseName(from))
+	} else {
+		var ok bool
+		if from, ok = chooseWarehouse("Gudang Asal", 0); !ok {
+			return
+		}
+	}
+	to, ok := chooseWarehouse("Gudang Tujuan", from)
+	if !ok {
+		return
+	}
+
+	var items []models.TransferItem
+	fmt.Println("\nMasukkan produk yang dikirim (ID, SKU, atau scan barcode). Enter kosong = selesai.")
+	for {
+		fmt.Print("Produk: ")
+		code := readInput()
+		if code == "" {
+			break
+		}
+		product, err := findWarehouseProduct(code, from)
+		if err != nil {
+			fmt.Println("❌", err)
+			continue
+		}
+
+		fmt.Printf("Jumlah %s (stok %d): ", product.DisplayName(), product.Stock)
+		qty, err := strconv.Atoi(readInput())
+		if err != nil || qty <= 0 {
+			fmt.Println("❌ Jumlah tidak valid!")
+			continue
+		}
+		if qty+transferQuantity(items, product.ID) > product.Stock {
+			fmt.Printf("❌ Stok tidak mencukupi! Tersedia: %d\n", product.Stock-transferQuantity(items, product.ID))
+			continue
+		}
+		items = append(items, models.TransferItem{ProductID: product.ID, Quantity: qty})
+		fmt.Printf("✅ %s x %d ditambahkan\n", product.DisplayName(), qty)
+	}
+	if len(items) == 0 {
+		fmt.Println("❌ Transfer dibatalkan, belum ada produk.")
+		return
+	}
+
+	fmt.Print("Catatan (opsional): ")
+	note := readInput()
+
+	t, err := models.CreateTransfer(user, from, to, note, items)
+	if err != nil {
+		fmt.Printf("❌ Gagal membuat transfer: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Draft transfer TRF-%06d dibuat\n", t.ID)
+	printTransfer(t)
+
+	fmt.Print("\nKirim sekarang? Stok gudang asal akan dikurangi (y/n): ")
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("💡 Draft bisa dikirim nanti lewat menu Kirim Transfer")
+		return
+	}
+	if _, err := models.SendTransfer(user, t.ID); err != nil {
+		fmt.Printf("❌ Gagal mengirim transfer: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Transfer TRF-%06d dikirim ke %s\n", t.ID, warehouseName(to))
+}
+
+// transferQuantity menjumlahkan qty produk yang sudah dimasukkan ke transfer
+func transferQuantity(items []models.TransferItem, productID int) int {
+	total := 0
+	for _, item := range items {
+		if item.ProductID == productID {
+			total += item.Quantity
+		}
+	}
+	return total
+}
+
+// findWarehouseProduct mencari produk di gudang tertentu berdasarkan barcode, SKU, atau ID
+func findWarehouseProduct(code string, warehouseID int) (*models.Product, error) {
+	matches, err := models.FindProductsByCode(code, &warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 0 {
+		return &matches[0], nil
+	}
+
+	id, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("produk dengan kode '%s' tidak ada di %s", code, warehouseName(warehouseID))
+	}
+	product, err := models.GetProductInWarehouse(id, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("produk dengan ID %d tidak ada di %s", id, warehouseName(warehouseID))
+	}
+	return product, nil
+}
+
+// chooseTransfer menampilkan transfer berstatus status dan meminta user memilih salah satu
+func chooseTransfer(user *models.User, status, title string) *models.Transfer {
+	transfers, err := models.GetTransfers(user, status)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return nil
+	}
+	printTransfers(title, transfers)
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	fmt.Print("\nID Transfer: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return nil
+	}
+	t, err := models.GetTransfer(user, id)
+	if err != nil {
+		fmt.Println("❌", err)
+		return nil
+	}
+	if t.Status != status {
+		fmt.Printf("❌ Transfer %d berstatus %s\n", t.ID, models.TransferStatusLabel(t.Status))
+		return nil
+	}
+	return t
+}
+
+// sendTransfer mengirim draft transfer; stok gudang asal berkurang
+func sendTransfer(user *models.User) {
+	t := chooseTransfer(user, models.TransferDraft, "DRAFT TRANSFER")
+	if t == nil {
+		return
+	}
+	printTransfer(t)
+
+	fmt.Print("\nKirim transfer ini? (y/n): ")
+	if strings.ToLower(readInput()) != "y" {
+		fmt.Println("❌ Dibatalkan")
+		return
+	}
+	if _, err := models.SendTransfer(user, t.ID); err != nil {
+		fmt.Printf("❌ Gagal mengirim transfer: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Transfer TRF-%06d dikirim ke %s\n", t.ID, warehouseName(t.ToWarehouseID))
+}
+
+// receiveTransfer mencatat penerimaan transfer di gudang tujuan. Jumlah yang diterima bisa lebih
+// kecil dari yang dikirim (barang kurang/rusak); selisihnya dicatat di transfer.
+func receiveTransfer(user *models.User) {
+	t := chooseTransfer(user, models.TransferSent, "TRANSFER DALAM PERJALANAN")
+	if t == nil {
+		return
+	}
+	printTransfer(t)
+
+	fmt.Println("\nHitung barang yang datang. Enter = diterima sesuai jumlah kirim.")
+	received := make(map[int]int)
+	for _, item := range t.Items {
+		fmt.Printf("Diterima %s [%d]: ", item.ProductName, item.Quantity)
+		input := readInput()
+		if input == "" {
+			continue
+		}
+		qty, err := strconv.Atoi(input)
+		if err != nil || qty < 0 || qty > item.Quantity {
+			fmt.Printf("❌ Jumlah harus antara 0 dan %d!\n", item.Quantity)
+			return
+		}
+		received[item.ProductID] = qty
+	}
+
+	done, err := models.ReceiveTransfer(user, t.ID, received)
+	if err != nil {
+		fmt.Printf("❌ Gagal menerima transfer: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Transfer TRF-%06d diterima di %s\n", done.ID, warehouseName(done.ToWarehouseID))
+	for _, item := range done.Items {
+		if item.Shortage() > 0 {
+			fmt.Printf("⚠️  %s kurang %d dari yang dikirim\n", item.ProductName, item.Shortage())
+		}
+	}
+}
+
+// cancelTransfer membatalkan draft transfer yang belum dikirim
+func cancelTransfer(user *models.User) {
+	t := chooseTransfer(user, models.TransferDraft, "DRAFT TRANSFER")
+	if t == nil {
+		return
+	}
+
+	fmt.Printf("Batalkan transfer TRF-%06d? (y/n): ", t.ID)
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+	if err := models.CancelTransfer(user, t.ID); err != nil {
+		fmt.Printf("❌ Gagal membatalkan transfer: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Draft transfer dibatalkan")
+}
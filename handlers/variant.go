@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strconv"
+	"strings"
+)
+
+// manageVariants menampilkan varian sebuah produk induk dan menambah varian baru
+func manageVariants(user *models.User) {
+	ListProducts(user)
+
+	fmt.Print("\nMasukkan ID produk induk: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil {
+		fmt.Println("❌ ID tidak valid!")
+		return
+	}
+	parent, err := models.GetProductByID(id)
+	if err != nil {
+		fmt.Println("❌ Produk tidak ditemukan!")
+		return
+	}
+	if !canAccessProduct(user, parent.ID) {
+		fmt.Println("❌ Anda tidak memiliki akses ke produk ini!")
+		return
+	}
+	if parent.IsVariant() {
+		fmt.Printf("❌ '%s' adalah varian, pilih produk induknya (ID %d)\n", parent.DisplayName(), *parent.ParentID)
+		return
+	}
+
+	for {
+		// User gudang melihat stok gudangnya, selain itu stok total semua gudang
+		var variants []models.Product
+		if user != nil && !user.HasAllWarehouses() && user.WarehouseID != nil {
+			variants, err = models.GetWarehouseVariants(parent.ID, *user.WarehouseID)
+		} else {
+			variants, err = models.GetVariants(parent.ID)
+		}
+		if err != nil {
+			fmt.Println("❌ Error:", err)
+			return
+		}
+		listVariants(parent, variants)
+
+		fmt.Print("\nTambah varian baru? (y/n): ")
+		if strings.ToLower(readInput()) != "y" {
+			return
+		}
+		addVariant(user, parent)
+	}
+}
+
+func listVariants(parent *models.Product, variants []models.Product) {
+	fmt.Printf("\n═══ VARIAN: %s ═══\n", parent.Name)
+	fmt.Println("┌─────┬──────────────────────┬───────────────┬───────────────┬────────┐")
+	fmt.Println("│ ID  │ Varian               │ SKU/Barcode   │ Harga         │ Stok   │")
+	fmt.Println("├─────┼──────────────────────┼───────────────┼───────────────┼────────┤")
+	if len(variants) == 0 {
+		fmt.Println("│                 B E L U M   A D A   V A R I A N                    │")
+	}
+	for _, v := range variants {
+		fmt.Printf("│ %-3d │ %-20s │ %-13s │ %13s │ %6d │\n",
+			v.ID, truncate(v.Variant, 20), truncate(productCode(v), 13), formatRupiah(v.SellingPrice), v.Stock)
+	}
+	fmt.Println("└─────┴──────────────────────┴───────────────┴───────────────┴────────┘")
+	fmt.Println("💡 Edit atau hapus varian lewat menu Edit/Hapus Produk dengan ID varian")
+}
+
+// addVariant menanyakan data varian baru; harga kosong mengikuti harga induk
+func addVariant(user *models.User, parent *models.Product) {
+	// Stok disimpan di tiap varian, jadi induk harus dikosongkan dulu lewat Edit Produk
+	if parent.Stock != 0 {
+		fmt.Printf("❌ Stok induk masih %d. Set stok '%s' ke 0 lewat Edit Produk, lalu isi stok di tiap varian.\n",
+			parent.Stock, parent.Name)
+		return
+	}
+
+	v := models.Product{PurchasePrice: parent.PurchasePrice, SellingPrice: parent.SellingPrice}
+	var ok bool
+	if v.WarehouseID, ok = variantWarehouse(user, parent); !ok {
+		return
+	}
+
+	fmt.Print("Nama Varian (contoh: L, Merah / 1 Liter): ")
+	v.Variant = readInput()
+	if v.Variant == "" {
+		fmt.Println("❌ Nama varian tidak boleh kosong!")
+		return
+	}
+
+	fmt.Print("SKU (opsional): ")
+	v.SKU = readInput()
+	fmt.Print("Barcode (opsional, bisa di-scan): ")
+	v.Barcode = readInput()
+
+	var err error
+	fmt.Printf("Harga Beli [%s]: ", formatRupiah(parent.PurchasePrice))
+	if input := readInput(); input != "" {
+		if v.PurchasePrice, err = strconv.ParseFloat(input, 64); err != nil || v.PurchasePrice < 0 {
+			fmt.Println("❌ Harga beli tidak valid!")
+			return
+		}
+	}
+	fmt.Printf("Harga Jual [%s]: ", formatRupiah(parent.SellingPrice))
+	if input := readInput(); input != "" {
+		if v.SellingPrice, err = strconv.ParseFloat(input, 64); err != nil || v.SellingPrice < 0 {
+			fmt.Println("❌ Harga jual tidak valid!")
+			return
+		}
+	}
+
+	fmt.Print("Stok: ")
+	if v.Stock, err = strconv.Atoi(readInput()); err != nil || v.Stock < 0 {
+		fmt.Println("❌ Stok tidak valid!")
+		return
+	}
+
+	variant, err := models.CreateVariant(user, parent.ID, v)
+	if err != nil {
+		fmt.Printf("❌ Gagal menambah varian: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Varian '%s' berhasil ditambahkan dengan ID: %d\n", variant.DisplayName(), variant.ID)
+}
+
+// variantWarehouse menentukan gudang stok awal varian baru: gudang user, atau pilihan admin jika
+// produk induk ada di beberapa gudang (0 = biarkan CreateVariant memakai satu-satunya gudang induk)
+func variantWarehouse(user *models.User, parent *models.Product) (int, bool) {
+	if user != nil && !user.HasAllWarehouses() && user.WarehouseID != nil {
+		return *user.WarehouseID, true
+	}
+	stocks, err := models.GetProductStocks(parent.ID)
+	if err != nil {
+		fmt.Println("❌ Error:", err)
+		return 0, false
+	}
+	if len(stocks) <= 1 {
+		return 0, true
+	}
+
+	fmt.Println("Produk induk ada di beberapa gudang (varian ditambahkan ke semuanya dengan stok 0):")
+	for _, st := range stocks {
+		fmt.Printf("  %d. %s\n", st.WarehouseID, warehouseName(st.WarehouseID))
+	}
+	fmt.Print("ID Gudang untuk stok awal: ")
+	id, err := strconv.Atoi(readInput())
+	if err != nil || id <= 0 {
+		fmt.Println("❌ ID gudang tidak valid!")
+		return 0, false
+	}
+	return id, true
+}
+
+// chooseVariant meminta kasir memilih varian (di gudang yang sama) jika produk yang di-scan adalah
+// produk induk. Produk tanpa varian dikembalikan apa adanya.
+func chooseVariant(product *models.Product) (*models.Product, error) {
+	variants, err := models.GetWarehouseVariants(product.ID, product.WarehouseID)
+	if err != nil || len(variants) == 0 {
+		return product, err
+	}
+
+	fmt.Printf("Pilih varian %s:\n", product.Name)
+	for i, v := range variants {
+		fmt.Printf("  %d. %s - %s (Stok: %d)\n", i+1, v.Variant, formatRupiah(v.SellingPrice), v.Stock)
+	}
+	fmt.Print("Pilih: ")
+
+	choice, err := strconv.Atoi(readInput())
+	if err != nil || choice < 1 || choice > len(variants) {
+		return nil, fmt.Errorf("pilihan varian tidak valid")
+	}
+	return &variants[choice-1], nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"kasir/models"
+	"strings"
+)
+
+// VoidMenu membatalkan transaksi yang sudah tersimpan (salah produk, pelanggan batal membeli).
+// Kasir memilih transaksinya, lalu supervisor mengetik username dan password-nya di kasir untuk
+// menyetujui. Stok kembali ke gudang dan transaksi tidak lagi dihitung di laporan penjualan.
+func VoidMenu(user *models.User) {
+	fmt.Print("\nNomor transaksi (TRX-000123 atau 123): ")
+	id, ok := parseTransactionNumber(readInput())
+	if !ok {
+		fmt.Println("❌ Nomor transaksi tidak valid!")
+		return
+	}
+	t, err := models.GetTransaction(user, id)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if t.Voided() {
+		fmt.Printf("❌ Transaksi TRX-%06d sudah di-void oleh %s: %s\n", t.ID, orDash(t.VoidedBy), t.VoidReason)
+		return
+	}
+
+	fmt.Printf("\n═══ VOID TRX-%06d ═══\n", t.ID)
+	fmt.Printf("Tanggal: %s  Kasir: %s  Gudang: %s\n", t.CreatedAt.Format("02-01-2006 15:04"),
+		orDash(t.CashierName), warehouseName(t.WarehouseID))
+	for _, item := range t.Items {
+		fmt.Printf("   • %-20s x%d = %s\n", truncate(item.ProductName, 20), item.Quantity, formatRupiah(item.Subtotal))
+	}
+	fmt.Printf("Total: %s\n", formatRupiah(t.Total))
+
+	fmt.Print("\nAlasan void: ")
+	reason := readInput()
+	if reason == "" {
+		fmt.Println("❌ Alasan void wajib diisi!")
+		return
+	}
+	fmt.Printf("Void TRX-%06d dan kembalikan stoknya? (y/n): ", t.ID)
+	if strings.ToLower(readInput()) != "y" {
+		return
+	}
+
+	fmt.Println("\n🔐 Persetujuan supervisor")
+	fmt.Print("Username supervisor: ")
+	username := readInput()
+	fmt.Print("Password supervisor: ")
+	password := readInput()
+	approver, err := models.AuthorizeVoid(username, password, "")
+	if err != nil {
+		fmt.Printf("❌ Persetujuan ditolak: %v\n", err)
+		return
+	}
+
+	voided, err := models.VoidTransaction(user, approver, t.ID, reason)
+	if err != nil {
+		fmt.Printf("❌ Gagal void transaksi: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ TRX-%06d di-void (disetujui %s), stok sudah dikembalikan\n", voided.ID, voided.VoidedBy)
+}
+
+// printVoidedTransactions menampilkan transaksi yang di-void pada laporan harian
+func printVoidedTransactions(transactions []models.Transaction) {
+	fmt.Println("\n═══ TRANSAKSI DI-VOID (tidak dihitung) ═══")
+	fmt.Println("┌────────────┬──────────┬───────────────┬──────────────┬──────────────────────┐")
+	fmt.Println("│ ID Trans   │ Waktu    │ Total         │ Disetujui    │ Alasan               │")
+	fmt.Println("├────────────┼──────────┼───────────────┼──────────────┼──────────────────────┤")
+	for _, t := range transactions {
+		fmt.Printf("│ TRX-%06d │ %s    │ %13s │ %-12s │ %-20s │\n", t.ID, t.CreatedAt.Format("15:04"),
+			formatRupiah(t.Total), truncate(t.VoidedBy, 12), truncate(t.VoidReason, 20))
+	}
+	fmt.Println("└────────────┴──────────┴───────────────┴──────────────┴──────────────────────┘")
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // WarehouseMenu menampilkan menu manajemen gudang (admin only)
-func WarehouseMenu() {
+func WarehouseMenu(user *models.User) {
 	for {
 		fmt.Println("\n╔══════════════════════════════════════╗")
 		fmt.Println("║        MANAJEMEN GUDANG              ║")
@@ -27,11 +27,11 @@ func WarehouseMenu() {
 		case "1":
 			listWarehouses()
 		case "2":
-			createWarehouse()
+			createWarehouse(user)
 		case "3":
-			editWarehouse()
+			editWarehouse(user)
 		case "4":
-			deleteWarehouse()
+			deleteWarehouse(user)
 		case "0":
 			return
 		default:
@@ -61,7 +61,7 @@ func listWarehouses() {
 	fmt.Println("└─────┴────────────────────────┴────────────────────────────────────┘")
 }
 
-func createWarehouse() {
+func createWarehouse(user *models.User) {
 	fmt.Println("\n═══ TAMBAH GUDANG BARU ═══")
 
 	fmt.Print("Nama Gudang: ")
@@ -72,7 +72,7 @@ func createWarehouse() {
 	address, _ := reader.ReadString('\n')
 	address = strings.TrimSpace(address)
 
-	warehouse, err := models.CreateWarehouse(name, address)
+	warehouse, err := models.CreateWarehouse(user, name, address)
 	if err != nil {
 		fmt.Println("❌ Gagal menambah gudang:", err)
 		return
@@ -81,7 +81,7 @@ func createWarehouse() {
 	fmt.Printf("✅ Gudang '%s' berhasil ditambahkan dengan ID: %d\n", warehouse.Name, warehouse.ID)
 }
 
-func editWarehouse() {
+func editWarehouse(user *models.User) {
 	listWarehouses()
 
 	fmt.Print("\nMasukkan ID gudang yang akan diedit (0 untuk batal): ")
@@ -115,7 +115,7 @@ func editWarehouse() {
 		address = warehouse.Address
 	}
 
-	err = models.UpdateWarehouse(id, name, address)
+	err = models.UpdateWarehouse(user, id, name, address)
 	if err != nil {
 		fmt.Println("❌ Gagal mengupdate gudang:", err)
 		return
@@ -124,7 +124,7 @@ func editWarehouse() {
 	fmt.Println("✅ Gudang berhasil diupdate!")
 }
 
-func deleteWarehouse() {
+func deleteWarehouse(user *models.User) {
 	listWarehouses()
 
 	fmt.Print("\nMasukkan ID gudang yang akan dihapus (0 untuk batal): ")
@@ -169,7 +169,7 @@ func deleteWarehouse() {
 		return
 	}
 
-	err := models.DeleteWarehouse(id)
+	err := models.DeleteWarehouse(user, id)
 	if err != nil {
 		fmt.Println("❌ Gagal menghapus gudang:", err)
 		return
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"kasir/api"
 	"kasir/config"
 	"kasir/handlers"
@@ -15,39 +16,94 @@ import (
 func main() {
 	// Parse flags
 	apiMode := flag.Bool("api", false, "Run in API mode")
-	port := flag.String("port", "8080", "Port for API server")
+	configPath := flag.String("config", "", "Path to config file (default: $KASIR_CONFIG or ./kasir.yaml)")
+	port := flag.String("port", "", "Port for API server (overrides server.listen)")
+	assumeYes := flag.Bool("yes", false, "Skip confirmation for migrate down")
 	flag.Parse()
+	migrateMode := flag.Arg(0) == "migrate"
+	commandMode := isCommand(flag.Args())
+
+	// Subcommand untuk script: stdout hanya berisi hasil, pesan status ke stderr
+	out := io.Writer(os.Stdout)
+	if commandMode {
+		out = os.Stderr
+	} else if !migrateMode {
+		printBanner()
+	}
 
-	// Banner
-	printBanner()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if *port != "" {
+		cfg.Server.Listen = ":" + *port
+	}
+	config.App = cfg
 
 	// Initialize database
-	fmt.Println("🔌 Menghubungkan ke database...")
-	err := config.InitDB()
-	if err != nil {
-		fmt.Printf("❌ %v\n", err)
-		fmt.Println("\n💡 Pastikan:")
-		fmt.Println("   1. PostgreSQL sudah berjalan")
-		fmt.Println("   2. Database 'kasir' sudah dibuat")
-		fmt.Println("   3. Jalankan migrations/init.sql")
-		fmt.Println("\n   Atau set environment variables:")
-		fmt.Println("   DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME")
+	if !commandMode {
+		fmt.Println("🔌 Menghubungkan ke database...")
+	}
+	if err := config.InitDB(); err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		if config.Driver == config.DriverSQLite {
+			fmt.Fprintln(out, "\n💡 Pastikan folder DB_PATH bisa ditulis")
+			os.Exit(1)
+		}
+		fmt.Fprintln(out, "\n💡 Pastikan:")
+		fmt.Fprintln(out, "   1. PostgreSQL sudah berjalan")
+		fmt.Fprintln(out, "   2. Database 'kasir' sudah dibuat")
+		fmt.Fprintln(out, "   3. Schema dipasang otomatis, atau jalankan: kasir migrate up")
+		fmt.Fprintln(out, "\n   Atur koneksi di kasir.yaml (lihat kasir.example.yaml) atau environment variables:")
+		fmt.Fprintln(out, "   DB_DSN, atau DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME, DB_SSLMODE")
+		fmt.Fprintln(out, "\n   Tanpa server PostgreSQL: DB_DRIVER=sqlite (file DB_PATH, default kasir.db)")
 		os.Exit(1)
 	}
 	defer config.CloseDB()
+
+	if migrateMode {
+		code := runMigrate(flag.Args()[1:], *assumeYes)
+		config.CloseDB()
+		os.Exit(code)
+	}
+	if err := ensureMigrated(out); err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		config.CloseDB()
+		os.Exit(1)
+	}
+
+	if config.Driver == config.DriverSQLite {
+		models.SetStore(models.NewSQLiteStore(config.DB))
+	} else {
+		models.SetStore(models.NewPostgresStore(config.DB))
+	}
+	if err := bootstrapAdmin(out); err != nil {
+		fmt.Fprintf(out, "❌ Gagal membuat user admin pertama: %v\n", err)
+		config.CloseDB()
+		os.Exit(1)
+	}
+
+	if commandMode {
+		code := runCommand(flag.Args(), os.Stdin, os.Stdout, os.Stderr)
+		config.CloseDB()
+		os.Exit(code)
+	}
 	fmt.Println("✅ Koneksi database berhasil!")
 
 	// Check if API mode
 	if *apiMode {
-		api.StartServer(*port)
+		api.StartServer(cfg.Server)
 		return
 	}
 
 	// CLI Mode below...
 	// Login loop
 	reader := bufio.NewReader(os.Stdin)
+	var user *models.User
 	for {
-		if !handlers.LoginMenu() {
+		user = handlers.LoginMenu()
+		if user == nil {
 			fmt.Print("\nCoba lagi? (y/n): ")
 			input, _ := reader.ReadString('\n')
 			if strings.ToLower(strings.TrimSpace(input)) != "y" {
@@ -58,55 +114,23 @@ func main() {
 		}
 		break
 	}
+	handlers.ShowLowStockAlert(user)
 
-	// Main loop berdasarkan role
+	// Main loop berdasarkan permission user
 	for {
-		if models.CurrentUser.IsAdmin() {
-			printAdminMenu()
-		} else {
-			printUserMenu()
-		}
+		items := mainMenuItems(user)
+		printMainMenu(user, items)
 
 		fmt.Print("Pilihan: ")
 		input, _ := reader.ReadString('\n')
 		choice := strings.TrimSpace(input)
 
-		if models.CurrentUser.IsAdmin() {
-			switch choice {
-			case "1":
-				handlers.TransactionMenu()
-			case "2":
-				handlers.ProductMenu()
-			case "3":
-				handlers.ReportMenu()
-			case "4":
-				handlers.UserMenu()
-			case "5":
-				handlers.WarehouseMenu()
-			case "6":
-				handlers.ChangePassword()
-			case "0":
-				logout()
-				return
-			default:
-				fmt.Println("❌ Pilihan tidak valid!")
-			}
-		} else {
-			switch choice {
-			case "1":
-				handlers.TransactionMenu()
-			case "2":
-				handlers.ListProducts()
-			case "3":
-				handlers.ReportMenu()
-			case "4":
-				handlers.ChangePassword()
-			case "0":
-				logout()
-				return
-			default:
-				fmt.Println("❌ Pilihan tidak valid!")
-			}
+		if choice == "0" {
+			fmt.Printf("\n👋 Sampai jumpa, %s!\n", user.Username)
+			return
+		}
+		if !handlers.RunMenuChoice(items, choice) {
+			fmt.Println("❌ Pilihan tidak valid!")
 		}
 	}
 }
@@ -129,46 +153,69 @@ func printBanner() {
 	fmt.Println()
 }
 
-func printAdminMenu() {
-	fmt.Println("\n╔══════════════════════════════════════╗")
-	fmt.Println("║        MENU UTAMA (ADMIN)            ║")
-	fmt.Println("╠══════════════════════════════════════╣")
-	fmt.Println("║  1. 🛒 Transaksi Baru                ║")
-	fmt.Println("║  2. 📦 Manajemen Produk              ║")
-	fmt.Println("║  3. 📊 Laporan Penjualan             ║")
-	fmt.Println("║  4. 👥 Manajemen User                ║")
-	fmt.Println("║  5. 🏭 Manajemen Gudang              ║")
-	fmt.Println("║  6. 🔑 Ubah Password                 ║")
-	fmt.Println("║  0. 🚪 Logout                        ║")
-	fmt.Println("╚══════════════════════════════════════╝")
+// mainMenuItems menyusun menu utama berdasarkan permission role user
+func mainMenuItems(user *models.User) []handlers.MenuItem {
+	var items []handlers.MenuItem
+	as := func(fn func(*models.User)) func() {
+		return func() { fn(user) }
+	}
+
+	if user.Can(models.PermTransactionCreate) {
+		items = append(items, handlers.MenuItem{Label: "🛒 Transaksi Baru", Action: as(handlers.TransactionMenu)})
+	}
+	if user.Can(models.PermTransactionReturn) {
+		items = append(items, handlers.MenuItem{Label: "🔄 Retur Penjualan", Action: as(handlers.ReturnMenu)})
+	}
+	if user.Can(models.PermTransactionCreate) || user.Can(models.PermTransactionVoid) {
+		items = append(items, handlers.MenuItem{Label: "🚫 Void Transaksi", Action: as(handlers.VoidMenu)})
+	}
+	if user.Can(models.PermProductManage) || user.Can(models.PermStockAdjust) {
+		items = append(items, handlers.MenuItem{Label: "📦 Manajemen Produk", Action: as(handlers.ProductMenu)})
+	} else if user.Can(models.PermProductView) {
+		items = append(items, handlers.MenuItem{Label: "📦 Lihat Produk", Action: as(handlers.ListProducts)})
+	}
+	if user.Can(models.PermStockTransfer) {
+		items = append(items, handlers.MenuItem{Label: "🚚 Transfer Stok", Action: as(handlers.TransferMenu)})
+	}
+	if user.Can(models.PermStockOpname) || user.Can(models.PermOpnameApprove) {
+		items = append(items, handlers.MenuItem{Label: "📋 Stock Opname", Action: as(handlers.OpnameMenu)})
+	}
+	if user.Can(models.PermPurchaseManage) || user.Can(models.PermPurchaseReceive) {
+		items = append(items, handlers.MenuItem{Label: "🧾 Pembelian", Action: as(handlers.PurchaseMenu)})
+	}
+	if user.Can(models.PermReportView) {
+		items = append(items, handlers.MenuItem{Label: "📊 Laporan Penjualan", Action: as(handlers.ReportMenu)})
+	}
+	if user.Can(models.PermUserManage) {
+		items = append(items, handlers.MenuItem{Label: "👥 Manajemen User", Action: as(handlers.UserMenu)})
+	}
+	if user.Can(models.PermWarehouseManage) {
+		items = append(items, handlers.MenuItem{Label: "🏭 Manajemen Gudang", Action: as(handlers.WarehouseMenu)})
+	}
+	if user.Can(models.PermRoleManage) {
+		items = append(items, handlers.MenuItem{Label: "🔐 Manajemen Role", Action: as(handlers.RoleMenu)})
+	}
+	if user.Can(models.PermAuditView) {
+		items = append(items, handlers.MenuItem{Label: "📜 Audit Log", Action: handlers.AuditLogMenu})
+	}
+	items = append(items, handlers.MenuItem{Label: "🔑 Ubah Password", Action: as(handlers.ChangePassword)})
+
+	return items
 }
 
-func printUserMenu() {
-	warehouseName := "Semua Gudang"
-	if models.CurrentUser.WarehouseID != nil {
-		warehouse, _ := models.GetWarehouseByID(*models.CurrentUser.WarehouseID)
+func printMainMenu(user *models.User, items []handlers.MenuItem) {
+	var info []string
+	if !user.HasAllWarehouses() {
+		warehouseName := "-"
+		warehouse, _ := models.GetWarehouseByID(*user.WarehouseID)
 		if warehouse != nil {
 			warehouseName = warehouse.Name
 		}
+		info = append(info, "Gudang: "+warehouseName)
 	}
 
-	fmt.Printf("\n╔══════════════════════════════════════╗\n")
-	fmt.Printf("║          MENU UTAMA (USER)           ║\n")
-	fmt.Printf("╠══════════════════════════════════════╣\n")
-	fmt.Printf("║  Gudang: %-27s ║\n", warehouseName)
-	fmt.Printf("╠══════════════════════════════════════╣\n")
-	fmt.Println("║  1. 🛒 Transaksi Baru                ║")
-	fmt.Println("║  2. 📦 Lihat Produk                  ║")
-	fmt.Println("║  3. 📊 Laporan Penjualan             ║")
-	fmt.Println("║  4. 🔑 Ubah Password                 ║")
-	fmt.Println("║  0. 🚪 Logout                        ║")
-	fmt.Println("╚══════════════════════════════════════╝")
-}
-
-func logout() {
-	username := models.CurrentUser.Username
-	models.Logout()
-	fmt.Printf("\n👋 Sampai jumpa, %s!\n", username)
+	title := fmt.Sprintf("MENU UTAMA (%s)", strings.ToUpper(user.Role))
+	handlers.PrintMenu(title, info, items, "🚪 Logout")
 }
 
 func formatRupiahMain(amount float64) string {
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"kasir/config"
+	"kasir/migrations"
+	"kasir/models"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ensureMigrated memastikan schema database sesuai versi binary sebelum aplikasi jalan.
+// Migrasi yang tertunda dipasang otomatis kecuali database.auto_migrate=false.
+func ensureMigrated(out io.Writer) error {
+	migrator, err := migrations.New(config.DB, config.Driver)
+	if err != nil {
+		return err
+	}
+
+	current, err := migrator.Current()
+	if err != nil {
+		return fmt.Errorf("gagal membaca versi schema: %v", err)
+	}
+	if current > migrator.Latest() {
+		return fmt.Errorf("versi schema database (%d) lebih baru dari aplikasi ini (%d), gunakan versi aplikasi yang lebih baru", current, migrator.Latest())
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if !config.App.Database.AutoMigrate {
+		return fmt.Errorf("database belum ter-migrasi (%d migrasi tertunda). Jalankan: kasir migrate up", len(pending))
+	}
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		fmt.Fprintf(out, "📦 Migrasi %04d_%s dipasang\n", m.Version, m.Name)
+	}
+	return err
+}
+
+// bootstrapAdmin membuat user admin pertama jika database belum punya user. Password-nya acak dan
+// hanya ditampilkan sekali, jadi tidak ada kredensial bawaan yang tersimpan di migrasi.
+func bootstrapAdmin(out io.Writer) error {
+	buf := make([]byte, 9)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	password := base64.RawURLEncoding.EncodeToString(buf)
+
+	admin, err := models.BootstrapAdmin("admin", password)
+	if err != nil || admin == nil {
+		return err
+	}
+	fmt.Fprintf(out, "👤 User admin pertama dibuat: username %s, password %s\n", admin.Username, password)
+	fmt.Fprintln(out, "   Password hanya ditampilkan sekali, segera ganti setelah login")
+	return nil
+}
+
+// runMigrate menjalankan subcommand: migrate up | down [n] | status | seed [nama]
+func runMigrate(args []string, assumeYes bool) int {
+	migrator, err := migrations.New(config.DB, config.Driver)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("✅ %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ Schema sudah versi terbaru")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				fmt.Println("❌ Jumlah langkah harus angka positif")
+				return 1
+			}
+		}
+		if !assumeYes && !confirm(fmt.Sprintf("⚠️  Rollback %d migrasi terakhir? Data di tabel yang dihapus akan hilang (y/n): ", steps)) {
+			fmt.Println("❌ Dibatalkan")
+			return 1
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("↩️  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("⚠️  Tidak ada migrasi yang terpasang")
+		}
+
+	case "status":
+		list, err := migrator.Status()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n%-6s %-30s %s\n", "Versi", "Nama", "Dipasang")
+		fmt.Println(strings.Repeat("─", 60))
+		for _, s := range list {
+			applied := "⏳ belum"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d   %-30s %s\n", s.Version, s.Name, applied)
+		}
+
+	case "seed":
+		if len(args) < 2 {
+			fmt.Printf("💡 Seed tersedia: %s\n", strings.Join(migrator.Seeds(), ", "))
+			fmt.Println("   Jalankan: kasir migrate seed <nama>")
+			return 1
+		}
+		if err := migrator.Seed(args[1]); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Seed '%s' berhasil dijalankan\n", args[1])
+
+	default:
+		fmt.Println("Penggunaan: kasir migrate up | down [n] | status | seed [nama]")
+		return 2
+	}
+	return 0
+}
+
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(input)) == "y"
+}
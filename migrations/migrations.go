@@ -0,0 +1,379 @@
+// Package migrations berisi schema database bernomor yang di-embed ke binary,
+// beserta migrator yang mencatat versi terpasang di tabel schema_migrations.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File migrasi: <dialect>/<versi>_<nama>.up.sql dan .down.sql
+// File seed: seeds/<nama>.sql (semua dialect) atau seeds/<nama>.<dialect>.sql
+//
+//go:embed postgres/*.sql sqlite/*.sql seeds/*.sql
+var files embed.FS
+
+// Migration adalah satu langkah perubahan schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status adalah migrasi beserta waktu dipasang (nil jika belum)
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator memasang dan membatalkan migrasi untuk satu database
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New menyiapkan migrator untuk dialect "postgres" atau "sqlite"
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+func load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("migrasi untuk database %s tidak tersedia", dialect)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		direction := ""
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s", name)
+		}
+
+		content, err := files.ReadFile(path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrasi %04d_%s tidak punya file .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest mengembalikan versi migrasi terbaru yang dikenal binary ini
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// legacyTables adalah tabel penanda migrasi awal yang dulu dipasang langsung dari init.sql.
+// init.sql baseline hanya berisi schema 0001; versi init.sql berikutnya menambah tabel 0002-0005.
+var legacyTables = map[int]string{
+	1: "users",
+	2: "api_sessions",
+	3: "roles",
+	4: "login_throttle",
+	5: "audit_logs",
+}
+
+// ensureTable membuat tabel schema_migrations. Database lama yang dibuat dari init.sql (sudah
+// punya tabel users tapi belum ada schema_migrations) dicatat sudah memasang migrasi awal yang
+// tabelnya sudah ada; migrasi awal lain dipasang seperti biasa oleh Up.
+func (m *Migrator) ensureTable() error {
+	exists, err := m.tableExists("schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+
+	legacy, err := m.tableExists(legacyTables[1])
+	if err != nil {
+		return err
+	}
+	var baseline []Migration
+	if legacy {
+		for _, mig := range m.migrations {
+			table, ok := legacyTables[mig.Version]
+			if !ok {
+				continue
+			}
+			found, err := m.tableExists(table)
+			if err != nil {
+				return err
+			}
+			if found {
+				baseline = append(baseline, mig)
+			}
+		}
+	}
+
+	_, err = m.db.Exec(`
+		CREATE TABLE schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range baseline {
+		_, err := m.db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) tableExists(name string) (bool, error) {
+	var query string
+	if m.dialect == "sqlite" {
+		query = `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1`
+	} else {
+		query = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`
+	}
+
+	var count int
+	if err := m.db.QueryRow(query, name).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Current mengembalikan versi tertinggi yang sudah terpasang di database
+func (m *Migrator) Current() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current, nil
+}
+
+// Status mengembalikan semua migrasi beserta status terpasangnya
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Pending mengembalikan migrasi yang belum terpasang (urut versi)
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up memasang semua migrasi yang belum terpasang. Setiap migrasi berjalan dalam
+// transaksinya sendiri sehingga kegagalan tidak meninggalkan schema setengah jadi.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range pending {
+		err := m.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(mig.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+				mig.Version, mig.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrasi %04d_%s gagal: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down membatalkan sejumlah migrasi terakhir yang terpasang (terbaru lebih dulu)
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return done, fmt.Errorf("migrasi %04d_%s tidak punya file .down.sql", mig.Version, mig.Name)
+		}
+
+		err := m.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(mig.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback %04d_%s gagal: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) inTx(fn func(*sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Seeds mengembalikan nama seed yang bisa dipakai dialect ini
+func (m *Migrator) Seeds() []string {
+	entries, _ := fs.ReadDir(files, "seeds")
+
+	var names []string
+	for _, e := range entries {
+		if name, ok := m.seedName(e.Name()); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// seedName mengubah nama file seed menjadi nama seed jika cocok dengan dialect
+func (m *Migrator) seedName(file string) (string, bool) {
+	base := strings.TrimSuffix(file, ".sql")
+	if name, dialect, ok := strings.Cut(base, "."); ok {
+		return name, dialect == m.dialect
+	}
+	return base, true
+}
+
+// Seed menjalankan file seed (data contoh), terpisah dari migrasi schema
+func (m *Migrator) Seed(name string) error {
+	if pending, err := m.Pending(); err != nil {
+		return err
+	} else if len(pending) > 0 {
+		return fmt.Errorf("masih ada %d migrasi yang belum terpasang, jalankan migrate up dulu", len(pending))
+	}
+
+	entries, _ := fs.ReadDir(files, "seeds")
+	for _, e := range entries {
+		if seed, ok := m.seedName(e.Name()); ok && seed == name {
+			content, err := files.ReadFile(path.Join("seeds", e.Name()))
+			if err != nil {
+				return err
+			}
+			return m.inTx(func(tx *sql.Tx) error {
+				_, err := tx.Exec(string(content))
+				return err
+			})
+		}
+	}
+	return fmt.Errorf("seed '%s' tidak ada untuk database %s (tersedia: %s)", name, m.dialect, strings.Join(m.Seeds(), ", "))
+}
@@ -0,0 +1,295 @@
+package migrations
+
+import (
+	"database/sql"
+	"kasir/config"
+	"kasir/models"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "kasir.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB) *Migrator {
+	t.Helper()
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestLoadBothDialects(t *testing.T) {
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		m, err := New(nil, dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		for _, mig := range m.migrations {
+			if mig.Down == "" {
+				t.Errorf("%s: migration %04d has no down file", dialect, mig.Version)
+			}
+		}
+	}
+	if _, err := New(nil, "mysql"); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	db := openTestDB(t)
+	m := newTestMigrator(t, db)
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(m.migrations))
+	}
+	if current, _ := m.Current(); current != m.Latest() {
+		t.Errorf("current = %d, want %d", current, m.Latest())
+	}
+	if countRows(t, db, "roles") == 0 {
+		t.Error("default roles should be created by the migrations")
+	}
+	if countRows(t, db, "users") != 0 {
+		t.Error("migrations should not create users, the admin is bootstrapped by the app")
+	}
+
+	// Up kedua kali tidak melakukan apa-apa
+	if again, err := m.Up(); err != nil || len(again) != 0 {
+		t.Fatalf("second up = %v, %v", again, err)
+	}
+
+	if _, err := m.Down(len(m.migrations)); err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			t.Errorf("migration %04d still applied after down", s.Version)
+		}
+	}
+
+	// Setelah down, up lagi harus bisa berjalan bersih
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("re-up: %v", err)
+	}
+}
+
+// legacySchema adalah migrations/init.sql versi baseline (sebelum ada role, sesi API, pembatasan
+// login, dan audit log), disesuaikan untuk SQLite
+const legacySchema = `
+CREATE TABLE warehouses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(100) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    warehouse_id INT REFERENCES warehouses(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE products (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    purchase_price DECIMAL(10,2) NOT NULL,
+    selling_price DECIMAL(10,2) NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    warehouse_id INT REFERENCES warehouses(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT REFERENCES users(id),
+    warehouse_id INT REFERENCES warehouses(id),
+    total DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0,
+    payment DECIMAL(10,2) NOT NULL,
+    change DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE transaction_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    purchase_price DECIMAL(10,2) NOT NULL,
+    selling_price DECIMAL(10,2) NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX idx_transactions_warehouse_id ON transactions(warehouse_id);
+CREATE INDEX idx_transactions_user_id ON transactions(user_id);
+CREATE INDEX idx_transaction_items_transaction_id ON transaction_items(transaction_id);
+CREATE INDEX idx_products_warehouse_id ON products(warehouse_id);
+CREATE INDEX idx_users_warehouse_id ON users(warehouse_id);
+
+INSERT INTO warehouses (name, address) VALUES ('Gudang Pusat', 'Jl. Utama No. 1');
+INSERT INTO users (username, password, role, warehouse_id) VALUES
+    ('admin', 'admin123', 'admin', NULL),
+    ('kasir1', 'user123', 'user', 1);
+INSERT INTO products (name, purchase_price, selling_price, stock, warehouse_id) VALUES
+    ('Indomie Goreng', 2500, 3500, 100, 1);
+`
+
+func TestLegacyDatabaseIsBaselined(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestMigrator(t, db)
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(m.migrations)-1 || pending[0].Version != 2 {
+		t.Fatalf("pending = %+v, want all migrations but 0001", pending)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("up on legacy database: %v", err)
+	}
+
+	// Data lama tetap ada dan user lama bisa login dengan permission dari tabel roles
+	models.SetStore(models.NewSQLiteStore(db))
+	for _, login := range []struct{ username, password, permission string }{
+		{"admin", "admin123", models.PermRoleManage},
+		{"kasir1", "user123", models.PermTransactionCreate},
+	} {
+		u, err := models.Authenticate(login.username, login.password, "")
+		if err != nil {
+			t.Fatalf("login %s after upgrade: %v", login.username, err)
+		}
+		if !u.Can(login.permission) {
+			t.Errorf("%s should have %s after upgrade", login.username, login.permission)
+		}
+	}
+	if countRows(t, db, "product_stocks WHERE stock = 100") != 1 {
+		t.Error("legacy product stock should survive the upgrade")
+	}
+}
+
+func TestLegacyDatabaseWithRolesIsBaselined(t *testing.T) {
+	db := openTestDB(t)
+	first := newTestMigrator(t, db)
+	// Database dari init.sql versi berikutnya: sudah punya tabel 0001-0005 tanpa schema_migrations
+	for _, mig := range first.migrations[:5] {
+		if _, err := db.Exec(mig.Up); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestMigrator(t, db)
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(m.migrations)-5 || pending[0].Version != 6 {
+		t.Fatalf("pending = %+v, want all migrations but 0001-0005", pending)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	db := openTestDB(t)
+	m := newTestMigrator(t, db)
+
+	if err := m.Seed("demo"); err == nil {
+		t.Error("seed before migrate up should fail")
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Seed("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, db, "products") == 0 || countRows(t, db, "warehouses") == 0 {
+		t.Error("demo seed should insert warehouses and products")
+	}
+	if countRows(t, db, "products") != 5 || countRows(t, db, "product_stocks") != 10 {
+		t.Error("demo seed should insert each product once with stock per warehouse")
+	}
+	if countRows(t, db, "products WHERE category_id IS NULL OR brand_id IS NULL") != 0 {
+		t.Error("demo products should all have a category and brand")
+	}
+	if err := m.Seed("bulk_products"); err == nil {
+		t.Error("postgres-only seed should not be available on sqlite")
+	}
+}
+
+func TestMasterCatalogMergesDuplicates(t *testing.T) {
+	db := openTestDB(t)
+	m := newTestMigrator(t, db)
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	// Kembali ke schema sebelum 0009_master_catalog
+	if _, err := m.Down(m.Latest() - 8); err != nil {
+		t.Fatal(err)
+	}
+
+	// Schema lama: produk yang sama disimpan sekali per gudang
+	_, err := db.Exec(`
+		INSERT INTO warehouses (id, name) VALUES (10, 'Pusat'), (11, 'Cabang');
+		INSERT INTO products (id, name, sku, purchase_price, selling_price, stock, warehouse_id) VALUES
+			(100, 'Indomie Goreng', 'MIE-GRG', 2500, 3500, 10, 10),
+			(101, 'Indomie Goreng', 'MIE-GRG', 2500, 3800, 4, 11),
+			(102, 'Roti Tawar', NULL, 12000, 15000, 3, 10),
+			(103, 'roti tawar', NULL, 12000, 15000, 2, 11);
+		INSERT INTO transactions (id, warehouse_id, total, payment, change) VALUES (1, 11, 3800, 5000, 1200);
+		INSERT INTO transaction_items (transaction_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal)
+		VALUES (1, 101, 'Indomie Goreng', 1, 2500, 3800, 3800);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countRows(t, db, "products"); n != 2 {
+		t.Errorf("products = %d, want 2 after merge", n)
+	}
+	if countRows(t, db, "product_stocks WHERE product_id = 100 AND warehouse_id = 11 AND stock = 4 AND selling_price = 3800") != 1 {
+		t.Error("branch stock should keep its own selling price")
+	}
+	if countRows(t, db, "product_stocks WHERE product_id = 100 AND warehouse_id = 10 AND selling_price IS NULL") != 1 {
+		t.Error("master price should not be stored as a warehouse price")
+	}
+	if countRows(t, db, "product_stocks WHERE product_id = 102") != 2 {
+		t.Error("products without codes should be merged by name")
+	}
+	if countRows(t, db, "transaction_items WHERE product_id = 100") != 1 {
+		t.Error("transaction items should point to the master product")
+	}
+}
@@ -1,12 +1,6 @@
--- Database Schema untuk Sistem Kasir v2.0
--- Dengan fitur: multi-gudang, user auth, harga beli/jual
-
--- Hapus tabel jika sudah ada (untuk fresh install)
-DROP TABLE IF EXISTS transaction_items CASCADE;
-DROP TABLE IF EXISTS transactions CASCADE;
-DROP TABLE IF EXISTS products CASCADE;
-DROP TABLE IF EXISTS users CASCADE;
-DROP TABLE IF EXISTS warehouses CASCADE;
+-- Database Schema untuk Sistem Kasir v2.0 (PostgreSQL)
+-- Sama dengan init.sql lama: multi-gudang, user auth, harga beli/jual. Tabel yang ditambahkan
+-- kemudian (sesi API, role, pembatasan login, audit log) ada di migrasi 0002-0005.
 
 -- Tabel Gudang
 CREATE TABLE warehouses (
@@ -69,32 +63,3 @@ CREATE INDEX idx_transactions_user_id ON transactions(user_id);
 CREATE INDEX idx_transaction_items_transaction_id ON transaction_items(transaction_id);
 CREATE INDEX idx_products_warehouse_id ON products(warehouse_id);
 CREATE INDEX idx_users_warehouse_id ON users(warehouse_id);
-
--- Sample data gudang
-INSERT INTO warehouses (name, address) VALUES
-    ('Gudang Pusat', 'Jl. Utama No. 1'),
-    ('Gudang Cabang A', 'Jl. Cabang A No. 10'),
-    ('Gudang Cabang B', 'Jl. Cabang B No. 20');
-
--- Sample admin user (password: admin123)
-INSERT INTO users (username, password, role, warehouse_id) VALUES
-    ('admin', 'admin123', 'admin', NULL);
-
--- Sample user per gudang (password: user123)
-INSERT INTO users (username, password, role, warehouse_id) VALUES
-    ('kasir1', 'user123', 'user', 1),
-    ('kasir2', 'user123', 'user', 2),
-    ('kasir3', 'user123', 'user', 3);
-
--- Sample data produk per gudang
-INSERT INTO products (name, purchase_price, selling_price, stock, warehouse_id) VALUES
-    ('Indomie Goreng', 2500, 3500, 100, 1),
-    ('Aqua 600ml', 2500, 4000, 50, 1),
-    ('Teh Botol Sosro', 3500, 5000, 30, 1),
-    ('Roti Tawar', 12000, 15000, 20, 1),
-    ('Susu Ultra 250ml', 4500, 6000, 40, 1),
-    ('Indomie Goreng', 2500, 3500, 80, 2),
-    ('Aqua 600ml', 2500, 4000, 40, 2),
-    ('Teh Botol Sosro', 3500, 5000, 25, 2),
-    ('Indomie Goreng', 2500, 3500, 60, 3),
-    ('Aqua 600ml', 2500, 4000, 35, 3);
@@ -0,0 +1,7 @@
+-- Tabel Pembatasan Login (key: 'user:<username>' atau 'ip:<alamat>')
+CREATE TABLE login_throttle (
+    key VARCHAR(150) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
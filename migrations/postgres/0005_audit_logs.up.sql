@@ -0,0 +1,15 @@
+-- Tabel Audit Log (siapa mengubah apa, beserta nilai sebelum/sesudah)
+CREATE TABLE audit_logs (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    username VARCHAR(100) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    entity VARCHAR(30) NOT NULL,
+    entity_id INT,
+    before_data JSONB,
+    after_data JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_audit_logs_entity ON audit_logs(entity, entity_id);
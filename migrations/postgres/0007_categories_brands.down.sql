@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_products_brand_id;
+DROP INDEX IF EXISTS idx_products_category_id;
+
+ALTER TABLE products DROP COLUMN brand_id;
+ALTER TABLE products DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS brands;
+DROP TABLE IF EXISTS categories;
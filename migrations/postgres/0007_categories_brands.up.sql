@@ -0,0 +1,22 @@
+-- Kategori bertingkat (parent_id NULL = kategori utama) dan merek produk
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    parent_id INT REFERENCES categories(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Nama kategori unik di bawah induk yang sama
+CREATE UNIQUE INDEX idx_categories_parent_name ON categories(COALESCE(parent_id, 0), name);
+
+CREATE TABLE brands (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE products ADD COLUMN category_id INT REFERENCES categories(id);
+ALTER TABLE products ADD COLUMN brand_id INT REFERENCES brands(id);
+
+CREATE INDEX idx_products_category_id ON products(category_id);
+CREATE INDEX idx_products_brand_id ON products(brand_id);
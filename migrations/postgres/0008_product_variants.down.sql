@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_parent_id;
+
+ALTER TABLE products DROP COLUMN variant;
+ALTER TABLE products DROP COLUMN parent_id;
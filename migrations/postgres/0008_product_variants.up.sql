@@ -0,0 +1,6 @@
+-- Varian produk (ukuran, warna, rasa): baris products dengan parent_id ke produk induk.
+-- Varian punya SKU/barcode, harga, dan stok sendiri; produk induk yang punya varian tidak dijual langsung.
+ALTER TABLE products ADD COLUMN parent_id INT REFERENCES products(id);
+ALTER TABLE products ADD COLUMN variant VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_products_parent_id ON products(parent_id);
@@ -0,0 +1,22 @@
+-- Produk kembali terikat ke satu gudang: gudang dengan ID terkecil tempat produk itu disimpan.
+-- Stok di gudang lain dan harga per gudang tidak ikut dikembalikan.
+DROP INDEX IF EXISTS idx_products_barcode;
+DROP INDEX IF EXISTS idx_products_sku;
+
+ALTER TABLE products ADD COLUMN warehouse_id INT REFERENCES warehouses(id);
+ALTER TABLE products ADD COLUMN stock INT NOT NULL DEFAULT 0;
+
+UPDATE products SET
+    warehouse_id = (SELECT MIN(s.warehouse_id) FROM product_stocks s WHERE s.product_id = products.id),
+    stock = COALESCE((
+        SELECT s.stock FROM product_stocks s
+        WHERE s.product_id = products.id
+        ORDER BY s.warehouse_id
+        LIMIT 1
+    ), 0);
+
+CREATE INDEX idx_products_warehouse_id ON products(warehouse_id);
+CREATE UNIQUE INDEX idx_products_warehouse_sku ON products(warehouse_id, sku);
+CREATE UNIQUE INDEX idx_products_warehouse_barcode ON products(warehouse_id, barcode);
+
+DROP TABLE product_stocks;
@@ -0,0 +1,30 @@
+-- Transfer stok antar gudang. Status: draft (stok belum berubah), sent (stok gudang asal sudah
+-- berkurang, barang dalam perjalanan), received (stok gudang tujuan bertambah sebanyak received).
+CREATE TABLE stock_transfers (
+    id SERIAL PRIMARY KEY,
+    from_warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    to_warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    note TEXT NOT NULL DEFAULT '',
+    user_id INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    received_at TIMESTAMP
+);
+
+CREATE TABLE stock_transfer_items (
+    id SERIAL PRIMARY KEY,
+    transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    received INT NOT NULL DEFAULT 0 -- selisih quantity - received = barang hilang/rusak di perjalanan
+);
+
+CREATE INDEX idx_stock_transfers_from_warehouse_id ON stock_transfers(from_warehouse_id);
+CREATE INDEX idx_stock_transfers_to_warehouse_id ON stock_transfers(to_warehouse_id);
+CREATE INDEX idx_stock_transfer_items_transfer_id ON stock_transfer_items(transfer_id);
+
+-- Permission transfer untuk role bawaan yang mengurus stok
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'stock.transfer' FROM roles WHERE name IN ('supervisor', 'stock_clerk');
@@ -0,0 +1,23 @@
+-- Buku mutasi stok: setiap perubahan stok produk di satu gudang beserta saldo setelahnya.
+-- quantity positif = masuk, negatif = keluar. type: sale, adjustment, import, transfer.
+CREATE TABLE stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    type VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL,
+    balance INT NOT NULL,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    username VARCHAR(100) NOT NULL DEFAULT 'system',
+    reference TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id, warehouse_id);
+CREATE INDEX idx_stock_movements_warehouse_id ON stock_movements(warehouse_id);
+
+-- Stok yang sudah ada dicatat sebagai saldo awal agar kartu stok dimulai dari angka yang benar
+INSERT INTO stock_movements (product_id, warehouse_id, type, quantity, balance, reference)
+SELECT product_id, warehouse_id, 'adjustment', stock, stock, 'saldo awal'
+FROM product_stocks
+WHERE stock <> 0;
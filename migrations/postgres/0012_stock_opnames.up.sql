@@ -0,0 +1,31 @@
+-- Stock opname (hitung fisik) per gudang. system_stock adalah snapshot stok saat sesi dibuat;
+-- saat disetujui (status approved) selisih counted - system_stock dibukukan ke stok gudang.
+CREATE TABLE stock_opnames (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    note TEXT NOT NULL DEFAULT '',
+    user_id INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    approved_by INT REFERENCES users(id),
+    approved_at TIMESTAMP
+);
+
+CREATE TABLE stock_opname_items (
+    id SERIAL PRIMARY KEY,
+    opname_id INT NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    system_stock INT NOT NULL,
+    counted INT, -- NULL = belum dihitung
+    purchase_price DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_stock_opnames_warehouse_id ON stock_opnames(warehouse_id);
+CREATE INDEX idx_stock_opname_items_opname_id ON stock_opname_items(opname_id);
+
+-- Permission stock opname untuk role bawaan; hanya supervisor yang boleh menyetujui
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'stock.opname' FROM roles WHERE name IN ('supervisor', 'stock_clerk');
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'stock.opname.approve' FROM roles WHERE name = 'supervisor';
@@ -0,0 +1,61 @@
+-- Supplier dan purchase order. Stok gudang bertambah hanya saat barang diterima (goods_receipts);
+-- satu PO bisa diterima bertahap, received di purchase_order_items adalah total yang sudah datang.
+CREATE TABLE suppliers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id),
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    note TEXT NOT NULL DEFAULT '',
+    user_id INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE purchase_order_items (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    received INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE goods_receipts (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES purchase_orders(id),
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    note TEXT NOT NULL DEFAULT '',
+    user_id INT REFERENCES users(id),
+    total DECIMAL(12,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE goods_receipt_items (
+    id SERIAL PRIMARY KEY,
+    receipt_id INT NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL
+);
+
+CREATE INDEX idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX idx_purchase_orders_warehouse_id ON purchase_orders(warehouse_id);
+CREATE INDEX idx_purchase_order_items_order_id ON purchase_order_items(order_id);
+CREATE INDEX idx_goods_receipts_order_id ON goods_receipts(order_id);
+CREATE INDEX idx_goods_receipts_created_at ON goods_receipts(created_at);
+CREATE INDEX idx_goods_receipt_items_receipt_id ON goods_receipt_items(receipt_id);
+
+-- Permission pembelian untuk role bawaan; petugas stok hanya menerima barang
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'purchase.manage' FROM roles WHERE name = 'supervisor';
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'purchase.receive' FROM roles WHERE name IN ('supervisor', 'stock_clerk');
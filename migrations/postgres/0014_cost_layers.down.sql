@@ -0,0 +1,3 @@
+ALTER TABLE stock_transfer_items DROP COLUMN unit_cost;
+
+DROP TABLE IF EXISTS cost_layers;
@@ -0,0 +1 @@
+ALTER TABLE product_stocks DROP COLUMN reorder_point;
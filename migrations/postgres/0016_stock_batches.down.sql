@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stock_transfer_batches;
+DROP TABLE IF EXISTS stock_batches;
@@ -0,0 +1,33 @@
+-- Retur penjualan: barang dari satu transaksi dikembalikan pelanggan, stoknya kembali ke gudang
+-- transaksi dan uangnya dikembalikan. Satu transaksi bisa diretur beberapa kali selama jumlah
+-- yang diretur tidak melebihi jumlah yang terjual.
+CREATE TABLE sales_returns (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    warehouse_id INT REFERENCES warehouses(id),
+    user_id INT REFERENCES users(id),
+    reason TEXT NOT NULL,
+    total DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE sales_return_items (
+    id SERIAL PRIMARY KEY,
+    return_id INT NOT NULL REFERENCES sales_returns(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    purchase_price DECIMAL(10,2) NOT NULL,
+    selling_price DECIMAL(10,2) NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_sales_returns_transaction_id ON sales_returns(transaction_id);
+CREATE INDEX idx_sales_returns_created_at ON sales_returns(created_at);
+CREATE INDEX idx_sales_return_items_return_id ON sales_return_items(return_id);
+
+-- Kasir dan supervisor boleh memproses retur
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'transaction.return' FROM roles WHERE name IN ('user', 'supervisor');
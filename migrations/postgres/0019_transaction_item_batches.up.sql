@@ -0,0 +1,12 @@
+-- Batch yang terjual lewat setiap transaksi, agar barang yang diretur atau transaksinya di-void
+-- kembali ke batch asalnya beserta tanggal kedaluwarsanya
+CREATE TABLE transaction_item_batches (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    batch_no VARCHAR(50) NOT NULL,
+    expiry_date DATE NOT NULL,
+    quantity INT NOT NULL
+);
+
+CREATE INDEX idx_transaction_item_batches_transaction_id ON transaction_item_batches(transaction_id);
@@ -0,0 +1,32 @@
+-- Script untuk menambahkan 1 juta produk ke gudang pertama (uji performa pagination/search)
+-- Menggunakan generate_series untuk performa optimal (khusus PostgreSQL)
+
+INSERT INTO products (name, purchase_price, selling_price)
+SELECT
+    'Produk-' || i AS name,
+    harga AS purchase_price,
+    ROUND(harga * 1.2, 2) AS selling_price
+FROM (
+    SELECT i, (random() * 99000 + 1000)::DECIMAL(10,2) AS harga
+    FROM generate_series(1, 1000000) AS i
+) s;
+
+INSERT INTO product_stocks (product_id, warehouse_id, stock)
+SELECT p.id, (SELECT MIN(id) FROM warehouses), (random() * 100 + 1)::INT
+FROM products p
+WHERE p.name LIKE 'Produk-%'
+  AND NOT EXISTS (SELECT 1 FROM product_stocks s WHERE s.product_id = p.id);
+
+INSERT INTO stock_movements (product_id, warehouse_id, type, quantity, balance, reference)
+SELECT s.product_id, s.warehouse_id, 'adjustment', s.stock, s.stock, 'saldo awal'
+FROM product_stocks s
+JOIN products p ON p.id = s.product_id
+WHERE p.name LIKE 'Produk-%'
+  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = s.product_id);
+
+INSERT INTO cost_layers (product_id, warehouse_id, quantity, remaining, unit_cost, reference)
+SELECT s.product_id, s.warehouse_id, s.stock, s.stock, p.purchase_price, 'saldo awal'
+FROM product_stocks s
+JOIN products p ON p.id = s.product_id
+WHERE p.name LIKE 'Produk-%'
+  AND NOT EXISTS (SELECT 1 FROM cost_layers l WHERE l.product_id = s.product_id);
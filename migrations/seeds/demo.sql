@@ -0,0 +1,99 @@
+-- Data contoh: 3 gudang, kasir per gudang, dan produk
+-- Jalankan di database kosong: kasir migrate seed demo
+
+INSERT INTO warehouses (name, address) VALUES
+    ('Gudang Pusat', 'Jl. Utama No. 1'),
+    ('Gudang Cabang A', 'Jl. Cabang A No. 10'),
+    ('Gudang Cabang B', 'Jl. Cabang B No. 20');
+
+-- Kasir per gudang (password: user123, disimpan sebagai hash bcrypt)
+INSERT INTO users (username, password, role, warehouse_id)
+SELECT u.username, '$2a$10$eJHvY5e2z/IlIq2P.GE1we.O3Xu.c4YOSKHj6tAMPLPKyfJfXxwnW', 'user', w.id
+FROM warehouses w
+JOIN (
+    SELECT 'kasir1' AS username, 'Gudang Pusat' AS warehouse UNION ALL
+    SELECT 'kasir2', 'Gudang Cabang A' UNION ALL
+    SELECT 'kasir3', 'Gudang Cabang B'
+) u ON u.warehouse = w.name;
+
+-- Katalog master: satu baris per produk, SKU dan barcode bisa langsung di-scan saat transaksi
+INSERT INTO products (name, sku, barcode, purchase_price, selling_price) VALUES
+    ('Indomie Goreng', 'MIE-GRG', '089686010947', 2500, 3500),
+    ('Aqua 600ml', 'AQU-600', '8886008101053', 2500, 4000),
+    ('Teh Botol Sosro', 'TBS-450', '8998888110017', 3500, 5000),
+    ('Roti Tawar', 'RTI-TWR', '8996001600146', 12000, 15000),
+    ('Susu Ultra 250ml', 'SSU-250', '8998009010231', 4500, 6000);
+
+-- Stok per gudang; Aqua di Cabang B dijual dengan harga sendiri
+INSERT INTO product_stocks (product_id, warehouse_id, stock, selling_price)
+SELECT p.id, w.id, s.stock, s.selling_price
+FROM warehouses w
+JOIN (
+    SELECT 'Gudang Pusat' AS warehouse, 'MIE-GRG' AS sku, 100 AS stock, NULL AS selling_price UNION ALL
+    SELECT 'Gudang Pusat', 'AQU-600', 50, NULL UNION ALL
+    SELECT 'Gudang Pusat', 'TBS-450', 30, NULL UNION ALL
+    SELECT 'Gudang Pusat', 'RTI-TWR', 20, NULL UNION ALL
+    SELECT 'Gudang Pusat', 'SSU-250', 40, NULL UNION ALL
+    SELECT 'Gudang Cabang A', 'MIE-GRG', 80, NULL UNION ALL
+    SELECT 'Gudang Cabang A', 'AQU-600', 40, NULL UNION ALL
+    SELECT 'Gudang Cabang A', 'TBS-450', 25, NULL UNION ALL
+    SELECT 'Gudang Cabang B', 'MIE-GRG', 60, NULL UNION ALL
+    SELECT 'Gudang Cabang B', 'AQU-600', 35, 4500
+) s ON s.warehouse = w.name
+JOIN products p ON p.sku = s.sku;
+
+-- Stok awal dicatat di buku mutasi agar kartu stok dimulai dari saldo yang benar
+INSERT INTO stock_movements (product_id, warehouse_id, type, quantity, balance, reference)
+SELECT product_id, warehouse_id, 'adjustment', stock, stock, 'saldo awal'
+FROM product_stocks;
+
+-- Stok awal juga menjadi lapis harga pokok dengan harga beli master
+INSERT INTO cost_layers (product_id, warehouse_id, quantity, remaining, unit_cost, reference)
+SELECT s.product_id, s.warehouse_id, s.stock, s.stock, p.purchase_price, 'saldo awal'
+FROM product_stocks s
+JOIN products p ON p.id = s.product_id;
+
+-- Titik pesan ulang; Roti Tawar di Gudang Pusat dan Teh Botol di Cabang A sudah menipis
+UPDATE product_stocks SET reorder_point = 20;
+UPDATE product_stocks SET reorder_point = 25
+WHERE product_id = (SELECT id FROM products WHERE sku = 'RTI-TWR');
+UPDATE product_stocks SET reorder_point = 30
+WHERE product_id = (SELECT id FROM products WHERE sku = 'TBS-450')
+  AND warehouse_id = (SELECT id FROM warehouses WHERE name = 'Gudang Cabang A');
+
+-- Kategori bertingkat dan merek
+INSERT INTO categories (name) VALUES ('Makanan'), ('Minuman');
+INSERT INTO categories (name, parent_id)
+SELECT c.name, p.id
+FROM categories p
+JOIN (
+    SELECT 'Makanan' AS parent, 'Mie Instan' AS name UNION ALL
+    SELECT 'Makanan', 'Roti' UNION ALL
+    SELECT 'Minuman', 'Air Mineral' UNION ALL
+    SELECT 'Minuman', 'Teh' UNION ALL
+    SELECT 'Minuman', 'Susu'
+) c ON c.parent = p.name AND p.parent_id IS NULL;
+
+INSERT INTO brands (name) VALUES ('Indofood'), ('Aqua'), ('Sosro'), ('Sari Roti'), ('Ultra Jaya');
+
+UPDATE products SET
+    category_id = (
+        SELECT c.id FROM categories c
+        WHERE c.parent_id IS NOT NULL AND c.name = CASE products.sku
+            WHEN 'MIE-GRG' THEN 'Mie Instan'
+            WHEN 'AQU-600' THEN 'Air Mineral'
+            WHEN 'TBS-450' THEN 'Teh'
+            WHEN 'RTI-TWR' THEN 'Roti'
+            WHEN 'SSU-250' THEN 'Susu'
+        END
+    ),
+    brand_id = (
+        SELECT b.id FROM brands b
+        WHERE b.name = CASE products.sku
+            WHEN 'MIE-GRG' THEN 'Indofood'
+            WHEN 'AQU-600' THEN 'Aqua'
+            WHEN 'TBS-450' THEN 'Sosro'
+            WHEN 'RTI-TWR' THEN 'Sari Roti'
+            WHEN 'SSU-250' THEN 'Ultra Jaya'
+        END
+    );
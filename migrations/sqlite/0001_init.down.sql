@@ -0,0 +1,6 @@
+-- Menghapus seluruh schema 0001 (SEMUA DATA HILANG)
+DROP TABLE IF EXISTS transaction_items;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS warehouses;
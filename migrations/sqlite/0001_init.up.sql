@@ -0,0 +1,64 @@
+-- Database Schema untuk Sistem Kasir v2.0 (SQLite)
+-- Sama dengan postgres/0001_init.up.sql, disesuaikan untuk SQLite
+
+-- Tabel Gudang
+CREATE TABLE warehouses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel User
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(100) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    warehouse_id INT REFERENCES warehouses(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel Produk dengan harga beli & jual
+CREATE TABLE products (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    purchase_price DECIMAL(10,2) NOT NULL,
+    selling_price DECIMAL(10,2) NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    warehouse_id INT REFERENCES warehouses(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel Transaksi
+CREATE TABLE transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT REFERENCES users(id),
+    warehouse_id INT REFERENCES warehouses(id),
+    total DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0,
+    payment DECIMAL(10,2) NOT NULL,
+    change DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel Detail Transaksi
+CREATE TABLE transaction_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    purchase_price DECIMAL(10,2) NOT NULL,
+    selling_price DECIMAL(10,2) NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    profit DECIMAL(10,2) NOT NULL DEFAULT 0
+);
+
+-- Index untuk performa
+CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX idx_transactions_warehouse_id ON transactions(warehouse_id);
+CREATE INDEX idx_transactions_user_id ON transactions(user_id);
+CREATE INDEX idx_transaction_items_transaction_id ON transaction_items(transaction_id);
+CREATE INDEX idx_products_warehouse_id ON products(warehouse_id);
+CREATE INDEX idx_users_warehouse_id ON users(warehouse_id);
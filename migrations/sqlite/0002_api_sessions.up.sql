@@ -0,0 +1,11 @@
+-- Tabel Sesi API (refresh token disimpan dalam bentuk hash SHA-256)
+CREATE TABLE api_sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_sessions_user_id ON api_sessions(user_id);
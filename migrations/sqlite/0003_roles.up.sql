@@ -0,0 +1,46 @@
+-- Tabel Role
+CREATE TABLE roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(20) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabel Permission per Role (kode permission lihat models/role.go)
+CREATE TABLE role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+-- Role bawaan
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Administrator, semua akses'),
+    ('user', 'Kasir gudang'),
+    ('supervisor', 'Supervisor toko: void transaksi dan penyesuaian stok'),
+    ('stock_clerk', 'Petugas stok: kelola produk, tanpa checkout'),
+    ('auditor', 'Auditor: hanya lihat laporan');
+
+WITH p(role, permission) AS (VALUES
+    ('admin', 'transaction.create'), ('admin', 'transaction.void'),
+    ('admin', 'product.view'), ('admin', 'product.manage'), ('admin', 'stock.adjust'),
+    ('admin', 'report.view'), ('admin', 'user.manage'), ('admin', 'warehouse.manage'),
+    ('admin', 'role.manage'), ('admin', 'warehouse.all'), ('admin', 'audit.view'),
+    ('user', 'transaction.create'), ('user', 'product.view'), ('user', 'report.view'),
+    ('supervisor', 'transaction.create'), ('supervisor', 'transaction.void'),
+    ('supervisor', 'product.view'), ('supervisor', 'stock.adjust'), ('supervisor', 'report.view'),
+    ('stock_clerk', 'product.view'), ('stock_clerk', 'product.manage'), ('stock_clerk', 'stock.adjust'),
+    ('auditor', 'product.view'), ('auditor', 'report.view'), ('auditor', 'audit.view'),
+    ('auditor', 'warehouse.all')
+)
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission FROM roles r JOIN p ON p.role = r.name;
+
+-- Database lama bisa punya user dengan role di luar role bawaan; role tersebut didaftarkan tanpa
+-- permission agar user-nya tetap bisa login
+INSERT INTO roles (name, description)
+SELECT DISTINCT u.role, 'Role dari database lama' FROM users u
+WHERE u.role NOT IN (SELECT name FROM roles);
+
+-- SQLite tidak bisa menambah foreign key ke tabel yang sudah ada; role user diperiksa aplikasi
+-- saat user dibuat
@@ -0,0 +1,15 @@
+-- Tabel Audit Log (nilai sebelum/sesudah disimpan sebagai teks JSON)
+CREATE TABLE audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    username VARCHAR(100) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    entity VARCHAR(30) NOT NULL,
+    entity_id INT,
+    before_data TEXT,
+    after_data TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_audit_logs_entity ON audit_logs(entity, entity_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_products_warehouse_barcode;
+DROP INDEX IF EXISTS idx_products_warehouse_sku;
+
+ALTER TABLE products DROP COLUMN barcode;
+ALTER TABLE products DROP COLUMN sku;
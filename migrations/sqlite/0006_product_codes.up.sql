@@ -0,0 +1,6 @@
+-- SKU dan barcode produk, unik per gudang (NULL = belum diisi)
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+ALTER TABLE products ADD COLUMN barcode VARCHAR(64);
+
+CREATE UNIQUE INDEX idx_products_warehouse_sku ON products(warehouse_id, sku);
+CREATE UNIQUE INDEX idx_products_warehouse_barcode ON products(warehouse_id, barcode);
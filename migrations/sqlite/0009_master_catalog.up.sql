@@ -0,0 +1,70 @@
+-- Katalog master: satu baris products per barang, stok dan harga per gudang di product_stocks.
+-- Produk kembar antar gudang digabung ke ID terkecil: SKU sama, atau barcode sama,
+-- atau nama sama jika tidak punya kode. Varian digabung jika induk dan nama variannya sama.
+
+CREATE TABLE product_stocks (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    stock INT NOT NULL DEFAULT 0,
+    purchase_price DECIMAL(10,2), -- NULL = ikut harga master
+    selling_price DECIMAL(10,2),  -- NULL = ikut harga master
+    PRIMARY KEY (product_id, warehouse_id)
+);
+
+CREATE INDEX idx_product_stocks_warehouse_id ON product_stocks(warehouse_id);
+
+CREATE TEMP TABLE product_merge (
+    old_id INT PRIMARY KEY,
+    new_id INT NOT NULL
+);
+
+INSERT INTO product_merge (old_id, new_id)
+SELECT p.id, (
+    SELECT MIN(o.id) FROM products o
+    WHERE o.parent_id IS NULL
+      AND CASE WHEN o.sku IS NOT NULL THEN 's:' || o.sku WHEN o.barcode IS NOT NULL THEN 'b:' || o.barcode ELSE 'n:' || LOWER(o.name) END
+        = CASE WHEN p.sku IS NOT NULL THEN 's:' || p.sku WHEN p.barcode IS NOT NULL THEN 'b:' || p.barcode ELSE 'n:' || LOWER(p.name) END
+)
+FROM products p
+WHERE p.parent_id IS NULL;
+
+INSERT INTO product_merge (old_id, new_id)
+SELECT v.id, (
+    SELECT MIN(o.id) FROM products o
+    JOIN product_merge om ON om.old_id = o.parent_id
+    WHERE om.new_id = vm.new_id AND LOWER(o.variant) = LOWER(v.variant)
+)
+FROM products v
+JOIN product_merge vm ON vm.old_id = v.parent_id;
+
+-- Stok digabung per gudang; harga gudang hanya disimpan jika berbeda dari harga master
+INSERT INTO product_stocks (product_id, warehouse_id, stock, purchase_price, selling_price)
+SELECT m.new_id, p.warehouse_id, SUM(p.stock), MAX(p.purchase_price), MAX(p.selling_price)
+FROM products p
+JOIN product_merge m ON m.old_id = p.id
+WHERE p.warehouse_id IS NOT NULL
+GROUP BY m.new_id, p.warehouse_id;
+
+UPDATE product_stocks SET purchase_price = NULL
+WHERE purchase_price = (SELECT p.purchase_price FROM products p WHERE p.id = product_stocks.product_id);
+UPDATE product_stocks SET selling_price = NULL
+WHERE selling_price = (SELECT p.selling_price FROM products p WHERE p.id = product_stocks.product_id);
+
+UPDATE transaction_items SET product_id = (SELECT m.new_id FROM product_merge m WHERE m.old_id = transaction_items.product_id)
+WHERE product_id IN (SELECT old_id FROM product_merge WHERE old_id <> new_id);
+UPDATE products SET parent_id = (SELECT m.new_id FROM product_merge m WHERE m.old_id = products.parent_id)
+WHERE parent_id IN (SELECT old_id FROM product_merge WHERE old_id <> new_id);
+DELETE FROM products WHERE id IN (SELECT old_id FROM product_merge WHERE old_id <> new_id);
+
+DROP TABLE product_merge;
+
+-- SKU dan barcode kini unik di seluruh katalog
+DROP INDEX IF EXISTS idx_products_warehouse_sku;
+DROP INDEX IF EXISTS idx_products_warehouse_barcode;
+DROP INDEX IF EXISTS idx_products_warehouse_id;
+
+ALTER TABLE products DROP COLUMN warehouse_id;
+ALTER TABLE products DROP COLUMN stock;
+
+CREATE UNIQUE INDEX idx_products_sku ON products(sku);
+CREATE UNIQUE INDEX idx_products_barcode ON products(barcode);
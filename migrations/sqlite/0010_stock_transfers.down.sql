@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'stock.transfer';
+
+DROP TABLE IF EXISTS stock_transfer_items;
+DROP TABLE IF EXISTS stock_transfers;
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission IN ('stock.opname', 'stock.opname.approve');
+
+DROP TABLE IF EXISTS stock_opname_items;
+DROP TABLE IF EXISTS stock_opnames;
@@ -0,0 +1,7 @@
+DELETE FROM role_permissions WHERE permission IN ('purchase.manage', 'purchase.receive');
+
+DROP TABLE IF EXISTS goods_receipt_items;
+DROP TABLE IF EXISTS goods_receipts;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS suppliers;
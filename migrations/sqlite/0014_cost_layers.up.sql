@@ -0,0 +1,25 @@
+-- Lapis harga pokok persediaan: setiap barang masuk ke gudang (pembelian, transfer, penyesuaian)
+-- menjadi satu lapis dengan harga pokoknya. Barang keluar mengurangi remaining sesuai metode
+-- harga pokok (average atau fifo), dan harga pokok yang terpakai menjadi harga beli item penjualan.
+CREATE TABLE cost_layers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    quantity INT NOT NULL,
+    remaining INT NOT NULL,
+    unit_cost DECIMAL(12,4) NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cost_layers_product_id ON cost_layers(product_id, warehouse_id, remaining);
+
+-- Harga pokok barang yang sedang dikirim antar gudang, dipakai sebagai lapis di gudang tujuan
+ALTER TABLE stock_transfer_items ADD COLUMN unit_cost DECIMAL(12,4) NOT NULL DEFAULT 0;
+
+-- Stok yang sudah ada menjadi lapis saldo awal dengan harga beli gudang saat ini
+INSERT INTO cost_layers (product_id, warehouse_id, quantity, remaining, unit_cost, reference)
+SELECT s.product_id, s.warehouse_id, s.stock, s.stock, COALESCE(s.purchase_price, p.purchase_price), 'saldo awal'
+FROM product_stocks s
+JOIN products p ON p.id = s.product_id
+WHERE s.stock > 0;
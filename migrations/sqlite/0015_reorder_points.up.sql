@@ -0,0 +1,3 @@
+-- Titik pesan ulang per produk per gudang: stok yang mencapai atau di bawah angka ini muncul di
+-- peringatan stok menipis. 0 = tanpa peringatan.
+ALTER TABLE product_stocks ADD COLUMN reorder_point INT NOT NULL DEFAULT 0;
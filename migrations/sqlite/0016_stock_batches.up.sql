@@ -0,0 +1,29 @@
+-- Batch stok dengan tanggal kedaluwarsa per produk per gudang. Stok di product_stocks tetap
+-- jumlah total; sisa batch tidak pernah melebihi stok, selisihnya adalah stok tanpa batch.
+-- Barang keluar mengambil batch yang paling cepat kedaluwarsa lebih dulu (FEFO).
+CREATE TABLE stock_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    batch_no VARCHAR(50) NOT NULL,
+    expiry_date DATE NOT NULL,
+    quantity INT NOT NULL,
+    remaining INT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_batches_product_id ON stock_batches(product_id, warehouse_id, expiry_date);
+CREATE INDEX idx_stock_batches_expiry_date ON stock_batches(expiry_date);
+
+-- Batch yang sedang dikirim antar gudang, dibuat ulang di gudang tujuan saat transfer diterima
+CREATE TABLE stock_transfer_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    batch_no VARCHAR(50) NOT NULL,
+    expiry_date DATE NOT NULL,
+    quantity INT NOT NULL
+);
+
+CREATE INDEX idx_stock_transfer_batches_transfer_id ON stock_transfer_batches(transfer_id);
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'transaction.return';
+
+DROP TABLE IF EXISTS sales_return_items;
+DROP TABLE IF EXISTS sales_returns;
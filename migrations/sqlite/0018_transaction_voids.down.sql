@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN void_reason;
+ALTER TABLE transactions DROP COLUMN voided_by;
+ALTER TABLE transactions DROP COLUMN voided_at;
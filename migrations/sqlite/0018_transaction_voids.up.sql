@@ -0,0 +1,5 @@
+-- Void transaksi: transaksi yang salah input dibatalkan dengan persetujuan supervisor. Barisnya tetap
+-- disimpan (ditandai voided_at) agar jejaknya terlihat di laporan, tapi tidak dihitung di penjualan.
+ALTER TABLE transactions ADD COLUMN voided_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN voided_by INT REFERENCES users(id);
+ALTER TABLE transactions ADD COLUMN void_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS api_session_used_tokens;
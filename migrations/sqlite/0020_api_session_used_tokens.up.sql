@@ -0,0 +1,9 @@
+-- Refresh token lama yang sudah dirotasi. Jika dipakai lagi, token itu kemungkinan dicuri
+-- sehingga seluruh sesinya dicabut
+CREATE TABLE api_session_used_tokens (
+    refresh_token_hash VARCHAR(64) PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL REFERENCES api_sessions(id) ON DELETE CASCADE,
+    rotated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_api_session_used_tokens_session_id ON api_session_used_tokens(session_id);
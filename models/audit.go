@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Aksi audit log
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditImport = "import"
+	AuditExport = "export"
+)
+
+// Entitas audit log
+const (
+	EntityProduct     = "product"
+	EntityWarehouse   = "warehouse"
+	EntityUser        = "user"
+	EntityRole        = "role"
+	EntityTransaction = "transaction"
+	EntityTransfer    = "transfer"
+	EntityOpname      = "opname"
+	EntitySupplier    = "supplier"
+	EntityPurchase    = "purchase"
+	EntityCategory    = "category"
+	EntityBrand       = "brand"
+	EntityBatch       = "batch"
+	EntityReturn      = "return"
+)
+
+// AuditLog model (jejak perubahan data oleh user)
+type AuditLog struct {
+	ID        int
+	UserID    *int
+	Username  string
+	Action    string
+	Entity    string
+	EntityID  *int
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// AuditFilter berisi filter untuk menelusuri audit log (field kosong = tidak difilter)
+type AuditFilter struct {
+	Entity   string
+	Action   string
+	Username string
+	EntityID int
+	From     *time.Time
+	To       *time.Time
+}
+
+// writeAudit mencatat satu baris audit log. Gunakan store transaksi yang sama dengan
+// perubahan datanya agar log dan data selalu konsisten.
+func writeAudit(s Store, actor *User, action, entity string, entityID int, before, after interface{}) error {
+	l := AuditLog{
+		Username:  "system",
+		Action:    action,
+		Entity:    entity,
+		CreatedAt: time.Now(),
+	}
+	if actor != nil {
+		l.UserID = &actor.ID
+		l.Username = actor.Username
+	}
+	if entityID > 0 {
+		l.EntityID = &entityID
+	}
+
+	var err error
+	if l.Before, err = auditJSON(before); err != nil {
+		return err
+	}
+	if l.After, err = auditJSON(after); err != nil {
+		return err
+	}
+	return s.Audit().Create(&l)
+}
+
+// RecordAudit mencatat aktivitas yang tidak terikat satu perubahan data (misal import/export Excel)
+func RecordAudit(actor *User, action, entity string, entityID int, before, after interface{}) error {
+	return writeAudit(store, actor, action, entity, entityID, before, after)
+}
+
+func auditJSON(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// GetAuditLogs mengambil audit log dengan filter dan pagination (terbaru di atas)
+func GetAuditLogs(filter AuditFilter, page, limit int) ([]AuditLog, int, error) {
+	return store.Audit().List(filter, page, limit)
+}
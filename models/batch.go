@@ -0,0 +1,365 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StockBatch adalah satu batch (lot) barang di satu gudang dengan tanggal kedaluwarsanya. Stok
+// gudang tetap dicatat di ProductStock; jumlah sisa batch tidak pernah melebihi stok tersebut,
+// selisihnya adalah stok tanpa batch. Barang keluar mengambil batch yang paling cepat
+// kedaluwarsa lebih dulu (FEFO).
+type StockBatch struct {
+	ID          int
+	ProductID   int
+	WarehouseID int
+	BatchNo     string
+	ExpiryDate  time.Time // hari terakhir barang boleh dijual
+	Quantity    int       // jumlah saat masuk
+	Remaining   int       // sisa yang belum keluar
+	Reference   string    // dokumen asal, contoh GR-000003, TRF-000002
+	CreatedAt   time.Time
+}
+
+// DaysLeft mengembalikan sisa hari sampai tanggal kedaluwarsa (0 = hari terakhir, negatif = sudah lewat)
+func (b StockBatch) DaysLeft(now time.Time) int {
+	today, _ := dayRange(now)
+	return int(expiryDay(b.ExpiryDate).Sub(today).Hours() / 24)
+}
+
+// IsExpired bernilai true jika tanggal kedaluwarsa batch sudah lewat
+func (b StockBatch) IsExpired(now time.Time) bool {
+	return b.DaysLeft(now) < 0
+}
+
+// BatchAllocation adalah jumlah barang yang diambil dari satu batch
+type BatchAllocation struct {
+	BatchNo    string
+	ExpiryDate time.Time
+	Quantity   int
+}
+
+// TransferBatch adalah batch barang yang dikirim lewat transfer, dibuat ulang di gudang tujuan
+// saat transfer diterima
+type TransferBatch struct {
+	ID         int
+	TransferID int
+	ProductID  int
+	BatchNo    string
+	ExpiryDate time.Time
+	Quantity   int
+}
+
+// TransactionBatch adalah batch yang terjual lewat satu transaksi, dipakai untuk mengembalikan
+// barang ke batch asalnya saat diretur atau transaksinya di-void
+type TransactionBatch struct {
+	ID            int
+	TransactionID int
+	ProductID     int
+	BatchNo       string
+	ExpiryDate    time.Time
+	Quantity      int
+}
+
+// ErrExpiredStock dikembalikan jika stok yang tersisa hanya barang yang sudah kedaluwarsa
+var ErrExpiredStock = errors.New("sisa stok sudah kedaluwarsa")
+
+// expiryDay membuang jam dari tanggal kedaluwarsa (tengah malam waktu lokal)
+func expiryDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// addBatch mencatat barang masuk sebagai batch baru. Gunakan store transaksi yang sama dengan
+// penambahan stoknya.
+func addBatch(s Store, productID, warehouseID int, batchNo string, expiry time.Time, quantity int, reference string) error {
+	if quantity <= 0 {
+		return nil
+	}
+	return s.Batches().Create(&StockBatch{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		BatchNo:     batchNo,
+		ExpiryDate:  expiryDay(expiry),
+		Quantity:    quantity,
+		Remaining:   quantity,
+		Reference:   reference,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// consumeBatches mengeluarkan quantity barang dari batch produk di satu gudang, mulai dari yang
+// paling cepat kedaluwarsa, dan mengembalikan batch yang terpakai. Panggil setelah stok gudang
+// dikurangi. Jika sellable, batch yang sudah kedaluwarsa dilewati; ErrExpiredStock dikembalikan
+// jika barang yang tersisa di gudang ternyata hanya barang kedaluwarsa.
+func consumeBatches(s Store, productID, warehouseID, quantity int, sellable bool) ([]BatchAllocation, error) {
+	if quantity <= 0 {
+		return nil, nil
+	}
+	batches, err := s.Batches().Open(productID, warehouseID)
+	if err != nil || len(batches) == 0 {
+		return nil, err
+	}
+
+	now := time.Now()
+	var used []BatchAllocation
+	left, remaining := quantity, 0
+	for i := range batches {
+		b := &batches[i]
+		if left > 0 && !(sellable && b.IsExpired(now)) {
+			take := b.Remaining
+			if take > left {
+				take = left
+			}
+			left -= take
+			b.Remaining -= take
+			if err := s.Batches().Update(b); err != nil {
+				return nil, err
+			}
+			used = append(used, BatchAllocation{BatchNo: b.BatchNo, ExpiryDate: b.ExpiryDate, Quantity: take})
+		}
+		remaining += b.Remaining
+	}
+
+	if sellable {
+		st, err := findStock(s, productID, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		if st == nil || remaining > st.Stock {
+			return nil, ErrExpiredStock
+		}
+	}
+	return used, nil
+}
+
+// restoreSaleBatches mengembalikan quantity barang produk yang terjual lewat satu transaksi (sold) ke
+// batch asalnya di gudang warehouseID, dengan tanggal kedaluwarsa semula. skip adalah jumlah yang
+// sudah dikembalikan sebelumnya; barang yang dulu terjual dari stok tanpa batch tetap tanpa batch.
+// Panggil setelah stok gudang ditambah.
+func restoreSaleBatches(s Store, sold []TransactionBatch, productID, warehouseID, skip, quantity int, reference string) error {
+	for _, b := range sold {
+		if b.ProductID != productID || quantity == 0 {
+			continue
+		}
+		take := b.Quantity
+		if take <= skip {
+			skip -= take
+			continue
+		}
+		take -= skip
+		skip = 0
+		if take > quantity {
+			take = quantity
+		}
+		quantity -= take
+		if err := addBatch(s, productID, warehouseID, b.BatchNo, b.ExpiryDate, take, reference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBatches mengambil batch produk yang masih bersisa di satu gudang, paling cepat kedaluwarsa dulu
+func GetBatches(productID, warehouseID int) ([]StockBatch, error) {
+	return store.Batches().Open(productID, warehouseID)
+}
+
+// AddStockBatch menambah stok produk di satu gudang sebagai batch baru dengan tanggal kedaluwarsa.
+// Stok bertambah sebanyak quantity dan dicatat di kartu stok sebagai penyesuaian; harga pokoknya
+// mengikuti harga beli gudang. Barang dari supplier sebaiknya masuk lewat penerimaan purchase order.
+func AddStockBatch(actor *User, productID, warehouseID int, batchNo string, expiry time.Time, quantity int) (*StockBatch, error) {
+	batchNo = strings.TrimSpace(batchNo)
+	if batchNo == "" {
+		return nil, errors.New("nomor batch wajib diisi")
+	}
+	if expiry.IsZero() {
+		return nil, errors.New("tanggal kedaluwarsa wajib diisi")
+	}
+	if quantity <= 0 {
+		return nil, errors.New("jumlah harus lebih dari 0")
+	}
+	if err := checkWarehouseAccess(actor, warehouseID); err != nil {
+		return nil, err
+	}
+
+	var batch *StockBatch
+	err := store.WithTx(func(s Store) error {
+		if _, err := s.Products().GetByID(productID); err != nil {
+			return fmt.Errorf("produk dengan ID %d tidak ditemukan", productID)
+		}
+		if _, err := s.Warehouses().GetByID(warehouseID); err != nil {
+			return fmt.Errorf("gudang dengan ID %d tidak ditemukan", warehouseID)
+		}
+		variants, err := s.Products().Variants(productID)
+		if err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			return fmt.Errorf("%w: batch dicatat di tiap varian", ErrHasVariants)
+		}
+
+		stock, err := receiveStock(s, productID, warehouseID, quantity)
+		if err != nil {
+			return err
+		}
+		reference := "BATCH " + batchNo
+		if err := adjustStockLayers(s, productID, warehouseID, quantity, reference); err != nil {
+			return err
+		}
+		batch = &StockBatch{
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			BatchNo:     batchNo,
+			ExpiryDate:  expiryDay(expiry),
+			Quantity:    quantity,
+			Remaining:   quantity,
+			Reference:   reference,
+			CreatedAt:   time.Now(),
+		}
+		if err := s.Batches().Create(batch); err != nil {
+			return err
+		}
+		err = writeMovement(s, actor, StockMovement{
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			Type:        MovementAdjustment,
+			Quantity:    quantity,
+			Balance:     stock,
+			Reference:   reference,
+		})
+		if err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityBatch, batch.ID, nil, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// ExpiringBatch adalah satu baris laporan batch yang mendekati atau sudah lewat tanggal kedaluwarsa
+type ExpiringBatch struct {
+	StockBatch
+	ProductName string
+	DaysLeft    int     // negatif = sudah kedaluwarsa
+	Value       float64 // nilai sisa batch dengan harga beli gudang
+}
+
+// GetExpiringBatches mengambil batch bersisa di gudang warehouseID (nil = semua gudang) yang
+// kedaluwarsa dalam days hari ke depan, termasuk yang sudah lewat, paling cepat kedaluwarsa dulu
+func GetExpiringBatches(warehouseID *int, days int) ([]ExpiringBatch, error) {
+	now := time.Now()
+	today, _ := dayRange(now)
+	batches, err := store.Batches().Expiring(warehouseID, today.AddDate(0, 0, days+1))
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]ExpiringBatch, 0, len(batches))
+	for _, b := range batches {
+		p, err := store.Products().GetByID(b.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		price, err := warehousePurchasePrice(store, b.ProductID, b.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, ExpiringBatch{
+			StockBatch:  b,
+			ProductName: p.DisplayName(),
+			DaysLeft:    b.DaysLeft(now),
+			Value:       float64(b.Remaining) * price,
+		})
+	}
+	return report, nil
+}
+
+// WriteOffBatch memusnahkan sisa satu batch: stok gudang berkurang sebanyak sisa batch dan dicatat
+// di kartu stok sebagai pemusnahan. Batch yang dikembalikan berisi keadaan sebelum dimusnahkan, jadi
+// Remaining adalah jumlah yang dimusnahkan.
+func WriteOffBatch(actor *User, id int) (*StockBatch, error) {
+	var written *StockBatch
+	err := store.WithTx(func(s Store) error {
+		b, err := s.Batches().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("batch dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, b.WarehouseID); err != nil {
+			return err
+		}
+		if b.Remaining == 0 {
+			return fmt.Errorf("batch %s sudah habis", b.BatchNo)
+		}
+		written = b
+		return writeOffBatch(s, actor, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// WriteOffExpiredBatches memusnahkan semua batch yang sudah kedaluwarsa di gudang warehouseID
+// (nil = semua gudang yang boleh diakses actor) dalam satu transaksi database. Seperti WriteOffBatch,
+// Remaining tiap batch yang dikembalikan adalah jumlah yang dimusnahkan.
+func WriteOffExpiredBatches(actor *User, warehouseID *int) ([]StockBatch, error) {
+	if warehouseID == nil {
+		warehouseID = reportWarehouse(actor)
+	} else if err := checkWarehouseAccess(actor, *warehouseID); err != nil {
+		return nil, err
+	}
+
+	var written []StockBatch
+	err := store.WithTx(func(s Store) error {
+		today, _ := dayRange(time.Now())
+		batches, err := s.Batches().Expiring(warehouseID, today)
+		if err != nil {
+			return err
+		}
+		for i := range batches {
+			if err := writeOffBatch(s, actor, &batches[i]); err != nil {
+				return err
+			}
+		}
+		written = batches
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// writeOffBatch mengeluarkan sisa batch b dari stok gudangnya. b sendiri tidak diubah.
+func writeOffBatch(s Store, actor *User, b *StockBatch) error {
+	quantity := b.Remaining
+	stock, err := s.Products().DecrementStock(b.ProductID, b.WarehouseID, quantity)
+	if err != nil {
+		return fmt.Errorf("stok batch %s tidak cocok dengan stok gudang: %w", b.BatchNo, err)
+	}
+	if _, err := consumeCost(s, b.ProductID, b.WarehouseID, quantity); err != nil {
+		return err
+	}
+
+	after := *b
+	after.Remaining = 0
+	if err := s.Batches().Update(&after); err != nil {
+		return err
+	}
+	err = writeMovement(s, actor, StockMovement{
+		ProductID:   b.ProductID,
+		WarehouseID: b.WarehouseID,
+		Type:        MovementWriteOff,
+		Quantity:    -quantity,
+		Balance:     stock,
+		Reference:   "EXP " + b.BatchNo,
+	})
+	if err != nil {
+		return err
+	}
+	return writeAudit(s, actor, AuditUpdate, EntityBatch, b.ID, b, after)
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustBatch(t *testing.T, productID, warehouseID int, batchNo string, days, quantity int) *StockBatch {
+	t.Helper()
+	b, err := AddStockBatch(nil, productID, warehouseID, batchNo, time.Now().AddDate(0, 0, days), quantity)
+	if err != nil {
+		t.Fatalf("add batch %s: %v", batchNo, err)
+	}
+	return b
+}
+
+func stockIn(t *testing.T, productID, warehouseID int) int {
+	t.Helper()
+	p, err := GetProductInWarehouse(productID, warehouseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Stock
+}
+
+func TestFEFOSaleSkipsExpiredBatches(t *testing.T) {
+	setupTestStore(t)
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	cashier := mustUser(t, "kasir1", "user", &cabang.ID)
+	roti := mustProduct(t, "Roti Tawar", 10000, 15000, 0, cabang.ID)
+
+	old := mustBatch(t, roti.ID, cabang.ID, "R-01", -1, 3)
+	mustBatch(t, roti.ID, cabang.ID, "R-03", 30, 5)
+	mustBatch(t, roti.ID, cabang.ID, "R-02", 5, 4)
+	if stock := stockIn(t, roti.ID, cabang.ID); stock != 12 {
+		t.Fatalf("stock after batches = %d", stock)
+	}
+
+	// 6 unit: R-02 habis (4), R-03 terpakai 2; R-01 sudah kedaluwarsa dan dilewati
+	trx := sell(t, cashier, roti.ID, 6)
+	batches, err := GetBatches(roti.ID, cabang.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0].BatchNo != "R-01" || batches[0].Remaining != 3 ||
+		batches[1].BatchNo != "R-03" || batches[1].Remaining != 3 {
+		t.Fatalf("batches after sale = %+v", batches)
+	}
+	// Batch yang terjual disimpan per transaksi
+	sold, err := store.Batches().TransactionBatches(trx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sold) != 2 || sold[0].BatchNo != "R-02" || sold[0].Quantity != 4 || sold[0].ProductID != roti.ID ||
+		sold[1].BatchNo != "R-03" || sold[1].Quantity != 2 {
+		t.Errorf("transaction batches = %+v", sold)
+	}
+
+	// Sisa 3 unit di gudang hanya barang kedaluwarsa, penjualan ditolak tanpa mengubah stok
+	sell(t, cashier, roti.ID, 3)
+	p, _ := GetProductInWarehouse(roti.ID, cabang.ID)
+	if _, err := CreateTransaction(cashier, []CartItem{{Product: p, Quantity: 1}}, 1e6); err == nil || !strings.Contains(err.Error(), "kedaluwarsa") {
+		t.Fatalf("sale of expired stock: err = %v", err)
+	}
+	if stock := stockIn(t, roti.ID, cabang.ID); stock != 3 {
+		t.Errorf("stock after refused sale = %d", stock)
+	}
+
+	report, err := GetExpiringBatches(&cabang.ID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].ID != old.ID || report[0].DaysLeft != -1 || report[0].Value != 3*10000 {
+		t.Fatalf("expiring report = %+v", report)
+	}
+
+	written, err := WriteOffExpiredBatches(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || written[0].ID != old.ID || written[0].Remaining != 3 {
+		t.Fatalf("written off = %+v", written)
+	}
+	if stock := stockIn(t, roti.ID, cabang.ID); stock != 0 {
+		t.Errorf("stock after write-off = %d", stock)
+	}
+	if batches, _ := GetBatches(roti.ID, cabang.ID); len(batches) != 0 {
+		t.Errorf("open batches after write-off = %+v", batches)
+	}
+	card, err := GetStockCard(roti.ID, &cabang.ID, time.Now().AddDate(0, 0, -1), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := card.Movements[len(card.Movements)-1]
+	if last.Type != MovementWriteOff || last.Quantity != -3 || last.Reference != "EXP R-01" {
+		t.Errorf("write-off movement = %+v", last)
+	}
+}
+
+func TestBatchesFollowPurchaseAndTransfer(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+	susu := mustProduct(t, "Susu Ultra 1L", 15000, 19000, 0, pusat.ID)
+	sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+
+	// Barang dari PO dengan tanggal kedaluwarsa tercatat sebagai batch bernomor penerimaan
+	po, err := CreatePurchaseOrder(nil, sup.ID, pusat.ID, "", []PurchaseOrderItem{{ProductID: susu.ID, Quantity: 10, Price: 15000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := time.Now().AddDate(0, 0, 10)
+	gr, err := ReceivePurchaseOrder(nil, po.ID, []GoodsReceiptItem{{ProductID: susu.ID, Quantity: 10, ExpiryDate: &expiry}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustBatch(t, susu.ID, pusat.ID, "S-02", 3, 4)
+
+	// Transfer 6 unit membawa S-02 (4) dan batch penerimaan (2) ke cabang
+	tr, err := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: susu.ID, Quantity: 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SendTransfer(nil, tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReceiveTransfer(nil, tr.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	grNo := fmt.Sprintf("GR-%06d", gr.ID)
+	pusatBatches, _ := GetBatches(susu.ID, pusat.ID)
+	if len(pusatBatches) != 1 || pusatBatches[0].BatchNo != grNo || pusatBatches[0].Remaining != 8 {
+		t.Fatalf("pusat batches = %+v", pusatBatches)
+	}
+	cabangBatches, _ := GetBatches(susu.ID, cabang.ID)
+	if len(cabangBatches) != 2 || cabangBatches[0].BatchNo != "S-02" || cabangBatches[0].Remaining != 4 ||
+		cabangBatches[1].BatchNo != grNo || cabangBatches[1].Remaining != 2 ||
+		!cabangBatches[1].ExpiryDate.Equal(expiryDay(expiry)) {
+		t.Fatalf("cabang batches = %+v", cabangBatches)
+	}
+
+	sell(t, kasirCabang, susu.ID, 5)
+	cabangBatches, _ = GetBatches(susu.ID, cabang.ID)
+	if len(cabangBatches) != 1 || cabangBatches[0].BatchNo != grNo || cabangBatches[0].Remaining != 1 {
+		t.Fatalf("cabang batches after sale = %+v", cabangBatches)
+	}
+
+	// User cabang tidak boleh memusnahkan batch gudang lain
+	if _, err := WriteOffBatch(kasirCabang, pusatBatches[0].ID); err == nil {
+		t.Error("expected error writing off batch of another warehouse")
+	}
+	written, err := WriteOffBatch(kasirCabang, cabangBatches[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.Remaining != 1 {
+		t.Errorf("written off quantity = %d", written.Remaining)
+	}
+	if stock := stockIn(t, susu.ID, cabang.ID); stock != 0 {
+		t.Errorf("cabang stock after write-off = %d", stock)
+	}
+	if _, err := WriteOffBatch(kasirCabang, cabangBatches[0].ID); err == nil {
+		t.Error("expected error writing off an empty batch")
+	}
+}
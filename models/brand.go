@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Brand model (merek produk)
+type Brand struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+}
+
+// ErrDuplicateBrand dikembalikan jika nama merek sudah dipakai
+var ErrDuplicateBrand = errors.New("merek dengan nama ini sudah ada")
+
+// GetAllBrands mengambil semua merek urut nama
+func GetAllBrands() ([]Brand, error) {
+	return store.Brands().List()
+}
+
+// GetBrandByID mengambil merek berdasarkan ID
+func GetBrandByID(id int) (*Brand, error) {
+	return store.Brands().GetByID(id)
+}
+
+// CreateBrand membuat merek baru
+func CreateBrand(actor *User, name string) (*Brand, error) {
+	b := Brand{Name: strings.TrimSpace(name)}
+	if b.Name == "" {
+		return nil, errors.New("nama merek tidak boleh kosong")
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if err := s.Brands().Create(&b); err != nil {
+			if err == ErrDuplicate {
+				return ErrDuplicateBrand
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityBrand, b.ID, nil, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// UpdateBrand mengganti nama merek
+func UpdateBrand(actor *User, id int, name string) error {
+	before, err := GetBrandByID(id)
+	if err != nil {
+		return fmt.Errorf("merek dengan ID %d tidak ditemukan", id)
+	}
+
+	after := *before
+	after.Name = strings.TrimSpace(name)
+	if after.Name == "" {
+		return errors.New("nama merek tidak boleh kosong")
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Brands().Update(&after); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("merek dengan ID %d tidak ditemukan", id)
+			}
+			if err == ErrDuplicate {
+				return ErrDuplicateBrand
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditUpdate, EntityBrand, id, before, after)
+	})
+}
+
+// DeleteBrand menghapus merek yang tidak dipakai produk
+func DeleteBrand(actor *User, id int) error {
+	before, err := GetBrandByID(id)
+	if err != nil {
+		return fmt.Errorf("merek dengan ID %d tidak ditemukan", id)
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Brands().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("merek dengan ID %d tidak ditemukan", id)
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityBrand, id, before, nil)
+	})
+}
+
+// FindOrCreateBrand mencari merek berdasarkan nama (tanpa membedakan huruf besar/kecil)
+// dan membuatnya jika belum ada. Nama kosong mengembalikan nil.
+func FindOrCreateBrand(actor *User, name string) (*Brand, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	brands, err := GetAllBrands()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range brands {
+		if strings.EqualFold(b.Name, name) {
+			return &b, nil
+		}
+	}
+	return CreateBrand(actor, name)
+}
@@ -0,0 +1,417 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CategoryPathSeparator memisahkan nama kategori induk dan anak, misal "Makanan > Mie Instan"
+const CategoryPathSeparator = " > "
+
+// UncategorizedLabel dipakai di laporan untuk produk tanpa kategori
+const UncategorizedLabel = "Tanpa Kategori"
+
+// Category model (kategori bertingkat, ParentID nil = kategori utama)
+type Category struct {
+	ID        int
+	Name      string
+	ParentID  *int
+	Path      string // nama lengkap dari kategori utama, diisi oleh GetAllCategories
+	CreatedAt time.Time
+}
+
+// ErrDuplicateCategory dikembalikan jika nama kategori sudah ada di bawah induk yang sama
+var ErrDuplicateCategory = errors.New("kategori dengan nama ini sudah ada di induk yang sama")
+
+// GetAllCategories mengambil semua kategori beserta path-nya, urut berdasarkan path
+func GetAllCategories() ([]Category, error) {
+	categories, err := store.Categories().List()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := categoryPaths(categories)
+	for i := range categories {
+		categories[i].Path = paths[categories[i].ID]
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Path < categories[j].Path })
+	return categories, nil
+}
+
+// GetCategoryByID mengambil kategori berdasarkan ID (beserta path-nya)
+func GetCategoryByID(id int) (*Category, error) {
+	categories, err := GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// CategoryPathMap mengembalikan path setiap kategori berdasarkan ID, untuk ditampilkan di daftar produk
+func CategoryPathMap() (map[int]string, error) {
+	categories, err := store.Categories().List()
+	if err != nil {
+		return nil, err
+	}
+	return categoryPaths(categories), nil
+}
+
+// categoryPaths menyusun path "Induk > Anak" untuk setiap kategori
+func categoryPaths(categories []Category) map[int]string {
+	byID := make(map[int]Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	paths := make(map[int]string, len(categories))
+	for _, c := range categories {
+		names := []string{c.Name}
+		seen := map[int]bool{c.ID: true}
+		for parent := c.ParentID; parent != nil && !seen[*parent]; {
+			p, ok := byID[*parent]
+			if !ok {
+				break
+			}
+			seen[p.ID] = true
+			names = append([]string{p.Name}, names...)
+			parent = p.ParentID
+		}
+		paths[c.ID] = strings.Join(names, CategoryPathSeparator)
+	}
+	return paths
+}
+
+// categoryWithDescendants mengembalikan ID kategori beserta seluruh subkategorinya
+func categoryWithDescendants(categories []Category, id int) []int {
+	children := make(map[int][]int)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []int{id}
+	seen := map[int]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// validateCategoryParent memastikan induk ada dan tidak membuat kategori menjadi turunan dirinya sendiri
+func validateCategoryParent(s Store, id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+	if _, err := s.Categories().GetByID(*parentID); err != nil {
+		return fmt.Errorf("kategori induk dengan ID %d tidak ditemukan", *parentID)
+	}
+	if id == 0 {
+		return nil
+	}
+
+	categories, err := s.Categories().List()
+	if err != nil {
+		return err
+	}
+	for _, descendant := range categoryWithDescendants(categories, id) {
+		if descendant == *parentID {
+			return errors.New("kategori tidak boleh menjadi induk dari dirinya sendiri atau subkategorinya")
+		}
+	}
+	return nil
+}
+
+// CreateCategory membuat kategori baru (parentID nil = kategori utama)
+func CreateCategory(actor *User, name string, parentID *int) (*Category, error) {
+	c := Category{Name: strings.TrimSpace(name), ParentID: parentID}
+	if c.Name == "" {
+		return nil, errors.New("nama kategori tidak boleh kosong")
+	}
+	if strings.Contains(c.Name, strings.TrimSpace(CategoryPathSeparator)) {
+		return nil, fmt.Errorf("nama kategori tidak boleh mengandung '%s'", strings.TrimSpace(CategoryPathSeparator))
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if err := validateCategoryParent(s, 0, parentID); err != nil {
+			return err
+		}
+		if err := s.Categories().Create(&c); err != nil {
+			if err == ErrDuplicate {
+				return ErrDuplicateCategory
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityCategory, c.ID, nil, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetCategoryByID(c.ID)
+}
+
+// UpdateCategory mengganti nama dan/atau induk kategori
+func UpdateCategory(actor *User, id int, name string, parentID *int) error {
+	before, err := store.Categories().GetByID(id)
+	if err != nil {
+		return fmt.Errorf("kategori dengan ID %d tidak ditemukan", id)
+	}
+
+	after := *before
+	after.Name, after.ParentID = strings.TrimSpace(name), parentID
+	if after.Name == "" {
+		return errors.New("nama kategori tidak boleh kosong")
+	}
+	if strings.Contains(after.Name, strings.TrimSpace(CategoryPathSeparator)) {
+		return fmt.Errorf("nama kategori tidak boleh mengandung '%s'", strings.TrimSpace(CategoryPathSeparator))
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := validateCategoryParent(s, id, parentID); err != nil {
+			return err
+		}
+		if err := s.Categories().Update(&after); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("kategori dengan ID %d tidak ditemukan", id)
+			}
+			if err == ErrDuplicate {
+				return ErrDuplicateCategory
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditUpdate, EntityCategory, id, before, after)
+	})
+}
+
+// DeleteCategory menghapus kategori yang tidak punya subkategori dan tidak dipakai produk
+func DeleteCategory(actor *User, id int) error {
+	before, err := store.Categories().GetByID(id)
+	if err != nil {
+		return fmt.Errorf("kategori dengan ID %d tidak ditemukan", id)
+	}
+
+	return store.WithTx(func(s Store) error {
+		categories, err := s.Categories().List()
+		if err != nil {
+			return err
+		}
+		for _, c := range categories {
+			if c.ParentID != nil && *c.ParentID == id {
+				return errors.New("kategori masih punya subkategori")
+			}
+		}
+
+		if err := s.Categories().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("kategori dengan ID %d tidak ditemukan", id)
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityCategory, id, before, nil)
+	})
+}
+
+// FindOrCreateCategoryPath mencari kategori dari path "Induk > Anak" dan membuat bagian yang belum ada.
+// Path kosong mengembalikan nil.
+func FindOrCreateCategoryPath(actor *User, path string) (*Category, error) {
+	var names []string
+	for _, part := range strings.Split(path, strings.TrimSpace(CategoryPathSeparator)) {
+		if part = strings.TrimSpace(part); part != "" {
+			names = append(names, part)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	categories, err := store.Categories().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var current *Category
+	for _, name := range names {
+		var found *Category
+		for i, c := range categories {
+			if strings.EqualFold(c.Name, name) && sameParent(c.ParentID, current) {
+				found = &categories[i]
+				break
+			}
+		}
+		if found == nil {
+			var parentID *int
+			if current != nil {
+				parentID = &current.ID
+			}
+			found, err = CreateCategory(actor, name, parentID)
+			if err != nil {
+				return nil, err
+			}
+			categories = append(categories, *found)
+		}
+		current = found
+	}
+
+	current.Path = strings.Join(names, CategoryPathSeparator)
+	return current, nil
+}
+
+func sameParent(parentID *int, parent *Category) bool {
+	if parent == nil {
+		return parentID == nil
+	}
+	return parentID != nil && *parentID == parent.ID
+}
+
+// CategorySales adalah ringkasan penjualan satu kategori
+type CategorySales struct {
+	CategoryID *int    `json:"category_id"`
+	Category   string  `json:"category"`
+	Quantity   int     `json:"quantity"`
+	Sales      float64 `json:"sales"`
+	Profit     float64 `json:"profit"`
+}
+
+// CategoryStock adalah ringkasan stok satu kategori
+type CategoryStock struct {
+	CategoryID *int    `json:"category_id"`
+	Category   string  `json:"category"`
+	Products   int     `json:"products"`
+	Stock      int     `json:"stock"`
+	StockValue float64 `json:"stock_value"` // stok x harga jual
+}
+
+// GetCategorySalesByDate mengelompokkan penjualan harian per kategori produk (sesuai gudang user)
+func GetCategorySalesByDate(user *User, date time.Time) ([]CategorySales, error) {
+	return GetWarehouseCategorySales(date, reportWarehouse(user))
+}
+
+// GetWarehouseCategorySales mengelompokkan penjualan harian per kategori di satu gudang (nil = semua gudang)
+func GetWarehouseCategorySales(date time.Time, warehouseID *int) ([]CategorySales, error) {
+	transactions, err := GetWarehouseTransactionsByDate(date, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	products, err := store.Products().List(warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := CategoryPathMap()
+	if err != nil {
+		return nil, err
+	}
+
+	productCategory := make(map[int]*int, len(products))
+	for _, p := range products {
+		productCategory[p.ID] = p.CategoryID
+	}
+
+	groups := make(map[int]*CategorySales)
+	for _, t := range transactions {
+		for _, item := range t.Items {
+			g := categoryGroup(groups, productCategory[item.ProductID], paths, func(id *int, name string) *CategorySales {
+				return &CategorySales{CategoryID: id, Category: name}
+			})
+			g.Quantity += item.Quantity
+			g.Sales += item.Subtotal
+			g.Profit += item.Profit
+		}
+	}
+
+	report := make([]CategorySales, 0, len(groups))
+	for _, g := range groups {
+		report = append(report, *g)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return categoryLess(report[i].CategoryID, report[i].Category, report[j].CategoryID, report[j].Category)
+	})
+	return report, nil
+}
+
+// GetCategoryStock mengelompokkan stok per kategori produk di satu gudang (nil = semua gudang)
+func GetCategoryStock(warehouseID *int) ([]CategoryStock, error) {
+	products, err := store.Products().List(warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := CategoryPathMap()
+	if err != nil {
+		return nil, err
+	}
+
+	// Produk induk yang punya varian tidak dihitung, stoknya ada di tiap varian
+	parents := make(map[int]bool)
+	for _, p := range products {
+		if p.ParentID != nil {
+			parents[*p.ParentID] = true
+		}
+	}
+
+	// Satu produk bisa muncul sekali per gudang, tapi dihitung sebagai satu produk
+	counted := make(map[int]bool)
+	groups := make(map[int]*CategoryStock)
+	for _, p := range products {
+		if parents[p.ID] {
+			continue
+		}
+		g := categoryGroup(groups, p.CategoryID, paths, func(id *int, name string) *CategoryStock {
+			return &CategoryStock{CategoryID: id, Category: name}
+		})
+		if !counted[p.ID] {
+			counted[p.ID] = true
+			g.Products++
+		}
+		g.Stock += p.Stock
+		g.StockValue += p.SellingPrice * float64(p.Stock)
+	}
+
+	report := make([]CategoryStock, 0, len(groups))
+	for _, g := range groups {
+		report = append(report, *g)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return categoryLess(report[i].CategoryID, report[i].Category, report[j].CategoryID, report[j].Category)
+	})
+	return report, nil
+}
+
+// categoryGroup mengambil (atau membuat) baris laporan untuk kategori; key 0 = tanpa kategori
+func categoryGroup[T any](groups map[int]*T, categoryID *int, paths map[int]string, newGroup func(*int, string) *T) *T {
+	key := 0
+	name := UncategorizedLabel
+	if categoryID != nil {
+		key = *categoryID
+		name = paths[key]
+	}
+	g, ok := groups[key]
+	if !ok {
+		var id *int
+		if categoryID != nil {
+			id = &key
+		}
+		g = newGroup(id, name)
+		groups[key] = g
+	}
+	return g
+}
+
+// categoryLess mengurutkan laporan berdasarkan path kategori, "Tanpa Kategori" paling akhir
+func categoryLess(idA *int, nameA string, idB *int, nameB string) bool {
+	if (idA == nil) != (idB == nil) {
+		return idB == nil
+	}
+	return nameA < nameB
+}
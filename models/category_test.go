@@ -0,0 +1,122 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	setupTestStore(t)
+
+	makanan, err := CreateCategory(nil, "Makanan", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mie, err := CreateCategory(nil, "Mie Instan", &makanan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateCategory(nil, "Mie Instan", &makanan.ID); err != ErrDuplicateCategory {
+		t.Errorf("duplicate sibling: err = %v, want ErrDuplicateCategory", err)
+	}
+	minuman, _ := CreateCategory(nil, "Minuman", nil)
+	if _, err := CreateCategory(nil, "Mie Instan", &minuman.ID); err != nil {
+		t.Errorf("same name under another parent should be allowed: %v", err)
+	}
+
+	got, err := GetCategoryByID(mie.ID)
+	if err != nil || got.Path != "Makanan > Mie Instan" {
+		t.Errorf("path = %+v, %v", got, err)
+	}
+
+	if err := UpdateCategory(nil, makanan.ID, "Makanan", &mie.ID); err == nil {
+		t.Error("moving a category under its own child should fail")
+	}
+	if err := DeleteCategory(nil, makanan.ID); err == nil {
+		t.Error("deleting a category with children should fail")
+	}
+
+	found, err := FindOrCreateCategoryPath(nil, "makanan > Mie Instan > Goreng")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ParentID == nil || *found.ParentID != mie.ID {
+		t.Errorf("path should reuse existing categories, got parent %v", found.ParentID)
+	}
+	if again, _ := FindOrCreateCategoryPath(nil, "Makanan>Mie Instan>Goreng"); again.ID != found.ID {
+		t.Errorf("second lookup created a new category %d, want %d", again.ID, found.ID)
+	}
+}
+
+func TestProductFilterIncludesSubcategories(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+
+	makanan, _ := CreateCategory(nil, "Makanan", nil)
+	mie, _ := CreateCategory(nil, "Mie Instan", &makanan.ID)
+	minuman, _ := CreateCategory(nil, "Minuman", nil)
+	indofood, _ := CreateBrand(nil, "Indofood")
+
+	CreateProduct(nil, Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: w.ID, CategoryID: &mie.ID, BrandID: &indofood.ID})
+	CreateProduct(nil, Product{Name: "Roti Tawar", PurchasePrice: 12000, SellingPrice: 15000, Stock: 5, WarehouseID: w.ID, CategoryID: &makanan.ID})
+	CreateProduct(nil, Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 10, WarehouseID: w.ID, CategoryID: &minuman.ID})
+	mustProduct(t, "Tanpa Label", 1000, 2000, 1, w.ID)
+
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		want   int
+	}{
+		{"parent includes children", ProductFilter{CategoryID: &makanan.ID}, 2},
+		{"leaf only", ProductFilter{CategoryID: &mie.ID}, 1},
+		{"brand", ProductFilter{BrandID: &indofood.ID}, 1},
+		{"category and search", ProductFilter{CategoryID: &makanan.ID, Search: "roti"}, 1},
+		{"no filter", ProductFilter{}, 4},
+	}
+	for _, tc := range tests {
+		if _, total, err := GetProducts(1, 10, tc.filter); err != nil || total != tc.want {
+			t.Errorf("%s: total = %d, %v, want %d", tc.name, total, err, tc.want)
+		}
+	}
+
+	if _, err := CreateProduct(nil, Product{Name: "X", WarehouseID: w.ID, CategoryID: new(int)}); err == nil {
+		t.Error("unknown category should be rejected")
+	}
+	if err := DeleteBrand(nil, indofood.ID); err == nil {
+		t.Error("deleting a brand still used by products should fail")
+	}
+}
+
+func TestCategoryReports(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+
+	makanan, _ := CreateCategory(nil, "Makanan", nil)
+	mieCat, _ := CreateCategory(nil, "Mie Instan", &makanan.ID)
+	mie, _ := CreateProduct(nil, Product{Name: "Indomie Goreng", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: w.ID, CategoryID: &mieCat.ID})
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 10, w.ID)
+
+	if _, err := CreateTransaction(cashier, []CartItem{{Product: mie, Quantity: 2}, {Product: aqua, Quantity: 1}}, 20000); err != nil {
+		t.Fatal(err)
+	}
+
+	sales, err := GetCategorySalesByDate(cashier, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sales) != 2 || sales[0].Category != "Makanan > Mie Instan" || sales[0].Quantity != 2 || sales[0].Sales != 7000 {
+		t.Errorf("sales = %+v", sales)
+	}
+	if sales[1].CategoryID != nil || sales[1].Category != UncategorizedLabel || sales[1].Profit != 1500 {
+		t.Errorf("uncategorized row = %+v", sales[1])
+	}
+
+	stock, err := GetCategoryStock(&w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stock) != 2 || stock[0].Stock != 8 || stock[0].StockValue != 28000 || stock[1].Products != 1 {
+		t.Errorf("stock = %+v", stock)
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"kasir/config"
+	"time"
+)
+
+// Metode harga pokok persediaan (dipilih per instalasi lewat inventory.costing)
+const (
+	CostingAverage = config.CostingAverage
+	CostingFIFO    = config.CostingFIFO
+)
+
+// CostLayer adalah satu lapis harga pokok: sejumlah barang yang masuk ke satu gudang dengan harga
+// pokok yang sama. Barang keluar mengurangi Remaining, dimulai dari lapis yang paling lama.
+type CostLayer struct {
+	ID          int
+	ProductID   int
+	WarehouseID int
+	Quantity    int // jumlah saat masuk
+	Remaining   int // sisa yang belum keluar
+	UnitCost    float64
+	Reference   string // dokumen asal, contoh GR-000003, TRF-000002, saldo awal
+	CreatedAt   time.Time
+}
+
+// CostingMethod mengembalikan metode harga pokok yang sedang dipakai
+func CostingMethod() string {
+	if config.App.Inventory.Costing == CostingFIFO {
+		return CostingFIFO
+	}
+	return CostingAverage
+}
+
+// CostingMethodLabel mengembalikan nama metode harga pokok untuk ditampilkan
+func CostingMethodLabel(method string) string {
+	switch method {
+	case CostingAverage:
+		return "Rata-rata Tertimbang"
+	case CostingFIFO:
+		return "FIFO"
+	}
+	return method
+}
+
+// addCostLayer mencatat barang masuk sebagai lapis harga pokok baru. Gunakan store transaksi
+// yang sama dengan penambahan stoknya.
+func addCostLayer(s Store, productID, warehouseID, quantity int, unitCost float64, reference string) error {
+	if quantity <= 0 {
+		return nil
+	}
+	return s.CostLayers().Create(&CostLayer{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+		Remaining:   quantity,
+		UnitCost:    unitCost,
+		Reference:   reference,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// consumeCost mengeluarkan quantity barang dari lapis harga pokok produk di satu gudang dan
+// mengembalikan total harga pokoknya. FIFO memakai harga lapis yang paling lama lebih dulu;
+// average memakai rata-rata tertimbang semua lapis, lalu sisa lapis dinilai ulang dengan rata-rata itu.
+// Barang yang tidak punya lapis (stok lama tanpa riwayat) dinilai dengan harga beli gudang.
+func consumeCost(s Store, productID, warehouseID, quantity int) (float64, error) {
+	if quantity <= 0 {
+		return 0, nil
+	}
+	layers, err := s.CostLayers().Open(productID, warehouseID)
+	if err != nil {
+		return 0, err
+	}
+
+	average := CostingMethod() == CostingAverage
+	var avgCost float64
+	if average {
+		var qty int
+		var value float64
+		for _, l := range layers {
+			qty += l.Remaining
+			value += float64(l.Remaining) * l.UnitCost
+		}
+		if qty > 0 {
+			avgCost = value / float64(qty)
+		}
+	}
+
+	var cost float64
+	left := quantity
+	for i := range layers {
+		if left == 0 && !average {
+			break
+		}
+		l := &layers[i]
+		take := l.Remaining
+		if take > left {
+			take = left
+		}
+		left -= take
+		l.Remaining -= take
+		if average {
+			// sisa lapis ikut dinilai dengan rata-rata agar nilai persediaan tetap konsisten
+			cost += float64(take) * avgCost
+			l.UnitCost = avgCost
+		} else {
+			cost += float64(take) * l.UnitCost
+		}
+		if err := s.CostLayers().Update(l); err != nil {
+			return 0, err
+		}
+	}
+
+	if left > 0 {
+		price, err := warehousePurchasePrice(s, productID, warehouseID)
+		if err != nil {
+			return 0, err
+		}
+		cost += float64(left) * price
+	}
+	return cost, nil
+}
+
+// warehousePurchasePrice mengembalikan harga beli produk di satu gudang (harga master jika
+// gudang tidak punya harga sendiri)
+func warehousePurchasePrice(s Store, productID, warehouseID int) (float64, error) {
+	p, err := s.Products().GetByID(productID)
+	if err != nil {
+		return 0, err
+	}
+	st, err := findStock(s, productID, warehouseID)
+	if err != nil {
+		return 0, err
+	}
+	if st != nil && st.PurchasePrice != nil {
+		return *st.PurchasePrice, nil
+	}
+	return p.PurchasePrice, nil
+}
+
+// adjustStockLayers menyesuaikan lapis harga pokok dan batch dengan perubahan stok delta tanpa
+// harga dokumen (penyesuaian, import, opname): stok bertambah menjadi lapis dengan harga beli gudang
+// (tanpa batch), stok berkurang dikeluarkan dari lapis sesuai metode harga pokok dan dari batch
+// yang paling cepat kedaluwarsa
+func adjustStockLayers(s Store, productID, warehouseID, delta int, reference string) error {
+	if delta < 0 {
+		if _, err := consumeCost(s, productID, warehouseID, -delta); err != nil {
+			return err
+		}
+		_, err := consumeBatches(s, productID, warehouseID, -delta, false)
+		return err
+	}
+	if delta == 0 {
+		return nil
+	}
+	price, err := warehousePurchasePrice(s, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	return addCostLayer(s, productID, warehouseID, delta, price, reference)
+}
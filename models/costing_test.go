@@ -0,0 +1,139 @@
+package models
+
+import (
+	"kasir/config"
+	"math"
+	"testing"
+)
+
+func useCosting(t *testing.T, method string) {
+	t.Helper()
+	previous := config.App.Inventory.Costing
+	config.App.Inventory.Costing = method
+	t.Cleanup(func() { config.App.Inventory.Costing = previous })
+}
+
+// receiveAt menerima quantity barang dari supplier ke gudang dengan harga beli price
+func receiveAt(t *testing.T, supplierID, productID, warehouseID, quantity int, price float64) {
+	t.Helper()
+	po, err := CreatePurchaseOrder(nil, supplierID, warehouseID, "", []PurchaseOrderItem{{ProductID: productID, Quantity: quantity, Price: price}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReceivePurchaseOrder(nil, po.ID, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sell(t *testing.T, cashier *User, productID, quantity int) *Transaction {
+	t.Helper()
+	p, err := GetProductInWarehouse(productID, *cashier.WarehouseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trx, err := CreateTransaction(cashier, []CartItem{{Product: p, Quantity: quantity}}, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trx
+}
+
+func TestSaleCostFollowsCostingMethod(t *testing.T) {
+	tests := []struct {
+		method     string
+		firstCost  float64 // harga pokok 15 unit pertama
+		secondCost float64 // harga pokok 10 unit berikutnya
+	}{
+		// FIFO: 10 @2500 lalu 5 @3000; sisa 5 @3000 + 5 @3500
+		{CostingFIFO, 10*2500 + 5*3000, 5*3000 + 5*3500},
+		// Average: (10*2500 + 10*3000) / 20 = 2750; sisa 5 @2750 + 5 @3500 = 3125
+		{CostingAverage, 15 * 2750, 10 * 3125},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method, func(t *testing.T) {
+			useCosting(t, tc.method)
+			setupTestStore(t)
+			w := mustWarehouse(t, "Gudang Pusat")
+			cashier := mustUser(t, "kasir1", "user", &w.ID)
+			mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 10, w.ID)
+			sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+
+			receiveAt(t, sup.ID, mie.ID, w.ID, 10, 3000)
+			trx := sell(t, cashier, mie.ID, 15)
+			if math.Abs(trx.Items[0].Subtotal-trx.Items[0].Profit-tc.firstCost) > 0.01 || trx.Profit != trx.Items[0].Profit {
+				t.Errorf("first sale = %+v, want cost %v", trx.Items[0], tc.firstCost)
+			}
+			if want := tc.firstCost / 15; math.Abs(trx.Items[0].PurchasePrice-want) > 0.01 {
+				t.Errorf("purchase price = %v, want %v", trx.Items[0].PurchasePrice, want)
+			}
+
+			receiveAt(t, sup.ID, mie.ID, w.ID, 5, 3500)
+			trx = sell(t, cashier, mie.ID, 10)
+			if cost := trx.Items[0].Subtotal - trx.Items[0].Profit; math.Abs(cost-tc.secondCost) > 0.01 {
+				t.Errorf("second sale cost = %v, want %v", cost, tc.secondCost)
+			}
+
+			items, _ := GetTransactionItems(trx.ID)
+			if len(items) != 1 || items[0].Profit != trx.Items[0].Profit {
+				t.Errorf("stored items = %+v", items)
+			}
+		})
+	}
+}
+
+func TestTransferCarriesCost(t *testing.T) {
+	useCosting(t, CostingFIFO)
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 4, pusat.ID)
+	sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+	receiveAt(t, sup.ID, mie.ID, pusat.ID, 6, 3000)
+
+	// 6 unit dikirim: 4 @2500 dan 2 @3000, tiba di cabang dengan rata-rata 2666,67
+	tr, err := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr, err = SendTransfer(nil, tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(tr.Items[0].UnitCost*6-(4*2500+2*3000)) > 0.01 {
+		t.Errorf("transfer unit cost = %v", tr.Items[0].UnitCost)
+	}
+	if _, err := ReceiveTransfer(nil, tr.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	trx := sell(t, kasirCabang, mie.ID, 3)
+	if cost := trx.Items[0].Subtotal - trx.Items[0].Profit; math.Abs(cost-3*(16000.0/6)) > 0.01 {
+		t.Errorf("sale cost at destination = %v", cost)
+	}
+}
+
+func TestStockAdjustmentCostLayers(t *testing.T) {
+	useCosting(t, CostingFIFO)
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 5, w.ID)
+
+	// Stok dikurangi 2 (keluar dari lapis tertua), lalu ditambah 4 dengan harga beli gudang baru
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: w.ID, Stock: 3}); err != nil {
+		t.Fatal(err)
+	}
+	price := 2800.0
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: w.ID, Stock: 7, PurchasePrice: &price}); err != nil {
+		t.Fatal(err)
+	}
+	layers, _ := store.CostLayers().Open(mie.ID, w.ID)
+	if len(layers) != 2 || layers[0].Remaining != 3 || layers[1].Remaining != 4 || layers[1].UnitCost != 2800 {
+		t.Fatalf("layers = %+v", layers)
+	}
+
+	trx := sell(t, cashier, mie.ID, 5)
+	if cost := trx.Items[0].Subtotal - trx.Items[0].Profit; cost != 3*2500+2*2800 {
+		t.Errorf("sale cost = %v, want %v", cost, 3*2500+2*2800)
+	}
+}
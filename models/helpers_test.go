@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+// setupTestStore memasang memory store baru dengan role admin dan kasir
+func setupTestStore(t *testing.T) {
+	t.Helper()
+	SetStore(NewMemoryStore())
+
+	if _, err := CreateRole(nil, AdminRole, "Administrator", nil); err != nil {
+		t.Fatalf("create role admin: %v", err)
+	}
+	if _, err := CreateRole(nil, "user", "Kasir", []string{PermTransactionCreate, PermProductView}); err != nil {
+		t.Fatalf("create role user: %v", err)
+	}
+}
+
+func mustWarehouse(t *testing.T, name string) *Warehouse {
+	t.Helper()
+	w, err := CreateWarehouse(nil, name, "")
+	if err != nil {
+		t.Fatalf("create warehouse: %v", err)
+	}
+	return w
+}
+
+func mustProduct(t *testing.T, name string, purchasePrice, sellingPrice float64, stock, warehouseID int) *Product {
+	t.Helper()
+	p, err := CreateProduct(nil, Product{Name: name, PurchasePrice: purchasePrice, SellingPrice: sellingPrice, Stock: stock, WarehouseID: warehouseID})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return p
+}
+
+func mustUser(t *testing.T, username, role string, warehouseID *int) *User {
+	t.Helper()
+	u, err := Register(nil, username, "rahasia", role, warehouseID)
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	u, err = GetUserByID(u.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	return u
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"fmt"
+	"kasir/config"
+	"time"
+)
+
+const (
+	loginBackoffBase = time.Second
+	loginBackoffMax  = 30 * time.Second
+)
+
+// LoginThrottledError dikembalikan saat login ditolak karena terlalu banyak percobaan gagal
+type LoginThrottledError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	wait := e.RetryAfter.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if e.Locked {
+		return fmt.Sprintf("akun terkunci karena terlalu banyak percobaan gagal, coba lagi dalam %s", wait)
+	}
+	return fmt.Sprintf("terlalu banyak percobaan, tunggu %s sebelum mencoba lagi", wait)
+}
+
+// LoginThrottle menyimpan jumlah login gagal untuk satu username atau IP
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+func userThrottleKey(username string) string { return "user:" + username }
+func ipThrottleKey(clientIP string) string   { return "ip:" + clientIP }
+
+// throttleKey adalah satu key yang dipantau beserta batas percobaannya
+type throttleKey struct {
+	key         string
+	maxAttempts int
+	backoff     bool // jeda eksponensial hanya untuk username, lihat reserveLoginAttempt
+}
+
+// throttleKeys mengembalikan key yang dipantau beserta batas percobaannya (auth di config), selalu
+// username lebih dulu agar urutan penguncian baris sama di semua request
+func throttleKeys(username, clientIP string) []throttleKey {
+	keys := []throttleKey{{key: userThrottleKey(username), maxAttempts: config.App.Auth.LoginMaxAttempts, backoff: true}}
+	if clientIP != "" {
+		keys = append(keys, throttleKey{key: ipThrottleKey(clientIP), maxAttempts: config.App.Auth.LoginMaxAttemptsIP})
+	}
+	return keys
+}
+
+// loginLockoutDuration mengembalikan lama username/IP dikunci setelah melewati batas percobaan
+func loginLockoutDuration() time.Duration {
+	return time.Duration(config.App.Auth.LockoutMinutes) * time.Minute
+}
+
+// CheckLoginAllowed menolak login jika username/IP sedang dikunci atau username masih dalam masa
+// back-off. Hanya membaca; Authenticate memakai reserveLoginAttempt yang memeriksa hal yang sama
+// sambil mencatat percobaan.
+func CheckLoginAllowed(username, clientIP string) error {
+	now := time.Now()
+	for _, k := range throttleKeys(username, clientIP) {
+		t, err := getLoginThrottle(k.key)
+		if err != nil {
+			return err
+		}
+		if err := checkThrottle(t, k, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkThrottle menolak percobaan jika key t sedang dikunci atau masih dalam masa back-off
+func checkThrottle(t *LoginThrottle, k throttleKey, now time.Time) error {
+	if t == nil {
+		return nil
+	}
+	if t.LockedUntil != nil && t.LockedUntil.After(now) {
+		return &LoginThrottledError{Locked: true, RetryAfter: t.LockedUntil.Sub(now)}
+	}
+	if k.backoff && t.LockedUntil == nil && t.Failures > 0 {
+		next := t.LastFailureAt.Add(loginBackoff(t.Failures))
+		if next.After(now) {
+			return &LoginThrottledError{RetryAfter: next.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// reserveLoginAttempt mencatat percobaan login sebagai gagal sebelum password dicocokkan dan
+// mengunci username/IP jika melewati batas. Baris throttle dikunci selama transaksi, jadi request
+// paralel dihitung satu per satu dan yang datang setelah batas tercapai langsung ditolak tanpa
+// mencocokkan password. Login yang berhasil membatalkan catatan ini lewat ResetLoginFailures.
+//
+// Back-off hanya berlaku per username: IP yang sama bisa dipakai banyak kasir sekaligus (NAT toko),
+// jadi IP cukup dibatasi dengan kunci setelah LoginMaxAttemptsIP kali gagal.
+func reserveLoginAttempt(username, clientIP string) error {
+	now := time.Now()
+	return store.WithTx(func(s Store) error {
+		for _, k := range throttleKeys(username, clientIP) {
+			t, err := s.LoginThrottle().GetForUpdate(k.key, now)
+			if err != nil {
+				return err
+			}
+			if err := checkThrottle(t, k, now); err != nil {
+				return err
+			}
+
+			failures := 1
+			if now.Sub(t.LastFailureAt) < loginLockoutDuration() && t.LockedUntil == nil {
+				failures = t.Failures + 1
+			}
+			t.Failures, t.LastFailureAt, t.LockedUntil = failures, now, nil
+			if failures >= k.maxAttempts {
+				until := now.Add(loginLockoutDuration())
+				t.LockedUntil = &until
+			}
+			if err := s.LoginThrottle().Save(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResetLoginFailures dipanggil setelah login berhasil: hitungan gagal username dihapus, sedangkan
+// hitungan IP dikurangi percobaan ini sendiri dan satu kegagalan sebelumnya. Salah ketik kasir lain
+// di balik IP yang sama (NAT toko) perlahan terhapus oleh login yang benar, tapi satu login berhasil
+// tidak menghapus semua tebakan yang sudah tercatat dari IP itu.
+func ResetLoginFailures(username, clientIP string) error {
+	return store.WithTx(func(s Store) error {
+		if _, err := s.LoginThrottle().Delete(userThrottleKey(username)); err != nil {
+			return err
+		}
+		if clientIP == "" {
+			return nil
+		}
+
+		key := ipThrottleKey(clientIP)
+		t, err := s.LoginThrottle().GetForUpdate(key, time.Now())
+		if err != nil {
+			return err
+		}
+		t.Failures -= 2
+		if t.Failures <= 0 {
+			_, err := s.LoginThrottle().Delete(key)
+			return err
+		}
+		if t.Failures < config.App.Auth.LoginMaxAttemptsIP {
+			t.LockedUntil = nil
+		}
+		return s.LoginThrottle().Save(t)
+	})
+}
+
+// UnlockUser membuka kunci akun secara manual (admin)
+func UnlockUser(actor *User, username string) error {
+	return store.WithTx(func(s Store) error {
+		var userID int
+		if u, err := s.Users().GetByUsername(username); err == nil {
+			if err := checkUserManageable(s, actor, u); err != nil {
+				return err
+			}
+			userID = u.ID
+		}
+
+		deleted, err := s.LoginThrottle().Delete(userThrottleKey(username))
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return fmt.Errorf("user '%s' tidak sedang terkunci", username)
+		}
+
+		before := map[string]string{"username": username, "status": "locked"}
+		after := map[string]string{"username": username, "status": "unlocked"}
+		return writeAudit(s, actor, AuditUpdate, EntityUser, userID, before, after)
+	})
+}
+
+// getLoginThrottle mengambil hitungan gagal untuk key (nil jika belum pernah gagal)
+func getLoginThrottle(key string) (*LoginThrottle, error) {
+	t, err := store.LoginThrottle().Get(key)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return t, err
+}
+
+// loginBackoff menghitung jeda eksponensial: 1s, 2s, 4s, ... maksimal 30s
+func loginBackoff(failures int) time.Duration {
+	d := loginBackoffBase
+	for i := 1; i < failures && d < loginBackoffMax; i++ {
+		d *= 2
+	}
+	if d > loginBackoffMax {
+		d = loginBackoffMax
+	}
+	return d
+}
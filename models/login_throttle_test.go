@@ -0,0 +1,324 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"kasir/config"
+	"kasir/migrations"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useLoginLimits mengganti batas percobaan login selama satu test
+func useLoginLimits(t *testing.T, perUser, perIP, lockoutMinutes int) {
+	t.Helper()
+	previous := config.App.Auth
+	config.App.Auth.LoginMaxAttempts = perUser
+	config.App.Auth.LoginMaxAttemptsIP = perIP
+	config.App.Auth.LockoutMinutes = lockoutMinutes
+	t.Cleanup(func() { config.App.Auth = previous })
+}
+
+// ageThrottle memundurkan waktu gagal terakhir (dan kunci) key sebanyak d, seolah waktu telah berlalu
+func ageThrottle(t *testing.T, key string, d time.Duration) {
+	t.Helper()
+	th, err := store.LoginThrottle().Get(key)
+	if err != nil {
+		t.Fatalf("get throttle %s: %v", key, err)
+	}
+	th.LastFailureAt = th.LastFailureAt.Add(-d)
+	if th.LockedUntil != nil {
+		until := th.LockedUntil.Add(-d)
+		th.LockedUntil = &until
+	}
+	if err := store.LoginThrottle().Save(th); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// failLogin melakukan login dengan password salah setelah melewati masa back-off
+func failLogin(t *testing.T, username, clientIP string) error {
+	t.Helper()
+	for _, key := range []string{userThrottleKey(username), ipThrottleKey(clientIP)} {
+		if _, err := store.LoginThrottle().Get(key); err == nil {
+			ageThrottle(t, key, loginBackoffMax)
+		}
+	}
+	_, err := Authenticate(username, "salah", clientIP)
+	return err
+}
+
+func throttled(err error) *LoginThrottledError {
+	var te *LoginThrottledError
+	if errors.As(err, &te) {
+		return te
+	}
+	return nil
+}
+
+func TestLoginBackoff(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 5, 20, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	mustUser(t, "kasir1", "user", &w.ID)
+
+	if _, err := Authenticate("kasir1", "salah", ""); err == nil || throttled(err) != nil {
+		t.Fatalf("first wrong password: err = %v", err)
+	}
+	// Percobaan berikutnya langsung ditolak, bahkan dengan password benar
+	_, err := Authenticate("kasir1", "rahasia", "")
+	te := throttled(err)
+	if te == nil || te.Locked || te.RetryAfter <= 0 || te.RetryAfter > loginBackoffBase {
+		t.Fatalf("login during back-off: err = %v", err)
+	}
+
+	if got := []time.Duration{loginBackoff(1), loginBackoff(2), loginBackoff(3), loginBackoff(10)}; got[0] != time.Second || got[1] != 2*time.Second || got[2] != 4*time.Second || got[3] != loginBackoffMax {
+		t.Errorf("back-off = %v", got)
+	}
+
+	// Setelah jeda lewat, login berhasil dan hitungan gagal dihapus
+	ageThrottle(t, userThrottleKey("kasir1"), loginBackoffBase)
+	if _, err := Authenticate("kasir1", "rahasia", ""); err != nil {
+		t.Fatalf("login after back-off: %v", err)
+	}
+	if _, err := store.LoginThrottle().Get(userThrottleKey("kasir1")); err != ErrNotFound {
+		t.Errorf("throttle after successful login: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLoginLockoutPerUser(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 3, 20, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	mustUser(t, "kasir1", "user", &w.ID)
+	mustUser(t, "kasir2", "user", &w.ID)
+
+	for i := 0; i < 3; i++ {
+		if err := failLogin(t, "kasir1", "10.0.0.1"); throttled(err) != nil {
+			t.Fatalf("attempt %d: err = %v", i+1, err)
+		}
+	}
+	_, err := Authenticate("kasir1", "rahasia", "10.0.0.2")
+	te := throttled(err)
+	if te == nil || !te.Locked || te.RetryAfter <= 14*time.Minute {
+		t.Fatalf("login after %d failures: err = %v", 3, err)
+	}
+	// Kunci berlaku per username: user lain dari IP yang sama tetap bisa login setelah back-off IP lewat
+	ageThrottle(t, ipThrottleKey("10.0.0.1"), loginBackoffMax)
+	if _, err := Authenticate("kasir2", "rahasia", "10.0.0.1"); err != nil {
+		t.Errorf("other user from same IP: %v", err)
+	}
+
+	// Kunci berakhir setelah masa lockout, lalu hitungan mulai dari awal
+	ageThrottle(t, userThrottleKey("kasir1"), 15*time.Minute)
+	if err := failLogin(t, "kasir1", "10.0.0.1"); throttled(err) != nil {
+		t.Fatalf("wrong password after lockout expired: err = %v", err)
+	}
+	if th, _ := store.LoginThrottle().Get(userThrottleKey("kasir1")); th == nil || th.Failures != 1 || th.LockedUntil != nil {
+		t.Errorf("throttle after lockout expired = %+v", th)
+	}
+	ageThrottle(t, userThrottleKey("kasir1"), loginBackoffMax)
+	ageThrottle(t, ipThrottleKey("10.0.0.1"), loginBackoffMax)
+	if _, err := Authenticate("kasir1", "rahasia", "10.0.0.1"); err != nil {
+		t.Errorf("login after lockout expired: %v", err)
+	}
+}
+
+func TestLoginLockoutPerIP(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 5, 3, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	mustUser(t, "kasir1", "user", &w.ID)
+
+	// Menebak password untuk banyak username berbeda dari satu IP
+	for _, name := range []string{"budi", "siti", "andi"} {
+		if err := failLogin(t, name, "10.0.0.9"); throttled(err) != nil {
+			t.Fatalf("guess %s: err = %v", name, err)
+		}
+	}
+	_, err := Authenticate("kasir1", "rahasia", "10.0.0.9")
+	if te := throttled(err); te == nil || !te.Locked {
+		t.Fatalf("login from locked IP: err = %v", err)
+	}
+	// IP lain dan login dari CLI (tanpa IP) tidak terpengaruh
+	if _, err := Authenticate("kasir1", "rahasia", "10.0.0.10"); err != nil {
+		t.Errorf("login from other IP: %v", err)
+	}
+	if _, err := Authenticate("kasir1", "rahasia", ""); err != nil {
+		t.Errorf("login without IP: %v", err)
+	}
+
+	ageThrottle(t, ipThrottleKey("10.0.0.9"), 15*time.Minute)
+	if _, err := Authenticate("kasir1", "rahasia", "10.0.0.9"); err != nil {
+		t.Errorf("login after IP lockout expired: %v", err)
+	}
+}
+
+func TestSuccessfulLoginDecaysIPFailures(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 5, 3, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	names := []string{"kasir1", "kasir2", "kasir3", "kasir4"}
+	for _, name := range names {
+		mustUser(t, name, "user", &w.ID)
+	}
+
+	// Satu cabang di balik satu IP: tiap kasir salah ketik sekali lalu login dengan benar.
+	// Tanpa pengurangan, kegagalan ke-3 dari IP itu mengunci seluruh cabang.
+	for _, name := range names {
+		if err := failLogin(t, name, "10.0.0.1"); throttled(err) != nil {
+			t.Fatalf("%s typo: err = %v", name, err)
+		}
+		ageThrottle(t, userThrottleKey(name), loginBackoffMax)
+		if _, err := Authenticate(name, "rahasia", "10.0.0.1"); err != nil {
+			t.Fatalf("%s login after typo: %v", name, err)
+		}
+	}
+	if _, err := store.LoginThrottle().Get(ipThrottleKey("10.0.0.1")); err != ErrNotFound {
+		t.Errorf("IP throttle after successful logins: err = %v, want ErrNotFound", err)
+	}
+
+	// Login berhasil hanya menghapus satu kegagalan sebelumnya, bukan semua tebakan
+	failLogin(t, "budi", "10.0.0.2")
+	failLogin(t, "siti", "10.0.0.2")
+	if _, err := Authenticate("kasir1", "rahasia", "10.0.0.2"); err != nil {
+		t.Fatal(err)
+	}
+	if th, _ := store.LoginThrottle().Get(ipThrottleKey("10.0.0.2")); th == nil || th.Failures != 1 {
+		t.Errorf("IP throttle after one success = %+v, want 1 failure left", th)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 2, 20, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	admin := mustUser(t, "admin", AdminRole, nil)
+	kasir := mustUser(t, "kasir1", "user", &w.ID)
+
+	if err := UnlockUser(admin, "kasir1"); err == nil {
+		t.Error("expected error unlocking a user that is not locked")
+	}
+	for i := 0; i < 2; i++ {
+		failLogin(t, "kasir1", "")
+	}
+	if _, err := Authenticate("kasir1", "rahasia", ""); throttled(err) == nil || !throttled(err).Locked {
+		t.Fatalf("login while locked: err = %v", err)
+	}
+
+	if err := UnlockUser(admin, "kasir1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Authenticate("kasir1", "rahasia", ""); err != nil {
+		t.Errorf("login after unlock: %v", err)
+	}
+
+	logs, _, err := GetAuditLogs(AuditFilter{Entity: EntityUser, Action: AuditUpdate, EntityID: kasir.ID}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Username != "admin" {
+		t.Errorf("unlock audit logs = %+v", logs)
+	}
+}
+
+// setupSQLiteTestStore memakai file SQLite baru dengan schema dan role bawaan dari migrations/sqlite
+func setupSQLiteTestStore(t *testing.T) {
+	t.Helper()
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "kasir.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.New(db, config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	SetStore(NewSQLiteStore(db))
+}
+
+// loginBurst menjalankan n login paralel dan mengembalikan berapa yang sampai ke pencocokan
+// password (ditolak karena password salah, bukan karena pembatasan)
+func loginBurst(t *testing.T, n int, attempt func(i int) error) int {
+	t.Helper()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := attempt(i)
+			if err == nil {
+				t.Errorf("attempt %d: expected error", i)
+				return
+			}
+			if throttled(err) == nil {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return checked
+}
+
+func TestConcurrentLoginFailuresLockAccount(t *testing.T) {
+	stores := []struct {
+		name  string
+		setup func(t *testing.T)
+	}{
+		{"memory", setupTestStore},
+		{"sqlite", setupSQLiteTestStore},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			st.setup(t)
+			useLoginLimits(t, 3, 5, 15)
+			w := mustWarehouse(t, "Gudang Pusat")
+			mustUser(t, "kasir1", "user", &w.ID)
+			mustUser(t, "kasir2", "user", &w.ID)
+
+			// Serangan paralel ke satu akun: tiap jeda back-off hanya satu tebakan yang dicocokkan,
+			// dan setiap tebakan itu terhitung sampai akun terkunci
+			for round := 1; round <= 3; round++ {
+				checked := loginBurst(t, 20, func(int) error {
+					_, err := Authenticate("kasir1", "salah", "")
+					return err
+				})
+				if checked != 1 {
+					t.Fatalf("round %d: %d attempts reached the password check, want 1", round, checked)
+				}
+				if th, _ := store.LoginThrottle().Get(userThrottleKey("kasir1")); th == nil || th.Failures != round {
+					t.Fatalf("round %d: throttle = %+v", round, th)
+				}
+				ageThrottle(t, userThrottleKey("kasir1"), loginBackoffMax)
+			}
+			_, err := Authenticate("kasir1", "rahasia", "")
+			if te := throttled(err); te == nil || !te.Locked {
+				t.Fatalf("login after concurrent failures: err = %v, want account locked", err)
+			}
+
+			// Tebakan paralel ke banyak username dari satu IP: tepat LoginMaxAttemptsIP yang dicocokkan
+			checked := loginBurst(t, 20, func(i int) error {
+				_, err := Authenticate(fmt.Sprintf("tebak%02d", i), "salah", "10.0.0.9")
+				return err
+			})
+			if checked != 5 {
+				t.Errorf("%d attempts from one IP reached the password check, want 5", checked)
+			}
+			_, err = Authenticate("kasir2", "rahasia", "10.0.0.9")
+			if te := throttled(err); te == nil || !te.Locked {
+				t.Errorf("login from IP after concurrent failures: err = %v, want IP locked", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Jenis mutasi stok
+const (
+	MovementSale       = "sale"       // terjual di kasir
+	MovementAdjustment = "adjustment" // stok diubah manual, termasuk stok awal produk baru
+	MovementImport     = "import"     // stok diisi dari import Excel
+	MovementTransfer   = "transfer"   // keluar saat transfer dikirim, masuk saat transfer diterima
+	MovementPurchase   = "purchase"   // barang dari supplier diterima atas purchase order
+	MovementWriteOff   = "writeoff"   // batch kedaluwarsa dimusnahkan
+	MovementReturn     = "return"     // barang dikembalikan pelanggan (retur penjualan)
+	MovementVoid       = "void"       // stok penjualan dikembalikan karena transaksinya di-void
+)
+
+// StockMovement adalah satu baris buku mutasi stok: setiap perubahan stok produk di satu gudang
+// dicatat beserta saldo setelahnya, sehingga stok saat ini selalu bisa ditelusuri asalnya.
+type StockMovement struct {
+	ID          int
+	ProductID   int
+	WarehouseID int
+	Type        string
+	Quantity    int // perubahan stok: positif = masuk, negatif = keluar
+	Balance     int // stok di gudang setelah mutasi ini
+	UserID      *int
+	Username    string
+	Reference   string // nomor dokumen atau keterangan, contoh TRX-000012, TRF-000003, stok awal
+	CreatedAt   time.Time
+}
+
+// MovementFilter berisi filter mutasi stok (nilai kosong/nil = tidak difilter)
+type MovementFilter struct {
+	ProductID   int
+	WarehouseID *int
+	From        *time.Time // mulai dari waktu ini
+	To          *time.Time // sebelum waktu ini
+}
+
+// MovementTypeLabel mengembalikan nama jenis mutasi untuk ditampilkan
+func MovementTypeLabel(movementType string) string {
+	switch movementType {
+	case MovementSale:
+		return "Penjualan"
+	case MovementAdjustment:
+		return "Penyesuaian"
+	case MovementImport:
+		return "Import"
+	case MovementTransfer:
+		return "Transfer"
+	case MovementPurchase:
+		return "Pembelian"
+	case MovementWriteOff:
+		return "Pemusnahan"
+	case MovementReturn:
+		return "Retur"
+	case MovementVoid:
+		return "Void"
+	}
+	return movementType
+}
+
+// writeMovement mencatat mutasi stok m dengan waktu sekarang dan actor sebagai pelaku.
+// Mutasi tanpa perubahan jumlah tidak dicatat. Gunakan store transaksi yang sama dengan
+// perubahan stoknya agar buku mutasi dan stok selalu cocok.
+func writeMovement(s Store, actor *User, m StockMovement) error {
+	if m.Quantity == 0 {
+		return nil
+	}
+	m.Username, m.CreatedAt = "system", time.Now()
+	if actor != nil {
+		m.UserID, m.Username = &actor.ID, actor.Username
+	}
+	return s.Movements().Create(&m)
+}
+
+// StockCard adalah kartu stok satu produk pada rentang tanggal: saldo awal, mutasi masuk/keluar,
+// dan saldo akhir. Tanpa gudang, saldo dijumlahkan dari semua gudang.
+type StockCard struct {
+	Product     *Product
+	WarehouseID *int
+	From        time.Time
+	To          time.Time // tanggal terakhir (termasuk)
+	Opening     int
+	In          int
+	Out         int
+	Closing     int
+	Movements   []StockMovement
+}
+
+// GetStockCard menyusun kartu stok produk productID dari tanggal from sampai to (termasuk)
+// di satu gudang (nil = semua gudang)
+func GetStockCard(productID int, warehouseID *int, from, to time.Time) (*StockCard, error) {
+	from, _ = dayRange(from)
+	_, end := dayRange(to)
+	if !end.After(from) {
+		return nil, errors.New("tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	p, err := store.Products().GetByID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("produk dengan ID %d tidak ditemukan", productID)
+	}
+	movements, err := store.Movements().List(MovementFilter{ProductID: productID, WarehouseID: warehouseID, To: &end})
+	if err != nil {
+		return nil, err
+	}
+
+	card := &StockCard{Product: p, WarehouseID: warehouseID, From: from, To: end.Add(-24 * time.Hour)}
+	// Saldo awal = saldo mutasi terakhir sebelum from di tiap gudang
+	opening := make(map[int]int)
+	for _, m := range movements {
+		if m.CreatedAt.Before(from) {
+			opening[m.WarehouseID] = m.Balance
+			continue
+		}
+		card.Movements = append(card.Movements, m)
+		if m.Quantity > 0 {
+			card.In += m.Quantity
+		} else {
+			card.Out -= m.Quantity
+		}
+	}
+	for _, balance := range opening {
+		card.Opening += balance
+	}
+	card.Closing = card.Opening + card.In - card.Out
+	return card, nil
+}
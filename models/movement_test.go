@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStockMovementsFollowEveryChange(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasir := mustUser(t, "kasir1", "user", &pusat.ID)
+
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 20, pusat.ID)
+	aqua, err := ImportProduct(nil, Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 10, WarehouseID: pusat.ID}, "/tmp/stok.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mieAtPusat, _ := GetProductInWarehouse(mie.ID, pusat.ID)
+	if _, err := CreateTransaction(kasir, []CartItem{{Product: mieAtPusat, Quantity: 3}}, 20000); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: pusat.ID, Stock: 15}); err != nil {
+		t.Fatal(err)
+	}
+	// Harga saja yang berubah: tidak ada mutasi
+	price := 3800.0
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: pusat.ID, Stock: 15, SellingPrice: &price}); err != nil {
+		t.Fatal(err)
+	}
+	trf, _ := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 5}})
+	SendTransfer(nil, trf.ID)
+	if _, err := ReceiveTransfer(nil, trf.ID, map[int]int{mie.ID: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	card, err := GetStockCard(mie.ID, &pusat.ID, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		kind              string
+		quantity, balance int
+	}{
+		{MovementAdjustment, 20, 20},
+		{MovementSale, -3, 17},
+		{MovementAdjustment, -2, 15},
+		{MovementTransfer, -5, 10},
+	}
+	if len(card.Movements) != len(want) {
+		t.Fatalf("movements = %+v", card.Movements)
+	}
+	for i, w := range want {
+		m := card.Movements[i]
+		if m.Type != w.kind || m.Quantity != w.quantity || m.Balance != w.balance {
+			t.Errorf("movement %d = %+v, want %+v", i, m, w)
+		}
+	}
+	if card.Movements[1].Username != "kasir1" || card.Movements[1].Reference != "TRX-000001" {
+		t.Errorf("sale movement = %+v", card.Movements[1])
+	}
+	if card.In != 20 || card.Out != 10 || card.Closing != 10 {
+		t.Errorf("card totals = in %d, out %d, closing %d", card.In, card.Out, card.Closing)
+	}
+
+	// Tanpa gudang: saldo semua gudang, 1 barang hilang di perjalanan
+	if card, _ := GetStockCard(mie.ID, nil, time.Now(), time.Now()); card.Closing != 14 {
+		t.Errorf("all warehouses closing = %d, want 14", card.Closing)
+	}
+	if card, _ := GetStockCard(aqua.ID, &pusat.ID, time.Now(), time.Now()); len(card.Movements) != 1 ||
+		card.Movements[0].Type != MovementImport || card.Movements[0].Reference != "stok.xlsx" {
+		t.Errorf("import movement = %+v", card.Movements)
+	}
+
+	if err := RemoveProductFromWarehouse(nil, mie.ID, cabang.ID); err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	card, _ = GetStockCard(mie.ID, &cabang.ID, tomorrow, tomorrow)
+	if card.Opening != 0 || card.Closing != 0 {
+		t.Errorf("removed from warehouse should close at 0: %+v", card)
+	}
+	if _, err := GetStockCard(mie.ID, nil, tomorrow, time.Now()); err == nil {
+		t.Error("end date before start date should fail")
+	}
+}
@@ -0,0 +1,307 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status stock opname
+const (
+	OpnameOpen     = "open"     // sedang dihitung, stok belum berubah
+	OpnameApproved = "approved" // sudah disetujui, selisih sudah dibukukan ke stok
+)
+
+// StockOpname adalah sesi hitung fisik stok di satu gudang. Stok sistem setiap produk dicatat
+// (snapshot) saat sesi dibuat; selisih hasil hitung terhadap snapshot baru dibukukan ke stok
+// setelah disetujui supervisor.
+type StockOpname struct {
+	ID           int
+	WarehouseID  int
+	Status       string
+	Note         string
+	UserID       int    // pembuat sesi
+	CreatedBy    string // username pembuat sesi
+	CreatedAt    time.Time
+	ApprovedByID int    // 0 = belum disetujui
+	ApprovedBy   string // username yang menyetujui
+	ApprovedAt   *time.Time
+	Items        []StockOpnameItem
+}
+
+// StockOpnameItem adalah satu produk di sesi stock opname
+type StockOpnameItem struct {
+	ID            int
+	OpnameID      int
+	ProductID     int
+	ProductName   string
+	SystemStock   int     // stok sistem saat sesi dibuat
+	Counted       *int    // hasil hitung fisik (nil = belum dihitung)
+	PurchasePrice float64 // harga beli di gudang saat sesi dibuat, untuk menilai selisih
+}
+
+// Variance mengembalikan selisih hitung fisik terhadap stok sistem (negatif = barang hilang).
+// Produk yang belum dihitung tidak punya selisih.
+func (i StockOpnameItem) Variance() int {
+	if i.Counted == nil {
+		return 0
+	}
+	return *i.Counted - i.SystemStock
+}
+
+// VarianceValue mengembalikan nilai selisih dengan harga beli
+func (i StockOpnameItem) VarianceValue() float64 {
+	return float64(i.Variance()) * i.PurchasePrice
+}
+
+// Uncounted mengembalikan jumlah produk yang belum dihitung
+func (o StockOpname) Uncounted() int {
+	n := 0
+	for _, item := range o.Items {
+		if item.Counted == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// VarianceValue mengembalikan total nilai selisih sesi (negatif = kerugian)
+func (o StockOpname) VarianceValue() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += item.VarianceValue()
+	}
+	return total
+}
+
+// OpnameStatusLabel mengembalikan nama status stock opname untuk ditampilkan
+func OpnameStatusLabel(status string) string {
+	switch status {
+	case OpnameOpen:
+		return "Dihitung"
+	case OpnameApproved:
+		return "Disetujui"
+	}
+	return status
+}
+
+// GetOpnames mengambil sesi stock opname di gudang user (semua gudang jika user punya akses
+// semua gudang), terbaru dulu. Status kosong = semua status.
+func GetOpnames(user *User, status string) ([]StockOpname, error) {
+	return GetWarehouseOpnames(reportWarehouse(user), status)
+}
+
+// GetWarehouseOpnames mengambil sesi stock opname di satu gudang (nil = semua gudang)
+func GetWarehouseOpnames(warehouseID *int, status string) ([]StockOpname, error) {
+	return store.Opnames().List(warehouseID, status)
+}
+
+// GetOpname mengambil sesi stock opname beserta item-nya; user gudang hanya boleh melihat
+// sesi di gudangnya
+func GetOpname(user *User, id int) (*StockOpname, error) {
+	o, err := store.Opnames().GetByID(id)
+	if err != nil || checkWarehouseAccess(user, o.WarehouseID) != nil {
+		return nil, fmt.Errorf("stock opname dengan ID %d tidak ditemukan", id)
+	}
+	return o, nil
+}
+
+// CreateOpname membuka sesi stock opname di gudang warehouseID dan mencatat stok sistem semua
+// produk di gudang tersebut (produk induk varian dilewati karena stoknya ada di varian).
+// Satu gudang hanya boleh punya satu sesi yang sedang dihitung.
+func CreateOpname(actor *User, warehouseID int, note string) (*StockOpname, error) {
+	if err := checkWarehouseAccess(actor, warehouseID); err != nil {
+		return nil, err
+	}
+
+	o := &StockOpname{WarehouseID: warehouseID, Status: OpnameOpen, Note: note}
+	if actor != nil {
+		o.UserID, o.CreatedBy = actor.ID, actor.Username
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if _, err := s.Warehouses().GetByID(warehouseID); err != nil {
+			return fmt.Errorf("gudang dengan ID %d tidak ditemukan", warehouseID)
+		}
+		open, err := s.Opnames().List(&warehouseID, OpnameOpen)
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("gudang ini masih punya stock opname OPN-%06d yang belum selesai", open[0].ID)
+		}
+
+		products, err := s.Products().List(&warehouseID)
+		if err != nil {
+			return err
+		}
+		parents := make(map[int]bool)
+		for _, p := range products {
+			if p.ParentID != nil {
+				parents[*p.ParentID] = true
+			}
+		}
+		for _, p := range products {
+			if parents[p.ID] {
+				continue
+			}
+			o.Items = append(o.Items, StockOpnameItem{
+				ProductID:     p.ID,
+				ProductName:   p.DisplayName(),
+				SystemStock:   p.Stock,
+				PurchasePrice: p.PurchasePrice,
+			})
+		}
+		if len(o.Items) == 0 {
+			return errors.New("belum ada produk di gudang ini")
+		}
+
+		if err := s.Opnames().Create(o); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityOpname, o.ID, nil, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// RecordOpnameCounts menyimpan hasil hitung fisik per ID produk. Produk yang tidak disebut
+// tidak berubah, sehingga hitungan bisa diisi bertahap.
+func RecordOpnameCounts(actor *User, id int, counts map[int]int) (*StockOpname, error) {
+	var updated *StockOpname
+	err := store.WithTx(func(s Store) error {
+		o, err := s.Opnames().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("stock opname dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, o.WarehouseID); err != nil {
+			return err
+		}
+		if o.Status != OpnameOpen {
+			return fmt.Errorf("stock opname %d sudah %s", id, OpnameStatusLabel(o.Status))
+		}
+
+		lines := make(map[int]int, len(o.Items)) // product ID -> index di o.Items
+		for i, item := range o.Items {
+			lines[item.ProductID] = i
+		}
+		before := *o
+		before.Items = append([]StockOpnameItem(nil), o.Items...)
+		for productID, qty := range counts {
+			i, ok := lines[productID]
+			if !ok {
+				return fmt.Errorf("produk dengan ID %d tidak ada di stock opname ini", productID)
+			}
+			if qty < 0 {
+				return fmt.Errorf("jumlah fisik %s tidak boleh negatif", o.Items[i].ProductName)
+			}
+			counted := qty
+			o.Items[i].Counted = &counted
+		}
+
+		if err := s.Opnames().Update(o); err != nil {
+			return err
+		}
+		updated = o
+		return writeAudit(s, actor, AuditUpdate, EntityOpname, o.ID, before, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ApproveOpname menyetujui stock opname dan membukukan selisih setiap produk yang sudah dihitung
+// ke stok gudang dalam satu transaksi database. Selisih dihitung terhadap snapshot, jadi penjualan
+// yang terjadi setelah sesi dibuat tetap terhitung. Produk yang belum dihitung tidak diubah.
+func ApproveOpname(actor *User, id int) (*StockOpname, error) {
+	var approved *StockOpname
+	err := store.WithTx(func(s Store) error {
+		o, err := s.Opnames().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("stock opname dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, o.WarehouseID); err != nil {
+			return err
+		}
+		if o.Status != OpnameOpen {
+			return fmt.Errorf("stock opname %d sudah %s", id, OpnameStatusLabel(o.Status))
+		}
+		if o.Uncounted() == len(o.Items) {
+			return errors.New("belum ada produk yang dihitung")
+		}
+
+		before := *o
+		for _, item := range o.Items {
+			variance := item.Variance()
+			if variance == 0 {
+				continue
+			}
+
+			var stock int
+			if variance > 0 {
+				stock, err = receiveStock(s, item.ProductID, o.WarehouseID, variance)
+			} else {
+				stock, err = s.Products().DecrementStock(item.ProductID, o.WarehouseID, -variance)
+				if err == ErrInsufficientStock {
+					return fmt.Errorf("stok %s saat ini lebih kecil dari selisih %d", item.ProductName, variance)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			reference := fmt.Sprintf("OPN-%06d", o.ID)
+			if err := adjustStockLayers(s, item.ProductID, o.WarehouseID, variance, reference); err != nil {
+				return err
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: o.WarehouseID,
+				Type:        MovementAdjustment,
+				Quantity:    variance,
+				Balance:     stock,
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		o.Status, o.ApprovedAt = OpnameApproved, &now
+		if actor != nil {
+			o.ApprovedByID, o.ApprovedBy = actor.ID, actor.Username
+		}
+		if err := s.Opnames().Update(o); err != nil {
+			return err
+		}
+		approved = o
+		return writeAudit(s, actor, AuditUpdate, EntityOpname, o.ID, before, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approved, nil
+}
+
+// CancelOpname membatalkan (menghapus) stock opname yang belum disetujui
+func CancelOpname(actor *User, id int) error {
+	return store.WithTx(func(s Store) error {
+		o, err := s.Opnames().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("stock opname dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, o.WarehouseID); err != nil {
+			return err
+		}
+		if o.Status != OpnameOpen {
+			return fmt.Errorf("stock opname %d sudah %s, tidak bisa dibatalkan", id, OpnameStatusLabel(o.Status))
+		}
+		if err := s.Opnames().Delete(id); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityOpname, id, o, nil)
+	})
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpnameLifecycle(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirPusat := mustUser(t, "kasir1", "user", &pusat.ID)
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 20, pusat.ID)
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 10, pusat.ID)
+	kaos := mustProduct(t, "Kaos Polos", 30000, 50000, 0, pusat.ID)
+	kaosL, err := CreateVariant(nil, kaos.ID, Product{Variant: "L", Stock: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	teh := mustProduct(t, "Teh Botol", 3000, 5000, 5, pusat.ID)
+
+	if _, err := CreateOpname(kasirCabang, pusat.ID, ""); err == nil {
+		t.Error("a warehouse user cannot count another warehouse")
+	}
+	opn, err := CreateOpname(kasirPusat, pusat.ID, "opname Oktober")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Produk induk varian tidak ikut dihitung, variannya yang dihitung
+	if len(opn.Items) != 4 || opn.Status != OpnameOpen || opn.Uncounted() != 4 {
+		t.Fatalf("opname = %+v", opn)
+	}
+	for _, item := range opn.Items {
+		if item.ProductID == kaos.ID {
+			t.Errorf("parent product should be skipped: %+v", item)
+		}
+	}
+	if _, err := CreateOpname(nil, pusat.ID, ""); err == nil {
+		t.Error("only one open opname per warehouse")
+	}
+	if _, err := ApproveOpname(nil, opn.ID); err == nil {
+		t.Error("an opname without counts cannot be approved")
+	}
+
+	if _, err := RecordOpnameCounts(kasirPusat, opn.ID, map[int]int{mie.ID: -1}); err == nil {
+		t.Error("negative counts must be rejected")
+	}
+	if _, err := RecordOpnameCounts(kasirPusat, opn.ID, map[int]int{9999: 1}); err == nil {
+		t.Error("products outside the opname must be rejected")
+	}
+	if _, err := RecordOpnameCounts(kasirPusat, opn.ID, map[int]int{mie.ID: 17, aqua.ID: 12}); err != nil {
+		t.Fatal(err)
+	}
+	opn, err = RecordOpnameCounts(kasirPusat, opn.ID, map[int]int{kaosL.ID: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opn.Uncounted() != 1 || opn.VarianceValue() != -3*2500+2*2500 {
+		t.Errorf("variance value = %v, uncounted %d", opn.VarianceValue(), opn.Uncounted())
+	}
+
+	// Penjualan setelah snapshot tetap terhitung: selisih dibukukan terhadap stok saat ini
+	mieAtPusat, _ := GetProductInWarehouse(mie.ID, pusat.ID)
+	if _, err := CreateTransaction(kasirPusat, []CartItem{{Product: mieAtPusat, Quantity: 2}}, 10000); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 18 {
+		t.Errorf("counting must not move stock, got %d", p.Stock)
+	}
+
+	approved, err := ApproveOpname(nil, opn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != OpnameApproved || approved.ApprovedAt == nil {
+		t.Errorf("approved = %+v", approved)
+	}
+	want := map[int]int{mie.ID: 15, aqua.ID: 12, kaosL.ID: 4, teh.ID: 5}
+	for id, stock := range want {
+		if p, _ := GetProductInWarehouse(id, pusat.ID); p.Stock != stock {
+			t.Errorf("product %d stock = %d, want %d", id, p.Stock, stock)
+		}
+	}
+	card, _ := GetStockCard(mie.ID, &pusat.ID, time.Now(), time.Now())
+	if m := card.Movements[len(card.Movements)-1]; m.Type != MovementAdjustment || m.Quantity != -3 || m.Balance != 15 || m.Reference != "OPN-000001" {
+		t.Errorf("opname movement = %+v", m)
+	}
+
+	if _, err := RecordOpnameCounts(nil, opn.ID, map[int]int{teh.ID: 5}); err == nil {
+		t.Error("an approved opname cannot be counted again")
+	}
+	if _, err := ApproveOpname(nil, opn.ID); err == nil {
+		t.Error("an opname can only be approved once")
+	}
+	if err := CancelOpname(nil, opn.ID); err == nil {
+		t.Error("an approved opname cannot be cancelled")
+	}
+	if list, _ := GetOpnames(kasirCabang, ""); len(list) != 0 {
+		t.Errorf("other warehouses must not see the opname: %+v", list)
+	}
+	if _, err := GetOpname(kasirCabang, opn.ID); err == nil {
+		t.Error("other warehouses must not open the opname")
+	}
+
+	// Sesi baru bisa dibuka setelah yang lama disetujui, dan dibatalkan tanpa mengubah stok
+	next, err := CreateOpname(kasirPusat, pusat.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Items[0].SystemStock != 15 {
+		t.Errorf("new snapshot = %+v", next.Items[0])
+	}
+	if err := CancelOpname(kasirPusat, next.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := GetOpnames(kasirPusat, ""); len(list) != 1 {
+		t.Errorf("cancelled opname should be gone: %+v", list)
+	}
+}
@@ -1,232 +1,489 @@
 package models
 
 import (
+	"errors"
 	"fmt"
-	"kasir/config"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-// Product model
+// Product model. Produk disimpan sekali di katalog master; stok dan harga bisa berbeda di tiap gudang.
+// Produk yang dibaca per gudang berisi stok dan harga gudang WarehouseID, sedangkan data master
+// (WarehouseID 0) berisi harga master dan stok total semua gudang.
 type Product struct {
 	ID            int
 	Name          string
+	SKU           string  // kode internal toko, unik di seluruh katalog (boleh kosong)
+	Barcode       string  // kode barcode kemasan (EAN/UPC), unik di seluruh katalog (boleh kosong)
 	PurchasePrice float64 // Harga Beli
 	SellingPrice  float64 // Harga Jual
 	Stock         int
-	WarehouseID   int
+	WarehouseID   int    // 0 = data master
+	CategoryID    *int   // nil = tanpa kategori
+	BrandID       *int   // nil = tanpa merek
+	ParentID      *int   // nil = bukan varian; selain itu ID produk induk
+	Variant       string // nama varian, contoh "L, Merah" (kosong untuk produk biasa/induk)
 	CreatedAt     time.Time
 }
 
-// GetAllProducts mengambil semua produk (filter by warehouse jika user biasa)
-func GetAllProducts() ([]Product, error) {
-	var query string
-	var args []interface{}
+// ProductStock adalah stok dan harga satu produk di satu gudang
+type ProductStock struct {
+	ProductID     int
+	WarehouseID   int
+	Stock         int
+	PurchasePrice *float64 // nil = ikut harga master
+	SellingPrice  *float64 // nil = ikut harga master
+	ReorderPoint  int      // stok minimum sebelum perlu dipesan ulang, 0 = tanpa peringatan
+}
 
-	if CurrentUser != nil && !CurrentUser.IsAdmin() && CurrentUser.WarehouseID != nil {
-		query = `
-			SELECT id, name, purchase_price, selling_price, stock, warehouse_id, created_at 
-			FROM products 
-			WHERE warehouse_id = $1
-			ORDER BY id
-		`
-		args = append(args, *CurrentUser.WarehouseID)
-	} else {
-		query = `
-			SELECT id, name, purchase_price, selling_price, stock, warehouse_id, created_at 
-			FROM products 
-			ORDER BY id
-		`
+// productInWarehouse mengembalikan produk master p seperti yang terlihat di gudang st
+func productInWarehouse(p Product, st ProductStock) Product {
+	p.Stock, p.WarehouseID = st.Stock, st.WarehouseID
+	if st.PurchasePrice != nil {
+		p.PurchasePrice = *st.PurchasePrice
 	}
-
-	rows, err := config.DB.Query(query, args...)
-	if err != nil {
-		return nil, err
+	if st.SellingPrice != nil {
+		p.SellingPrice = *st.SellingPrice
 	}
-	defer rows.Close()
+	return p
+}
 
-	var products []Product
-	for rows.Next() {
-		var p Product
-		err := rows.Scan(&p.ID, &p.Name, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID, &p.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, p)
+// ProductFilter adalah filter daftar produk (nilai kosong/nil = tidak difilter)
+type ProductFilter struct {
+	Search      string // nama (sebagian) atau SKU/barcode (persis)
+	WarehouseID *int
+	CategoryID  *int // termasuk semua subkategori
+	BrandID     *int
+
+	categoryIDs []int // CategoryID beserta subkategorinya, diisi oleh GetProducts
+}
+
+// categories mengembalikan ID kategori yang dicari (nil = semua kategori)
+func (f ProductFilter) categories() []int {
+	if f.categoryIDs != nil {
+		return f.categoryIDs
 	}
-	return products, nil
+	if f.CategoryID != nil {
+		return []int{*f.CategoryID}
+	}
+	return nil
+}
+
+// GetAllProducts mengambil produk per gudang (filter by warehouse jika user tidak punya akses semua gudang)
+func GetAllProducts(user *User) ([]Product, error) {
+	return store.Products().List(reportWarehouse(user))
 }
 
 // GetProductsByWarehouse mengambil produk berdasarkan warehouse
 func GetProductsByWarehouse(warehouseID int) ([]Product, error) {
-	rows, err := config.DB.Query(`
-		SELECT id, name, purchase_price, selling_price, stock, warehouse_id, created_at 
-		FROM products 
-		WHERE warehouse_id = $1
-		ORDER BY id
-	`, warehouseID)
+	return store.Products().List(&warehouseID)
+}
+
+// GetProductByID mengambil data master produk (harga master, stok total semua gudang)
+func GetProductByID(id int) (*Product, error) {
+	return store.Products().GetByID(id)
+}
+
+// ErrNotInWarehouse dikembalikan jika produk belum ada di gudang yang diminta
+var ErrNotInWarehouse = errors.New("produk belum ada di gudang ini")
+
+// GetProductInWarehouse mengambil produk dengan stok dan harga di satu gudang
+func GetProductInWarehouse(id, warehouseID int) (*Product, error) {
+	products, err := GetProductWarehouses(id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var products []Product
-	for rows.Next() {
-		var p Product
-		err := rows.Scan(&p.ID, &p.Name, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID, &p.CreatedAt)
-		if err != nil {
-			return nil, err
+	for _, p := range products {
+		if p.WarehouseID == warehouseID {
+			return &p, nil
 		}
-		products = append(products, p)
 	}
-	return products, nil
+	return nil, ErrNotInWarehouse
 }
 
-// GetProductByID mengambil produk berdasarkan ID
-func GetProductByID(id int) (*Product, error) {
-	var p Product
-	err := config.DB.QueryRow(`
-		SELECT id, name, purchase_price, selling_price, stock, warehouse_id, created_at 
-		FROM products 
-		WHERE id = $1
-	`, id).Scan(&p.ID, &p.Name, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID, &p.CreatedAt)
-
+// GetProductWarehouses mengambil produk di setiap gudang tempat produk itu tersedia, urut ID gudang
+func GetProductWarehouses(id int) ([]Product, error) {
+	master, err := store.Products().GetByID(id)
 	if err != nil {
 		return nil, err
 	}
-	return &p, nil
+	stocks, err := store.Products().Stocks(id)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]Product, len(stocks))
+	for i, st := range stocks {
+		products[i] = productInWarehouse(*master, st)
+	}
+	return products, nil
+}
+
+// GetProductStocks mengambil stok dan harga gudang sebuah produk, urut ID gudang
+func GetProductStocks(id int) ([]ProductStock, error) {
+	return store.Products().Stocks(id)
 }
 
-// CreateProduct membuat produk baru
-func CreateProduct(name string, purchasePrice, sellingPrice float64, stock, warehouseID int) (*Product, error) {
-	var p Product
-	err := config.DB.QueryRow(`
-		INSERT INTO products (name, purchase_price, selling_price, stock, warehouse_id) 
-		VALUES ($1, $2, $3, $4, $5) 
-		RETURNING id, name, purchase_price, selling_price, stock, warehouse_id, created_at
-	`, name, purchasePrice, sellingPrice, stock, warehouseID).Scan(
-		&p.ID, &p.Name, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID, &p.CreatedAt)
+// ErrDuplicateCode dikembalikan jika SKU/barcode sudah dipakai produk lain di katalog
+var ErrDuplicateCode = errors.New("SKU atau barcode sudah dipakai produk lain")
 
+// FindProductsByCode mencari produk dengan barcode atau SKU tertentu (warehouseID nil = semua gudang)
+func FindProductsByCode(code string, warehouseID *int) ([]Product, error) {
+	return store.Products().FindByCode(strings.TrimSpace(code), warehouseID)
+}
+
+// ErrInvalidProductRef dikembalikan jika kategori atau merek yang dipilih untuk produk tidak ada
+var ErrInvalidProductRef = errors.New("kategori atau merek tidak ditemukan")
+
+// checkProductRefs memastikan kategori dan merek produk ada
+func checkProductRefs(s Store, p *Product) error {
+	if p.CategoryID != nil {
+		if _, err := s.Categories().GetByID(*p.CategoryID); err != nil {
+			return fmt.Errorf("%w: kategori ID %d", ErrInvalidProductRef, *p.CategoryID)
+		}
+	}
+	if p.BrandID != nil {
+		if _, err := s.Brands().GetByID(*p.BrandID); err != nil {
+			return fmt.Errorf("%w: merek ID %d", ErrInvalidProductRef, *p.BrandID)
+		}
+	}
+	return nil
+}
+
+// CreateProduct membuat produk baru di katalog master dari data p (ID dan CreatedAt diisi otomatis).
+// Jika p.WarehouseID diisi, produk langsung ditambahkan ke gudang tersebut dengan stok p.Stock.
+// Jika p.ParentID diisi, produk dibuat sebagai varian lewat CreateVariant.
+func CreateProduct(actor *User, p Product) (*Product, error) {
+	return createProduct(actor, p, StockMovement{Type: MovementAdjustment, Reference: "stok awal"})
+}
+
+// ImportProduct sama dengan CreateProduct, tapi stok awalnya dicatat sebagai mutasi import dari file
+func ImportProduct(actor *User, p Product, file string) (*Product, error) {
+	return createProduct(actor, p, StockMovement{Type: MovementImport, Reference: filepath.Base(file)})
+}
+
+// createProduct membuat produk baru; stok awal dicatat di buku mutasi dengan jenis dan referensi movement
+func createProduct(actor *User, p Product, movement StockMovement) (*Product, error) {
+	if p.ParentID != nil {
+		return CreateVariant(actor, *p.ParentID, p)
+	}
+	p.Variant = ""
+	p.SKU, p.Barcode = strings.TrimSpace(p.SKU), strings.TrimSpace(p.Barcode)
+	if p.WarehouseID == 0 {
+		p.Stock = 0
+	}
+	if p.Stock < 0 {
+		return nil, errors.New("stok tidak boleh negatif")
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if err := checkProductRefs(s, &p); err != nil {
+			return err
+		}
+		if err := s.Products().Create(&p); err != nil {
+			if err == ErrDuplicate {
+				return ErrDuplicateCode
+			}
+			return err
+		}
+		if p.WarehouseID != 0 {
+			if err := addToWarehouse(s, p.ID, p.WarehouseID, p.Stock); err != nil {
+				return err
+			}
+			if err := adjustStockLayers(s, p.ID, p.WarehouseID, p.Stock, movement.Reference); err != nil {
+				return err
+			}
+			movement.ProductID, movement.WarehouseID = p.ID, p.WarehouseID
+			movement.Quantity, movement.Balance = p.Stock, p.Stock
+			if err := writeMovement(s, actor, movement); err != nil {
+				return err
+			}
+		}
+		return writeAudit(s, actor, AuditCreate, EntityProduct, p.ID, nil, p)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &p, nil
 }
 
-// UpdateProduct mengupdate produk
-func UpdateProduct(id int, name string, purchasePrice, sellingPrice float64, stock int) error {
-	result, err := config.DB.Exec(`
-		UPDATE products 
-		SET name = $1, purchase_price = $2, selling_price = $3, stock = $4 
-		WHERE id = $5
-	`, name, purchasePrice, sellingPrice, stock, id)
+// UpdateProduct mengupdate data master produk p.ID, sehingga berlaku di semua gudang. Stok, gudang,
+// induk, dan tanggal dibuat tidak ikut berubah; stok dan harga per gudang diatur lewat SetProductStock.
+// Varian selalu mengikuti nama, kategori, dan merek induknya; perubahan di induk disalin ke variannya.
+func UpdateProduct(actor *User, p Product) error {
+	return store.WithTx(func(s Store) error {
+		return updateProduct(s, actor, p)
+	})
+}
 
+// UpdateProductWithStock mengupdate data master produk dan stok satu gudang dalam satu transaksi,
+// sehingga keduanya tersimpan bersama atau tidak sama sekali. p atau st yang nil tidak diubah.
+func UpdateProductWithStock(actor *User, p *Product, st *ProductStock) error {
+	return store.WithTx(func(s Store) error {
+		if p != nil {
+			if err := updateProduct(s, actor, *p); err != nil {
+				return err
+			}
+		}
+		if st != nil {
+			return saveProductStock(s, actor, *st, StockMovement{Type: MovementAdjustment})
+		}
+		return nil
+	})
+}
+
+func updateProduct(s Store, actor *User, p Product) error {
+	id := p.ID
+	before, err := s.Products().GetByID(id)
 	if err != nil {
-		return err
+		return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
+	after := p
+	after.Stock, after.WarehouseID = before.Stock, before.WarehouseID
+	after.CreatedAt, after.ParentID = before.CreatedAt, before.ParentID
+	after.SKU, after.Barcode = strings.TrimSpace(p.SKU), strings.TrimSpace(p.Barcode)
+	after.Variant = strings.TrimSpace(p.Variant)
+
+	var variants []Product
+	if after.IsVariant() {
+		after.Name, after.CategoryID, after.BrandID = before.Name, before.CategoryID, before.BrandID
+		if after.Variant == "" {
+			return errors.New("nama varian tidak boleh kosong")
+		}
+		if err := checkVariantName(s, *after.ParentID, id, after.Variant); err != nil {
+			return err
+		}
+	} else {
+		after.Variant = ""
+		if variants, err = s.Products().Variants(id); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	if err := checkProductRefs(s, &after); err != nil {
+		return err
+	}
+	if err := s.Products().Update(&after); err != nil {
+		if err == ErrNotFound {
+			return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
+		}
+		if err == ErrDuplicate {
+			return ErrDuplicateCode
+		}
+		return err
+	}
+	if err := syncVariants(s, &after, variants); err != nil {
+		return err
+	}
+	return writeAudit(s, actor, AuditUpdate, EntityProduct, id, before, after)
 }
 
-// DeleteProduct menghapus produk
-func DeleteProduct(id int) error {
-	result, err := config.DB.Exec(`DELETE FROM products WHERE id = $1`, id)
+// SetProductStock menyimpan stok dan harga gudang st.WarehouseID untuk produk st.ProductID.
+// Produk yang belum ada di gudang tersebut ditambahkan; harga nil berarti mengikuti harga master.
+// Selisih stok dicatat di buku mutasi sebagai penyesuaian.
+func SetProductStock(actor *User, st ProductStock) error {
+	return setProductStock(actor, st, StockMovement{Type: MovementAdjustment})
+}
+
+// ImportProductStock sama dengan SetProductStock, tapi selisih stok dicatat sebagai mutasi import dari file
+func ImportProductStock(actor *User, st ProductStock, file string) error {
+	return setProductStock(actor, st, StockMovement{Type: MovementImport, Reference: filepath.Base(file)})
+}
+
+// setProductStock menyimpan stok gudang; selisihnya dicatat dengan jenis dan referensi movement
+func setProductStock(actor *User, st ProductStock, movement StockMovement) error {
+	return store.WithTx(func(s Store) error {
+		return saveProductStock(s, actor, st, movement)
+	})
+}
+
+func saveProductStock(s Store, actor *User, st ProductStock, movement StockMovement) error {
+	if st.Stock < 0 {
+		return errors.New("stok tidak boleh negatif")
+	}
+	if (st.PurchasePrice != nil && *st.PurchasePrice < 0) || (st.SellingPrice != nil && *st.SellingPrice < 0) {
+		return errors.New("harga tidak boleh negatif")
+	}
+	if _, err := s.Products().GetByID(st.ProductID); err != nil {
+		return fmt.Errorf("produk dengan ID %d tidak ditemukan", st.ProductID)
+	}
+	if _, err := s.Warehouses().GetByID(st.WarehouseID); err != nil {
+		return fmt.Errorf("gudang dengan ID %d tidak ditemukan", st.WarehouseID)
+	}
+	if st.Stock != 0 {
+		variants, err := s.Products().Variants(st.ProductID)
+		if err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			return fmt.Errorf("%w: stok diatur di tiap varian", ErrHasVariants)
+		}
+	}
+
+	current, err := findStock(s, st.ProductID, st.WarehouseID)
 	if err != nil {
 		return err
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
+	var before interface{}
+	movement.ProductID, movement.WarehouseID = st.ProductID, st.WarehouseID
+	movement.Quantity, movement.Balance = st.Stock, st.Stock
+	if current != nil {
+		before = current
+		movement.Quantity -= current.Stock
 	}
-	return nil
+	if err := s.Products().SaveStock(&st); err != nil {
+		return err
+	}
+	if err := adjustStockLayers(s, st.ProductID, st.WarehouseID, movement.Quantity, movement.Reference); err != nil {
+		return err
+	}
+	if err := writeMovement(s, actor, movement); err != nil {
+		return err
+	}
+	return writeAudit(s, actor, AuditUpdate, EntityProduct, st.ProductID, before, st)
 }
 
-// UpdateStock mengupdate stok produk
-func UpdateStock(id int, quantity int) error {
-	result, err := config.DB.Exec(`
-		UPDATE products 
-		SET stock = stock - $1 
-		WHERE id = $2 AND stock >= $1
-	`, quantity, id)
+// findStock mengambil stok produk di satu gudang (nil jika produk belum ada di gudang tersebut)
+func findStock(s Store, productID, warehouseID int) (*ProductStock, error) {
+	stocks, err := s.Products().Stocks(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stocks {
+		if st.WarehouseID == warehouseID {
+			return &st, nil
+		}
+	}
+	return nil, nil
+}
 
+// addToWarehouse menambahkan produk ke gudang dengan harga master jika belum ada di sana.
+// Stok yang sudah ada di gudang tersebut diganti dengan stock.
+func addToWarehouse(s Store, productID, warehouseID, stock int) error {
+	current, err := findStock(s, productID, warehouseID)
 	if err != nil {
 		return err
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("stok tidak mencukupi atau produk tidak ditemukan")
+	st := ProductStock{ProductID: productID, WarehouseID: warehouseID, Stock: stock}
+	if current != nil {
+		st.PurchasePrice, st.SellingPrice = current.PurchasePrice, current.SellingPrice
 	}
-	return nil
+	return s.Products().SaveStock(&st)
 }
 
+// RemoveProductFromWarehouse mengeluarkan produk dari satu gudang; data master dan stok
+// di gudang lain tidak berubah
+func RemoveProductFromWarehouse(actor *User, productID, warehouseID int) error {
+	return store.WithTx(func(s Store) error {
+		before, err := findStock(s, productID, warehouseID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotInWarehouse
+		}
 
-// GetProfit menghitung profit per item
-func (p *Product) GetProfit() float64 {
-	return p.SellingPrice - p.PurchasePrice
+		variants, err := s.Products().Variants(productID)
+		if err != nil {
+			return err
+		}
+		for _, v := range variants {
+			st, err := findStock(s, v.ID, warehouseID)
+			if err != nil {
+				return err
+			}
+			if st != nil {
+				return errors.New("varian produk ini masih ada di gudang ini, hapus variannya terlebih dahulu")
+			}
+		}
+
+		if err := adjustStockLayers(s, productID, warehouseID, -before.Stock, ""); err != nil {
+			return err
+		}
+		if err := s.Products().DeleteStock(productID, warehouseID); err != nil {
+			return err
+		}
+		err = writeMovement(s, actor, StockMovement{
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			Type:        MovementAdjustment,
+			Quantity:    -before.Stock,
+			Reference:   "dikeluarkan dari gudang",
+		})
+		if err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityProduct, productID, before, nil)
+	})
 }
 
-// GetProducts mengambil produk dengan pagination dan search
-func GetProducts(page, limit int, search string, warehouseID *int) ([]Product, int, error) {
-	offset := (page - 1) * limit
-	var query string
-	var countQuery string
-	var args []interface{}
-	
-	baseQuery := "FROM products WHERE 1=1"
-	
-	// Add warehouse filter
-	argCount := 1
-	if warehouseID != nil {
-		baseQuery += fmt.Sprintf(" AND warehouse_id = $%d", argCount)
-		args = append(args, *warehouseID)
-		argCount++
-	}
-	
-	// Add search filter
-	if search != "" {
-		baseQuery += fmt.Sprintf(" AND name ILIKE $%d", argCount)
-		args = append(args, "%"+search+"%")
-		argCount++
-	}
-
-	// Count total
-	countQuery = "SELECT COUNT(*) " + baseQuery
-	var total int
-	err := config.DB.QueryRow(countQuery, args...).Scan(&total)
+// DeleteProduct menghapus produk dari katalog master beserta stoknya di semua gudang
+func DeleteProduct(actor *User, id int) error {
+	before, err := GetProductByID(id)
 	if err != nil {
-		return nil, 0, err
+		return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
 	}
 
-	// Get data
-	query = fmt.Sprintf(`
-		SELECT id, name, purchase_price, selling_price, stock, warehouse_id, created_at 
-		%s 
-		ORDER BY id 
-		LIMIT $%d OFFSET $%d
-	`, baseQuery, argCount, argCount+1)
-	
-	args = append(args, limit, offset)
+	return store.WithTx(func(s Store) error {
+		variants, err := s.Products().Variants(id)
+		if err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			return fmt.Errorf("produk masih punya %d varian, hapus variannya terlebih dahulu", len(variants))
+		}
+		if err := s.Products().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("produk dengan ID %d tidak ditemukan", id)
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityProduct, id, before, nil)
+	})
+}
 
-	rows, err := config.DB.Query(query, args...)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer rows.Close()
+// UpdateStock mengurangi stok produk di satu gudang
+func UpdateStock(actor *User, id, warehouseID, quantity int) error {
+	return store.WithTx(func(s Store) error {
+		stock, err := s.Products().DecrementStock(id, warehouseID, quantity)
+		if err != nil {
+			return fmt.Errorf("stok tidak mencukupi atau produk tidak ditemukan")
+		}
+		if err := adjustStockLayers(s, id, warehouseID, -quantity, ""); err != nil {
+			return err
+		}
+
+		err = writeMovement(s, actor, StockMovement{
+			ProductID:   id,
+			WarehouseID: warehouseID,
+			Type:        MovementAdjustment,
+			Quantity:    -quantity,
+			Balance:     stock,
+		})
+		if err != nil {
+			return err
+		}
+
+		before := map[string]int{"warehouse_id": warehouseID, "stock": stock + quantity}
+		after := map[string]int{"warehouse_id": warehouseID, "stock": stock}
+		return writeAudit(s, actor, AuditUpdate, EntityProduct, id, before, after)
+	})
+}
+
+// GetProfit menghitung profit per item
+func (p *Product) GetProfit() float64 {
+	return p.SellingPrice - p.PurchasePrice
+}
 
-	var products []Product
-	for rows.Next() {
-		var p Product
-		err := rows.Scan(&p.ID, &p.Name, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID, &p.CreatedAt)
+// GetProducts mengambil produk dengan pagination sesuai filter
+func GetProducts(page, limit int, filter ProductFilter) ([]Product, int, error) {
+	if filter.CategoryID != nil {
+		categories, err := store.Categories().List()
 		if err != nil {
 			return nil, 0, err
 		}
-		products = append(products, p)
+		filter.categoryIDs = categoryWithDescendants(categories, *filter.CategoryID)
 	}
-	
-	return products, total, nil
+	return store.Products().Search(filter, page, limit)
 }
@@ -0,0 +1,170 @@
+package models
+
+import "testing"
+
+func TestGetProductsPaginationAndSearch(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+
+	names := []string{"Indomie Goreng", "Indomie Soto", "Aqua 600ml", "Teh Botol Sosro", "Roti Tawar"}
+	for _, name := range names {
+		mustProduct(t, name, 1000, 2000, 10, pusat.ID)
+	}
+	mustProduct(t, "Indomie Goreng", 1000, 2000, 10, cabang.ID)
+
+	tests := []struct {
+		name      string
+		page      int
+		limit     int
+		search    string
+		warehouse *int
+		wantNames []string
+		wantTotal int
+	}{
+		{"first page", 1, 2, "", nil, []string{"Indomie Goreng", "Indomie Soto"}, 6},
+		{"last partial page", 3, 2, "", nil, []string{"Roti Tawar", "Indomie Goreng"}, 6},
+		{"page past end", 4, 2, "", nil, nil, 6},
+		{"search is case-insensitive", 1, 10, "INDOMIE", nil, []string{"Indomie Goreng", "Indomie Soto", "Indomie Goreng"}, 3},
+		{"search within warehouse", 1, 10, "indomie", &pusat.ID, []string{"Indomie Goreng", "Indomie Soto"}, 2},
+		{"warehouse only", 1, 10, "", &cabang.ID, []string{"Indomie Goreng"}, 1},
+		{"no match", 1, 10, "kopi", nil, nil, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			products, total, err := GetProducts(tc.page, tc.limit, ProductFilter{Search: tc.search, WarehouseID: tc.warehouse})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tc.wantTotal {
+				t.Errorf("total = %d, want %d", total, tc.wantTotal)
+			}
+			var got []string
+			for _, p := range products {
+				got = append(got, p.Name)
+			}
+			if len(got) != len(tc.wantNames) {
+				t.Fatalf("names = %v, want %v", got, tc.wantNames)
+			}
+			for i := range got {
+				if got[i] != tc.wantNames[i] {
+					t.Errorf("names = %v, want %v", got, tc.wantNames)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateStockRejectsOversell(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	p := mustProduct(t, "Aqua 600ml", 2500, 4000, 3, w.ID)
+
+	if err := UpdateStock(nil, p.ID, w.ID, 2); err != nil {
+		t.Fatalf("UpdateStock: %v", err)
+	}
+	if err := UpdateStock(nil, p.ID, w.ID, 2); err == nil {
+		t.Fatal("expected error when stock would go negative")
+	}
+
+	got, _ := GetProductByID(p.ID)
+	if got.Stock != 1 {
+		t.Errorf("stock = %d, want 1", got.Stock)
+	}
+}
+
+func TestProductCodesUnique(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+
+	mie, err := CreateProduct(nil, Product{Name: "Indomie Goreng", SKU: "MIE-01", Barcode: "8991002101234", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateProduct(nil, Product{Name: "Indomie Goreng", SKU: "MIE-01", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: cabang.ID}); err != ErrDuplicateCode {
+		t.Errorf("same sku in another warehouse: err = %v, want ErrDuplicateCode", err)
+	}
+	if _, err := CreateProduct(nil, Product{Name: "Mie Lain", SKU: "MIE-02", Barcode: "8991002101234", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID}); err != ErrDuplicateCode {
+		t.Errorf("duplicate barcode: err = %v, want ErrDuplicateCode", err)
+	}
+	aqua, _ := CreateProduct(nil, Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 10, WarehouseID: pusat.ID})
+	if _, err := CreateProduct(nil, Product{Name: "Teh Botol", PurchasePrice: 3000, SellingPrice: 5000, Stock: 10, WarehouseID: pusat.ID}); err != nil {
+		t.Errorf("empty codes must not collide: %v", err)
+	}
+	aqua.SKU = "MIE-01"
+	if err := UpdateProduct(nil, *aqua); err != ErrDuplicateCode {
+		t.Errorf("update to duplicate sku: err = %v, want ErrDuplicateCode", err)
+	}
+
+	found, err := FindProductsByCode(" 8991002101234 ", &pusat.ID)
+	if err != nil || len(found) != 1 || found[0].ID != mie.ID {
+		t.Errorf("find by barcode in warehouse = %+v, %v", found, err)
+	}
+	if found, _ := FindProductsByCode("MIE-01", &cabang.ID); len(found) != 0 {
+		t.Errorf("product not stocked in cabang should not be found there: %+v", found)
+	}
+
+	products, total, err := GetProducts(1, 10, ProductFilter{Search: "8991002101234", WarehouseID: &pusat.ID})
+	if err != nil || total != 1 || products[0].ID != mie.ID {
+		t.Errorf("search by barcode = %+v, total %d, %v", products, total, err)
+	}
+}
+
+func TestMasterCatalogStockPerWarehouse(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	mie, _ := CreateProduct(nil, Product{Name: "Indomie Goreng", SKU: "MIE-01", PurchasePrice: 2500, SellingPrice: 3500, Stock: 10, WarehouseID: pusat.ID})
+
+	// Produk yang sama masuk ke cabang dengan harga jual sendiri
+	price := 4000.0
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: cabang.ID, Stock: 4, SellingPrice: &price}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: cabang.ID, Stock: -1}); err == nil {
+		t.Error("negative stock should be rejected")
+	}
+
+	master, _ := GetProductByID(mie.ID)
+	if master.Stock != 14 || master.WarehouseID != 0 || master.SellingPrice != 3500 {
+		t.Errorf("master = %+v, want total stock 14 at master price", master)
+	}
+	inCabang, err := GetProductInWarehouse(mie.ID, cabang.ID)
+	if err != nil || inCabang.Stock != 4 || inCabang.SellingPrice != 4000 || inCabang.PurchasePrice != 2500 {
+		t.Errorf("in cabang = %+v, %v", inCabang, err)
+	}
+	if found, _ := FindProductsByCode("MIE-01", nil); len(found) != 2 {
+		t.Errorf("find by sku across warehouses = %d rows, want 2", len(found))
+	}
+
+	// Ganti nama dan harga master sekali, berlaku di semua gudang kecuali harga yang di-override
+	master.Name, master.SellingPrice = "Indomie Goreng Original", 3700
+	if err := UpdateProduct(nil, *master); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := GetProductWarehouses(mie.ID)
+	if len(rows) != 2 || rows[0].Name != "Indomie Goreng Original" || rows[0].SellingPrice != 3700 ||
+		rows[1].Name != "Indomie Goreng Original" || rows[1].SellingPrice != 4000 || rows[1].Stock != 4 {
+		t.Errorf("per warehouse after rename = %+v", rows)
+	}
+
+	if err := RemoveProductFromWarehouse(nil, mie.ID, cabang.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetProductInWarehouse(mie.ID, cabang.ID); err != ErrNotInWarehouse {
+		t.Errorf("after remove: err = %v, want ErrNotInWarehouse", err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p == nil || p.Stock != 10 {
+		t.Errorf("pusat stock should be untouched: %+v", p)
+	}
+
+	if err := DeleteProduct(nil, mie.ID); err != nil {
+		t.Fatal(err)
+	}
+	if products, _ := GetProductsByWarehouse(pusat.ID); len(products) != 0 {
+		t.Errorf("deleting the master should remove it from every warehouse: %+v", products)
+	}
+}
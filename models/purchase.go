@@ -0,0 +1,442 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status purchase order
+const (
+	PurchaseOpen     = "open"     // sudah dipesan, belum ada barang yang diterima
+	PurchasePartial  = "partial"  // sebagian barang sudah diterima
+	PurchaseReceived = "received" // semua barang sudah diterima
+)
+
+// PurchaseOrder adalah pesanan barang ke supplier untuk satu gudang. Stok belum berubah saat
+// PO dibuat; stok gudang bertambah setiap kali barang diterima lewat GoodsReceipt.
+type PurchaseOrder struct {
+	ID           int
+	SupplierID   int
+	SupplierName string
+	WarehouseID  int // gudang penerima barang
+	Status       string
+	Note         string
+	UserID       int    // pembuat PO
+	CreatedBy    string // username pembuat PO
+	CreatedAt    time.Time
+	Items        []PurchaseOrderItem
+}
+
+// PurchaseOrderItem adalah satu produk yang dipesan beserta harga beli yang disepakati
+type PurchaseOrderItem struct {
+	ID          int
+	OrderID     int
+	ProductID   int
+	ProductName string
+	Quantity    int     // jumlah dipesan
+	Price       float64 // harga beli per unit
+	Received    int     // jumlah yang sudah diterima dari semua penerimaan
+}
+
+// Outstanding mengembalikan jumlah yang belum diterima
+func (i PurchaseOrderItem) Outstanding() int {
+	return i.Quantity - i.Received
+}
+
+// Total mengembalikan nilai pesanan seluruh PO
+func (o PurchaseOrder) Total() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += float64(item.Quantity) * item.Price
+	}
+	return total
+}
+
+// OutstandingValue mengembalikan nilai barang yang belum diterima
+func (o PurchaseOrder) OutstandingValue() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += float64(item.Outstanding()) * item.Price
+	}
+	return total
+}
+
+// GoodsReceipt adalah satu kali penerimaan barang dari supplier atas sebuah purchase order
+type GoodsReceipt struct {
+	ID           int
+	OrderID      int
+	SupplierID   int
+	SupplierName string
+	WarehouseID  int
+	Note         string
+	UserID       int    // penerima barang
+	ReceivedBy   string // username penerima barang
+	Total        float64
+	CreatedAt    time.Time
+	Items        []GoodsReceiptItem
+}
+
+// GoodsReceiptItem adalah jumlah dan harga beli satu produk yang diterima
+type GoodsReceiptItem struct {
+	ID          int
+	ReceiptID   int
+	ProductID   int
+	ProductName string
+	Quantity    int
+	Price       float64    // harga beli per unit; 0 saat penerimaan = ikut harga di PO
+	BatchNo     string     // opsional; kosong = nomor penerimaan (GR-000001)
+	ExpiryDate  *time.Time // diisi jika barang dicatat sebagai batch dengan tanggal kedaluwarsa
+}
+
+// PurchaseFilter berisi filter daftar purchase order (nilai kosong/nil = tidak difilter)
+type PurchaseFilter struct {
+	WarehouseID *int
+	SupplierID  int
+	Status      string
+	Outstanding bool // hanya PO yang masih menunggu barang (open dan partial)
+}
+
+// ReceiptFilter berisi filter riwayat penerimaan barang (nilai kosong/nil = tidak difilter)
+type ReceiptFilter struct {
+	SupplierID  int
+	WarehouseID *int
+	From        *time.Time // mulai dari waktu ini
+	To          *time.Time // sebelum waktu ini
+}
+
+// PurchaseStatusLabel mengembalikan nama status purchase order untuk ditampilkan
+func PurchaseStatusLabel(status string) string {
+	switch status {
+	case PurchaseOpen:
+		return "Menunggu Barang"
+	case PurchasePartial:
+		return "Diterima Sebagian"
+	case PurchaseReceived:
+		return "Selesai"
+	}
+	return status
+}
+
+// GetPurchaseOrders mengambil purchase order sesuai filter; user gudang hanya melihat PO
+// untuk gudangnya. Item tidak ikut diambil.
+func GetPurchaseOrders(user *User, filter PurchaseFilter) ([]PurchaseOrder, error) {
+	if scope := reportWarehouse(user); scope != nil {
+		filter.WarehouseID = scope
+	}
+	return store.Purchases().List(filter)
+}
+
+// GetPurchaseOrder mengambil purchase order beserta item-nya; user gudang hanya boleh melihat
+// PO untuk gudangnya
+func GetPurchaseOrder(user *User, id int) (*PurchaseOrder, error) {
+	o, err := store.Purchases().GetByID(id)
+	if err != nil || checkWarehouseAccess(user, o.WarehouseID) != nil {
+		return nil, fmt.Errorf("purchase order dengan ID %d tidak ditemukan", id)
+	}
+	return o, nil
+}
+
+// CreatePurchaseOrder membuat purchase order ke supplier untuk gudang warehouseID. Produk yang sama
+// digabung menjadi satu baris (harga baris terakhir dipakai); stok belum berubah sampai barang diterima.
+func CreatePurchaseOrder(actor *User, supplierID, warehouseID int, note string, items []PurchaseOrderItem) (*PurchaseOrder, error) {
+	if len(items) == 0 {
+		return nil, errors.New("purchase order harus berisi minimal satu produk")
+	}
+	if err := checkWarehouseAccess(actor, warehouseID); err != nil {
+		return nil, err
+	}
+
+	o := &PurchaseOrder{SupplierID: supplierID, WarehouseID: warehouseID, Status: PurchaseOpen, Note: note}
+	if actor != nil {
+		o.UserID, o.CreatedBy = actor.ID, actor.Username
+	}
+
+	err := store.WithTx(func(s Store) error {
+		sup, err := s.Suppliers().GetByID(supplierID)
+		if err != nil {
+			return fmt.Errorf("supplier dengan ID %d tidak ditemukan", supplierID)
+		}
+		o.SupplierName = sup.Name
+		if _, err := s.Warehouses().GetByID(warehouseID); err != nil {
+			return fmt.Errorf("gudang dengan ID %d tidak ditemukan", warehouseID)
+		}
+
+		lines := make(map[int]int) // product ID -> index di o.Items
+		for _, item := range items {
+			if item.Quantity <= 0 {
+				return errors.New("jumlah pesanan harus lebih dari 0")
+			}
+			if item.Price < 0 {
+				return errors.New("harga beli tidak boleh negatif")
+			}
+			if i, ok := lines[item.ProductID]; ok {
+				o.Items[i].Quantity += item.Quantity
+				o.Items[i].Price = item.Price
+				continue
+			}
+
+			p, err := s.Products().GetByID(item.ProductID)
+			if err != nil {
+				return fmt.Errorf("produk dengan ID %d tidak ditemukan", item.ProductID)
+			}
+			variants, err := s.Products().Variants(p.ID)
+			if err != nil {
+				return err
+			}
+			if len(variants) > 0 {
+				return fmt.Errorf("%s: %w", p.Name, ErrHasVariants)
+			}
+
+			lines[p.ID] = len(o.Items)
+			o.Items = append(o.Items, PurchaseOrderItem{ProductID: p.ID, ProductName: p.DisplayName(), Quantity: item.Quantity, Price: item.Price})
+		}
+
+		if err := s.Purchases().Create(o); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityPurchase, o.ID, nil, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ReceivePurchaseOrder mencatat penerimaan barang atas purchase order id. items berisi jumlah yang
+// datang per produk (Price 0 = harga PO); tanpa items semua sisa pesanan dianggap datang. Stok gudang
+// penerima bertambah, harga beli gudang diganti harga penerimaan, dan semuanya disimpan dalam satu
+// transaksi database. Item dengan ExpiryDate dicatat sebagai batch. PO bisa diterima bertahap sampai
+// semua barang datang.
+func ReceivePurchaseOrder(actor *User, id int, items []GoodsReceiptItem, note string) (*GoodsReceipt, error) {
+	var receipt *GoodsReceipt
+	err := store.WithTx(func(s Store) error {
+		o, err := s.Purchases().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("purchase order dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, o.WarehouseID); err != nil {
+			return err
+		}
+		if o.Status == PurchaseReceived {
+			return fmt.Errorf("purchase order %d sudah diterima lengkap", id)
+		}
+
+		lines := make(map[int]int, len(o.Items)) // product ID -> index di o.Items
+		for i, item := range o.Items {
+			lines[item.ProductID] = i
+		}
+		if len(items) == 0 {
+			for _, item := range o.Items {
+				if item.Outstanding() > 0 {
+					items = append(items, GoodsReceiptItem{ProductID: item.ProductID, Quantity: item.Outstanding()})
+				}
+			}
+		}
+
+		before := *o
+		before.Items = append([]PurchaseOrderItem(nil), o.Items...)
+		r := &GoodsReceipt{OrderID: o.ID, SupplierID: o.SupplierID, SupplierName: o.SupplierName, WarehouseID: o.WarehouseID, Note: note}
+		if actor != nil {
+			r.UserID, r.ReceivedBy = actor.ID, actor.Username
+		}
+		for _, in := range items {
+			i, ok := lines[in.ProductID]
+			if !ok {
+				return fmt.Errorf("produk dengan ID %d tidak ada di purchase order ini", in.ProductID)
+			}
+			line := &o.Items[i]
+			if in.Quantity <= 0 || in.Quantity > line.Outstanding() {
+				return fmt.Errorf("jumlah diterima %s harus antara 1 dan %d", line.ProductName, line.Outstanding())
+			}
+			if in.Price < 0 {
+				return errors.New("harga beli tidak boleh negatif")
+			}
+			if in.Price == 0 {
+				in.Price = line.Price
+			}
+			line.Received += in.Quantity
+			r.Items = append(r.Items, GoodsReceiptItem{ProductID: line.ProductID, ProductName: line.ProductName, Quantity: in.Quantity,
+				Price: in.Price, BatchNo: in.BatchNo, ExpiryDate: in.ExpiryDate})
+			r.Total += float64(in.Quantity) * in.Price
+		}
+		if len(r.Items) == 0 {
+			return errors.New("tidak ada barang yang diterima")
+		}
+		if err := s.Purchases().CreateReceipt(r); err != nil {
+			return err
+		}
+
+		for _, item := range r.Items {
+			stock, err := receiveStock(s, item.ProductID, o.WarehouseID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			if err := setPurchasePrice(s, item.ProductID, o.WarehouseID, item.Price); err != nil {
+				return err
+			}
+			reference := fmt.Sprintf("GR-%06d", r.ID)
+			if err := addCostLayer(s, item.ProductID, o.WarehouseID, item.Quantity, item.Price, reference); err != nil {
+				return err
+			}
+			if item.ExpiryDate != nil {
+				batchNo := item.BatchNo
+				if batchNo == "" {
+					batchNo = reference
+				}
+				if err := addBatch(s, item.ProductID, o.WarehouseID, batchNo, *item.ExpiryDate, item.Quantity, reference); err != nil {
+					return err
+				}
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: o.WarehouseID,
+				Type:        MovementPurchase,
+				Quantity:    item.Quantity,
+				Balance:     stock,
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		o.Status = PurchaseReceived
+		for _, item := range o.Items {
+			if item.Outstanding() > 0 {
+				o.Status = PurchasePartial
+			}
+		}
+		if err := s.Purchases().Update(o); err != nil {
+			return err
+		}
+		receipt = r
+		return writeAudit(s, actor, AuditUpdate, EntityPurchase, o.ID, before, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// setPurchasePrice mengganti harga beli produk di satu gudang. Harga yang sama dengan harga master
+// disimpan sebagai "ikut harga master".
+func setPurchasePrice(s Store, productID, warehouseID int, price float64) error {
+	st, err := findStock(s, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	master, err := s.Products().GetByID(productID)
+	if err != nil {
+		return err
+	}
+	st.PurchasePrice = &price
+	if price == master.PurchasePrice {
+		st.PurchasePrice = nil
+	}
+	return s.Products().SaveStock(st)
+}
+
+// CancelPurchaseOrder membatalkan (menghapus) purchase order yang belum menerima barang sama sekali
+func CancelPurchaseOrder(actor *User, id int) error {
+	return store.WithTx(func(s Store) error {
+		o, err := s.Purchases().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("purchase order dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, o.WarehouseID); err != nil {
+			return err
+		}
+		if o.Status != PurchaseOpen {
+			return fmt.Errorf("purchase order %d sudah %s, tidak bisa dibatalkan", id, PurchaseStatusLabel(o.Status))
+		}
+		if err := s.Purchases().Delete(id); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityPurchase, id, o, nil)
+	})
+}
+
+// OutstandingPurchase adalah satu baris PO yang barangnya belum diterima lengkap
+type OutstandingPurchase struct {
+	OrderID      int
+	SupplierName string
+	WarehouseID  int
+	CreatedAt    time.Time
+	ProductID    int
+	ProductName  string
+	Ordered      int
+	Received     int
+	Outstanding  int
+	Value        float64 // nilai barang yang belum diterima dengan harga PO
+}
+
+// GetOutstandingPurchases mengambil semua baris PO yang barangnya belum datang di gudang warehouseID
+// (nil = semua gudang), PO terlama dulu
+func GetOutstandingPurchases(warehouseID *int) ([]OutstandingPurchase, error) {
+	orders, err := store.Purchases().List(PurchaseFilter{WarehouseID: warehouseID, Outstanding: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var report []OutstandingPurchase
+	for i := len(orders) - 1; i >= 0; i-- {
+		o, err := store.Purchases().GetByID(orders[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range o.Items {
+			if item.Outstanding() <= 0 {
+				continue
+			}
+			report = append(report, OutstandingPurchase{
+				OrderID:      o.ID,
+				SupplierName: o.SupplierName,
+				WarehouseID:  o.WarehouseID,
+				CreatedAt:    o.CreatedAt,
+				ProductID:    item.ProductID,
+				ProductName:  item.ProductName,
+				Ordered:      item.Quantity,
+				Received:     item.Received,
+				Outstanding:  item.Outstanding(),
+				Value:        float64(item.Outstanding()) * item.Price,
+			})
+		}
+	}
+	return report, nil
+}
+
+// SupplierPurchases adalah riwayat pembelian dari satu supplier pada rentang tanggal
+type SupplierPurchases struct {
+	Supplier    *Supplier
+	WarehouseID *int
+	From        time.Time
+	To          time.Time // tanggal terakhir (termasuk)
+	Receipts    []GoodsReceipt
+	Total       float64
+}
+
+// GetSupplierPurchases menyusun riwayat penerimaan barang dari supplier supplierID dari tanggal from
+// sampai to (termasuk) di satu gudang (nil = semua gudang), terbaru dulu
+func GetSupplierPurchases(supplierID int, warehouseID *int, from, to time.Time) (*SupplierPurchases, error) {
+	from, _ = dayRange(from)
+	_, end := dayRange(to)
+	if !end.After(from) {
+		return nil, errors.New("tanggal akhir tidak boleh sebelum tanggal awal")
+	}
+
+	sup, err := store.Suppliers().GetByID(supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("supplier dengan ID %d tidak ditemukan", supplierID)
+	}
+	receipts, err := store.Purchases().Receipts(ReceiptFilter{SupplierID: supplierID, WarehouseID: warehouseID, From: &from, To: &end})
+	if err != nil {
+		return nil, err
+	}
+
+	history := &SupplierPurchases{Supplier: sup, WarehouseID: warehouseID, From: from, To: end.Add(-24 * time.Hour), Receipts: receipts}
+	for _, r := range receipts {
+		history.Total += r.Total
+	}
+	return history, nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurchaseOrderLifecycle(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, pusat.ID)
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 0, pusat.ID)
+
+	if _, err := CreateSupplier(nil, " ", "", ""); err == nil {
+		t.Error("supplier name is required")
+	}
+	sup, err := CreateSupplier(nil, "PT Sumber Pangan", "021-555", "Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateSupplier(nil, "PT Sumber Pangan", "", ""); err != ErrDuplicateSupplier {
+		t.Errorf("duplicate supplier: err = %v, want ErrDuplicateSupplier", err)
+	}
+
+	if _, err := CreatePurchaseOrder(kasirCabang, sup.ID, pusat.ID, "", []PurchaseOrderItem{{ProductID: mie.ID, Quantity: 1, Price: 2400}}); err == nil {
+		t.Error("a warehouse user cannot order for another warehouse")
+	}
+	if _, err := CreatePurchaseOrder(nil, 9999, pusat.ID, "", []PurchaseOrderItem{{ProductID: mie.ID, Quantity: 1, Price: 2400}}); err == nil {
+		t.Error("unknown supplier must be rejected")
+	}
+	po, err := CreatePurchaseOrder(nil, sup.ID, pusat.ID, "mingguan", []PurchaseOrderItem{
+		{ProductID: mie.ID, Quantity: 24, Price: 2400}, {ProductID: aqua.ID, Quantity: 12, Price: 2200}, {ProductID: mie.ID, Quantity: 6, Price: 2400},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if po.Status != PurchaseOpen || len(po.Items) != 2 || po.Items[0].Quantity != 30 || po.SupplierName != sup.Name || po.Total() != 30*2400+12*2200 {
+		t.Errorf("purchase order = %+v", po)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 10 {
+		t.Errorf("ordering should not move stock, got %d", p.Stock)
+	}
+	if err := DeleteSupplier(nil, sup.ID); err == nil {
+		t.Error("a supplier with purchase orders cannot be deleted")
+	}
+
+	if _, err := ReceivePurchaseOrder(nil, po.ID, []GoodsReceiptItem{{ProductID: mie.ID, Quantity: 31}}, ""); err == nil {
+		t.Error("cannot receive more than was ordered")
+	}
+	// Indomie datang 20 dari 30 dengan harga faktur yang naik; Aqua belum datang
+	gr, err := ReceivePurchaseOrder(nil, po.ID, []GoodsReceiptItem{{ProductID: mie.ID, Quantity: 20, Price: 2600}}, "faktur 001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gr.Total != 20*2600 || gr.SupplierName != sup.Name {
+		t.Errorf("receipt = %+v", gr)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 30 || p.PurchasePrice != 2600 {
+		t.Errorf("stock after receipt = %+v, want 30 at 2600", p)
+	}
+	if p, _ := GetProductByID(mie.ID); p.PurchasePrice != 2500 {
+		t.Errorf("master purchase price changed to %v", p.PurchasePrice)
+	}
+	if err := CancelPurchaseOrder(nil, po.ID); err == nil {
+		t.Error("a partially received order cannot be cancelled")
+	}
+
+	outstanding, err := GetOutstandingPurchases(nil)
+	if err != nil || len(outstanding) != 2 || outstanding[0].Outstanding != 10 || outstanding[1].Value != 12*2200 {
+		t.Errorf("outstanding = %+v, %v", outstanding, err)
+	}
+	if other, _ := GetOutstandingPurchases(&cabang.ID); len(other) != 0 {
+		t.Errorf("other warehouse should have nothing outstanding: %+v", other)
+	}
+
+	// Tanpa rincian, semua sisa pesanan dianggap datang dengan harga PO
+	if _, err := ReceivePurchaseOrder(nil, po.ID, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	po, _ = GetPurchaseOrder(nil, po.ID)
+	if po.Status != PurchaseReceived || po.OutstandingValue() != 0 {
+		t.Errorf("completed order = %+v", po)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 40 || p.PurchasePrice != 2400 {
+		t.Errorf("stock after second receipt = %+v, want 40 at 2400", p)
+	}
+	if p, _ := GetProductInWarehouse(aqua.ID, pusat.ID); p.Stock != 12 || p.PurchasePrice != 2200 {
+		t.Errorf("aqua after receipt = %+v", p)
+	}
+	if _, err := ReceivePurchaseOrder(nil, po.ID, nil, ""); err == nil {
+		t.Error("a completed order cannot be received again")
+	}
+
+	card, err := GetStockCard(mie.ID, &pusat.ID, time.Now(), time.Now())
+	if err != nil || card.In != 40 || card.Movements[1].Type != MovementPurchase || card.Movements[1].Reference != "GR-000001" {
+		t.Errorf("stock card = %+v, %v", card, err)
+	}
+
+	history, err := GetSupplierPurchases(sup.ID, nil, time.Now(), time.Now())
+	if err != nil || len(history.Receipts) != 2 || history.Total != 20*2600+10*2400+12*2200 {
+		t.Errorf("supplier history = %+v, %v", history, err)
+	}
+	if history, _ := GetSupplierPurchases(sup.ID, &cabang.ID, time.Now(), time.Now()); len(history.Receipts) != 0 {
+		t.Errorf("history for other warehouse = %+v", history.Receipts)
+	}
+}
+
+func TestCancelPurchaseOrder(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, pusat.ID)
+	sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+
+	po, err := CreatePurchaseOrder(nil, sup.ID, pusat.ID, "", []PurchaseOrderItem{{ProductID: mie.ID, Quantity: 5, Price: 2500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CancelPurchaseOrder(nil, po.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetPurchaseOrder(nil, po.ID); err == nil {
+		t.Error("cancelled order should be gone")
+	}
+	if err := DeleteSupplier(nil, sup.ID); err != nil {
+		t.Errorf("supplier without orders should be deletable: %v", err)
+	}
+}
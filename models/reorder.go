@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IsLow bernilai true jika stok sudah mencapai atau di bawah titik pesan ulang (stok minimum)
+func (st ProductStock) IsLow() bool {
+	return st.ReorderPoint > 0 && st.Stock <= st.ReorderPoint
+}
+
+// SetReorderPoint mengatur stok minimum produk di satu gudang; 0 mematikan peringatan stok menipis
+func SetReorderPoint(actor *User, productID, warehouseID, level int) error {
+	if level < 0 {
+		return errors.New("stok minimum tidak boleh negatif")
+	}
+	return store.WithTx(func(s Store) error {
+		if _, err := s.Products().GetByID(productID); err != nil {
+			return fmt.Errorf("produk dengan ID %d tidak ditemukan", productID)
+		}
+		before, err := findStock(s, productID, warehouseID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return ErrNotInWarehouse
+		}
+		if level > 0 {
+			variants, err := s.Products().Variants(productID)
+			if err != nil {
+				return err
+			}
+			if len(variants) > 0 {
+				return fmt.Errorf("%w: stok minimum diatur di tiap varian", ErrHasVariants)
+			}
+		}
+
+		if err := s.Products().SetReorderPoint(productID, warehouseID, level); err != nil {
+			return err
+		}
+		after := *before
+		after.ReorderPoint = level
+		return writeAudit(s, actor, AuditUpdate, EntityProduct, productID, before, after)
+	})
+}
+
+// GetReorderLevels mengambil stok yang punya stok minimum di gudang warehouseID (nil = semua gudang)
+func GetReorderLevels(warehouseID *int) ([]ProductStock, error) {
+	return store.Products().ReorderLevels(warehouseID)
+}
+
+// LowStockItem adalah produk yang stoknya di satu gudang sudah mencapai stok minimum
+type LowStockItem struct {
+	ProductID    int
+	ProductName  string
+	SKU          string
+	WarehouseID  int
+	Stock        int
+	ReorderPoint int
+	OnOrder      int // jumlah yang sudah dipesan di PO dan belum diterima gudang ini
+}
+
+// GetLowStock mengambil produk yang stoknya menipis di gudang warehouseID (nil = semua gudang),
+// urut gudang lalu ID produk
+func GetLowStock(warehouseID *int) ([]LowStockItem, error) {
+	levels, err := store.Products().ReorderLevels(warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []LowStockItem
+	for _, st := range levels {
+		if !st.IsLow() {
+			continue
+		}
+		p, err := store.Products().GetByID(st.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, LowStockItem{
+			ProductID:    p.ID,
+			ProductName:  p.DisplayName(),
+			SKU:          p.SKU,
+			WarehouseID:  st.WarehouseID,
+			Stock:        st.Stock,
+			ReorderPoint: st.ReorderPoint,
+		})
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	outstanding, err := GetOutstandingPurchases(warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range outstanding {
+		for i := range items {
+			if items[i].ProductID == o.ProductID && items[i].WarehouseID == o.WarehouseID {
+				items[i].OnOrder += o.Outstanding
+			}
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import "testing"
+
+func TestLowStockPerWarehouse(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	cashier := mustUser(t, "kasir1", "user", &cabang.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 12, cabang.ID)
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 50, cabang.ID)
+	if err := SetProductStock(nil, ProductStock{ProductID: mie.ID, WarehouseID: pusat.ID, Stock: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetReorderPoint(nil, mie.ID, cabang.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetReorderPoint(nil, aqua.ID, cabang.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetReorderPoint(nil, aqua.ID, pusat.ID, 10); err != ErrNotInWarehouse {
+		t.Errorf("reorder point outside warehouse: err = %v", err)
+	}
+	if err := SetReorderPoint(nil, mie.ID, cabang.ID, -1); err == nil {
+		t.Error("expected error for negative reorder point")
+	}
+
+	// Belum menipis: 12 > 10. Pusat tidak punya stok minimum, jadi 3 unit tidak dianggap menipis.
+	if low, _ := GetLowStock(nil); len(low) != 0 {
+		t.Fatalf("low stock before sale = %+v", low)
+	}
+
+	// Penjualan menurunkan stok ke titik pesan ulang; penyesuaian stok tidak mengubah stok minimum
+	sell(t, cashier, mie.ID, 2)
+	if err := SetProductStock(nil, ProductStock{ProductID: aqua.ID, WarehouseID: cabang.ID, Stock: 60}); err != nil {
+		t.Fatal(err)
+	}
+	sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+	if _, err := CreatePurchaseOrder(nil, sup.ID, cabang.ID, "", []PurchaseOrderItem{{ProductID: mie.ID, Quantity: 24, Price: 2500}}); err != nil {
+		t.Fatal(err)
+	}
+
+	low, err := GetLowStock(&cabang.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low) != 1 || low[0].ProductID != mie.ID || low[0].Stock != 10 || low[0].ReorderPoint != 10 || low[0].OnOrder != 24 {
+		t.Fatalf("low stock = %+v", low)
+	}
+	if low, _ := GetLowStock(&pusat.ID); len(low) != 0 {
+		t.Errorf("low stock in pusat = %+v", low)
+	}
+	if levels, _ := GetReorderLevels(&cabang.ID); len(levels) != 2 || levels[1].ReorderPoint != 10 {
+		t.Errorf("reorder levels = %+v", levels)
+	}
+
+	// 0 mematikan peringatan
+	if err := SetReorderPoint(nil, mie.ID, cabang.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if low, _ := GetLowStock(nil); len(low) != 0 {
+		t.Errorf("low stock after disabling = %+v", low)
+	}
+}
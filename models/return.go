@@ -0,0 +1,285 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SalesReturn adalah retur barang dari satu transaksi yang sudah dibayar. Barangnya kembali ke stok
+// gudang transaksi dan uangnya dikembalikan ke pelanggan; laporan harian mengurangkan retur dari
+// penjualan pada hari retur dicatat.
+type SalesReturn struct {
+	ID            int
+	TransactionID int
+	WarehouseID   int
+	UserID        int
+	CashierName   string // username yang memproses retur
+	Reason        string
+	Total         float64 // uang yang dikembalikan ke pelanggan
+	Profit        float64 // profit penjualan yang batal karena barangnya kembali
+	CreatedAt     time.Time
+	Items         []ReturnItem
+}
+
+// ReturnItem adalah satu produk yang diretur. Harga jual dan harga pokok diambil dari item
+// transaksi asalnya.
+type ReturnItem struct {
+	ID            int
+	ReturnID      int
+	ProductID     int
+	ProductName   string
+	Quantity      int
+	PurchasePrice float64
+	SellingPrice  float64
+	Subtotal      float64
+	Profit        float64
+}
+
+// ReturnableItem adalah item transaksi beserta jumlah yang sudah diretur
+type ReturnableItem struct {
+	TransactionItem
+	Returned int
+}
+
+// Returnable mengembalikan jumlah yang masih boleh diretur
+func (i ReturnableItem) Returnable() int {
+	return i.Quantity - i.Returned
+}
+
+// GetTransaction mengambil transaksi beserta item-nya; user gudang hanya boleh melihat transaksi
+// gudangnya
+func GetTransaction(user *User, id int) (*Transaction, error) {
+	t, err := store.Transactions().GetByID(id)
+	if err != nil || checkWarehouseAccess(user, t.WarehouseID) != nil {
+		return nil, fmt.Errorf("transaksi TRX-%06d tidak ditemukan", id)
+	}
+	return t, nil
+}
+
+// GetTransactionReturns mengambil retur dari satu transaksi, urut saat dicatat
+func GetTransactionReturns(user *User, transactionID int) ([]SalesReturn, error) {
+	if _, err := GetTransaction(user, transactionID); err != nil {
+		return nil, err
+	}
+	return store.Transactions().Returns(transactionID)
+}
+
+// GetReturnableItems mengambil item transaksi beserta jumlah yang sudah diretur
+func GetReturnableItems(user *User, transactionID int) ([]ReturnableItem, error) {
+	t, err := GetTransaction(user, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	returns, err := store.Transactions().Returns(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return returnableItems(t, returns), nil
+}
+
+func returnableItems(t *Transaction, returns []SalesReturn) []ReturnableItem {
+	returned := map[int]int{}
+	for _, r := range returns {
+		for _, item := range r.Items {
+			returned[item.ProductID] += item.Quantity
+		}
+	}
+	items := make([]ReturnableItem, 0, len(t.Items))
+	for _, item := range t.Items {
+		// Produk yang sama di beberapa baris: jumlah retur dibagi ke baris pertama lebih dulu
+		n := returned[item.ProductID]
+		if n > item.Quantity {
+			n = item.Quantity
+		}
+		returned[item.ProductID] -= n
+		items = append(items, ReturnableItem{TransactionItem: item, Returned: n})
+	}
+	return items
+}
+
+// CreateReturn mencatat retur barang dari transaksi transactionID. items berisi produk dan jumlah
+// yang dikembalikan; jumlahnya tidak boleh melebihi yang terjual dikurangi retur sebelumnya. Stok
+// kembali ke gudang transaksi dengan harga pokok saat terjual, uang dikembalikan sebesar harga jual,
+// dan semuanya disimpan dalam satu transaksi database.
+func CreateReturn(actor *User, transactionID int, items []ReturnItem, reason string) (*SalesReturn, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan retur wajib diisi")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("tidak ada barang yang diretur")
+	}
+
+	var ret *SalesReturn
+	err := store.WithTx(func(s Store) error {
+		t, err := s.Transactions().GetByID(transactionID)
+		if err != nil || checkWarehouseAccess(actor, t.WarehouseID) != nil {
+			return fmt.Errorf("transaksi TRX-%06d tidak ditemukan", transactionID)
+		}
+		if t.Voided() {
+			return fmt.Errorf("transaksi TRX-%06d sudah di-void", t.ID)
+		}
+		previous, err := s.Transactions().Returns(t.ID)
+		if err != nil {
+			return err
+		}
+		lines := returnableItems(t, previous)
+
+		r := &SalesReturn{TransactionID: t.ID, WarehouseID: t.WarehouseID, Reason: reason}
+		if actor != nil {
+			r.UserID = actor.ID
+			r.CashierName = actor.Username
+		}
+		for _, in := range items {
+			if in.Quantity <= 0 {
+				return errors.New("jumlah retur harus lebih dari 0")
+			}
+			if err := checkReturnable(t, lines, in); err != nil {
+				return err
+			}
+			left := in.Quantity
+			for i := range lines {
+				line := &lines[i]
+				if line.ProductID != in.ProductID || line.Returnable() == 0 || left == 0 {
+					continue
+				}
+				qty := line.Returnable()
+				if qty > left {
+					qty = left
+				}
+				left -= qty
+				line.Returned += qty
+				subtotal := float64(qty) * line.SellingPrice
+				profit := subtotal - float64(qty)*line.PurchasePrice
+				r.Items = append(r.Items, ReturnItem{
+					ProductID:     line.ProductID,
+					ProductName:   line.ProductName,
+					Quantity:      qty,
+					PurchasePrice: line.PurchasePrice,
+					SellingPrice:  line.SellingPrice,
+					Subtotal:      subtotal,
+					Profit:        profit,
+				})
+				r.Total += subtotal
+				r.Profit += profit
+			}
+		}
+
+		if err := s.Transactions().CreateReturn(r); err != nil {
+			return err
+		}
+		// Barang kembali ke batch asalnya; retur sebelumnya dianggap mengambil batch yang terjual lebih dulu
+		sold, err := s.Batches().TransactionBatches(t.ID)
+		if err != nil {
+			return err
+		}
+		restored := map[int]int{}
+		for _, p := range previous {
+			for _, item := range p.Items {
+				restored[item.ProductID] += item.Quantity
+			}
+		}
+
+		reference := fmt.Sprintf("RET-%06d", r.ID)
+		for _, item := range r.Items {
+			stock, err := receiveStock(s, item.ProductID, r.WarehouseID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			if err := restoreSaleBatches(s, sold, item.ProductID, r.WarehouseID, restored[item.ProductID], item.Quantity, reference); err != nil {
+				return err
+			}
+			restored[item.ProductID] += item.Quantity
+			if err := addCostLayer(s, item.ProductID, r.WarehouseID, item.Quantity, item.PurchasePrice, reference); err != nil {
+				return err
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: r.WarehouseID,
+				Type:        MovementReturn,
+				Quantity:    item.Quantity,
+				Balance:     stock,
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		ret = r
+		return writeAudit(s, actor, AuditCreate, EntityReturn, r.ID, nil, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// checkReturnable menolak retur yang melebihi jumlah terjual dikurangi retur sebelumnya
+func checkReturnable(t *Transaction, lines []ReturnableItem, in ReturnItem) error {
+	sold, returnable := 0, 0
+	name := ""
+	for _, line := range lines {
+		if line.ProductID == in.ProductID {
+			sold += line.Quantity
+			returnable += line.Returnable()
+			name = line.ProductName
+		}
+	}
+	if sold == 0 {
+		return fmt.Errorf("produk dengan ID %d tidak ada di transaksi TRX-%06d", in.ProductID, t.ID)
+	}
+	if in.Quantity > returnable {
+		return fmt.Errorf("retur %s melebihi jumlah terjual (terjual %d, sisa yang bisa diretur %d)", name, sold, returnable)
+	}
+	return nil
+}
+
+// GetReturnsByDate mengambil retur yang dicatat pada tanggal tertentu di gudang yang boleh dilihat user
+func GetReturnsByDate(user *User, date time.Time) ([]SalesReturn, error) {
+	return GetWarehouseReturnsByDate(date, reportWarehouse(user))
+}
+
+// GetWarehouseReturnsByDate mengambil retur pada tanggal tertentu di satu gudang (nil = semua gudang)
+func GetWarehouseReturnsByDate(date time.Time, warehouseID *int) ([]SalesReturn, error) {
+	from, to := dayRange(date)
+	return store.Transactions().ReturnsByDate(from, to, warehouseID)
+}
+
+// SalesSummary adalah ringkasan penjualan satu hari: penjualan kotor, retur, dan bersihnya.
+// Nama field JSON penjualan kotor sama dengan ringkasan laporan sebelum ada retur.
+type SalesSummary struct {
+	Transactions   int     `json:"transaction_count"`
+	GrossSales     float64 `json:"total_sales"`
+	GrossProfit    float64 `json:"total_profit"`
+	Returns        int     `json:"return_count"`    // jumlah dokumen retur
+	ReturnTotal    float64 `json:"return_total"`    // uang yang dikembalikan ke pelanggan
+	ReturnedProfit float64 `json:"returned_profit"` // profit yang batal karena retur
+	NetSales       float64 `json:"net_sales"`
+	NetProfit      float64 `json:"net_profit"`
+}
+
+// GetDailySummary mengambil ringkasan penjualan harian di gudang yang boleh dilihat user
+func GetDailySummary(user *User, date time.Time) (*SalesSummary, error) {
+	return GetWarehouseDailySummary(date, reportWarehouse(user))
+}
+
+// GetWarehouseDailySummary mengambil ringkasan penjualan harian di satu gudang (nil = semua gudang).
+// Retur dihitung pada hari retur dicatat, bukan hari transaksi asalnya.
+func GetWarehouseDailySummary(date time.Time, warehouseID *int) (*SalesSummary, error) {
+	from, to := dayRange(date)
+	var sum SalesSummary
+	var err error
+	sum.GrossSales, sum.GrossProfit, sum.Transactions, err = store.Transactions().Summary(from, to, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	sum.ReturnTotal, sum.ReturnedProfit, sum.Returns, err = store.Transactions().ReturnSummary(from, to, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	sum.NetSales = sum.GrossSales - sum.ReturnTotal
+	sum.NetProfit = sum.GrossProfit - sum.ReturnedProfit
+	return &sum, nil
+}
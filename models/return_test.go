@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReturnRestocksAndLimitsQuantity(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	cashier := mustUser(t, "kasir1", "user", &pusat.ID)
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 10, pusat.ID)
+	teh := mustProduct(t, "Teh Botol", 3000, 5000, 5, pusat.ID)
+
+	p1, _ := GetProductInWarehouse(mie.ID, pusat.ID)
+	p2, _ := GetProductInWarehouse(teh.ID, pusat.ID)
+	trx, err := CreateTransaction(cashier, []CartItem{{Product: p1, Quantity: 4}, {Product: p2, Quantity: 2}}, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refused := []struct {
+		name   string
+		user   *User
+		items  []ReturnItem
+		reason string
+		want   string
+	}{
+		{"tanpa alasan", cashier, []ReturnItem{{ProductID: mie.ID, Quantity: 1}}, " ", "alasan"},
+		{"melebihi terjual", cashier, []ReturnItem{{ProductID: mie.ID, Quantity: 5}}, "rusak", "melebihi"},
+		{"duplikat melebihi terjual", cashier, []ReturnItem{{ProductID: mie.ID, Quantity: 3}, {ProductID: mie.ID, Quantity: 2}}, "rusak", "melebihi"},
+		{"produk lain", cashier, []ReturnItem{{ProductID: 999, Quantity: 1}}, "rusak", "tidak ada di transaksi"},
+		{"gudang lain", kasirCabang, []ReturnItem{{ProductID: mie.ID, Quantity: 1}}, "rusak", "tidak ditemukan"},
+	}
+	for _, tc := range refused {
+		if _, err := CreateReturn(tc.user, trx.ID, tc.items, tc.reason); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+	if stock := stockIn(t, mie.ID, pusat.ID); stock != 6 {
+		t.Fatalf("stock after refused returns = %d", stock)
+	}
+
+	ret, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 3}}, "kemasan rusak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.Total != 3*4000 || ret.Profit != 3*1500 || len(ret.Items) != 1 || ret.CashierName != "kasir1" {
+		t.Fatalf("return = %+v", ret)
+	}
+	if stock := stockIn(t, mie.ID, pusat.ID); stock != 9 {
+		t.Errorf("stock after return = %d", stock)
+	}
+	card, err := GetStockCard(mie.ID, &pusat.ID, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := card.Movements[len(card.Movements)-1]
+	if last.Type != MovementReturn || last.Quantity != 3 || last.Balance != 9 || last.Reference != "RET-000001" {
+		t.Errorf("return movement = %+v", last)
+	}
+
+	summary, err := GetDailySummary(cashier, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SalesSummary{
+		Transactions: 1, GrossSales: 26000, GrossProfit: 10000,
+		Returns: 1, ReturnTotal: 12000, ReturnedProfit: 4500,
+		NetSales: 14000, NetProfit: 5500,
+	}
+	if *summary != want {
+		t.Errorf("summary = %+v, want %+v", *summary, want)
+	}
+	if other, _ := GetDailySummary(kasirCabang, time.Now()); other.Returns != 0 || other.NetSales != 0 {
+		t.Errorf("cabang summary = %+v", other)
+	}
+
+	// Sisa mie yang bisa diretur tinggal 1
+	if _, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 2}}, "rusak"); err == nil ||
+		!strings.Contains(err.Error(), "sisa yang bisa diretur 1") {
+		t.Errorf("second over-return: err = %v", err)
+	}
+	if _, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 1}, {ProductID: teh.ID, Quantity: 2}}, "salah beli"); err != nil {
+		t.Fatal(err)
+	}
+	items, err := GetReturnableItems(cashier, trx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.Returnable() != 0 || item.Returned != item.Quantity {
+			t.Errorf("returnable item = %+v", item)
+		}
+	}
+	returns, _ := GetTransactionReturns(cashier, trx.ID)
+	if len(returns) != 2 || returns[0].Reason != "kemasan rusak" || len(returns[1].Items) != 2 {
+		t.Errorf("returns = %+v", returns)
+	}
+	if summary, _ := GetDailySummary(nil, time.Now()); summary.NetSales != 0 || summary.NetProfit != 0 {
+		t.Errorf("summary after full return = %+v", summary)
+	}
+}
+
+func TestReturnedStockKeepsSaleCost(t *testing.T) {
+	useCosting(t, CostingFIFO)
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 10, w.ID)
+	sup, _ := CreateSupplier(nil, "PT Sumber Pangan", "", "")
+
+	trx := sell(t, cashier, mie.ID, 10)
+	receiveAt(t, sup.ID, mie.ID, w.ID, 10, 3000)
+	if _, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 2}}, "kedaluwarsa"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Barang retur masuk lagi dengan harga pokok saat terjual (2500), bukan harga beli terakhir
+	trx = sell(t, cashier, mie.ID, 12)
+	if cost := trx.Items[0].Subtotal - trx.Items[0].Profit; cost != 10*3000+2*2500 {
+		t.Errorf("sale cost after return = %v", cost)
+	}
+}
+
+func TestReturnRestoresSoldBatches(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	roti := mustProduct(t, "Roti Tawar", 10000, 15000, 1, w.ID)
+	a := mustBatch(t, roti.ID, w.ID, "R-01", 5, 2)
+	b := mustBatch(t, roti.ID, w.ID, "R-02", 30, 3)
+
+	// Terjual R-01 2 dan R-02 3; 1 unit tanpa batch tersisa di gudang
+	trx := sell(t, cashier, roti.ID, 5)
+	if batches, _ := GetBatches(roti.ID, w.ID); len(batches) != 0 {
+		t.Fatalf("batches after sale = %+v", batches)
+	}
+
+	// Retur pertama mengembalikan batch yang terjual lebih dulu
+	ret, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: roti.ID, Quantity: 3}}, "salah beli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches, err := GetBatches(roti.ID, w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := fmt.Sprintf("RET-%06d", ret.ID)
+	if len(batches) != 2 || batches[0].BatchNo != "R-01" || batches[0].Remaining != 2 || !batches[0].ExpiryDate.Equal(a.ExpiryDate) ||
+		batches[1].BatchNo != "R-02" || batches[1].Remaining != 1 || !batches[1].ExpiryDate.Equal(b.ExpiryDate) || batches[0].Reference != reference {
+		t.Fatalf("batches after first return = %+v", batches)
+	}
+
+	// Retur berikutnya melanjutkan dari batch yang belum dikembalikan
+	if _, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: roti.ID, Quantity: 2}}, "salah beli"); err != nil {
+		t.Fatal(err)
+	}
+	remaining := map[string]int{}
+	batches, _ = GetBatches(roti.ID, w.ID)
+	for _, b := range batches {
+		remaining[b.BatchNo] += b.Remaining
+	}
+	if len(remaining) != 2 || remaining["R-01"] != 2 || remaining["R-02"] != 3 {
+		t.Errorf("batch remaining after second return = %v", remaining)
+	}
+	if stock := stockIn(t, roti.ID, w.ID); stock != 6 {
+		t.Errorf("stock after returns = %d, want 6", stock)
+	}
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Daftar permission yang dikenal aplikasi
+const (
+	PermTransactionCreate = "transaction.create"   // Transaksi penjualan (checkout)
+	PermTransactionVoid   = "transaction.void"     // Membatalkan transaksi yang sudah tersimpan
+	PermTransactionReturn = "transaction.return"   // Memproses retur barang dari transaksi yang sudah dibayar
+	PermProductView       = "product.view"         // Melihat produk
+	PermProductManage     = "product.manage"       // Tambah/edit/hapus produk, export/import Excel
+	PermStockAdjust       = "stock.adjust"         // Mengubah jumlah stok
+	PermStockTransfer     = "stock.transfer"       // Membuat, mengirim, dan menerima transfer stok antar gudang
+	PermStockOpname       = "stock.opname"         // Membuat sesi stock opname dan mengisi hasil hitung fisik
+	PermOpnameApprove     = "stock.opname.approve" // Menyetujui stock opname dan membukukan selisihnya
+	PermPurchaseManage    = "purchase.manage"      // Kelola supplier dan purchase order
+	PermPurchaseReceive   = "purchase.receive"     // Menerima barang dari supplier atas purchase order
+	PermReportView        = "report.view"          // Melihat laporan penjualan
+	PermUserManage        = "user.manage"          // Manajemen user
+	PermWarehouseManage   = "warehouse.manage"     // Manajemen gudang
+	PermRoleManage        = "role.manage"          // Manajemen role & permission
+	PermAllWarehouses     = "warehouse.all"        // Akses data semua gudang
+	PermAuditView         = "audit.view"           // Melihat audit log
+)
+
+// PermissionInfo berisi kode dan keterangan permission
+type PermissionInfo struct {
+	Code        string
+	Description string
+}
+
+// AllPermissions adalah daftar permission beserta keterangannya (urut untuk tampilan)
+var AllPermissions = []PermissionInfo{
+	{PermTransactionCreate, "Transaksi penjualan"},
+	{PermTransactionVoid, "Void transaksi"},
+	{PermTransactionReturn, "Retur penjualan"},
+	{PermProductView, "Lihat produk"},
+	{PermProductManage, "Kelola produk"},
+	{PermStockAdjust, "Penyesuaian stok"},
+	{PermStockTransfer, "Transfer stok antar gudang"},
+	{PermStockOpname, "Stock opname (hitung fisik)"},
+	{PermOpnameApprove, "Setujui stock opname"},
+	{PermPurchaseManage, "Kelola supplier & purchase order"},
+	{PermPurchaseReceive, "Penerimaan barang dari supplier"},
+	{PermReportView, "Lihat laporan"},
+	{PermUserManage, "Manajemen user"},
+	{PermWarehouseManage, "Manajemen gudang"},
+	{PermRoleManage, "Manajemen role"},
+	{PermAllWarehouses, "Akses semua gudang"},
+	{PermAuditView, "Lihat audit log"},
+}
+
+// AdminRole adalah role bawaan yang selalu memiliki semua permission
+const AdminRole = "admin"
+
+// Role model
+type Role struct {
+	ID          int
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+// IsValidPermission mengecek apakah kode permission dikenal
+func IsValidPermission(code string) bool {
+	for _, p := range AllPermissions {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllRoles mengambil semua role beserta permission-nya
+func GetAllRoles() ([]Role, error) {
+	return store.Roles().List()
+}
+
+// GetRoleByName mengambil role berdasarkan nama
+func GetRoleByName(name string) (*Role, error) {
+	r, err := store.Roles().GetByName(name)
+	if err != nil {
+		return nil, errors.New("role tidak ditemukan")
+	}
+	return r, nil
+}
+
+// GetRolePermissions mengambil daftar permission milik role
+func GetRolePermissions(roleName string) ([]string, error) {
+	return store.Roles().Permissions(roleName)
+}
+
+// CreateRole membuat role baru dengan daftar permission
+func CreateRole(actor *User, name, description string, permissions []string) (*Role, error) {
+	for _, p := range permissions {
+		if !IsValidPermission(p) {
+			return nil, errors.New("permission tidak dikenal: " + p)
+		}
+	}
+
+	r := Role{Name: name, Description: description, Permissions: permissions}
+	err := store.WithTx(func(s Store) error {
+		if err := s.Roles().Create(&r); err != nil {
+			return errors.New("gagal membuat role, nama mungkin sudah digunakan")
+		}
+		return writeAudit(s, actor, AuditCreate, EntityRole, r.ID, nil, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SetRolePermissions mengganti seluruh permission milik role
+func SetRolePermissions(actor *User, name string, permissions []string) error {
+	if name == AdminRole {
+		return errors.New("permission role admin tidak dapat diubah")
+	}
+	for _, p := range permissions {
+		if !IsValidPermission(p) {
+			return errors.New("permission tidak dikenal: " + p)
+		}
+	}
+
+	role, err := GetRoleByName(name)
+	if err != nil {
+		return err
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Roles().SetPermissions(role.ID, permissions); err != nil {
+			return err
+		}
+
+		after := *role
+		after.Permissions = permissions
+		return writeAudit(s, actor, AuditUpdate, EntityRole, role.ID, role, after)
+	})
+}
+
+// DeleteRole menghapus role yang tidak lagi dipakai user
+func DeleteRole(actor *User, name string) error {
+	if name == AdminRole {
+		return errors.New("role admin tidak dapat dihapus")
+	}
+
+	before, err := GetRoleByName(name)
+	if err != nil {
+		return err
+	}
+
+	userCount, err := store.Roles().CountUsers(name)
+	if err != nil {
+		return err
+	}
+	if userCount > 0 {
+		return errors.New("role masih digunakan oleh user")
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Roles().Delete(name); err != nil {
+			if err == ErrNotFound {
+				return errors.New("role tidak ditemukan")
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityRole, before.ID, before, nil)
+	})
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Session model (sesi API, menyimpan hash refresh token)
+type Session struct {
+	ID               string
+	UserID           int
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+	RevokedAt        *time.Time
+	CreatedAt        time.Time
+}
+
+// CreateSession membuat sesi API baru untuk user
+func CreateSession(id string, userID int, refreshTokenHash string, expiresAt time.Time) (*Session, error) {
+	s := Session{ID: id, UserID: userID, RefreshTokenHash: refreshTokenHash, ExpiresAt: expiresAt}
+	if err := store.Sessions().Create(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ErrRefreshTokenReused dikembalikan saat refresh token yang sudah dirotasi dipakai lagi
+var ErrRefreshTokenReused = errors.New("refresh token sudah pernah dipakai, sesi dicabut")
+
+// GetActiveSessionByRefreshHash mengambil sesi aktif (belum dicabut dan belum kedaluwarsa).
+// Refresh token lama yang dipakai ulang berarti token itu bocor: seluruh sesinya dicabut
+// sehingga refresh token terbaru dan access token sesi itu juga tidak berlaku lagi.
+func GetActiveSessionByRefreshHash(refreshTokenHash string) (*Session, error) {
+	s, err := store.Sessions().GetActiveByRefreshHash(refreshTokenHash, time.Now())
+	if err == nil {
+		return s, nil
+	}
+	if err != ErrNotFound {
+		return nil, err
+	}
+	used, err := store.Sessions().GetByUsedRefreshHash(refreshTokenHash)
+	if err == ErrNotFound {
+		return nil, errors.New("sesi tidak valid atau sudah berakhir")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Sessions().Revoke(used.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return nil, ErrRefreshTokenReused
+}
+
+// RotateSessionRefresh mengganti refresh token sesi (refresh token lama tidak berlaku lagi)
+func RotateSessionRefresh(id, oldHash, newHash string, expiresAt time.Time) error {
+	err := store.WithTx(func(s Store) error {
+		return s.Sessions().RotateRefresh(id, oldHash, newHash, expiresAt)
+	})
+	if err == ErrNotFound {
+		return errors.New("sesi tidak valid atau sudah berakhir")
+	}
+	return err
+}
+
+// RevokeSession mencabut sesi API (logout)
+func RevokeSession(id string) error {
+	return store.Sessions().Revoke(id, time.Now())
+}
+
+// IsSessionActive mengecek apakah sesi masih ada dan belum dicabut. Sesi hilang saat user dihapus
+// dan dicabut saat logout atau password user diganti.
+func IsSessionActive(id string) (bool, error) {
+	s, err := store.Sessions().GetByID(id)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return s.RevokedAt == nil, nil
+}
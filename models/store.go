@@ -0,0 +1,278 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound dikembalikan repository jika data yang dicari tidak ada
+var ErrNotFound = errors.New("data tidak ditemukan")
+
+// ErrInsufficientStock dikembalikan jika stok produk lebih kecil dari jumlah yang diminta
+var ErrInsufficientStock = errors.New("stok tidak mencukupi")
+
+// ErrDuplicate dikembalikan jika data melanggar batasan unik (misalnya SKU/barcode kembar)
+var ErrDuplicate = errors.New("data sudah ada")
+
+// ProductRepository menyimpan katalog master produk beserta stok per gudangnya.
+// List, Search, dan FindByCode mengembalikan satu baris per produk per gudang (warehouseID nil = semua
+// gudang, termasuk produk yang belum ada di gudang mana pun); GetByID dan Variants mengembalikan data
+// master dengan stok total.
+type ProductRepository interface {
+	List(warehouseID *int) ([]Product, error)
+	Search(filter ProductFilter, page, limit int) ([]Product, int, error)
+	GetByID(id int) (*Product, error)
+	// FindByCode mencari produk yang barcode atau SKU-nya sama persis
+	FindByCode(code string, warehouseID *int) ([]Product, error)
+	// Variants mengembalikan varian dari produk induk parentID
+	Variants(parentID int) ([]Product, error)
+	// Create dan Update hanya menyimpan data master (stok dan gudang diabaikan)
+	Create(p *Product) error
+	Update(p *Product) error
+	Delete(id int) error
+	// Stocks mengembalikan stok produk di tiap gudang, urut ID gudang
+	Stocks(productID int) ([]ProductStock, error)
+	// SaveStock menambah atau mengganti stok dan harga produk di satu gudang (titik pesan ulang
+	// tidak ikut berubah)
+	SaveStock(st *ProductStock) error
+	// SetReorderPoint mengganti titik pesan ulang produk di satu gudang (ErrNotFound jika produk
+	// belum ada di gudang tersebut)
+	SetReorderPoint(productID, warehouseID, level int) error
+	// ReorderLevels mengembalikan stok yang punya titik pesan ulang (warehouseID nil = semua
+	// gudang), urut ID gudang lalu ID produk
+	ReorderLevels(warehouseID *int) ([]ProductStock, error)
+	DeleteStock(productID, warehouseID int) error
+	// DecrementStock mengurangi stok di satu gudang hanya jika mencukupi, mengembalikan sisa stok
+	DecrementStock(id, warehouseID, quantity int) (int, error)
+	// IncrementStock menambah stok di satu gudang, mengembalikan stok baru (ErrNotFound jika
+	// produk belum ada di gudang tersebut)
+	IncrementStock(id, warehouseID, quantity int) (int, error)
+}
+
+// CategoryRepository menyimpan kategori produk
+type CategoryRepository interface {
+	List() ([]Category, error)
+	GetByID(id int) (*Category, error)
+	Create(c *Category) error
+	Update(c *Category) error
+	Delete(id int) error
+}
+
+// BrandRepository menyimpan merek produk
+type BrandRepository interface {
+	List() ([]Brand, error)
+	GetByID(id int) (*Brand, error)
+	Create(b *Brand) error
+	Update(b *Brand) error
+	Delete(id int) error
+}
+
+// UserRepository menyimpan data user
+type UserRepository interface {
+	List() ([]User, error)
+	GetByID(id int) (*User, error)
+	GetByUsername(username string) (*User, error)
+	Create(u *User) error
+	Delete(id int) error
+	UpdatePassword(id int, hash string) error
+}
+
+// WarehouseRepository menyimpan data gudang
+type WarehouseRepository interface {
+	List() ([]Warehouse, error)
+	GetByID(id int) (*Warehouse, error)
+	Create(w *Warehouse) error
+	Update(w *Warehouse) error
+	Delete(id int) error
+}
+
+// TransactionRepository menyimpan transaksi penjualan beserta item-nya
+type TransactionRepository interface {
+	// Create menyimpan header dan item transaksi, mengisi ID dan CreatedAt
+	Create(t *Transaction) error
+	// GetByID mengembalikan transaksi beserta item-nya
+	GetByID(id int) (*Transaction, error)
+	// ListByDate mengembalikan transaksi pada rentang tanggal, termasuk yang sudah di-void
+	ListByDate(from, to time.Time, warehouseID *int) ([]Transaction, error)
+	Items(transactionID int) ([]TransactionItem, error)
+	// Summary menjumlahkan transaksi pada rentang tanggal, tanpa transaksi yang sudah di-void
+	Summary(from, to time.Time, warehouseID *int) (total, profit float64, count int, err error)
+	// Void menandai transaksi dibatalkan (VoidedAt, VoidedByID, VoidReason); ErrNotFound jika
+	// transaksi tidak ada atau sudah di-void
+	Void(t *Transaction) error
+	// CreateReturn menyimpan retur penjualan beserta item-nya, mengisi ID dan CreatedAt
+	CreateReturn(r *SalesReturn) error
+	// Returns mengembalikan retur dari satu transaksi beserta item-nya, urut saat dicatat
+	Returns(transactionID int) ([]SalesReturn, error)
+	// ReturnsByDate mengembalikan retur yang dicatat pada rentang waktu beserta item-nya, terbaru dulu
+	ReturnsByDate(from, to time.Time, warehouseID *int) ([]SalesReturn, error)
+	// ReturnSummary menjumlahkan uang yang dikembalikan dan profit yang batal pada rentang waktu
+	ReturnSummary(from, to time.Time, warehouseID *int) (total, profit float64, count int, err error)
+}
+
+// TransferRepository menyimpan dokumen transfer stok antar gudang beserta item-nya
+type TransferRepository interface {
+	// Create menyimpan header dan item transfer, mengisi ID dan CreatedAt
+	Create(t *Transfer) error
+	GetByID(id int) (*Transfer, error)
+	// List mengembalikan transfer dari atau ke gudang warehouseID (nil = semua gudang), terbaru dulu;
+	// status kosong = semua status. Item tidak ikut diambil.
+	List(warehouseID *int, status string) ([]Transfer, error)
+	// Update menyimpan status, waktu kirim/terima, dan jumlah diterima tiap item
+	Update(t *Transfer) error
+	Delete(id int) error
+}
+
+// OpnameRepository menyimpan sesi stock opname beserta item-nya
+type OpnameRepository interface {
+	// Create menyimpan header dan item stock opname, mengisi ID dan CreatedAt
+	Create(o *StockOpname) error
+	GetByID(id int) (*StockOpname, error)
+	// List mengembalikan stock opname di gudang warehouseID (nil = semua gudang), terbaru dulu;
+	// status kosong = semua status. Item tidak ikut diambil.
+	List(warehouseID *int, status string) ([]StockOpname, error)
+	// Update menyimpan status, persetujuan, dan hasil hitung tiap item
+	Update(o *StockOpname) error
+	Delete(id int) error
+}
+
+// SupplierRepository menyimpan data supplier
+type SupplierRepository interface {
+	List() ([]Supplier, error)
+	GetByID(id int) (*Supplier, error)
+	Create(s *Supplier) error
+	Update(s *Supplier) error
+	Delete(id int) error
+}
+
+// PurchaseRepository menyimpan purchase order beserta item-nya dan penerimaan barang atasnya
+type PurchaseRepository interface {
+	// Create menyimpan header dan item purchase order, mengisi ID dan CreatedAt
+	Create(o *PurchaseOrder) error
+	GetByID(id int) (*PurchaseOrder, error)
+	// List mengembalikan purchase order sesuai filter, terbaru dulu. Item tidak ikut diambil.
+	List(filter PurchaseFilter) ([]PurchaseOrder, error)
+	// Update menyimpan status, catatan, dan jumlah diterima tiap item
+	Update(o *PurchaseOrder) error
+	Delete(id int) error
+	// CreateReceipt menyimpan penerimaan barang beserta item-nya, mengisi ID dan CreatedAt
+	CreateReceipt(r *GoodsReceipt) error
+	// Receipts mengembalikan penerimaan barang sesuai filter, terbaru dulu. Item tidak ikut diambil.
+	Receipts(filter ReceiptFilter) ([]GoodsReceipt, error)
+}
+
+// MovementRepository menyimpan buku mutasi stok
+type MovementRepository interface {
+	// Create menyimpan mutasi dan mengisi ID
+	Create(m *StockMovement) error
+	// List mengembalikan mutasi sesuai filter, urut dari yang paling lama dicatat
+	List(filter MovementFilter) ([]StockMovement, error)
+}
+
+// CostLayerRepository menyimpan lapis harga pokok persediaan
+type CostLayerRepository interface {
+	// Create menyimpan lapis dan mengisi ID
+	Create(l *CostLayer) error
+	// Open mengembalikan lapis produk di satu gudang yang masih bersisa, urut dari yang paling lama masuk
+	Open(productID, warehouseID int) ([]CostLayer, error)
+	// Update menyimpan sisa dan harga pokok per unit lapis
+	Update(l *CostLayer) error
+}
+
+// BatchRepository menyimpan batch stok dengan tanggal kedaluwarsa
+type BatchRepository interface {
+	// Create menyimpan batch dan mengisi ID
+	Create(b *StockBatch) error
+	GetByID(id int) (*StockBatch, error)
+	// Open mengembalikan batch produk di satu gudang yang masih bersisa, paling cepat kedaluwarsa dulu
+	Open(productID, warehouseID int) ([]StockBatch, error)
+	// Expiring mengembalikan batch bersisa yang kedaluwarsa sebelum tanggal before (warehouseID nil =
+	// semua gudang), paling cepat kedaluwarsa dulu
+	Expiring(warehouseID *int, before time.Time) ([]StockBatch, error)
+	// Update menyimpan sisa batch
+	Update(b *StockBatch) error
+	// CreateTransferBatch menyimpan batch yang dikirim lewat transfer dan mengisi ID
+	CreateTransferBatch(tb *TransferBatch) error
+	// TransferBatches mengembalikan batch yang dikirim lewat satu transfer, urut saat dikirim
+	TransferBatches(transferID int) ([]TransferBatch, error)
+	// CreateTransactionBatch menyimpan batch yang terjual lewat transaksi dan mengisi ID
+	CreateTransactionBatch(tb *TransactionBatch) error
+	// TransactionBatches mengembalikan batch yang terjual lewat satu transaksi, urut saat terjual
+	TransactionBatches(transactionID int) ([]TransactionBatch, error)
+}
+
+// RoleRepository menyimpan role dan permission-nya
+type RoleRepository interface {
+	List() ([]Role, error)
+	GetByName(name string) (*Role, error)
+	Permissions(roleName string) ([]string, error)
+	Create(r *Role) error
+	SetPermissions(roleID int, permissions []string) error
+	Delete(name string) error
+	CountUsers(name string) (int, error)
+}
+
+// SessionRepository menyimpan sesi API
+type SessionRepository interface {
+	Create(s *Session) error
+	GetByID(id string) (*Session, error)
+	GetActiveByRefreshHash(refreshTokenHash string, now time.Time) (*Session, error)
+	// RotateRefresh mengganti refresh token sesi dan mencatat hash lama sebagai sudah dipakai
+	RotateRefresh(id, oldHash, newHash string, expiresAt time.Time) error
+	// GetByUsedRefreshHash mengambil sesi pemilik refresh token yang sudah dirotasi
+	GetByUsedRefreshHash(refreshTokenHash string) (*Session, error)
+	Revoke(id string, at time.Time) error
+	// RevokeUser mencabut semua sesi milik user yang masih aktif
+	RevokeUser(userID int, at time.Time) error
+}
+
+// LoginThrottleRepository menyimpan hitungan login gagal per username/IP
+type LoginThrottleRepository interface {
+	Get(key string) (*LoginThrottle, error)
+	// GetForUpdate mengambil baris key dan menguncinya sampai transaksi selesai. Jika belum ada,
+	// baris dibuat dengan failures 0 dan last_failure_at now agar request paralel ikut menunggu.
+	// Panggil di dalam WithTx.
+	GetForUpdate(key string, now time.Time) (*LoginThrottle, error)
+	Save(t *LoginThrottle) error
+	Delete(key string) (bool, error)
+	// LockedKeys mengembalikan key yang masih terkunci pada waktu now
+	LockedKeys(now time.Time) (map[string]time.Time, error)
+}
+
+// AuditRepository menyimpan audit log
+type AuditRepository interface {
+	Create(l *AuditLog) error
+	List(filter AuditFilter, page, limit int) ([]AuditLog, int, error)
+}
+
+// Store mengumpulkan semua repository dari satu sumber data
+type Store interface {
+	Products() ProductRepository
+	Categories() CategoryRepository
+	Brands() BrandRepository
+	Users() UserRepository
+	Warehouses() WarehouseRepository
+	Transactions() TransactionRepository
+	Transfers() TransferRepository
+	Opnames() OpnameRepository
+	Suppliers() SupplierRepository
+	Purchases() PurchaseRepository
+	Movements() MovementRepository
+	CostLayers() CostLayerRepository
+	Batches() BatchRepository
+	Roles() RoleRepository
+	Sessions() SessionRepository
+	LoginThrottle() LoginThrottleRepository
+	Audit() AuditRepository
+
+	// WithTx menjalankan fn dalam satu transaksi; semua perubahan dibatalkan jika fn mengembalikan error
+	WithTx(fn func(Store) error) error
+}
+
+// store adalah sumber data yang dipakai semua fungsi di package models
+var store Store
+
+// SetStore mengganti sumber data (PostgreSQL saat aplikasi berjalan, memory saat test)
+func SetStore(s Store) {
+	store = s
+}
@@ -0,0 +1,2037 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memData adalah seluruh isi memory store
+type memData struct {
+	products     map[int]Product
+	stocks       map[stockKey]ProductStock
+	categories   map[int]Category
+	brands       map[int]Brand
+	users        map[int]User
+	warehouses   map[int]Warehouse
+	transactions map[int]Transaction
+	returns      map[int]SalesReturn
+	transfers    map[int]Transfer
+	opnames      map[int]StockOpname
+	suppliers    map[int]Supplier
+	purchases    map[int]PurchaseOrder
+	receipts     map[int]GoodsReceipt
+	movements    []StockMovement
+	costLayers   []CostLayer
+	batches      []StockBatch
+	trfBatches   []TransferBatch
+	trxBatches   []TransactionBatch
+	roles        map[int]Role
+	sessions     map[string]Session
+	usedRefresh  map[string]string
+	throttle     map[string]LoginThrottle
+	audit        []AuditLog
+	lastID       map[string]int
+}
+
+// clone menyalin data untuk rollback. Slice di dalam struct (Items, Permissions)
+// tidak pernah diubah di tempat sehingga cukup disalin referensinya.
+func (d *memData) clone() *memData {
+	c := &memData{
+		products:     make(map[int]Product, len(d.products)),
+		stocks:       make(map[stockKey]ProductStock, len(d.stocks)),
+		categories:   make(map[int]Category, len(d.categories)),
+		brands:       make(map[int]Brand, len(d.brands)),
+		users:        make(map[int]User, len(d.users)),
+		warehouses:   make(map[int]Warehouse, len(d.warehouses)),
+		transactions: make(map[int]Transaction, len(d.transactions)),
+		returns:      make(map[int]SalesReturn, len(d.returns)),
+		transfers:    make(map[int]Transfer, len(d.transfers)),
+		opnames:      make(map[int]StockOpname, len(d.opnames)),
+		suppliers:    make(map[int]Supplier, len(d.suppliers)),
+		purchases:    make(map[int]PurchaseOrder, len(d.purchases)),
+		receipts:     make(map[int]GoodsReceipt, len(d.receipts)),
+		roles:        make(map[int]Role, len(d.roles)),
+		sessions:     make(map[string]Session, len(d.sessions)),
+		usedRefresh:  make(map[string]string, len(d.usedRefresh)),
+		throttle:     make(map[string]LoginThrottle, len(d.throttle)),
+		movements:    append([]StockMovement(nil), d.movements...),
+		costLayers:   append([]CostLayer(nil), d.costLayers...),
+		batches:      append([]StockBatch(nil), d.batches...),
+		trfBatches:   append([]TransferBatch(nil), d.trfBatches...),
+		trxBatches:   append([]TransactionBatch(nil), d.trxBatches...),
+		audit:        append([]AuditLog(nil), d.audit...),
+		lastID:       make(map[string]int, len(d.lastID)),
+	}
+	for k, v := range d.products {
+		c.products[k] = v
+	}
+	for k, v := range d.stocks {
+		c.stocks[k] = v
+	}
+	for k, v := range d.categories {
+		c.categories[k] = v
+	}
+	for k, v := range d.brands {
+		c.brands[k] = v
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.warehouses {
+		c.warehouses[k] = v
+	}
+	for k, v := range d.transactions {
+		c.transactions[k] = v
+	}
+	for k, v := range d.returns {
+		c.returns[k] = v
+	}
+	for k, v := range d.transfers {
+		c.transfers[k] = v
+	}
+	for k, v := range d.opnames {
+		c.opnames[k] = v
+	}
+	for k, v := range d.suppliers {
+		c.suppliers[k] = v
+	}
+	for k, v := range d.purchases {
+		c.purchases[k] = v
+	}
+	for k, v := range d.receipts {
+		c.receipts[k] = v
+	}
+	for k, v := range d.roles {
+		c.roles[k] = v
+	}
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range d.usedRefresh {
+		c.usedRefresh[k] = v
+	}
+	for k, v := range d.throttle {
+		c.throttle[k] = v
+	}
+	for k, v := range d.lastID {
+		c.lastID[k] = v
+	}
+	return c
+}
+
+func (d *memData) nextID(table string) int {
+	d.lastID[table]++
+	return d.lastID[table]
+}
+
+// memStore adalah implementasi Store di memory, dipakai untuk test tanpa database
+type memStore struct {
+	mu   *sync.Mutex
+	data **memData
+	inTx bool
+}
+
+// NewMemoryStore membuat Store kosong yang menyimpan data di memory
+func NewMemoryStore() Store {
+	d := &memData{
+		products:     make(map[int]Product),
+		stocks:       make(map[stockKey]ProductStock),
+		categories:   make(map[int]Category),
+		brands:       make(map[int]Brand),
+		users:        make(map[int]User),
+		warehouses:   make(map[int]Warehouse),
+		transactions: make(map[int]Transaction),
+		returns:      make(map[int]SalesReturn),
+		transfers:    make(map[int]Transfer),
+		opnames:      make(map[int]StockOpname),
+		suppliers:    make(map[int]Supplier),
+		purchases:    make(map[int]PurchaseOrder),
+		receipts:     make(map[int]GoodsReceipt),
+		roles:        make(map[int]Role),
+		sessions:     make(map[string]Session),
+		usedRefresh:  make(map[string]string),
+		throttle:     make(map[string]LoginThrottle),
+		lastID:       make(map[string]int),
+	}
+	return &memStore{mu: &sync.Mutex{}, data: &d}
+}
+
+// lock mengunci store kecuali sedang di dalam WithTx (kunci sudah dipegang)
+func (s *memStore) lock() (*memData, func()) {
+	if s.inTx {
+		return *s.data, func() {}
+	}
+	s.mu.Lock()
+	return *s.data, s.mu.Unlock
+}
+
+func (s *memStore) Products() ProductRepository            { return memProductRepo{s} }
+func (s *memStore) Categories() CategoryRepository         { return memCategoryRepo{s} }
+func (s *memStore) Brands() BrandRepository                { return memBrandRepo{s} }
+func (s *memStore) Users() UserRepository                  { return memUserRepo{s} }
+func (s *memStore) Warehouses() WarehouseRepository        { return memWarehouseRepo{s} }
+func (s *memStore) Transactions() TransactionRepository    { return memTransactionRepo{s} }
+func (s *memStore) Transfers() TransferRepository          { return memTransferRepo{s} }
+func (s *memStore) Opnames() OpnameRepository              { return memOpnameRepo{s} }
+func (s *memStore) Suppliers() SupplierRepository          { return memSupplierRepo{s} }
+func (s *memStore) Purchases() PurchaseRepository          { return memPurchaseRepo{s} }
+func (s *memStore) Movements() MovementRepository          { return memMovementRepo{s} }
+func (s *memStore) CostLayers() CostLayerRepository        { return memCostLayerRepo{s} }
+func (s *memStore) Batches() BatchRepository               { return memBatchRepo{s} }
+func (s *memStore) Roles() RoleRepository                  { return memRoleRepo{s} }
+func (s *memStore) Sessions() SessionRepository            { return memSessionRepo{s} }
+func (s *memStore) LoginThrottle() LoginThrottleRepository { return memThrottleRepo{s} }
+func (s *memStore) Audit() AuditRepository                 { return memAuditRepo{s} }
+
+func (s *memStore) WithTx(fn func(Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := (*s.data).clone()
+	if err := fn(&memStore{mu: s.mu, data: s.data, inTx: true}); err != nil {
+		*s.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// ===== Produk =====
+
+type memProductRepo struct{ s *memStore }
+
+// stockKey adalah primary key product_stocks
+type stockKey struct{ product, warehouse int }
+
+// productStocks mengembalikan stok produk id di semua gudang, urut ID gudang
+func (d *memData) productStocks(id int) []ProductStock {
+	var stocks []ProductStock
+	for k, st := range d.stocks {
+		if k.product == id {
+			stocks = append(stocks, st)
+		}
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i].WarehouseID < stocks[j].WarehouseID })
+	return stocks
+}
+
+// master meniru masterColumns: data master dengan stok total semua gudang
+func (d *memData) master(p Product) Product {
+	p.Stock, p.WarehouseID = 0, 0
+	for _, st := range d.productStocks(p.ID) {
+		p.Stock += st.Stock
+	}
+	return p
+}
+
+func (r memProductRepo) List(warehouseID *int) ([]Product, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var products []Product
+	for _, p := range d.products {
+		stocks := d.productStocks(p.ID)
+		if warehouseID == nil && len(stocks) == 0 {
+			products = append(products, p)
+		}
+		for _, st := range stocks {
+			if warehouseID == nil || st.WarehouseID == *warehouseID {
+				products = append(products, productInWarehouse(p, st))
+			}
+		}
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].ID != products[j].ID {
+			return products[i].ID < products[j].ID
+		}
+		return products[i].WarehouseID < products[j].WarehouseID
+	})
+	return products, nil
+}
+
+func (r memProductRepo) Search(filter ProductFilter, page, limit int) ([]Product, int, error) {
+	all, _ := r.List(filter.WarehouseID)
+
+	search := strings.ToLower(filter.Search)
+	categories := filter.categories()
+	var matched []Product
+	for _, p := range all {
+		if search != "" && !strings.Contains(strings.ToLower(p.Name), search) &&
+			strings.ToLower(p.SKU) != search && strings.ToLower(p.Barcode) != search {
+			continue
+		}
+		if categories != nil && (p.CategoryID == nil || !containsInt(categories, *p.CategoryID)) {
+			continue
+		}
+		if filter.BrandID != nil && (p.BrandID == nil || *p.BrandID != *filter.BrandID) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	// Varian ditampilkan tepat di bawah produk induknya
+	sort.SliceStable(matched, func(i, j int) bool { return groupID(matched[i]) < groupID(matched[j]) })
+
+	return paginate(matched, page, limit), len(matched), nil
+}
+
+func (r memProductRepo) GetByID(id int) (*Product, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	p, ok := d.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	p = d.master(p)
+	return &p, nil
+}
+
+func (r memProductRepo) FindByCode(code string, warehouseID *int) ([]Product, error) {
+	all, _ := r.List(warehouseID)
+
+	var matched []Product
+	for _, p := range all {
+		if code != "" && (p.Barcode == code || p.SKU == code) {
+			matched = append(matched, p)
+		}
+	}
+	return matched, nil
+}
+
+func (r memProductRepo) Variants(parentID int) ([]Product, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var variants []Product
+	for _, p := range d.products {
+		if p.ParentID != nil && *p.ParentID == parentID {
+			variants = append(variants, d.master(p))
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+	return variants, nil
+}
+
+// groupID meniru ORDER BY COALESCE(parent_id, id)
+func groupID(p Product) int {
+	if p.ParentID != nil {
+		return *p.ParentID
+	}
+	return p.ID
+}
+
+// codeTaken meniru unique index sku dan barcode
+func (d *memData) codeTaken(p *Product) bool {
+	for _, other := range d.products {
+		if other.ID == p.ID {
+			continue
+		}
+		if (p.SKU != "" && other.SKU == p.SKU) || (p.Barcode != "" && other.Barcode == p.Barcode) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memProductRepo) Create(p *Product) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if d.codeTaken(p) {
+		return ErrDuplicate
+	}
+	p.ID = d.nextID("products")
+	p.CreatedAt = time.Now()
+	master := *p
+	master.Stock, master.WarehouseID = 0, 0
+	d.products[p.ID] = master
+	return nil
+}
+
+func (r memProductRepo) Update(p *Product) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.products[p.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Name, current.PurchasePrice, current.SellingPrice = p.Name, p.PurchasePrice, p.SellingPrice
+	current.SKU, current.Barcode = p.SKU, p.Barcode
+	current.CategoryID, current.BrandID, current.Variant = p.CategoryID, p.BrandID, p.Variant
+	if d.codeTaken(&current) {
+		return ErrDuplicate
+	}
+	d.products[p.ID] = current
+	return nil
+}
+
+func (r memProductRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.products[id]; !ok {
+		return ErrNotFound
+	}
+	for _, t := range d.transactions {
+		for _, item := range t.Items {
+			if item.ProductID == id {
+				return errors.New("produk masih dipakai di transaksi")
+			}
+		}
+	}
+	for _, t := range d.transfers {
+		for _, item := range t.Items {
+			if item.ProductID == id {
+				return errors.New("produk masih dipakai di transfer stok")
+			}
+		}
+	}
+	for _, o := range d.opnames {
+		for _, item := range o.Items {
+			if item.ProductID == id {
+				return errors.New("produk masih dipakai di stock opname")
+			}
+		}
+	}
+	for _, o := range d.purchases {
+		for _, item := range o.Items {
+			if item.ProductID == id {
+				return errors.New("produk masih dipakai di purchase order")
+			}
+		}
+	}
+	for _, p := range d.products {
+		if p.ParentID != nil && *p.ParentID == id {
+			return errors.New("produk masih punya varian")
+		}
+	}
+	delete(d.products, id)
+	// ON DELETE CASCADE
+	for k := range d.stocks {
+		if k.product == id {
+			delete(d.stocks, k)
+		}
+	}
+	var movements []StockMovement
+	for _, m := range d.movements {
+		if m.ProductID != id {
+			movements = append(movements, m)
+		}
+	}
+	d.movements = movements
+	var layers []CostLayer
+	for _, l := range d.costLayers {
+		if l.ProductID != id {
+			layers = append(layers, l)
+		}
+	}
+	d.costLayers = layers
+	var batches []StockBatch
+	for _, b := range d.batches {
+		if b.ProductID != id {
+			batches = append(batches, b)
+		}
+	}
+	d.batches = batches
+	return nil
+}
+
+func (r memProductRepo) Stocks(productID int) ([]ProductStock, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	return d.productStocks(productID), nil
+}
+
+func (r memProductRepo) SaveStock(st *ProductStock) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.products[st.ProductID]; !ok {
+		return errors.New("produk tidak ditemukan")
+	}
+	if _, ok := d.warehouses[st.WarehouseID]; !ok {
+		return errors.New("gudang tidak ditemukan")
+	}
+	key := stockKey{st.ProductID, st.WarehouseID}
+	saved := *st
+	saved.ReorderPoint = d.stocks[key].ReorderPoint
+	d.stocks[key] = saved
+	return nil
+}
+
+func (r memProductRepo) SetReorderPoint(productID, warehouseID, level int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	key := stockKey{productID, warehouseID}
+	st, ok := d.stocks[key]
+	if !ok {
+		return ErrNotFound
+	}
+	st.ReorderPoint = level
+	d.stocks[key] = st
+	return nil
+}
+
+func (r memProductRepo) ReorderLevels(warehouseID *int) ([]ProductStock, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var stocks []ProductStock
+	for _, st := range d.stocks {
+		if st.ReorderPoint > 0 && (warehouseID == nil || st.WarehouseID == *warehouseID) {
+			stocks = append(stocks, st)
+		}
+	}
+	sort.Slice(stocks, func(i, j int) bool {
+		if stocks[i].WarehouseID != stocks[j].WarehouseID {
+			return stocks[i].WarehouseID < stocks[j].WarehouseID
+		}
+		return stocks[i].ProductID < stocks[j].ProductID
+	})
+	return stocks, nil
+}
+
+func (r memProductRepo) DeleteStock(productID, warehouseID int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	key := stockKey{productID, warehouseID}
+	if _, ok := d.stocks[key]; !ok {
+		return ErrNotFound
+	}
+	delete(d.stocks, key)
+	return nil
+}
+
+func (r memProductRepo) DecrementStock(id, warehouseID, quantity int) (int, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	key := stockKey{id, warehouseID}
+	st, ok := d.stocks[key]
+	if !ok || st.Stock < quantity {
+		return 0, ErrInsufficientStock
+	}
+	st.Stock -= quantity
+	d.stocks[key] = st
+	return st.Stock, nil
+}
+
+func (r memProductRepo) IncrementStock(id, warehouseID, quantity int) (int, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	key := stockKey{id, warehouseID}
+	st, ok := d.stocks[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	st.Stock += quantity
+	d.stocks[key] = st
+	return st.Stock, nil
+}
+
+// ===== Kategori =====
+
+type memCategoryRepo struct{ s *memStore }
+
+func (r memCategoryRepo) List() ([]Category, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var categories []Category
+	for _, c := range d.categories {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+func (r memCategoryRepo) GetByID(id int) (*Category, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	c, ok := d.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+// categoryNameTaken meniru unique index (COALESCE(parent_id, 0), name)
+func (d *memData) categoryNameTaken(c *Category) bool {
+	for _, other := range d.categories {
+		if other.ID != c.ID && other.Name == c.Name && sameID(other.ParentID, c.ParentID) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameID membandingkan dua ID opsional (nil sama dengan nil)
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (r memCategoryRepo) Create(c *Category) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if d.categoryNameTaken(c) {
+		return ErrDuplicate
+	}
+	c.ID = d.nextID("categories")
+	c.CreatedAt = time.Now()
+	stored := *c
+	stored.Path = ""
+	d.categories[c.ID] = stored
+	return nil
+}
+
+func (r memCategoryRepo) Update(c *Category) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.categories[c.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Name, current.ParentID = c.Name, c.ParentID
+	if d.categoryNameTaken(&current) {
+		return ErrDuplicate
+	}
+	d.categories[c.ID] = current
+	return nil
+}
+
+func (r memCategoryRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.categories[id]; !ok {
+		return ErrNotFound
+	}
+	for _, c := range d.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return errors.New("kategori masih dipakai oleh subkategori")
+		}
+	}
+	for _, p := range d.products {
+		if p.CategoryID != nil && *p.CategoryID == id {
+			return errors.New("kategori masih dipakai oleh produk")
+		}
+	}
+	delete(d.categories, id)
+	return nil
+}
+
+// ===== Merek =====
+
+type memBrandRepo struct{ s *memStore }
+
+func (r memBrandRepo) List() ([]Brand, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var brands []Brand
+	for _, b := range d.brands {
+		brands = append(brands, b)
+	}
+	sort.Slice(brands, func(i, j int) bool { return brands[i].Name < brands[j].Name })
+	return brands, nil
+}
+
+func (r memBrandRepo) GetByID(id int) (*Brand, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	b, ok := d.brands[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &b, nil
+}
+
+func (d *memData) brandNameTaken(b *Brand) bool {
+	for _, other := range d.brands {
+		if other.ID != b.ID && other.Name == b.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memBrandRepo) Create(b *Brand) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if d.brandNameTaken(b) {
+		return ErrDuplicate
+	}
+	b.ID = d.nextID("brands")
+	b.CreatedAt = time.Now()
+	d.brands[b.ID] = *b
+	return nil
+}
+
+func (r memBrandRepo) Update(b *Brand) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.brands[b.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Name = b.Name
+	if d.brandNameTaken(&current) {
+		return ErrDuplicate
+	}
+	d.brands[b.ID] = current
+	return nil
+}
+
+func (r memBrandRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.brands[id]; !ok {
+		return ErrNotFound
+	}
+	for _, p := range d.products {
+		if p.BrandID != nil && *p.BrandID == id {
+			return errors.New("merek masih dipakai oleh produk")
+		}
+	}
+	delete(d.brands, id)
+	return nil
+}
+
+// ===== User =====
+
+type memUserRepo struct{ s *memStore }
+
+func (r memUserRepo) List() ([]User, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var users []User
+	for _, u := range d.users {
+		u.Password = ""
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r memUserRepo) GetByID(id int) (*User, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	u, ok := d.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r memUserRepo) GetByUsername(username string) (*User, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for _, u := range d.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memUserRepo) Create(u *User) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for _, existing := range d.users {
+		if existing.Username == u.Username {
+			return errors.New("username sudah digunakan")
+		}
+	}
+	if _, ok := findRole(d, u.Role); !ok {
+		return errors.New("role tidak ditemukan")
+	}
+
+	u.ID = d.nextID("users")
+	u.CreatedAt = time.Now()
+	stored := *u
+	stored.Permissions = nil
+	d.users[u.ID] = stored
+	return nil
+}
+
+func (r memUserRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.users[id]; !ok {
+		return ErrNotFound
+	}
+	for _, t := range d.transactions {
+		if t.UserID == id || t.VoidedByID == id {
+			return errors.New("user masih dipakai di transaksi")
+		}
+	}
+	delete(d.users, id)
+	for sid, s := range d.sessions {
+		if s.UserID == id {
+			delete(d.sessions, sid)
+		}
+	}
+	for hash, sid := range d.usedRefresh {
+		if _, ok := d.sessions[sid]; !ok {
+			delete(d.usedRefresh, hash)
+		}
+	}
+	for i := range d.audit {
+		if d.audit[i].UserID != nil && *d.audit[i].UserID == id {
+			d.audit[i].UserID = nil
+		}
+	}
+	return nil
+}
+
+func (r memUserRepo) UpdatePassword(id int, hash string) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	u, ok := d.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.Password = hash
+	d.users[id] = u
+	return nil
+}
+
+// ===== Gudang =====
+
+type memWarehouseRepo struct{ s *memStore }
+
+func (r memWarehouseRepo) List() ([]Warehouse, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var warehouses []Warehouse
+	for _, w := range d.warehouses {
+		warehouses = append(warehouses, w)
+	}
+	sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].ID < warehouses[j].ID })
+	return warehouses, nil
+}
+
+func (r memWarehouseRepo) GetByID(id int) (*Warehouse, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	w, ok := d.warehouses[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &w, nil
+}
+
+func (r memWarehouseRepo) Create(w *Warehouse) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	w.ID = d.nextID("warehouses")
+	w.CreatedAt = time.Now()
+	d.warehouses[w.ID] = *w
+	return nil
+}
+
+func (r memWarehouseRepo) Update(w *Warehouse) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.warehouses[w.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Name, current.Address = w.Name, w.Address
+	d.warehouses[w.ID] = current
+	return nil
+}
+
+func (r memWarehouseRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.warehouses[id]; !ok {
+		return ErrNotFound
+	}
+	for k := range d.stocks {
+		if k.warehouse == id {
+			return errors.New("gudang masih dipakai oleh produk")
+		}
+	}
+	for _, u := range d.users {
+		if u.WarehouseID != nil && *u.WarehouseID == id {
+			return errors.New("gudang masih dipakai oleh user")
+		}
+	}
+	for _, t := range d.transfers {
+		if t.FromWarehouseID == id || t.ToWarehouseID == id {
+			return errors.New("gudang masih dipakai di transfer stok")
+		}
+	}
+	for _, o := range d.opnames {
+		if o.WarehouseID == id {
+			return errors.New("gudang masih dipakai di stock opname")
+		}
+	}
+	for _, o := range d.purchases {
+		if o.WarehouseID == id {
+			return errors.New("gudang masih dipakai di purchase order")
+		}
+	}
+	for _, m := range d.movements {
+		if m.WarehouseID == id {
+			return errors.New("gudang masih punya riwayat mutasi stok")
+		}
+	}
+	delete(d.warehouses, id)
+	return nil
+}
+
+// ===== Transaksi =====
+
+type memTransactionRepo struct{ s *memStore }
+
+func (r memTransactionRepo) Create(t *Transaction) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	t.ID = d.nextID("transactions")
+	t.CreatedAt = time.Now()
+	for i := range t.Items {
+		t.Items[i].ID = d.nextID("transaction_items")
+		t.Items[i].TransactionID = t.ID
+	}
+
+	stored := *t
+	stored.CashierName = ""
+	stored.Items = append([]TransactionItem(nil), t.Items...)
+	d.transactions[t.ID] = stored
+	return nil
+}
+
+func (r memTransactionRepo) ListByDate(from, to time.Time, warehouseID *int) ([]Transaction, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var transactions []Transaction
+	for _, t := range d.transactions {
+		if t.CreatedAt.Before(from) || !t.CreatedAt.Before(to) {
+			continue
+		}
+		if warehouseID != nil && t.WarehouseID != *warehouseID {
+			continue
+		}
+		if u, ok := d.users[t.UserID]; ok {
+			t.CashierName = u.Username
+		}
+		t.VoidedBy = d.users[t.VoidedByID].Username
+		transactions = append(transactions, t)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.After(transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+func (r memTransactionRepo) Items(transactionID int) ([]TransactionItem, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	return append([]TransactionItem(nil), d.transactions[transactionID].Items...), nil
+}
+
+func (r memTransactionRepo) Summary(from, to time.Time, warehouseID *int) (float64, float64, int, error) {
+	transactions, _ := r.ListByDate(from, to, warehouseID)
+
+	var total, profit float64
+	count := 0
+	for _, t := range transactions {
+		if t.Voided() {
+			continue
+		}
+		count++
+		total += t.Total
+		profit += t.Profit
+	}
+	return total, profit, count, nil
+}
+
+func (r memTransactionRepo) GetByID(id int) (*Transaction, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	t, ok := d.transactions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if u, ok := d.users[t.UserID]; ok {
+		t.CashierName = u.Username
+	}
+	t.VoidedBy = d.users[t.VoidedByID].Username
+	t.Items = append([]TransactionItem(nil), t.Items...)
+	return &t, nil
+}
+
+func (r memTransactionRepo) Void(t *Transaction) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.transactions[t.ID]
+	if !ok || current.Voided() {
+		return ErrNotFound
+	}
+	current.VoidedAt, current.VoidedByID, current.VoidReason = t.VoidedAt, t.VoidedByID, t.VoidReason
+	d.transactions[t.ID] = current
+	return nil
+}
+
+func (r memTransactionRepo) CreateReturn(ret *SalesReturn) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.transactions[ret.TransactionID]; !ok {
+		return ErrNotFound
+	}
+	ret.ID = d.nextID("sales_returns")
+	ret.CreatedAt = time.Now()
+	for i := range ret.Items {
+		ret.Items[i].ID = d.nextID("sales_return_items")
+		ret.Items[i].ReturnID = ret.ID
+	}
+
+	stored := *ret
+	stored.CashierName = ""
+	stored.Items = append([]ReturnItem(nil), ret.Items...)
+	d.returns[ret.ID] = stored
+	return nil
+}
+
+// returnsWhere mengambil retur yang lolos filter, urut ID naik
+func (r memTransactionRepo) returnsWhere(keep func(SalesReturn) bool) []SalesReturn {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var returns []SalesReturn
+	for _, ret := range d.returns {
+		if !keep(ret) {
+			continue
+		}
+		if u, ok := d.users[ret.UserID]; ok {
+			ret.CashierName = u.Username
+		}
+		ret.Items = append([]ReturnItem(nil), ret.Items...)
+		returns = append(returns, ret)
+	}
+	sort.Slice(returns, func(i, j int) bool { return returns[i].ID < returns[j].ID })
+	return returns
+}
+
+func (r memTransactionRepo) Returns(transactionID int) ([]SalesReturn, error) {
+	return r.returnsWhere(func(ret SalesReturn) bool { return ret.TransactionID == transactionID }), nil
+}
+
+func (r memTransactionRepo) ReturnsByDate(from, to time.Time, warehouseID *int) ([]SalesReturn, error) {
+	returns := r.returnsWhere(func(ret SalesReturn) bool {
+		if ret.CreatedAt.Before(from) || !ret.CreatedAt.Before(to) {
+			return false
+		}
+		return warehouseID == nil || ret.WarehouseID == *warehouseID
+	})
+	sort.SliceStable(returns, func(i, j int) bool { return returns[i].ID > returns[j].ID })
+	return returns, nil
+}
+
+func (r memTransactionRepo) ReturnSummary(from, to time.Time, warehouseID *int) (float64, float64, int, error) {
+	returns, _ := r.ReturnsByDate(from, to, warehouseID)
+
+	var total, profit float64
+	for _, ret := range returns {
+		total += ret.Total
+		profit += ret.Profit
+	}
+	return total, profit, len(returns), nil
+}
+
+// ===== Transfer Stok =====
+
+type memTransferRepo struct{ s *memStore }
+
+func (r memTransferRepo) Create(t *Transfer) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	t.ID = d.nextID("stock_transfers")
+	t.CreatedAt = time.Now()
+	for i := range t.Items {
+		t.Items[i].ID = d.nextID("stock_transfer_items")
+		t.Items[i].TransferID = t.ID
+	}
+
+	stored := *t
+	stored.CreatedBy = ""
+	stored.Items = append([]TransferItem(nil), t.Items...)
+	d.transfers[t.ID] = stored
+	return nil
+}
+
+// withUser melengkapi username pembuat transfer seperti LEFT JOIN users
+func (d *memData) withUser(t Transfer) Transfer {
+	if u, ok := d.users[t.UserID]; ok {
+		t.CreatedBy = u.Username
+	}
+	return t
+}
+
+func (r memTransferRepo) GetByID(id int) (*Transfer, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	t, ok := d.transfers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	t = d.withUser(t)
+	t.Items = append([]TransferItem(nil), t.Items...)
+	return &t, nil
+}
+
+func (r memTransferRepo) List(warehouseID *int, status string) ([]Transfer, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var transfers []Transfer
+	for _, t := range d.transfers {
+		if warehouseID != nil && t.FromWarehouseID != *warehouseID && t.ToWarehouseID != *warehouseID {
+			continue
+		}
+		if status != "" && t.Status != status {
+			continue
+		}
+		t = d.withUser(t)
+		t.Items = nil
+		transfers = append(transfers, t)
+	}
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].ID > transfers[j].ID })
+	return transfers, nil
+}
+
+func (r memTransferRepo) Update(t *Transfer) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.transfers[t.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Status, current.Note, current.SentAt, current.ReceivedAt = t.Status, t.Note, t.SentAt, t.ReceivedAt
+	items := append([]TransferItem(nil), current.Items...)
+	for i := range items {
+		for _, updated := range t.Items {
+			if updated.ID == items[i].ID {
+				items[i].Received, items[i].UnitCost = updated.Received, updated.UnitCost
+			}
+		}
+	}
+	current.Items = items
+	d.transfers[t.ID] = current
+	return nil
+}
+
+func (r memTransferRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.transfers[id]; !ok {
+		return ErrNotFound
+	}
+	delete(d.transfers, id)
+	return nil
+}
+
+// ===== Stock Opname =====
+
+type memOpnameRepo struct{ s *memStore }
+
+func (r memOpnameRepo) Create(o *StockOpname) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	o.ID = d.nextID("stock_opnames")
+	o.CreatedAt = time.Now()
+	for i := range o.Items {
+		o.Items[i].ID = d.nextID("stock_opname_items")
+		o.Items[i].OpnameID = o.ID
+	}
+
+	stored := *o
+	stored.CreatedBy, stored.ApprovedBy = "", ""
+	stored.Items = append([]StockOpnameItem(nil), o.Items...)
+	d.opnames[o.ID] = stored
+	return nil
+}
+
+// withOpnameUsers melengkapi username pembuat dan penyetuju seperti LEFT JOIN users
+func (d *memData) withOpnameUsers(o StockOpname) StockOpname {
+	if u, ok := d.users[o.UserID]; ok {
+		o.CreatedBy = u.Username
+	}
+	if u, ok := d.users[o.ApprovedByID]; ok {
+		o.ApprovedBy = u.Username
+	}
+	return o
+}
+
+func (r memOpnameRepo) GetByID(id int) (*StockOpname, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	o, ok := d.opnames[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	o = d.withOpnameUsers(o)
+	o.Items = append([]StockOpnameItem(nil), o.Items...)
+	return &o, nil
+}
+
+func (r memOpnameRepo) List(warehouseID *int, status string) ([]StockOpname, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var opnames []StockOpname
+	for _, o := range d.opnames {
+		if warehouseID != nil && o.WarehouseID != *warehouseID {
+			continue
+		}
+		if status != "" && o.Status != status {
+			continue
+		}
+		o = d.withOpnameUsers(o)
+		o.Items = nil
+		opnames = append(opnames, o)
+	}
+	sort.Slice(opnames, func(i, j int) bool { return opnames[i].ID > opnames[j].ID })
+	return opnames, nil
+}
+
+func (r memOpnameRepo) Update(o *StockOpname) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.opnames[o.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Status, current.Note, current.ApprovedByID, current.ApprovedAt = o.Status, o.Note, o.ApprovedByID, o.ApprovedAt
+	items := append([]StockOpnameItem(nil), current.Items...)
+	for i := range items {
+		for _, updated := range o.Items {
+			if updated.ID == items[i].ID {
+				items[i].Counted = updated.Counted
+			}
+		}
+	}
+	current.Items = items
+	d.opnames[o.ID] = current
+	return nil
+}
+
+func (r memOpnameRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.opnames[id]; !ok {
+		return ErrNotFound
+	}
+	delete(d.opnames, id)
+	return nil
+}
+
+// ===== Supplier =====
+
+type memSupplierRepo struct{ s *memStore }
+
+func (r memSupplierRepo) List() ([]Supplier, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var suppliers []Supplier
+	for _, sup := range d.suppliers {
+		suppliers = append(suppliers, sup)
+	}
+	sort.Slice(suppliers, func(i, j int) bool { return suppliers[i].Name < suppliers[j].Name })
+	return suppliers, nil
+}
+
+func (r memSupplierRepo) GetByID(id int) (*Supplier, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	sup, ok := d.suppliers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &sup, nil
+}
+
+func (d *memData) supplierNameTaken(sup *Supplier) bool {
+	for _, other := range d.suppliers {
+		if other.ID != sup.ID && other.Name == sup.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memSupplierRepo) Create(sup *Supplier) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if d.supplierNameTaken(sup) {
+		return ErrDuplicate
+	}
+	sup.ID = d.nextID("suppliers")
+	sup.CreatedAt = time.Now()
+	d.suppliers[sup.ID] = *sup
+	return nil
+}
+
+func (r memSupplierRepo) Update(sup *Supplier) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.suppliers[sup.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Name, current.Phone, current.Address = sup.Name, sup.Phone, sup.Address
+	if d.supplierNameTaken(&current) {
+		return ErrDuplicate
+	}
+	d.suppliers[sup.ID] = current
+	return nil
+}
+
+func (r memSupplierRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.suppliers[id]; !ok {
+		return ErrNotFound
+	}
+	for _, o := range d.purchases {
+		if o.SupplierID == id {
+			return errors.New("supplier masih dipakai di purchase order")
+		}
+	}
+	delete(d.suppliers, id)
+	return nil
+}
+
+// ===== Purchase Order =====
+
+type memPurchaseRepo struct{ s *memStore }
+
+func (r memPurchaseRepo) Create(o *PurchaseOrder) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	o.ID = d.nextID("purchase_orders")
+	o.CreatedAt = time.Now()
+	for i := range o.Items {
+		o.Items[i].ID = d.nextID("purchase_order_items")
+		o.Items[i].OrderID = o.ID
+	}
+
+	stored := *o
+	stored.SupplierName, stored.CreatedBy = "", ""
+	stored.Items = append([]PurchaseOrderItem(nil), o.Items...)
+	d.purchases[o.ID] = stored
+	return nil
+}
+
+// withPurchaseNames melengkapi nama supplier dan username pembuat PO seperti JOIN
+func (d *memData) withPurchaseNames(o PurchaseOrder) PurchaseOrder {
+	o.SupplierName = d.suppliers[o.SupplierID].Name
+	if u, ok := d.users[o.UserID]; ok {
+		o.CreatedBy = u.Username
+	}
+	return o
+}
+
+func (r memPurchaseRepo) GetByID(id int) (*PurchaseOrder, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	o, ok := d.purchases[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	o = d.withPurchaseNames(o)
+	o.Items = append([]PurchaseOrderItem(nil), o.Items...)
+	return &o, nil
+}
+
+func (r memPurchaseRepo) List(filter PurchaseFilter) ([]PurchaseOrder, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var orders []PurchaseOrder
+	for _, o := range d.purchases {
+		if filter.WarehouseID != nil && o.WarehouseID != *filter.WarehouseID {
+			continue
+		}
+		if filter.SupplierID != 0 && o.SupplierID != filter.SupplierID {
+			continue
+		}
+		if filter.Status != "" && o.Status != filter.Status {
+			continue
+		}
+		if filter.Outstanding && o.Status == PurchaseReceived {
+			continue
+		}
+		o = d.withPurchaseNames(o)
+		o.Items = nil
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	return orders, nil
+}
+
+func (r memPurchaseRepo) Update(o *PurchaseOrder) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	current, ok := d.purchases[o.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	current.Status, current.Note = o.Status, o.Note
+	items := append([]PurchaseOrderItem(nil), current.Items...)
+	for i := range items {
+		for _, updated := range o.Items {
+			if updated.ID == items[i].ID {
+				items[i].Received = updated.Received
+			}
+		}
+	}
+	current.Items = items
+	d.purchases[o.ID] = current
+	return nil
+}
+
+func (r memPurchaseRepo) Delete(id int) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.purchases[id]; !ok {
+		return ErrNotFound
+	}
+	for _, gr := range d.receipts {
+		if gr.OrderID == id {
+			return errors.New("purchase order sudah punya penerimaan barang")
+		}
+	}
+	delete(d.purchases, id)
+	return nil
+}
+
+func (r memPurchaseRepo) CreateReceipt(gr *GoodsReceipt) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := d.purchases[gr.OrderID]; !ok {
+		return ErrNotFound
+	}
+	gr.ID = d.nextID("goods_receipts")
+	gr.CreatedAt = time.Now()
+	for i := range gr.Items {
+		gr.Items[i].ID = d.nextID("goods_receipt_items")
+		gr.Items[i].ReceiptID = gr.ID
+	}
+
+	stored := *gr
+	stored.SupplierID, stored.SupplierName, stored.ReceivedBy = 0, "", ""
+	stored.Items = append([]GoodsReceiptItem(nil), gr.Items...)
+	d.receipts[gr.ID] = stored
+	return nil
+}
+
+func (r memPurchaseRepo) Receipts(filter ReceiptFilter) ([]GoodsReceipt, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var receipts []GoodsReceipt
+	for _, gr := range d.receipts {
+		gr.SupplierID = d.purchases[gr.OrderID].SupplierID
+		if filter.SupplierID != 0 && gr.SupplierID != filter.SupplierID {
+			continue
+		}
+		if filter.WarehouseID != nil && gr.WarehouseID != *filter.WarehouseID {
+			continue
+		}
+		if filter.From != nil && gr.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !gr.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		gr.SupplierName = d.suppliers[gr.SupplierID].Name
+		if u, ok := d.users[gr.UserID]; ok {
+			gr.ReceivedBy = u.Username
+		}
+		gr.Items = nil
+		receipts = append(receipts, gr)
+	}
+	sort.Slice(receipts, func(i, j int) bool { return receipts[i].ID > receipts[j].ID })
+	return receipts, nil
+}
+
+// ===== Mutasi Stok =====
+
+type memMovementRepo struct{ s *memStore }
+
+func (r memMovementRepo) Create(m *StockMovement) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	m.ID = d.nextID("stock_movements")
+	d.movements = append(d.movements, *m)
+	return nil
+}
+
+func (r memMovementRepo) List(filter MovementFilter) ([]StockMovement, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var movements []StockMovement
+	for _, m := range d.movements {
+		if filter.ProductID > 0 && m.ProductID != filter.ProductID {
+			continue
+		}
+		if filter.WarehouseID != nil && m.WarehouseID != *filter.WarehouseID {
+			continue
+		}
+		if filter.From != nil && m.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !m.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
+
+// ===== Lapis Harga Pokok =====
+
+type memCostLayerRepo struct{ s *memStore }
+
+func (r memCostLayerRepo) Create(l *CostLayer) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	l.ID = d.nextID("cost_layers")
+	d.costLayers = append(d.costLayers, *l)
+	return nil
+}
+
+func (r memCostLayerRepo) Open(productID, warehouseID int) ([]CostLayer, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var layers []CostLayer
+	for _, l := range d.costLayers {
+		if l.ProductID == productID && l.WarehouseID == warehouseID && l.Remaining > 0 {
+			layers = append(layers, l)
+		}
+	}
+	return layers, nil
+}
+
+func (r memCostLayerRepo) Update(l *CostLayer) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for i := range d.costLayers {
+		if d.costLayers[i].ID == l.ID {
+			d.costLayers[i].Remaining, d.costLayers[i].UnitCost = l.Remaining, l.UnitCost
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ===== Batch Stok =====
+
+type memBatchRepo struct{ s *memStore }
+
+func (r memBatchRepo) Create(b *StockBatch) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	b.ID = d.nextID("stock_batches")
+	d.batches = append(d.batches, *b)
+	return nil
+}
+
+func (r memBatchRepo) GetByID(id int) (*StockBatch, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for _, b := range d.batches {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// sortBatches mengurutkan batch dari yang paling cepat kedaluwarsa
+func sortBatches(batches []StockBatch) {
+	sort.SliceStable(batches, func(i, j int) bool {
+		if !batches[i].ExpiryDate.Equal(batches[j].ExpiryDate) {
+			return batches[i].ExpiryDate.Before(batches[j].ExpiryDate)
+		}
+		return batches[i].ID < batches[j].ID
+	})
+}
+
+func (r memBatchRepo) Open(productID, warehouseID int) ([]StockBatch, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var batches []StockBatch
+	for _, b := range d.batches {
+		if b.ProductID == productID && b.WarehouseID == warehouseID && b.Remaining > 0 {
+			batches = append(batches, b)
+		}
+	}
+	sortBatches(batches)
+	return batches, nil
+}
+
+func (r memBatchRepo) Expiring(warehouseID *int, before time.Time) ([]StockBatch, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var batches []StockBatch
+	for _, b := range d.batches {
+		if b.Remaining > 0 && b.ExpiryDate.Before(before) && (warehouseID == nil || b.WarehouseID == *warehouseID) {
+			batches = append(batches, b)
+		}
+	}
+	sortBatches(batches)
+	return batches, nil
+}
+
+func (r memBatchRepo) Update(b *StockBatch) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for i := range d.batches {
+		if d.batches[i].ID == b.ID {
+			d.batches[i].Remaining = b.Remaining
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r memBatchRepo) CreateTransferBatch(tb *TransferBatch) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	tb.ID = d.nextID("stock_transfer_batches")
+	d.trfBatches = append(d.trfBatches, *tb)
+	return nil
+}
+
+func (r memBatchRepo) TransferBatches(transferID int) ([]TransferBatch, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var batches []TransferBatch
+	for _, tb := range d.trfBatches {
+		if tb.TransferID == transferID {
+			batches = append(batches, tb)
+		}
+	}
+	return batches, nil
+}
+
+func (r memBatchRepo) CreateTransactionBatch(tb *TransactionBatch) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	tb.ID = d.nextID("transaction_item_batches")
+	d.trxBatches = append(d.trxBatches, *tb)
+	return nil
+}
+
+func (r memBatchRepo) TransactionBatches(transactionID int) ([]TransactionBatch, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var batches []TransactionBatch
+	for _, tb := range d.trxBatches {
+		if tb.TransactionID == transactionID {
+			batches = append(batches, tb)
+		}
+	}
+	return batches, nil
+}
+
+// ===== Role =====
+
+type memRoleRepo struct{ s *memStore }
+
+func findRole(d *memData, name string) (Role, bool) {
+	for _, role := range d.roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return Role{}, false
+}
+
+func (r memRoleRepo) List() ([]Role, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var roles []Role
+	for _, role := range d.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+	return roles, nil
+}
+
+func (r memRoleRepo) GetByName(name string) (*Role, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	role, ok := findRole(d, name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &role, nil
+}
+
+func (r memRoleRepo) Permissions(roleName string) ([]string, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	role, _ := findRole(d, roleName)
+	return role.Permissions, nil
+}
+
+func (r memRoleRepo) Create(role *Role) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	if _, ok := findRole(d, role.Name); ok {
+		return errors.New("role sudah ada")
+	}
+	role.ID = d.nextID("roles")
+	role.CreatedAt = time.Now()
+	role.Permissions = uniqueSorted(role.Permissions)
+	d.roles[role.ID] = *role
+	return nil
+}
+
+func (r memRoleRepo) SetPermissions(roleID int, permissions []string) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	role, ok := d.roles[roleID]
+	if !ok {
+		return ErrNotFound
+	}
+	role.Permissions = uniqueSorted(permissions)
+	d.roles[roleID] = role
+	return nil
+}
+
+func (r memRoleRepo) Delete(name string) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	role, ok := findRole(d, name)
+	if !ok {
+		return ErrNotFound
+	}
+	delete(d.roles, role.ID)
+	return nil
+}
+
+func (r memRoleRepo) CountUsers(name string) (int, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	count := 0
+	for _, u := range d.users {
+		if u.Role == name {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func uniqueSorted(list []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ===== Sesi API =====
+
+type memSessionRepo struct{ s *memStore }
+
+func (r memSessionRepo) Create(s *Session) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for _, existing := range d.sessions {
+		if existing.RefreshTokenHash == s.RefreshTokenHash {
+			return errors.New("refresh token sudah digunakan")
+		}
+	}
+	s.CreatedAt = time.Now()
+	d.sessions[s.ID] = *s
+	return nil
+}
+
+func (r memSessionRepo) GetByID(id string) (*Session, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	s, ok := d.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r memSessionRepo) GetActiveByRefreshHash(refreshTokenHash string, now time.Time) (*Session, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for _, s := range d.sessions {
+		if s.RefreshTokenHash == refreshTokenHash && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			return &s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memSessionRepo) RotateRefresh(id, oldHash, newHash string, expiresAt time.Time) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	s, ok := d.sessions[id]
+	if !ok || s.RefreshTokenHash != oldHash || s.RevokedAt != nil {
+		return ErrNotFound
+	}
+	s.RefreshTokenHash, s.ExpiresAt = newHash, expiresAt
+	d.sessions[id] = s
+	d.usedRefresh[oldHash] = id
+	return nil
+}
+
+func (r memSessionRepo) GetByUsedRefreshHash(refreshTokenHash string) (*Session, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	id, ok := d.usedRefresh[refreshTokenHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s, ok := d.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r memSessionRepo) Revoke(id string, at time.Time) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	s, ok := d.sessions[id]
+	if ok && s.RevokedAt == nil {
+		s.RevokedAt = &at
+		d.sessions[id] = s
+	}
+	return nil
+}
+
+func (r memSessionRepo) RevokeUser(userID int, at time.Time) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	for id, s := range d.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &at
+			d.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+// ===== Pembatasan login =====
+
+type memThrottleRepo struct{ s *memStore }
+
+func (r memThrottleRepo) Get(key string) (*LoginThrottle, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	t, ok := d.throttle[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r memThrottleRepo) GetForUpdate(key string, now time.Time) (*LoginThrottle, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	// Di dalam WithTx seluruh memStore sudah terkunci
+	t, ok := d.throttle[key]
+	if !ok {
+		t = LoginThrottle{Key: key, LastFailureAt: now}
+		d.throttle[key] = t
+	}
+	return &t, nil
+}
+
+func (r memThrottleRepo) Save(t *LoginThrottle) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	d.throttle[t.Key] = *t
+	return nil
+}
+
+func (r memThrottleRepo) Delete(key string) (bool, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	_, ok := d.throttle[key]
+	delete(d.throttle, key)
+	return ok, nil
+}
+
+func (r memThrottleRepo) LockedKeys(now time.Time) (map[string]time.Time, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	locked := make(map[string]time.Time)
+	for key, t := range d.throttle {
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			locked[key] = *t.LockedUntil
+		}
+	}
+	return locked, nil
+}
+
+// ===== Audit log =====
+
+type memAuditRepo struct{ s *memStore }
+
+func (r memAuditRepo) Create(l *AuditLog) error {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	l.ID = d.nextID("audit_logs")
+	d.audit = append(d.audit, *l)
+	return nil
+}
+
+func (r memAuditRepo) List(filter AuditFilter, page, limit int) ([]AuditLog, int, error) {
+	d, unlock := r.s.lock()
+	defer unlock()
+
+	var matched []AuditLog
+	for i := len(d.audit) - 1; i >= 0; i-- {
+		l := d.audit[i]
+		if filter.Entity != "" && l.Entity != filter.Entity {
+			continue
+		}
+		if filter.Action != "" && l.Action != filter.Action {
+			continue
+		}
+		if filter.Username != "" && l.Username != filter.Username {
+			continue
+		}
+		if filter.EntityID > 0 && (l.EntityID == nil || *l.EntityID != filter.EntityID) {
+			continue
+		}
+		if filter.From != nil && l.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !l.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		matched = append(matched, l)
+	}
+	return paginate(matched, page, limit), len(matched), nil
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate memotong slice sesuai halaman (page mulai dari 1)
+func paginate[T any](list []T, page, limit int) []T {
+	start := (page - 1) * limit
+	if start < 0 || start >= len(list) {
+		return nil
+	}
+	end := start + limit
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[start:end]
+}
@@ -0,0 +1,2112 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// queryer dipenuhi oleh *sql.DB maupun *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner dipenuhi oleh *sql.Row maupun *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// sqlDialect berisi perbedaan SQL antar database yang didukung
+type sqlDialect struct {
+	ilike     string // operator LIKE tanpa membedakan huruf besar/kecil
+	forUpdate string // penguncian baris yang dibaca sampai transaksi selesai
+}
+
+var (
+	postgresDialect = sqlDialect{ilike: "ILIKE", forUpdate: " FOR UPDATE"}
+	// LIKE di SQLite sudah case-insensitive untuk ASCII; transaksi SQLite dibuka dengan
+	// _txlock=immediate sehingga penulis lain sudah menunggu tanpa FOR UPDATE
+	sqliteDialect = sqlDialect{ilike: "LIKE"}
+)
+
+// sqlStore adalah implementasi Store di atas database/sql (PostgreSQL atau SQLite)
+type sqlStore struct {
+	db      *sql.DB // nil jika store ini berjalan di dalam transaksi
+	q       queryer
+	dialect sqlDialect
+}
+
+// NewPostgresStore membuat Store yang membaca dan menulis ke PostgreSQL
+func NewPostgresStore(db *sql.DB) Store {
+	return &sqlStore{db: db, q: db, dialect: postgresDialect}
+}
+
+// NewSQLiteStore membuat Store yang membaca dan menulis ke file SQLite
+// (schema dipasang oleh config.OpenSQLite)
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqlStore{db: db, q: db, dialect: sqliteDialect}
+}
+
+func (s *sqlStore) Products() ProductRepository            { return sqlProductRepo{s.q, s.dialect} }
+func (s *sqlStore) Categories() CategoryRepository         { return sqlCategoryRepo{s.q} }
+func (s *sqlStore) Brands() BrandRepository                { return sqlBrandRepo{s.q} }
+func (s *sqlStore) Users() UserRepository                  { return sqlUserRepo{s.q} }
+func (s *sqlStore) Warehouses() WarehouseRepository        { return sqlWarehouseRepo{s.q} }
+func (s *sqlStore) Transactions() TransactionRepository    { return sqlTransactionRepo{s.q} }
+func (s *sqlStore) Transfers() TransferRepository          { return sqlTransferRepo{s.q} }
+func (s *sqlStore) Opnames() OpnameRepository              { return sqlOpnameRepo{s.q} }
+func (s *sqlStore) Suppliers() SupplierRepository          { return sqlSupplierRepo{s.q} }
+func (s *sqlStore) Purchases() PurchaseRepository          { return sqlPurchaseRepo{s.q} }
+func (s *sqlStore) Movements() MovementRepository          { return sqlMovementRepo{s.q} }
+func (s *sqlStore) CostLayers() CostLayerRepository        { return sqlCostLayerRepo{s.q} }
+func (s *sqlStore) Batches() BatchRepository               { return sqlBatchRepo{s.q} }
+func (s *sqlStore) Roles() RoleRepository                  { return sqlRoleRepo{s.q} }
+func (s *sqlStore) Sessions() SessionRepository            { return sqlSessionRepo{s.q} }
+func (s *sqlStore) LoginThrottle() LoginThrottleRepository { return sqlThrottleRepo{s.q, s.dialect} }
+func (s *sqlStore) Audit() AuditRepository                 { return sqlAuditRepo{s.q} }
+
+func (s *sqlStore) WithTx(fn func(Store) error) error {
+	if s.db == nil {
+		// Sudah di dalam transaksi, pakai transaksi yang sama
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqlStore{q: tx, dialect: s.dialect}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// notFound menerjemahkan sql.ErrNoRows menjadi ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// checkAffected mengembalikan ErrNotFound jika tidak ada baris yang berubah
+func checkAffected(result sql.Result) error {
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ===== Produk =====
+
+type sqlProductRepo struct {
+	q       queryer
+	dialect sqlDialect
+}
+
+// productColumns membaca produk seperti yang terlihat di satu gudang: stok dari product_stocks dan
+// harga gudang jika ada, selain itu harga master. Dipakai bersama productFrom.
+const productColumns = `p.id, p.name, p.sku, p.barcode, COALESCE(s.purchase_price, p.purchase_price),
+	COALESCE(s.selling_price, p.selling_price), COALESCE(s.stock, 0), COALESCE(s.warehouse_id, 0),
+	p.category_id, p.brand_id, p.parent_id, p.variant, p.created_at`
+
+// productFrom menggabungkan master dengan stok per gudang; produk yang belum ada di gudang mana pun
+// tetap muncul satu kali dengan gudang 0
+const productFrom = `FROM products p LEFT JOIN product_stocks s ON s.product_id = p.id`
+
+// masterColumns membaca data master dengan stok total semua gudang
+const masterColumns = `p.id, p.name, p.sku, p.barcode, p.purchase_price, p.selling_price,
+	COALESCE((SELECT SUM(ps.stock) FROM product_stocks ps WHERE ps.product_id = p.id), 0), 0,
+	p.category_id, p.brand_id, p.parent_id, p.variant, p.created_at`
+
+func scanProduct(row rowScanner) (Product, error) {
+	var p Product
+	var sku, barcode sql.NullString
+	err := row.Scan(&p.ID, &p.Name, &sku, &barcode, &p.PurchasePrice, &p.SellingPrice, &p.Stock, &p.WarehouseID,
+		&p.CategoryID, &p.BrandID, &p.ParentID, &p.Variant, &p.CreatedAt)
+	p.SKU, p.Barcode = sku.String, barcode.String
+	return p, err
+}
+
+// nullIfEmpty menyimpan string kosong sebagai NULL agar tidak bentrok dengan unique index
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// uniqueViolation menerjemahkan pelanggaran unique constraint (PostgreSQL maupun SQLite) menjadi ErrDuplicate
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r sqlProductRepo) queryProducts(query string, args ...interface{}) ([]Product, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+func (r sqlProductRepo) List(warehouseID *int) ([]Product, error) {
+	if warehouseID != nil {
+		return r.queryProducts(`SELECT `+productColumns+` `+productFrom+` WHERE s.warehouse_id = $1 ORDER BY p.id`, *warehouseID)
+	}
+	return r.queryProducts(`SELECT ` + productColumns + ` ` + productFrom + ` ORDER BY p.id, s.warehouse_id`)
+}
+
+func (r sqlProductRepo) Search(filter ProductFilter, page, limit int) ([]Product, int, error) {
+	offset := (page - 1) * limit
+	var args []interface{}
+
+	baseQuery := productFrom + " WHERE 1=1"
+
+	argCount := 1
+	if filter.WarehouseID != nil {
+		baseQuery += fmt.Sprintf(" AND s.warehouse_id = $%d", argCount)
+		args = append(args, *filter.WarehouseID)
+		argCount++
+	}
+	if filter.Search != "" {
+		// Cocokkan nama (sebagian) atau SKU/barcode (persis, untuk hasil scan)
+		baseQuery += fmt.Sprintf(" AND (p.name %s $%d OR p.sku = $%d OR p.barcode = $%d)", r.dialect.ilike, argCount, argCount+1, argCount+1)
+		args = append(args, "%"+filter.Search+"%", filter.Search)
+		argCount += 2
+	}
+	if categories := filter.categories(); categories != nil {
+		placeholders := make([]string, len(categories))
+		for i, id := range categories {
+			placeholders[i] = fmt.Sprintf("$%d", argCount)
+			args = append(args, id)
+			argCount++
+		}
+		baseQuery += " AND p.category_id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	if filter.BrandID != nil {
+		baseQuery += fmt.Sprintf(" AND p.brand_id = $%d", argCount)
+		args = append(args, *filter.BrandID)
+		argCount++
+	}
+
+	var total int
+	err := r.q.QueryRow("SELECT COUNT(*) "+baseQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		ORDER BY COALESCE(p.parent_id, p.id), p.id, s.warehouse_id
+		LIMIT $%d OFFSET $%d
+	`, productColumns, baseQuery, argCount, argCount+1)
+	args = append(args, limit, offset)
+
+	products, err := r.queryProducts(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r sqlProductRepo) GetByID(id int) (*Product, error) {
+	p, err := scanProduct(r.q.QueryRow(`SELECT `+masterColumns+` FROM products p WHERE p.id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &p, nil
+}
+
+func (r sqlProductRepo) FindByCode(code string, warehouseID *int) ([]Product, error) {
+	if warehouseID != nil {
+		return r.queryProducts(`SELECT `+productColumns+` `+productFrom+`
+			WHERE (p.barcode = $1 OR p.sku = $1) AND s.warehouse_id = $2 ORDER BY p.id`, code, *warehouseID)
+	}
+	return r.queryProducts(`SELECT `+productColumns+` `+productFrom+`
+		WHERE p.barcode = $1 OR p.sku = $1 ORDER BY p.id, s.warehouse_id`, code)
+}
+
+func (r sqlProductRepo) Variants(parentID int) ([]Product, error) {
+	return r.queryProducts(`SELECT `+masterColumns+` FROM products p WHERE p.parent_id = $1 ORDER BY p.id`, parentID)
+}
+
+func (r sqlProductRepo) Create(p *Product) error {
+	err := r.q.QueryRow(`
+		INSERT INTO products (name, sku, barcode, purchase_price, selling_price, category_id, brand_id,
+			parent_id, variant, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, p.Name, nullIfEmpty(p.SKU), nullIfEmpty(p.Barcode), p.PurchasePrice, p.SellingPrice,
+		p.CategoryID, p.BrandID, p.ParentID, p.Variant, time.Now()).Scan(&p.ID, &p.CreatedAt)
+	return uniqueViolation(err)
+}
+
+func (r sqlProductRepo) Update(p *Product) error {
+	result, err := r.q.Exec(`
+		UPDATE products
+		SET name = $1, sku = $2, barcode = $3, purchase_price = $4, selling_price = $5,
+			category_id = $6, brand_id = $7, variant = $8
+		WHERE id = $9
+	`, p.Name, nullIfEmpty(p.SKU), nullIfEmpty(p.Barcode), p.PurchasePrice, p.SellingPrice,
+		p.CategoryID, p.BrandID, p.Variant, p.ID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	return checkAffected(result)
+}
+
+func (r sqlProductRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlProductRepo) Stocks(productID int) ([]ProductStock, error) {
+	rows, err := r.q.Query(`
+		SELECT `+stockColumns+`
+		FROM product_stocks
+		WHERE product_id = $1
+		ORDER BY warehouse_id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	return scanStocks(rows)
+}
+
+const stockColumns = `product_id, warehouse_id, stock, purchase_price, selling_price, reorder_point`
+
+func scanStocks(rows *sql.Rows) ([]ProductStock, error) {
+	defer rows.Close()
+
+	var stocks []ProductStock
+	for rows.Next() {
+		var st ProductStock
+		if err := rows.Scan(&st.ProductID, &st.WarehouseID, &st.Stock, &st.PurchasePrice, &st.SellingPrice, &st.ReorderPoint); err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, st)
+	}
+	return stocks, rows.Err()
+}
+
+func (r sqlProductRepo) SaveStock(st *ProductStock) error {
+	_, err := r.q.Exec(`
+		INSERT INTO product_stocks (product_id, warehouse_id, stock, purchase_price, selling_price)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id, warehouse_id) DO UPDATE
+		SET stock = excluded.stock, purchase_price = excluded.purchase_price, selling_price = excluded.selling_price
+	`, st.ProductID, st.WarehouseID, st.Stock, st.PurchasePrice, st.SellingPrice)
+	return err
+}
+
+func (r sqlProductRepo) SetReorderPoint(productID, warehouseID, level int) error {
+	result, err := r.q.Exec(`UPDATE product_stocks SET reorder_point = $1 WHERE product_id = $2 AND warehouse_id = $3`,
+		level, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlProductRepo) ReorderLevels(warehouseID *int) ([]ProductStock, error) {
+	query := `SELECT ` + stockColumns + ` FROM product_stocks WHERE reorder_point > 0`
+	var args []interface{}
+	if warehouseID != nil {
+		query += ` AND warehouse_id = $1`
+		args = append(args, *warehouseID)
+	}
+	rows, err := r.q.Query(query+` ORDER BY warehouse_id, product_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanStocks(rows)
+}
+
+func (r sqlProductRepo) DeleteStock(productID, warehouseID int) error {
+	result, err := r.q.Exec(`DELETE FROM product_stocks WHERE product_id = $1 AND warehouse_id = $2`, productID, warehouseID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlProductRepo) DecrementStock(id, warehouseID, quantity int) (int, error) {
+	var stock int
+	err := r.q.QueryRow(`
+		UPDATE product_stocks
+		SET stock = stock - $1
+		WHERE product_id = $2 AND warehouse_id = $3 AND stock >= $1
+		RETURNING stock
+	`, quantity, id, warehouseID).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInsufficientStock
+	}
+	return stock, err
+}
+
+func (r sqlProductRepo) IncrementStock(id, warehouseID, quantity int) (int, error) {
+	var stock int
+	err := r.q.QueryRow(`
+		UPDATE product_stocks
+		SET stock = stock + $1
+		WHERE product_id = $2 AND warehouse_id = $3
+		RETURNING stock
+	`, quantity, id, warehouseID).Scan(&stock)
+	return stock, notFound(err)
+}
+
+// ===== Kategori =====
+
+type sqlCategoryRepo struct{ q queryer }
+
+func (r sqlCategoryRepo) List() ([]Category, error) {
+	rows, err := r.q.Query(`
+		SELECT id, name, parent_id, created_at
+		FROM categories
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (r sqlCategoryRepo) GetByID(id int) (*Category, error) {
+	var c Category
+	err := r.q.QueryRow(`
+		SELECT id, name, parent_id, created_at
+		FROM categories
+		WHERE id = $1
+	`, id).Scan(&c.ID, &c.Name, &c.ParentID, &c.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
+
+func (r sqlCategoryRepo) Create(c *Category) error {
+	err := r.q.QueryRow(`
+		INSERT INTO categories (name, parent_id, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, c.Name, c.ParentID, time.Now()).Scan(&c.ID, &c.CreatedAt)
+	return uniqueViolation(err)
+}
+
+func (r sqlCategoryRepo) Update(c *Category) error {
+	result, err := r.q.Exec(`
+		UPDATE categories
+		SET name = $1, parent_id = $2
+		WHERE id = $3
+	`, c.Name, c.ParentID, c.ID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	return checkAffected(result)
+}
+
+func (r sqlCategoryRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Merek =====
+
+type sqlBrandRepo struct{ q queryer }
+
+func (r sqlBrandRepo) List() ([]Brand, error) {
+	rows, err := r.q.Query(`
+		SELECT id, name, created_at
+		FROM brands
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brands []Brand
+	for rows.Next() {
+		var b Brand
+		if err := rows.Scan(&b.ID, &b.Name, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	return brands, rows.Err()
+}
+
+func (r sqlBrandRepo) GetByID(id int) (*Brand, error) {
+	var b Brand
+	err := r.q.QueryRow(`SELECT id, name, created_at FROM brands WHERE id = $1`, id).Scan(&b.ID, &b.Name, &b.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &b, nil
+}
+
+func (r sqlBrandRepo) Create(b *Brand) error {
+	err := r.q.QueryRow(`
+		INSERT INTO brands (name, created_at)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`, b.Name, time.Now()).Scan(&b.ID, &b.CreatedAt)
+	return uniqueViolation(err)
+}
+
+func (r sqlBrandRepo) Update(b *Brand) error {
+	result, err := r.q.Exec(`UPDATE brands SET name = $1 WHERE id = $2`, b.Name, b.ID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	return checkAffected(result)
+}
+
+func (r sqlBrandRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM brands WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== User =====
+
+type sqlUserRepo struct{ q queryer }
+
+func (r sqlUserRepo) List() ([]User, error) {
+	rows, err := r.q.Query(`
+		SELECT id, username, role, warehouse_id, created_at
+		FROM users
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.WarehouseID, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r sqlUserRepo) getBy(column string, value interface{}) (*User, error) {
+	var u User
+	err := r.q.QueryRow(`
+		SELECT id, username, password, role, warehouse_id, created_at
+		FROM users
+		WHERE `+column+` = $1
+	`, value).Scan(&u.ID, &u.Username, &u.Password, &u.Role, &u.WarehouseID, &u.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &u, nil
+}
+
+func (r sqlUserRepo) GetByID(id int) (*User, error) {
+	return r.getBy("id", id)
+}
+
+func (r sqlUserRepo) GetByUsername(username string) (*User, error) {
+	return r.getBy("username", username)
+}
+
+func (r sqlUserRepo) Create(u *User) error {
+	return r.q.QueryRow(`
+		INSERT INTO users (username, password, role, warehouse_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, u.Username, u.Password, u.Role, u.WarehouseID, time.Now()).Scan(&u.ID, &u.CreatedAt)
+}
+
+func (r sqlUserRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlUserRepo) UpdatePassword(id int, hash string) error {
+	result, err := r.q.Exec(`UPDATE users SET password = $1 WHERE id = $2`, hash, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Gudang =====
+
+type sqlWarehouseRepo struct{ q queryer }
+
+func (r sqlWarehouseRepo) List() ([]Warehouse, error) {
+	rows, err := r.q.Query(`
+		SELECT id, name, COALESCE(address, ''), created_at
+		FROM warehouses
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []Warehouse
+	for rows.Next() {
+		var w Warehouse
+		if err := rows.Scan(&w.ID, &w.Name, &w.Address, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, w)
+	}
+	return warehouses, rows.Err()
+}
+
+func (r sqlWarehouseRepo) GetByID(id int) (*Warehouse, error) {
+	var w Warehouse
+	err := r.q.QueryRow(`
+		SELECT id, name, COALESCE(address, ''), created_at
+		FROM warehouses
+		WHERE id = $1
+	`, id).Scan(&w.ID, &w.Name, &w.Address, &w.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &w, nil
+}
+
+func (r sqlWarehouseRepo) Create(w *Warehouse) error {
+	return r.q.QueryRow(`
+		INSERT INTO warehouses (name, address, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, w.Name, w.Address, time.Now()).Scan(&w.ID, &w.CreatedAt)
+}
+
+func (r sqlWarehouseRepo) Update(w *Warehouse) error {
+	result, err := r.q.Exec(`
+		UPDATE warehouses
+		SET name = $1, address = $2
+		WHERE id = $3
+	`, w.Name, w.Address, w.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlWarehouseRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM warehouses WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Transaksi =====
+
+type sqlTransactionRepo struct{ q queryer }
+
+const transactionColumns = `t.id, t.user_id, COALESCE(u.username, ''), t.warehouse_id, t.total, t.profit, t.payment, t.change,
+	t.created_at, t.voided_at, COALESCE(t.voided_by, 0), COALESCE(v.username, ''), t.void_reason`
+
+func scanTransaction(row rowScanner) (Transaction, error) {
+	var t Transaction
+	err := row.Scan(&t.ID, &t.UserID, &t.CashierName, &t.WarehouseID, &t.Total, &t.Profit, &t.Payment, &t.Change,
+		&t.CreatedAt, &t.VoidedAt, &t.VoidedByID, &t.VoidedBy, &t.VoidReason)
+	return t, err
+}
+
+func (r sqlTransactionRepo) Create(t *Transaction) error {
+	err := r.q.QueryRow(`
+		INSERT INTO transactions (user_id, warehouse_id, total, profit, payment, change, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, t.UserID, t.WarehouseID, t.Total, t.Profit, t.Payment, t.Change, time.Now()).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range t.Items {
+		item := &t.Items[i]
+		item.TransactionID = t.ID
+		err = r.q.QueryRow(`
+			INSERT INTO transaction_items
+			(transaction_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal, profit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`, t.ID, item.ProductID, item.ProductName, item.Quantity,
+			item.PurchasePrice, item.SellingPrice, item.Subtotal, item.Profit).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlTransactionRepo) ListByDate(from, to time.Time, warehouseID *int) ([]Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		LEFT JOIN users u ON u.id = t.user_id
+		LEFT JOIN users v ON v.id = t.voided_by
+		WHERE t.created_at >= $1 AND t.created_at < $2`
+	args := []interface{}{from, to}
+	if warehouseID != nil {
+		query += ` AND t.warehouse_id = $3`
+		args = append(args, *warehouseID)
+	}
+	query += ` ORDER BY t.created_at DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Item diambil setelah rows ditutup agar aman dipakai di dalam transaksi (satu koneksi)
+	for i := range transactions {
+		transactions[i].Items, err = r.Items(transactions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+func (r sqlTransactionRepo) Items(transactionID int) ([]TransactionItem, error) {
+	rows, err := r.q.Query(`
+		SELECT id, transaction_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal, profit
+		FROM transaction_items
+		WHERE transaction_id = $1
+		ORDER BY id
+	`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []TransactionItem
+	for rows.Next() {
+		var item TransactionItem
+		err := rows.Scan(&item.ID, &item.TransactionID, &item.ProductID,
+			&item.ProductName, &item.Quantity, &item.PurchasePrice, &item.SellingPrice, &item.Subtotal, &item.Profit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r sqlTransactionRepo) Summary(from, to time.Time, warehouseID *int) (float64, float64, int, error) {
+	query := `
+		SELECT COALESCE(SUM(total), 0), COALESCE(SUM(profit), 0), COUNT(*)
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2 AND voided_at IS NULL`
+	args := []interface{}{from, to}
+	if warehouseID != nil {
+		query += ` AND warehouse_id = $3`
+		args = append(args, *warehouseID)
+	}
+
+	var total, profit float64
+	var count int
+	err := r.q.QueryRow(query, args...).Scan(&total, &profit, &count)
+	return total, profit, count, err
+}
+
+func (r sqlTransactionRepo) GetByID(id int) (*Transaction, error) {
+	t, err := scanTransaction(r.q.QueryRow(`
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		LEFT JOIN users u ON u.id = t.user_id
+		LEFT JOIN users v ON v.id = t.voided_by
+		WHERE t.id = $1
+	`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	t.Items, err = r.Items(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r sqlTransactionRepo) Void(t *Transaction) error {
+	result, err := r.q.Exec(`
+		UPDATE transactions SET voided_at = $1, voided_by = $2, void_reason = $3
+		WHERE id = $4 AND voided_at IS NULL
+	`, t.VoidedAt, nullIfZero(t.VoidedByID), t.VoidReason, t.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlTransactionRepo) CreateReturn(ret *SalesReturn) error {
+	err := r.q.QueryRow(`
+		INSERT INTO sales_returns (transaction_id, warehouse_id, user_id, reason, total, profit, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, ret.TransactionID, ret.WarehouseID, nullIfZero(ret.UserID), ret.Reason, ret.Total, ret.Profit, time.Now()).Scan(&ret.ID, &ret.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range ret.Items {
+		item := &ret.Items[i]
+		item.ReturnID = ret.ID
+		err = r.q.QueryRow(`
+			INSERT INTO sales_return_items
+			(return_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal, profit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`, ret.ID, item.ProductID, item.ProductName, item.Quantity,
+			item.PurchasePrice, item.SellingPrice, item.Subtotal, item.Profit).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryReturns mengambil retur beserta item-nya sesuai kondisi WHERE dan urutan yang diberikan
+func (r sqlTransactionRepo) queryReturns(where, order string, args ...interface{}) ([]SalesReturn, error) {
+	rows, err := r.q.Query(`
+		SELECT s.id, s.transaction_id, s.warehouse_id, COALESCE(s.user_id, 0), COALESCE(u.username, ''),
+			s.reason, s.total, s.profit, s.created_at
+		FROM sales_returns s
+		LEFT JOIN users u ON u.id = s.user_id
+		WHERE `+where+` ORDER BY `+order, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []SalesReturn
+	for rows.Next() {
+		var ret SalesReturn
+		err := rows.Scan(&ret.ID, &ret.TransactionID, &ret.WarehouseID, &ret.UserID, &ret.CashierName,
+			&ret.Reason, &ret.Total, &ret.Profit, &ret.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range returns {
+		returns[i].Items, err = r.returnItems(returns[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return returns, nil
+}
+
+func (r sqlTransactionRepo) returnItems(returnID int) ([]ReturnItem, error) {
+	rows, err := r.q.Query(`
+		SELECT id, return_id, COALESCE(product_id, 0), product_name, quantity, purchase_price, selling_price, subtotal, profit
+		FROM sales_return_items
+		WHERE return_id = $1
+		ORDER BY id
+	`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ReturnItem
+	for rows.Next() {
+		var item ReturnItem
+		err := rows.Scan(&item.ID, &item.ReturnID, &item.ProductID, &item.ProductName, &item.Quantity,
+			&item.PurchasePrice, &item.SellingPrice, &item.Subtotal, &item.Profit)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r sqlTransactionRepo) Returns(transactionID int) ([]SalesReturn, error) {
+	return r.queryReturns(`s.transaction_id = $1`, `s.id`, transactionID)
+}
+
+func (r sqlTransactionRepo) ReturnsByDate(from, to time.Time, warehouseID *int) ([]SalesReturn, error) {
+	where := `s.created_at >= $1 AND s.created_at < $2`
+	args := []interface{}{from, to}
+	if warehouseID != nil {
+		where += ` AND s.warehouse_id = $3`
+		args = append(args, *warehouseID)
+	}
+	return r.queryReturns(where, `s.created_at DESC, s.id DESC`, args...)
+}
+
+func (r sqlTransactionRepo) ReturnSummary(from, to time.Time, warehouseID *int) (float64, float64, int, error) {
+	query := `
+		SELECT COALESCE(SUM(total), 0), COALESCE(SUM(profit), 0), COUNT(*)
+		FROM sales_returns
+		WHERE created_at >= $1 AND created_at < $2`
+	args := []interface{}{from, to}
+	if warehouseID != nil {
+		query += ` AND warehouse_id = $3`
+		args = append(args, *warehouseID)
+	}
+
+	var total, profit float64
+	var count int
+	err := r.q.QueryRow(query, args...).Scan(&total, &profit, &count)
+	return total, profit, count, err
+}
+
+// ===== Transfer Stok =====
+
+type sqlTransferRepo struct{ q queryer }
+
+const transferColumns = `t.id, t.from_warehouse_id, t.to_warehouse_id, t.status, t.note, COALESCE(t.user_id, 0),
+	COALESCE(u.username, ''), t.created_at, t.sent_at, t.received_at`
+
+func scanTransfer(row rowScanner) (Transfer, error) {
+	var t Transfer
+	err := row.Scan(&t.ID, &t.FromWarehouseID, &t.ToWarehouseID, &t.Status, &t.Note, &t.UserID,
+		&t.CreatedBy, &t.CreatedAt, &t.SentAt, &t.ReceivedAt)
+	return t, err
+}
+
+// nullIfZero menyimpan ID 0 sebagai NULL (misalnya transfer yang dibuat sistem tanpa user)
+func nullIfZero(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func (r sqlTransferRepo) Create(t *Transfer) error {
+	err := r.q.QueryRow(`
+		INSERT INTO stock_transfers (from_warehouse_id, to_warehouse_id, status, note, user_id, created_at, sent_at, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, t.FromWarehouseID, t.ToWarehouseID, t.Status, t.Note, nullIfZero(t.UserID), time.Now(), t.SentAt, t.ReceivedAt,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range t.Items {
+		item := &t.Items[i]
+		item.TransferID = t.ID
+		err = r.q.QueryRow(`
+			INSERT INTO stock_transfer_items (transfer_id, product_id, product_name, quantity, received, unit_cost)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, t.ID, item.ProductID, item.ProductName, item.Quantity, item.Received, item.UnitCost).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlTransferRepo) GetByID(id int) (*Transfer, error) {
+	t, err := scanTransfer(r.q.QueryRow(`
+		SELECT `+transferColumns+`
+		FROM stock_transfers t
+		LEFT JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1
+	`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	rows, err := r.q.Query(`
+		SELECT id, transfer_id, product_id, product_name, quantity, received, unit_cost
+		FROM stock_transfer_items
+		WHERE transfer_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item TransferItem
+		if err := rows.Scan(&item.ID, &item.TransferID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Received, &item.UnitCost); err != nil {
+			return nil, err
+		}
+		t.Items = append(t.Items, item)
+	}
+	return &t, rows.Err()
+}
+
+func (r sqlTransferRepo) List(warehouseID *int, status string) ([]Transfer, error) {
+	query := `
+		SELECT ` + transferColumns + `
+		FROM stock_transfers t
+		LEFT JOIN users u ON u.id = t.user_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if warehouseID != nil {
+		args = append(args, *warehouseID)
+		query += fmt.Sprintf(` AND (t.from_warehouse_id = $%d OR t.to_warehouse_id = $%d)`, len(args), len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		query += fmt.Sprintf(` AND t.status = $%d`, len(args))
+	}
+	query += ` ORDER BY t.created_at DESC, t.id DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []Transfer
+	for rows.Next() {
+		t, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func (r sqlTransferRepo) Update(t *Transfer) error {
+	result, err := r.q.Exec(`
+		UPDATE stock_transfers SET status = $1, note = $2, sent_at = $3, received_at = $4 WHERE id = $5
+	`, t.Status, t.Note, t.SentAt, t.ReceivedAt, t.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	for _, item := range t.Items {
+		_, err := r.q.Exec(`UPDATE stock_transfer_items SET received = $1, unit_cost = $2 WHERE id = $3`, item.Received, item.UnitCost, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlTransferRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM stock_transfers WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Stock Opname =====
+
+type sqlOpnameRepo struct{ q queryer }
+
+const opnameColumns = `o.id, o.warehouse_id, o.status, o.note, COALESCE(o.user_id, 0), COALESCE(u.username, ''),
+	o.created_at, COALESCE(o.approved_by, 0), COALESCE(a.username, ''), o.approved_at`
+
+func scanOpname(row rowScanner) (StockOpname, error) {
+	var o StockOpname
+	err := row.Scan(&o.ID, &o.WarehouseID, &o.Status, &o.Note, &o.UserID, &o.CreatedBy,
+		&o.CreatedAt, &o.ApprovedByID, &o.ApprovedBy, &o.ApprovedAt)
+	return o, err
+}
+
+func (r sqlOpnameRepo) Create(o *StockOpname) error {
+	err := r.q.QueryRow(`
+		INSERT INTO stock_opnames (warehouse_id, status, note, user_id, created_at, approved_by, approved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, o.WarehouseID, o.Status, o.Note, nullIfZero(o.UserID), time.Now(), nullIfZero(o.ApprovedByID), o.ApprovedAt,
+	).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.OpnameID = o.ID
+		err = r.q.QueryRow(`
+			INSERT INTO stock_opname_items (opname_id, product_id, product_name, system_stock, counted, purchase_price)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, o.ID, item.ProductID, item.ProductName, item.SystemStock, item.Counted, item.PurchasePrice).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlOpnameRepo) GetByID(id int) (*StockOpname, error) {
+	o, err := scanOpname(r.q.QueryRow(`
+		SELECT `+opnameColumns+`
+		FROM stock_opnames o
+		LEFT JOIN users u ON u.id = o.user_id
+		LEFT JOIN users a ON a.id = o.approved_by
+		WHERE o.id = $1
+	`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	rows, err := r.q.Query(`
+		SELECT id, opname_id, product_id, product_name, system_stock, counted, purchase_price
+		FROM stock_opname_items
+		WHERE opname_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item StockOpnameItem
+		err := rows.Scan(&item.ID, &item.OpnameID, &item.ProductID, &item.ProductName, &item.SystemStock,
+			&item.Counted, &item.PurchasePrice)
+		if err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, item)
+	}
+	return &o, rows.Err()
+}
+
+func (r sqlOpnameRepo) List(warehouseID *int, status string) ([]StockOpname, error) {
+	query := `
+		SELECT ` + opnameColumns + `
+		FROM stock_opnames o
+		LEFT JOIN users u ON u.id = o.user_id
+		LEFT JOIN users a ON a.id = o.approved_by
+		WHERE 1 = 1`
+	var args []interface{}
+	if warehouseID != nil {
+		args = append(args, *warehouseID)
+		query += fmt.Sprintf(` AND o.warehouse_id = $%d`, len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		query += fmt.Sprintf(` AND o.status = $%d`, len(args))
+	}
+	query += ` ORDER BY o.created_at DESC, o.id DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var opnames []StockOpname
+	for rows.Next() {
+		o, err := scanOpname(rows)
+		if err != nil {
+			return nil, err
+		}
+		opnames = append(opnames, o)
+	}
+	return opnames, rows.Err()
+}
+
+func (r sqlOpnameRepo) Update(o *StockOpname) error {
+	result, err := r.q.Exec(`
+		UPDATE stock_opnames SET status = $1, note = $2, approved_by = $3, approved_at = $4 WHERE id = $5
+	`, o.Status, o.Note, nullIfZero(o.ApprovedByID), o.ApprovedAt, o.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	for _, item := range o.Items {
+		_, err := r.q.Exec(`UPDATE stock_opname_items SET counted = $1 WHERE id = $2`, item.Counted, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlOpnameRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM stock_opnames WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Supplier =====
+
+type sqlSupplierRepo struct{ q queryer }
+
+func (r sqlSupplierRepo) List() ([]Supplier, error) {
+	rows, err := r.q.Query(`
+		SELECT id, name, phone, address, created_at
+		FROM suppliers
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suppliers []Supplier
+	for rows.Next() {
+		var sup Supplier
+		if err := rows.Scan(&sup.ID, &sup.Name, &sup.Phone, &sup.Address, &sup.CreatedAt); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, sup)
+	}
+	return suppliers, rows.Err()
+}
+
+func (r sqlSupplierRepo) GetByID(id int) (*Supplier, error) {
+	var sup Supplier
+	err := r.q.QueryRow(`
+		SELECT id, name, phone, address, created_at
+		FROM suppliers
+		WHERE id = $1
+	`, id).Scan(&sup.ID, &sup.Name, &sup.Phone, &sup.Address, &sup.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &sup, nil
+}
+
+func (r sqlSupplierRepo) Create(sup *Supplier) error {
+	err := r.q.QueryRow(`
+		INSERT INTO suppliers (name, phone, address, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, sup.Name, sup.Phone, sup.Address, time.Now()).Scan(&sup.ID, &sup.CreatedAt)
+	return uniqueViolation(err)
+}
+
+func (r sqlSupplierRepo) Update(sup *Supplier) error {
+	result, err := r.q.Exec(`
+		UPDATE suppliers
+		SET name = $1, phone = $2, address = $3
+		WHERE id = $4
+	`, sup.Name, sup.Phone, sup.Address, sup.ID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	return checkAffected(result)
+}
+
+func (r sqlSupplierRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM suppliers WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Purchase Order =====
+
+type sqlPurchaseRepo struct{ q queryer }
+
+const purchaseColumns = `o.id, o.supplier_id, COALESCE(s.name, ''), o.warehouse_id, o.status, o.note,
+	COALESCE(o.user_id, 0), COALESCE(u.username, ''), o.created_at`
+
+func scanPurchase(row rowScanner) (PurchaseOrder, error) {
+	var o PurchaseOrder
+	err := row.Scan(&o.ID, &o.SupplierID, &o.SupplierName, &o.WarehouseID, &o.Status, &o.Note,
+		&o.UserID, &o.CreatedBy, &o.CreatedAt)
+	return o, err
+}
+
+func (r sqlPurchaseRepo) Create(o *PurchaseOrder) error {
+	err := r.q.QueryRow(`
+		INSERT INTO purchase_orders (supplier_id, warehouse_id, status, note, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, o.SupplierID, o.WarehouseID, o.Status, o.Note, nullIfZero(o.UserID), time.Now()).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.OrderID = o.ID
+		err = r.q.QueryRow(`
+			INSERT INTO purchase_order_items (order_id, product_id, product_name, quantity, price, received)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, o.ID, item.ProductID, item.ProductName, item.Quantity, item.Price, item.Received).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlPurchaseRepo) GetByID(id int) (*PurchaseOrder, error) {
+	o, err := scanPurchase(r.q.QueryRow(`
+		SELECT `+purchaseColumns+`
+		FROM purchase_orders o
+		LEFT JOIN suppliers s ON s.id = o.supplier_id
+		LEFT JOIN users u ON u.id = o.user_id
+		WHERE o.id = $1
+	`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	rows, err := r.q.Query(`
+		SELECT id, order_id, product_id, product_name, quantity, price, received
+		FROM purchase_order_items
+		WHERE order_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item PurchaseOrderItem
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Price, &item.Received)
+		if err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, item)
+	}
+	return &o, rows.Err()
+}
+
+func (r sqlPurchaseRepo) List(filter PurchaseFilter) ([]PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseColumns + `
+		FROM purchase_orders o
+		LEFT JOIN suppliers s ON s.id = o.supplier_id
+		LEFT JOIN users u ON u.id = o.user_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if filter.WarehouseID != nil {
+		args = append(args, *filter.WarehouseID)
+		query += fmt.Sprintf(` AND o.warehouse_id = $%d`, len(args))
+	}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		query += fmt.Sprintf(` AND o.supplier_id = $%d`, len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(` AND o.status = $%d`, len(args))
+	}
+	if filter.Outstanding {
+		args = append(args, PurchaseReceived)
+		query += fmt.Sprintf(` AND o.status <> $%d`, len(args))
+	}
+	query += ` ORDER BY o.created_at DESC, o.id DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []PurchaseOrder
+	for rows.Next() {
+		o, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+func (r sqlPurchaseRepo) Update(o *PurchaseOrder) error {
+	result, err := r.q.Exec(`UPDATE purchase_orders SET status = $1, note = $2 WHERE id = $3`, o.Status, o.Note, o.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	for _, item := range o.Items {
+		_, err := r.q.Exec(`UPDATE purchase_order_items SET received = $1 WHERE id = $2`, item.Received, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlPurchaseRepo) Delete(id int) error {
+	result, err := r.q.Exec(`DELETE FROM purchase_orders WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlPurchaseRepo) CreateReceipt(gr *GoodsReceipt) error {
+	err := r.q.QueryRow(`
+		INSERT INTO goods_receipts (order_id, warehouse_id, note, user_id, total, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, gr.OrderID, gr.WarehouseID, gr.Note, nullIfZero(gr.UserID), gr.Total, time.Now()).Scan(&gr.ID, &gr.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range gr.Items {
+		item := &gr.Items[i]
+		item.ReceiptID = gr.ID
+		err = r.q.QueryRow(`
+			INSERT INTO goods_receipt_items (receipt_id, product_id, product_name, quantity, price)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, gr.ID, item.ProductID, item.ProductName, item.Quantity, item.Price).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlPurchaseRepo) Receipts(filter ReceiptFilter) ([]GoodsReceipt, error) {
+	query := `
+		SELECT g.id, g.order_id, o.supplier_id, COALESCE(s.name, ''), g.warehouse_id, g.note,
+			COALESCE(g.user_id, 0), COALESCE(u.username, ''), g.total, g.created_at
+		FROM goods_receipts g
+		JOIN purchase_orders o ON o.id = g.order_id
+		LEFT JOIN suppliers s ON s.id = o.supplier_id
+		LEFT JOIN users u ON u.id = g.user_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		query += fmt.Sprintf(` AND o.supplier_id = $%d`, len(args))
+	}
+	if filter.WarehouseID != nil {
+		args = append(args, *filter.WarehouseID)
+		query += fmt.Sprintf(` AND g.warehouse_id = $%d`, len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(` AND g.created_at >= $%d`, len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(` AND g.created_at < $%d`, len(args))
+	}
+	query += ` ORDER BY g.created_at DESC, g.id DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []GoodsReceipt
+	for rows.Next() {
+		var gr GoodsReceipt
+		err := rows.Scan(&gr.ID, &gr.OrderID, &gr.SupplierID, &gr.SupplierName, &gr.WarehouseID, &gr.Note,
+			&gr.UserID, &gr.ReceivedBy, &gr.Total, &gr.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, gr)
+	}
+	return receipts, rows.Err()
+}
+
+// ===== Mutasi Stok =====
+
+type sqlMovementRepo struct{ q queryer }
+
+func (r sqlMovementRepo) Create(m *StockMovement) error {
+	return r.q.QueryRow(`
+		INSERT INTO stock_movements (product_id, warehouse_id, type, quantity, balance, user_id, username, reference, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, m.ProductID, m.WarehouseID, m.Type, m.Quantity, m.Balance, m.UserID, m.Username, m.Reference, m.CreatedAt).Scan(&m.ID)
+}
+
+func (r sqlMovementRepo) List(filter MovementFilter) ([]StockMovement, error) {
+	query := `
+		SELECT id, product_id, warehouse_id, type, quantity, balance, user_id, username, reference, created_at
+		FROM stock_movements
+		WHERE 1 = 1`
+	var args []interface{}
+	if filter.ProductID > 0 {
+		args = append(args, filter.ProductID)
+		query += fmt.Sprintf(` AND product_id = $%d`, len(args))
+	}
+	if filter.WarehouseID != nil {
+		args = append(args, *filter.WarehouseID)
+		query += fmt.Sprintf(` AND warehouse_id = $%d`, len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(` AND created_at >= $%d`, len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(` AND created_at < $%d`, len(args))
+	}
+	query += ` ORDER BY id`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []StockMovement
+	for rows.Next() {
+		var m StockMovement
+		err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &m.Balance,
+			&m.UserID, &m.Username, &m.Reference, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// ===== Lapis Harga Pokok =====
+
+type sqlCostLayerRepo struct{ q queryer }
+
+func (r sqlCostLayerRepo) Create(l *CostLayer) error {
+	return r.q.QueryRow(`
+		INSERT INTO cost_layers (product_id, warehouse_id, quantity, remaining, unit_cost, reference, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, l.ProductID, l.WarehouseID, l.Quantity, l.Remaining, l.UnitCost, l.Reference, l.CreatedAt).Scan(&l.ID)
+}
+
+func (r sqlCostLayerRepo) Open(productID, warehouseID int) ([]CostLayer, error) {
+	rows, err := r.q.Query(`
+		SELECT id, product_id, warehouse_id, quantity, remaining, unit_cost, reference, created_at
+		FROM cost_layers
+		WHERE product_id = $1 AND warehouse_id = $2 AND remaining > 0
+		ORDER BY id
+	`, productID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layers []CostLayer
+	for rows.Next() {
+		var l CostLayer
+		err := rows.Scan(&l.ID, &l.ProductID, &l.WarehouseID, &l.Quantity, &l.Remaining, &l.UnitCost, &l.Reference, &l.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+	return layers, rows.Err()
+}
+
+func (r sqlCostLayerRepo) Update(l *CostLayer) error {
+	result, err := r.q.Exec(`UPDATE cost_layers SET remaining = $1, unit_cost = $2 WHERE id = $3`, l.Remaining, l.UnitCost, l.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+// ===== Batch Stok =====
+
+type sqlBatchRepo struct{ q queryer }
+
+const batchColumns = `id, product_id, warehouse_id, batch_no, expiry_date, quantity, remaining, reference, created_at`
+
+func scanBatch(row rowScanner) (StockBatch, error) {
+	var b StockBatch
+	err := row.Scan(&b.ID, &b.ProductID, &b.WarehouseID, &b.BatchNo, &b.ExpiryDate, &b.Quantity, &b.Remaining, &b.Reference, &b.CreatedAt)
+	return b, err
+}
+
+func (r sqlBatchRepo) queryBatches(query string, args ...interface{}) ([]StockBatch, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []StockBatch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func (r sqlBatchRepo) Create(b *StockBatch) error {
+	return r.q.QueryRow(`
+		INSERT INTO stock_batches (product_id, warehouse_id, batch_no, expiry_date, quantity, remaining, reference, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, b.ProductID, b.WarehouseID, b.BatchNo, b.ExpiryDate, b.Quantity, b.Remaining, b.Reference, b.CreatedAt).Scan(&b.ID)
+}
+
+func (r sqlBatchRepo) GetByID(id int) (*StockBatch, error) {
+	b, err := scanBatch(r.q.QueryRow(`SELECT `+batchColumns+` FROM stock_batches WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &b, nil
+}
+
+func (r sqlBatchRepo) Open(productID, warehouseID int) ([]StockBatch, error) {
+	return r.queryBatches(`
+		SELECT `+batchColumns+`
+		FROM stock_batches
+		WHERE product_id = $1 AND warehouse_id = $2 AND remaining > 0
+		ORDER BY expiry_date, id
+	`, productID, warehouseID)
+}
+
+func (r sqlBatchRepo) Expiring(warehouseID *int, before time.Time) ([]StockBatch, error) {
+	query := `SELECT ` + batchColumns + ` FROM stock_batches WHERE remaining > 0 AND expiry_date < $1`
+	args := []interface{}{before}
+	if warehouseID != nil {
+		args = append(args, *warehouseID)
+		query += ` AND warehouse_id = $2`
+	}
+	return r.queryBatches(query+` ORDER BY expiry_date, id`, args...)
+}
+
+func (r sqlBatchRepo) Update(b *StockBatch) error {
+	result, err := r.q.Exec(`UPDATE stock_batches SET remaining = $1 WHERE id = $2`, b.Remaining, b.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlBatchRepo) CreateTransferBatch(tb *TransferBatch) error {
+	return r.q.QueryRow(`
+		INSERT INTO stock_transfer_batches (transfer_id, product_id, batch_no, expiry_date, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, tb.TransferID, tb.ProductID, tb.BatchNo, tb.ExpiryDate, tb.Quantity).Scan(&tb.ID)
+}
+
+func (r sqlBatchRepo) TransferBatches(transferID int) ([]TransferBatch, error) {
+	rows, err := r.q.Query(`
+		SELECT id, transfer_id, product_id, batch_no, expiry_date, quantity
+		FROM stock_transfer_batches
+		WHERE transfer_id = $1
+		ORDER BY id
+	`, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []TransferBatch
+	for rows.Next() {
+		var tb TransferBatch
+		if err := rows.Scan(&tb.ID, &tb.TransferID, &tb.ProductID, &tb.BatchNo, &tb.ExpiryDate, &tb.Quantity); err != nil {
+			return nil, err
+		}
+		batches = append(batches, tb)
+	}
+	return batches, rows.Err()
+}
+
+func (r sqlBatchRepo) CreateTransactionBatch(tb *TransactionBatch) error {
+	return r.q.QueryRow(`
+		INSERT INTO transaction_item_batches (transaction_id, product_id, batch_no, expiry_date, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, tb.TransactionID, tb.ProductID, tb.BatchNo, tb.ExpiryDate, tb.Quantity).Scan(&tb.ID)
+}
+
+func (r sqlBatchRepo) TransactionBatches(transactionID int) ([]TransactionBatch, error) {
+	rows, err := r.q.Query(`
+		SELECT id, transaction_id, product_id, batch_no, expiry_date, quantity
+		FROM transaction_item_batches
+		WHERE transaction_id = $1
+		ORDER BY id
+	`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []TransactionBatch
+	for rows.Next() {
+		var tb TransactionBatch
+		if err := rows.Scan(&tb.ID, &tb.TransactionID, &tb.ProductID, &tb.BatchNo, &tb.ExpiryDate, &tb.Quantity); err != nil {
+			return nil, err
+		}
+		batches = append(batches, tb)
+	}
+	return batches, rows.Err()
+}
+
+// ===== Role =====
+
+type sqlRoleRepo struct{ q queryer }
+
+func (r sqlRoleRepo) List() ([]Role, error) {
+	rows, err := r.q.Query(`
+		SELECT id, name, COALESCE(description, ''), created_at
+		FROM roles
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range roles {
+		roles[i].Permissions, err = r.Permissions(roles[i].Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func (r sqlRoleRepo) GetByName(name string) (*Role, error) {
+	var role Role
+	err := r.q.QueryRow(`
+		SELECT id, name, COALESCE(description, ''), created_at
+		FROM roles
+		WHERE name = $1
+	`, name).Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	role.Permissions, err = r.Permissions(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r sqlRoleRepo) Permissions(roleName string) ([]string, error) {
+	rows, err := r.q.Query(`
+		SELECT rp.permission
+		FROM role_permissions rp
+		JOIN roles r ON r.id = rp.role_id
+		WHERE r.name = $1
+		ORDER BY rp.permission
+	`, roleName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+func (r sqlRoleRepo) Create(role *Role) error {
+	err := r.q.QueryRow(`
+		INSERT INTO roles (name, description, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, role.Name, role.Description, time.Now()).Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return r.insertPermissions(role.ID, role.Permissions)
+}
+
+func (r sqlRoleRepo) insertPermissions(roleID int, permissions []string) error {
+	for _, p := range permissions {
+		_, err := r.q.Exec(`INSERT INTO role_permissions (role_id, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r sqlRoleRepo) SetPermissions(roleID int, permissions []string) error {
+	_, err := r.q.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID)
+	if err != nil {
+		return err
+	}
+	return r.insertPermissions(roleID, permissions)
+}
+
+func (r sqlRoleRepo) Delete(name string) error {
+	result, err := r.q.Exec(`DELETE FROM roles WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (r sqlRoleRepo) CountUsers(name string) (int, error) {
+	var count int
+	err := r.q.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1`, name).Scan(&count)
+	return count, err
+}
+
+// ===== Sesi API =====
+
+type sqlSessionRepo struct{ q queryer }
+
+func (r sqlSessionRepo) Create(s *Session) error {
+	return r.q.QueryRow(`
+		INSERT INTO api_sessions (id, user_id, refresh_token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, s.ID, s.UserID, s.RefreshTokenHash, s.ExpiresAt, time.Now()).Scan(&s.CreatedAt)
+}
+
+func (r sqlSessionRepo) GetByID(id string) (*Session, error) {
+	var s Session
+	err := r.q.QueryRow(`
+		SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at
+		FROM api_sessions
+		WHERE id = $1
+	`, id).Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.ExpiresAt, &s.RevokedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r sqlSessionRepo) GetActiveByRefreshHash(refreshTokenHash string, now time.Time) (*Session, error) {
+	var s Session
+	err := r.q.QueryRow(`
+		SELECT id, user_id, refresh_token_hash, expires_at, revoked_at, created_at
+		FROM api_sessions
+		WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > $2
+	`, refreshTokenHash, now).Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.ExpiresAt, &s.RevokedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r sqlSessionRepo) RotateRefresh(id, oldHash, newHash string, expiresAt time.Time) error {
+	result, err := r.q.Exec(`
+		UPDATE api_sessions
+		SET refresh_token_hash = $1, expires_at = $2
+		WHERE id = $3 AND refresh_token_hash = $4 AND revoked_at IS NULL
+	`, newHash, expiresAt, id, oldHash)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+	_, err = r.q.Exec(`
+		INSERT INTO api_session_used_tokens (refresh_token_hash, session_id, rotated_at)
+		VALUES ($1, $2, $3)
+	`, oldHash, id, time.Now())
+	return err
+}
+
+func (r sqlSessionRepo) GetByUsedRefreshHash(refreshTokenHash string) (*Session, error) {
+	var s Session
+	err := r.q.QueryRow(`
+		SELECT s.id, s.user_id, s.refresh_token_hash, s.expires_at, s.revoked_at, s.created_at
+		FROM api_session_used_tokens u
+		JOIN api_sessions s ON s.id = u.session_id
+		WHERE u.refresh_token_hash = $1
+	`, refreshTokenHash).Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.ExpiresAt, &s.RevokedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &s, nil
+}
+
+func (r sqlSessionRepo) Revoke(id string, at time.Time) error {
+	_, err := r.q.Exec(`
+		UPDATE api_sessions
+		SET revoked_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`, at, id)
+	return err
+}
+
+func (r sqlSessionRepo) RevokeUser(userID int, at time.Time) error {
+	_, err := r.q.Exec(`
+		UPDATE api_sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+	`, at, userID)
+	return err
+}
+
+// ===== Pembatasan login =====
+
+type sqlThrottleRepo struct {
+	q       queryer
+	dialect sqlDialect
+}
+
+func (r sqlThrottleRepo) Get(key string) (*LoginThrottle, error) {
+	var t LoginThrottle
+	err := r.q.QueryRow(`
+		SELECT key, failures, last_failure_at, locked_until
+		FROM login_throttle
+		WHERE key = $1
+	`, key).Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.LockedUntil)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (r sqlThrottleRepo) GetForUpdate(key string, now time.Time) (*LoginThrottle, error) {
+	// Baris dibuat lebih dulu: FOR UPDATE tidak mengunci baris yang belum ada
+	_, err := r.q.Exec(`
+		INSERT INTO login_throttle (key, failures, last_failure_at)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+	`, key, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var t LoginThrottle
+	err = r.q.QueryRow(`
+		SELECT key, failures, last_failure_at, locked_until
+		FROM login_throttle
+		WHERE key = $1`+r.dialect.forUpdate, key).Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.LockedUntil)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (r sqlThrottleRepo) Save(t *LoginThrottle) error {
+	_, err := r.q.Exec(`
+		INSERT INTO login_throttle (key, failures, last_failure_at, locked_until)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = EXCLUDED.failures, last_failure_at = EXCLUDED.last_failure_at, locked_until = EXCLUDED.locked_until
+	`, t.Key, t.Failures, t.LastFailureAt, t.LockedUntil)
+	return err
+}
+
+func (r sqlThrottleRepo) Delete(key string) (bool, error) {
+	result, err := r.q.Exec(`DELETE FROM login_throttle WHERE key = $1`, key)
+	if err != nil {
+		return false, err
+	}
+	return checkAffected(result) == nil, nil
+}
+
+func (r sqlThrottleRepo) LockedKeys(now time.Time) (map[string]time.Time, error) {
+	rows, err := r.q.Query(`SELECT key, locked_until FROM login_throttle WHERE locked_until > $1`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locked := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var until time.Time
+		if err := rows.Scan(&key, &until); err != nil {
+			return nil, err
+		}
+		locked[key] = until
+	}
+	return locked, rows.Err()
+}
+
+// ===== Audit log =====
+
+type sqlAuditRepo struct{ q queryer }
+
+func (r sqlAuditRepo) Create(l *AuditLog) error {
+	return r.q.QueryRow(`
+		INSERT INTO audit_logs (user_id, username, action, entity, entity_id, before_data, after_data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, l.UserID, l.Username, l.Action, l.Entity, l.EntityID, nullJSON(l.Before), nullJSON(l.After), l.CreatedAt).Scan(&l.ID)
+}
+
+func nullJSON(data json.RawMessage) *string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
+
+func (r sqlAuditRepo) List(filter AuditFilter, page, limit int) ([]AuditLog, int, error) {
+	offset := (page - 1) * limit
+	var args []interface{}
+
+	baseQuery := "FROM audit_logs WHERE 1=1"
+	argCount := 1
+	if filter.Entity != "" {
+		baseQuery += fmt.Sprintf(" AND entity = $%d", argCount)
+		args = append(args, filter.Entity)
+		argCount++
+	}
+	if filter.Action != "" {
+		baseQuery += fmt.Sprintf(" AND action = $%d", argCount)
+		args = append(args, filter.Action)
+		argCount++
+	}
+	if filter.Username != "" {
+		baseQuery += fmt.Sprintf(" AND username = $%d", argCount)
+		args = append(args, filter.Username)
+		argCount++
+	}
+	if filter.EntityID > 0 {
+		baseQuery += fmt.Sprintf(" AND entity_id = $%d", argCount)
+		args = append(args, filter.EntityID)
+		argCount++
+	}
+	if filter.From != nil {
+		baseQuery += fmt.Sprintf(" AND created_at >= $%d", argCount)
+		args = append(args, *filter.From)
+		argCount++
+	}
+	if filter.To != nil {
+		baseQuery += fmt.Sprintf(" AND created_at < $%d", argCount)
+		args = append(args, *filter.To)
+		argCount++
+	}
+
+	var total int
+	err := r.q.QueryRow("SELECT COUNT(*) "+baseQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, username, action, entity, entity_id,
+			   COALESCE(CAST(before_data AS TEXT), ''), COALESCE(CAST(after_data AS TEXT), ''), created_at
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, baseQuery, argCount, argCount+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var logs []AuditLog
+	for rows.Next() {
+		var l AuditLog
+		var before, after string
+		err := rows.Scan(&l.ID, &l.UserID, &l.Username, &l.Action, &l.Entity, &l.EntityID, &before, &after, &l.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		if before != "" {
+			l.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			l.After = json.RawMessage(after)
+		}
+		logs = append(logs, l)
+	}
+	return logs, total, rows.Err()
+}
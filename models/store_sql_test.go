@@ -0,0 +1,348 @@
+package models
+
+import (
+	"kasir/config"
+	"kasir/migrations"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSQLiteStore menjalankan alur utama di atas file SQLite sungguhan
+// (schema dan data awal dari migrations/sqlite)
+func TestSQLiteStore(t *testing.T) {
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "kasir.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db, config.DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	SetStore(NewSQLiteStore(db))
+
+	if _, err := BootstrapAdmin("admin", "admin123"); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := BootstrapAdmin("admin2", "admin123"); err != nil || again != nil {
+		t.Errorf("second bootstrap = %+v, %v, want no new admin", again, err)
+	}
+	admin, err := Authenticate("admin", "admin123", "")
+	if err != nil {
+		t.Fatalf("login admin: %v", err)
+	}
+	if !isPasswordHash(mustGetUser(t, admin.ID).Password) {
+		t.Error("bootstrapped admin password should be stored as bcrypt")
+	}
+
+	w, err := CreateWarehouse(admin, "Gudang Cabang A", "Jl. Cabang A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cashier, err := Register(admin, "kasir1", "user123", "user", &w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cashier, _ = Authenticate(cashier.Username, "user123", "")
+
+	mie, _ := CreateProduct(admin, Product{Name: "Indomie Goreng", SKU: "MIE-GRG", Barcode: "8998866200301", PurchasePrice: 2500, SellingPrice: 3500, Stock: 5, WarehouseID: w.ID})
+	CreateProduct(admin, Product{Name: "Aqua 600ml", PurchasePrice: 2500, SellingPrice: 4000, Stock: 5, WarehouseID: w.ID})
+	CreateProduct(admin, Product{Name: "Teh Botol", PurchasePrice: 3000, SellingPrice: 5000, Stock: 5, WarehouseID: w.ID})
+
+	if _, err := CreateProduct(admin, Product{Name: "Mie Kembar", Barcode: "8998866200301", PurchasePrice: 2500, SellingPrice: 3500, Stock: 5, WarehouseID: w.ID}); err != ErrDuplicateCode {
+		t.Errorf("duplicate barcode: err = %v, want ErrDuplicateCode", err)
+	}
+	if found, err := FindProductsByCode("8998866200301", &w.ID); err != nil || len(found) != 1 || found[0].SKU != "MIE-GRG" {
+		t.Errorf("find by barcode = %+v, %v", found, err)
+	}
+
+	products, total, err := GetProducts(1, 10, ProductFilter{Search: "INDOMIE", WarehouseID: &w.ID})
+	if err != nil || total != 1 || products[0].ID != mie.ID {
+		t.Fatalf("search: %v, total %d, %+v", err, total, products)
+	}
+
+	minuman, _ := CreateCategory(admin, "Minuman", nil)
+	if _, err := CreateCategory(admin, "Minuman", nil); err != ErrDuplicateCategory {
+		t.Errorf("duplicate top-level category: err = %v", err)
+	}
+	teh, err := FindOrCreateCategoryPath(admin, "Minuman > Teh")
+	if err != nil || teh.ParentID == nil || *teh.ParentID != minuman.ID {
+		t.Fatalf("category path: %+v, %v", teh, err)
+	}
+	sosro, _ := CreateBrand(admin, "Sosro")
+	tehBotol, err := CreateProduct(admin, Product{Name: "Teh Botol Sosro", PurchasePrice: 3500, SellingPrice: 5000,
+		Stock: 5, WarehouseID: w.ID, CategoryID: &teh.ID, BrandID: &sosro.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, total, err = GetProducts(1, 10, ProductFilter{CategoryID: &minuman.ID, BrandID: &sosro.ID})
+	if err != nil || total != 1 || products[0].ID != tehBotol.ID || *products[0].CategoryID != teh.ID {
+		t.Errorf("category filter: %v, total %d, %+v", err, total, products)
+	}
+	if err := DeleteCategory(admin, teh.ID); err == nil {
+		t.Error("expected foreign key error deleting category that still has products")
+	}
+
+	kaos, _ := CreateProduct(admin, Product{Name: "Kaos Polos", PurchasePrice: 30000, SellingPrice: 50000, WarehouseID: w.ID})
+	kaosL, err := CreateVariant(admin, kaos.ID, Product{Variant: "L", SKU: "KAOS-L", Stock: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variants, err := GetVariants(kaos.ID); err != nil || len(variants) != 1 || variants[0].DisplayName() != "Kaos Polos (L)" {
+		t.Errorf("variants = %+v, %v", variants, err)
+	}
+	products, _, _ = GetProducts(1, 10, ProductFilter{Search: "kaos"})
+	if len(products) != 2 || products[0].ID != kaos.ID || *products[1].ParentID != kaos.ID {
+		t.Errorf("variant should be listed under its parent: %+v", products)
+	}
+	if err := DeleteProduct(admin, kaos.ID); err == nil {
+		t.Error("expected error deleting parent that still has variants")
+	}
+	if err := DeleteProduct(admin, kaosL.ID); err != nil {
+		t.Errorf("delete variant: %v", err)
+	}
+
+	if _, err := CreateTransaction(cashier, []CartItem{{Product: mie, Quantity: 2}}, 10000); err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	if _, err := CreateTransaction(cashier, []CartItem{{Product: mie, Quantity: 4}}, 20000); err == nil {
+		t.Fatal("expected insufficient stock error")
+	}
+	if p, _ := GetProductByID(mie.ID); p.Stock != 3 {
+		t.Errorf("stock = %d, want 3", p.Stock)
+	}
+
+	// Produk yang sama di gudang lain: stok dan harga jual sendiri, master tetap satu
+	pusat, _ := CreateWarehouse(admin, "Gudang Pusat 2", "Jl. Pusat")
+	price := 3800.0
+	if err := SetProductStock(admin, ProductStock{ProductID: mie.ID, WarehouseID: pusat.ID, Stock: 7, SellingPrice: &price}); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := GetProductInWarehouse(mie.ID, pusat.ID); err != nil || p.Stock != 7 || p.SellingPrice != 3800 || p.PurchasePrice != 2500 {
+		t.Errorf("in second warehouse = %+v, %v", p, err)
+	}
+	if p, _ := GetProductByID(mie.ID); p.Stock != 10 || p.SellingPrice != 3500 {
+		t.Errorf("master = %+v, want total 10 at master price", p)
+	}
+	if err := UpdateStock(admin, mie.ID, pusat.ID, 7); err != nil {
+		t.Errorf("decrement second warehouse: %v", err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, w.ID); p.Stock != 3 {
+		t.Errorf("stock in cabang = %d, want 3", p.Stock)
+	}
+	if err := SetReorderPoint(admin, mie.ID, w.ID, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProductStock(admin, ProductStock{ProductID: mie.ID, WarehouseID: w.ID, Stock: 3, SellingPrice: &price}); err != nil {
+		t.Fatal(err)
+	}
+	if low, err := GetLowStock(&w.ID); err != nil || len(low) != 1 || low[0].ProductID != mie.ID || low[0].ReorderPoint != 5 {
+		t.Errorf("low stock = %+v, %v", low, err)
+	}
+	if err := DeleteWarehouse(admin, pusat.ID); err == nil {
+		t.Error("expected error deleting warehouse that still stocks products")
+	}
+
+	// Transfer: stok keluar saat dikirim, masuk sebanyak yang diterima
+	trf, err := CreateTransfer(admin, w.ID, pusat.ID, "restock", []TransferItem{{ProductID: tehBotol.ID, Quantity: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SendTransfer(admin, trf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if inTransit, err := GetTransfers(admin, TransferSent); err != nil || len(inTransit) != 1 || inTransit[0].CreatedBy != "admin" {
+		t.Errorf("in transit = %+v, %v", inTransit, err)
+	}
+	if _, err := ReceiveTransfer(admin, trf.ID, map[int]int{tehBotol.ID: 3}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetTransfer(admin, trf.ID)
+	if err != nil || got.Status != TransferReceived || got.SentAt == nil || got.ReceivedAt == nil || got.Items[0].Shortage() != 1 {
+		t.Errorf("received transfer = %+v, %v", got, err)
+	}
+	if p, err := GetProductInWarehouse(tehBotol.ID, pusat.ID); err != nil || p.Stock != 3 {
+		t.Errorf("received stock = %+v, %v", p, err)
+	}
+	if p, _ := GetProductInWarehouse(tehBotol.ID, w.ID); p.Stock != 1 {
+		t.Errorf("source stock = %d, want 1", p.Stock)
+	}
+
+	// Buku mutasi: setiap perubahan stok tercatat beserta saldo setelahnya
+	card, err := GetStockCard(mie.ID, &w.ID, time.Now(), time.Now())
+	if err != nil || card.Opening != 0 || card.Closing != 3 || len(card.Movements) != 2 {
+		t.Fatalf("stock card = %+v, %v", card, err)
+	}
+	if m := card.Movements[1]; m.Type != MovementSale || m.Quantity != -2 || m.Balance != 3 || m.Username != "kasir1" || m.Reference != "TRX-000001" {
+		t.Errorf("sale movement = %+v", m)
+	}
+	if card, _ := GetStockCard(mie.ID, nil, time.Now(), time.Now()); card.In != 12 || card.Out != 9 || card.Closing != 3 {
+		t.Errorf("all warehouses card = %+v", card)
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	if card, _ := GetStockCard(tehBotol.ID, &pusat.ID, tomorrow, tomorrow); card.Opening != 3 || len(card.Movements) != 0 {
+		t.Errorf("opening balance from earlier movements = %+v", card)
+	}
+
+	// Stock opname: hasil hitung NULL sampai diisi, selisih dibukukan saat disetujui
+	opn, err := CreateOpname(admin, pusat.ID, "akhir bulan")
+	if err != nil || len(opn.Items) != 2 {
+		t.Fatalf("create opname = %+v, %v", opn, err)
+	}
+	if _, err := RecordOpnameCounts(admin, opn.ID, map[int]int{tehBotol.ID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApproveOpname(admin, opn.ID); err != nil {
+		t.Fatal(err)
+	}
+	opn, err = GetOpname(admin, opn.ID)
+	if err != nil || opn.Status != OpnameApproved || opn.ApprovedBy != "admin" || opn.ApprovedAt == nil || opn.Uncounted() != 1 || opn.VarianceValue() != -3500 {
+		t.Errorf("approved opname = %+v, %v", opn, err)
+	}
+	if p, _ := GetProductInWarehouse(tehBotol.ID, pusat.ID); p.Stock != 2 {
+		t.Errorf("stock after opname = %d, want 2", p.Stock)
+	}
+	if card, _ := GetStockCard(tehBotol.ID, &pusat.ID, time.Now(), time.Now()); card.Movements[len(card.Movements)-1].Reference != "OPN-000001" {
+		t.Errorf("opname movement = %+v", card.Movements)
+	}
+
+	// Pembelian: PO diterima bertahap, harga beli gudang ikut harga penerimaan terakhir
+	sup, err := CreateSupplier(admin, "PT Sumber Pangan", "021-555", "Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	po, err := CreatePurchaseOrder(admin, sup.ID, pusat.ID, "", []PurchaseOrderItem{{ProductID: tehBotol.ID, Quantity: 10, Price: 3200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReceivePurchaseOrder(admin, po.ID, []GoodsReceiptItem{{ProductID: tehBotol.ID, Quantity: 4}}, "faktur 001"); err != nil {
+		t.Fatal(err)
+	}
+	if outstanding, err := GetOutstandingPurchases(&pusat.ID); err != nil || len(outstanding) != 1 || outstanding[0].Outstanding != 6 || outstanding[0].SupplierName != sup.Name {
+		t.Errorf("outstanding = %+v, %v", outstanding, err)
+	}
+	if p, _ := GetProductInWarehouse(tehBotol.ID, pusat.ID); p.Stock != 6 || p.PurchasePrice != 3200 {
+		t.Errorf("stock after receipt = %+v, want 6 at 3200", p)
+	}
+	if history, err := GetSupplierPurchases(sup.ID, nil, time.Now(), time.Now()); err != nil || len(history.Receipts) != 1 || history.Total != 12800 || history.Receipts[0].ReceivedBy != "admin" {
+		t.Errorf("supplier history = %+v, %v", history, err)
+	}
+
+	// Batch: sisa PO diterima dengan tanggal kedaluwarsa, batch yang sudah lewat dimusnahkan
+	expiry := time.Now().AddDate(0, 0, 20)
+	if _, err := ReceivePurchaseOrder(admin, po.ID, []GoodsReceiptItem{{ProductID: tehBotol.ID, Quantity: 6, BatchNo: "TB-01", ExpiryDate: &expiry}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddStockBatch(admin, tehBotol.ID, pusat.ID, "TB-00", time.Now().AddDate(0, 0, -2), 2); err != nil {
+		t.Fatal(err)
+	}
+	if batches, err := GetBatches(tehBotol.ID, pusat.ID); err != nil || len(batches) != 2 || batches[0].BatchNo != "TB-00" || batches[1].Remaining != 6 {
+		t.Errorf("batches = %+v, %v", batches, err)
+	}
+	if report, err := GetExpiringBatches(&pusat.ID, 30); err != nil || len(report) != 2 || report[0].DaysLeft != -2 || report[1].DaysLeft != 20 {
+		t.Errorf("expiring batches = %+v, %v", report, err)
+	}
+	if written, err := WriteOffExpiredBatches(admin, &pusat.ID); err != nil || len(written) != 1 || written[0].Remaining != 2 {
+		t.Errorf("written off = %+v, %v", written, err)
+	}
+	if p, _ := GetProductInWarehouse(tehBotol.ID, pusat.ID); p.Stock != 12 {
+		t.Errorf("stock after batch write-off = %d, want 12", p.Stock)
+	}
+
+	sales, profit, count, err := GetDailyTotal(cashier, time.Now())
+	if err != nil || sales != 7000 || profit != 2000 || count != 1 {
+		t.Errorf("daily total = (%v, %v, %d, %v), want (7000, 2000, 1, nil)", sales, profit, count, err)
+	}
+	trxs, err := GetTransactionsByDate(admin, time.Now())
+	if err != nil || len(trxs) != 1 || trxs[0].CashierName != "kasir1" || len(trxs[0].Items) != 1 {
+		t.Errorf("transactions = %+v, %v", trxs, err)
+	}
+
+	// Retur: satu dari dua mie dikembalikan, laporan harian menghitung penjualan bersih
+	before, _ := GetProductInWarehouse(mie.ID, w.ID)
+	if _, err := CreateReturn(cashier, trxs[0].ID, []ReturnItem{{ProductID: mie.ID, Quantity: 3}}, "rusak"); err == nil {
+		t.Error("expected error returning more than sold")
+	}
+	ret, err := CreateReturn(cashier, trxs[0].ID, []ReturnItem{{ProductID: mie.ID, Quantity: 1}}, "kemasan rusak")
+	if err != nil || ret.Total != 3500 || ret.Items[0].ID == 0 {
+		t.Fatalf("return = %+v, %v", ret, err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, w.ID); p.Stock != before.Stock+1 {
+		t.Errorf("stock after return = %d, want %d", p.Stock, before.Stock+1)
+	}
+	if returns, err := GetReturnsByDate(admin, time.Now()); err != nil || len(returns) != 1 || returns[0].CashierName != "kasir1" ||
+		returns[0].Reason != "kemasan rusak" || len(returns[0].Items) != 1 {
+		t.Errorf("returns = %+v, %v", returns, err)
+	}
+	if items, err := GetReturnableItems(cashier, trxs[0].ID); err != nil || len(items) != 1 || items[0].Returnable() != 1 {
+		t.Errorf("returnable items = %+v, %v", items, err)
+	}
+	if summary, err := GetDailySummary(cashier, time.Now()); err != nil || summary.Returns != 1 || summary.NetSales != 3500 || summary.NetProfit != 1000 {
+		t.Errorf("daily summary = %+v, %v", summary, err)
+	}
+
+	logs, _, err := GetAuditLogs(AuditFilter{Entity: EntityTransaction}, 1, 10)
+	if err != nil || len(logs) != 1 || len(logs[0].After) == 0 {
+		t.Errorf("audit logs = %+v, %v", logs, err)
+	}
+
+	// Void: stok dan batch kembali, transaksi tetap tersimpan tapi tidak dihitung di total harian
+	if _, err := AddStockBatch(admin, mie.ID, w.ID, "MIE-01", time.Now().AddDate(0, 0, 30), 2); err != nil {
+		t.Fatal(err)
+	}
+	before, _ = GetProductInWarehouse(mie.ID, w.ID)
+	voided := sell(t, cashier, mie.ID, 1)
+	if sold, err := store.Batches().TransactionBatches(voided.ID); err != nil || len(sold) != 1 || sold[0].BatchNo != "MIE-01" || sold[0].Quantity != 1 {
+		t.Errorf("transaction batches = %+v, %v", sold, err)
+	}
+	if _, err := VoidTransaction(cashier, admin, voided.ID, "pelanggan batal"); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, w.ID); p.Stock != before.Stock {
+		t.Errorf("stock after void = %d, want %d", p.Stock, before.Stock)
+	}
+	if batches, err := GetBatches(mie.ID, w.ID); err != nil || len(batches) != 2 || batches[0].Remaining+batches[1].Remaining != 2 {
+		t.Errorf("batches after void = %+v, %v", batches, err)
+	}
+	if _, err := VoidTransaction(cashier, admin, voided.ID, "lagi"); err == nil {
+		t.Error("expected error voiding twice")
+	}
+	if sales, _, count, err := GetDailyTotal(cashier, time.Now()); err != nil || sales != 7000 || count != 1 {
+		t.Errorf("daily total after void = (%v, %d, %v), want (7000, 1, nil)", sales, count, err)
+	}
+	if list, err := GetVoidedTransactionsByDate(admin, time.Now()); err != nil || len(list) != 1 || list[0].VoidedBy != "admin" ||
+		list[0].VoidReason != "pelanggan batal" || list[0].VoidedAt == nil || len(list[0].Items) != 1 {
+		t.Errorf("voided transactions = %+v, %v", list, err)
+	}
+
+	if _, err := Authenticate("kasir1", "salah", "10.0.0.1"); err == nil {
+		t.Error("expected wrong password to fail")
+	}
+	if th, err := getLoginThrottle(userThrottleKey("kasir1")); err != nil || th == nil || th.Failures != 1 {
+		t.Errorf("login throttle = %+v, %v", th, err)
+	}
+	if _, err := CreateSession("sesi-1", cashier.ID, "hash-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetActiveSessionByRefreshHash("hash-1"); err != nil {
+		t.Errorf("active session: %v", err)
+	}
+
+	if err := DeleteWarehouse(admin, w.ID); err == nil {
+		t.Error("expected foreign key error deleting warehouse that still has products")
+	}
+}
+
+func mustGetUser(t *testing.T, id int) *User {
+	t.Helper()
+	u, err := store.Users().GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supplier model (pemasok barang)
+type Supplier struct {
+	ID        int
+	Name      string
+	Phone     string
+	Address   string
+	CreatedAt time.Time
+}
+
+// ErrDuplicateSupplier dikembalikan jika nama supplier sudah dipakai
+var ErrDuplicateSupplier = errors.New("supplier dengan nama ini sudah ada")
+
+// GetAllSuppliers mengambil semua supplier urut nama
+func GetAllSuppliers() ([]Supplier, error) {
+	return store.Suppliers().List()
+}
+
+// GetSupplierByID mengambil supplier berdasarkan ID
+func GetSupplierByID(id int) (*Supplier, error) {
+	return store.Suppliers().GetByID(id)
+}
+
+// CreateSupplier membuat supplier baru
+func CreateSupplier(actor *User, name, phone, address string) (*Supplier, error) {
+	sup := Supplier{Name: strings.TrimSpace(name), Phone: strings.TrimSpace(phone), Address: strings.TrimSpace(address)}
+	if sup.Name == "" {
+		return nil, errors.New("nama supplier tidak boleh kosong")
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if err := s.Suppliers().Create(&sup); err != nil {
+			if err == ErrDuplicate {
+				return ErrDuplicateSupplier
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntitySupplier, sup.ID, nil, sup)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sup, nil
+}
+
+// UpdateSupplier mengubah nama, telepon, dan alamat supplier
+func UpdateSupplier(actor *User, id int, name, phone, address string) error {
+	before, err := GetSupplierByID(id)
+	if err != nil {
+		return fmt.Errorf("supplier dengan ID %d tidak ditemukan", id)
+	}
+
+	after := *before
+	after.Name, after.Phone, after.Address = strings.TrimSpace(name), strings.TrimSpace(phone), strings.TrimSpace(address)
+	if after.Name == "" {
+		return errors.New("nama supplier tidak boleh kosong")
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Suppliers().Update(&after); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("supplier dengan ID %d tidak ditemukan", id)
+			}
+			if err == ErrDuplicate {
+				return ErrDuplicateSupplier
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditUpdate, EntitySupplier, id, before, after)
+	})
+}
+
+// DeleteSupplier menghapus supplier yang belum punya purchase order
+func DeleteSupplier(actor *User, id int) error {
+	before, err := GetSupplierByID(id)
+	if err != nil {
+		return fmt.Errorf("supplier dengan ID %d tidak ditemukan", id)
+	}
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Suppliers().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return fmt.Errorf("supplier dengan ID %d tidak ditemukan", id)
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntitySupplier, id, before, nil)
+	})
+}
@@ -1,7 +1,8 @@
 package models
 
 import (
-	"kasir/config"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -9,6 +10,7 @@ import (
 type Transaction struct {
 	ID          int
 	UserID      int
+	CashierName string // username kasir yang memproses transaksi
 	WarehouseID int
 	Total       float64
 	Profit      float64
@@ -16,6 +18,15 @@ type Transaction struct {
 	Change      float64
 	CreatedAt   time.Time
 	Items       []TransactionItem
+	VoidedAt    *time.Time // nil = transaksi berlaku
+	VoidedByID  int        // supervisor yang menyetujui void
+	VoidedBy    string     // username supervisor yang menyetujui void
+	VoidReason  string
+}
+
+// Voided mengembalikan true jika transaksi sudah dibatalkan (void)
+func (t *Transaction) Voided() bool {
+	return t.VoidedAt != nil
 }
 
 // TransactionItem model
@@ -37,105 +48,140 @@ type CartItem struct {
 	Quantity int
 }
 
-// CreateTransaction membuat transaksi baru dengan items
+// ErrInvalidTransaction dikembalikan jika keranjang atau pembayaran tidak valid (jumlah item tidak
+// positif atau pembayaran kurang dari total)
+var ErrInvalidTransaction = errors.New("transaksi tidak valid")
+
+// CreateTransaction membuat transaksi baru dengan items. Harga beli dan profit tiap item diambil dari
+// lapis harga pokok yang terpakai (sesuai metode harga pokok), bukan dari harga beli produk saat ini.
 func CreateTransaction(user *User, items []CartItem, payment float64) (*Transaction, error) {
-	// Hitung total dan profit
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: keranjang kosong", ErrInvalidTransaction)
+	}
+
+	// Hitung total; profit baru diketahui setelah stok dikeluarkan dari lapis harga pokok
 	var total float64
-	var totalProfit float64
 	for _, item := range items {
-		subtotal := item.Product.SellingPrice * float64(item.Quantity)
-		profit := (item.Product.SellingPrice - item.Product.PurchasePrice) * float64(item.Quantity)
-		total += subtotal
-		totalProfit += profit
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: jumlah %s harus lebih dari 0", ErrInvalidTransaction, item.Product.DisplayName())
+		}
+		total += item.Product.SellingPrice * float64(item.Quantity)
+	}
+	if payment < total {
+		return nil, fmt.Errorf("%w: pembayaran kurang %.0f dari total %.0f", ErrInvalidTransaction, total-payment, total)
 	}
 	change := payment - total
 
 	// Get user dan warehouse info
 	userID := 0
+	cashierName := ""
 	warehouseID := 0
 	if user != nil {
 		userID = user.ID
+		cashierName = user.Username
 		if user.WarehouseID != nil {
 			warehouseID = *user.WarehouseID
-		} else if len(items) > 0 {
-			warehouseID = items[0].Product.WarehouseID
 		}
 	}
-
-	// Mulai transaction database
-	tx, err := config.DB.Begin()
-	if err != nil {
-		return nil, err
+	if warehouseID == 0 && len(items) > 0 {
+		warehouseID = items[0].Product.WarehouseID
 	}
-	defer tx.Rollback()
-
-	// Insert transaksi
-	var transactionID int
-	var createdAt time.Time
-	err = tx.QueryRow(`
-		INSERT INTO transactions (user_id, warehouse_id, total, profit, payment, change) 
-		VALUES ($1, $2, $3, $4, $5, $6) 
-		RETURNING id, created_at
-	`, userID, warehouseID, total, totalProfit, payment, change).Scan(&transactionID, &createdAt)
-	if err != nil {
-		return nil, err
+	// Stok dikurangi di gudang transaksi, jadi semua item harus diambil dari gudang yang sama
+	for _, item := range items {
+		if item.Product.WarehouseID != warehouseID {
+			return nil, fmt.Errorf("%s bukan stok gudang transaksi ini", item.Product.DisplayName())
+		}
 	}
 
-	// Insert items dan update stok
 	transaction := &Transaction{
-		ID:          transactionID,
 		UserID:      userID,
+		CashierName: cashierName,
 		WarehouseID: warehouseID,
 		Total:       total,
-		Profit:      totalProfit,
 		Payment:     payment,
 		Change:      change,
-		CreatedAt:   createdAt,
 	}
 
 	for _, item := range items {
-		subtotal := item.Product.SellingPrice * float64(item.Quantity)
-		profit := (item.Product.SellingPrice - item.Product.PurchasePrice) * float64(item.Quantity)
-
-		// Insert transaction item
-		_, err = tx.Exec(`
-			INSERT INTO transaction_items 
-			(transaction_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal, profit) 
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		`, transactionID, item.Product.ID, item.Product.Name, item.Quantity,
-			item.Product.PurchasePrice, item.Product.SellingPrice, subtotal, profit)
-		if err != nil {
-			return nil, err
-		}
+		transaction.Items = append(transaction.Items, TransactionItem{
+			ProductID:    item.Product.ID,
+			ProductName:  item.Product.DisplayName(),
+			Quantity:     item.Quantity,
+			SellingPrice: item.Product.SellingPrice,
+			Subtotal:     item.Product.SellingPrice * float64(item.Quantity),
+		})
+	}
 
-		// Update stok
-		result, err := tx.Exec(`
-			UPDATE products 
-			SET stock = stock - $1 
-			WHERE id = $2 AND stock >= $1
-		`, item.Quantity, item.Product.ID)
-		if err != nil {
-			return nil, err
-		}
+	// Kurangi stok, hitung harga pokok, dan simpan transaksi dalam satu transaksi database
+	err := store.WithTx(func(s Store) error {
+		balances := make([]int, len(items))
+		var sold []TransactionBatch
+		for i, item := range items {
+			variants, err := s.Products().Variants(item.Product.ID)
+			if err != nil {
+				return err
+			}
+			if len(variants) > 0 {
+				return fmt.Errorf("%s: %w", item.Product.Name, ErrHasVariants)
+			}
+
+			stock, err := s.Products().DecrementStock(item.Product.ID, warehouseID, item.Quantity)
+			if err == ErrInsufficientStock {
+				return fmt.Errorf("stok %s tidak mencukupi", item.Product.DisplayName())
+			}
+			if err != nil {
+				return err
+			}
+			balances[i] = stock
+			// Batch yang paling cepat kedaluwarsa terjual lebih dulu; batch kedaluwarsa tidak boleh dijual
+			used, err := consumeBatches(s, item.Product.ID, warehouseID, item.Quantity, true)
+			if err == ErrExpiredStock {
+				return fmt.Errorf("stok %s tidak mencukupi, sisanya sudah kedaluwarsa", item.Product.DisplayName())
+			}
+			if err != nil {
+				return err
+			}
+			for _, b := range used {
+				sold = append(sold, TransactionBatch{ProductID: item.Product.ID, BatchNo: b.BatchNo, ExpiryDate: b.ExpiryDate, Quantity: b.Quantity})
+			}
 
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			return nil, err
+			cost, err := consumeCost(s, item.Product.ID, warehouseID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			line := &transaction.Items[i]
+			if line.Quantity > 0 {
+				line.PurchasePrice = cost / float64(line.Quantity)
+			}
+			line.Profit = line.Subtotal - cost
+			transaction.Profit += line.Profit
 		}
 
-		transaction.Items = append(transaction.Items, TransactionItem{
-			ProductID:     item.Product.ID,
-			ProductName:   item.Product.Name,
-			Quantity:      item.Quantity,
-			PurchasePrice: item.Product.PurchasePrice,
-			SellingPrice:  item.Product.SellingPrice,
-			Subtotal:      subtotal,
-			Profit:        profit,
-		})
-	}
+		if err := s.Transactions().Create(transaction); err != nil {
+			return err
+		}
+		for i := range sold {
+			sold[i].TransactionID = transaction.ID
+			if err := s.Batches().CreateTransactionBatch(&sold[i]); err != nil {
+				return err
+			}
+		}
+		for i, item := range items {
+			err := writeMovement(s, user, StockMovement{
+				ProductID:   item.Product.ID,
+				WarehouseID: warehouseID,
+				Type:        MovementSale,
+				Quantity:    -item.Quantity,
+				Balance:     balances[i],
+				Reference:   fmt.Sprintf("TRX-%06d", transaction.ID),
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	// Commit transaction
-	err = tx.Commit()
+		return writeAudit(s, user, AuditCreate, EntityTransaction, transaction.ID, nil, transaction)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -145,107 +191,73 @@ func CreateTransaction(user *User, items []CartItem, payment float64) (*Transact
 
 // GetTransactionsByDate mengambil transaksi berdasarkan tanggal
 func GetTransactionsByDate(user *User, date time.Time) ([]Transaction, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	var query string
-	var args []interface{}
-
-	if user != nil && !user.IsAdmin() && user.WarehouseID != nil {
-		query = `
-			SELECT id, user_id, warehouse_id, total, profit, payment, change, created_at 
-			FROM transactions 
-			WHERE created_at >= $1 AND created_at < $2 AND warehouse_id = $3
-			ORDER BY created_at DESC
-		`
-		args = []interface{}{startOfDay, endOfDay, *user.WarehouseID}
-	} else {
-		query = `
-			SELECT id, user_id, warehouse_id, total, profit, payment, change, created_at 
-			FROM transactions 
-			WHERE created_at >= $1 AND created_at < $2 
-			ORDER BY created_at DESC
-		`
-		args = []interface{}{startOfDay, endOfDay}
-	}
+	return GetWarehouseTransactionsByDate(date, reportWarehouse(user))
+}
+
+// GetWarehouseTransactionsByDate mengambil transaksi pada tanggal tertentu di satu gudang (nil = semua gudang).
+// Transaksi yang sudah di-void tidak ikut.
+func GetWarehouseTransactionsByDate(date time.Time, warehouseID *int) ([]Transaction, error) {
+	return transactionsByDate(date, warehouseID, false)
+}
 
-	rows, err := config.DB.Query(query, args...)
+// GetVoidedTransactionsByDate mengambil transaksi yang di-void pada tanggal transaksinya
+func GetVoidedTransactionsByDate(user *User, date time.Time) ([]Transaction, error) {
+	return GetWarehouseVoidedTransactionsByDate(date, reportWarehouse(user))
+}
+
+// GetWarehouseVoidedTransactionsByDate mengambil transaksi yang di-void pada tanggal tertentu di satu
+// gudang (nil = semua gudang)
+func GetWarehouseVoidedTransactionsByDate(date time.Time, warehouseID *int) ([]Transaction, error) {
+	return transactionsByDate(date, warehouseID, true)
+}
+
+func transactionsByDate(date time.Time, warehouseID *int, voided bool) ([]Transaction, error) {
+	startOfDay, endOfDay := dayRange(date)
+	all, err := store.Transactions().ListByDate(startOfDay, endOfDay, warehouseID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
 	var transactions []Transaction
-	for rows.Next() {
-		var t Transaction
-		err := rows.Scan(&t.ID, &t.UserID, &t.WarehouseID, &t.Total, &t.Profit, &t.Payment, &t.Change, &t.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-
-		// Get items for this transaction
-		t.Items, err = GetTransactionItems(t.ID)
-		if err != nil {
-			return nil, err
+	for _, t := range all {
+		if t.Voided() == voided {
+			transactions = append(transactions, t)
 		}
-
-		transactions = append(transactions, t)
 	}
 	return transactions, nil
 }
 
 // GetTransactionItems mengambil item-item transaksi
 func GetTransactionItems(transactionID int) ([]TransactionItem, error) {
-	rows, err := config.DB.Query(`
-		SELECT id, transaction_id, product_id, product_name, quantity, purchase_price, selling_price, subtotal, profit 
-		FROM transaction_items 
-		WHERE transaction_id = $1
-	`, transactionID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var items []TransactionItem
-	for rows.Next() {
-		var item TransactionItem
-		err := rows.Scan(&item.ID, &item.TransactionID, &item.ProductID,
-			&item.ProductName, &item.Quantity, &item.PurchasePrice, &item.SellingPrice, &item.Subtotal, &item.Profit)
-		if err != nil {
-			return nil, err
-		}
-		items = append(items, item)
-	}
-	return items, nil
+	return store.Transactions().Items(transactionID)
 }
 
 // GetDailyTotal mengambil total penjualan harian
 func GetDailyTotal(user *User, date time.Time) (float64, float64, int, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	var query string
-	var args []interface{}
-
-	if user != nil && !user.IsAdmin() && user.WarehouseID != nil {
-		query = `
-			SELECT COALESCE(SUM(total), 0), COALESCE(SUM(profit), 0), COUNT(*) 
-			FROM transactions 
-			WHERE created_at >= $1 AND created_at < $2 AND warehouse_id = $3
-		`
-		args = []interface{}{startOfDay, endOfDay, *user.WarehouseID}
-	} else {
-		query = `
-			SELECT COALESCE(SUM(total), 0), COALESCE(SUM(profit), 0), COUNT(*) 
-			FROM transactions 
-			WHERE created_at >= $1 AND created_at < $2
-		`
-		args = []interface{}{startOfDay, endOfDay}
-	}
+	return GetWarehouseDailyTotal(date, reportWarehouse(user))
+}
 
-	var total, profit float64
-	var count int
-	err := config.DB.QueryRow(query, args...).Scan(&total, &profit, &count)
+// GetWarehouseDailyTotal mengambil total penjualan, profit, dan jumlah transaksi harian
+// di satu gudang (nil = semua gudang), tanpa transaksi yang sudah di-void
+func GetWarehouseDailyTotal(date time.Time, warehouseID *int) (float64, float64, int, error) {
+	startOfDay, endOfDay := dayRange(date)
+	return store.Transactions().Summary(startOfDay, endOfDay, warehouseID)
+}
+
+// dayRange mengembalikan awal hari dan awal hari berikutnya dari tanggal
+func dayRange(date time.Time) (time.Time, time.Time) {
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return startOfDay, startOfDay.Add(24 * time.Hour)
+}
 
-	return total, profit, count, err
+// reportWarehouse mengembalikan gudang yang boleh dilihat user (nil = semua gudang)
+func reportWarehouse(user *User) *int {
+	if user != nil && !user.HasAllWarehouses() {
+		if user.WarehouseID == nil {
+			// Tanpa gudang dan tanpa akses semua gudang: tidak boleh melihat data gudang mana pun
+			none := 0
+			return &none
+		}
+		return user.WarehouseID
+	}
+	return nil
 }
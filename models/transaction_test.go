@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateTransactionTotalsAndStock(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 100, w.ID)
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 50, w.ID)
+
+	trx, err := CreateTransaction(cashier, []CartItem{
+		{Product: mie, Quantity: 3},
+		{Product: aqua, Quantity: 2},
+	}, 20000)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+
+	if trx.Total != 18500 {
+		t.Errorf("total = %v, want 18500", trx.Total)
+	}
+	if trx.Profit != 6000 {
+		t.Errorf("profit = %v, want 6000", trx.Profit)
+	}
+	if trx.Change != 1500 {
+		t.Errorf("change = %v, want 1500", trx.Change)
+	}
+	if trx.WarehouseID != w.ID || trx.UserID != cashier.ID || trx.CashierName != "kasir1" {
+		t.Errorf("transaction header = %+v", trx)
+	}
+	if len(trx.Items) != 2 || trx.Items[0].Subtotal != 10500 || trx.Items[1].Profit != 3000 {
+		t.Errorf("items = %+v", trx.Items)
+	}
+
+	for _, tc := range []struct {
+		id   int
+		want int
+	}{{mie.ID, 97}, {aqua.ID, 48}} {
+		p, _ := GetProductByID(tc.id)
+		if p.Stock != tc.want {
+			t.Errorf("stock product %d = %d, want %d", tc.id, p.Stock, tc.want)
+		}
+	}
+
+	logs, total, _ := GetAuditLogs(AuditFilter{Entity: EntityTransaction}, 1, 10)
+	if total != 1 || *logs[0].EntityID != trx.ID || logs[0].Username != "kasir1" {
+		t.Errorf("audit log = %+v (total %d)", logs, total)
+	}
+}
+
+func TestCreateTransactionInsufficientStockRollsBack(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, w.ID)
+	roti := mustProduct(t, "Roti Tawar", 12000, 15000, 1, w.ID)
+
+	_, err := CreateTransaction(cashier, []CartItem{
+		{Product: mie, Quantity: 5},
+		{Product: roti, Quantity: 2},
+	}, 100000)
+	if err == nil {
+		t.Fatal("expected error for insufficient stock")
+	}
+
+	p, _ := GetProductByID(mie.ID)
+	if p.Stock != 10 {
+		t.Errorf("stock after rollback = %d, want 10", p.Stock)
+	}
+
+	trxs, err := GetTransactionsByDate(nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trxs) != 0 {
+		t.Errorf("transactions saved = %d, want 0", len(trxs))
+	}
+	if _, total, _ := GetAuditLogs(AuditFilter{Entity: EntityTransaction}, 1, 10); total != 0 {
+		t.Errorf("audit logs = %d, want 0", total)
+	}
+}
+
+func TestCreateTransactionValidatesCartAndPayment(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, w.ID)
+	roti := mustProduct(t, "Roti Tawar", 12000, 15000, 5, w.ID)
+
+	tests := []struct {
+		name    string
+		items   []CartItem
+		payment float64
+	}{
+		{"empty cart", nil, 10000},
+		{"zero quantity", []CartItem{{Product: mie, Quantity: 2}, {Product: roti, Quantity: 0}}, 100000},
+		// Jumlah negatif akan menambah stok dan mengurangi total jika lolos
+		{"negative quantity", []CartItem{{Product: roti, Quantity: 1}, {Product: mie, Quantity: -3}}, 100000},
+		{"underpayment", []CartItem{{Product: mie, Quantity: 2}}, 6999},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := CreateTransaction(cashier, tc.items, tc.payment); !errors.Is(err, ErrInvalidTransaction) {
+				t.Errorf("err = %v, want ErrInvalidTransaction", err)
+			}
+		})
+	}
+	if stock := stockIn(t, mie.ID, w.ID); stock != 10 {
+		t.Errorf("stock after rejected transactions = %d, want 10", stock)
+	}
+	if trxs, _ := GetTransactionsByDate(nil, time.Now()); len(trxs) != 0 {
+		t.Errorf("transactions saved = %d, want 0", len(trxs))
+	}
+
+	// Uang pas diterima
+	trx, err := CreateTransaction(cashier, []CartItem{{Product: mie, Quantity: 2}}, 7000)
+	if err != nil || trx.Change != 0 {
+		t.Errorf("exact payment: trx = %+v, err = %v", trx, err)
+	}
+}
+
+func TestCreateTransactionAdminUsesProductWarehouse(t *testing.T) {
+	setupTestStore(t)
+	mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	admin := mustUser(t, "admin", AdminRole, nil)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, cabang.ID)
+
+	trx, err := CreateTransaction(admin, []CartItem{{Product: mie, Quantity: 1}}, 3500)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	if trx.WarehouseID != cabang.ID {
+		t.Errorf("warehouse = %d, want %d", trx.WarehouseID, cabang.ID)
+	}
+}
+
+func TestDailyReportScopedToWarehouse(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirPusat := mustUser(t, "kasir1", "user", &pusat.ID)
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+	admin := mustUser(t, "admin", AdminRole, nil)
+	mie1 := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, pusat.ID)
+	mie2 := mustProduct(t, "Indomie Goreng", 2500, 3500, 10, cabang.ID)
+
+	if _, err := CreateTransaction(kasirPusat, []CartItem{{Product: mie1, Quantity: 2}}, 10000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateTransaction(kasirCabang, []CartItem{{Product: mie2, Quantity: 1}}, 5000); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user   *User
+		total  float64
+		profit float64
+		count  int
+	}{
+		{kasirPusat, 7000, 2000, 1},
+		{kasirCabang, 3500, 1000, 1},
+		{admin, 10500, 3000, 2},
+	}
+	for _, tc := range tests {
+		total, profit, count, err := GetDailyTotal(tc.user, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != tc.total || profit != tc.profit || count != tc.count {
+			t.Errorf("%s: got (%v, %v, %d), want (%v, %v, %d)", tc.user.Username, total, profit, count, tc.total, tc.profit, tc.count)
+		}
+
+		trxs, _ := GetTransactionsByDate(tc.user, time.Now())
+		if len(trxs) != tc.count {
+			t.Errorf("%s: transactions = %d, want %d", tc.user.Username, len(trxs), tc.count)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status transfer stok
+const (
+	TransferDraft    = "draft"    // belum dikirim, stok belum berubah
+	TransferSent     = "sent"     // sudah keluar dari gudang asal, dalam perjalanan
+	TransferReceived = "received" // sudah diterima gudang tujuan
+)
+
+// Transfer adalah dokumen pemindahan stok dari satu gudang ke gudang lain.
+// Stok gudang asal berkurang saat dikirim, stok gudang tujuan bertambah saat diterima.
+type Transfer struct {
+	ID              int
+	FromWarehouseID int
+	ToWarehouseID   int
+	Status          string
+	Note            string
+	UserID          int    // pembuat transfer
+	CreatedBy       string // username pembuat transfer
+	CreatedAt       time.Time
+	SentAt          *time.Time
+	ReceivedAt      *time.Time
+	Items           []TransferItem
+}
+
+// TransferItem adalah satu produk di dokumen transfer
+type TransferItem struct {
+	ID          int
+	TransferID  int
+	ProductID   int
+	ProductName string
+	Quantity    int     // jumlah dikirim
+	Received    int     // jumlah diterima, diisi saat penerimaan
+	UnitCost    float64 // harga pokok per unit yang keluar dari gudang asal, diisi saat dikirim
+}
+
+// Shortage mengembalikan selisih barang yang dikirim tapi tidak sampai di gudang tujuan
+func (i TransferItem) Shortage() int {
+	return i.Quantity - i.Received
+}
+
+// TransferStatusLabel mengembalikan nama status transfer untuk ditampilkan
+func TransferStatusLabel(status string) string {
+	switch status {
+	case TransferDraft:
+		return "Draft"
+	case TransferSent:
+		return "Dalam Perjalanan"
+	case TransferReceived:
+		return "Diterima"
+	}
+	return status
+}
+
+// checkWarehouseAccess memastikan actor boleh mengurus dokumen stok (transfer, opname) di gudang warehouseID
+func checkWarehouseAccess(actor *User, warehouseID int) error {
+	if actor == nil || actor.HasAllWarehouses() {
+		return nil
+	}
+	if actor.WarehouseID == nil || *actor.WarehouseID != warehouseID {
+		return errors.New("tidak punya akses ke gudang tersebut")
+	}
+	return nil
+}
+
+// GetTransfers mengambil transfer dari atau ke gudang user (semua gudang jika user punya akses
+// semua gudang), terbaru dulu. Status kosong = semua status.
+func GetTransfers(user *User, status string) ([]Transfer, error) {
+	return GetWarehouseTransfers(reportWarehouse(user), status)
+}
+
+// GetWarehouseTransfers mengambil transfer dari atau ke satu gudang (nil = semua gudang)
+func GetWarehouseTransfers(warehouseID *int, status string) ([]Transfer, error) {
+	return store.Transfers().List(warehouseID, status)
+}
+
+// GetTransfer mengambil transfer beserta item-nya; user gudang hanya boleh melihat transfer
+// yang berasal dari atau menuju gudangnya
+func GetTransfer(user *User, id int) (*Transfer, error) {
+	t, err := store.Transfers().GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("transfer dengan ID %d tidak ditemukan", id)
+	}
+	if checkWarehouseAccess(user, t.FromWarehouseID) != nil && checkWarehouseAccess(user, t.ToWarehouseID) != nil {
+		return nil, fmt.Errorf("transfer dengan ID %d tidak ditemukan", id)
+	}
+	return t, nil
+}
+
+// CreateTransfer membuat draft transfer dari gudang from ke gudang to. Produk yang sama
+// digabung menjadi satu baris; stok belum berubah sampai transfer dikirim.
+func CreateTransfer(actor *User, from, to int, note string, items []TransferItem) (*Transfer, error) {
+	if from == to {
+		return nil, errors.New("gudang asal dan tujuan tidak boleh sama")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("transfer harus berisi minimal satu produk")
+	}
+	if err := checkWarehouseAccess(actor, from); err != nil {
+		return nil, err
+	}
+
+	t := &Transfer{FromWarehouseID: from, ToWarehouseID: to, Status: TransferDraft, Note: note}
+	if actor != nil {
+		t.UserID, t.CreatedBy = actor.ID, actor.Username
+	}
+
+	err := store.WithTx(func(s Store) error {
+		if _, err := s.Warehouses().GetByID(from); err != nil {
+			return fmt.Errorf("gudang asal dengan ID %d tidak ditemukan", from)
+		}
+		if _, err := s.Warehouses().GetByID(to); err != nil {
+			return fmt.Errorf("gudang tujuan dengan ID %d tidak ditemukan", to)
+		}
+
+		lines := make(map[int]int) // product ID -> index di t.Items
+		for _, item := range items {
+			if item.Quantity <= 0 {
+				return errors.New("jumlah transfer harus lebih dari 0")
+			}
+			if i, ok := lines[item.ProductID]; ok {
+				t.Items[i].Quantity += item.Quantity
+				continue
+			}
+
+			p, err := s.Products().GetByID(item.ProductID)
+			if err != nil {
+				return fmt.Errorf("produk dengan ID %d tidak ditemukan", item.ProductID)
+			}
+			variants, err := s.Products().Variants(p.ID)
+			if err != nil {
+				return err
+			}
+			if len(variants) > 0 {
+				return fmt.Errorf("%s: %w", p.Name, ErrHasVariants)
+			}
+			st, err := findStock(s, p.ID, from)
+			if err != nil {
+				return err
+			}
+			if st == nil {
+				return fmt.Errorf("%s: %w", p.DisplayName(), ErrNotInWarehouse)
+			}
+
+			lines[p.ID] = len(t.Items)
+			t.Items = append(t.Items, TransferItem{ProductID: p.ID, ProductName: p.DisplayName(), Quantity: item.Quantity})
+		}
+
+		if err := s.Transfers().Create(t); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityTransfer, t.ID, nil, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SendTransfer mengirim draft transfer: stok gudang asal berkurang dan barang tercatat
+// dalam perjalanan sampai diterima gudang tujuan
+func SendTransfer(actor *User, id int) (*Transfer, error) {
+	var sent *Transfer
+	err := store.WithTx(func(s Store) error {
+		t, err := s.Transfers().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("transfer dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, t.FromWarehouseID); err != nil {
+			return err
+		}
+		if t.Status != TransferDraft {
+			return fmt.Errorf("transfer %d sudah berstatus %s", id, TransferStatusLabel(t.Status))
+		}
+
+		before := *t
+		before.Items = append([]TransferItem(nil), t.Items...)
+		for i, item := range t.Items {
+			stock, err := s.Products().DecrementStock(item.ProductID, t.FromWarehouseID, item.Quantity)
+			if err == ErrInsufficientStock {
+				return fmt.Errorf("stok %s di gudang asal tidak mencukupi", item.ProductName)
+			}
+			if err != nil {
+				return err
+			}
+			cost, err := consumeCost(s, item.ProductID, t.FromWarehouseID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			t.Items[i].UnitCost = cost / float64(item.Quantity)
+			batches, err := consumeBatches(s, item.ProductID, t.FromWarehouseID, item.Quantity, true)
+			if err == ErrExpiredStock {
+				return fmt.Errorf("stok %s di gudang asal tidak mencukupi, sisanya sudah kedaluwarsa", item.ProductName)
+			}
+			if err != nil {
+				return err
+			}
+			for _, b := range batches {
+				tb := TransferBatch{TransferID: t.ID, ProductID: item.ProductID, BatchNo: b.BatchNo, ExpiryDate: b.ExpiryDate, Quantity: b.Quantity}
+				if err := s.Batches().CreateTransferBatch(&tb); err != nil {
+					return err
+				}
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: t.FromWarehouseID,
+				Type:        MovementTransfer,
+				Quantity:    -item.Quantity,
+				Balance:     stock,
+				Reference:   fmt.Sprintf("TRF-%06d", t.ID),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		t.Status, t.SentAt = TransferSent, &now
+		if err := s.Transfers().Update(t); err != nil {
+			return err
+		}
+		sent = t
+		return writeAudit(s, actor, AuditUpdate, EntityTransfer, t.ID, before, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sent, nil
+}
+
+// ReceiveTransfer mencatat penerimaan transfer di gudang tujuan. received berisi jumlah yang
+// benar-benar diterima per ID produk; produk yang tidak disebut dianggap diterima penuh.
+// Selisih (dikirim - diterima) dicatat di item transfer dan tidak kembali ke gudang asal.
+func ReceiveTransfer(actor *User, id int, received map[int]int) (*Transfer, error) {
+	var done *Transfer
+	err := store.WithTx(func(s Store) error {
+		t, err := s.Transfers().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("transfer dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, t.ToWarehouseID); err != nil {
+			return err
+		}
+		if t.Status != TransferSent {
+			return fmt.Errorf("transfer %d berstatus %s, hanya transfer dalam perjalanan yang bisa diterima",
+				id, TransferStatusLabel(t.Status))
+		}
+
+		lines := make(map[int]bool, len(t.Items))
+		for _, item := range t.Items {
+			lines[item.ProductID] = true
+		}
+		for productID := range received {
+			if !lines[productID] {
+				return fmt.Errorf("produk dengan ID %d tidak ada di transfer ini", productID)
+			}
+		}
+
+		batches, err := s.Batches().TransferBatches(t.ID)
+		if err != nil {
+			return err
+		}
+
+		before := *t
+		before.Items = append([]TransferItem(nil), t.Items...)
+		for i := range t.Items {
+			item := &t.Items[i]
+			item.Received = item.Quantity
+			if qty, ok := received[item.ProductID]; ok {
+				item.Received = qty
+			}
+			if item.Received < 0 || item.Received > item.Quantity {
+				return fmt.Errorf("jumlah diterima %s harus antara 0 dan %d", item.ProductName, item.Quantity)
+			}
+			if item.Received == 0 {
+				continue
+			}
+			stock, err := receiveStock(s, item.ProductID, t.ToWarehouseID, item.Received)
+			if err != nil {
+				return err
+			}
+			// Barang yang sampai membawa harga pokok dari gudang asal; selisihnya hilang bersama barangnya
+			if err := addCostLayer(s, item.ProductID, t.ToWarehouseID, item.Received, item.UnitCost, fmt.Sprintf("TRF-%06d", t.ID)); err != nil {
+				return err
+			}
+			if err := receiveTransferBatches(s, t, item.ProductID, item.Received, batches); err != nil {
+				return err
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: t.ToWarehouseID,
+				Type:        MovementTransfer,
+				Quantity:    item.Received,
+				Balance:     stock,
+				Reference:   fmt.Sprintf("TRF-%06d", t.ID),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		t.Status, t.ReceivedAt = TransferReceived, &now
+		if err := s.Transfers().Update(t); err != nil {
+			return err
+		}
+		done = t
+		return writeAudit(s, actor, AuditUpdate, EntityTransfer, t.ID, before, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// receiveStock menambah stok produk di gudang tujuan dan mengembalikan stok barunya; produk (dan induk
+// varian) yang belum ada di gudang tersebut ditambahkan dengan harga master
+func receiveStock(s Store, productID, warehouseID, quantity int) (int, error) {
+	stock, err := s.Products().IncrementStock(productID, warehouseID, quantity)
+	if err != ErrNotFound {
+		return stock, err
+	}
+	if err := addToWarehouse(s, productID, warehouseID, quantity); err != nil {
+		return 0, err
+	}
+
+	p, err := s.Products().GetByID(productID)
+	if err != nil || p.ParentID == nil {
+		return quantity, err
+	}
+	stocks, err := s.Products().Stocks(*p.ParentID)
+	if err != nil {
+		return 0, err
+	}
+	return quantity, addParentToWarehouse(s, *p.ParentID, warehouseID, stocks)
+}
+
+// receiveTransferBatches membuat ulang batch yang dikirim di gudang tujuan. Jumlah received dibagi
+// ke batch yang paling cepat kedaluwarsa lebih dulu; sisanya menjadi stok tanpa batch.
+func receiveTransferBatches(s Store, t *Transfer, productID, received int, batches []TransferBatch) error {
+	for _, b := range batches {
+		if b.ProductID != productID || received == 0 {
+			continue
+		}
+		quantity := b.Quantity
+		if quantity > received {
+			quantity = received
+		}
+		received -= quantity
+		if err := addBatch(s, productID, t.ToWarehouseID, b.BatchNo, b.ExpiryDate, quantity, fmt.Sprintf("TRF-%06d", t.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelTransfer membatalkan (menghapus) transfer yang masih draft
+func CancelTransfer(actor *User, id int) error {
+	return store.WithTx(func(s Store) error {
+		t, err := s.Transfers().GetByID(id)
+		if err != nil {
+			return fmt.Errorf("transfer dengan ID %d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(actor, t.FromWarehouseID); err != nil {
+			return err
+		}
+		if t.Status != TransferDraft {
+			return fmt.Errorf("transfer %d sudah berstatus %s, hanya draft yang bisa dibatalkan", id, TransferStatusLabel(t.Status))
+		}
+		if err := s.Transfers().Delete(id); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityTransfer, id, t, nil)
+	})
+}
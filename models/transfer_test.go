@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransferLifecycle(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	kasirPusat := mustUser(t, "kasir1", "user", &pusat.ID)
+	kasirCabang := mustUser(t, "kasir2", "user", &cabang.ID)
+
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 20, pusat.ID)
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 10, pusat.ID)
+
+	if _, err := CreateTransfer(kasirCabang, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 1}}); err == nil {
+		t.Error("only the source warehouse may create a transfer")
+	}
+	if _, err := CreateTransfer(kasirPusat, pusat.ID, pusat.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 1}}); err == nil {
+		t.Error("source and destination must differ")
+	}
+
+	trf, err := CreateTransfer(kasirPusat, pusat.ID, cabang.ID, "stok mingguan", []TransferItem{
+		{ProductID: mie.ID, Quantity: 6}, {ProductID: aqua.ID, Quantity: 4}, {ProductID: mie.ID, Quantity: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trf.Status != TransferDraft || len(trf.Items) != 2 || trf.Items[0].Quantity != 10 {
+		t.Errorf("draft = %+v", trf)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 20 {
+		t.Errorf("draft should not move stock, got %d", p.Stock)
+	}
+
+	if _, err := ReceiveTransfer(kasirCabang, trf.ID, nil); err == nil {
+		t.Error("a draft cannot be received")
+	}
+	if _, err := SendTransfer(kasirCabang, trf.ID); err == nil {
+		t.Error("destination must not dispatch the transfer")
+	}
+	if _, err := SendTransfer(kasirPusat, trf.ID); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := GetProductInWarehouse(mie.ID, pusat.ID); p.Stock != 10 {
+		t.Errorf("source stock after dispatch = %d, want 10", p.Stock)
+	}
+	if inTransit, _ := GetTransfers(kasirCabang, TransferSent); len(inTransit) != 1 {
+		t.Errorf("destination should see the transfer in transit: %+v", inTransit)
+	}
+	if err := CancelTransfer(kasirPusat, trf.ID); err == nil {
+		t.Error("a sent transfer cannot be cancelled")
+	}
+
+	if _, err := ReceiveTransfer(kasirPusat, trf.ID, nil); err == nil {
+		t.Error("source must not receive its own transfer")
+	}
+	if _, err := ReceiveTransfer(kasirCabang, trf.ID, map[int]int{mie.ID: 11}); err == nil {
+		t.Error("cannot receive more than was sent")
+	}
+	// Aqua hanya sampai 3 dari 4; Indomie diterima penuh
+	done, err := ReceiveTransfer(kasirCabang, trf.ID, map[int]int{aqua.ID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != TransferReceived || done.Items[0].Shortage() != 0 || done.Items[1].Shortage() != 1 {
+		t.Errorf("received = %+v", done)
+	}
+	if p, err := GetProductInWarehouse(aqua.ID, cabang.ID); err != nil || p.Stock != 3 || p.SellingPrice != 4000 {
+		t.Errorf("destination stock = %+v, %v", p, err)
+	}
+	if p, _ := GetProductByID(aqua.ID); p.Stock != 9 {
+		t.Errorf("total stock = %d, want 9 (1 lost in transit)", p.Stock)
+	}
+	if _, err := ReceiveTransfer(kasirCabang, trf.ID, nil); err == nil {
+		t.Error("a transfer can only be received once")
+	}
+}
+
+func TestTransferDispatchChecksStock(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	mie := mustProduct(t, "Indomie Goreng", 2500, 3500, 2, pusat.ID)
+	kaos := mustProduct(t, "Kaos Polos", 30000, 50000, 0, pusat.ID)
+	l, _ := CreateVariant(nil, kaos.ID, Product{Variant: "L", Stock: 5})
+
+	if _, err := CreateTransfer(nil, cabang.ID, pusat.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 1}}); !errors.Is(err, ErrNotInWarehouse) {
+		t.Errorf("product missing at source: err = %v, want ErrNotInWarehouse", err)
+	}
+	if _, err := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: kaos.ID, Quantity: 1}}); !errors.Is(err, ErrHasVariants) {
+		t.Errorf("parent product: err = %v, want ErrHasVariants", err)
+	}
+
+	short, _ := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: mie.ID, Quantity: 3}})
+	if _, err := SendTransfer(nil, short.ID); err == nil {
+		t.Error("dispatch should fail when source stock is insufficient")
+	}
+	if got, _ := GetTransfer(nil, short.ID); got.Status != TransferDraft {
+		t.Errorf("failed dispatch should keep the draft, got %s", got.Status)
+	}
+	if err := CancelTransfer(nil, short.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetTransfer(nil, short.ID); err == nil {
+		t.Error("cancelled draft should be removed")
+	}
+
+	// Varian yang diterima di gudang baru ikut membawa produk induknya
+	trf, _ := CreateTransfer(nil, pusat.ID, cabang.ID, "", []TransferItem{{ProductID: l.ID, Quantity: 2}})
+	SendTransfer(nil, trf.ID)
+	if _, err := ReceiveTransfer(nil, trf.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if variants, _ := GetWarehouseVariants(kaos.ID, cabang.ID); len(variants) != 1 || variants[0].Stock != 2 {
+		t.Errorf("variants in destination = %+v", variants)
+	}
+	if _, err := GetProductInWarehouse(kaos.ID, cabang.ID); err != nil {
+		t.Errorf("parent should be added to destination: %v", err)
+	}
+}
@@ -1,132 +1,305 @@
 package models
 
 import (
+	"crypto/subtle"
 	"errors"
-	"kasir/config"
+	"fmt"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // User model
 type User struct {
 	ID          int
 	Username    string
-	Password    string
-	Role        string // "admin" atau "user"
-	WarehouseID *int   // nil untuk admin (akses semua gudang)
+	Password    string     `json:"-"` // hash bcrypt, tidak pernah dikirim ke client
+	Role        string     // nama role, lihat tabel roles
+	WarehouseID *int       // nil hanya untuk role dengan akses semua gudang
+	Permissions []string   // permission dari role, diisi saat login
+	LockedUntil *time.Time // terisi jika akun sedang dikunci karena login gagal
 	CreatedAt   time.Time
 }
 
-// CurrentUser menyimpan user yang sedang login
-var CurrentUser *User
+// Authenticate memverifikasi username dan password dengan pembatasan percobaan gagal
+// per username dan per IP client (clientIP kosong untuk login dari CLI).
+func Authenticate(username, password, clientIP string) (*User, error) {
+	// Percobaan dicatat sebelum password dicocokkan agar request paralel tidak lolos dari batas
+	if err := reserveLoginAttempt(username, clientIP); err != nil {
+		return nil, err
+	}
 
-// Login melakukan validasi login
-func Login(username, password string) (*User, error) {
-	u, err := Authenticate(username, password)
+	u, err := verifyCredentials(username, password)
 	if err != nil {
 		return nil, err
 	}
-	CurrentUser = u
+
+	if err := ResetLoginFailures(username, clientIP); err != nil {
+		return nil, err
+	}
+	if err := u.CheckWarehouseAssigned(); err != nil {
+		return nil, err
+	}
 	return u, nil
 }
 
-// Authenticate memverifikasi username dan password
-func Authenticate(username, password string) (*User, error) {
-	var u User
-	var warehouseID *int
+// verifyCredentials mencocokkan password dengan hash di database.
+// Password plaintext lama (sebelum hashing) akan di-upgrade ke bcrypt saat login berhasil.
+func verifyCredentials(username, password string) (*User, error) {
+	u, err := store.Users().GetByUsername(username)
+	if err != nil {
+		return nil, errors.New("username atau password salah")
+	}
 
-	err := config.DB.QueryRow(`
-		SELECT id, username, password, role, warehouse_id, created_at 
-		FROM users 
-		WHERE username = $1 AND password = $2
-	`, username, password).Scan(&u.ID, &u.Username, &u.Password, &u.Role, &warehouseID, &u.CreatedAt)
+	if isPasswordHash(u.Password) {
+		if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+			return nil, errors.New("username atau password salah")
+		}
+	} else {
+		// Baris lama masih menyimpan plaintext
+		if subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) != 1 {
+			return nil, errors.New("username atau password salah")
+		}
+		if hash, err := HashPassword(password); err == nil {
+			if err := store.Users().UpdatePassword(u.ID, hash); err == nil {
+				u.Password = hash
+			}
+		}
+	}
 
+	u.Permissions, err = GetRolePermissions(u.Role)
 	if err != nil {
-		return nil, errors.New("username atau password salah")
+		return nil, err
 	}
+	return u, nil
+}
 
-	u.WarehouseID = warehouseID
-	return &u, nil
+// HashPassword menghasilkan hash bcrypt dari password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.New("gagal memproses password")
+	}
+	return string(hash), nil
 }
 
-// Logout menghapus current user session
-func Logout() {
-	CurrentUser = nil
+// isPasswordHash mengecek apakah nilai kolom password sudah berupa hash bcrypt
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
 }
 
-// Register membuat user baru
-func Register(username, password, role string, warehouseID *int) (*User, error) {
-	var u User
-	err := config.DB.QueryRow(`
-		INSERT INTO users (username, password, role, warehouse_id) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, username, password, role, warehouse_id, created_at
-	`, username, password, role, warehouseID).Scan(&u.ID, &u.Username, &u.Password, &u.Role, &u.WarehouseID, &u.CreatedAt)
+// ErrRoleNotAllowed dikembalikan jika actor memberikan role yang lebih tinggi dari haknya sendiri
+var ErrRoleNotAllowed = errors.New("tidak boleh memberikan role dengan permission yang tidak Anda miliki")
+
+// ErrWarehouseRequired dikembalikan jika user dengan role tanpa akses semua gudang dibuat tanpa gudang
+var ErrWarehouseRequired = errors.New("role tanpa akses semua gudang wajib terikat ke gudang")
 
+// Register membuat user baru. Role harus terdaftar di tabel roles dan boleh diberikan actor
+// (lihat checkRoleAssignable); actor nil berarti dibuat oleh sistem.
+func Register(actor *User, username, password, role string, warehouseID *int) (*User, error) {
+	r, err := store.Roles().GetByName(role)
 	if err != nil {
-		return nil, errors.New("gagal membuat user, username mungkin sudah digunakan")
+		return nil, fmt.Errorf("role '%s' tidak ditemukan", role)
+	}
+	if err := checkRoleAssignable(actor, r); err != nil {
+		return nil, err
+	}
+	if warehouseID == nil && !(&User{Role: r.Name, Permissions: r.Permissions}).HasAllWarehouses() {
+		return nil, fmt.Errorf("role '%s': %w", role, ErrWarehouseRequired)
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	u := User{Username: username, Password: hash, Role: role, WarehouseID: warehouseID}
+
+	err = store.WithTx(func(s Store) error {
+		if err := s.Users().Create(&u); err != nil {
+			return errors.New("gagal membuat user, username mungkin sudah digunakan")
+		}
+		return writeAudit(s, actor, AuditCreate, EntityUser, u.ID, nil, u)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &u, nil
 }
 
-// GetAllUsers mengambil semua user
+// checkRoleAssignable mencegah eskalasi hak: role admin hanya bisa diberikan admin, role lain hanya
+// oleh actor yang punya role.manage atau sudah memiliki semua permission role tersebut
+func checkRoleAssignable(actor *User, r *Role) error {
+	if actor == nil || actor.IsAdmin() {
+		return nil
+	}
+	if r.Name == AdminRole {
+		return ErrRoleNotAllowed
+	}
+	if actor.Can(PermRoleManage) {
+		return nil
+	}
+	for _, p := range r.Permissions {
+		if !actor.Can(p) {
+			return ErrRoleNotAllowed
+		}
+	}
+	return nil
+}
+
+// BootstrapAdmin membuat user admin pertama jika database belum punya user sama sekali, dengan
+// password yang di-hash bcrypt. Mengembalikan nil tanpa error jika sudah ada user.
+func BootstrapAdmin(username, password string) (*User, error) {
+	users, err := store.Users().List()
+	if err != nil || len(users) > 0 {
+		return nil, err
+	}
+	return Register(nil, username, password, AdminRole, nil)
+}
+
+// GetAllUsers mengambil semua user beserta status kunci login-nya
 func GetAllUsers() ([]User, error) {
-	rows, err := config.DB.Query(`
-		SELECT u.id, u.username, u.role, u.warehouse_id, u.created_at,
-			   COALESCE(w.name, '-') as warehouse_name
-		FROM users u
-		LEFT JOIN warehouses w ON u.warehouse_id = w.id
-		ORDER BY u.id
-	`)
+	users, err := store.Users().List()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var users []User
-	for rows.Next() {
-		var u User
-		var warehouseName string
-		err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.WarehouseID, &u.CreatedAt, &warehouseName)
-		if err != nil {
-			return nil, err
+	locked, err := store.LoginThrottle().LockedKeys(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if until, ok := locked[userThrottleKey(users[i].Username)]; ok {
+			users[i].LockedUntil = &until
 		}
-		users = append(users, u)
 	}
 	return users, nil
 }
 
 // GetUserByID mengambil user berdasarkan ID
 func GetUserByID(id int) (*User, error) {
-	var u User
-	err := config.DB.QueryRow(`
-		SELECT id, username, password, role, warehouse_id, created_at 
-		FROM users 
-		WHERE id = $1
-	`, id).Scan(&u.ID, &u.Username, &u.Password, &u.Role, &u.WarehouseID, &u.CreatedAt)
+	u, err := store.Users().GetByID(id)
+	if err != nil {
+		return nil, err
+	}
 
+	u.Permissions, err = GetRolePermissions(u.Role)
 	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	return u, nil
 }
 
 // DeleteUser menghapus user
-func DeleteUser(id int) error {
-	result, err := config.DB.Exec(`DELETE FROM users WHERE id = $1`, id)
+func DeleteUser(actor *User, id int) error {
+	return store.WithTx(func(s Store) error {
+		before, err := s.Users().GetByID(id)
+		if err != nil {
+			return errors.New("user tidak ditemukan")
+		}
+		if err := checkUserManageable(s, actor, before); err != nil {
+			return err
+		}
+		if before.IsAdmin() {
+			users, err := s.Users().List()
+			if err != nil {
+				return err
+			}
+			admins := 0
+			for _, u := range users {
+				if u.IsAdmin() {
+					admins++
+				}
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		if err := s.Users().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return errors.New("user tidak ditemukan")
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityUser, id, before, nil)
+	})
+}
+
+// ErrLastAdmin dikembalikan saat menghapus user admin terakhir
+var ErrLastAdmin = errors.New("admin terakhir tidak bisa dihapus")
+
+// checkUserManageable memastikan actor boleh mengurus akun target (hapus, buka kunci) dengan aturan
+// yang sama seperti memberikan role target, agar pemegang user.manage tidak bisa mengurus akun admin
+// atau akun dengan hak lebih tinggi dari dirinya
+func checkUserManageable(s Store, actor, target *User) error {
+	r, err := s.Roles().GetByName(target.Role)
 	if err != nil {
-		return err
+		// Role sudah tidak terdaftar: hanya admin (atau sistem) yang boleh mengurusnya
+		r = &Role{Name: AdminRole}
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("user tidak ditemukan")
+	if err := checkRoleAssignable(actor, r); err != nil {
+		return fmt.Errorf("%w: akun '%s' (role %s)", err, target.Username, target.Role)
 	}
 	return nil
 }
 
-// IsAdmin mengecek apakah user adalah admin
+// IsAdmin mengecek apakah user memiliki role bawaan admin
 func (u *User) IsAdmin() bool {
-	return u.Role == "admin"
+	return u.Role == AdminRole
+}
+
+// Can mengecek apakah user memiliki permission tertentu
+func (u *User) Can(permission string) bool {
+	if u == nil {
+		return false
+	}
+	if u.IsAdmin() {
+		return true
+	}
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllWarehouses mengecek apakah user boleh mengakses data semua gudang.
+// Hanya ditentukan oleh permission, bukan oleh kosongnya warehouse_id.
+func (u *User) HasAllWarehouses() bool {
+	if u == nil {
+		return false
+	}
+	return u.Can(PermAllWarehouses)
+}
+
+// ErrNoWarehouse dikembalikan saat user tanpa akses semua gudang belum terikat ke gudang mana pun
+var ErrNoWarehouse = errors.New("user belum terikat ke gudang, hubungi admin")
+
+// CheckWarehouseAssigned memastikan user tanpa akses semua gudang punya gudang, misalnya
+// user lama atau user yang permission warehouse.all-nya dicabut dari role.
+func (u *User) CheckWarehouseAssigned() error {
+	if !u.HasAllWarehouses() && u.WarehouseID == nil {
+		return ErrNoWarehouse
+	}
+	return nil
+}
+
+// ScopeWarehouse menentukan gudang yang boleh diakses untuk permintaan ke gudang requested
+// (nil = semua). User yang terikat gudang selalu dibatasi ke gudangnya sendiri.
+func (u *User) ScopeWarehouse(requested *int) (*int, error) {
+	if u.HasAllWarehouses() {
+		return requested, nil
+	}
+	if u == nil || u.WarehouseID == nil {
+		return nil, ErrNoWarehouse
+	}
+	if requested != nil && *requested != *u.WarehouseID {
+		return nil, errors.New("tidak punya akses ke gudang tersebut")
+	}
+	return u.WarehouseID, nil
 }
 
 // GetWarehouseID mengembalikan warehouse_id user (0 jika admin/nil)
@@ -137,16 +310,27 @@ func (u *User) GetWarehouseID() int {
 	return *u.WarehouseID
 }
 
-// UpdatePassword memperbarui password user
-func UpdatePassword(userID int, newPassword string) error {
-	result, err := config.DB.Exec("UPDATE users SET password = $1 WHERE id = $2", newPassword, userID)
+// UpdatePassword memperbarui password user (nilai password tidak dicatat di audit log)
+func UpdatePassword(actor *User, userID int, newPassword string) error {
+	hash, err := HashPassword(newPassword)
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("user tidak ditemukan")
-	}
-	return nil
+	return store.WithTx(func(s Store) error {
+		if err := s.Users().UpdatePassword(userID, hash); err != nil {
+			if err == ErrNotFound {
+				return errors.New("user tidak ditemukan")
+			}
+			return err
+		}
+
+		// Token API yang sudah beredar ikut tidak berlaku
+		if err := s.Sessions().RevokeUser(userID, time.Now()); err != nil {
+			return err
+		}
+
+		change := map[string]string{"password": "changed"}
+		return writeAudit(s, actor, AuditUpdate, EntityUser, userID, nil, change)
+	})
 }
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegisterChecksRole(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	if _, err := CreateRole(nil, "hrd", "Kelola user", []string{PermUserManage, PermTransactionCreate, PermProductView}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateRole(nil, "supervisor", "Supervisor", []string{PermTransactionCreate, PermTransactionVoid}); err != nil {
+		t.Fatal(err)
+	}
+	hrd := mustUser(t, "hrd1", "hrd", &w.ID)
+
+	if _, err := Register(hrd, "kasir9", "rahasia", "usr", &w.ID); err == nil {
+		t.Error("expected error registering with an unknown role")
+	}
+	for _, role := range []string{AdminRole, "supervisor"} {
+		if _, err := Register(hrd, "naik-"+role, "rahasia", role, &w.ID); !errors.Is(err, ErrRoleNotAllowed) {
+			t.Errorf("hrd assigning %s: err = %v, want ErrRoleNotAllowed", role, err)
+		}
+	}
+	if _, err := Register(hrd, "kasir9", "rahasia", "user", &w.ID); err != nil {
+		t.Errorf("hrd assigning a role within its own permissions: %v", err)
+	}
+
+	// Pemegang role.manage sudah bisa mengatur permission role, jadi boleh memberikan role apa pun selain admin
+	if _, err := CreateRole(nil, "pengelola", "", []string{PermUserManage, PermRoleManage}); err != nil {
+		t.Fatal(err)
+	}
+	manager := mustUser(t, "pengelola1", "pengelola", &w.ID)
+	if _, err := Register(manager, "spv1", "rahasia", "supervisor", &w.ID); err != nil {
+		t.Errorf("role manager assigning supervisor: %v", err)
+	}
+	if _, err := Register(manager, "admin2", "rahasia", AdminRole, nil); !errors.Is(err, ErrRoleNotAllowed) {
+		t.Errorf("role manager assigning admin: err = %v", err)
+	}
+}
+
+func TestAllWarehousesFollowsPermission(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	mustProduct(t, "Indomie Goreng", 2500, 4000, 10, pusat.ID)
+	mustProduct(t, "Aqua 600ml", 2000, 3500, 10, cabang.ID)
+
+	if _, err := Register(nil, "kasir9", "rahasia", "user", nil); !errors.Is(err, ErrWarehouseRequired) {
+		t.Error("expected error registering a role without warehouse.all and no warehouse")
+	}
+	if _, err := CreateRole(nil, "auditor", "", []string{PermProductView, PermAllWarehouses}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Register(nil, "auditor1", "rahasia", "auditor", nil); err != nil {
+		t.Errorf("auditor without warehouse: %v", err)
+	}
+
+	// User lama tanpa gudang tidak otomatis mendapat akses semua gudang
+	hash, _ := HashPassword("rahasia")
+	legacy := User{Username: "kasirlama", Password: hash, Role: "user"}
+	if err := store.Users().Create(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	u, err := GetUserByID(legacy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.HasAllWarehouses() {
+		t.Error("user without warehouse and without warehouse.all must not see all warehouses")
+	}
+	if products, err := GetAllProducts(u); err != nil || len(products) != 0 {
+		t.Errorf("products for user without warehouse = %+v, %v", products, err)
+	}
+	if _, err := u.ScopeWarehouse(nil); !errors.Is(err, ErrNoWarehouse) {
+		t.Errorf("ScopeWarehouse: err = %v, want ErrNoWarehouse", err)
+	}
+	if _, err := Authenticate("kasirlama", "rahasia", ""); !errors.Is(err, ErrNoWarehouse) {
+		t.Errorf("login without warehouse: err = %v, want ErrNoWarehouse", err)
+	}
+
+	// Mencabut warehouse.all dari role juga mencabut akses semua gudang
+	if err := SetRolePermissions(nil, "auditor", []string{PermProductView}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Authenticate("auditor1", "rahasia", ""); !errors.Is(err, ErrNoWarehouse) {
+		t.Errorf("login after warehouse.all revoked: err = %v, want ErrNoWarehouse", err)
+	}
+}
+
+func TestDeleteAndUnlockUserChecksRole(t *testing.T) {
+	setupTestStore(t)
+	useLoginLimits(t, 1, 20, 15)
+	w := mustWarehouse(t, "Gudang Pusat")
+	if _, err := CreateRole(nil, "hrd", "Kelola user", []string{PermUserManage, PermTransactionCreate, PermProductView}); err != nil {
+		t.Fatal(err)
+	}
+	admin := mustUser(t, "admin", AdminRole, nil)
+	hrd := mustUser(t, "hrd1", "hrd", &w.ID)
+	kasir := mustUser(t, "kasir1", "user", &w.ID)
+
+	// Pemegang user.manage tidak boleh menghapus atau membuka kunci admin
+	if err := DeleteUser(hrd, admin.ID); !errors.Is(err, ErrRoleNotAllowed) {
+		t.Errorf("hrd deleting admin: err = %v, want ErrRoleNotAllowed", err)
+	}
+	if _, err := GetUserByID(admin.ID); err != nil {
+		t.Errorf("admin after refused delete: %v", err)
+	}
+	failLogin(t, "admin", "")
+	if err := UnlockUser(hrd, "admin"); !errors.Is(err, ErrRoleNotAllowed) {
+		t.Errorf("hrd unlocking admin: err = %v, want ErrRoleNotAllowed", err)
+	}
+	if _, err := Authenticate("admin", "rahasia", ""); throttled(err) == nil {
+		t.Errorf("admin should still be locked: err = %v", err)
+	}
+
+	// User dengan permission yang dimiliki hrd tetap bisa diurus
+	failLogin(t, "kasir1", "")
+	if err := UnlockUser(hrd, "kasir1"); err != nil {
+		t.Errorf("hrd unlocking kasir: %v", err)
+	}
+	if err := DeleteUser(hrd, kasir.ID); err != nil {
+		t.Errorf("hrd deleting kasir: %v", err)
+	}
+
+	// Admin terakhir tidak bisa dihapus, admin kedua boleh
+	if err := DeleteUser(nil, admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("deleting last admin: err = %v, want ErrLastAdmin", err)
+	}
+	admin2 := mustUser(t, "admin2", AdminRole, nil)
+	if err := DeleteUser(admin, admin2.ID); err != nil {
+		t.Errorf("deleting second admin: %v", err)
+	}
+}
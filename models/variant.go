@@ -0,0 +1,253 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrDuplicateVariant dikembalikan jika nama varian sudah dipakai varian lain dari produk yang sama
+var ErrDuplicateVariant = errors.New("varian dengan nama tersebut sudah ada di produk ini")
+
+// ErrHasVariants dikembalikan jika produk induk yang punya varian dijual atau diberi stok langsung
+var ErrHasVariants = errors.New("produk punya varian, pilih salah satu varian")
+
+// IsVariant bernilai true jika produk adalah varian dari produk induk
+func (p *Product) IsVariant() bool {
+	return p.ParentID != nil
+}
+
+// DisplayName mengembalikan nama produk beserta variannya, contoh "Kaos Polos (L, Merah)"
+func (p *Product) DisplayName() string {
+	if p.Variant == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.Variant)
+}
+
+// GetVariants mengambil semua varian dari produk induk (data master, stok total semua gudang)
+func GetVariants(parentID int) ([]Product, error) {
+	return store.Products().Variants(parentID)
+}
+
+// GetWarehouseVariants mengambil varian produk induk yang tersedia di satu gudang
+func GetWarehouseVariants(parentID, warehouseID int) ([]Product, error) {
+	variants, err := store.Products().Variants(parentID)
+	if err != nil {
+		return nil, err
+	}
+	var found []Product
+	for _, v := range variants {
+		p, err := GetProductInWarehouse(v.ID, warehouseID)
+		if err == ErrNotInWarehouse {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, *p)
+	}
+	return found, nil
+}
+
+// CreateVariant menambah varian v ke produk induk parentID. Nama, kategori, dan merek mengikuti induk;
+// harga yang kosong (0) diisi dari harga master induk. Varian ditambahkan ke semua gudang tempat induk
+// tersedia dengan stok 0, kecuali gudang v.WarehouseID yang diisi stok v.Stock. Jika v.WarehouseID
+// kosong dan induk hanya ada di satu gudang, gudang itu yang dipakai.
+func CreateVariant(actor *User, parentID int, v Product) (*Product, error) {
+	v.Variant = strings.TrimSpace(v.Variant)
+	v.SKU, v.Barcode = strings.TrimSpace(v.SKU), strings.TrimSpace(v.Barcode)
+	if v.Variant == "" {
+		return nil, errors.New("nama varian tidak boleh kosong")
+	}
+	if v.Stock < 0 {
+		return nil, errors.New("stok tidak boleh negatif")
+	}
+
+	err := store.WithTx(func(s Store) error {
+		parent, err := s.Products().GetByID(parentID)
+		if err != nil {
+			return fmt.Errorf("produk induk dengan ID %d tidak ditemukan", parentID)
+		}
+		if parent.IsVariant() {
+			return errors.New("varian tidak bisa punya varian lagi, pilih produk induknya")
+		}
+		if parent.Stock != 0 {
+			return fmt.Errorf("stok produk induk masih %d; set stok induk ke 0 lalu isi stok di tiap varian", parent.Stock)
+		}
+		if err := checkVariantName(s, parentID, 0, v.Variant); err != nil {
+			return err
+		}
+
+		stocks, err := s.Products().Stocks(parentID)
+		if err != nil {
+			return err
+		}
+		if v.WarehouseID == 0 && len(stocks) == 1 {
+			v.WarehouseID = stocks[0].WarehouseID
+		}
+		if v.WarehouseID == 0 && v.Stock != 0 {
+			return errors.New("pilih gudang untuk stok varian")
+		}
+
+		v.ParentID = &parent.ID
+		v.Name = parent.Name
+		v.CategoryID, v.BrandID = parent.CategoryID, parent.BrandID
+		if v.PurchasePrice == 0 {
+			v.PurchasePrice = parent.PurchasePrice
+		}
+		if v.SellingPrice == 0 {
+			v.SellingPrice = parent.SellingPrice
+		}
+
+		if err := s.Products().Create(&v); err != nil {
+			if err == ErrDuplicate {
+				return ErrDuplicateCode
+			}
+			return err
+		}
+		for _, st := range stocks {
+			if st.WarehouseID != v.WarehouseID {
+				if err := addToWarehouse(s, v.ID, st.WarehouseID, 0); err != nil {
+					return err
+				}
+			}
+		}
+		if v.WarehouseID != 0 {
+			// Induk ikut ditambahkan ke gudang varian agar bisa dicari dan di-scan di sana
+			if err := addToWarehouse(s, v.ID, v.WarehouseID, v.Stock); err != nil {
+				return err
+			}
+			if err := adjustStockLayers(s, v.ID, v.WarehouseID, v.Stock, "stok awal"); err != nil {
+				return err
+			}
+			err := writeMovement(s, actor, StockMovement{
+				ProductID:   v.ID,
+				WarehouseID: v.WarehouseID,
+				Type:        MovementAdjustment,
+				Quantity:    v.Stock,
+				Balance:     v.Stock,
+				Reference:   "stok awal",
+			})
+			if err != nil {
+				return err
+			}
+			if err := addParentToWarehouse(s, parentID, v.WarehouseID, stocks); err != nil {
+				return err
+			}
+		}
+		return writeAudit(s, actor, AuditCreate, EntityProduct, v.ID, nil, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// addParentToWarehouse menambahkan produk induk ke gudang dengan stok 0 jika belum ada di sana
+func addParentToWarehouse(s Store, parentID, warehouseID int, stocks []ProductStock) error {
+	for _, st := range stocks {
+		if st.WarehouseID == warehouseID {
+			return nil
+		}
+	}
+	return addToWarehouse(s, parentID, warehouseID, 0)
+}
+
+// checkVariantName memastikan nama varian belum dipakai varian lain (selain exceptID) dari induk yang sama
+func checkVariantName(s Store, parentID, exceptID int, name string) error {
+	siblings, err := s.Products().Variants(parentID)
+	if err != nil {
+		return err
+	}
+	for _, sibling := range siblings {
+		if sibling.ID != exceptID && strings.EqualFold(sibling.Variant, name) {
+			return ErrDuplicateVariant
+		}
+	}
+	return nil
+}
+
+// syncVariants menyalin nama, kategori, dan merek produk induk ke semua variannya
+func syncVariants(s Store, parent *Product, variants []Product) error {
+	for _, v := range variants {
+		if v.Name == parent.Name && sameID(v.CategoryID, parent.CategoryID) && sameID(v.BrandID, parent.BrandID) {
+			continue
+		}
+		v.Name, v.CategoryID, v.BrandID = parent.Name, parent.CategoryID, parent.BrandID
+		if err := s.Products().Update(&v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProductSales adalah ringkasan penjualan satu produk (atau satu produk induk beserta variannya)
+type ProductSales struct {
+	ProductID int     `json:"product_id"`
+	Product   string  `json:"product"`
+	Quantity  int     `json:"quantity"`
+	Sales     float64 `json:"sales"`
+	Profit    float64 `json:"profit"`
+}
+
+// GetProductSalesByDate mengelompokkan penjualan harian per produk (sesuai gudang user).
+// Jika rollUp bernilai true, penjualan varian digabung ke produk induknya.
+func GetProductSalesByDate(user *User, date time.Time, rollUp bool) ([]ProductSales, error) {
+	return GetWarehouseProductSales(date, reportWarehouse(user), rollUp)
+}
+
+// GetWarehouseProductSales mengelompokkan penjualan harian per produk di satu gudang (nil = semua gudang)
+func GetWarehouseProductSales(date time.Time, warehouseID *int, rollUp bool) ([]ProductSales, error) {
+	transactions, err := GetWarehouseTransactionsByDate(date, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	products, err := store.Products().List(warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	groups := make(map[int]*ProductSales)
+	for _, t := range transactions {
+		for _, item := range t.Items {
+			// Produk yang sudah dihapus tetap dilaporkan dengan nama di transaksi
+			key, name := item.ProductID, item.ProductName
+			if p, ok := byID[item.ProductID]; ok {
+				if rollUp && p.ParentID != nil {
+					key, name = *p.ParentID, p.Name
+				} else {
+					name = p.DisplayName()
+				}
+			}
+
+			g, ok := groups[key]
+			if !ok {
+				g = &ProductSales{ProductID: key, Product: name}
+				groups[key] = g
+			}
+			g.Quantity += item.Quantity
+			g.Sales += item.Subtotal
+			g.Profit += item.Profit
+		}
+	}
+
+	report := make([]ProductSales, 0, len(groups))
+	for _, g := range groups {
+		report = append(report, *g)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Sales != report[j].Sales {
+			return report[i].Sales > report[j].Sales
+		}
+		return report[i].Product < report[j].Product
+	})
+	return report, nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProductVariants(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	kaos := mustProduct(t, "Kaos Polos", 30000, 50000, 0, w.ID)
+
+	l, err := CreateVariant(nil, kaos.ID, Product{Variant: "L", Barcode: "2000000000011", Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "Kaos Polos" || l.WarehouseID != w.ID || l.SellingPrice != 50000 || l.DisplayName() != "Kaos Polos (L)" {
+		t.Errorf("variant should inherit from parent: %+v", l)
+	}
+	xl, err := CreateProduct(nil, Product{ParentID: &kaos.ID, Variant: "XL", SellingPrice: 55000, Stock: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CreateVariant(nil, kaos.ID, Product{Variant: "l"}); err != ErrDuplicateVariant {
+		t.Errorf("duplicate variant name: err = %v, want ErrDuplicateVariant", err)
+	}
+	if _, err := CreateVariant(nil, l.ID, Product{Variant: "Merah"}); err == nil {
+		t.Error("a variant must not have its own variants")
+	}
+	stocked := mustProduct(t, "Teh Botol", 3000, 5000, 10, w.ID)
+	if _, err := CreateVariant(nil, stocked.ID, Product{Variant: "Less Sugar"}); err == nil {
+		t.Error("parent with stock should not accept variants")
+	}
+
+	// Mengganti nama induk ikut mengganti nama varian; stok induk tetap 0
+	kaos.Name = "Kaos Oblong"
+	if err := UpdateProduct(nil, *kaos); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetProductByID(xl.ID); got.Name != "Kaos Oblong" || got.DisplayName() != "Kaos Oblong (XL)" {
+		t.Errorf("variant name not synced: %+v", got)
+	}
+	if err := SetProductStock(nil, ProductStock{ProductID: kaos.ID, WarehouseID: w.ID, Stock: 4}); !errors.Is(err, ErrHasVariants) {
+		t.Errorf("stock on parent: err = %v, want ErrHasVariants", err)
+	}
+	if err := DeleteProduct(nil, kaos.ID); err == nil {
+		t.Error("deleting a parent with variants should fail")
+	}
+
+	found, err := FindProductsByCode("2000000000011", &w.ID)
+	if err != nil || len(found) != 1 || found[0].ID != l.ID {
+		t.Errorf("scan variant barcode = %+v, %v", found, err)
+	}
+	if variants, _ := GetVariants(kaos.ID); len(variants) != 2 {
+		t.Errorf("variants = %d, want 2", len(variants))
+	}
+}
+
+func TestVariantSalesRollUp(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+
+	kaos := mustProduct(t, "Kaos Polos", 30000, 50000, 0, w.ID)
+	l, _ := CreateVariant(nil, kaos.ID, Product{Variant: "L", Stock: 5})
+	xl, _ := CreateVariant(nil, kaos.ID, Product{Variant: "XL", SellingPrice: 55000, Stock: 5})
+	aqua := mustProduct(t, "Aqua 600ml", 2500, 4000, 10, w.ID)
+
+	if _, err := CreateTransaction(cashier, []CartItem{{Product: kaos, Quantity: 1}}, 50000); !errors.Is(err, ErrHasVariants) {
+		t.Errorf("selling a parent product: err = %v, want ErrHasVariants", err)
+	}
+	trx, err := CreateTransaction(cashier, []CartItem{{Product: l, Quantity: 2}, {Product: xl, Quantity: 1}, {Product: aqua, Quantity: 1}}, 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trx.Items[0].ProductName != "Kaos Polos (L)" {
+		t.Errorf("item name = %q", trx.Items[0].ProductName)
+	}
+
+	perVariant, err := GetProductSalesByDate(cashier, time.Now(), false)
+	if err != nil || len(perVariant) != 3 {
+		t.Fatalf("per variant = %+v, %v", perVariant, err)
+	}
+	rolled, err := GetProductSalesByDate(cashier, time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolled) != 2 || rolled[0].ProductID != kaos.ID || rolled[0].Quantity != 3 || rolled[0].Sales != 155000 {
+		t.Errorf("rolled up = %+v", rolled)
+	}
+
+	stock, _ := GetCategoryStock(&w.ID)
+	if len(stock) != 1 || stock[0].Products != 3 || stock[0].Stock != 16 {
+		t.Errorf("parent should not be counted in stock report: %+v", stock)
+	}
+}
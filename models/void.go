@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuthorizeVoid memeriksa username dan password supervisor yang diketik di kasir untuk menyetujui
+// void. Percobaan yang gagal ikut dihitung pembatasan login seperti login biasa.
+func AuthorizeVoid(username, password, clientIP string) (*User, error) {
+	approver, err := Authenticate(strings.TrimSpace(username), password, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if !approver.Can(PermTransactionVoid) {
+		return nil, fmt.Errorf("%s tidak punya izin menyetujui void transaksi", approver.Username)
+	}
+	return approver, nil
+}
+
+// VoidTransaction membatalkan transaksi yang sudah tersimpan. Transaksi tidak dihapus, hanya ditandai
+// void beserta supervisor yang menyetujui dan alasannya; stok semua item kembali ke gudang transaksi
+// dengan harga pokok saat terjual. approver harus sudah diverifikasi lewat AuthorizeVoid. Transaksi
+// yang sudah punya retur tidak bisa di-void.
+func VoidTransaction(actor, approver *User, id int, reason string) (*Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan void wajib diisi")
+	}
+	if !approver.Can(PermTransactionVoid) {
+		return nil, errors.New("void transaksi harus disetujui supervisor")
+	}
+
+	var voided *Transaction
+	err := store.WithTx(func(s Store) error {
+		t, err := s.Transactions().GetByID(id)
+		if err != nil || checkWarehouseAccess(actor, t.WarehouseID) != nil {
+			return fmt.Errorf("transaksi TRX-%06d tidak ditemukan", id)
+		}
+		if err := checkWarehouseAccess(approver, t.WarehouseID); err != nil {
+			return fmt.Errorf("%s tidak punya akses ke gudang transaksi ini", approver.Username)
+		}
+		if t.Voided() {
+			return fmt.Errorf("transaksi TRX-%06d sudah di-void", id)
+		}
+		returns, err := s.Transactions().Returns(id)
+		if err != nil {
+			return err
+		}
+		if len(returns) > 0 {
+			return fmt.Errorf("transaksi TRX-%06d sudah punya retur, tidak bisa di-void", id)
+		}
+
+		before := *t
+		now := time.Now()
+		t.VoidedAt, t.VoidedByID, t.VoidedBy, t.VoidReason = &now, approver.ID, approver.Username, reason
+		if err := s.Transactions().Void(t); err != nil {
+			return err
+		}
+
+		// Barang kembali ke batch asalnya dengan tanggal kedaluwarsa semula
+		sold, err := s.Batches().TransactionBatches(t.ID)
+		if err != nil {
+			return err
+		}
+		restored := map[int]int{}
+
+		reference := fmt.Sprintf("TRX-%06d", t.ID)
+		for _, item := range t.Items {
+			stock, err := receiveStock(s, item.ProductID, t.WarehouseID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			if err := restoreSaleBatches(s, sold, item.ProductID, t.WarehouseID, restored[item.ProductID], item.Quantity, reference); err != nil {
+				return err
+			}
+			restored[item.ProductID] += item.Quantity
+			if err := addCostLayer(s, item.ProductID, t.WarehouseID, item.Quantity, item.PurchasePrice, reference); err != nil {
+				return err
+			}
+			err = writeMovement(s, actor, StockMovement{
+				ProductID:   item.ProductID,
+				WarehouseID: t.WarehouseID,
+				Type:        MovementVoid,
+				Quantity:    item.Quantity,
+				Balance:     stock,
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		voided = t
+		return writeAudit(s, actor, AuditUpdate, EntityTransaction, t.ID, before, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return voided, nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVoidTransactionNeedsSupervisor(t *testing.T) {
+	setupTestStore(t)
+	pusat := mustWarehouse(t, "Gudang Pusat")
+	cabang := mustWarehouse(t, "Gudang Cabang A")
+	if _, err := CreateRole(nil, "supervisor", "Supervisor", []string{PermTransactionCreate, PermTransactionVoid}); err != nil {
+		t.Fatal(err)
+	}
+	cashier := mustUser(t, "kasir1", "user", &pusat.ID)
+	spvCabang := mustUser(t, "spv2", "supervisor", &cabang.ID)
+	mustUser(t, "spv1", "supervisor", &pusat.ID)
+	mie := mustProduct(t, "Indomie Goreng", 2500, 4000, 10, pusat.ID)
+
+	kept := sell(t, cashier, mie.ID, 1)
+	trx := sell(t, cashier, mie.ID, 3)
+
+	if _, err := AuthorizeVoid("kasir1", "rahasia", ""); err == nil || !strings.Contains(err.Error(), "tidak punya izin") {
+		t.Errorf("cashier approving void: err = %v", err)
+	}
+	if _, err := AuthorizeVoid("spv2", "salah", ""); err == nil {
+		t.Error("expected wrong supervisor password to fail")
+	}
+	if _, err := VoidTransaction(cashier, cashier, trx.ID, "salah produk"); err == nil {
+		t.Error("expected void approved by cashier to fail")
+	}
+	if _, err := VoidTransaction(cashier, spvCabang, trx.ID, "salah produk"); err == nil || !strings.Contains(err.Error(), "akses") {
+		t.Errorf("void approved by supervisor of another warehouse: err = %v", err)
+	}
+	spv, err := AuthorizeVoid("spv1", "rahasia", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VoidTransaction(cashier, spv, trx.ID, " "); err == nil {
+		t.Error("expected error voiding without reason")
+	}
+
+	voided, err := VoidTransaction(cashier, spv, trx.ID, "pelanggan batal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !voided.Voided() || voided.VoidedBy != "spv1" || voided.VoidReason != "pelanggan batal" {
+		t.Errorf("voided transaction = %+v", voided)
+	}
+	if stock := stockIn(t, mie.ID, pusat.ID); stock != 9 {
+		t.Errorf("stock after void = %d, want 9", stock)
+	}
+	card, err := GetStockCard(mie.ID, &pusat.ID, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := card.Movements[len(card.Movements)-1]
+	if last.Type != MovementVoid || last.Quantity != 3 || last.Balance != 9 || last.Reference != "TRX-000002" {
+		t.Errorf("void movement = %+v", last)
+	}
+
+	// Transaksi yang di-void tidak dihitung, tapi tetap tersimpan dan tampil terpisah
+	if total, _, count, _ := GetDailyTotal(cashier, time.Now()); total != 4000 || count != 1 {
+		t.Errorf("daily total = %v (%d transaksi), want 4000 (1)", total, count)
+	}
+	if active, _ := GetTransactionsByDate(cashier, time.Now()); len(active) != 1 || active[0].ID != kept.ID {
+		t.Errorf("active transactions = %+v", active)
+	}
+	list, err := GetVoidedTransactionsByDate(nil, time.Now())
+	if err != nil || len(list) != 1 || list[0].ID != trx.ID || list[0].VoidedBy != "spv1" {
+		t.Errorf("voided transactions = %+v, %v", list, err)
+	}
+
+	if _, err := VoidTransaction(cashier, spv, trx.ID, "lagi"); err == nil || !strings.Contains(err.Error(), "sudah di-void") {
+		t.Errorf("second void: err = %v", err)
+	}
+	if _, err := CreateReturn(cashier, trx.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 1}}, "rusak"); err == nil {
+		t.Error("expected error returning a voided transaction")
+	}
+
+	// Transaksi yang sudah diretur tidak bisa di-void
+	if _, err := CreateReturn(cashier, kept.ID, []ReturnItem{{ProductID: mie.ID, Quantity: 1}}, "rusak"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VoidTransaction(cashier, spv, kept.ID, "salah produk"); err == nil || !strings.Contains(err.Error(), "retur") {
+		t.Errorf("void after return: err = %v", err)
+	}
+}
+
+func TestVoidRestoresSoldBatches(t *testing.T) {
+	setupTestStore(t)
+	w := mustWarehouse(t, "Gudang Pusat")
+	cashier := mustUser(t, "kasir1", "user", &w.ID)
+	spv := mustUser(t, "spv1", AdminRole, nil)
+	roti := mustProduct(t, "Roti Tawar", 10000, 15000, 2, w.ID)
+	susu := mustProduct(t, "Susu UHT", 5000, 7000, 0, w.ID)
+	a := mustBatch(t, roti.ID, w.ID, "R-01", 5, 3)
+	mustBatch(t, susu.ID, w.ID, "S-01", 10, 4)
+
+	// Roti: 3 dari R-01 dan 1 tanpa batch; susu 2 dari S-01
+	p1, _ := GetProductInWarehouse(roti.ID, w.ID)
+	p2, _ := GetProductInWarehouse(susu.ID, w.ID)
+	trx, err := CreateTransaction(cashier, []CartItem{{Product: p1, Quantity: 4}, {Product: p2, Quantity: 2}}, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VoidTransaction(cashier, spv, trx.ID, "salah input"); err != nil {
+		t.Fatal(err)
+	}
+
+	batches, err := GetBatches(roti.ID, w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || batches[0].BatchNo != "R-01" || batches[0].Remaining != 3 ||
+		!batches[0].ExpiryDate.Equal(a.ExpiryDate) || batches[0].Reference != "TRX-000001" {
+		t.Errorf("roti batches after void = %+v", batches)
+	}
+	remaining := 0
+	batches, _ = GetBatches(susu.ID, w.ID)
+	for _, b := range batches {
+		if b.BatchNo != "S-01" {
+			t.Errorf("unexpected susu batch %+v", b)
+		}
+		remaining += b.Remaining
+	}
+	if remaining != 4 {
+		t.Errorf("susu batch remaining after void = %d, want 4", remaining)
+	}
+	if stock := stockIn(t, roti.ID, w.ID); stock != 5 {
+		t.Errorf("roti stock after void = %d, want 5", stock)
+	}
+}
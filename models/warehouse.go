@@ -2,7 +2,6 @@ package models
 
 import (
 	"errors"
-	"kasir/config"
 	"time"
 )
 
@@ -16,52 +15,24 @@ type Warehouse struct {
 
 // GetAllWarehouses mengambil semua gudang
 func GetAllWarehouses() ([]Warehouse, error) {
-	rows, err := config.DB.Query(`
-		SELECT id, name, COALESCE(address, ''), created_at 
-		FROM warehouses 
-		ORDER BY id
-	`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var warehouses []Warehouse
-	for rows.Next() {
-		var w Warehouse
-		err := rows.Scan(&w.ID, &w.Name, &w.Address, &w.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		warehouses = append(warehouses, w)
-	}
-	return warehouses, nil
+	return store.Warehouses().List()
 }
 
 // GetWarehouseByID mengambil gudang berdasarkan ID
 func GetWarehouseByID(id int) (*Warehouse, error) {
-	var w Warehouse
-	err := config.DB.QueryRow(`
-		SELECT id, name, COALESCE(address, ''), created_at 
-		FROM warehouses 
-		WHERE id = $1
-	`, id).Scan(&w.ID, &w.Name, &w.Address, &w.CreatedAt)
-
-	if err != nil {
-		return nil, err
-	}
-	return &w, nil
+	return store.Warehouses().GetByID(id)
 }
 
 // CreateWarehouse membuat gudang baru
-func CreateWarehouse(name, address string) (*Warehouse, error) {
-	var w Warehouse
-	err := config.DB.QueryRow(`
-		INSERT INTO warehouses (name, address) 
-		VALUES ($1, $2) 
-		RETURNING id, name, COALESCE(address, ''), created_at
-	`, name, address).Scan(&w.ID, &w.Name, &w.Address, &w.CreatedAt)
+func CreateWarehouse(actor *User, name, address string) (*Warehouse, error) {
+	w := Warehouse{Name: name, Address: address}
 
+	err := store.WithTx(func(s Store) error {
+		if err := s.Warehouses().Create(&w); err != nil {
+			return err
+		}
+		return writeAudit(s, actor, AuditCreate, EntityWarehouse, w.ID, nil, w)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -69,34 +40,40 @@ func CreateWarehouse(name, address string) (*Warehouse, error) {
 }
 
 // UpdateWarehouse mengupdate gudang
-func UpdateWarehouse(id int, name, address string) error {
-	result, err := config.DB.Exec(`
-		UPDATE warehouses 
-		SET name = $1, address = $2 
-		WHERE id = $3
-	`, name, address, id)
-
+func UpdateWarehouse(actor *User, id int, name, address string) error {
+	before, err := GetWarehouseByID(id)
 	if err != nil {
-		return err
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
 		return errors.New("gudang tidak ditemukan")
 	}
-	return nil
+
+	after := *before
+	after.Name, after.Address = name, address
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Warehouses().Update(&after); err != nil {
+			if err == ErrNotFound {
+				return errors.New("gudang tidak ditemukan")
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditUpdate, EntityWarehouse, id, before, after)
+	})
 }
 
 // DeleteWarehouse menghapus gudang
-func DeleteWarehouse(id int) error {
-	result, err := config.DB.Exec(`DELETE FROM warehouses WHERE id = $1`, id)
+func DeleteWarehouse(actor *User, id int) error {
+	before, err := GetWarehouseByID(id)
 	if err != nil {
-		return err
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
 		return errors.New("gudang tidak ditemukan")
 	}
-	return nil
+
+	return store.WithTx(func(s Store) error {
+		if err := s.Warehouses().Delete(id); err != nil {
+			if err == ErrNotFound {
+				return errors.New("gudang tidak ditemukan")
+			}
+			return err
+		}
+		return writeAudit(s, actor, AuditDelete, EntityWarehouse, id, before, nil)
+	})
 }